@@ -41,6 +41,9 @@ type (
 
 	// RegistryInfo
 	RegistryInfo = internal.RegistryActivityInfo
+
+	// CancellationDetails describes why an activity was canceled.
+	CancellationDetails = internal.CancellationDetails
 )
 
 // ErrResultPending is returned from activity's implementation to indicate the activity is not completed when
@@ -102,6 +105,20 @@ func GetInfo(ctx context.Context) Info {
 	return internal.GetActivityInfo(ctx)
 }
 
+// GetHeaders returns a copy of the headers the workflow of the activity was started with, see
+// client.StartWorkflowOptions.Headers.
+func GetHeaders(ctx context.Context) map[string]string {
+	return internal.GetActivityHeaders(ctx)
+}
+
+// GetCancellationDetails returns why the activity was canceled, or nil if it was not canceled by a heartbeat
+// response. Its Source is workflow.CancellationSourceWorkflow when the workflow requested the cancellation, and
+// workflow.CancellationSourceWorkflowClosed when the workflow is already closed. A timed out activity has its context
+// done with context.DeadlineExceeded instead.
+func GetCancellationDetails(ctx context.Context) *CancellationDetails {
+	return internal.GetActivityCancellationDetails(ctx)
+}
+
 // GetLogger returns a logger that can be used in activity
 func GetLogger(ctx context.Context) *zap.Logger {
 	return internal.GetActivityLogger(ctx)
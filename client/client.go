@@ -21,6 +21,7 @@
 // when adding any, make sure you update the files that it checks in the makefile
 //go:generate mockery --srcpkg . --name Client --output ../mocks --boilerplate-file ../LICENSE
 //go:generate mockery --srcpkg . --name DomainClient --output ../mocks --boilerplate-file ../LICENSE
+//go:generate mockery --srcpkg . --name ScheduleClient --output ../mocks --boilerplate-file ../LICENSE
 
 // Package client contains functions to create Cadence clients used to communicate to Cadence service.
 //
@@ -51,6 +52,33 @@ const (
 	// QueryTypeQueryTypes is the build in query type for Client.QueryWorkflow() call. Use this query type to list
 	// all query types of the workflow. The result will be a string encoded in the EncodedValue.
 	QueryTypeQueryTypes string = internal.QueryTypeQueryTypes
+
+	// QueryTypeWorkflowState is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the key/value state set by workflow.SetState. The result will be a map of key to value encoded in the
+	// encoded.Value. It is only available once the workflow has called workflow.SetState.
+	QueryTypeWorkflowState string = internal.QueryTypeWorkflowState
+
+	// QueryTypeUnhandledSignals is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the signals which were sent to the workflow but not received yet. The result will be a map of signal name to the
+	// number of pending signals encoded in the encoded.Value.
+	QueryTypeUnhandledSignals string = internal.QueryTypeUnhandledSignals
+
+	// QueryTypeVersions is the build in query type for Client.QueryWorkflow() call. Use this query type to get the
+	// versions the workflow chose with workflow.GetVersion. The result will be a map of change ID to workflow.Version
+	// encoded in the encoded.Value. GetVersionReport aggregates it across workflow executions.
+	QueryTypeVersions string = internal.QueryTypeVersions
+
+	// WorkflowConfigSignalName is the signal sent by UpdateWorkflowConfig to change the workflow.WorkflowConfig of a
+	// running workflow.
+	WorkflowConfigSignalName = internal.WorkflowConfigSignalName
+
+	// QueryTypeExecutionProfile is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the ExecutionProfile of the workflow. It is only available on workers with worker.Options.EnableExecutionProfile.
+	QueryTypeExecutionProfile string = internal.QueryTypeExecutionProfile
+
+	// QueryTypeYieldDiagnostics is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the YieldDiagnostics of the workflow. It is only available on workers with worker.Options.EnableYieldDiagnostics.
+	QueryTypeYieldDiagnostics string = internal.QueryTypeYieldDiagnostics
 )
 
 type (
@@ -63,6 +91,12 @@ type (
 	// StartWorkflowOptions configuration parameters for starting a workflow execution.
 	StartWorkflowOptions = internal.StartWorkflowOptions
 
+	// OptionsValidationError is returned by StartWorkflowOptions.Validate and lists every invalid field.
+	OptionsValidationError = internal.OptionsValidationError
+
+	// OptionsProblem describes a single invalid field of StartWorkflowOptions.
+	OptionsProblem = internal.OptionsProblem
+
 	// HistoryEventIterator is a iterator which can return history events
 	HistoryEventIterator = internal.HistoryEventIterator
 
@@ -86,6 +120,107 @@ type (
 	//  - WithCancelReason(...)
 	CancelOption = internal.Option
 
+	// WatchWorkflowOptions configure Client.WatchWorkflow.
+	WatchWorkflowOptions = internal.WatchWorkflowOptions
+
+	// WorkflowEvent is a lifecycle event of a workflow execution delivered by Client.WatchWorkflow.
+	WorkflowEvent = internal.WorkflowEvent
+
+	// WorkflowEventType is the kind of a WorkflowEvent.
+	WorkflowEventType = internal.WorkflowEventType
+
+	// WorkerRegistry stores the registrations workers publish, see worker.Options.WorkerRegistry.
+	WorkerRegistry = internal.WorkerRegistry
+
+	// WorkerRegistration describes a running worker published to a WorkerRegistry.
+	WorkerRegistration = internal.WorkerRegistration
+
+	// WorkflowCompletion is the outcome of a closed workflow execution, delivered by Client.OnWorkflowCompletion.
+	WorkflowCompletion = internal.WorkflowCompletion
+
+	// WorkflowCompletionCallback is called with the outcome of a workflow execution awaited with
+	// Client.OnWorkflowCompletion.
+	WorkflowCompletionCallback = internal.WorkflowCompletionCallback
+
+	// StopWorkflowOptions configure Client.StopWorkflow.
+	StopWorkflowOptions = internal.StopWorkflowOptions
+
+	// StopWorkflowMode is how Client.StopWorkflow stops a workflow execution.
+	StopWorkflowMode = internal.StopWorkflowMode
+
+	// StopWorkflowReason is the data StopWorkflowOptions.Reason is rendered with.
+	StopWorkflowReason = internal.StopWorkflowReason
+
+	// ExecutionProfile is the cost profile of a workflow execution, returned by the QueryTypeExecutionProfile query.
+	ExecutionProfile = internal.ExecutionProfile
+
+	// ActivityProfile accumulates the activities of one activity type within an ExecutionProfile.
+	ActivityProfile = internal.ActivityProfile
+
+	// YieldDiagnostics tells why the coroutines of a workflow blocked during its last decision task, returned by the
+	// QueryTypeYieldDiagnostics query.
+	YieldDiagnostics = internal.YieldDiagnostics
+
+	// CoroutineYields counts the yields of one coroutine of a workflow within YieldDiagnostics.
+	CoroutineYields = internal.CoroutineYields
+
+	// VersionReport aggregates the versions chosen with workflow.GetVersion by open workflow executions, see
+	// GetVersionReport.
+	VersionReport = internal.VersionReport
+
+	// ConnectionOptions configure the connection opened by DialService to the Cadence frontend, see
+	// Options.Connection.
+	ConnectionOptions = internal.ConnectionOptions
+
+	// ServiceConnection is a connection to the Cadence frontend opened by DialService.
+	ServiceConnection = internal.ServiceConnection
+
+	// ClusterInfo describes the Cadence cluster a client is connected to, see Client.GetClusterInfo.
+	ClusterInfo = internal.ClusterInfo
+
+	// ServiceCapability is an optional feature of the Cadence service reported in ClusterInfo.Capabilities.
+	ServiceCapability = internal.ServiceCapability
+
+	// NamingPolicy constrains a name, e.g. a workflow ID or a signal name, with a pattern and a maximum length.
+	NamingPolicy = internal.NamingPolicy
+
+	// NamingPolicies constrain the workflow IDs, signal names and query types used by a client, see
+	// Options.NamingPolicies.
+	NamingPolicies = internal.NamingPolicies
+
+	// NamingPolicyError is returned when a name does not follow a NamingPolicy.
+	NamingPolicyError = internal.NamingPolicyError
+
+	// ScheduleOptions configure a schedule created by ScheduleClient.CreateSchedule.
+	ScheduleOptions = internal.ScheduleOptions
+
+	// Schedule is a schedule returned by ScheduleClient.ListSchedules.
+	Schedule = internal.Schedule
+
+	// EntityClientOptions configure an EntityClient.
+	EntityClientOptions = internal.EntityClientOptions
+
+	// EntityClient sends signals to entities, i.e. long-running singleton workflows per key, which are started by
+	// the first signal sent to their key. The workflow of an entity is run with workflow.RunEntity.
+	EntityClient = internal.EntityClient
+
+	// EntityHeartbeat is the liveness of the workflow of an entity, returned by EntityClient.Heartbeat.
+	EntityHeartbeat = internal.EntityHeartbeat
+
+	// WorkflowRequestClientOptions configure a WorkflowRequestClient.
+	WorkflowRequestClientOptions = internal.WorkflowRequestClientOptions
+
+	// WorkflowRequestClient sends requests to workflows, and waits for the responses the workflows send back with
+	// workflow.Respond.
+	WorkflowRequestClient = internal.WorkflowRequestClient
+
+	// WorkflowRequestError is returned by WorkflowRequestClient.Request when the workflow responded with an error.
+	WorkflowRequestError = internal.WorkflowRequestError
+
+	// DecisionTaskFailure describes the failure of the pending decision task of a workflow execution, see
+	// Client.GetDecisionTaskFailure.
+	DecisionTaskFailure = internal.DecisionTaskFailure
+
 	// Client is the client for starting and getting information about a workflow executions as well as
 	// completing activities asynchronously.
 	Client interface {
@@ -111,6 +246,20 @@ type (
 		//	- InternalServiceError
 		StartWorkflowAsync(ctx context.Context, options StartWorkflowOptions, workflow interface{}, args ...interface{}) (*workflow.ExecutionAsync, error)
 
+		// StartWorkflowIfNotRunning behaves like StartWorkflow, except that when a run with the same workflow ID is
+		// already running, the execution of that run is returned instead of a WorkflowExecutionAlreadyStartedError.
+		// The run of the error is described to verify that it is still running.
+		// This is meant for at-least-once producers, which may attempt the same start more than once.
+		// Set StartWorkflowOptions.RequestID to a stable value to also deduplicate starts of runs which have
+		// already completed.
+		// The errors it can return:
+		//	- EntityNotExistsError, if domain does not exists
+		//	- BadRequestError
+		//	- WorkflowExecutionAlreadyStartedError, if the workflow ID is reused against the WorkflowIDReusePolicy
+		//	  of a closed run
+		//	- InternalServiceError
+		StartWorkflowIfNotRunning(ctx context.Context, options StartWorkflowOptions, workflow interface{}, args ...interface{}) (*workflow.Execution, error)
+
 		// ExecuteWorkflow starts a workflow execution and return a WorkflowRun instance and error
 		// The user can use this to start using a function or workflow type name.
 		// Either by
@@ -206,6 +355,22 @@ type (
 		//	- WorkflowExecutionAlreadyCompletedError
 		TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details []byte) error
 
+		// StopWorkflow stops a workflow execution following the usual operational runbook: by default it requests
+		// cancellation, waits up to options.TerminateAfter for the workflow to honor it, and terminates it otherwise.
+		// options.Mode can also only cancel or only terminate the workflow. options.Reason is a text/template
+		// rendered with the workflow identity and options.Operator, which is also sent in the "cadence-operator"
+		// header of the requests. A workflow which already closed is stopped successfully when requesting its
+		// cancellation, and a workflow whose cancellation was already requested is still terminated when it does
+		// not close in time.
+		// - workflow ID of the workflow.
+		// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
+		// The errors it can return:
+		//	- EntityNotExistsError
+		//	- BadRequestError
+		//	- InternalServiceError
+		//	- WorkflowExecutionAlreadyCompletedError, with StopWorkflowModeTerminate
+		StopWorkflow(ctx context.Context, workflowID string, runID string, options StopWorkflowOptions) error
+
 		// GetWorkflowHistory gets history events of a particular workflow
 		// - workflow ID of the workflow.
 		// - runID can be default(empty string). if empty string then it will pick the last running execution of that workflow ID.
@@ -226,6 +391,48 @@ type (
 		//		}
 		GetWorkflowHistory(ctx context.Context, workflowID string, runID string, isLongPoll bool, filterType s.HistoryEventFilterType) HistoryEventIterator
 
+		// WatchWorkflow returns a channel of the lifecycle events of a workflow execution: started, decision completed,
+		// activity scheduled/completed, signaled and closed. The events are read with history long polls, so the
+		// workflow can be followed without polling DescribeWorkflowExecution.
+		// - workflow ID of the workflow.
+		// - options.RunID can be default(empty string). if empty string then it will pick the current run of that workflow ID.
+		// - options.ResumeToken continues a previous watch after the event which carried the token.
+		// The channel is closed after the WorkflowEventClosed event, when ctx is done, or after an event with Err set.
+		// Example:-
+		//	events, err := WatchWorkflow(ctx, workflowID, WatchWorkflowOptions{})
+		//	if err != nil {
+		//		return err
+		//	}
+		//	for event := range events {
+		//		if event.Err != nil {
+		//			return event.Err
+		//		}
+		//		// handle event, and save event.ResumeToken to continue from it later
+		//	}
+		// The errors it can return:
+		//  - EntityNotExistsError
+		//  - BadRequestError
+		//  - InternalServiceError
+		WatchWorkflow(ctx context.Context, workflowID string, options WatchWorkflowOptions) (<-chan WorkflowEvent, error)
+
+		// OnWorkflowCompletion calls callback with the outcome of the workflow execution once it is closed, following
+		// the runs it continues as new to. The execution is awaited with history long polls shared by all the
+		// executions awaited through the client, see Options.MaxConcurrentCompletionPolls, so services awaiting many
+		// workflows do not need a goroutine blocked on WorkflowRun.Get for each of them.
+		// - workflow ID of the workflow.
+		// - runID can be default(empty string). if empty string then it will pick the current run of that workflow ID.
+		// The completion is delivered at least once: polls failing with transient errors are retried, and the
+		// completion is delivered again when callback returns an error. Awaiting stops when ctx is done.
+		// Example:-
+		//	err := OnWorkflowCompletion(ctx, workflowID, "", func(completion WorkflowCompletion) error {
+		//		var result string
+		//		if err := completion.Get(&result); err != nil {
+		//			return markFailed(completion.WorkflowID, err)
+		//		}
+		//		return saveResult(completion.WorkflowID, result)
+		//	})
+		OnWorkflowCompletion(ctx context.Context, workflowID string, runID string, callback WorkflowCompletionCallback) error
+
 		// CompleteActivity reports activity completed.
 		// activity Execute method can return activity.ErrResultPending to
 		// indicate the activity is not completed when it's Execute method returns. In that case, this CompleteActivity() method
@@ -383,6 +590,32 @@ type (
 		//  - EntityNotExistError
 		DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*s.DescribeWorkflowExecutionResponse, error)
 
+		// ListPendingActivities returns the activities of a workflow execution which are scheduled or running, as
+		// reported by DescribeWorkflowExecution, e.g. to find activities stuck on a third party call.
+		// Cadence cannot cancel a single activity from outside of the workflow; see CancelActivitySignalName for a
+		// recipe to let operators cancel them.
+		// - workflow ID of the workflow.
+		// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
+		// The errors it can return:
+		//  - BadRequestError
+		//  - InternalServiceError
+		//  - EntityNotExistError
+		ListPendingActivities(ctx context.Context, workflowID, runID string) ([]*s.PendingActivityInfo, error)
+
+		// GetDecisionTaskFailure returns the failure of the pending decision task of a workflow execution while it
+		// keeps failing, with the decision and the workflow code which caused it when they are known, or nil if the
+		// pending decision task, if any, is on its first attempt. The server records only the first failed attempt
+		// of a decision task in the history, so the failure describes that attempt, with the attempt of the pending
+		// decision task reported by DescribeWorkflowExecution. It is a shortcut to diagnose a workflow stuck on a
+		// panic, a nondeterministic change or a decision rejected by the server.
+		// - workflow ID of the workflow.
+		// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
+		// The errors it can return:
+		//  - BadRequestError
+		//  - InternalServiceError
+		//  - EntityNotExistError
+		GetDecisionTaskFailure(ctx context.Context, workflowID, runID string) (*DecisionTaskFailure, error)
+
 		// DescribeTaskList returns information about the target tasklist, right now this API returns the
 		// pollers which polled this tasklist in last few minutes.
 		// The errors it can return:
@@ -400,6 +633,15 @@ type (
 		//  - ServiceBusyError
 		//  - EntityNotExistError
 		RefreshWorkflowTasks(ctx context.Context, workflowID, runID string) error
+
+		// GetClusterInfo returns the client versions supported by the Cadence cluster and the optional features it
+		// provides to the client's domain, so callers can adapt to the cluster instead of failing at runtime,
+		// e.g. fall back from ListWorkflow to ListOpenWorkflow without advanced visibility.
+		// Capabilities are discovered by probing the service, so the call makes one request per known capability.
+		// The errors it can return:
+		//  - InternalServiceError
+		//  - ServiceBusyError
+		GetClusterInfo(ctx context.Context) (*ClusterInfo, error)
 	}
 
 	// DomainClient is the client for managing operations on the domain.
@@ -429,6 +671,40 @@ type (
 		//	- InternalServiceError
 		Update(ctx context.Context, request *s.UpdateDomainRequest) error
 	}
+
+	// ScheduleClient manages schedules, i.e. workflows started periodically on a cron schedule. Cadence has no native
+	// schedules, so a schedule is a cron workflow whose memo holds the schedule under ScheduleMemoKey, and its ID is
+	// the workflow ID of the cron workflow. Use Client.TerminateWorkflow to delete a running schedule.
+	ScheduleClient interface {
+		// CreateSchedule starts the cron workflow of a new schedule.
+		// The errors it can return:
+		//  - BadRequestError
+		//  - WorkflowExecutionAlreadyStartedError
+		//  - InternalServiceError
+		CreateSchedule(ctx context.Context, options ScheduleOptions, workflow interface{}, args ...interface{}) (*workflow.Execution, error)
+
+		// ListSchedules returns the running and the paused schedules of the domain. It lists the open and the
+		// terminated workflows with visibility APIs, so it is meant for management tools rather than frequent calls.
+		// Paused schedules are only listed until their cron workflow exceeds the retention period of the domain.
+		ListSchedules(ctx context.Context) ([]*Schedule, error)
+
+		// PauseSchedule stops starting the workflow of a schedule by terminating its cron workflow with
+		// SchedulePausedReason. The note is recorded as termination details and returned by ListSchedules.
+		// The errors it can return:
+		//  - ErrScheduleNotFound
+		//  - EntityNotExistsError
+		//  - InternalServiceError
+		PauseSchedule(ctx context.Context, scheduleID string, note string) error
+
+		// ResumeSchedule restarts the cron workflow of a paused schedule with the workflow type, input and options of
+		// its last run.
+		// The errors it can return:
+		//  - ErrScheduleNotFound
+		//  - ErrScheduleNotPaused
+		//  - EntityNotExistsError
+		//  - InternalServiceError
+		ResumeSchedule(ctx context.Context, scheduleID string) error
+	}
 )
 
 const (
@@ -458,21 +734,179 @@ const (
 	ParentClosePolicyAbandon = internal.ParentClosePolicyAbandon
 )
 
+const (
+	// WorkflowEventStarted is sent when the workflow execution is started.
+	WorkflowEventStarted = internal.WorkflowEventStarted
+	// WorkflowEventDecisionCompleted is sent when a decision task of the workflow is completed.
+	WorkflowEventDecisionCompleted = internal.WorkflowEventDecisionCompleted
+	// WorkflowEventActivityScheduled is sent when an activity is scheduled.
+	WorkflowEventActivityScheduled = internal.WorkflowEventActivityScheduled
+	// WorkflowEventActivityCompleted is sent when an activity has completed, failed, timed out or was canceled.
+	WorkflowEventActivityCompleted = internal.WorkflowEventActivityCompleted
+	// WorkflowEventSignaled is sent when the workflow execution receives a signal.
+	WorkflowEventSignaled = internal.WorkflowEventSignaled
+	// WorkflowEventClosed is sent when the workflow execution is closed.
+	WorkflowEventClosed = internal.WorkflowEventClosed
+)
+
+const (
+	// StopWorkflowModeCancelThenTerminate requests cancellation of the workflow, and terminates it if it did not
+	// close within StopWorkflowOptions.TerminateAfter.
+	StopWorkflowModeCancelThenTerminate = internal.StopWorkflowModeCancelThenTerminate
+	// StopWorkflowModeCancel only requests cancellation of the workflow.
+	StopWorkflowModeCancel = internal.StopWorkflowModeCancel
+	// StopWorkflowModeTerminate terminates the workflow right away.
+	StopWorkflowModeTerminate = internal.StopWorkflowModeTerminate
+)
+
+const (
+	// ServiceCapabilityAdvancedVisibility is advanced visibility, which is used by ListWorkflow, ScanWorkflow,
+	// CountWorkflow and search attributes.
+	ServiceCapabilityAdvancedVisibility = internal.ServiceCapabilityAdvancedVisibility
+	// ServiceCapabilityAsyncAPIs are the asynchronous StartWorkflowAsync and SignalWithStartWorkflowAsync APIs.
+	ServiceCapabilityAsyncAPIs = internal.ServiceCapabilityAsyncAPIs
+)
+
+// CancelActivitySignalName is the conventional name of the signal asking a workflow to cancel one of its pending
+// activities. The signal input is the activity ID.
+//
+// Cadence has no API to cancel a single activity from outside of the workflow, so the workflow has to cooperate.
+// Operators look up the stuck activity and signal the workflow:
+//
+//	activities, err := c.ListPendingActivities(ctx, workflowID, "")
+//	// pick the stuck activity, e.g. by type, attempt or last heartbeat time
+//	err = c.SignalWorkflow(ctx, workflowID, "", client.CancelActivitySignalName, activities[0].GetActivityID())
+//
+// and the workflow runs the activity with an explicit ID and a cancellable context, which it cancels when it
+// receives the signal:
+//
+//	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{ActivityID: "charge", ...})
+//	activityCtx, cancel := workflow.WithCancel(ctx)
+//	future := workflow.ExecuteActivity(activityCtx, ChargeActivity, order)
+//	selector := workflow.NewSelector(ctx)
+//	selector.AddFuture(future, func(f workflow.Future) {})
+//	selector.AddReceive(workflow.GetSignalChannel(ctx, workflow.CancelActivitySignalName), func(c workflow.Channel, more bool) {
+//		var activityID string
+//		c.Receive(ctx, &activityID)
+//		if activityID == "charge" {
+//			cancel()
+//		}
+//	})
+//	selector.Select(ctx)
+//	err := future.Get(ctx, nil) // CanceledError if the activity was cancelled
+//
+// Activities observe the cancellation through their context once they heartbeat.
+const CancelActivitySignalName = internal.CancelActivitySignalName
+
+const (
+	// ScheduleMemoKey is the memo key marking the cron workflows started by ScheduleClient.CreateSchedule.
+	ScheduleMemoKey = internal.ScheduleMemoKey
+
+	// SchedulePausedReason is the reason ScheduleClient.PauseSchedule terminates the cron workflow of a schedule with.
+	SchedulePausedReason = internal.SchedulePausedReason
+)
+
+var (
+	// ErrScheduleNotFound is returned by ScheduleClient when the workflow with the schedule ID was not started by
+	// CreateSchedule.
+	ErrScheduleNotFound = internal.ErrScheduleNotFound
+
+	// ErrScheduleNotPaused is returned by ScheduleClient.ResumeSchedule when the schedule is not paused.
+	ErrScheduleNotPaused = internal.ErrScheduleNotPaused
+)
+
 // NewClient creates an instance of a workflow client
 func NewClient(service workflowserviceclient.Interface, domain string, options *Options) Client {
 	return internal.NewClient(service, domain, options)
 }
 
+// DialService opens a gRPC connection to the Cadence frontend at hostPort, configured by options.Connection, without
+// building a YARPC dispatcher by hand. The connection can be shared by clients and workers, and must be closed once
+// they are stopped:
+//
+//	options := &client.Options{
+//		Connection: client.ConnectionOptions{
+//			ProxyURL: &url.URL{Scheme: "socks5", Host: "gateway.internal:1080"},
+//		},
+//	}
+//	service, err := client.DialService("cadence-frontend:7833", options)
+//	if err != nil {
+//		return err
+//	}
+//	defer service.Close()
+//	c := client.NewClient(service, "my-domain", options)
+//	w := worker.New(service, "my-domain", "my-task-list", worker.Options{})
+func DialService(hostPort string, options *Options) (*ServiceConnection, error) {
+	return internal.DialService(hostPort, options)
+}
+
 // NewDomainClient creates an instance of a domain client, to manage lifecycle of domains.
 func NewDomainClient(service workflowserviceclient.Interface, options *Options) DomainClient {
 	return internal.NewDomainClient(service, options)
 }
 
+// NewScheduleClient creates an instance of a schedule client, to manage the schedules of a domain.
+func NewScheduleClient(service workflowserviceclient.Interface, domain string, options *Options) ScheduleClient {
+	return internal.NewScheduleClient(service, domain, options)
+}
+
+// NewEntityClient returns a client for the entities of the given options, which sends the signals with c:
+//
+//	carts, err := client.NewEntityClient(c, client.EntityClientOptions{
+//		WorkflowIDPrefix: "cart:",
+//		Workflow:         CartWorkflow,
+//		WorkflowOptions: client.StartWorkflowOptions{
+//			TaskList:                     "carts",
+//			ExecutionStartToCloseTimeout: 365 * 24 * time.Hour,
+//		},
+//	})
+//	...
+//	_, err = carts.Signal(ctx, userID, "add-item", item)
+func NewEntityClient(c Client, options EntityClientOptions) (*EntityClient, error) {
+	return internal.NewEntityClient(c, options)
+}
+
+// ErrWorkflowRequestTimeout is returned by WorkflowRequestClient.Request when the workflow did not respond within
+// WorkflowRequestClientOptions.Timeout.
+var ErrWorkflowRequestTimeout = internal.ErrWorkflowRequestTimeout
+
+// NewWorkflowRequestClient returns a client sending requests to workflows with c, which respond with workflow.Respond.
+// Workflows can only signal other workflows, so every request starts a short-lived listener workflow, which the
+// workers on options.TaskList must register:
+//
+//	w.RegisterWorkflowWithOptions(workflow.ResponseListener, workflow.RegisterOptions{Name: workflow.ResponseListenerName})
+//
+// The listener completes with the response, which the request waits for:
+//
+//	requests, err := client.NewWorkflowRequestClient(c, client.WorkflowRequestClientOptions{TaskList: "listeners"})
+//	...
+//	var price int
+//	err = requests.Request(ctx, "pricing", "", "quote", item, &price)
+func NewWorkflowRequestClient(c Client, options WorkflowRequestClientOptions) (*WorkflowRequestClient, error) {
+	return internal.NewWorkflowRequestClient(c, options)
+}
+
+// GetVersionReport lists the open workflow executions matching the visibility query and queries their versions with
+// QueryTypeVersions, to find out which branches of workflow.GetVersion calls are still in use:
+//
+//	report, err := client.GetVersionReport(ctx, c, "WorkflowType = 'OrderWorkflow' AND CloseTime = missing")
+//	if err == nil && len(report.Failed) == 0 && !report.InUse("add-fraud-check", workflow.DefaultVersion) {
+//		// the code path of workflow.DefaultVersion can be deleted
+//	}
+//
+// Closed executions are skipped. Every open execution is queried, which replays its history on a worker unless it is
+// cached, so use a selective query for workflow types with many open executions. It requires advanced visibility.
+func GetVersionReport(ctx context.Context, c Client, query string) (*VersionReport, error) {
+	return internal.GetVersionReport(ctx, c, query)
+}
+
 // make sure if new methods are added to internal.Client they are also added to public Client.
 var _ Client = internal.Client(nil)
 var _ internal.Client = Client(nil)
 var _ DomainClient = internal.DomainClient(nil)
 var _ internal.DomainClient = DomainClient(nil)
+var _ ScheduleClient = internal.ScheduleClient(nil)
+var _ internal.ScheduleClient = ScheduleClient(nil)
 
 // NewValue creates a new encoded.Value which can be used to decode binary data returned by Cadence.  For example:
 // User had Activity.RecordHeartbeat(ctx, "my-heartbeat") and then got response from calling Client.DescribeWorkflowExecution.
@@ -554,3 +988,33 @@ func IsWorkflowError(err error) bool {
 func WithCancelReason(reason string) CancelOption {
 	return internal.WithCancelReason(reason)
 }
+
+// WorkflowConfigMemo returns the memo holding the initial values of the workflow.WorkflowConfig of a workflow, to be
+// merged into StartWorkflowOptions.Memo:
+//
+//	options.Memo = client.WorkflowConfigMemo(map[string]interface{}{"batch-size": 100})
+func WorkflowConfigMemo(values map[string]interface{}) map[string]interface{} {
+	return internal.WorkflowConfigMemo(values)
+}
+
+// UpdateWorkflowConfig changes values of the workflow.WorkflowConfig of a running workflow, allowing to tune an
+// execution without redeploying the workers. The values are merged into the current config; keys that are config
+// search attributes of the workflow are also upserted by the workflow.
+func UpdateWorkflowConfig(ctx context.Context, c Client, workflowID, runID string, values map[string]interface{}) error {
+	return internal.UpdateWorkflowConfig(ctx, c, workflowID, runID, values)
+}
+
+// FindWorkflowTaskLists returns the sorted task lists of the domain polled by live workers serving workflowType,
+// according to the registrations the workers published to registry, e.g. to route new executions or to validate that
+// a deployment serves the workflow types it should:
+//
+//	taskLists, err := client.FindWorkflowTaskLists(ctx, registry, "my-domain", "OrderWorkflow")
+func FindWorkflowTaskLists(ctx context.Context, registry WorkerRegistry, domain string, workflowType string) ([]string, error) {
+	return internal.FindWorkflowTaskLists(ctx, registry, domain, workflowType)
+}
+
+// FindActivityTaskLists returns the sorted task lists of the domain polled by live workers serving activityType,
+// according to the registrations the workers published to registry.
+func FindActivityTaskLists(ctx context.Context, registry WorkerRegistry, domain string, activityType string) ([]string, error) {
+	return internal.FindActivityTaskLists(ctx, registry, domain, activityType)
+}
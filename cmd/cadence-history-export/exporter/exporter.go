@@ -0,0 +1,192 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package exporter implements the cadence-history-export command line tool, which streams the histories of closed
+// workflow executions matching a visibility query and writes a flattened record per execution as JSON Lines, to
+// feed data warehouses.
+//
+// Custom fields are extracted by the binary embedding the tool:
+//
+//	func main() {
+//		exporter.Main(func(events []*shared.HistoryEvent) (map[string]interface{}, error) {
+//			return map[string]interface{}{"customer": customerOf(events)}, nil
+//		})
+//	}
+//
+// Usage:
+//
+//	my-exporter -address 127.0.0.1:7833 -domain samples-domain -query "WorkflowType = 'MyWorkflow'" -output out.jsonl
+//
+// The tool exits with status 0 if the export succeeds, 1 if it fails and 2 for invalid arguments. Other formats,
+// like Parquet, can be written by calling worker.ExportHistories with a custom worker.HistoryExportWriter.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	apiv1 "github.com/uber/cadence-idl/go/proto/api/v1"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/transport/grpc"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/compatibility"
+	"go.uber.org/cadence/worker"
+)
+
+const (
+	exitCodeOK           = 0
+	exitCodeExportFailed = 1
+	exitCodeInvalidArgs  = 2
+
+	frontendServiceName = "cadence-frontend"
+)
+
+type (
+	// Options configure Run.
+	Options struct {
+		// Extractor returns the custom fields of the record of an execution.
+		// Optional: records have no custom fields if nil.
+		Extractor func(events []*shared.HistoryEvent) (map[string]interface{}, error)
+
+		// NewService creates the client used to fetch histories from the frontend at address.
+		// Optional: defaults to a gRPC client.
+		NewService func(address string) (workflowserviceclient.Interface, error)
+
+		// Stdout receives the records unless -output is set, Stderr the summary of the export and usage errors.
+		// Optional: default to os.Stdout and os.Stderr.
+		Stdout io.Writer
+		Stderr io.Writer
+	}
+
+	config struct {
+		address       string
+		domain        string
+		query         string
+		maxExecutions int
+		output        string
+	}
+)
+
+// Main runs the tool with the command line arguments of the process and exits with its status.
+func Main(extractor func(events []*shared.HistoryEvent) (map[string]interface{}, error)) {
+	os.Exit(Run(os.Args[1:], Options{Extractor: extractor}))
+}
+
+// Run runs the tool with args and returns its exit status.
+func Run(args []string, options Options) int {
+	if options.Stdout == nil {
+		options.Stdout = os.Stdout
+	}
+	if options.Stderr == nil {
+		options.Stderr = os.Stderr
+	}
+	if options.NewService == nil {
+		options.NewService = newGRPCService
+	}
+
+	cfg, err := parseArgs(args, options.Stderr)
+	if err != nil {
+		return exitCodeInvalidArgs
+	}
+
+	service, err := options.NewService(cfg.address)
+	if err != nil {
+		fmt.Fprintf(options.Stderr, "unable to create client for %v: %v\n", cfg.address, err)
+		return exitCodeInvalidArgs
+	}
+
+	output := options.Stdout
+	if cfg.output != "" {
+		file, err := os.Create(cfg.output)
+		if err != nil {
+			fmt.Fprintln(options.Stderr, err)
+			return exitCodeInvalidArgs
+		}
+		defer file.Close()
+		output = file
+	}
+
+	exported, err := worker.ExportHistories(context.Background(), service, cfg.domain, worker.NewJSONLinesHistoryExportWriter(output), worker.HistoryExportOptions{
+		Query:         cfg.query,
+		MaxExecutions: cfg.maxExecutions,
+		Extractor:     options.Extractor,
+	})
+	if err != nil {
+		fmt.Fprintf(options.Stderr, "export failed after %v executions: %v\n", exported, err)
+		return exitCodeExportFailed
+	}
+	fmt.Fprintf(options.Stderr, "exported %v executions\n", exported)
+	return exitCodeOK
+}
+
+func parseArgs(args []string, output io.Writer) (*config, error) {
+	cfg := &config{}
+	flags := flag.NewFlagSet("cadence-history-export", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&cfg.address, "address", "127.0.0.1:7833", "gRPC address of the Cadence frontend")
+	flags.StringVar(&cfg.domain, "domain", "", "domain of the workflow executions to export")
+	flags.StringVar(&cfg.query, "query", "", "visibility query selecting the workflow executions to export")
+	flags.IntVar(&cfg.maxExecutions, "max-executions", 0, "maximum number of executions to export, unlimited if 0")
+	flags.StringVar(&cfg.output, "output", "", "JSON Lines file to write the records to, defaults to stdout")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch {
+	case flags.NArg() > 0:
+		err = fmt.Errorf("unexpected arguments: %v", flags.Args())
+	case cfg.domain == "":
+		err = errors.New("-domain is required")
+	case cfg.maxExecutions < 0:
+		err = errors.New("-max-executions must not be negative")
+	}
+	if err != nil {
+		fmt.Fprintln(output, err)
+		flags.Usage()
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func newGRPCService(address string) (workflowserviceclient.Interface, error) {
+	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+		Name: "cadence-history-export",
+		Outbounds: yarpc.Outbounds{
+			frontendServiceName: {Unary: grpc.NewTransport().NewSingleOutbound(address)},
+		},
+	})
+	if err := dispatcher.Start(); err != nil {
+		return nil, err
+	}
+	clientConfig := dispatcher.ClientConfig(frontendServiceName)
+	return compatibility.NewThrift2ProtoAdapter(
+		apiv1.NewDomainAPIYARPCClient(clientConfig),
+		apiv1.NewWorkflowAPIYARPCClient(clientConfig),
+		apiv1.NewWorkerAPIYARPCClient(clientConfig),
+		apiv1.NewVisibilityAPIYARPCClient(clientConfig),
+	), nil
+}
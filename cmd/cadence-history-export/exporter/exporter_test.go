@@ -0,0 +1,136 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/worker"
+)
+
+const historyFile = "../../../test/replaytests/basic.json"
+
+func TestRun(t *testing.T) {
+	data, err := os.ReadFile(historyFile)
+	require.NoError(t, err)
+	var events []*shared.HistoryEvent
+	require.NoError(t, json.Unmarshal(data, &events))
+
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(mockCtrl)
+	workflowID, runID, closeTime := "wid", "rid", int64(1)
+	service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.ListWorkflowExecutionsResponse{
+			Executions: []*shared.WorkflowExecutionInfo{{
+				Execution: &shared.WorkflowExecution{WorkflowId: &workflowID, RunId: &runID},
+				CloseTime: &closeTime,
+			}},
+		}, nil).
+		Do(func(_ interface{}, req *shared.ListWorkflowExecutionsRequest, _ ...interface{}) {
+			assert.Equal(t, "samples-domain", req.GetDomain())
+			assert.Equal(t, "CloseTime > 0", req.GetQuery())
+		})
+	service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.GetWorkflowExecutionHistoryResponse{History: &shared.History{Events: events}}, nil)
+
+	output := filepath.Join(t.TempDir(), "out.jsonl")
+	var stderr bytes.Buffer
+	code := Run([]string{"-address", "frontend:7833", "-domain", "samples-domain", "-query", "CloseTime > 0", "-output", output}, Options{
+		Extractor: func(events []*shared.HistoryEvent) (map[string]interface{}, error) {
+			return map[string]interface{}{"first": events[0].GetEventType().String()}, nil
+		},
+		NewService: func(address string) (workflowserviceclient.Interface, error) {
+			assert.Equal(t, "frontend:7833", address)
+			return service, nil
+		},
+		Stderr: &stderr,
+	})
+	assert.Equal(t, exitCodeOK, code, stderr.String())
+	assert.Equal(t, "exported 1 executions\n", stderr.String())
+
+	data, err = os.ReadFile(output)
+	require.NoError(t, err)
+	var record worker.HistoryExportRecord
+	require.NoError(t, json.Unmarshal(data, &record))
+	assert.Equal(t, "wid", record.WorkflowID)
+	assert.Equal(t, len(events), record.HistoryLength)
+	require.Len(t, record.Activities, 1)
+	assert.Equal(t, 3, record.Activities[0].Completed)
+	assert.Equal(t, map[string]interface{}{"first": "WorkflowExecutionStarted"}, record.Fields)
+}
+
+func TestRun_ExportFailed(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(mockCtrl)
+	service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, &shared.BadRequestError{Message: "invalid query"})
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-domain", "samples-domain", "-query", "bad"}, Options{
+		NewService: func(address string) (workflowserviceclient.Interface, error) {
+			return service, nil
+		},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	assert.Equal(t, exitCodeExportFailed, code)
+	assert.Contains(t, stderr.String(), "invalid query")
+	assert.Zero(t, stdout.Len())
+}
+
+func TestRun_ServiceError(t *testing.T) {
+	var stderr bytes.Buffer
+	code := Run([]string{"-domain", "samples-domain"}, Options{
+		NewService: func(address string) (workflowserviceclient.Interface, error) {
+			return nil, errors.New("connection refused")
+		},
+		Stderr: &stderr,
+	})
+	assert.Equal(t, exitCodeInvalidArgs, code)
+	assert.Contains(t, stderr.String(), "connection refused")
+}
+
+func TestRun_InvalidArgs(t *testing.T) {
+	for name, args := range map[string][]string{
+		"missing domain":          {},
+		"negative max executions": {"-domain", "d", "-max-executions", "-1"},
+		"unknown flag":            {"-unknown"},
+		"extra argument":          {"-domain", "d", "extra"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			assert.Equal(t, exitCodeInvalidArgs, Run(args, Options{Stderr: &stderr}))
+			assert.Contains(t, stderr.String(), "Usage")
+		})
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package replayer implements the cadence-replayer command line tool, which replays workflow histories against
+// the workflows of a binary and reports non-determinism.
+//
+// The workflows are registered by the binary embedding the tool:
+//
+//	func main() {
+//		replayer.Main(func(r worker.WorkflowReplayer) {
+//			r.RegisterWorkflow(MyWorkflow)
+//		})
+//	}
+//
+// The history is read from a JSON file downloaded with `cadence workflow showid <workflow_id> -of <file>`, or
+// fetched from the Cadence frontend:
+//
+//	my-replayer -history history.json
+//	my-replayer -address 127.0.0.1:7833 -domain samples-domain -workflow-id my-workflow [-run-id <run ID>]
+//
+// The tool exits with status 0 if the replay succeeds, 1 if it fails and 2 for invalid arguments. With -json the
+// outcome is printed as a Result object, so it can be consumed in CI.
+package replayer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	apiv1 "github.com/uber/cadence-idl/go/proto/api/v1"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/transport/grpc"
+	"go.uber.org/zap"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/compatibility"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+)
+
+const (
+	exitCodeOK           = 0
+	exitCodeReplayFailed = 1
+	exitCodeInvalidArgs  = 2
+
+	frontendServiceName = "cadence-frontend"
+)
+
+type (
+	// Options configure Run.
+	Options struct {
+		// Register registers the workflows and activities of the binary on the replayer.
+		Register func(r worker.WorkflowReplayer)
+
+		// NewService creates the client used to fetch histories from the frontend at address.
+		// Optional: defaults to a gRPC client.
+		NewService func(address string) (workflowserviceclient.Interface, error)
+
+		// Stdout and Stderr receive the outcome of the replay and usage errors.
+		// Optional: default to os.Stdout and os.Stderr.
+		Stdout io.Writer
+		Stderr io.Writer
+	}
+
+	// Result is the outcome of a replay, as printed with -json.
+	Result struct {
+		// Source is the history file, or the domain/workflowID/runID of the replayed execution.
+		Source string `json:"source"`
+		// Deterministic is true if the workflow code replayed its history.
+		Deterministic bool `json:"deterministic"`
+		// Error is the replay error.
+		Error string `json:"error,omitempty"`
+		// NonDeterminism describes the mismatch when the replay failed due to non-determinism.
+		NonDeterminism *NonDeterminism `json:"nonDeterminism,omitempty"`
+	}
+
+	// NonDeterminism is the structured content of a worker.NonDeterministicError.
+	NonDeterminism struct {
+		Reason         string `json:"reason"`
+		WorkflowType   string `json:"workflowType"`
+		WorkflowID     string `json:"workflowId"`
+		RunID          string `json:"runId"`
+		TaskList       string `json:"taskList"`
+		Domain         string `json:"domain"`
+		HistoryEvent   string `json:"historyEvent,omitempty"`
+		ReplayDecision string `json:"replayDecision,omitempty"`
+	}
+
+	config struct {
+		historyFile string
+		lastEventID int64
+		address     string
+		domain      string
+		workflowID  string
+		runID       string
+		timeout     time.Duration
+		json        bool
+		verbose     bool
+	}
+)
+
+// Main runs the tool with the command line arguments of the process and exits with its status.
+func Main(register func(r worker.WorkflowReplayer)) {
+	os.Exit(Run(os.Args[1:], Options{Register: register}))
+}
+
+// Run runs the tool with args and returns its exit status.
+func Run(args []string, options Options) int {
+	if options.Stdout == nil {
+		options.Stdout = os.Stdout
+	}
+	if options.Stderr == nil {
+		options.Stderr = os.Stderr
+	}
+	if options.NewService == nil {
+		options.NewService = newGRPCService
+	}
+
+	cfg, err := parseArgs(args, options.Stderr)
+	if err != nil {
+		return exitCodeInvalidArgs
+	}
+
+	logger := zap.NewNop()
+	if cfg.verbose {
+		logger, err = zap.NewDevelopment()
+		if err != nil {
+			fmt.Fprintln(options.Stderr, err)
+			return exitCodeInvalidArgs
+		}
+	}
+
+	replayer := worker.NewWorkflowReplayer()
+	if options.Register != nil {
+		options.Register(replayer)
+	}
+
+	var result *Result
+	if cfg.historyFile != "" {
+		result = replayFile(replayer, logger, cfg)
+	} else {
+		service, err := options.NewService(cfg.address)
+		if err != nil {
+			fmt.Fprintf(options.Stderr, "unable to create client for %v: %v\n", cfg.address, err)
+			return exitCodeInvalidArgs
+		}
+		result = replayExecution(replayer, service, logger, cfg)
+	}
+
+	if err := printResult(options.Stdout, result, cfg.json); err != nil {
+		fmt.Fprintln(options.Stderr, err)
+	}
+	if !result.Deterministic {
+		return exitCodeReplayFailed
+	}
+	return exitCodeOK
+}
+
+func parseArgs(args []string, output io.Writer) (*config, error) {
+	cfg := &config{}
+	flags := flag.NewFlagSet("cadence-replayer", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&cfg.historyFile, "history", "", "JSON history file to replay")
+	flags.Int64Var(&cfg.lastEventID, "last-event-id", 0, "replay the history file up to this event ID (inclusive)")
+	flags.StringVar(&cfg.address, "address", "127.0.0.1:7833", "gRPC address of the Cadence frontend")
+	flags.StringVar(&cfg.domain, "domain", "", "domain of the workflow execution to replay")
+	flags.StringVar(&cfg.workflowID, "workflow-id", "", "ID of the workflow execution to replay")
+	flags.StringVar(&cfg.runID, "run-id", "", "run ID of the workflow execution, defaults to the latest run")
+	flags.DurationVar(&cfg.timeout, "timeout", time.Minute, "timeout for fetching the history")
+	flags.BoolVar(&cfg.json, "json", false, "print the result as JSON")
+	flags.BoolVar(&cfg.verbose, "verbose", false, "log the replay")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch {
+	case flags.NArg() > 0:
+		err = fmt.Errorf("unexpected arguments: %v", flags.Args())
+	case cfg.historyFile != "" && cfg.workflowID != "":
+		err = errors.New("-history and -workflow-id are mutually exclusive")
+	case cfg.historyFile == "" && cfg.workflowID == "":
+		err = errors.New("either -history or -workflow-id is required")
+	case cfg.workflowID != "" && cfg.domain == "":
+		err = errors.New("-domain is required with -workflow-id")
+	}
+	if err != nil {
+		fmt.Fprintln(output, err)
+		flags.Usage()
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func replayFile(replayer worker.WorkflowReplayer, logger *zap.Logger, cfg *config) *Result {
+	var err error
+	if cfg.lastEventID > 0 {
+		err = replayer.ReplayPartialWorkflowHistoryFromJSONFile(logger, cfg.historyFile, cfg.lastEventID)
+	} else {
+		err = replayer.ReplayWorkflowHistoryFromJSONFile(logger, cfg.historyFile)
+	}
+	return newResult(cfg.historyFile, err)
+}
+
+func replayExecution(replayer worker.WorkflowReplayer, service workflowserviceclient.Interface, logger *zap.Logger, cfg *config) *Result {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	execution := workflow.Execution{ID: cfg.workflowID, RunID: cfg.runID}
+	err := replayer.ReplayWorkflowExecution(ctx, service, logger, cfg.domain, execution)
+	return newResult(fmt.Sprintf("%v/%v/%v", cfg.domain, cfg.workflowID, cfg.runID), err)
+}
+
+func newResult(source string, err error) *Result {
+	result := &Result{Source: source, Deterministic: err == nil}
+	if err == nil {
+		return result
+	}
+	result.Error = err.Error()
+	var ndErr *worker.NonDeterministicError
+	if errors.As(err, &ndErr) {
+		result.NonDeterminism = &NonDeterminism{
+			Reason:         ndErr.Reason,
+			WorkflowType:   ndErr.WorkflowType,
+			WorkflowID:     ndErr.WorkflowID,
+			RunID:          ndErr.RunID,
+			TaskList:       ndErr.TaskList,
+			Domain:         ndErr.DomainName,
+			HistoryEvent:   ndErr.HistoryEventText,
+			ReplayDecision: ndErr.DecisionText,
+		}
+	}
+	return result
+}
+
+func printResult(w io.Writer, result *Result, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	if result.Deterministic {
+		_, err := fmt.Fprintf(w, "%v: replayed successfully\n", result.Source)
+		return err
+	}
+	nd := result.NonDeterminism
+	if nd == nil {
+		_, err := fmt.Fprintf(w, "%v: replay failed: %v\n", result.Source, result.Error)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%v: non-deterministic workflow %v (%v/%v): %v\n"+
+		"- history event:   %v\n"+
+		"+ replay decision: %v\n",
+		result.Source, nd.WorkflowType, nd.WorkflowID, nd.RunID, nd.Reason,
+		valueOrNone(nd.HistoryEvent), valueOrNone(nd.ReplayDecision))
+	return err
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+func newGRPCService(address string) (workflowserviceclient.Interface, error) {
+	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+		Name: "cadence-replayer",
+		Outbounds: yarpc.Outbounds{
+			frontendServiceName: {Unary: grpc.NewTransport().NewSingleOutbound(address)},
+		},
+	})
+	if err := dispatcher.Start(); err != nil {
+		return nil, err
+	}
+	clientConfig := dispatcher.ClientConfig(frontendServiceName)
+	return compatibility.NewThrift2ProtoAdapter(
+		apiv1.NewDomainAPIYARPCClient(clientConfig),
+		apiv1.NewWorkflowAPIYARPCClient(clientConfig),
+		apiv1.NewWorkerAPIYARPCClient(clientConfig),
+		apiv1.NewVisibilityAPIYARPCClient(clientConfig),
+	), nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replayer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+)
+
+const (
+	historyFile  = "../../../test/replaytests/basic.json"
+	workflowType = "go.uber.org/cadence/test/replaytests.Workflow"
+)
+
+// replaysHistory matches the workflow recorded in historyFile.
+func replaysHistory(ctx workflow.Context, name string) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+		HeartbeatTimeout:       20 * time.Second,
+	})
+	workflow.GetVersion(ctx, "test-change", workflow.DefaultVersion, 1)
+	for i := 0; i < 3; i++ {
+		if err := workflow.ExecuteActivity(ctx, "main.helloworldActivity", name).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sleeps schedules a timer where the history has a version marker.
+func sleeps(ctx workflow.Context, name string) error {
+	return workflow.Sleep(ctx, time.Minute)
+}
+
+func register(workflowFn interface{}) func(r worker.WorkflowReplayer) {
+	return func(r worker.WorkflowReplayer) {
+		r.RegisterWorkflowWithOptions(workflowFn, workflow.RegisterOptions{Name: workflowType})
+	}
+}
+
+func TestRun_HistoryFile(t *testing.T) {
+	var stdout bytes.Buffer
+	code := Run([]string{"-history", historyFile}, Options{Register: register(replaysHistory), Stdout: &stdout})
+	assert.Equal(t, exitCodeOK, code)
+	assert.Equal(t, historyFile+": replayed successfully\n", stdout.String())
+}
+
+func TestRun_NonDeterminism(t *testing.T) {
+	var stdout bytes.Buffer
+	code := Run([]string{"-history", historyFile, "-json"}, Options{Register: register(sleeps), Stdout: &stdout})
+	assert.Equal(t, exitCodeReplayFailed, code)
+
+	var result Result
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+	assert.False(t, result.Deterministic)
+	require.NotNil(t, result.NonDeterminism, result.Error)
+	assert.Equal(t, workflowType, result.NonDeterminism.WorkflowType)
+	assert.NotEmpty(t, result.NonDeterminism.Reason)
+	assert.Contains(t, result.NonDeterminism.ReplayDecision, "StartTimer")
+}
+
+func TestRun_Execution(t *testing.T) {
+	history, err := readHistory(historyFile)
+	require.NoError(t, err)
+
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(mockCtrl)
+	service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.GetWorkflowExecutionHistoryResponse{History: history}, nil).
+		Do(func(_ interface{}, req *shared.GetWorkflowExecutionHistoryRequest, _ ...interface{}) {
+			assert.Equal(t, "samples-domain", req.GetDomain())
+			assert.Equal(t, "wid", req.GetExecution().GetWorkflowId())
+		})
+
+	var stdout bytes.Buffer
+	code := Run([]string{"-address", "frontend:7833", "-domain", "samples-domain", "-workflow-id", "wid"}, Options{
+		Register: register(replaysHistory),
+		NewService: func(address string) (workflowserviceclient.Interface, error) {
+			assert.Equal(t, "frontend:7833", address)
+			return service, nil
+		},
+		Stdout: &stdout,
+	})
+	assert.Equal(t, exitCodeOK, code, stdout.String())
+	assert.Equal(t, "samples-domain/wid/: replayed successfully\n", stdout.String())
+}
+
+func TestRun_ServiceError(t *testing.T) {
+	var stderr bytes.Buffer
+	code := Run([]string{"-domain", "samples-domain", "-workflow-id", "wid"}, Options{
+		NewService: func(address string) (workflowserviceclient.Interface, error) {
+			return nil, errors.New("connection refused")
+		},
+		Stderr: &stderr,
+	})
+	assert.Equal(t, exitCodeInvalidArgs, code)
+	assert.Contains(t, stderr.String(), "connection refused")
+}
+
+func TestRun_InvalidArgs(t *testing.T) {
+	for name, args := range map[string][]string{
+		"no source":      {},
+		"both sources":   {"-history", historyFile, "-domain", "d", "-workflow-id", "wid"},
+		"missing domain": {"-workflow-id", "wid"},
+		"unknown flag":   {"-unknown"},
+		"extra argument": {"-history", historyFile, "extra"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			assert.Equal(t, exitCodeInvalidArgs, Run(args, Options{Stderr: &stderr}))
+			assert.Contains(t, stderr.String(), "Usage")
+		})
+	}
+}
+
+func readHistory(fileName string) (*shared.History, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var events []*shared.HistoryEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, err
+	}
+	return &shared.History{Events: events}, nil
+}
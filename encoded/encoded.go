@@ -42,9 +42,54 @@ type (
 	// Cadence support using different DataConverters for different activity/childWorkflow in same workflow.
 	//   2. Activity/Workflow worker that run these activity/childWorkflow, through worker.Options.
 	DataConverter = internal.DataConverter
+
+	// DataConverterOptions configures the JSON encoding of the DataConverter created with NewDataConverter.
+	DataConverterOptions = internal.DataConverterOptions
+
+	// PayloadFormat is how the JSON arguments of a payload are laid out, see DataConverterOptions.PayloadFormat.
+	PayloadFormat = internal.PayloadFormat
+
+	// DataConverterRoundTripError is returned by the DataConverter created with NewStrictDataConverter when a value
+	// does not survive being encoded and decoded back.
+	DataConverterRoundTripError = internal.DataConverterRoundTripError
 )
 
 // GetDefaultDataConverter return default data converter used by Cadence worker
 func GetDefaultDataConverter() DataConverter {
 	return internal.DefaultDataConverter
 }
+
+const (
+	// PayloadFormatNewlineDelimited writes every argument as a JSON value followed by a newline. It is the format
+	// of the Go client, which other clients cannot split into arguments.
+	PayloadFormatNewlineDelimited = internal.PayloadFormatNewlineDelimited
+	// PayloadFormatJSONArray writes a single argument as its JSON value and several arguments as a JSON array of
+	// them, like the JSON data converter of the Java client.
+	PayloadFormatJSONArray = internal.PayloadFormatJSONArray
+	// PayloadFormatEnvelope writes the arguments in a versioned JSON object, {"cadencePayload":1,"args":[...]},
+	// which clients in any language can decode without knowing the signature of the workflow or activity.
+	PayloadFormatEnvelope = internal.PayloadFormatEnvelope
+)
+
+// NewDataConverter returns the default data converter with its JSON encoding configured by options, e.g. to decode
+// numbers as float64 or to fail on unknown fields:
+//
+//	dc := encoded.NewDataConverter(encoded.DataConverterOptions{DisallowUnknownFields: true})
+//
+// Like the default data converter, it writes json.RawMessage values compacted but otherwise unmodified, and
+// encoding/json uses the
+// json.Marshaler and encoding.TextMarshaler implementations of the values.
+func NewDataConverter(options DataConverterOptions) DataConverter {
+	return internal.NewDataConverter(options)
+}
+
+// NewStrictDataConverter returns a DataConverter which decodes every payload encoded by dataConverter back and fails
+// the encoding with a *DataConverterRoundTripError when the decoded values differ from the source values, e.g.
+// because of unexported struct fields or of interface{} values whose type changes. It is meant for development and
+// tests, set it in worker.Options, client.Options or the test environment to catch serialization bugs before
+// payloads reach histories:
+//
+//	env.SetWorkerOptions(worker.Options{DataConverter: encoded.NewStrictDataConverter(myDataConverter)})
+func NewStrictDataConverter(dataConverter DataConverter) DataConverter {
+	return internal.NewStrictDataConverter(dataConverter)
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package entity runs entities: long-running workflows per key, whose state is changed by typed commands. A command
+// is delivered to the workflow of its entity with a signal, which starts the workflow if it is not running, and its
+// result is returned to the sender by querying the workflow. The state and the recent results of an entity are passed
+// to the next run as a Snapshot when the workflow continues as new.
+//
+// The workflow of an entity takes the snapshot of the previous run, which is nil when it is started by a command:
+//
+//	func CartWorkflow(ctx workflow.Context, snapshot *entity.Snapshot[Cart]) (Cart, error) {
+//		return entity.Run(ctx, snapshot, entity.Options[Cart]{
+//			Commands: map[string]entity.CommandHandler[Cart]{
+//				"add-item": entity.NewCommandHandler(func(ctx workflow.Context, cart *Cart, item Item) (int, error) {
+//					cart.Items = append(cart.Items, item)
+//					return len(cart.Items), nil
+//				}),
+//			},
+//			ContinueAsNew: func(ctx workflow.Context, snapshot entity.Snapshot[Cart]) error {
+//				return workflow.NewContinueAsNewError(ctx, CartWorkflow, &snapshot)
+//			},
+//		})
+//	}
+//
+// Commands are sent with a typed stub:
+//
+//	carts, err := client.NewEntityClient(c, client.EntityClientOptions{
+//		WorkflowIDPrefix: "cart:",
+//		Workflow:         CartWorkflow,
+//		WorkflowOptions:  client.StartWorkflowOptions{TaskList: "carts", ExecutionStartToCloseTimeout: time.Hour},
+//	})
+//	addItem := entity.NewCommandStub[Item, int](carts, "add-item")
+//	count, err := addItem.Execute(ctx, userID, item)
+package entity
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/cadence/internal"
+	"go.uber.org/cadence/workflow"
+)
+
+const (
+	// CommandSignalName is the name of the signal delivering CommandRequest to a workflow run by Run.
+	CommandSignalName = internal.EntityCommandSignalName
+
+	// CommandResultQueryType is the query type of the CommandResult of a command, which takes the ID of the command
+	// as its argument.
+	CommandResultQueryType = internal.EntityCommandResultQueryType
+)
+
+// ErrUnknownCommand is returned by CommandFuture.Get when the entity did not receive the command, or no longer retains
+// its result, see Options.MaximumRetainedResults.
+var ErrUnknownCommand = internal.ErrUnknownEntityCommand
+
+type (
+	// CommandRequest is the payload of the CommandSignalName signal, which asks an entity to handle a command.
+	CommandRequest = internal.EntityCommandRequest
+
+	// CommandResult is the result of a command handled by an entity, returned by its CommandResultQueryType query.
+	CommandResult = internal.EntityCommandResult
+
+	// CommandError is returned by CommandFuture.Get when the handler of the command failed.
+	CommandError = internal.EntityCommandError
+
+	// Snapshot is the state of an entity with the results of its recent commands. It is passed to the next run of
+	// the workflow when the entity continues as new.
+	Snapshot[S any] struct {
+		State   S
+		Results []CommandResult // oldest first
+	}
+
+	// CommandHandler handles a command of an entity with state S, see NewCommandHandler.
+	CommandHandler[S any] struct {
+		handler internal.EntityCommandHandler[S]
+	}
+
+	// Options configure Run.
+	Options[S any] struct {
+		// Commands - The handlers of the commands of the entity by command name. Required.
+		Commands map[string]CommandHandler[S]
+
+		// IdleTimeout - The time without commands after which the entity continues as new with its snapshot, which
+		// drops the history of the commands handled by the run. A run which did not handle any command keeps waiting.
+		// Optional: defaulted to no timeout.
+		IdleTimeout time.Duration
+
+		// ContinueAsNew - Called with the snapshot of the entity once the history of the workflow has grown to
+		// MaximumHistoryLength events, or after IdleTimeout. It should return a ContinueAsNewError which passes the
+		// snapshot to the new run.
+		// Optional: by default, Run continues as new the workflow type of the run, with a pointer to the snapshot as
+		// its only argument.
+		ContinueAsNew func(ctx workflow.Context, snapshot Snapshot[S]) error
+
+		// MaximumHistoryLength - The length of the history at which ContinueAsNew is called.
+		// Optional: defaulted to 10000 events.
+		MaximumHistoryLength int64
+
+		// MaximumRetainedResults - The number of command results which are retained for their senders to query.
+		// Optional: defaulted to 1000.
+		MaximumRetainedResults int
+	}
+
+	// CommandStub sends the commands of type C with results of type R to the entities of an EntityClient, whose
+	// workflows call Run.
+	CommandStub[C, R any] struct {
+		stub *internal.EntityCommandStub[C, R]
+	}
+
+	// CommandFuture is the result of a command sent with CommandStub.Send.
+	CommandFuture[R any] struct {
+		future *internal.EntityCommandFuture[R]
+	}
+)
+
+// NewCommandHandler returns the handler of a command of type C with a result of type R, which updates the state of
+// the entity. An error returned by fn is returned to the sender of the command, and does not stop the entity.
+func NewCommandHandler[S, C, R any](fn func(ctx workflow.Context, state *S, command C) (R, error)) CommandHandler[S] {
+	return CommandHandler[S]{handler: internal.NewEntityCommandHandler(fn)}
+}
+
+// Run runs the workflow of an entity with state S, which handles the commands sent with CommandStub one at a time, in
+// the order they are received. It starts with the snapshot passed to the workflow, and returns the state of the
+// entity with the error of options.ContinueAsNew, once the history of the run grew to options.MaximumHistoryLength
+// events or after options.IdleTimeout, or with *CanceledError if ctx is canceled.
+func Run[S any](ctx workflow.Context, snapshot *Snapshot[S], options Options[S]) (S, error) {
+	commands := make(map[string]internal.EntityCommandHandler[S], len(options.Commands))
+	for name, command := range options.Commands {
+		commands[name] = command.handler
+	}
+	internalOptions := internal.CommandEntityOptions[S]{
+		Commands:               commands,
+		IdleTimeout:            options.IdleTimeout,
+		MaximumHistoryLength:   options.MaximumHistoryLength,
+		MaximumRetainedResults: options.MaximumRetainedResults,
+	}
+	if options.ContinueAsNew != nil {
+		internalOptions.ContinueAsNew = func(ctx internal.Context, snapshot internal.EntitySnapshot[S]) error {
+			return options.ContinueAsNew(ctx, Snapshot[S](snapshot))
+		}
+	}
+	return internal.RunCommandEntity(ctx, (*internal.EntitySnapshot[S])(snapshot), internalOptions)
+}
+
+// NewCommandStub returns a stub sending the command with the given name to the entities of entities.
+func NewCommandStub[C, R any](entities *internal.EntityClient, name string) CommandStub[C, R] {
+	return CommandStub[C, R]{stub: internal.NewEntityCommandStub[C, R](entities, name)}
+}
+
+// Send sends the command to the entity with the given key, starting its workflow with a nil *Snapshot if it is not
+// running, and returns the future of its result.
+func (s CommandStub[C, R]) Send(ctx context.Context, key string, command C) (CommandFuture[R], error) {
+	future, err := s.stub.Send(ctx, key, command)
+	return CommandFuture[R]{future: future}, err
+}
+
+// Execute sends the command to the entity with the given key, and waits for its result.
+func (s CommandStub[C, R]) Execute(ctx context.Context, key string, command C) (R, error) {
+	return s.stub.Execute(ctx, key, command)
+}
+
+// CommandID returns the ID of the command.
+func (f CommandFuture[R]) CommandID() string {
+	return f.future.CommandID()
+}
+
+// Get waits for the entity to handle the command, by polling its CommandResultQueryType query with strong consistency
+// until ctx is done. It returns *CommandError if the handler of the command failed, and ErrUnknownCommand without
+// waiting if the entity does not know the command.
+func (f CommandFuture[R]) Get(ctx context.Context) (R, error) {
+	return f.future.Get(ctx)
+}
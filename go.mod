@@ -27,6 +27,7 @@ require (
 	golang.org/x/net v0.19.0
 	golang.org/x/oauth2 v0.1.0
 	golang.org/x/time v0.0.0-20170927054726-6dc17368e09b
+	google.golang.org/grpc v1.28.0
 )
 
 require (
@@ -58,7 +59,6 @@ require (
 	golang.org/x/tools v0.6.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/genproto v0.0.0-20200212174721-66ed5ce911ce // indirect
-	google.golang.org/grpc v1.28.0 // indirect
 	google.golang.org/protobuf v1.31.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -0,0 +1,210 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package history contains helpers to analyze the history events of a workflow execution, e.g. the ones returned by
+// client.Client.GetWorkflowHistory: summaries of its activities, timers, signals, child workflows and decision tasks,
+// and the critical path which explains where the time of the workflow went.
+package history
+
+import (
+	"io"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	internal "go.uber.org/cadence/internal/common/history"
+)
+
+type (
+	// ActivityState is the state of an activity at the end of the analyzed history.
+	ActivityState = internal.ActivityState
+
+	// ActivitySummary describes a single scheduled activity, including how long it waited, ran and was retried.
+	ActivitySummary = internal.ActivitySummary
+
+	// TimerSummary describes a single started timer.
+	TimerSummary = internal.TimerSummary
+
+	// Signal describes a single signal received by the workflow.
+	Signal = internal.Signal
+
+	// ChildWorkflowSummary describes a single child workflow started by the workflow.
+	ChildWorkflowSummary = internal.ChildWorkflowSummary
+
+	// DecisionTaskSummary describes a single decision task.
+	DecisionTaskSummary = internal.DecisionTaskSummary
+
+	// SpanKind is the kind of operation a Span was spent on.
+	SpanKind = internal.SpanKind
+
+	// Span is a period of time spent on a single operation of the workflow.
+	Span = internal.Span
+
+	// TimeBreakdown splits the time between the first and the last event of a history by the kind of operations on
+	// its critical path.
+	TimeBreakdown = internal.TimeBreakdown
+
+	// ActivitySample is a single observed execution of an activity, extracted from a history by ActivitySamples or
+	// built from the metrics emitted by workers.
+	ActivitySample = internal.ActivitySample
+
+	// TimeoutAdvisorOptions configure RecommendTimeouts.
+	TimeoutAdvisorOptions = internal.TimeoutAdvisorOptions
+
+	// TimeoutRecommendation holds the recommended timeouts and retry policy of an activity type.
+	TimeoutRecommendation = internal.TimeoutRecommendation
+
+	// RetryPolicyRecommendation is the recommended retry policy of an activity type.
+	RetryPolicyRecommendation = internal.RetryPolicyRecommendation
+)
+
+const (
+	// ActivityStateScheduled means the activity was scheduled but not started.
+	ActivityStateScheduled = internal.ActivityStateScheduled
+	// ActivityStateStarted means the activity was started but not closed.
+	ActivityStateStarted = internal.ActivityStateStarted
+	// ActivityStateCompleted means the activity completed successfully.
+	ActivityStateCompleted = internal.ActivityStateCompleted
+	// ActivityStateFailed means the activity failed.
+	ActivityStateFailed = internal.ActivityStateFailed
+	// ActivityStateTimedOut means the activity timed out.
+	ActivityStateTimedOut = internal.ActivityStateTimedOut
+	// ActivityStateCanceled means the activity was canceled.
+	ActivityStateCanceled = internal.ActivityStateCanceled
+)
+
+const (
+	// SpanKindDecisionTask is the time from scheduling a decision task to closing it.
+	SpanKindDecisionTask = internal.SpanKindDecisionTask
+	// SpanKindActivity is the time from scheduling an activity to closing it, including its retries.
+	SpanKindActivity = internal.SpanKindActivity
+	// SpanKindTimer is the time from starting a timer to firing it.
+	SpanKindTimer = internal.SpanKindTimer
+	// SpanKindChildWorkflow is the time from initiating a child workflow to closing it.
+	SpanKindChildWorkflow = internal.SpanKindChildWorkflow
+	// SpanKindSignal is the time the workflow waited for a signal after its previous decision task.
+	SpanKindSignal = internal.SpanKindSignal
+	// SpanKindOther is the time the workflow waited for any other operation or external event.
+	SpanKindOther = internal.SpanKindOther
+	// SpanKindWorkflow is the time from the start of the workflow to its last event.
+	SpanKindWorkflow = internal.SpanKindWorkflow
+)
+
+// Activities returns the summaries of all activities scheduled in the history, in the order they were scheduled.
+func Activities(events []*shared.HistoryEvent) []ActivitySummary {
+	return internal.Activities(events)
+}
+
+// Timers returns the summaries of all timers started in the history, keyed by timer ID.
+func Timers(events []*shared.HistoryEvent) map[string]TimerSummary {
+	return internal.Timers(events)
+}
+
+// Signals returns all signals received by the workflow, in the order they were received.
+func Signals(events []*shared.HistoryEvent) []Signal {
+	return internal.Signals(events)
+}
+
+// ChildWorkflows returns the summaries of all child workflows initiated in the history, in the order they were
+// initiated.
+func ChildWorkflows(events []*shared.HistoryEvent) []ChildWorkflowSummary {
+	return internal.ChildWorkflows(events)
+}
+
+// DecisionTasks returns the summaries of all decision tasks in the history, in the order they were scheduled.
+func DecisionTasks(events []*shared.HistoryEvent) []DecisionTaskSummary {
+	return internal.DecisionTasks(events)
+}
+
+// CriticalPath returns the chain of operations which determined when the last event of the history happened, in
+// chronological order. E.g. for a workflow which ran an activity and then waited for a signal, it is the first decision
+// task, the activity, the wait for the signal after the decision task which handled the activity result, and the last
+// decision task.
+func CriticalPath(events []*shared.HistoryEvent) []Span {
+	return internal.CriticalPath(events)
+}
+
+// Breakdown returns how the time between the first and the last event of the history was spent, according to its
+// CriticalPath.
+func Breakdown(events []*shared.HistoryEvent) TimeBreakdown {
+	return internal.Breakdown(events)
+}
+
+// Timeline returns a span for the workflow, and for each of its decision tasks, activities, timers, child workflows and
+// signals, sorted by start time. Operations which did not close end at the last event of the history, signals are
+// zero length spans at the time they were received.
+func Timeline(events []*shared.HistoryEvent) []Span {
+	return internal.Timeline(events)
+}
+
+// WriteChromeTrace writes the Timeline of the history as a Chrome trace JSON, which can be opened with chrome://tracing,
+// Perfetto or speedscope to get a flame graph of the workflow.
+func WriteChromeTrace(w io.Writer, events []*shared.HistoryEvent) error {
+	return internal.WriteChromeTrace(w, events)
+}
+
+// ReportSpans reports the Timeline of the history to the tracer, as a span for the workflow with a child span for each
+// of its operations, so it can be viewed in an existing tracing UI like Jaeger.
+func ReportSpans(tracer opentracing.Tracer, events []*shared.HistoryEvent) {
+	internal.ReportSpans(tracer, events)
+}
+
+// WriteTemporalJSON writes the history in the JSON format of Temporal histories, so that Cadence histories can be
+// replayed with the workflow replayer of the Temporal Go SDK against workflow code migrated to it. Decision tasks become
+// workflow tasks, task lists task queues, domains namespaces, payloads are split into one JSON payload per argument,
+// except for the JSON array payload format whose arguments cannot be told apart from a single array argument, and
+// failure reasons become application failure types. Cadence markers, like versions and side effects, are kept as
+// they are. It fails for CancelTimerFailed and RequestCancelActivityTaskFailed events, which Temporal has no
+// equivalent of.
+func WriteTemporalJSON(w io.Writer, events []*shared.HistoryEvent) error {
+	return internal.WriteTemporalJSON(w, events)
+}
+
+// ActivitySamples returns a sample for every closed activity of the history, to be passed to RecommendTimeouts. Only
+// the last attempt of a retried activity is sampled, as the others are not recorded in the history.
+func ActivitySamples(events []*shared.HistoryEvent) []ActivitySample {
+	return internal.ActivitySamples(events)
+}
+
+// RecommendTimeouts recommends the ScheduleToStart, StartToClose and Heartbeat timeouts of every activity type of the
+// samples, based on a percentile of the observed durations (p99 by default) multiplied by a safety margin, and a
+// retry policy for the activity types which failed, timed out or were retried. The durations of timed out samples are
+// bounded by their timeouts, so they are only counted. Samples are usually collected from many histories, or built
+// from the latency metrics emitted by workers.
+func RecommendTimeouts(samples []ActivitySample, options TimeoutAdvisorOptions) []TimeoutRecommendation {
+	return internal.RecommendTimeouts(samples, options)
+}
+
+// WriteTimeoutRecommendationsGo writes the recommendations as a Go map of workflow.ActivityOptions by activity type.
+func WriteTimeoutRecommendationsGo(w io.Writer, recommendations []TimeoutRecommendation) error {
+	return internal.WriteTimeoutRecommendationsGo(w, recommendations)
+}
+
+// WriteTimeoutRecommendationsJSON writes the recommendations as a JSON array to be loaded as configuration, with
+// durations formatted like time.Duration.String.
+func WriteTimeoutRecommendationsJSON(w io.Writer, recommendations []TimeoutRecommendation) error {
+	return internal.WriteTimeoutRecommendationsJSON(w, recommendations)
+}
+
+// EventTime returns the timestamp of a history event.
+func EventTime(event *shared.HistoryEvent) time.Time {
+	return internal.EventTime(event)
+}
@@ -22,6 +22,8 @@
 package interceptors
 
 import (
+	"go.uber.org/zap"
+
 	"go.uber.org/cadence/internal"
 )
 
@@ -42,3 +44,104 @@ type (
 	// to the next link in an interceptor chain. To be used as base implementation of interceptors.
 	WorkflowInterceptorBase = internal.WorkflowInterceptorBase
 )
+
+// PayloadHooks validate or transform the payloads of every workflow executed by a worker.
+type PayloadHooks = internal.WorkflowPayloadHooks
+
+// NewPayloadInterceptorFactory returns a WorkflowInterceptorFactory applying hooks to the input and the result of
+// every workflow. Add it to worker.Options.WorkflowInterceptorChainFactories to enforce contracts on workflow payloads,
+// like schema validation or redaction, for all workflows of a worker:
+//
+//	options := worker.Options{
+//		WorkflowInterceptorChainFactories: []interceptors.WorkflowInterceptorFactory{
+//			interceptors.NewPayloadInterceptorFactory(interceptors.PayloadHooks{
+//				Input: func(ctx workflow.Context, workflowType string, args ...interface{}) error {
+//					return validateSchema(workflowType, args)
+//				},
+//			}),
+//		},
+//	}
+func NewPayloadInterceptorFactory(hooks PayloadHooks) WorkflowInterceptorFactory {
+	return internal.NewWorkflowPayloadInterceptorFactory(hooks)
+}
+
+type (
+	// AuditOperation is the kind of operation described by an AuditRecord.
+	AuditOperation = internal.AuditOperation
+
+	// AuditRecord describes an operation of a workflow recorded by the audit interceptor.
+	AuditRecord = internal.AuditRecord
+
+	// AuditSink stores the records of the audit interceptor. Record is called from workflow code, so it must not
+	// block, and it is called concurrently for the workflows of a worker.
+	AuditSink = internal.AuditSink
+
+	// AuditOptions configure NewAuditInterceptorFactory.
+	AuditOptions = internal.AuditOptions
+)
+
+const (
+	// AuditOperationSignal is a signal received by the workflow.
+	AuditOperationSignal = internal.AuditOperationSignal
+	// AuditOperationSignalExternal is a signal sent by the workflow to another workflow.
+	AuditOperationSignalExternal = internal.AuditOperationSignalExternal
+	// AuditOperationQuery is a query answered by a handler registered with workflow.SetQueryHandler.
+	AuditOperationQuery = internal.AuditOperationQuery
+	// AuditOperationActivity is an activity scheduled by the workflow.
+	AuditOperationActivity = internal.AuditOperationActivity
+	// AuditOperationLocalActivity is a local activity scheduled by the workflow.
+	AuditOperationLocalActivity = internal.AuditOperationLocalActivity
+	// AuditOperationChildWorkflow is a child workflow started by the workflow.
+	AuditOperationChildWorkflow = internal.AuditOperationChildWorkflow
+)
+
+// NewAuditInterceptorFactory returns a WorkflowInterceptorFactory recording every received and sent signal, query,
+// activity and local activity schedule, and child workflow start of the workflows of a worker to options.Sink.
+// Records carry a digest of the arguments instead of the arguments themselves, and the caller identity returned by
+// options.CallerIdentity, typically read from a value a context propagator extracted from the request headers:
+//
+//	options := worker.Options{
+//		WorkflowInterceptorChainFactories: []interceptors.WorkflowInterceptorFactory{
+//			interceptors.NewAuditInterceptorFactory(interceptors.AuditOptions{
+//				Sink: interceptors.NewLoggerAuditSink(auditLogger),
+//				CallerIdentity: func(ctx workflow.Context) string {
+//					caller, _ := ctx.Value(callerKey).(string)
+//					return caller
+//				},
+//			}),
+//		},
+//	}
+func NewAuditInterceptorFactory(options AuditOptions) WorkflowInterceptorFactory {
+	return internal.NewAuditInterceptorFactory(options)
+}
+
+// NewLoggerAuditSink returns an AuditSink writing every record as an info log entry of logger.
+func NewLoggerAuditSink(logger *zap.Logger) AuditSink {
+	return internal.NewLoggerAuditSink(logger)
+}
+
+type (
+	// FeatureFlagProvider decides whether feature flags are enabled for a workflow execution, see
+	// NewFeatureFlagInterceptorFactory. IsEnabled is called from workflow code, so it must not block, and it is called
+	// concurrently for the workflows of a worker.
+	FeatureFlagProvider = internal.FeatureFlagProvider
+
+	// FeatureFlagProviderFunc is an adapter to allow the use of ordinary functions as FeatureFlagProvider.
+	FeatureFlagProviderFunc = internal.FeatureFlagProviderFunc
+)
+
+// NewFeatureFlagInterceptorFactory returns a WorkflowInterceptorFactory making provider available to
+// workflow.IsFeatureEnabled in every workflow of a worker, to roll out changes of workflow logic gradually:
+//
+//	options := worker.Options{
+//		WorkflowInterceptorChainFactories: []interceptors.WorkflowInterceptorFactory{
+//			interceptors.NewFeatureFlagInterceptorFactory(interceptors.FeatureFlagProviderFunc(
+//				func(flag string, info *workflow.Info) bool {
+//					return flags.Enabled(flag, info.Domain)
+//				},
+//			)),
+//		},
+//	}
+func NewFeatureFlagInterceptorFactory(provider FeatureFlagProvider) WorkflowInterceptorFactory {
+	return internal.NewFeatureFlagInterceptorFactory(provider)
+}
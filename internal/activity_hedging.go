@@ -0,0 +1,111 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"time"
+)
+
+// ActivityHedgingOptions configure the hedging of an activity, see WithActivityHedging.
+type ActivityHedgingOptions struct {
+	// Delay after which a backup attempt of the activity is scheduled, if the first attempt has not completed yet.
+	// Required.
+	Delay time.Duration
+
+	// TaskList the backup attempt is scheduled on, e.g. one polled by another pool of workers.
+	// Optional: the task list of the activity.
+	TaskList string
+}
+
+const activityHedgingContextKey contextKey = "activityHedging"
+
+// hedgedActivityIDSuffix is appended to the explicit activity ID of the activity for its backup attempt.
+const hedgedActivityIDSuffix = "-hedge"
+
+// WithActivityHedging returns a copy of ctx whose activities are hedged: when an activity has not completed after
+// options.Delay, a backup attempt of it is scheduled, and the result of the attempt completing first is the result of
+// the activity, while the other attempt is canceled. It is meant for idempotent, latency-critical activities whose
+// tail latency is dominated by occasional slow workers, as both attempts may execute.
+func WithActivityHedging(ctx Context, options ActivityHedgingOptions) Context {
+	return WithValue(ctx, activityHedgingContextKey, &options)
+}
+
+func getActivityHedgingOptions(ctx Context) *ActivityHedgingOptions {
+	options, _ := ctx.Value(activityHedgingContextKey).(*ActivityHedgingOptions)
+	return options
+}
+
+// executeHedgedActivity executes the activity through the interceptor, and a backup attempt of it once the hedging
+// delay passed.
+func executeHedgedActivity(
+	ctx Context,
+	interceptor WorkflowInterceptor,
+	activityType string,
+	options ActivityHedgingOptions,
+	args []interface{},
+) Future {
+	future, settable := NewNamedFuture(ctx, "activity:"+activityType)
+	if options.Delay <= 0 {
+		settable.SetError(errors.New("activity hedging delay must be positive"))
+		return future
+	}
+
+	primaryCtx, cancelPrimary := WithCancel(ctx)
+	primary := interceptor.ExecuteActivity(primaryCtx, activityType, args...)
+	Go(ctx, func(ctx Context) {
+		timerCtx, cancelTimer := WithCancel(ctx)
+		defer cancelTimer()
+		var winner Future
+		var cancelLoser CancelFunc
+
+		selector := NewSelector(ctx)
+		selector.AddFuture(primary, func(f Future) {
+			winner = f
+		})
+		selector.AddFuture(NewTimer(timerCtx, options.Delay), func(f Future) {
+			if f.Get(ctx, nil) != nil {
+				return // canceled along with the activity
+			}
+			backupCtx, cancelBackup := WithCancel(ctx)
+			if options.TaskList != "" {
+				backupCtx = WithTaskList(backupCtx, options.TaskList)
+			}
+			if activityOptions := getActivityOptions(ctx); activityOptions != nil &&
+				activityOptions.ActivityID != nil && *activityOptions.ActivityID != "" {
+				backupCtx = WithActivityID(backupCtx, *activityOptions.ActivityID+hedgedActivityIDSuffix)
+			}
+			cancelLoser = cancelBackup
+			selector.AddFuture(interceptor.ExecuteActivity(backupCtx, activityType, args...), func(f Future) {
+				winner = f
+				cancelLoser = cancelPrimary
+			})
+		})
+		for winner == nil {
+			selector.Select(ctx)
+		}
+		if cancelLoser != nil {
+			cancelLoser()
+		}
+		settable.Set(winner.(asyncFuture).GetValueAndError())
+	})
+	return future
+}
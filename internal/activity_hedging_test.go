@@ -0,0 +1,98 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityHedging(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	// the activity is slow on the "slow" task list
+	activityFn := func(ctx context.Context) (string, error) {
+		taskList := GetActivityInfo(ctx).TaskList
+		if taskList == "slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return taskList, nil
+	}
+	workflowFn := func(ctx Context, taskList string, activityID string) (string, error) {
+		ctx = WithActivityOptions(ctx, ActivityOptions{
+			TaskList:               taskList,
+			ActivityID:             activityID,
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+		})
+		ctx = WithActivityHedging(ctx, ActivityHedgingOptions{Delay: 10 * time.Millisecond, TaskList: "fast"})
+		var result string
+		if err := ExecuteActivity(ctx, activityFn).Get(ctx, &result); err != nil {
+			return "", err
+		}
+		// let the cancellation of the other attempt be processed
+		return result, Sleep(ctx, time.Minute)
+	}
+
+	t.Run("completed before the delay", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions(activityFn, RegisterActivityOptions{Name: "hedged"})
+		env.ExecuteWorkflow(workflowFn, "primary", "")
+		require.NoError(t, env.GetWorkflowError())
+		var result string
+		require.NoError(t, env.GetWorkflowResult(&result))
+		assert.Equal(t, "primary", result)
+		assert.Equal(t, []string{"activity hedged", "timer 10ms", "timer 1m0s"}, env.Trace())
+	})
+
+	t.Run("backup attempt", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions(activityFn, RegisterActivityOptions{Name: "hedged"})
+		var canceled, completed []string
+		env.SetOnActivityCanceledListener(func(info *ActivityInfo) {
+			canceled = append(canceled, info.ActivityID)
+		})
+		env.SetOnActivityCompletedListener(func(info *ActivityInfo, result Value, err error) {
+			completed = append(completed, info.ActivityID)
+		})
+		env.ExecuteWorkflow(workflowFn, "slow", "lookup")
+		require.NoError(t, env.GetWorkflowError())
+		var result string
+		require.NoError(t, env.GetWorkflowResult(&result))
+		assert.Equal(t, "fast", result)
+		assert.Equal(t, []string{"activity hedged", "timer 10ms", "activity hedged", "timer 1m0s"}, env.Trace())
+		assert.Equal(t, []string{"lookup" + hedgedActivityIDSuffix}, completed)
+		assert.Equal(t, []string{"lookup"}, canceled)
+	})
+
+	t.Run("invalid delay", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) error {
+			ctx = WithActivityHedging(ctx, ActivityHedgingOptions{})
+			return ExecuteActivity(ctx, activityFn).Get(ctx, nil)
+		})
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "activity hedging delay must be positive")
+	})
+}
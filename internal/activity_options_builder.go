@@ -0,0 +1,169 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"time"
+
+	"go.uber.org/cadence/internal/common"
+)
+
+// ActivityOptionsBuilder composes ActivityOptions from a base value and per-call overrides. Fields that are not set
+// on the builder keep the value of the base options.
+type ActivityOptionsBuilder struct {
+	options ActivityOptions
+}
+
+// NewActivityOptionsBuilder creates a builder starting from a copy of the base options.
+func NewActivityOptionsBuilder(base ActivityOptions) *ActivityOptionsBuilder {
+	return &ActivityOptionsBuilder{options: base.copy()}
+}
+
+// TaskList sets the task list that the activity is scheduled on.
+func (b *ActivityOptionsBuilder) TaskList(name string) *ActivityOptionsBuilder {
+	b.options.TaskList = name
+	return b
+}
+
+// ScheduleToCloseTimeout sets the end to end timeout of the activity.
+func (b *ActivityOptionsBuilder) ScheduleToCloseTimeout(d time.Duration) *ActivityOptionsBuilder {
+	b.options.ScheduleToCloseTimeout = d
+	return b
+}
+
+// ScheduleToStartTimeout sets the timeout of the activity waiting in the task list.
+func (b *ActivityOptionsBuilder) ScheduleToStartTimeout(d time.Duration) *ActivityOptionsBuilder {
+	b.options.ScheduleToStartTimeout = d
+	return b
+}
+
+// StartToCloseTimeout sets the timeout of a single activity attempt.
+func (b *ActivityOptionsBuilder) StartToCloseTimeout(d time.Duration) *ActivityOptionsBuilder {
+	b.options.StartToCloseTimeout = d
+	return b
+}
+
+// HeartbeatTimeout sets the maximum interval between two heartbeats of the activity.
+func (b *ActivityOptionsBuilder) HeartbeatTimeout(d time.Duration) *ActivityOptionsBuilder {
+	b.options.HeartbeatTimeout = d
+	return b
+}
+
+// WaitForCancellation sets whether a cancelled activity is waited for until it completes.
+func (b *ActivityOptionsBuilder) WaitForCancellation(wait bool) *ActivityOptionsBuilder {
+	b.options.WaitForCancellation = wait
+	return b
+}
+
+// ActivityID sets the business level ID of the activity.
+func (b *ActivityOptionsBuilder) ActivityID(activityID string) *ActivityOptionsBuilder {
+	b.options.ActivityID = activityID
+	return b
+}
+
+// RetryPolicy sets the retry policy of the activity. The policy is copied.
+func (b *ActivityOptionsBuilder) RetryPolicy(retryPolicy RetryPolicy) *ActivityOptionsBuilder {
+	b.options.RetryPolicy = &retryPolicy
+	return b
+}
+
+// NoRetryPolicy removes the retry policy inherited from the base options.
+func (b *ActivityOptionsBuilder) NoRetryPolicy() *ActivityOptionsBuilder {
+	b.options.RetryPolicy = nil
+	return b
+}
+
+// Build returns the composed options. The builder can be used again after Build.
+func (b *ActivityOptionsBuilder) Build() ActivityOptions {
+	return b.options.copy()
+}
+
+// Merge returns a copy of the options where every field set in overrides replaces the value of o. Zero values in
+// overrides, like an unset HeartbeatTimeout, keep the value of o. WaitForCancellation is only merged when it is true.
+func (o ActivityOptions) Merge(overrides ActivityOptions) ActivityOptions {
+	merged := o.copy()
+	if overrides.TaskList != "" {
+		merged.TaskList = overrides.TaskList
+	}
+	if overrides.ScheduleToCloseTimeout != 0 {
+		merged.ScheduleToCloseTimeout = overrides.ScheduleToCloseTimeout
+	}
+	if overrides.ScheduleToStartTimeout != 0 {
+		merged.ScheduleToStartTimeout = overrides.ScheduleToStartTimeout
+	}
+	if overrides.StartToCloseTimeout != 0 {
+		merged.StartToCloseTimeout = overrides.StartToCloseTimeout
+	}
+	if overrides.HeartbeatTimeout != 0 {
+		merged.HeartbeatTimeout = overrides.HeartbeatTimeout
+	}
+	if overrides.WaitForCancellation {
+		merged.WaitForCancellation = true
+	}
+	if overrides.ActivityID != "" {
+		merged.ActivityID = overrides.ActivityID
+	}
+	if overrides.RetryPolicy != nil {
+		merged.RetryPolicy = overrides.copy().RetryPolicy
+	}
+	return merged
+}
+
+// WithActivityOptionOverrides adds the fields set in overrides to the activity options of the copy of the context.
+// Unlike WithActivityOptions, fields that are not set in overrides keep the value already stored in the context.
+func WithActivityOptionOverrides(ctx Context, overrides ActivityOptions) Context {
+	ctx1 := setActivityParametersIfNotExist(ctx)
+	eap := getActivityOptions(ctx1)
+	if overrides.TaskList != "" {
+		eap.TaskListName = overrides.TaskList
+	}
+	if overrides.ScheduleToCloseTimeout != 0 {
+		eap.ScheduleToCloseTimeoutSeconds = common.Int32Ceil(overrides.ScheduleToCloseTimeout.Seconds())
+	}
+	if overrides.ScheduleToStartTimeout != 0 {
+		eap.ScheduleToStartTimeoutSeconds = common.Int32Ceil(overrides.ScheduleToStartTimeout.Seconds())
+	}
+	if overrides.StartToCloseTimeout != 0 {
+		eap.StartToCloseTimeoutSeconds = common.Int32Ceil(overrides.StartToCloseTimeout.Seconds())
+	}
+	if overrides.HeartbeatTimeout != 0 {
+		eap.HeartbeatTimeoutSeconds = common.Int32Ceil(overrides.HeartbeatTimeout.Seconds())
+	}
+	if overrides.WaitForCancellation {
+		eap.WaitForCancellation = true
+	}
+	if overrides.ActivityID != "" {
+		eap.ActivityID = common.StringPtr(overrides.ActivityID)
+	}
+	if overrides.RetryPolicy != nil {
+		eap.RetryPolicy = convertRetryPolicy(overrides.RetryPolicy)
+	}
+	return ctx1
+}
+
+func (o ActivityOptions) copy() ActivityOptions {
+	if o.RetryPolicy != nil {
+		retryPolicy := *o.RetryPolicy
+		retryPolicy.NonRetriableErrorReasons = append([]string(nil), o.RetryPolicy.NonRetriableErrorReasons...)
+		o.RetryPolicy = &retryPolicy
+	}
+	return o
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityOptionsBuilder(t *testing.T) {
+	base := ActivityOptions{
+		TaskList:               "base-tl",
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+		HeartbeatTimeout:       10 * time.Second,
+		RetryPolicy:            &RetryPolicy{InitialInterval: time.Second, MaximumAttempts: 3},
+	}
+
+	b := NewActivityOptionsBuilder(base).StartToCloseTimeout(time.Hour).ActivityID("id")
+	ao := b.Build()
+	assert.Equal(t, "base-tl", ao.TaskList)
+	assert.Equal(t, time.Hour, ao.StartToCloseTimeout)
+	assert.Equal(t, 10*time.Second, ao.HeartbeatTimeout)
+	assert.Equal(t, "id", ao.ActivityID)
+	require.NotNil(t, ao.RetryPolicy)
+	assert.False(t, ao.RetryPolicy == base.RetryPolicy, "retry policy must be copied")
+
+	noRetry := b.NoRetryPolicy().Build()
+	assert.Nil(t, noRetry.RetryPolicy)
+	assert.NotNil(t, ao.RetryPolicy, "built options must not change with the builder")
+	assert.Equal(t, time.Minute, base.StartToCloseTimeout)
+}
+
+func TestActivityOptionsMerge(t *testing.T) {
+	base := ActivityOptions{
+		TaskList:               "base-tl",
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+		HeartbeatTimeout:       10 * time.Second,
+	}
+	merged := base.Merge(ActivityOptions{
+		StartToCloseTimeout: time.Hour,
+		RetryPolicy:         &RetryPolicy{InitialInterval: time.Second, MaximumAttempts: 3},
+	})
+	assert.Equal(t, ActivityOptions{
+		TaskList:               "base-tl",
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Hour,
+		HeartbeatTimeout:       10 * time.Second,
+		RetryPolicy:            &RetryPolicy{InitialInterval: time.Second, MaximumAttempts: 3},
+	}, merged)
+	assert.Nil(t, base.RetryPolicy)
+}
+
+func TestWithActivityOptionOverrides(t *testing.T) {
+	ctx := WithActivityOptions(background, ActivityOptions{
+		TaskList:               "base-tl",
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+		HeartbeatTimeout:       10 * time.Second,
+	})
+	overridden := WithActivityOptionOverrides(ctx, ActivityOptions{StartToCloseTimeout: time.Hour, ActivityID: "id"})
+
+	eap := getActivityOptions(overridden)
+	assert.Equal(t, "base-tl", eap.TaskListName)
+	assert.Equal(t, int32(60), eap.ScheduleToStartTimeoutSeconds)
+	assert.Equal(t, int32(3600), eap.StartToCloseTimeoutSeconds)
+	assert.Equal(t, int32(10), eap.HeartbeatTimeoutSeconds)
+	assert.Equal(t, "id", *eap.ActivityID)
+
+	// the original context is not modified
+	assert.Equal(t, int32(60), getActivityOptions(ctx).StartToCloseTimeoutSeconds)
+}
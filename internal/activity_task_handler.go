@@ -54,6 +54,7 @@ type (
 		tracer             opentracing.Tracer
 		featureFlags       FeatureFlags
 		activityTracker    debug.ActivityTracker
+		payloadRedactor    PayloadRedactor
 	}
 )
 
@@ -62,7 +63,7 @@ func newActivityTaskHandler(
 	params workerExecutionParameters,
 	registry *registry,
 ) ActivityTaskHandler {
-	return newActivityTaskHandlerWithCustomProvider(service, params, registry, nil, clockwork.NewRealClock())
+	return newActivityTaskHandlerWithCustomProvider(service, params, registry, nil, getWorkerClock(params.WorkerOptions))
 }
 
 func newActivityTaskHandlerWithCustomProvider(
@@ -94,6 +95,7 @@ func newActivityTaskHandlerWithCustomProvider(
 		tracer:             params.Tracer,
 		featureFlags:       params.FeatureFlags,
 		activityTracker:    params.WorkerStats.ActivityTracker,
+		payloadRedactor:    params.PayloadRedactor,
 	}
 }
 
@@ -115,7 +117,7 @@ func (ath *activityTaskHandlerImpl) Execute(taskList string, t *s.PollForActivit
 
 	workflowType := t.WorkflowType.GetName()
 	activityType := t.ActivityType.GetName()
-	invoker := newServiceInvoker(t.TaskToken, ath.identity, ath.service, cancel, t.GetHeartbeatTimeoutSeconds(), ath.workerStopCh, ath.featureFlags, ath.logger, workflowType, activityType)
+	invoker := newServiceInvoker(t.TaskToken, ath.identity, ath.service, cancel, t.GetHeartbeatTimeoutSeconds(), ath.workerStopCh, ath.featureFlags, ath.logger, workflowType, activityType, ath.clock)
 	defer func() {
 		_, activityCompleted := result.(*s.RespondActivityTaskCompletedRequest)
 		invoker.Close(!activityCompleted) // flush buffered heartbeat if activity was not successfully completed.
@@ -140,7 +142,7 @@ func (ath *activityTaskHandlerImpl) Execute(taskList string, t *s.PollForActivit
 				zap.String(tagWorkflowID, t.WorkflowExecution.GetWorkflowId()),
 				zap.String(tagRunID, t.WorkflowExecution.GetRunId()),
 				zap.String(tagActivityType, activityType),
-				zap.String(tagPanicError, fmt.Sprintf("%v", p)),
+				zap.String(tagPanicError, redactPayload(ath.payloadRedactor, fmt.Sprintf("%v", p))),
 				zap.String(tagPanicStack, st))
 			metricsScope.Counter(metrics.ActivityTaskPanicCounter).Inc(1)
 			panicErr := newPanicError(p, st)
@@ -157,6 +159,9 @@ func (ath *activityTaskHandlerImpl) Execute(taskList string, t *s.PollForActivit
 	}
 
 	info := ctx.Value(activityEnvContextKey).(*activityEnvironment)
+	if info.headers, err = readWorkflowHeadersHeader(t.Header); err != nil {
+		return nil, fmt.Errorf("unable to read workflow headers %w", err)
+	}
 	ctx, dlCancelFunc := context.WithDeadline(ctx, info.deadline)
 	defer dlCancelFunc()
 
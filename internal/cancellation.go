@@ -0,0 +1,116 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+)
+
+// CancellationSource is what requested the cancellation of a workflow or an activity.
+type CancellationSource int
+
+const (
+	// CancellationSourceUnknown is used when the source of the cancellation could not be determined.
+	CancellationSourceUnknown CancellationSource = iota
+	// CancellationSourceClient means the workflow cancellation was requested through Client.CancelWorkflow.
+	CancellationSourceClient
+	// CancellationSourceWorkflow means the cancellation was requested by a workflow: the workflow cancellation by
+	// another workflow with RequestCancelExternalWorkflow or by canceling the context of a child workflow, the
+	// activity cancellation by the workflow executing the activity.
+	CancellationSourceWorkflow
+	// CancellationSourceParentClosePolicy means the workflow cancellation was requested because its parent closed
+	// with ParentClosePolicyRequestCancel.
+	CancellationSourceParentClosePolicy
+	// CancellationSourceWorkflowClosed means the activity was canceled because its workflow was closed or could not
+	// be found.
+	CancellationSourceWorkflowClosed
+)
+
+// CancellationDetails describes why a workflow or an activity was canceled.
+type CancellationDetails struct {
+	// Source is what requested the cancellation.
+	Source CancellationSource
+	// Cause is the reason given to the cancellation request, see WithCancelReason.
+	Cause string
+	// Identity is the identity of the requester.
+	Identity string
+	// RequestID is the ID of the cancellation request.
+	RequestID string
+	// ExternalWorkflowExecution is the workflow which requested the cancellation, for the
+	// CancellationSourceWorkflow and CancellationSourceParentClosePolicy sources of workflow cancellations.
+	ExternalWorkflowExecution *WorkflowExecution
+}
+
+// String returns the name of the source.
+func (c CancellationSource) String() string {
+	switch c {
+	case CancellationSourceUnknown:
+		return "Unknown"
+	case CancellationSourceClient:
+		return "Client"
+	case CancellationSourceWorkflow:
+		return "Workflow"
+	case CancellationSourceParentClosePolicy:
+		return "ParentClosePolicy"
+	case CancellationSourceWorkflowClosed:
+		return "WorkflowClosed"
+	}
+	return "Unknown"
+}
+
+// GetCancellationDetails returns why the workflow was canceled, or nil if its cancellation was not requested.
+// It is typically called once ctx.Done() is ready, to branch the compensation logic on the source of the
+// cancellation.
+func GetCancellationDetails(ctx Context) *CancellationDetails {
+	return getWorkflowEnvironment(ctx).GetCancellationDetails()
+}
+
+// GetActivityCancellationDetails returns why the activity was canceled, or nil if it was not canceled by a
+// heartbeat response. A timed out activity has its context done with context.DeadlineExceeded instead.
+func GetActivityCancellationDetails(ctx context.Context) *CancellationDetails {
+	if invoker, ok := getActivityEnv(ctx).serviceInvoker.(*cadenceInvoker); ok {
+		return invoker.getCancellationDetails()
+	}
+	return nil
+}
+
+// newWorkflowCancellationDetails returns the details of a WorkflowExecutionCancelRequested event. The server
+// requests the cancellation on behalf of the parent workflow without an initiating event when applying the parent
+// close policy.
+func newWorkflowCancellationDetails(attributes *s.WorkflowExecutionCancelRequestedEventAttributes) *CancellationDetails {
+	details := &CancellationDetails{
+		Source:    CancellationSourceClient,
+		Cause:     attributes.GetCause(),
+		Identity:  attributes.GetIdentity(),
+		RequestID: attributes.GetRequestId(),
+	}
+	if external := attributes.ExternalWorkflowExecution; external != nil {
+		details.ExternalWorkflowExecution = &WorkflowExecution{ID: external.GetWorkflowId(), RunID: external.GetRunId()}
+		if attributes.GetExternalInitiatedEventId() > 0 {
+			details.Source = CancellationSourceWorkflow
+		} else {
+			details.Source = CancellationSourceParentClosePolicy
+		}
+	}
+	return details
+}
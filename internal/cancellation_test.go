@@ -0,0 +1,139 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestNewWorkflowCancellationDetails(t *testing.T) {
+	external := &s.WorkflowExecution{WorkflowId: common.StringPtr("parent-id"), RunId: common.StringPtr("parent-run-id")}
+	tests := map[string]struct {
+		attributes *s.WorkflowExecutionCancelRequestedEventAttributes
+		expected   *CancellationDetails
+	}{
+		"client": {
+			attributes: &s.WorkflowExecutionCancelRequestedEventAttributes{
+				Cause:     common.StringPtr("reason"),
+				Identity:  common.StringPtr("operator"),
+				RequestId: common.StringPtr("request-id"),
+			},
+			expected: &CancellationDetails{
+				Source:    CancellationSourceClient,
+				Cause:     "reason",
+				Identity:  "operator",
+				RequestID: "request-id",
+			},
+		},
+		"workflow": {
+			attributes: &s.WorkflowExecutionCancelRequestedEventAttributes{
+				ExternalInitiatedEventId:  common.Int64Ptr(5),
+				ExternalWorkflowExecution: external,
+			},
+			expected: &CancellationDetails{
+				Source:                    CancellationSourceWorkflow,
+				ExternalWorkflowExecution: &WorkflowExecution{ID: "parent-id", RunID: "parent-run-id"},
+			},
+		},
+		"parent close policy": {
+			attributes: &s.WorkflowExecutionCancelRequestedEventAttributes{
+				Identity:                  common.StringPtr("history-service"),
+				ExternalWorkflowExecution: external,
+			},
+			expected: &CancellationDetails{
+				Source:                    CancellationSourceParentClosePolicy,
+				Identity:                  "history-service",
+				ExternalWorkflowExecution: &WorkflowExecution{ID: "parent-id", RunID: "parent-run-id"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, newWorkflowCancellationDetails(tt.attributes))
+		})
+	}
+}
+
+func TestGetCancellationDetails(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	t.Run("client", func(t *testing.T) {
+		var details *CancellationDetails
+		workflowFn := func(ctx Context) error {
+			if GetCancellationDetails(ctx) != nil {
+				return NewCustomError("unexpected cancellation details")
+			}
+			err := Sleep(ctx, time.Hour)
+			details = GetCancellationDetails(ctx)
+			return err
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterDelayedCallback(func() {
+			env.CancelWorkflowWithDetails(CancellationDetails{Source: CancellationSourceClient, Cause: "incident", Identity: "operator"})
+		}, time.Minute)
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.True(t, IsCanceledError(env.GetWorkflowError()))
+		assert.Equal(t, &CancellationDetails{Source: CancellationSourceClient, Cause: "incident", Identity: "operator"}, details)
+	})
+
+	t.Run("parent", func(t *testing.T) {
+		var details *CancellationDetails
+		childFn := func(ctx Context) error {
+			err := Sleep(ctx, time.Hour)
+			details = GetCancellationDetails(ctx)
+			return err
+		}
+		parentFn := func(ctx Context) error {
+			ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
+				WorkflowID:                   "child-id",
+				ExecutionStartToCloseTimeout: 2 * time.Hour,
+				WaitForCancellation:          true,
+			})
+			childCtx, cancel := WithCancel(ctx)
+			future := ExecuteChildWorkflow(childCtx, childFn)
+			if err := Sleep(ctx, time.Minute); err != nil {
+				return err
+			}
+			cancel()
+			err := future.Get(ctx, nil)
+			if !IsCanceledError(err) {
+				return NewCustomError("expected child to be canceled")
+			}
+			return nil
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterWorkflow(childFn)
+		env.ExecuteWorkflow(parentFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		require.NotNil(t, details)
+		assert.Equal(t, CancellationSourceWorkflow, details.Source)
+		assert.Equal(t, defaultTestWorkflowID, details.ExternalWorkflowExecution.ID)
+	})
+}
@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/opentracing/opentracing-go"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
@@ -49,14 +50,48 @@ const (
 	// QueryTypeQueryTypes is the build in query type for Client.QueryWorkflow() call. Use this query type to list
 	// all query types of the workflow. The result will be a string encoded in the EncodedValue.
 	QueryTypeQueryTypes string = "__query_types"
+
+	// QueryTypeWorkflowState is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the key/value state set by workflow.SetState. The result will be a map of key to value encoded in the
+	// EncodedValue. It is only available once the workflow has called workflow.SetState.
+	QueryTypeWorkflowState string = "__workflow_state"
+
+	// QueryTypeUnhandledSignals is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the signals which were sent to the workflow but not received yet. The result will be a map of signal name to the
+	// number of pending signals encoded in the EncodedValue. Querying a closed workflow returns the signals it was
+	// closed with.
+	QueryTypeUnhandledSignals string = "__unhandled_signals"
+
+	// QueryTypeVersions is the build in query type for Client.QueryWorkflow() call. Use this query type to get the
+	// versions the workflow chose with workflow.GetVersion. The result will be a map of change ID to Version encoded in
+	// the EncodedValue. GetVersionReport aggregates it across workflow executions.
+	QueryTypeVersions string = "__versions"
+
+	// QueryTypeExecutionProfile is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the ExecutionProfile of the workflow, i.e. its decision count and the durations and retries of its activities.
+	// The result will be an ExecutionProfile encoded in the EncodedValue. It is only available on workers with
+	// WorkerOptions.EnableExecutionProfile.
+	QueryTypeExecutionProfile string = "__execution_profile"
+
+	// QueryTypeYieldDiagnostics is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the YieldDiagnostics of the workflow, i.e. why its coroutines blocked during its last decision task. The result
+	// will be a YieldDiagnostics encoded in the EncodedValue. It is only available on workers with
+	// WorkerOptions.EnableYieldDiagnostics.
+	QueryTypeYieldDiagnostics string = "__yield_diagnostics"
 )
 
+// CancelActivitySignalName is the conventional name of the signal asking a workflow to cancel one of its pending
+// activities. The signal input is the activity ID, as returned by Client.ListPendingActivities.
+const CancelActivitySignalName = "cadence-cancel-activity"
+
 // BuiltinQueryTypes returns a list of built-in query types
 func BuiltinQueryTypes() []string {
 	return []string{
 		QueryTypeOpenSessions,
 		QueryTypeStackTrace,
 		QueryTypeQueryTypes,
+		QueryTypeUnhandledSignals,
+		QueryTypeVersions,
 	}
 }
 
@@ -102,6 +137,20 @@ type (
 		//	- InternalServiceError
 		StartWorkflowAsync(ctx context.Context, options StartWorkflowOptions, workflow interface{}, args ...interface{}) (*WorkflowExecutionAsync, error)
 
+		// StartWorkflowIfNotRunning behaves like StartWorkflow, except that when a run with the same workflow ID is
+		// already running, the execution of that run is returned instead of a WorkflowExecutionAlreadyStartedError.
+		// The run of the error is described to verify that it is still running.
+		// This is meant for at-least-once producers, which may attempt the same start more than once.
+		// Set StartWorkflowOptions.RequestID to a stable value to also deduplicate starts of runs which have
+		// already completed.
+		// The errors it can return:
+		//	- EntityNotExistsError, if domain does not exists
+		//	- BadRequestError
+		//	- WorkflowExecutionAlreadyStartedError, if the workflow ID is reused against the WorkflowIDReusePolicy
+		//	  of a closed run
+		//	- InternalServiceError
+		StartWorkflowIfNotRunning(ctx context.Context, options StartWorkflowOptions, workflow interface{}, args ...interface{}) (*WorkflowExecution, error)
+
 		// ExecuteWorkflow starts a workflow execution and return a WorkflowRun instance and error
 		// The user can use this to start using a function or workflow type name.
 		// Either by
@@ -199,6 +248,22 @@ type (
 		//	- WorkflowExecutionAlreadyCompletedError
 		TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details []byte) error
 
+		// StopWorkflow stops a workflow execution following the usual operational runbook: by default it requests
+		// cancellation, waits up to options.TerminateAfter for the workflow to honor it, and terminates it otherwise.
+		// options.Mode can also only cancel or only terminate the workflow. options.Reason is a text/template
+		// rendered with the workflow identity and options.Operator, which is also sent in the "cadence-operator"
+		// header of the requests. A workflow which already closed is stopped successfully when requesting its
+		// cancellation, and a workflow whose cancellation was already requested is still terminated when it does
+		// not close in time.
+		// - workflow ID of the workflow.
+		// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
+		// The errors it can return:
+		//	- EntityNotExistsError
+		//	- BadRequestError
+		//	- InternalServiceError
+		//	- WorkflowExecutionAlreadyCompletedError, with StopWorkflowModeTerminate
+		StopWorkflow(ctx context.Context, workflowID string, runID string, options StopWorkflowOptions) error
+
 		// GetWorkflowHistory gets history events of a particular workflow
 		// - workflow ID of the workflow.
 		// - runID can be default(empty string). if empty string then it will pick the last running execution of that workflow ID.
@@ -219,6 +284,48 @@ type (
 		//		}
 		GetWorkflowHistory(ctx context.Context, workflowID string, runID string, isLongPoll bool, filterType s.HistoryEventFilterType) HistoryEventIterator
 
+		// WatchWorkflow returns a channel of the lifecycle events of a workflow execution: started, decision completed,
+		// activity scheduled/completed, signaled and closed. The events are read with history long polls, so the
+		// workflow can be followed without polling DescribeWorkflowExecution.
+		// - workflow ID of the workflow.
+		// - options.RunID can be default(empty string). if empty string then it will pick the current run of that workflow ID.
+		// - options.ResumeToken continues a previous watch after the event which carried the token.
+		// The channel is closed after the WorkflowEventClosed event, when ctx is done, or after an event with Err set.
+		// Example:-
+		//	events, err := WatchWorkflow(ctx, workflowID, WatchWorkflowOptions{})
+		//	if err != nil {
+		//		return err
+		//	}
+		//	for event := range events {
+		//		if event.Err != nil {
+		//			return event.Err
+		//		}
+		//		// handle event, and save event.ResumeToken to continue from it later
+		//	}
+		// The errors it can return:
+		//  - EntityNotExistsError
+		//  - BadRequestError
+		//  - InternalServiceError
+		WatchWorkflow(ctx context.Context, workflowID string, options WatchWorkflowOptions) (<-chan WorkflowEvent, error)
+
+		// OnWorkflowCompletion calls callback with the outcome of the workflow execution once it is closed, following
+		// the runs it continues as new to. The execution is awaited with history long polls shared by all the
+		// executions awaited through the client, see ClientOptions.MaxConcurrentCompletionPolls, so services
+		// awaiting many workflows do not need a goroutine blocked on WorkflowRun.Get for each of them.
+		// - workflow ID of the workflow.
+		// - runID can be default(empty string). if empty string then it will pick the current run of that workflow ID.
+		// The completion is delivered at least once: polls failing with transient errors are retried, and the
+		// completion is delivered again when callback returns an error. Awaiting stops when ctx is done.
+		// Example:-
+		//	err := OnWorkflowCompletion(ctx, workflowID, "", func(completion WorkflowCompletion) error {
+		//		var result string
+		//		if err := completion.Get(&result); err != nil {
+		//			return markFailed(completion.WorkflowID, err)
+		//		}
+		//		return saveResult(completion.WorkflowID, result)
+		//	})
+		OnWorkflowCompletion(ctx context.Context, workflowID string, runID string, callback WorkflowCompletionCallback) error
+
 		// CompleteActivity reports activity completed.
 		// activity Execute method can return acitivity.activity.ErrResultPending to
 		// indicate the activity is not completed when it's Execute method returns. In that case, this CompleteActivity() method
@@ -367,6 +474,31 @@ type (
 		//  - EntityNotExistError
 		DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*s.DescribeWorkflowExecutionResponse, error)
 
+		// ListPendingActivities returns the activities of a workflow execution which are scheduled or running, as
+		// reported by DescribeWorkflowExecution, e.g. to find activities stuck on a third party call.
+		// Cadence cannot cancel a single activity from outside of the workflow; see CancelActivitySignalName for a
+		// recipe to let operators cancel them.
+		// - workflow ID of the workflow.
+		// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
+		// The errors it can return:
+		//  - BadRequestError
+		//  - InternalServiceError
+		//  - EntityNotExistError
+		ListPendingActivities(ctx context.Context, workflowID, runID string) ([]*s.PendingActivityInfo, error)
+
+		// GetDecisionTaskFailure returns the failure of the pending decision task of a workflow execution while it
+		// keeps failing, with the decision and the workflow code which caused it when they are known, or nil if the
+		// pending decision task, if any, is on its first attempt. The server records only the first failed attempt
+		// of a decision task in the history, so the failure describes that attempt, with the attempt of the pending
+		// decision task reported by DescribeWorkflowExecution.
+		// - workflow ID of the workflow.
+		// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
+		// The errors it can return:
+		//  - BadRequestError
+		//  - InternalServiceError
+		//  - EntityNotExistError
+		GetDecisionTaskFailure(ctx context.Context, workflowID, runID string) (*DecisionTaskFailure, error)
+
 		// DescribeTaskList returns information about the target tasklist, right now this API returns the
 		// pollers which polled this tasklist in last few minutes.
 		// The errors it can return:
@@ -384,6 +516,15 @@ type (
 		//  - ServiceBusyError
 		//  - EntityNotExistError
 		RefreshWorkflowTasks(ctx context.Context, workflowID, runID string) error
+
+		// GetClusterInfo returns the client versions supported by the Cadence cluster and the optional features it
+		// provides to the client's domain, so callers can adapt to the cluster instead of failing at runtime,
+		// e.g. fall back from ListWorkflow to ListOpenWorkflow without advanced visibility.
+		// Capabilities are discovered by probing the service, so the call makes one request per known capability.
+		// The errors it can return:
+		//  - InternalServiceError
+		//  - ServiceBusyError
+		GetClusterInfo(ctx context.Context) (*ClusterInfo, error)
 	}
 
 	// ClientOptions are optional parameters for Client creation.
@@ -396,6 +537,49 @@ type (
 		ContextPropagators []ContextPropagator
 		FeatureFlags       FeatureFlags
 		Authorization      auth.AuthorizationProvider
+
+		// Clock is the source of wall-clock time used by the client, e.g. to wait between retries of failed
+		// requests. Tests can use a fake clock like clockwork.NewFakeClock().
+		// Optional: defaulted to the system clock.
+		Clock clockwork.Clock
+
+		// NamingPolicies constrain the workflow IDs, signal names and query types used by the client.
+		// Optional: default accepts any name.
+		NamingPolicies NamingPolicies
+
+		// MaxConcurrentCompletionPolls is the number of long polls OnWorkflowCompletion keeps open at most. When
+		// more executions are awaited, the polls rotate through them, each lasting up to a minute.
+		// Optional: defaulted to 10.
+		MaxConcurrentCompletionPolls int
+
+		// Connection configures the connection DialService opens to the Cadence frontend. It is not used by
+		// clients created with an existing service.
+		// Optional: default connects directly to the frontend.
+		Connection ConnectionOptions
+	}
+
+	// ClusterInfo describes the Cadence cluster a client is connected to, see Client.GetClusterInfo.
+	ClusterInfo struct {
+		// SupportedGoSDKVersion is the range of go.uber.org/cadence versions accepted by the cluster, e.g. ">=1.0.0 <2.0.0".
+		// Empty if the cluster does not report it.
+		SupportedGoSDKVersion string
+
+		// SupportedJavaSDKVersion is the range of Java client versions accepted by the cluster.
+		// Empty if the cluster does not report it.
+		SupportedJavaSDKVersion string
+
+		// Capabilities are the optional features the cluster provides to the client's domain.
+		Capabilities []ServiceCapability
+
+		// BlobSizeLimit is the size in bytes above which the cluster rejects a payload, e.g. workflow or activity
+		// input and results, signal arguments or heartbeat details. Payloads close to it are better offloaded.
+		// The cluster does not report its limits, so this is the Cadence server default which operators may have
+		// changed with dynamic config.
+		BlobSizeLimit int
+
+		// BlobSizeWarnLimit is the size in bytes above which the cluster logs a warning about a payload.
+		// Like BlobSizeLimit, this is the Cadence server default.
+		BlobSizeWarnLimit int
 	}
 
 	// StartWorkflowOptions configuration parameters for starting a workflow execution.
@@ -406,6 +590,12 @@ type (
 		// Optional: defaulted to a uuid.
 		ID string
 
+		// RequestID - The idempotency token of the start request. Start requests which carry the same RequestID
+		// for the same workflow ID are deduplicated by the server, and the run which was already started is
+		// returned instead of an error. Set this when the same start may be attempted more than once.
+		// Optional: defaulted to a uuid.
+		RequestID string
+
 		// TaskList - The decisions of the workflow are scheduled on this queue.
 		// This is also the default task list on which activities are scheduled. The workflow author can choose
 		// to override this using activity options.
@@ -471,6 +661,23 @@ type (
 		// This will only be used and override DelayStart and JitterStart if provided in the first run
 		// Optional: defaulted to Unix epoch time
 		FirstRunAt time.Time
+
+		// DefaultActivityOptions - Activity options applied inside the workflow to every activity which leaves the
+		// corresponding field unset, so timeouts can be tuned per deployment without code changes. ActivityID is
+		// ignored. The defaults are inherited by continued-as-new runs and child workflows.
+		// Optional: defaulted to no default activity options
+		DefaultActivityOptions *ActivityOptions
+
+		// DefaultLocalActivityOptions - Local activity options applied inside the workflow to every local activity
+		// which leaves the corresponding field unset. Inherited like DefaultActivityOptions.
+		// Optional: defaulted to no default local activity options
+		DefaultLocalActivityOptions *LocalActivityOptions
+
+		// Headers - Static key-value metadata of the execution, e.g. the tenant or the caller, readable by the workflow
+		// with workflow.GetHeaders and by its activities with activity.GetHeaders, without implementing a
+		// ContextPropagator. The headers are inherited by continued-as-new runs and child workflows.
+		// Optional: defaulted to no headers
+		Headers map[string]string
 	}
 
 	// RetryPolicy defines the retry policy.
@@ -583,6 +790,13 @@ func getFeatureFlags(options *ClientOptions) FeatureFlags {
 	return FeatureFlags{}
 }
 
+func getClock(options *ClientOptions) clockwork.Clock {
+	if options != nil && options.Clock != nil {
+		return options.Clock
+	}
+	return clockwork.NewRealClock()
+}
+
 // NewClient creates an instance of a workflow client
 func NewClient(service workflowserviceclient.Interface, domain string, options *ClientOptions) Client {
 	var identity string
@@ -620,7 +834,7 @@ func NewClient(service workflowserviceclient.Interface, domain string, options *
 		service = isolationgroup.NewWorkflowServiceWrapper(service, options.IsolationGroup)
 	}
 	service = metrics.NewWorkflowServiceWrapper(service, metricScope)
-	return &workflowClient{
+	client := &workflowClient{
 		workflowService:    service,
 		domain:             domain,
 		registry:           newRegistry(),
@@ -630,7 +844,15 @@ func NewClient(service workflowserviceclient.Interface, domain string, options *
 		contextPropagators: contextPropagators,
 		tracer:             tracer,
 		featureFlags:       getFeatureFlags(options),
+		clock:              getClock(options),
+		namingPolicies:     getNamingPolicies(options),
+	}
+	var completionConcurrency int
+	if options != nil {
+		completionConcurrency = options.MaxConcurrentCompletionPolls
 	}
+	client.completions = newCompletionListener(client, completionConcurrency)
+	return client
 }
 
 // NewDomainClient creates an instance of a domain client, to manager lifecycle of domains.
@@ -26,6 +26,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/jonboulle/clockwork"
+
 	s "go.uber.org/cadence/.gen/go/shared"
 )
 
@@ -91,10 +93,15 @@ func NewConcurrentRetrier(retryPolicy RetryPolicy) *ConcurrentRetrier {
 
 // Retry function can be used to wrap any call with retry logic using the passed in policy
 func Retry(ctx context.Context, operation Operation, policy RetryPolicy, isRetriable IsRetryable) error {
+	return RetryWithClock(ctx, clockwork.NewRealClock(), operation, policy, isRetriable)
+}
+
+// RetryWithClock is Retry using the passed in clock to measure the elapsed time and to wait between attempts
+func RetryWithClock(ctx context.Context, clock clockwork.Clock, operation Operation, policy RetryPolicy, isRetriable IsRetryable) error {
 	var err error
 	var next time.Duration
 
-	r := NewRetrier(policy, SystemClock)
+	r := NewRetrier(policy, clock)
 Retry_Loop:
 	for {
 		// operation completed successfully.  No need to retry.
@@ -136,18 +143,18 @@ Retry_Loop:
 			//
 			// this could probably be changed if we get requests for it, but for now it better-protects
 			// the server by preventing "external" retry storms.
-			timer := time.NewTimer(next)
+			timer := clock.NewTimer(next)
 			select {
 			case <-ctxDone:
 				timer.Stop()
 				return err
-			case <-timer.C:
+			case <-timer.Chan():
 				continue Retry_Loop
 			}
 		}
 
 		// ctx is not cancellable
-		time.Sleep(next)
+		clock.Sleep(next)
 	}
 }
 
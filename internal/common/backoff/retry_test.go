@@ -26,6 +26,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/assert"
 
 	"go.uber.org/cadence/.gen/go/shared"
@@ -115,6 +116,41 @@ func TestRetry(t *testing.T) {
 	}
 }
 
+func TestRetryWithClock(t *testing.T) {
+	t.Parallel()
+
+	clock := clockwork.NewFakeClock()
+	policy := NewExponentialRetryPolicy(time.Hour)
+	policy.SetMaximumInterval(time.Hour)
+	policy.SetExpirationInterval(NoInterval)
+	policy.SetMaximumAttempts(2)
+
+	calls := 0
+	op := func() error {
+		calls++
+		return &someError{}
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- RetryWithClock(context.Background(), clock, op, policy, func(error) bool { return true })
+	}()
+
+	// every backoff waits on the fake clock, so the hour-long intervals elapse instantly.
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Hour)
+	}
+
+	select {
+	case err := <-errCh:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("retry did not complete after advancing the fake clock")
+	}
+	assert.Equal(t, 3, calls, "max 2 retries == 3 calls")
+}
+
 func TestConcurrentRetrier(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
@@ -0,0 +1,303 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+type (
+	// SpanKind is the kind of operation a Span was spent on.
+	SpanKind int
+
+	// Span is a period of time spent on a single operation of the workflow.
+	Span struct {
+		Kind SpanKind
+		// Name is the activity type, timer ID, child workflow type, signal name or event type of the operation.
+		Name         string
+		Start        time.Time
+		End          time.Time
+		StartEventID int64
+		EndEventID   int64
+	}
+
+	// TimeBreakdown splits the time between the first and the last event of a history by the kind of operations on
+	// its critical path.
+	TimeBreakdown struct {
+		Total  time.Duration
+		ByKind map[SpanKind]time.Duration
+		// Unaccounted is the part of Total which is not covered by the critical path, e.g. the latency between an
+		// activity completing and the next decision task being scheduled.
+		Unaccounted time.Duration
+	}
+)
+
+const (
+	// SpanKindDecisionTask is the time from scheduling a decision task to closing it.
+	SpanKindDecisionTask SpanKind = iota
+	// SpanKindActivity is the time from scheduling an activity to closing it, including its retries.
+	SpanKindActivity
+	// SpanKindTimer is the time from starting a timer to firing it.
+	SpanKindTimer
+	// SpanKindChildWorkflow is the time from initiating a child workflow to closing it.
+	SpanKindChildWorkflow
+	// SpanKindSignal is the time the workflow waited for a signal after its previous decision task.
+	SpanKindSignal
+	// SpanKindOther is the time the workflow waited for any other operation or external event.
+	SpanKindOther
+	// SpanKindWorkflow is the time from the start of the workflow to its last event.
+	SpanKindWorkflow
+)
+
+// String returns the name of the span kind.
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindDecisionTask:
+		return "DecisionTask"
+	case SpanKindActivity:
+		return "Activity"
+	case SpanKindTimer:
+		return "Timer"
+	case SpanKindChildWorkflow:
+		return "ChildWorkflow"
+	case SpanKindSignal:
+		return "Signal"
+	case SpanKindOther:
+		return "Other"
+	case SpanKindWorkflow:
+		return "Workflow"
+	default:
+		return fmt.Sprintf("SpanKind(%d)", int(k))
+	}
+}
+
+// Duration returns the length of the span.
+func (s Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// CriticalPath returns the chain of operations which determined when the last event of the history happened, in
+// chronological order. It follows the last event back to the decision task which produced it, that decision task back to
+// the event which caused it to be scheduled (e.g. an activity completion), that event back to the decision task which
+// scheduled the operation, and so on until the start of the workflow.
+func CriticalPath(events []*shared.HistoryEvent) []Span {
+	if len(events) == 0 {
+		return nil
+	}
+	byID := make(map[int64]*shared.HistoryEvent, len(events))
+	for _, event := range events {
+		byID[event.GetEventId()] = event
+	}
+
+	var path []Span
+	addSpan := func(kind SpanKind, name string, start, end *shared.HistoryEvent) {
+		path = append(path, Span{
+			Kind:         kind,
+			Name:         name,
+			Start:        EventTime(start),
+			End:          EventTime(end),
+			StartEventID: start.GetEventId(),
+			EndEventID:   end.GetEventId(),
+		})
+	}
+
+	current := events[len(events)-1]
+	for current != nil {
+		var previous *shared.HistoryEvent
+		switch current.GetEventType() {
+		case shared.EventTypeWorkflowExecutionStarted:
+			// reached the start of the workflow
+
+		case shared.EventTypeDecisionTaskCompleted,
+			shared.EventTypeDecisionTaskFailed,
+			shared.EventTypeDecisionTaskTimedOut:
+			if scheduled := byID[decisionTaskScheduledEventID(current)]; scheduled != nil {
+				addSpan(SpanKindDecisionTask, "", scheduled, current)
+				// the event recorded right before a decision task is the one which caused it to be scheduled
+				previous = byID[scheduled.GetEventId()-1]
+			}
+
+		case shared.EventTypeActivityTaskCompleted,
+			shared.EventTypeActivityTaskFailed,
+			shared.EventTypeActivityTaskTimedOut,
+			shared.EventTypeActivityTaskCanceled:
+			if scheduled := byID[activityScheduledEventID(current)]; scheduled != nil {
+				addSpan(SpanKindActivity, scheduled.ActivityTaskScheduledEventAttributes.GetActivityType().GetName(), scheduled, current)
+				previous = byID[scheduled.ActivityTaskScheduledEventAttributes.GetDecisionTaskCompletedEventId()]
+			}
+
+		case shared.EventTypeTimerFired:
+			if started := byID[current.TimerFiredEventAttributes.GetStartedEventId()]; started != nil {
+				addSpan(SpanKindTimer, started.TimerStartedEventAttributes.GetTimerId(), started, current)
+				previous = byID[started.TimerStartedEventAttributes.GetDecisionTaskCompletedEventId()]
+			}
+
+		case shared.EventTypeExternalWorkflowExecutionSignaled:
+			if initiated := byID[current.ExternalWorkflowExecutionSignaledEventAttributes.GetInitiatedEventId()]; initiated != nil {
+				addSpan(SpanKindOther, initiated.GetEventType().String(), initiated, current)
+				previous = initiated
+			}
+
+		case shared.EventTypeExternalWorkflowExecutionCancelRequested:
+			if initiated := byID[current.ExternalWorkflowExecutionCancelRequestedEventAttributes.GetInitiatedEventId()]; initiated != nil {
+				addSpan(SpanKindOther, initiated.GetEventType().String(), initiated, current)
+				previous = initiated
+			}
+
+		default:
+			if initiatedEventID, ok := childInitiatedEventID(current); ok {
+				if initiated := byID[initiatedEventID]; initiated != nil {
+					attributes := initiated.StartChildWorkflowExecutionInitiatedEventAttributes
+					addSpan(SpanKindChildWorkflow, attributes.GetWorkflowType().GetName(), initiated, current)
+					previous = byID[attributes.GetDecisionTaskCompletedEventId()]
+				}
+			} else if decisionTaskCompletedEventID, ok := decisionTaskCompletedEventID(current); ok {
+				// produced by a decision, which is where the time went
+				previous = byID[decisionTaskCompletedEventID]
+			} else {
+				// caused outside of the workflow, so the workflow was waiting for it since its last decision
+				previous = lastDecisionTaskCompletedBefore(events, current.GetEventId())
+				if previous != nil {
+					kind, name := SpanKindOther, current.GetEventType().String()
+					if current.GetEventType() == shared.EventTypeWorkflowExecutionSignaled {
+						kind, name = SpanKindSignal, current.WorkflowExecutionSignaledEventAttributes.GetSignalName()
+					}
+					addSpan(kind, name, previous, current)
+				}
+			}
+		}
+
+		if previous != nil && previous.GetEventId() >= current.GetEventId() {
+			// malformed history, stop rather than loop forever
+			previous = nil
+		}
+		current = previous
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Breakdown returns how the time between the first and the last event of the history was spent, according to its
+// CriticalPath.
+func Breakdown(events []*shared.HistoryEvent) TimeBreakdown {
+	breakdown := TimeBreakdown{ByKind: make(map[SpanKind]time.Duration)}
+	if len(events) == 0 {
+		return breakdown
+	}
+	breakdown.Total = EventTime(events[len(events)-1]).Sub(EventTime(events[0]))
+
+	var accounted time.Duration
+	for _, span := range CriticalPath(events) {
+		breakdown.ByKind[span.Kind] += span.Duration()
+		accounted += span.Duration()
+	}
+	breakdown.Unaccounted = breakdown.Total - accounted
+	return breakdown
+}
+
+func lastDecisionTaskCompletedBefore(events []*shared.HistoryEvent, eventID int64) *shared.HistoryEvent {
+	var last *shared.HistoryEvent
+	for _, event := range events {
+		if event.GetEventId() >= eventID {
+			break
+		}
+		switch event.GetEventType() {
+		case shared.EventTypeDecisionTaskCompleted, shared.EventTypeWorkflowExecutionStarted:
+			last = event
+		}
+	}
+	return last
+}
+
+func decisionTaskScheduledEventID(event *shared.HistoryEvent) int64 {
+	switch event.GetEventType() {
+	case shared.EventTypeDecisionTaskCompleted:
+		return event.DecisionTaskCompletedEventAttributes.GetScheduledEventId()
+	case shared.EventTypeDecisionTaskFailed:
+		return event.DecisionTaskFailedEventAttributes.GetScheduledEventId()
+	case shared.EventTypeDecisionTaskTimedOut:
+		return event.DecisionTaskTimedOutEventAttributes.GetScheduledEventId()
+	default:
+		return 0
+	}
+}
+
+func activityScheduledEventID(event *shared.HistoryEvent) int64 {
+	switch event.GetEventType() {
+	case shared.EventTypeActivityTaskCompleted:
+		return event.ActivityTaskCompletedEventAttributes.GetScheduledEventId()
+	case shared.EventTypeActivityTaskFailed:
+		return event.ActivityTaskFailedEventAttributes.GetScheduledEventId()
+	case shared.EventTypeActivityTaskTimedOut:
+		return event.ActivityTaskTimedOutEventAttributes.GetScheduledEventId()
+	case shared.EventTypeActivityTaskCanceled:
+		return event.ActivityTaskCanceledEventAttributes.GetScheduledEventId()
+	default:
+		return 0
+	}
+}
+
+func decisionTaskCompletedEventID(event *shared.HistoryEvent) (int64, bool) {
+	switch event.GetEventType() {
+	case shared.EventTypeActivityTaskScheduled:
+		return event.ActivityTaskScheduledEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeActivityTaskCancelRequested:
+		return event.ActivityTaskCancelRequestedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeRequestCancelActivityTaskFailed:
+		return event.RequestCancelActivityTaskFailedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeTimerStarted:
+		return event.TimerStartedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeTimerCanceled:
+		return event.TimerCanceledEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeCancelTimerFailed:
+		return event.CancelTimerFailedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeMarkerRecorded:
+		return event.MarkerRecordedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeStartChildWorkflowExecutionInitiated:
+		return event.StartChildWorkflowExecutionInitiatedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeSignalExternalWorkflowExecutionInitiated:
+		return event.SignalExternalWorkflowExecutionInitiatedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeSignalExternalWorkflowExecutionFailed:
+		return event.SignalExternalWorkflowExecutionFailedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeRequestCancelExternalWorkflowExecutionInitiated:
+		return event.RequestCancelExternalWorkflowExecutionInitiatedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeRequestCancelExternalWorkflowExecutionFailed:
+		return event.RequestCancelExternalWorkflowExecutionFailedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeUpsertWorkflowSearchAttributes:
+		return event.UpsertWorkflowSearchAttributesEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeWorkflowExecutionCompleted:
+		return event.WorkflowExecutionCompletedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeWorkflowExecutionFailed:
+		return event.WorkflowExecutionFailedEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeWorkflowExecutionCanceled:
+		return event.WorkflowExecutionCanceledEventAttributes.GetDecisionTaskCompletedEventId(), true
+	case shared.EventTypeWorkflowExecutionContinuedAsNew:
+		return event.WorkflowExecutionContinuedAsNewEventAttributes.GetDecisionTaskCompletedEventId(), true
+	default:
+		return 0, false
+	}
+}
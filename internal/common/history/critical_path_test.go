@@ -0,0 +1,98 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestCriticalPath(t *testing.T) {
+	at := func(second int) time.Time {
+		return testStart.Add(time.Duration(second) * time.Second)
+	}
+	assert.Equal(t, []Span{
+		{Kind: SpanKindDecisionTask, Start: at(0), End: at(2), StartEventID: 2, EndEventID: 4},
+		{Kind: SpanKindActivity, Name: "activity", Start: at(2), End: at(7), StartEventID: 5, EndEventID: 8},
+		{Kind: SpanKindDecisionTask, Start: at(7), End: at(9), StartEventID: 9, EndEventID: 11},
+		{Kind: SpanKindSignal, Name: "signal", Start: at(9), End: at(13), StartEventID: 11, EndEventID: 12},
+		{Kind: SpanKindDecisionTask, Start: at(13), End: at(15), StartEventID: 13, EndEventID: 15},
+	}, CriticalPath(newTestHistory()))
+}
+
+func TestCriticalPath_TimerAndFailedDecision(t *testing.T) {
+	var events []*shared.HistoryEvent
+	events = append(events, newTestEvent(1, 0, shared.EventTypeWorkflowExecutionStarted, nil))
+	events = append(events, newTestDecisionTask(2, 0)...)
+	events = append(events,
+		newTestEvent(5, 2, shared.EventTypeTimerStarted, func(e *shared.HistoryEvent) {
+			e.TimerStartedEventAttributes = &shared.TimerStartedEventAttributes{
+				TimerId:                      common.StringPtr("1"),
+				DecisionTaskCompletedEventId: common.Int64Ptr(4),
+			}
+		}),
+		newTestEvent(6, 12, shared.EventTypeTimerFired, func(e *shared.HistoryEvent) {
+			e.TimerFiredEventAttributes = &shared.TimerFiredEventAttributes{TimerId: common.StringPtr("1"), StartedEventId: common.Int64Ptr(5)}
+		}),
+		newTestEvent(7, 12, shared.EventTypeDecisionTaskScheduled, nil),
+		newTestEvent(8, 13, shared.EventTypeDecisionTaskStarted, nil),
+		newTestEvent(9, 14, shared.EventTypeDecisionTaskFailed, func(e *shared.HistoryEvent) {
+			e.DecisionTaskFailedEventAttributes = &shared.DecisionTaskFailedEventAttributes{ScheduledEventId: common.Int64Ptr(7)}
+		}),
+	)
+	events = append(events, newTestDecisionTask(10, 20)...)
+
+	path := CriticalPath(events)
+	var kinds []SpanKind
+	for _, span := range path {
+		kinds = append(kinds, span.Kind)
+	}
+	assert.Equal(t, []SpanKind{SpanKindDecisionTask, SpanKindTimer, SpanKindDecisionTask, SpanKindDecisionTask}, kinds)
+	assert.Equal(t, 10*time.Second, path[1].Duration())
+
+	breakdown := Breakdown(events)
+	assert.Equal(t, 22*time.Second, breakdown.Total)
+	assert.Equal(t, 6*time.Second, breakdown.ByKind[SpanKindDecisionTask])
+	assert.Equal(t, 10*time.Second, breakdown.ByKind[SpanKindTimer])
+	// the failed decision task was retried 6s later
+	assert.Equal(t, 6*time.Second, breakdown.Unaccounted)
+}
+
+func TestBreakdown(t *testing.T) {
+	breakdown := Breakdown(newTestHistory())
+	assert.Equal(t, 15*time.Second, breakdown.Total)
+	assert.Equal(t, map[SpanKind]time.Duration{
+		SpanKindDecisionTask: 6 * time.Second,
+		SpanKindActivity:     5 * time.Second,
+		SpanKindSignal:       4 * time.Second,
+	}, breakdown.ByKind)
+	assert.Equal(t, time.Duration(0), breakdown.Unaccounted)
+}
+
+func TestCriticalPath_Empty(t *testing.T) {
+	assert.Nil(t, CriticalPath(nil))
+	assert.Equal(t, time.Duration(0), Breakdown(nil).Total)
+}
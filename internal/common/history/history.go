@@ -0,0 +1,405 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package history contains helpers to analyze the history events of a workflow execution.
+package history
+
+import (
+	"time"
+
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+type (
+	// ActivityState is the state of an activity at the end of the analyzed history.
+	ActivityState int
+
+	// ActivitySummary describes a single scheduled activity.
+	ActivitySummary struct {
+		ActivityID       string
+		ActivityType     string
+		TaskList         string
+		ScheduledEventID int64
+		State            ActivityState
+		// Scheduled, Started and Closed are zero if the activity did not reach the corresponding state.
+		Scheduled time.Time
+		Started   time.Time
+		Closed    time.Time
+		// Attempt is the attempt of the last start of the activity, i.e. the number of retries done by the server.
+		Attempt int32
+		// Identity is the identity of the worker which last started the activity.
+		Identity string
+		// FailureReason is the failure reason of a failed activity, the last failure reason of a timed out one.
+		FailureReason string
+		// TimeoutType is set for a timed out activity.
+		TimeoutType shared.TimeoutType
+	}
+
+	// TimerSummary describes a single started timer.
+	TimerSummary struct {
+		TimerID        string
+		StartedEventID int64
+		Started        time.Time
+		Timeout        time.Duration
+		// Closed is zero if the timer neither fired nor was canceled.
+		Closed   time.Time
+		Fired    bool
+		Canceled bool
+	}
+
+	// Signal describes a single signal received by the workflow.
+	Signal struct {
+		Name     string
+		EventID  int64
+		Received time.Time
+		Input    []byte
+		Identity string
+	}
+
+	// ChildWorkflowSummary describes a single child workflow started by the workflow.
+	ChildWorkflowSummary struct {
+		WorkflowID       string
+		RunID            string
+		WorkflowType     string
+		Domain           string
+		InitiatedEventID int64
+		// CloseEventType is nil while the child is running.
+		CloseEventType *shared.EventType
+		// Initiated, Started and Closed are zero if the child did not reach the corresponding state.
+		Initiated time.Time
+		Started   time.Time
+		Closed    time.Time
+	}
+
+	// DecisionTaskSummary describes a single decision task.
+	DecisionTaskSummary struct {
+		ScheduledEventID int64
+		Attempt          int64
+		// CloseEventType is nil while the decision task is running.
+		CloseEventType *shared.EventType
+		// Scheduled, Started and Closed are zero if the decision task did not reach the corresponding state.
+		Scheduled time.Time
+		Started   time.Time
+		Closed    time.Time
+	}
+)
+
+const (
+	// ActivityStateScheduled means the activity was scheduled but not started.
+	ActivityStateScheduled ActivityState = iota
+	// ActivityStateStarted means the activity was started but not closed.
+	ActivityStateStarted
+	// ActivityStateCompleted means the activity completed successfully.
+	ActivityStateCompleted
+	// ActivityStateFailed means the activity failed.
+	ActivityStateFailed
+	// ActivityStateTimedOut means the activity timed out.
+	ActivityStateTimedOut
+	// ActivityStateCanceled means the activity was canceled.
+	ActivityStateCanceled
+)
+
+// String returns the name of the state.
+func (s ActivityState) String() string {
+	switch s {
+	case ActivityStateScheduled:
+		return "Scheduled"
+	case ActivityStateStarted:
+		return "Started"
+	case ActivityStateCompleted:
+		return "Completed"
+	case ActivityStateFailed:
+		return "Failed"
+	case ActivityStateTimedOut:
+		return "TimedOut"
+	case ActivityStateCanceled:
+		return "Canceled"
+	default:
+		return "Unknown"
+	}
+}
+
+// EventTime returns the timestamp of a history event.
+func EventTime(event *shared.HistoryEvent) time.Time {
+	return time.Unix(0, event.GetTimestamp())
+}
+
+// ScheduleToStart returns how long the activity waited in the task list, or 0 if it was not started.
+func (a ActivitySummary) ScheduleToStart() time.Duration {
+	return between(a.Scheduled, a.Started)
+}
+
+// StartToClose returns how long the last attempt of the activity ran, or 0 if it is not closed.
+func (a ActivitySummary) StartToClose() time.Duration {
+	return between(a.Started, a.Closed)
+}
+
+// ScheduleToClose returns how long the activity took including all of its retries, or 0 if it is not closed.
+func (a ActivitySummary) ScheduleToClose() time.Duration {
+	return between(a.Scheduled, a.Closed)
+}
+
+// Duration returns how long the timer was running, or 0 if it is still running.
+func (t TimerSummary) Duration() time.Duration {
+	return between(t.Started, t.Closed)
+}
+
+// Duration returns how long the child workflow took from being initiated to closing, or 0 if it is still running.
+func (c ChildWorkflowSummary) Duration() time.Duration {
+	return between(c.Initiated, c.Closed)
+}
+
+// ScheduleToStart returns how long the decision task waited in the task list, or 0 if it was not started.
+func (d DecisionTaskSummary) ScheduleToStart() time.Duration {
+	return between(d.Scheduled, d.Started)
+}
+
+// StartToClose returns how long the worker took to process the decision task, or 0 if it is not closed.
+func (d DecisionTaskSummary) StartToClose() time.Duration {
+	return between(d.Started, d.Closed)
+}
+
+// Activities returns the summaries of all activities scheduled in the history, in the order they were scheduled.
+func Activities(events []*shared.HistoryEvent) []ActivitySummary {
+	var summaries []ActivitySummary
+	indexes := make(map[int64]int)
+	get := func(scheduledEventID int64) *ActivitySummary {
+		if i, ok := indexes[scheduledEventID]; ok {
+			return &summaries[i]
+		}
+		return nil
+	}
+
+	for _, event := range events {
+		switch event.GetEventType() {
+		case shared.EventTypeActivityTaskScheduled:
+			attributes := event.ActivityTaskScheduledEventAttributes
+			indexes[event.GetEventId()] = len(summaries)
+			summaries = append(summaries, ActivitySummary{
+				ActivityID:       attributes.GetActivityId(),
+				ActivityType:     attributes.GetActivityType().GetName(),
+				TaskList:         attributes.GetTaskList().GetName(),
+				ScheduledEventID: event.GetEventId(),
+				State:            ActivityStateScheduled,
+				Scheduled:        EventTime(event),
+			})
+		case shared.EventTypeActivityTaskStarted:
+			attributes := event.ActivityTaskStartedEventAttributes
+			if a := get(attributes.GetScheduledEventId()); a != nil {
+				a.State = ActivityStateStarted
+				a.Started = EventTime(event)
+				a.Attempt = attributes.GetAttempt()
+				a.Identity = attributes.GetIdentity()
+				a.FailureReason = attributes.GetLastFailureReason()
+			}
+		case shared.EventTypeActivityTaskCompleted:
+			if a := get(event.ActivityTaskCompletedEventAttributes.GetScheduledEventId()); a != nil {
+				a.State = ActivityStateCompleted
+				a.Closed = EventTime(event)
+				a.FailureReason = ""
+			}
+		case shared.EventTypeActivityTaskFailed:
+			attributes := event.ActivityTaskFailedEventAttributes
+			if a := get(attributes.GetScheduledEventId()); a != nil {
+				a.State = ActivityStateFailed
+				a.Closed = EventTime(event)
+				a.FailureReason = attributes.GetReason()
+			}
+		case shared.EventTypeActivityTaskTimedOut:
+			attributes := event.ActivityTaskTimedOutEventAttributes
+			if a := get(attributes.GetScheduledEventId()); a != nil {
+				a.State = ActivityStateTimedOut
+				a.Closed = EventTime(event)
+				a.TimeoutType = attributes.GetTimeoutType()
+				if attributes.IsSetLastFailureReason() {
+					a.FailureReason = attributes.GetLastFailureReason()
+				}
+			}
+		case shared.EventTypeActivityTaskCanceled:
+			if a := get(event.ActivityTaskCanceledEventAttributes.GetScheduledEventId()); a != nil {
+				a.State = ActivityStateCanceled
+				a.Closed = EventTime(event)
+			}
+		}
+	}
+	return summaries
+}
+
+// Timers returns the summaries of all timers started in the history, keyed by timer ID.
+func Timers(events []*shared.HistoryEvent) map[string]TimerSummary {
+	timers := make(map[string]TimerSummary)
+	for _, event := range events {
+		switch event.GetEventType() {
+		case shared.EventTypeTimerStarted:
+			attributes := event.TimerStartedEventAttributes
+			timers[attributes.GetTimerId()] = TimerSummary{
+				TimerID:        attributes.GetTimerId(),
+				StartedEventID: event.GetEventId(),
+				Started:        EventTime(event),
+				Timeout:        time.Duration(attributes.GetStartToFireTimeoutSeconds()) * time.Second,
+			}
+		case shared.EventTypeTimerFired:
+			timerID := event.TimerFiredEventAttributes.GetTimerId()
+			if t, ok := timers[timerID]; ok {
+				t.Fired = true
+				t.Closed = EventTime(event)
+				timers[timerID] = t
+			}
+		case shared.EventTypeTimerCanceled:
+			timerID := event.TimerCanceledEventAttributes.GetTimerId()
+			if t, ok := timers[timerID]; ok {
+				t.Canceled = true
+				t.Closed = EventTime(event)
+				timers[timerID] = t
+			}
+		}
+	}
+	return timers
+}
+
+// Signals returns all signals received by the workflow, in the order they were received.
+func Signals(events []*shared.HistoryEvent) []Signal {
+	var signals []Signal
+	for _, event := range events {
+		if event.GetEventType() != shared.EventTypeWorkflowExecutionSignaled {
+			continue
+		}
+		attributes := event.WorkflowExecutionSignaledEventAttributes
+		signals = append(signals, Signal{
+			Name:     attributes.GetSignalName(),
+			EventID:  event.GetEventId(),
+			Received: EventTime(event),
+			Input:    attributes.Input,
+			Identity: attributes.GetIdentity(),
+		})
+	}
+	return signals
+}
+
+// ChildWorkflows returns the summaries of all child workflows initiated in the history, in the order they were
+// initiated.
+func ChildWorkflows(events []*shared.HistoryEvent) []ChildWorkflowSummary {
+	var summaries []ChildWorkflowSummary
+	indexes := make(map[int64]int)
+	for _, event := range events {
+		if event.GetEventType() == shared.EventTypeStartChildWorkflowExecutionInitiated {
+			attributes := event.StartChildWorkflowExecutionInitiatedEventAttributes
+			indexes[event.GetEventId()] = len(summaries)
+			summaries = append(summaries, ChildWorkflowSummary{
+				WorkflowID:       attributes.GetWorkflowId(),
+				WorkflowType:     attributes.GetWorkflowType().GetName(),
+				Domain:           attributes.GetDomain(),
+				InitiatedEventID: event.GetEventId(),
+				Initiated:        EventTime(event),
+			})
+			continue
+		}
+
+		initiatedEventID, ok := childInitiatedEventID(event)
+		if !ok {
+			continue
+		}
+		i, ok := indexes[initiatedEventID]
+		if !ok {
+			continue
+		}
+		c := &summaries[i]
+		if event.GetEventType() == shared.EventTypeChildWorkflowExecutionStarted {
+			c.Started = EventTime(event)
+			c.RunID = event.ChildWorkflowExecutionStartedEventAttributes.GetWorkflowExecution().GetRunId()
+		} else {
+			c.Closed = EventTime(event)
+			c.CloseEventType = event.EventType
+		}
+	}
+	return summaries
+}
+
+// DecisionTasks returns the summaries of all decision tasks in the history, in the order they were scheduled.
+func DecisionTasks(events []*shared.HistoryEvent) []DecisionTaskSummary {
+	var summaries []DecisionTaskSummary
+	indexes := make(map[int64]int)
+	get := func(scheduledEventID int64) *DecisionTaskSummary {
+		if i, ok := indexes[scheduledEventID]; ok {
+			return &summaries[i]
+		}
+		return nil
+	}
+
+	for _, event := range events {
+		switch event.GetEventType() {
+		case shared.EventTypeDecisionTaskScheduled:
+			indexes[event.GetEventId()] = len(summaries)
+			summaries = append(summaries, DecisionTaskSummary{
+				ScheduledEventID: event.GetEventId(),
+				Attempt:          event.DecisionTaskScheduledEventAttributes.GetAttempt(),
+				Scheduled:        EventTime(event),
+			})
+		case shared.EventTypeDecisionTaskStarted:
+			if d := get(event.DecisionTaskStartedEventAttributes.GetScheduledEventId()); d != nil {
+				d.Started = EventTime(event)
+			}
+		case shared.EventTypeDecisionTaskCompleted:
+			if d := get(event.DecisionTaskCompletedEventAttributes.GetScheduledEventId()); d != nil {
+				d.Closed = EventTime(event)
+				d.CloseEventType = event.EventType
+			}
+		case shared.EventTypeDecisionTaskFailed:
+			if d := get(event.DecisionTaskFailedEventAttributes.GetScheduledEventId()); d != nil {
+				d.Closed = EventTime(event)
+				d.CloseEventType = event.EventType
+			}
+		case shared.EventTypeDecisionTaskTimedOut:
+			if d := get(event.DecisionTaskTimedOutEventAttributes.GetScheduledEventId()); d != nil {
+				d.Closed = EventTime(event)
+				d.CloseEventType = event.EventType
+			}
+		}
+	}
+	return summaries
+}
+
+func childInitiatedEventID(event *shared.HistoryEvent) (int64, bool) {
+	switch event.GetEventType() {
+	case shared.EventTypeChildWorkflowExecutionStarted:
+		return event.ChildWorkflowExecutionStartedEventAttributes.GetInitiatedEventId(), true
+	case shared.EventTypeStartChildWorkflowExecutionFailed:
+		return event.StartChildWorkflowExecutionFailedEventAttributes.GetInitiatedEventId(), true
+	case shared.EventTypeChildWorkflowExecutionCompleted:
+		return event.ChildWorkflowExecutionCompletedEventAttributes.GetInitiatedEventId(), true
+	case shared.EventTypeChildWorkflowExecutionFailed:
+		return event.ChildWorkflowExecutionFailedEventAttributes.GetInitiatedEventId(), true
+	case shared.EventTypeChildWorkflowExecutionCanceled:
+		return event.ChildWorkflowExecutionCanceledEventAttributes.GetInitiatedEventId(), true
+	case shared.EventTypeChildWorkflowExecutionTimedOut:
+		return event.ChildWorkflowExecutionTimedOutEventAttributes.GetInitiatedEventId(), true
+	case shared.EventTypeChildWorkflowExecutionTerminated:
+		return event.ChildWorkflowExecutionTerminatedEventAttributes.GetInitiatedEventId(), true
+	default:
+		return 0, false
+	}
+}
+
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return end.Sub(start)
+}
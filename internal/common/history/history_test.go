@@ -0,0 +1,213 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+var testStart = time.Unix(1704067200, 0)
+
+func newTestEvent(eventID int64, second int, eventType shared.EventType, setAttributes func(event *shared.HistoryEvent)) *shared.HistoryEvent {
+	event := &shared.HistoryEvent{
+		EventId:   common.Int64Ptr(eventID),
+		EventType: eventType.Ptr(),
+		Timestamp: common.Int64Ptr(testStart.Add(time.Duration(second) * time.Second).UnixNano()),
+	}
+	if setAttributes != nil {
+		setAttributes(event)
+	}
+	return event
+}
+
+func newTestDecisionTask(scheduledEventID int64, second int) []*shared.HistoryEvent {
+	return []*shared.HistoryEvent{
+		newTestEvent(scheduledEventID, second, shared.EventTypeDecisionTaskScheduled, func(e *shared.HistoryEvent) {
+			e.DecisionTaskScheduledEventAttributes = &shared.DecisionTaskScheduledEventAttributes{}
+		}),
+		newTestEvent(scheduledEventID+1, second+1, shared.EventTypeDecisionTaskStarted, func(e *shared.HistoryEvent) {
+			e.DecisionTaskStartedEventAttributes = &shared.DecisionTaskStartedEventAttributes{ScheduledEventId: common.Int64Ptr(scheduledEventID)}
+		}),
+		newTestEvent(scheduledEventID+2, second+2, shared.EventTypeDecisionTaskCompleted, func(e *shared.HistoryEvent) {
+			e.DecisionTaskCompletedEventAttributes = &shared.DecisionTaskCompletedEventAttributes{
+				ScheduledEventId: common.Int64Ptr(scheduledEventID),
+				StartedEventId:   common.Int64Ptr(scheduledEventID + 1),
+			}
+		}),
+	}
+}
+
+// newTestHistory returns a workflow which runs an activity and a timer in parallel, waits for the activity and a signal,
+// and completes. The timer is still running when the workflow completes.
+func newTestHistory() []*shared.HistoryEvent {
+	var events []*shared.HistoryEvent
+	events = append(events, newTestEvent(1, 0, shared.EventTypeWorkflowExecutionStarted, func(e *shared.HistoryEvent) {
+		e.WorkflowExecutionStartedEventAttributes = &shared.WorkflowExecutionStartedEventAttributes{}
+	}))
+	events = append(events, newTestDecisionTask(2, 0)...)
+	events = append(events,
+		newTestEvent(5, 2, shared.EventTypeActivityTaskScheduled, func(e *shared.HistoryEvent) {
+			e.ActivityTaskScheduledEventAttributes = &shared.ActivityTaskScheduledEventAttributes{
+				ActivityId:                   common.StringPtr("0"),
+				ActivityType:                 &shared.ActivityType{Name: common.StringPtr("activity")},
+				TaskList:                     &shared.TaskList{Name: common.StringPtr("tl")},
+				DecisionTaskCompletedEventId: common.Int64Ptr(4),
+			}
+		}),
+		newTestEvent(6, 2, shared.EventTypeTimerStarted, func(e *shared.HistoryEvent) {
+			e.TimerStartedEventAttributes = &shared.TimerStartedEventAttributes{
+				TimerId:                      common.StringPtr("1"),
+				StartToFireTimeoutSeconds:    common.Int64Ptr(60),
+				DecisionTaskCompletedEventId: common.Int64Ptr(4),
+			}
+		}),
+		newTestEvent(7, 5, shared.EventTypeActivityTaskStarted, func(e *shared.HistoryEvent) {
+			e.ActivityTaskStartedEventAttributes = &shared.ActivityTaskStartedEventAttributes{
+				ScheduledEventId:  common.Int64Ptr(5),
+				Attempt:           common.Int32Ptr(2),
+				Identity:          common.StringPtr("worker"),
+				LastFailureReason: common.StringPtr("retryable"),
+			}
+		}),
+		newTestEvent(8, 7, shared.EventTypeActivityTaskCompleted, func(e *shared.HistoryEvent) {
+			e.ActivityTaskCompletedEventAttributes = &shared.ActivityTaskCompletedEventAttributes{
+				ScheduledEventId: common.Int64Ptr(5),
+				StartedEventId:   common.Int64Ptr(7),
+			}
+		}),
+	)
+	events = append(events, newTestDecisionTask(9, 7)...)
+	events = append(events, newTestEvent(12, 13, shared.EventTypeWorkflowExecutionSignaled, func(e *shared.HistoryEvent) {
+		e.WorkflowExecutionSignaledEventAttributes = &shared.WorkflowExecutionSignaledEventAttributes{
+			SignalName: common.StringPtr("signal"),
+			Input:      []byte("input"),
+			Identity:   common.StringPtr("sender"),
+		}
+	}))
+	events = append(events, newTestDecisionTask(13, 13)...)
+	events = append(events, newTestEvent(16, 15, shared.EventTypeWorkflowExecutionCompleted, func(e *shared.HistoryEvent) {
+		e.WorkflowExecutionCompletedEventAttributes = &shared.WorkflowExecutionCompletedEventAttributes{
+			DecisionTaskCompletedEventId: common.Int64Ptr(15),
+		}
+	}))
+	return events
+}
+
+func TestActivities(t *testing.T) {
+	activities := Activities(newTestHistory())
+	require.Len(t, activities, 1)
+	a := activities[0]
+	assert.Equal(t, "0", a.ActivityID)
+	assert.Equal(t, "activity", a.ActivityType)
+	assert.Equal(t, "tl", a.TaskList)
+	assert.Equal(t, int64(5), a.ScheduledEventID)
+	assert.Equal(t, ActivityStateCompleted, a.State)
+	assert.Equal(t, int32(2), a.Attempt)
+	assert.Equal(t, "worker", a.Identity)
+	assert.Empty(t, a.FailureReason)
+	assert.Equal(t, 3*time.Second, a.ScheduleToStart())
+	assert.Equal(t, 2*time.Second, a.StartToClose())
+	assert.Equal(t, 5*time.Second, a.ScheduleToClose())
+}
+
+func TestActivities_Pending(t *testing.T) {
+	activities := Activities(newTestHistory()[:7])
+	require.Len(t, activities, 1)
+	assert.Equal(t, ActivityStateStarted, activities[0].State)
+	assert.Equal(t, "retryable", activities[0].FailureReason)
+	assert.Equal(t, time.Duration(0), activities[0].ScheduleToClose())
+}
+
+func TestTimers(t *testing.T) {
+	timers := Timers(newTestHistory())
+	require.Len(t, timers, 1)
+	timer := timers["1"]
+	assert.Equal(t, int64(6), timer.StartedEventID)
+	assert.Equal(t, time.Minute, timer.Timeout)
+	assert.False(t, timer.Fired)
+	assert.False(t, timer.Canceled)
+	assert.Equal(t, time.Duration(0), timer.Duration())
+}
+
+func TestSignals(t *testing.T) {
+	assert.Equal(t, []Signal{{
+		Name:     "signal",
+		EventID:  12,
+		Received: testStart.Add(13 * time.Second),
+		Input:    []byte("input"),
+		Identity: "sender",
+	}}, Signals(newTestHistory()))
+}
+
+func TestChildWorkflows(t *testing.T) {
+	events := []*shared.HistoryEvent{
+		newTestEvent(5, 2, shared.EventTypeStartChildWorkflowExecutionInitiated, func(e *shared.HistoryEvent) {
+			e.StartChildWorkflowExecutionInitiatedEventAttributes = &shared.StartChildWorkflowExecutionInitiatedEventAttributes{
+				Domain:       common.StringPtr("domain"),
+				WorkflowId:   common.StringPtr("child"),
+				WorkflowType: &shared.WorkflowType{Name: common.StringPtr("childType")},
+			}
+		}),
+		newTestEvent(6, 3, shared.EventTypeChildWorkflowExecutionStarted, func(e *shared.HistoryEvent) {
+			e.ChildWorkflowExecutionStartedEventAttributes = &shared.ChildWorkflowExecutionStartedEventAttributes{
+				InitiatedEventId:  common.Int64Ptr(5),
+				WorkflowExecution: &shared.WorkflowExecution{WorkflowId: common.StringPtr("child"), RunId: common.StringPtr("run")},
+			}
+		}),
+		newTestEvent(7, 10, shared.EventTypeChildWorkflowExecutionFailed, func(e *shared.HistoryEvent) {
+			e.ChildWorkflowExecutionFailedEventAttributes = &shared.ChildWorkflowExecutionFailedEventAttributes{
+				InitiatedEventId: common.Int64Ptr(5),
+			}
+		}),
+	}
+
+	children := ChildWorkflows(events)
+	require.Len(t, children, 1)
+	child := children[0]
+	assert.Equal(t, "child", child.WorkflowID)
+	assert.Equal(t, "run", child.RunID)
+	assert.Equal(t, "childType", child.WorkflowType)
+	assert.Equal(t, "domain", child.Domain)
+	assert.Equal(t, shared.EventTypeChildWorkflowExecutionFailed.Ptr(), child.CloseEventType)
+	assert.Equal(t, 8*time.Second, child.Duration())
+}
+
+func TestDecisionTasks(t *testing.T) {
+	decisionTasks := DecisionTasks(newTestHistory())
+	require.Len(t, decisionTasks, 3)
+	for _, d := range decisionTasks {
+		assert.Equal(t, shared.EventTypeDecisionTaskCompleted.Ptr(), d.CloseEventType)
+		assert.Equal(t, time.Second, d.ScheduleToStart())
+		assert.Equal(t, time.Second, d.StartToClose())
+	}
+	assert.Equal(t, []int64{2, 9, 13}, []int64{
+		decisionTasks[0].ScheduledEventID,
+		decisionTasks[1].ScheduledEventID,
+		decisionTasks[2].ScheduledEventID,
+	})
+}
@@ -0,0 +1,608 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+type (
+	// temporalFields is a message of the Temporal history in the protobuf JSON mapping. put omits default values,
+	// like the mapping does.
+	temporalFields map[string]interface{}
+
+	temporalPayloads struct {
+		Payloads []temporalPayload `json:"payloads"`
+	}
+
+	temporalPayload struct {
+		Metadata map[string][]byte `json:"metadata"`
+		Data     []byte            `json:"data"`
+	}
+)
+
+const (
+	temporalEncodingJSON   = "json/plain"
+	temporalEncodingBinary = "binary/plain"
+)
+
+// WriteTemporalJSON writes the history in the JSON format of Temporal histories, e.g. to replay Cadence histories with
+// the workflow replayer of the Temporal Go SDK while migrating a workflow to it. Decision tasks become workflow tasks,
+// task lists task queues and domains namespaces. Payloads are split into one JSON payload per argument, or kept as a
+// single binary payload when they are not JSON, and failure reasons become application failure types. Cadence markers,
+// like versions and side effects, are kept as they are, so code paths relying on them do not replay against Temporal
+// SDK markers. It fails for the events Temporal has no equivalent of, CancelTimerFailed and
+// RequestCancelActivityTaskFailed.
+func WriteTemporalJSON(w io.Writer, events []*shared.HistoryEvent) error {
+	translated := make([]temporalFields, 0, len(events))
+	// Temporal identifies the activity of a cancel request by the ID of its scheduled event, Cadence by activity ID
+	scheduledActivities := make(map[string]int64)
+	for _, event := range events {
+		if event.GetEventType() == shared.EventTypeActivityTaskScheduled {
+			scheduledActivities[event.ActivityTaskScheduledEventAttributes.GetActivityId()] = event.GetEventId()
+		}
+		t, err := translateTemporalEvent(event, scheduledActivities)
+		if err != nil {
+			return err
+		}
+		translated = append(translated, t)
+	}
+	return json.NewEncoder(w).Encode(temporalFields{"events": translated})
+}
+
+func translateTemporalEvent(event *shared.HistoryEvent, scheduledActivities map[string]int64) (temporalFields, error) {
+	eventType := event.GetEventType()
+	name := strings.Replace(eventType.String(), "DecisionTask", "WorkflowTask", 1)
+	result := temporalFields{}
+	result.put("eventId", temporalInt64(event.GetEventId()))
+	result.put("eventTime", EventTime(event).UTC().Format(time.RFC3339Nano))
+	result.put("eventType", "EVENT_TYPE_"+screamingSnakeCase(name))
+	result.put("version", temporalInt64(event.GetVersion()))
+	result.put("taskId", temporalInt64(event.GetTaskId()))
+
+	a := temporalFields{}
+	switch eventType {
+	case shared.EventTypeWorkflowExecutionStarted:
+		attributes := event.WorkflowExecutionStartedEventAttributes
+		a.put("workflowType", temporalName(attributes.GetWorkflowType().GetName()))
+		a.put("parentWorkflowNamespace", attributes.GetParentWorkflowDomain())
+		a.put("parentWorkflowExecution", temporalExecution(attributes.ParentWorkflowExecution))
+		a.put("parentInitiatedEventId", temporalInt64(attributes.GetParentInitiatedEventId()))
+		a.put("taskQueue", temporalTaskQueue(attributes.TaskList))
+		a.put("input", temporalPayloadsOf(attributes.Input))
+		a.put("workflowExecutionTimeout", temporalSeconds(attributes.GetExecutionStartToCloseTimeoutSeconds()))
+		a.put("workflowRunTimeout", temporalSeconds(attributes.GetExecutionStartToCloseTimeoutSeconds()))
+		a.put("workflowTaskTimeout", temporalSeconds(attributes.GetTaskStartToCloseTimeoutSeconds()))
+		a.put("continuedExecutionRunId", attributes.GetContinuedExecutionRunId())
+		if attributes.Initiator != nil {
+			a.put("initiator", "CONTINUE_AS_NEW_INITIATOR_"+screamingSnakeCase(attributes.Initiator.String()))
+		}
+		if attributes.ContinuedFailureReason != nil {
+			a.put("continuedFailure", temporalApplicationFailure(attributes.GetContinuedFailureReason(), attributes.ContinuedFailureDetails))
+		}
+		a.put("lastCompletionResult", temporalPayloadsOf(attributes.LastCompletionResult))
+		a.put("originalExecutionRunId", attributes.GetOriginalExecutionRunId())
+		a.put("identity", attributes.GetIdentity())
+		a.put("firstExecutionRunId", attributes.GetFirstExecutionRunId())
+		a.put("retryPolicy", temporalRetryPolicy(attributes.RetryPolicy))
+		a.put("attempt", attributes.GetAttempt()+1)
+		if attributes.ExpirationTimestamp != nil {
+			a.put("workflowExecutionExpirationTime", time.Unix(0, attributes.GetExpirationTimestamp()).UTC().Format(time.RFC3339Nano))
+		}
+		a.put("cronSchedule", attributes.GetCronSchedule())
+		a.put("firstWorkflowTaskBackoff", temporalSeconds(attributes.GetFirstDecisionTaskBackoffSeconds()))
+		a.put("memo", temporalMemo(attributes.Memo))
+		a.put("searchAttributes", temporalSearchAttributes(attributes.SearchAttributes))
+		a.put("header", temporalHeader(attributes.Header))
+	case shared.EventTypeWorkflowExecutionCompleted:
+		attributes := event.WorkflowExecutionCompletedEventAttributes
+		a.put("result", temporalPayloadsOf(attributes.Result))
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+	case shared.EventTypeWorkflowExecutionFailed:
+		attributes := event.WorkflowExecutionFailedEventAttributes
+		a.put("failure", temporalApplicationFailure(attributes.GetReason(), attributes.Details))
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+	case shared.EventTypeWorkflowExecutionTimedOut:
+		// Temporal workflows only time out on their execution or run timeout, which carry no attributes
+	case shared.EventTypeWorkflowExecutionCanceled:
+		attributes := event.WorkflowExecutionCanceledEventAttributes
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+		a.put("details", temporalPayloadsOf(attributes.Details))
+	case shared.EventTypeWorkflowExecutionTerminated:
+		attributes := event.WorkflowExecutionTerminatedEventAttributes
+		a.put("reason", attributes.GetReason())
+		a.put("details", temporalPayloadsOf(attributes.Details))
+		a.put("identity", attributes.GetIdentity())
+	case shared.EventTypeWorkflowExecutionContinuedAsNew:
+		attributes := event.WorkflowExecutionContinuedAsNewEventAttributes
+		a.put("newExecutionRunId", attributes.GetNewExecutionRunId())
+		a.put("workflowType", temporalName(attributes.GetWorkflowType().GetName()))
+		a.put("taskQueue", temporalTaskQueue(attributes.TaskList))
+		a.put("input", temporalPayloadsOf(attributes.Input))
+		a.put("workflowRunTimeout", temporalSeconds(attributes.GetExecutionStartToCloseTimeoutSeconds()))
+		a.put("workflowTaskTimeout", temporalSeconds(attributes.GetTaskStartToCloseTimeoutSeconds()))
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+		a.put("backoffStartInterval", temporalSeconds(attributes.GetBackoffStartIntervalInSeconds()))
+		if attributes.Initiator != nil {
+			a.put("initiator", "CONTINUE_AS_NEW_INITIATOR_"+screamingSnakeCase(attributes.Initiator.String()))
+		}
+		if attributes.FailureReason != nil {
+			a.put("failure", temporalApplicationFailure(attributes.GetFailureReason(), attributes.FailureDetails))
+		}
+		a.put("lastCompletionResult", temporalPayloadsOf(attributes.LastCompletionResult))
+		a.put("header", temporalHeader(attributes.Header))
+		a.put("memo", temporalMemo(attributes.Memo))
+		a.put("searchAttributes", temporalSearchAttributes(attributes.SearchAttributes))
+	case shared.EventTypeWorkflowExecutionSignaled:
+		attributes := event.WorkflowExecutionSignaledEventAttributes
+		a.put("signalName", attributes.GetSignalName())
+		a.put("input", temporalPayloadsOf(attributes.Input))
+		a.put("identity", attributes.GetIdentity())
+	case shared.EventTypeWorkflowExecutionCancelRequested:
+		attributes := event.WorkflowExecutionCancelRequestedEventAttributes
+		a.put("cause", attributes.GetCause())
+		a.put("externalInitiatedEventId", temporalInt64(attributes.GetExternalInitiatedEventId()))
+		a.put("externalWorkflowExecution", temporalExecution(attributes.ExternalWorkflowExecution))
+		a.put("identity", attributes.GetIdentity())
+	case shared.EventTypeUpsertWorkflowSearchAttributes:
+		attributes := event.UpsertWorkflowSearchAttributesEventAttributes
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+		a.put("searchAttributes", temporalSearchAttributes(attributes.SearchAttributes))
+
+	case shared.EventTypeDecisionTaskScheduled:
+		attributes := event.DecisionTaskScheduledEventAttributes
+		a.put("taskQueue", temporalTaskQueue(attributes.TaskList))
+		a.put("startToCloseTimeout", temporalSeconds(attributes.GetStartToCloseTimeoutSeconds()))
+		a.put("attempt", int32(attributes.GetAttempt())+1)
+	case shared.EventTypeDecisionTaskStarted:
+		attributes := event.DecisionTaskStartedEventAttributes
+		a.put("scheduledEventId", temporalInt64(attributes.GetScheduledEventId()))
+		a.put("identity", attributes.GetIdentity())
+		a.put("requestId", attributes.GetRequestId())
+	case shared.EventTypeDecisionTaskCompleted:
+		attributes := event.DecisionTaskCompletedEventAttributes
+		a.put("scheduledEventId", temporalInt64(attributes.GetScheduledEventId()))
+		a.put("startedEventId", temporalInt64(attributes.GetStartedEventId()))
+		a.put("identity", attributes.GetIdentity())
+		a.put("binaryChecksum", attributes.GetBinaryChecksum())
+	case shared.EventTypeDecisionTaskTimedOut:
+		attributes := event.DecisionTaskTimedOutEventAttributes
+		a.put("scheduledEventId", temporalInt64(attributes.GetScheduledEventId()))
+		a.put("startedEventId", temporalInt64(attributes.GetStartedEventId()))
+		a.put("timeoutType", temporalTimeoutType(attributes.TimeoutType))
+	case shared.EventTypeDecisionTaskFailed:
+		attributes := event.DecisionTaskFailedEventAttributes
+		a.put("scheduledEventId", temporalInt64(attributes.GetScheduledEventId()))
+		a.put("startedEventId", temporalInt64(attributes.GetStartedEventId()))
+		if attributes.Cause != nil {
+			cause := strings.NewReplacer("DECISION", "COMMAND", "TASKLIST", "TASK_QUEUE").Replace(attributes.Cause.String())
+			a.put("cause", "WORKFLOW_TASK_FAILED_CAUSE_"+cause)
+		}
+		a.put("failure", temporalApplicationFailure(attributes.GetReason(), attributes.Details))
+		a.put("identity", attributes.GetIdentity())
+		a.put("baseRunId", attributes.GetBaseRunId())
+		a.put("newRunId", attributes.GetNewRunId())
+		a.put("forkEventVersion", temporalInt64(attributes.GetForkEventVersion()))
+		a.put("binaryChecksum", attributes.GetBinaryChecksum())
+
+	case shared.EventTypeActivityTaskScheduled:
+		attributes := event.ActivityTaskScheduledEventAttributes
+		a.put("activityId", attributes.GetActivityId())
+		a.put("activityType", temporalName(attributes.GetActivityType().GetName()))
+		a.put("taskQueue", temporalTaskQueue(attributes.TaskList))
+		a.put("header", temporalHeader(attributes.Header))
+		a.put("input", temporalPayloadsOf(attributes.Input))
+		a.put("scheduleToCloseTimeout", temporalSeconds(attributes.GetScheduleToCloseTimeoutSeconds()))
+		a.put("scheduleToStartTimeout", temporalSeconds(attributes.GetScheduleToStartTimeoutSeconds()))
+		a.put("startToCloseTimeout", temporalSeconds(attributes.GetStartToCloseTimeoutSeconds()))
+		a.put("heartbeatTimeout", temporalSeconds(attributes.GetHeartbeatTimeoutSeconds()))
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+		a.put("retryPolicy", temporalRetryPolicy(attributes.RetryPolicy))
+	case shared.EventTypeActivityTaskStarted:
+		attributes := event.ActivityTaskStartedEventAttributes
+		a.put("scheduledEventId", temporalInt64(attributes.GetScheduledEventId()))
+		a.put("identity", attributes.GetIdentity())
+		a.put("requestId", attributes.GetRequestId())
+		a.put("attempt", attributes.GetAttempt()+1)
+		if attributes.LastFailureReason != nil {
+			a.put("lastFailure", temporalApplicationFailure(attributes.GetLastFailureReason(), attributes.LastFailureDetails))
+		}
+	case shared.EventTypeActivityTaskCompleted:
+		attributes := event.ActivityTaskCompletedEventAttributes
+		a.put("result", temporalPayloadsOf(attributes.Result))
+		a.put("scheduledEventId", temporalInt64(attributes.GetScheduledEventId()))
+		a.put("startedEventId", temporalInt64(attributes.GetStartedEventId()))
+		a.put("identity", attributes.GetIdentity())
+	case shared.EventTypeActivityTaskFailed:
+		attributes := event.ActivityTaskFailedEventAttributes
+		a.put("failure", temporalApplicationFailure(attributes.GetReason(), attributes.Details))
+		a.put("scheduledEventId", temporalInt64(attributes.GetScheduledEventId()))
+		a.put("startedEventId", temporalInt64(attributes.GetStartedEventId()))
+		a.put("identity", attributes.GetIdentity())
+	case shared.EventTypeActivityTaskTimedOut:
+		attributes := event.ActivityTaskTimedOutEventAttributes
+		timeoutInfo := temporalFields{}
+		timeoutInfo.put("timeoutType", temporalTimeoutType(attributes.TimeoutType))
+		timeoutInfo.put("lastHeartbeatDetails", temporalPayloadsOf(attributes.Details))
+		failure := temporalFields{"message": "activity timeout", "timeoutFailureInfo": timeoutInfo}
+		if attributes.LastFailureReason != nil {
+			failure.put("cause", temporalApplicationFailure(attributes.GetLastFailureReason(), attributes.LastFailureDetails))
+		}
+		a.put("failure", failure)
+		a.put("scheduledEventId", temporalInt64(attributes.GetScheduledEventId()))
+		a.put("startedEventId", temporalInt64(attributes.GetStartedEventId()))
+	case shared.EventTypeActivityTaskCancelRequested:
+		attributes := event.ActivityTaskCancelRequestedEventAttributes
+		a.put("scheduledEventId", temporalInt64(scheduledActivities[attributes.GetActivityId()]))
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+	case shared.EventTypeActivityTaskCanceled:
+		attributes := event.ActivityTaskCanceledEventAttributes
+		a.put("details", temporalPayloadsOf(attributes.Details))
+		a.put("latestCancelRequestedEventId", temporalInt64(attributes.GetLatestCancelRequestedEventId()))
+		a.put("scheduledEventId", temporalInt64(attributes.GetScheduledEventId()))
+		a.put("startedEventId", temporalInt64(attributes.GetStartedEventId()))
+		a.put("identity", attributes.GetIdentity())
+
+	case shared.EventTypeTimerStarted:
+		attributes := event.TimerStartedEventAttributes
+		a.put("timerId", attributes.GetTimerId())
+		a.put("startToFireTimeout", temporalSeconds(int32(attributes.GetStartToFireTimeoutSeconds())))
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+	case shared.EventTypeTimerFired:
+		attributes := event.TimerFiredEventAttributes
+		a.put("timerId", attributes.GetTimerId())
+		a.put("startedEventId", temporalInt64(attributes.GetStartedEventId()))
+	case shared.EventTypeTimerCanceled:
+		attributes := event.TimerCanceledEventAttributes
+		a.put("timerId", attributes.GetTimerId())
+		a.put("startedEventId", temporalInt64(attributes.GetStartedEventId()))
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+		a.put("identity", attributes.GetIdentity())
+
+	case shared.EventTypeMarkerRecorded:
+		attributes := event.MarkerRecordedEventAttributes
+		a.put("markerName", attributes.GetMarkerName())
+		if details := temporalPayloadsOf(attributes.Details); details != nil {
+			a.put("details", map[string]*temporalPayloads{"data": details})
+		}
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+		a.put("header", temporalHeader(attributes.Header))
+
+	case shared.EventTypeStartChildWorkflowExecutionInitiated:
+		attributes := event.StartChildWorkflowExecutionInitiatedEventAttributes
+		a.put("namespace", attributes.GetDomain())
+		a.put("workflowId", attributes.GetWorkflowId())
+		a.put("workflowType", temporalName(attributes.GetWorkflowType().GetName()))
+		a.put("taskQueue", temporalTaskQueue(attributes.TaskList))
+		a.put("input", temporalPayloadsOf(attributes.Input))
+		a.put("workflowExecutionTimeout", temporalSeconds(attributes.GetExecutionStartToCloseTimeoutSeconds()))
+		a.put("workflowRunTimeout", temporalSeconds(attributes.GetExecutionStartToCloseTimeoutSeconds()))
+		a.put("workflowTaskTimeout", temporalSeconds(attributes.GetTaskStartToCloseTimeoutSeconds()))
+		if attributes.ParentClosePolicy != nil {
+			a.put("parentClosePolicy", "PARENT_CLOSE_POLICY_"+attributes.ParentClosePolicy.String())
+		}
+		a.put("control", string(attributes.Control))
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+		if attributes.WorkflowIdReusePolicy != nil {
+			a.put("workflowIdReusePolicy", "WORKFLOW_ID_REUSE_POLICY_"+screamingSnakeCase(attributes.WorkflowIdReusePolicy.String()))
+		}
+		a.put("retryPolicy", temporalRetryPolicy(attributes.RetryPolicy))
+		a.put("cronSchedule", attributes.GetCronSchedule())
+		a.put("header", temporalHeader(attributes.Header))
+		a.put("memo", temporalMemo(attributes.Memo))
+		a.put("searchAttributes", temporalSearchAttributes(attributes.SearchAttributes))
+	case shared.EventTypeStartChildWorkflowExecutionFailed:
+		attributes := event.StartChildWorkflowExecutionFailedEventAttributes
+		a.put("namespace", attributes.GetDomain())
+		a.put("workflowId", attributes.GetWorkflowId())
+		a.put("workflowType", temporalName(attributes.GetWorkflowType().GetName()))
+		if attributes.Cause != nil {
+			a.put("cause", "START_CHILD_WORKFLOW_EXECUTION_FAILED_CAUSE_"+attributes.Cause.String())
+		}
+		a.put("control", string(attributes.Control))
+		a.put("initiatedEventId", temporalInt64(attributes.GetInitiatedEventId()))
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+	case shared.EventTypeChildWorkflowExecutionStarted:
+		attributes := event.ChildWorkflowExecutionStartedEventAttributes
+		a.put("namespace", attributes.GetDomain())
+		a.put("initiatedEventId", temporalInt64(attributes.GetInitiatedEventId()))
+		a.put("workflowExecution", temporalExecution(attributes.WorkflowExecution))
+		a.put("workflowType", temporalName(attributes.GetWorkflowType().GetName()))
+		a.put("header", temporalHeader(attributes.Header))
+	case shared.EventTypeChildWorkflowExecutionCompleted:
+		attributes := event.ChildWorkflowExecutionCompletedEventAttributes
+		a.put("result", temporalPayloadsOf(attributes.Result))
+		putTemporalChild(a, attributes.GetDomain(), attributes.WorkflowExecution, attributes.GetWorkflowType().GetName(), attributes.GetInitiatedEventId(), attributes.GetStartedEventId())
+	case shared.EventTypeChildWorkflowExecutionFailed:
+		attributes := event.ChildWorkflowExecutionFailedEventAttributes
+		a.put("failure", temporalApplicationFailure(attributes.GetReason(), attributes.Details))
+		putTemporalChild(a, attributes.GetDomain(), attributes.WorkflowExecution, attributes.GetWorkflowType().GetName(), attributes.GetInitiatedEventId(), attributes.GetStartedEventId())
+	case shared.EventTypeChildWorkflowExecutionCanceled:
+		attributes := event.ChildWorkflowExecutionCanceledEventAttributes
+		a.put("details", temporalPayloadsOf(attributes.Details))
+		putTemporalChild(a, attributes.GetDomain(), attributes.WorkflowExecution, attributes.GetWorkflowType().GetName(), attributes.GetInitiatedEventId(), attributes.GetStartedEventId())
+	case shared.EventTypeChildWorkflowExecutionTimedOut:
+		attributes := event.ChildWorkflowExecutionTimedOutEventAttributes
+		putTemporalChild(a, attributes.GetDomain(), attributes.WorkflowExecution, attributes.GetWorkflowType().GetName(), attributes.GetInitiatedEventId(), attributes.GetStartedEventId())
+	case shared.EventTypeChildWorkflowExecutionTerminated:
+		attributes := event.ChildWorkflowExecutionTerminatedEventAttributes
+		putTemporalChild(a, attributes.GetDomain(), attributes.WorkflowExecution, attributes.GetWorkflowType().GetName(), attributes.GetInitiatedEventId(), attributes.GetStartedEventId())
+
+	case shared.EventTypeSignalExternalWorkflowExecutionInitiated:
+		attributes := event.SignalExternalWorkflowExecutionInitiatedEventAttributes
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+		a.put("namespace", attributes.GetDomain())
+		a.put("workflowExecution", temporalExecution(attributes.WorkflowExecution))
+		a.put("signalName", attributes.GetSignalName())
+		a.put("input", temporalPayloadsOf(attributes.Input))
+		a.put("control", string(attributes.Control))
+		a.put("childWorkflowOnly", attributes.GetChildWorkflowOnly())
+	case shared.EventTypeSignalExternalWorkflowExecutionFailed:
+		attributes := event.SignalExternalWorkflowExecutionFailedEventAttributes
+		if attributes.Cause != nil {
+			a.put("cause", "SIGNAL_EXTERNAL_WORKFLOW_EXECUTION_FAILED_CAUSE_"+temporalExternalFailureCause(attributes.Cause.String()))
+		}
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+		a.put("namespace", attributes.GetDomain())
+		a.put("workflowExecution", temporalExecution(attributes.WorkflowExecution))
+		a.put("initiatedEventId", temporalInt64(attributes.GetInitiatedEventId()))
+		a.put("control", string(attributes.Control))
+	case shared.EventTypeExternalWorkflowExecutionSignaled:
+		attributes := event.ExternalWorkflowExecutionSignaledEventAttributes
+		a.put("initiatedEventId", temporalInt64(attributes.GetInitiatedEventId()))
+		a.put("namespace", attributes.GetDomain())
+		a.put("workflowExecution", temporalExecution(attributes.WorkflowExecution))
+		a.put("control", string(attributes.Control))
+	case shared.EventTypeRequestCancelExternalWorkflowExecutionInitiated:
+		attributes := event.RequestCancelExternalWorkflowExecutionInitiatedEventAttributes
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+		a.put("namespace", attributes.GetDomain())
+		a.put("workflowExecution", temporalExecution(attributes.WorkflowExecution))
+		a.put("control", string(attributes.Control))
+		a.put("childWorkflowOnly", attributes.GetChildWorkflowOnly())
+	case shared.EventTypeRequestCancelExternalWorkflowExecutionFailed:
+		attributes := event.RequestCancelExternalWorkflowExecutionFailedEventAttributes
+		if attributes.Cause != nil {
+			a.put("cause", "CANCEL_EXTERNAL_WORKFLOW_EXECUTION_FAILED_CAUSE_"+temporalExternalFailureCause(attributes.Cause.String()))
+		}
+		a.put("workflowTaskCompletedEventId", temporalInt64(attributes.GetDecisionTaskCompletedEventId()))
+		a.put("namespace", attributes.GetDomain())
+		a.put("workflowExecution", temporalExecution(attributes.WorkflowExecution))
+		a.put("initiatedEventId", temporalInt64(attributes.GetInitiatedEventId()))
+		a.put("control", string(attributes.Control))
+	case shared.EventTypeExternalWorkflowExecutionCancelRequested:
+		attributes := event.ExternalWorkflowExecutionCancelRequestedEventAttributes
+		a.put("initiatedEventId", temporalInt64(attributes.GetInitiatedEventId()))
+		a.put("namespace", attributes.GetDomain())
+		a.put("workflowExecution", temporalExecution(attributes.WorkflowExecution))
+
+	default:
+		return nil, fmt.Errorf("event %d: %s has no Temporal equivalent", event.GetEventId(), eventType)
+	}
+	result[lowerFirst(name)+"EventAttributes"] = a
+	return result, nil
+}
+
+func putTemporalChild(a temporalFields, domain string, execution *shared.WorkflowExecution, workflowType string, initiatedEventID, startedEventID int64) {
+	a.put("namespace", domain)
+	a.put("workflowExecution", temporalExecution(execution))
+	a.put("workflowType", temporalName(workflowType))
+	a.put("initiatedEventId", temporalInt64(initiatedEventID))
+	a.put("startedEventId", temporalInt64(startedEventID))
+}
+
+// put sets key to value, unless it is the default value of its type.
+func (f temporalFields) put(key string, value interface{}) {
+	if value == nil {
+		return
+	}
+	if v := reflect.ValueOf(value); v.IsZero() || (v.Kind() == reflect.Map && v.Len() == 0) {
+		return
+	}
+	f[key] = value
+}
+
+// temporalInt64 formats an int64 as a string, like the protobuf JSON mapping does.
+func temporalInt64(v int64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+func temporalSeconds(seconds int32) string {
+	if seconds == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(seconds)) + "s"
+}
+
+func temporalName(name string) temporalFields {
+	f := temporalFields{}
+	f.put("name", name)
+	return f
+}
+
+func temporalExecution(execution *shared.WorkflowExecution) temporalFields {
+	f := temporalFields{}
+	f.put("workflowId", execution.GetWorkflowId())
+	f.put("runId", execution.GetRunId())
+	return f
+}
+
+func temporalTaskQueue(taskList *shared.TaskList) temporalFields {
+	if taskList == nil {
+		return nil
+	}
+	f := temporalFields{"kind": "TASK_QUEUE_KIND_NORMAL"}
+	f.put("name", taskList.GetName())
+	if taskList.GetKind() == shared.TaskListKindSticky {
+		f["kind"] = "TASK_QUEUE_KIND_STICKY"
+	}
+	return f
+}
+
+func temporalTimeoutType(timeoutType *shared.TimeoutType) string {
+	if timeoutType == nil {
+		return ""
+	}
+	return "TIMEOUT_TYPE_" + timeoutType.String()
+}
+
+func temporalExternalFailureCause(cause string) string {
+	if cause == "UNKNOWN_EXTERNAL_WORKFLOW_EXECUTION" {
+		return "EXTERNAL_WORKFLOW_EXECUTION_NOT_FOUND"
+	}
+	return cause
+}
+
+func temporalRetryPolicy(policy *shared.RetryPolicy) temporalFields {
+	if policy == nil {
+		return nil
+	}
+	f := temporalFields{}
+	f.put("initialInterval", temporalSeconds(policy.GetInitialIntervalInSeconds()))
+	f.put("backoffCoefficient", policy.GetBackoffCoefficient())
+	f.put("maximumInterval", temporalSeconds(policy.GetMaximumIntervalInSeconds()))
+	f.put("maximumAttempts", policy.GetMaximumAttempts())
+	f.put("nonRetryableErrorTypes", policy.NonRetriableErrorReasons)
+	return f
+}
+
+// temporalApplicationFailure maps a Cadence failure reason and details to a Temporal application failure, the
+// reason being its type.
+func temporalApplicationFailure(reason string, details []byte) temporalFields {
+	info := temporalFields{}
+	info.put("type", reason)
+	info.put("details", temporalPayloadsOf(details))
+	return temporalFields{"message": reason, "applicationFailureInfo": info}
+}
+
+func temporalMemo(memo *shared.Memo) temporalFields {
+	if memo == nil {
+		return nil
+	}
+	f := temporalFields{}
+	f.put("fields", temporalPayloadMap(memo.Fields, temporalEncodingJSON))
+	return f
+}
+
+func temporalSearchAttributes(searchAttributes *shared.SearchAttributes) temporalFields {
+	if searchAttributes == nil {
+		return nil
+	}
+	f := temporalFields{}
+	f.put("indexedFields", temporalPayloadMap(searchAttributes.IndexedFields, temporalEncodingJSON))
+	return f
+}
+
+// temporalHeader keeps the header fields, written by context propagators, as binary payloads.
+func temporalHeader(header *shared.Header) temporalFields {
+	if header == nil {
+		return nil
+	}
+	f := temporalFields{}
+	f.put("fields", temporalPayloadMap(header.Fields, temporalEncodingBinary))
+	return f
+}
+
+// temporalPayloadMap maps fields holding a single value each to payloads of encoding, or binary ones for values which
+// are not JSON.
+func temporalPayloadMap(fields map[string][]byte, encoding string) map[string]temporalPayload {
+	result := make(map[string]temporalPayload, len(fields))
+	for key, value := range fields {
+		valueEncoding := encoding
+		if encoding == temporalEncodingJSON && !json.Valid(value) {
+			valueEncoding = temporalEncodingBinary
+		}
+		result[key] = newTemporalPayload(valueEncoding, value)
+	}
+	return result
+}
+
+// temporalPayloadsOf splits a Cadence payload of JSON arguments into a payload per argument, detecting its format
+// like the default DataConverter. As the number of arguments is not known, a payload holding a JSON array is kept as a
+// single argument, even if it was written by a DataConverter in the JSON array format. A payload which is not JSON,
+// e.g. raw bytes or thrift, is kept as a single binary payload.
+func temporalPayloadsOf(data []byte) *temporalPayloads {
+	if len(data) == 0 {
+		return nil
+	}
+	binary := &temporalPayloads{Payloads: []temporalPayload{newTemporalPayload(temporalEncodingBinary, data)}}
+	args, ok, err := common.SplitJSONPayload(data, 0)
+	if err != nil {
+		return binary
+	}
+	if !ok {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var arg json.RawMessage
+			err := dec.Decode(&arg)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return binary
+			}
+			args = append(args, arg)
+		}
+	}
+	var payloads []temporalPayload
+	for _, arg := range args {
+		payloads = append(payloads, newTemporalPayload(temporalEncodingJSON, arg))
+	}
+	return &temporalPayloads{Payloads: payloads}
+}
+
+func newTemporalPayload(encoding string, data []byte) temporalPayload {
+	return temporalPayload{Metadata: map[string][]byte{"encoding": []byte(encoding)}, Data: data}
+}
+
+// screamingSnakeCase turns CamelCase names into SCREAMING_SNAKE_CASE, e.g. WorkflowTaskScheduled into
+// WORKFLOW_TASK_SCHEDULED.
+func screamingSnakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+func lowerFirst(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
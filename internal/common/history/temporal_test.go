@@ -0,0 +1,168 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestWriteTemporalJSON(t *testing.T) {
+	events := []*shared.HistoryEvent{
+		newTestEvent(1, 0, shared.EventTypeWorkflowExecutionStarted, func(e *shared.HistoryEvent) {
+			e.WorkflowExecutionStartedEventAttributes = &shared.WorkflowExecutionStartedEventAttributes{
+				WorkflowType:                        &shared.WorkflowType{Name: common.StringPtr("OrderWorkflow")},
+				TaskList:                            &shared.TaskList{Name: common.StringPtr("orders")},
+				Input:                               []byte("\"order\"\n42\n"),
+				ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(3600),
+				TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(10),
+				Memo:                                &shared.Memo{Fields: map[string][]byte{"owner": []byte(`"team"`)}},
+				Header:                              &shared.Header{Fields: map[string][]byte{"tracing": {0xff}}},
+			}
+		}),
+	}
+	events = append(events, newTestDecisionTask(2, 1)...)
+	events = append(events,
+		newTestEvent(5, 4, shared.EventTypeActivityTaskScheduled, func(e *shared.HistoryEvent) {
+			e.ActivityTaskScheduledEventAttributes = &shared.ActivityTaskScheduledEventAttributes{
+				ActivityId:                   common.StringPtr("charge"),
+				ActivityType:                 &shared.ActivityType{Name: common.StringPtr("ChargeActivity")},
+				TaskList:                     &shared.TaskList{Name: common.StringPtr("orders")},
+				StartToCloseTimeoutSeconds:   common.Int32Ptr(30),
+				DecisionTaskCompletedEventId: common.Int64Ptr(4),
+			}
+		}),
+		newTestEvent(6, 5, shared.EventTypeActivityTaskStarted, func(e *shared.HistoryEvent) {
+			e.ActivityTaskStartedEventAttributes = &shared.ActivityTaskStartedEventAttributes{ScheduledEventId: common.Int64Ptr(5)}
+		}),
+		newTestEvent(7, 6, shared.EventTypeActivityTaskCancelRequested, func(e *shared.HistoryEvent) {
+			e.ActivityTaskCancelRequestedEventAttributes = &shared.ActivityTaskCancelRequestedEventAttributes{
+				ActivityId:                   common.StringPtr("charge"),
+				DecisionTaskCompletedEventId: common.Int64Ptr(4),
+			}
+		}),
+		newTestEvent(8, 7, shared.EventTypeActivityTaskFailed, func(e *shared.HistoryEvent) {
+			e.ActivityTaskFailedEventAttributes = &shared.ActivityTaskFailedEventAttributes{
+				Reason:           common.StringPtr("CardDeclined"),
+				Details:          []byte{0x01, 0x02},
+				ScheduledEventId: common.Int64Ptr(5),
+				StartedEventId:   common.Int64Ptr(6),
+			}
+		}),
+		newTestEvent(9, 8, shared.EventTypeWorkflowExecutionCompleted, func(e *shared.HistoryEvent) {
+			e.WorkflowExecutionCompletedEventAttributes = &shared.WorkflowExecutionCompletedEventAttributes{
+				DecisionTaskCompletedEventId: common.Int64Ptr(4),
+			}
+		}),
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTemporalJSON(&buf, events))
+	var history struct {
+		Events []map[string]interface{} `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &history))
+	require.Len(t, history.Events, len(events))
+
+	payload := func(encoding, data string) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{"encoding": base64.StdEncoding.EncodeToString([]byte(encoding))},
+			"data":     base64.StdEncoding.EncodeToString([]byte(data)),
+		}
+	}
+
+	started := history.Events[0]
+	assert.Equal(t, "1", started["eventId"])
+	assert.Equal(t, "2024-01-01T00:00:00Z", started["eventTime"])
+	assert.Equal(t, "EVENT_TYPE_WORKFLOW_EXECUTION_STARTED", started["eventType"])
+	assert.Equal(t, map[string]interface{}{
+		"workflowType":             map[string]interface{}{"name": "OrderWorkflow"},
+		"taskQueue":                map[string]interface{}{"name": "orders", "kind": "TASK_QUEUE_KIND_NORMAL"},
+		"input":                    map[string]interface{}{"payloads": []interface{}{payload("json/plain", `"order"`), payload("json/plain", "42")}},
+		"workflowExecutionTimeout": "3600s",
+		"workflowRunTimeout":       "3600s",
+		"workflowTaskTimeout":      "10s",
+		"attempt":                  float64(1),
+		"memo":                     map[string]interface{}{"fields": map[string]interface{}{"owner": payload("json/plain", `"team"`)}},
+		"header":                   map[string]interface{}{"fields": map[string]interface{}{"tracing": payload("binary/plain", "\xff")}},
+	}, started["workflowExecutionStartedEventAttributes"])
+
+	assert.Equal(t, "EVENT_TYPE_WORKFLOW_TASK_SCHEDULED", history.Events[1]["eventType"])
+	assert.Equal(t, map[string]interface{}{
+		"scheduledEventId": "2",
+		"startedEventId":   "3",
+	}, history.Events[3]["workflowTaskCompletedEventAttributes"])
+
+	assert.Equal(t, map[string]interface{}{
+		"scheduledEventId":             "5",
+		"workflowTaskCompletedEventId": "4",
+	}, history.Events[6]["activityTaskCancelRequestedEventAttributes"])
+	assert.Equal(t, map[string]interface{}{
+		"failure": map[string]interface{}{
+			"message": "CardDeclined",
+			"applicationFailureInfo": map[string]interface{}{
+				"type":    "CardDeclined",
+				"details": map[string]interface{}{"payloads": []interface{}{payload("binary/plain", "\x01\x02")}},
+			},
+		},
+		"scheduledEventId": "5",
+		"startedEventId":   "6",
+	}, history.Events[7]["activityTaskFailedEventAttributes"])
+	assert.Equal(t, map[string]interface{}{
+		"workflowTaskCompletedEventId": "4",
+	}, history.Events[8]["workflowExecutionCompletedEventAttributes"])
+
+	err := WriteTemporalJSON(&buf, []*shared.HistoryEvent{
+		newTestEvent(1, 0, shared.EventTypeCancelTimerFailed, func(e *shared.HistoryEvent) {
+			e.CancelTimerFailedEventAttributes = &shared.CancelTimerFailedEventAttributes{}
+		}),
+	})
+	assert.EqualError(t, err, "event 1: CancelTimerFailed has no Temporal equivalent")
+}
+
+func TestTemporalPayloadsOf(t *testing.T) {
+	jsonPayloads := func(args ...string) *temporalPayloads {
+		payloads := &temporalPayloads{}
+		for _, arg := range args {
+			payloads.Payloads = append(payloads.Payloads, newTemporalPayload(temporalEncodingJSON, []byte(arg)))
+		}
+		return payloads
+	}
+	binaryPayload := func(data string) *temporalPayloads {
+		return &temporalPayloads{Payloads: []temporalPayload{newTemporalPayload(temporalEncodingBinary, []byte(data))}}
+	}
+
+	assert.Nil(t, temporalPayloadsOf(nil))
+	assert.Equal(t, jsonPayloads(`"order"`, "42"), temporalPayloadsOf([]byte("\"order\"\n42\n")))
+	assert.Equal(t, jsonPayloads(`"order"`, "42"), temporalPayloadsOf([]byte(`{"cadencePayload":1,"args":["order",42]}`)))
+	// the number of arguments of a JSON array is unknown
+	assert.Equal(t, jsonPayloads(`["order",42]`), temporalPayloadsOf([]byte(`["order",42]`)))
+	assert.Equal(t, binaryPayload(`{"cadencePayload":2,"args":[]}`), temporalPayloadsOf([]byte(`{"cadencePayload":2,"args":[]}`)))
+	assert.Equal(t, binaryPayload("\x01\x02"), temporalPayloadsOf([]byte("\x01\x02")))
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+type (
+	// chromeTrace is the JSON object format of the Trace Event Format, which is understood by chrome://tracing,
+	// Perfetto and speedscope.
+	chromeTrace struct {
+		TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+		DisplayTimeUnit string             `json:"displayTimeUnit"`
+	}
+
+	chromeTraceEvent struct {
+		Name      string                 `json:"name"`
+		Category  string                 `json:"cat"`
+		Phase     string                 `json:"ph"`
+		Timestamp int64                  `json:"ts"`
+		Duration  int64                  `json:"dur"`
+		PID       int                    `json:"pid"`
+		TID       int                    `json:"tid"`
+		Args      map[string]interface{} `json:"args,omitempty"`
+	}
+)
+
+// Timeline returns a span for the workflow, and for each of its decision tasks, activities, timers, child workflows and
+// signals, sorted by start time. Operations which did not close end at the last event of the history, signals are
+// zero length spans at the time they were received.
+func Timeline(events []*shared.HistoryEvent) []Span {
+	if len(events) == 0 {
+		return nil
+	}
+	first, last := events[0], events[len(events)-1]
+	end := func(closed time.Time, closedEventID int64) (time.Time, int64) {
+		if closed.IsZero() {
+			return EventTime(last), last.GetEventId()
+		}
+		return closed, closedEventID
+	}
+
+	spans := []Span{{
+		Kind:         SpanKindWorkflow,
+		Name:         first.GetWorkflowExecutionStartedEventAttributes().GetWorkflowType().GetName(),
+		Start:        EventTime(first),
+		End:          EventTime(last),
+		StartEventID: first.GetEventId(),
+		EndEventID:   last.GetEventId(),
+	}}
+	closedEventIDs := closedEventIDs(events)
+
+	for _, d := range DecisionTasks(events) {
+		span := Span{Kind: SpanKindDecisionTask, Start: d.Scheduled, StartEventID: d.ScheduledEventID}
+		span.End, span.EndEventID = end(d.Closed, closedEventIDs[d.ScheduledEventID])
+		spans = append(spans, span)
+	}
+	for _, a := range Activities(events) {
+		span := Span{Kind: SpanKindActivity, Name: a.ActivityType, Start: a.Scheduled, StartEventID: a.ScheduledEventID}
+		span.End, span.EndEventID = end(a.Closed, closedEventIDs[a.ScheduledEventID])
+		spans = append(spans, span)
+	}
+	for _, t := range Timers(events) {
+		span := Span{Kind: SpanKindTimer, Name: t.TimerID, Start: t.Started, StartEventID: t.StartedEventID}
+		span.End, span.EndEventID = end(t.Closed, closedEventIDs[t.StartedEventID])
+		spans = append(spans, span)
+	}
+	for _, c := range ChildWorkflows(events) {
+		span := Span{Kind: SpanKindChildWorkflow, Name: c.WorkflowType, Start: c.Initiated, StartEventID: c.InitiatedEventID}
+		span.End, span.EndEventID = end(c.Closed, closedEventIDs[c.InitiatedEventID])
+		spans = append(spans, span)
+	}
+	for _, s := range Signals(events) {
+		spans = append(spans, Span{
+			Kind:         SpanKindSignal,
+			Name:         s.Name,
+			Start:        s.Received,
+			End:          s.Received,
+			StartEventID: s.EventID,
+			EndEventID:   s.EventID,
+		})
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].Start.Equal(spans[j].Start) {
+			return spans[i].StartEventID < spans[j].StartEventID
+		}
+		return spans[i].Start.Before(spans[j].Start)
+	})
+	return spans
+}
+
+// WriteChromeTrace writes the Timeline of the history as a Chrome trace JSON, which can be opened with chrome://tracing,
+// Perfetto or speedscope to get a flame graph of the workflow. Each kind of span is a separate process in the trace,
+// and overlapping spans of the same kind are laid out in separate threads.
+func WriteChromeTrace(w io.Writer, events []*shared.HistoryEvent) error {
+	trace := chromeTrace{TraceEvents: []chromeTraceEvent{}, DisplayTimeUnit: "ms"}
+	lanes := make(map[SpanKind][]time.Time)
+	for _, span := range Timeline(events) {
+		// pick the first lane of the kind which is free at the start of the span
+		lane := 0
+		for ; lane < len(lanes[span.Kind]); lane++ {
+			if !lanes[span.Kind][lane].After(span.Start) {
+				break
+			}
+		}
+		if lane == len(lanes[span.Kind]) {
+			lanes[span.Kind] = append(lanes[span.Kind], time.Time{})
+		}
+		lanes[span.Kind][lane] = span.End
+
+		name := span.Kind.String()
+		if span.Name != "" {
+			name += ": " + span.Name
+		}
+		trace.TraceEvents = append(trace.TraceEvents, chromeTraceEvent{
+			Name:      name,
+			Category:  span.Kind.String(),
+			Phase:     "X",
+			Timestamp: span.Start.UnixNano() / int64(time.Microsecond),
+			Duration:  span.Duration().Microseconds(),
+			PID:       int(span.Kind),
+			TID:       lane,
+			Args: map[string]interface{}{
+				"startEventID": span.StartEventID,
+				"endEventID":   span.EndEventID,
+			},
+		})
+	}
+	return json.NewEncoder(w).Encode(trace)
+}
+
+// ReportSpans reports the Timeline of the history to the tracer, as a span for the workflow with a child span for each
+// of its operations. Unlike the spans created by the tracing interceptors of the workflow, these show the timing of the
+// operations as recorded by the server, e.g. including the time activities waited in their task lists.
+func ReportSpans(tracer opentracing.Tracer, events []*shared.HistoryEvent) {
+	timeline := Timeline(events)
+	if len(timeline) == 0 {
+		return
+	}
+
+	root := startSpan(tracer, timeline[0])
+	for _, span := range timeline[1:] {
+		startSpan(tracer, span, opentracing.ChildOf(root.Context())).
+			FinishWithOptions(opentracing.FinishOptions{FinishTime: span.End})
+	}
+	root.FinishWithOptions(opentracing.FinishOptions{FinishTime: timeline[0].End})
+}
+
+func startSpan(tracer opentracing.Tracer, span Span, options ...opentracing.StartSpanOption) opentracing.Span {
+	operationName := span.Kind.String()
+	if span.Name != "" {
+		operationName += ": " + span.Name
+	}
+	options = append(options,
+		opentracing.StartTime(span.Start),
+		opentracing.Tags{
+			"cadence.kind":         span.Kind.String(),
+			"cadence.startEventID": span.StartEventID,
+			"cadence.endEventID":   span.EndEventID,
+		},
+	)
+	return tracer.StartSpan(operationName, options...)
+}
+
+// closedEventIDs maps the ID of the event which started an operation to the ID of the event which closed it.
+func closedEventIDs(events []*shared.HistoryEvent) map[int64]int64 {
+	closed := make(map[int64]int64)
+	for _, event := range events {
+		var startEventID int64
+		switch event.GetEventType() {
+		case shared.EventTypeDecisionTaskCompleted,
+			shared.EventTypeDecisionTaskFailed,
+			shared.EventTypeDecisionTaskTimedOut:
+			startEventID = decisionTaskScheduledEventID(event)
+		case shared.EventTypeActivityTaskCompleted,
+			shared.EventTypeActivityTaskFailed,
+			shared.EventTypeActivityTaskTimedOut,
+			shared.EventTypeActivityTaskCanceled:
+			startEventID = activityScheduledEventID(event)
+		case shared.EventTypeTimerFired:
+			startEventID = event.TimerFiredEventAttributes.GetStartedEventId()
+		case shared.EventTypeTimerCanceled:
+			startEventID = event.TimerCanceledEventAttributes.GetStartedEventId()
+		case shared.EventTypeChildWorkflowExecutionStarted:
+			// not a close event
+			continue
+		default:
+			if initiatedEventID, ok := childInitiatedEventID(event); ok {
+				startEventID = initiatedEventID
+			}
+		}
+		if startEventID != 0 {
+			closed[startEventID] = event.GetEventId()
+		}
+	}
+	return closed
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeline(t *testing.T) {
+	at := func(second int) time.Time {
+		return testStart.Add(time.Duration(second) * time.Second)
+	}
+	assert.Equal(t, []Span{
+		{Kind: SpanKindWorkflow, Start: at(0), End: at(15), StartEventID: 1, EndEventID: 16},
+		{Kind: SpanKindDecisionTask, Start: at(0), End: at(2), StartEventID: 2, EndEventID: 4},
+		{Kind: SpanKindActivity, Name: "activity", Start: at(2), End: at(7), StartEventID: 5, EndEventID: 8},
+		// still running when the workflow completed
+		{Kind: SpanKindTimer, Name: "1", Start: at(2), End: at(15), StartEventID: 6, EndEventID: 16},
+		{Kind: SpanKindDecisionTask, Start: at(7), End: at(9), StartEventID: 9, EndEventID: 11},
+		{Kind: SpanKindSignal, Name: "signal", Start: at(13), End: at(13), StartEventID: 12, EndEventID: 12},
+		{Kind: SpanKindDecisionTask, Start: at(13), End: at(15), StartEventID: 13, EndEventID: 15},
+	}, Timeline(newTestHistory()))
+}
+
+func TestWriteChromeTrace(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteChromeTrace(&buf, newTestHistory()))
+
+	var trace chromeTrace
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &trace))
+	require.Len(t, trace.TraceEvents, 7)
+
+	activity := trace.TraceEvents[2]
+	assert.Equal(t, "Activity: activity", activity.Name)
+	assert.Equal(t, "X", activity.Phase)
+	assert.Equal(t, testStart.Add(2*time.Second).UnixNano()/int64(time.Microsecond), activity.Timestamp)
+	assert.Equal(t, (5 * time.Second).Microseconds(), activity.Duration)
+	for _, event := range trace.TraceEvents {
+		// none of the spans of the same kind overlap
+		assert.Equal(t, 0, event.TID)
+	}
+}
+
+func TestReportSpans(t *testing.T) {
+	tracer := mocktracer.New()
+	ReportSpans(tracer, newTestHistory())
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 7)
+	root := spans[len(spans)-1]
+	assert.Equal(t, "Workflow", root.OperationName)
+	for _, span := range spans[:len(spans)-1] {
+		assert.Equal(t, root.SpanContext.SpanID, span.ParentID)
+	}
+	assert.Equal(t, "Activity: activity", spans[1].OperationName)
+	assert.Equal(t, testStart.Add(2*time.Second), spans[1].StartTime)
+	assert.Equal(t, testStart.Add(7*time.Second), spans[1].FinishTime)
+	assert.Equal(t, int64(5), spans[1].Tag("cadence.startEventID"))
+}
@@ -0,0 +1,320 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+const (
+	defaultTimeoutAdvisorPercentile   = 0.99
+	defaultTimeoutAdvisorSafetyMargin = 2
+	defaultTimeoutAdvisorMinTimeout   = time.Second
+
+	recommendedRetryInitialInterval    = time.Second
+	recommendedRetryBackoffCoefficient = 2
+	recommendedRetryMinimumAttempts    = 3
+)
+
+type (
+	// ActivitySample is a single observed execution of an activity, extracted from a history by ActivitySamples or
+	// built from the metrics emitted by workers.
+	ActivitySample struct {
+		ActivityType string
+		// ScheduleToStart is how long the activity waited in the task list, zero if unknown.
+		ScheduleToStart time.Duration
+		// StartToClose is how long the attempt ran, zero if unknown.
+		StartToClose time.Duration
+		// HeartbeatInterval is the longest interval between two heartbeats of the attempt, zero if unknown.
+		HeartbeatInterval time.Duration
+		// Attempt is the attempt of the sample, 0 for the first one.
+		Attempt int32
+		// Failed is true if the activity failed.
+		Failed bool
+		// TimedOut is true if the activity timed out. Its durations are then bounded by the timeouts it ran with
+		// rather than observed, so they are not used for the recommended timeouts.
+		TimedOut bool
+	}
+
+	// TimeoutAdvisorOptions configure RecommendTimeouts.
+	TimeoutAdvisorOptions struct {
+		// Percentile of the observed durations the timeouts are based on.
+		// Optional: defaults to 0.99.
+		Percentile float64
+		// SafetyMargin is the factor the percentile is multiplied by to get a timeout.
+		// Optional: defaults to 2.
+		SafetyMargin float64
+		// MinTimeout is the lowest recommended timeout.
+		// Optional: defaults to 1 second.
+		MinTimeout time.Duration
+	}
+
+	// TimeoutRecommendation holds the recommended options of an activity type. Timeouts are rounded up to seconds, the
+	// precision of the server, and are zero if no sample which did not time out had the corresponding duration.
+	TimeoutRecommendation struct {
+		ActivityType string
+		Samples      int
+		// TimedOut is the number of samples which timed out, and are only used for the retry policy.
+		TimedOut               int
+		ScheduleToStartTimeout time.Duration
+		StartToCloseTimeout    time.Duration
+		HeartbeatTimeout       time.Duration
+		// RetryPolicy is nil if no sample failed, timed out or was retried.
+		RetryPolicy *RetryPolicyRecommendation
+	}
+
+	// RetryPolicyRecommendation is the recommended retry policy of an activity type. MaximumAttempts is twice the
+	// number of attempts the activity needed at most, but at least 3.
+	RetryPolicyRecommendation struct {
+		InitialInterval    time.Duration
+		BackoffCoefficient float64
+		MaximumInterval    time.Duration
+		MaximumAttempts    int32
+	}
+
+	timeoutRecommendationJSON struct {
+		ActivityType           string                         `json:"activityType"`
+		Samples                int                            `json:"samples"`
+		TimedOut               int                            `json:"timedOut,omitempty"`
+		ScheduleToStartTimeout string                         `json:"scheduleToStartTimeout,omitempty"`
+		StartToCloseTimeout    string                         `json:"startToCloseTimeout,omitempty"`
+		HeartbeatTimeout       string                         `json:"heartbeatTimeout,omitempty"`
+		RetryPolicy            *retryPolicyRecommendationJSON `json:"retryPolicy,omitempty"`
+	}
+
+	retryPolicyRecommendationJSON struct {
+		InitialInterval    string  `json:"initialInterval"`
+		BackoffCoefficient float64 `json:"backoffCoefficient"`
+		MaximumInterval    string  `json:"maximumInterval"`
+		MaximumAttempts    int32   `json:"maximumAttempts"`
+	}
+)
+
+// ActivitySamples returns a sample for every closed activity of the history. Attempts before the last one of a retried
+// activity are not recorded in the history, and its schedule to start time includes them, so only the start to close
+// time of its last attempt is sampled.
+func ActivitySamples(events []*shared.HistoryEvent) []ActivitySample {
+	var samples []ActivitySample
+	for _, activity := range Activities(events) {
+		if activity.Closed.IsZero() || activity.State == ActivityStateCanceled {
+			continue
+		}
+		sample := ActivitySample{
+			ActivityType: activity.ActivityType,
+			StartToClose: activity.StartToClose(),
+			Attempt:      activity.Attempt,
+			Failed:       activity.State == ActivityStateFailed,
+			TimedOut:     activity.State == ActivityStateTimedOut,
+		}
+		if activity.Attempt == 0 {
+			sample.ScheduleToStart = activity.ScheduleToStart()
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// RecommendTimeouts recommends the timeouts and the retry policy of every activity type of the samples, sorted by
+// activity type. A timeout is the configured percentile of the observed durations multiplied by the safety margin. The
+// samples which timed out are counted separately, and only make the activity type get a retry policy.
+func RecommendTimeouts(samples []ActivitySample, options TimeoutAdvisorOptions) []TimeoutRecommendation {
+	if options.Percentile <= 0 || options.Percentile > 1 {
+		options.Percentile = defaultTimeoutAdvisorPercentile
+	}
+	if options.SafetyMargin <= 0 {
+		options.SafetyMargin = defaultTimeoutAdvisorSafetyMargin
+	}
+	if options.MinTimeout <= 0 {
+		options.MinTimeout = defaultTimeoutAdvisorMinTimeout
+	}
+
+	byType := make(map[string][]ActivitySample)
+	for _, sample := range samples {
+		byType[sample.ActivityType] = append(byType[sample.ActivityType], sample)
+	}
+
+	recommendations := make([]TimeoutRecommendation, 0, len(byType))
+	for activityType, samples := range byType {
+		var scheduleToStart, startToClose, heartbeat []time.Duration
+		var maxAttempt int32
+		var timedOut int
+		retried := false
+		for _, sample := range samples {
+			maxAttempt = max(maxAttempt, sample.Attempt)
+			retried = retried || sample.Failed || sample.TimedOut || sample.Attempt > 0
+			if sample.TimedOut {
+				timedOut++
+				continue
+			}
+			if sample.ScheduleToStart > 0 {
+				scheduleToStart = append(scheduleToStart, sample.ScheduleToStart)
+			}
+			if sample.StartToClose > 0 {
+				startToClose = append(startToClose, sample.StartToClose)
+			}
+			if sample.HeartbeatInterval > 0 {
+				heartbeat = append(heartbeat, sample.HeartbeatInterval)
+			}
+		}
+
+		recommendation := TimeoutRecommendation{
+			ActivityType:           activityType,
+			Samples:                len(samples),
+			TimedOut:               timedOut,
+			ScheduleToStartTimeout: recommendTimeout(scheduleToStart, options),
+			StartToCloseTimeout:    recommendTimeout(startToClose, options),
+			HeartbeatTimeout:       recommendTimeout(heartbeat, options),
+		}
+		if retried {
+			recommendation.RetryPolicy = &RetryPolicyRecommendation{
+				InitialInterval:    recommendedRetryInitialInterval,
+				BackoffCoefficient: recommendedRetryBackoffCoefficient,
+				MaximumInterval:    max(recommendedRetryInitialInterval, recommendation.StartToCloseTimeout),
+				MaximumAttempts:    max(recommendedRetryMinimumAttempts, 2*(maxAttempt+1)),
+			}
+		}
+		recommendations = append(recommendations, recommendation)
+	}
+	sort.Slice(recommendations, func(i, j int) bool {
+		return recommendations[i].ActivityType < recommendations[j].ActivityType
+	})
+	return recommendations
+}
+
+// WriteTimeoutRecommendationsGo writes the recommendations as Go code declaring the activity options of every activity
+// type, formatted with gofmt, to be pasted into workflow code.
+func WriteTimeoutRecommendationsGo(w io.Writer, recommendations []TimeoutRecommendation) error {
+	var b strings.Builder
+	b.WriteString("var activityOptions = map[string]workflow.ActivityOptions{\n")
+	for _, r := range recommendations {
+		if r.TimedOut > 0 {
+			fmt.Fprintf(&b, "\t// %v samples, %v timed out\n", r.Samples, r.TimedOut)
+		} else {
+			fmt.Fprintf(&b, "\t// %v samples\n", r.Samples)
+		}
+		fmt.Fprintf(&b, "\t%q: {\n", r.ActivityType)
+		writeGoDurationField(&b, "\t\t", "ScheduleToStartTimeout", r.ScheduleToStartTimeout)
+		writeGoDurationField(&b, "\t\t", "StartToCloseTimeout", r.StartToCloseTimeout)
+		writeGoDurationField(&b, "\t\t", "HeartbeatTimeout", r.HeartbeatTimeout)
+		if p := r.RetryPolicy; p != nil {
+			b.WriteString("\t\tRetryPolicy: &cadence.RetryPolicy{\n")
+			writeGoDurationField(&b, "\t\t\t", "InitialInterval", p.InitialInterval)
+			fmt.Fprintf(&b, "\t\t\tBackoffCoefficient: %v,\n", p.BackoffCoefficient)
+			writeGoDurationField(&b, "\t\t\t", "MaximumInterval", p.MaximumInterval)
+			fmt.Fprintf(&b, "\t\t\tMaximumAttempts: %v,\n", p.MaximumAttempts)
+			b.WriteString("\t\t},\n")
+		}
+		b.WriteString("\t},\n")
+	}
+	b.WriteString("}\n")
+	source, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(source)
+	return err
+}
+
+// WriteTimeoutRecommendationsJSON writes the recommendations as a JSON array, with durations formatted like
+// time.Duration.String, to be loaded as configuration.
+func WriteTimeoutRecommendationsJSON(w io.Writer, recommendations []TimeoutRecommendation) error {
+	records := make([]timeoutRecommendationJSON, 0, len(recommendations))
+	for _, r := range recommendations {
+		record := timeoutRecommendationJSON{
+			ActivityType:           r.ActivityType,
+			Samples:                r.Samples,
+			TimedOut:               r.TimedOut,
+			ScheduleToStartTimeout: jsonDuration(r.ScheduleToStartTimeout),
+			StartToCloseTimeout:    jsonDuration(r.StartToCloseTimeout),
+			HeartbeatTimeout:       jsonDuration(r.HeartbeatTimeout),
+		}
+		if p := r.RetryPolicy; p != nil {
+			record.RetryPolicy = &retryPolicyRecommendationJSON{
+				InitialInterval:    p.InitialInterval.String(),
+				BackoffCoefficient: p.BackoffCoefficient,
+				MaximumInterval:    p.MaximumInterval.String(),
+				MaximumAttempts:    p.MaximumAttempts,
+			}
+		}
+		records = append(records, record)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// recommendTimeout returns the percentile of the durations multiplied by the safety margin, rounded up to seconds.
+func recommendTimeout(durations []time.Duration, options TimeoutAdvisorOptions) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	rank := int(math.Ceil(options.Percentile*float64(len(durations)))) - 1
+	timeout := time.Duration(float64(durations[max(rank, 0)]) * options.SafetyMargin)
+	if timeout%time.Second != 0 {
+		timeout = timeout.Truncate(time.Second) + time.Second
+	}
+	return max(timeout, options.MinTimeout)
+}
+
+func writeGoDurationField(b *strings.Builder, indent string, name string, d time.Duration) {
+	if d == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%v%v: %v,\n", indent, name, goDuration(d))
+}
+
+// goDuration returns a Go expression of a duration of whole seconds.
+func goDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return goDurationMultiple(int64(d/time.Hour), "time.Hour")
+	case d%time.Minute == 0:
+		return goDurationMultiple(int64(d/time.Minute), "time.Minute")
+	case d%time.Second == 0:
+		return goDurationMultiple(int64(d/time.Second), "time.Second")
+	default:
+		return fmt.Sprintf("time.Duration(%d)", int64(d))
+	}
+}
+
+func goDurationMultiple(n int64, unit string) string {
+	if n == 1 {
+		return unit
+	}
+	return fmt.Sprintf("%d * %v", n, unit)
+}
+
+func jsonDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivitySamples(t *testing.T) {
+	samples := ActivitySamples(newTestHistory())
+	require.Len(t, samples, 1)
+	// the activity of the test history was retried, so its schedule to start time includes the previous attempts
+	assert.Equal(t, ActivitySample{
+		ActivityType: "activity",
+		StartToClose: 2 * time.Second,
+		Attempt:      2,
+	}, samples[0])
+}
+
+func newTestTimeoutRecommendations() []TimeoutRecommendation {
+	var samples []ActivitySample
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, ActivitySample{
+			ActivityType:    "upload",
+			ScheduleToStart: 500 * time.Millisecond,
+			StartToClose:    time.Duration(i) * time.Second,
+		})
+	}
+	samples = append(samples,
+		ActivitySample{ActivityType: "charge", StartToClose: 100 * time.Millisecond, HeartbeatInterval: 20 * time.Second},
+		ActivitySample{ActivityType: "charge", StartToClose: 40 * time.Second, Attempt: 2, Failed: true},
+		// the duration of a timed out attempt is its timeout, which would inflate the recommendation
+		ActivitySample{ActivityType: "charge", StartToClose: time.Hour, TimedOut: true},
+	)
+	return RecommendTimeouts(samples, TimeoutAdvisorOptions{})
+}
+
+func TestRecommendTimeouts(t *testing.T) {
+	assert.Equal(t, []TimeoutRecommendation{
+		{
+			ActivityType:        "charge",
+			Samples:             3,
+			TimedOut:            1,
+			StartToCloseTimeout: 80 * time.Second,
+			HeartbeatTimeout:    40 * time.Second,
+			RetryPolicy: &RetryPolicyRecommendation{
+				InitialInterval:    time.Second,
+				BackoffCoefficient: 2,
+				MaximumInterval:    80 * time.Second,
+				MaximumAttempts:    6,
+			},
+		},
+		{
+			ActivityType:           "upload",
+			Samples:                100,
+			ScheduleToStartTimeout: time.Second,
+			StartToCloseTimeout:    198 * time.Second,
+		},
+	}, newTestTimeoutRecommendations())
+
+	recommendations := RecommendTimeouts([]ActivitySample{{ActivityType: "fast", StartToClose: time.Millisecond}}, TimeoutAdvisorOptions{
+		Percentile:   0.5,
+		SafetyMargin: 3,
+		MinTimeout:   5 * time.Second,
+	})
+	assert.Equal(t, 5*time.Second, recommendations[0].StartToCloseTimeout)
+
+	// timed out samples only make the activity type get a retry policy
+	recommendations = RecommendTimeouts([]ActivitySample{{ActivityType: "slow", StartToClose: time.Minute, TimedOut: true}}, TimeoutAdvisorOptions{})
+	assert.Equal(t, []TimeoutRecommendation{{
+		ActivityType: "slow",
+		Samples:      1,
+		TimedOut:     1,
+		RetryPolicy: &RetryPolicyRecommendation{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+			MaximumInterval:    time.Second,
+			MaximumAttempts:    3,
+		},
+	}}, recommendations)
+}
+
+func TestWriteTimeoutRecommendationsGo(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteTimeoutRecommendationsGo(&buf, newTestTimeoutRecommendations()))
+	assert.Equal(t, `var activityOptions = map[string]workflow.ActivityOptions{
+	// 3 samples, 1 timed out
+	"charge": {
+		StartToCloseTimeout: 80 * time.Second,
+		HeartbeatTimeout:    40 * time.Second,
+		RetryPolicy: &cadence.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+			MaximumInterval:    80 * time.Second,
+			MaximumAttempts:    6,
+		},
+	},
+	// 100 samples
+	"upload": {
+		ScheduleToStartTimeout: time.Second,
+		StartToCloseTimeout:    198 * time.Second,
+	},
+}
+`, buf.String())
+}
+
+func TestWriteTimeoutRecommendationsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteTimeoutRecommendationsJSON(&buf, newTestTimeoutRecommendations()))
+	var config []map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &config))
+	require.Len(t, config, 2)
+	assert.Equal(t, map[string]interface{}{
+		"activityType":        "charge",
+		"samples":             float64(3),
+		"timedOut":            float64(1),
+		"startToCloseTimeout": "1m20s",
+		"heartbeatTimeout":    "40s",
+		"retryPolicy": map[string]interface{}{
+			"initialInterval":    "1s",
+			"backoffCoefficient": float64(2),
+			"maximumInterval":    "1m20s",
+			"maximumAttempts":    float64(6),
+		},
+	}, config[0])
+	assert.Equal(t, "3m18s", config[1]["startToCloseTimeout"])
+	assert.NotContains(t, config[1], "retryPolicy")
+	assert.NotContains(t, config[1], "timedOut")
+}
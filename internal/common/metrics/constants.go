@@ -51,8 +51,11 @@ const (
 	DecisionResponseFailedCounter      = CadenceMetricsPrefix + "decision-response-failed"
 	DecisionResponseLatency            = CadenceMetricsPrefix + "decision-response-latency"
 	DecisionTaskPanicCounter           = CadenceMetricsPrefix + "decision-task-panic"
+	DecisionTaskPanicByDecisionCounter = CadenceMetricsPrefix + "decision-task-panic-by-decision" // tagged by the type of the last decision
 	DecisionTaskCompletedCounter       = CadenceMetricsPrefix + "decision-task-completed"
 	DecisionTaskForceCompleted         = CadenceMetricsPrefix + "decision-task-force-completed"
+	DecisionTaskShutdownCheckpoint     = CadenceMetricsPrefix + "decision-task-shutdown-checkpoint"
+	DecisionCounter                    = CadenceMetricsPrefix + "decision-total" // decisions sent per decision task, tagged by decision type
 
 	ActivityPollCounter                         = CadenceMetricsPrefix + "activity-poll-total"
 	ActivityPollFailedCounter                   = CadenceMetricsPrefix + "activity-poll-failed"
@@ -86,6 +89,13 @@ const (
 	ActivityLocalDispatchSucceedCounter         = CadenceMetricsPrefix + "activity-local-dispatch-succeed"
 	WorkerPanicCounter                          = CadenceMetricsPrefix + "worker-panic"
 
+	QueryCounter       = CadenceMetricsPrefix + "query-total"
+	QueryFailedCounter = CadenceMetricsPrefix + "query-failed"
+	QueryLatency       = CadenceMetricsPrefix + "query-latency"
+	QuerySlowCounter   = CadenceMetricsPrefix + "query-slow"
+	QueryHedgeCounter  = CadenceMetricsPrefix + "query-hedge"
+	QueryHedgeWins     = CadenceMetricsPrefix + "query-hedge-win"
+
 	UnhandledSignalsCounter = CadenceMetricsPrefix + "unhandled-signals"
 	CorruptedSignalsCounter = CadenceMetricsPrefix + "corrupted-signals"
 
@@ -103,8 +113,15 @@ const (
 	StickyCacheStall = CadenceMetricsPrefix + "sticky-cache-stall"
 	StickyCacheSize  = CadenceMetricsPrefix + "sticky-cache-size"
 
+	WorkflowCacheEviction = CadenceMetricsPrefix + "workflow-cache-eviction"
+	FullReplayCounter     = CadenceMetricsPrefix + "full-replay"
+	FullReplayEvents      = CadenceMetricsPrefix + "full-replay-events"
+	FullReplayLatency     = CadenceMetricsPrefix + "full-replay-latency"
+
 	NonDeterministicError = CadenceMetricsPrefix + "non-deterministic-error"
 
+	ShadowScannedCounter = CadenceMetricsPrefix + "shadow-scanned"
+	ReplayCounter        = CadenceMetricsPrefix + "replay"
 	ReplaySucceedCounter = CadenceMetricsPrefix + "replay-succeed"
 	ReplayFailedCounter  = CadenceMetricsPrefix + "replay-failed"
 	ReplaySkippedCounter = CadenceMetricsPrefix + "replay-skipped"
@@ -114,4 +131,14 @@ const (
 	ServerSideHistorySize    = CadenceMetricsPrefix + "server-side-history-size"
 	ConcurrentTaskQuota      = CadenceMetricsPrefix + "concurrent-task-quota"
 	PollerRequestBufferUsage = CadenceMetricsPrefix + "poller-request-buffer-usage"
+
+	HistoryLimitWarningCounter = CadenceMetricsPrefix + "history-limit-warning"
+
+	WorkflowProfileDecisions        = CadenceMetricsPrefix + "workflow-profile-decisions"
+	WorkflowProfileActivities       = CadenceMetricsPrefix + "workflow-profile-activities"
+	WorkflowProfileActivityRetries  = CadenceMetricsPrefix + "workflow-profile-activity-retries"
+	WorkflowProfileActivityDuration = CadenceMetricsPrefix + "workflow-profile-activity-duration"
+
+	SampledDecisionTaskLatency = CadenceMetricsPrefix + "sampled-decision-task-latency"
+	SampledDecisionPayloadSize = CadenceMetricsPrefix + "sampled-decision-payload-size"
 )
@@ -0,0 +1,67 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// PayloadEnvelopeVersion is the version of the envelope format of the JSON arguments of a payload.
+const PayloadEnvelopeVersion = 1
+
+// PayloadEnvelopePrefix starts every payload in the envelope format, as the version is its first field.
+var PayloadEnvelopePrefix = []byte(`{"cadencePayload":`)
+
+// PayloadEnvelope is the envelope format of the JSON arguments of a payload.
+type PayloadEnvelope struct {
+	Version int               `json:"cadencePayload"`
+	Args    []json.RawMessage `json:"args"`
+}
+
+// SplitJSONPayload returns the arguments of a payload in the envelope format, or in the JSON array format when count,
+// the number of arguments expected, is more than one. It returns false for the other payloads, which are in the
+// newline delimited format if they are JSON. A single argument written in the JSON array format is its JSON value, so
+// a JSON array is only split when several arguments are expected.
+func SplitJSONPayload(data []byte, count int) ([]json.RawMessage, bool, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, PayloadEnvelopePrefix) {
+		var envelope PayloadEnvelope
+		if err := json.Unmarshal(trimmed, &envelope); err != nil {
+			return nil, false, fmt.Errorf("unable to decode payload envelope, with json error: %v", err)
+		}
+		if envelope.Version != PayloadEnvelopeVersion {
+			return nil, false, fmt.Errorf("unsupported payload envelope version %d", envelope.Version)
+		}
+		return envelope.Args, true, nil
+	}
+	if count > 1 && len(trimmed) > 0 && trimmed[0] == '[' {
+		// several arguments in the JSON array format are a single array, while in the newline delimited format an
+		// array can only be the first of several values
+		var args []json.RawMessage
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		if err := dec.Decode(&args); err == nil && !dec.More() {
+			return args, true, nil
+		}
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tasktoken parses and serializes the activity task tokens issued by the Cadence server.
+package tasktoken
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// TaskToken is the content of an activity task token, as serialized by the Cadence server.
+type TaskToken struct {
+	DomainID        string `json:"domainId"`
+	WorkflowID      string `json:"workflowId"`
+	WorkflowType    string `json:"workflowType"`
+	RunID           string `json:"runId"`
+	ScheduleID      int64  `json:"scheduleId"`
+	ScheduleAttempt int64  `json:"scheduleAttempt"`
+	ActivityID      string `json:"activityId"`
+	ActivityType    string `json:"activityType"`
+}
+
+// Parse decodes and validates a task token.
+func Parse(token []byte) (*TaskToken, error) {
+	if len(token) == 0 {
+		return nil, errors.New("task token is empty")
+	}
+	var t TaskToken
+	if err := json.Unmarshal(token, &t); err != nil {
+		return nil, fmt.Errorf("unable to decode task token: %w", err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Serialize validates and encodes the task token in the format expected by the Cadence server.
+func (t *TaskToken) Serialize() ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(t)
+}
+
+// Validate returns an error if the task token does not identify an activity of a workflow run.
+func (t *TaskToken) Validate() error {
+	switch {
+	case t.DomainID == "":
+		return errors.New("invalid task token: domain ID is not set")
+	case t.WorkflowID == "":
+		return errors.New("invalid task token: workflow ID is not set")
+	case t.RunID == "":
+		return errors.New("invalid task token: run ID is not set")
+	case t.ScheduleID <= 0 && t.ActivityID == "":
+		return errors.New("invalid task token: neither schedule ID nor activity ID is set")
+	case t.ScheduleAttempt < 0:
+		return fmt.Errorf("invalid task token: schedule attempt %v is negative", t.ScheduleAttempt)
+	}
+	return nil
+}
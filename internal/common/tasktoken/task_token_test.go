@@ -0,0 +1,79 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tasktoken
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	// a token as issued by the server
+	token := []byte(`{"domainId":"f1c3b1a8-6b4d-4f3c-9d1e-2a5b7c9d0e1f","workflowId":"wid","workflowType":"wtype",` +
+		`"runId":"rid","scheduleId":5,"scheduleAttempt":2,"activityId":"aid","activityType":"atype"}`)
+
+	parsed, err := Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, &TaskToken{
+		DomainID:        "f1c3b1a8-6b4d-4f3c-9d1e-2a5b7c9d0e1f",
+		WorkflowID:      "wid",
+		WorkflowType:    "wtype",
+		RunID:           "rid",
+		ScheduleID:      5,
+		ScheduleAttempt: 2,
+		ActivityID:      "aid",
+		ActivityType:    "atype",
+	}, parsed)
+
+	serialized, err := parsed.Serialize()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(token), string(serialized))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		err   string
+	}{
+		{"empty", ``, "task token is empty"},
+		{"not json", `opaque`, "unable to decode task token"},
+		{"no domain", `{"workflowId":"wid","runId":"rid","scheduleId":5}`, "domain ID is not set"},
+		{"no workflow", `{"domainId":"did","runId":"rid","scheduleId":5}`, "workflow ID is not set"},
+		{"no run", `{"domainId":"did","workflowId":"wid","scheduleId":5}`, "run ID is not set"},
+		{"no activity", `{"domainId":"did","workflowId":"wid","runId":"rid"}`, "neither schedule ID nor activity ID is set"},
+		{"negative attempt", `{"domainId":"did","workflowId":"wid","runId":"rid","activityId":"aid","scheduleAttempt":-1}`, "schedule attempt -1 is negative"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse([]byte(test.token))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
+
+func TestSerialize_Invalid(t *testing.T) {
+	_, err := (&TaskToken{WorkflowID: "wid", RunID: "rid", ScheduleID: 5}).Serialize()
+	assert.EqualError(t, err, "invalid task token: domain ID is not set")
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"go.uber.org/zap"
+)
+
+type (
+	// CorruptSignal is a signal which could not be decoded into the value passed to Receive of its signal channel.
+	// Corrupt signals are dropped from the signal channel, see SetCorruptSignalHandler and GetCorruptSignalChannel to
+	// capture them.
+	CorruptSignal struct {
+		// SignalName is the name of the signal channel the signal was received on.
+		SignalName string
+		// Payload is the raw signal input, which can be decoded again with the data converter of the workflow.
+		// It is nil for values sent to the signal channel by the workflow itself.
+		Payload []byte
+		// Err is the error returned when decoding the signal.
+		Err error
+	}
+
+	// corruptSignalOptions are shared by all signal channels of a workflow.
+	corruptSignalOptions struct {
+		handler func(signal CorruptSignal)
+		channel Channel
+	}
+)
+
+// SetCorruptSignalHandler registers a handler which is called with every signal that fails to be decoded when it is
+// received, before it is dropped. The handler runs synchronously inside Receive, so it must not block. It replaces
+// the handler set by previous calls, a nil handler removes it.
+func SetCorruptSignalHandler(ctx Context, handler func(signal CorruptSignal)) {
+	getWorkflowEnvOptions(ctx).corruptSignalOptions.handler = handler
+}
+
+// GetCorruptSignalChannel returns a Channel which receives a CorruptSignal for every signal that fails to be decoded
+// when it is received, so it can be inspected or reprocessed by the workflow. Corrupt signals exceeding the buffer of
+// the channel are dropped.
+func GetCorruptSignalChannel(ctx Context) Channel {
+	options := getWorkflowEnvOptions(ctx).corruptSignalOptions
+	if options.channel == nil {
+		options.channel = NewNamedBufferedChannel(ctx, "corrupt-signals", defaultSignalChannelSize)
+	}
+	return options.channel
+}
+
+func (o *corruptSignalOptions) capture(env workflowEnvironment, signalName string, value interface{}, err error) {
+	signal := CorruptSignal{SignalName: signalName, Err: err}
+	if payload, ok := value.([]byte); ok {
+		signal.Payload = payload
+	}
+	if o.handler != nil {
+		o.handler(signal)
+	}
+	if o.channel != nil && !o.channel.SendAsync(signal) {
+		env.GetLogger().Warn("Corrupt signal channel is full, dropping corrupt signal",
+			zap.String("SignalName", signalName))
+	}
+}
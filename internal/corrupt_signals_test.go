@@ -0,0 +1,94 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorruptSignals(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	newEnv := func() *TestWorkflowEnvironment {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow("count", "not-a-number")
+			env.SignalWorkflow("count", 5)
+		}, time.Minute)
+		return env
+	}
+
+	t.Run("handler", func(t *testing.T) {
+		var captured []CorruptSignal
+		workflowFn := func(ctx Context) (int, error) {
+			SetCorruptSignalHandler(ctx, func(signal CorruptSignal) {
+				captured = append(captured, signal)
+			})
+			var count int
+			GetSignalChannel(ctx, "count").Receive(ctx, &count)
+			return count, nil
+		}
+		env := newEnv()
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var result int
+		require.NoError(t, env.GetWorkflowResult(&result))
+		assert.Equal(t, 5, result)
+
+		require.Len(t, captured, 1)
+		assert.Equal(t, "count", captured[0].SignalName)
+		assert.Error(t, captured[0].Err)
+		var payload string
+		require.NoError(t, newEncodedValue(captured[0].Payload, nil).Get(&payload))
+		assert.Equal(t, "not-a-number", payload)
+	})
+
+	t.Run("channel", func(t *testing.T) {
+		workflowFn := func(ctx Context) (string, error) {
+			corrupt := GetCorruptSignalChannel(ctx)
+			var count int
+			GetSignalChannel(ctx, "count").Receive(ctx, &count)
+
+			var signal CorruptSignal
+			if !corrupt.ReceiveAsync(&signal) {
+				return "", NewCustomError("no corrupt signal")
+			}
+			// reprocess the corrupt signal with the expected type
+			var payload string
+			if err := newEncodedValue(signal.Payload, nil).Get(&payload); err != nil {
+				return "", err
+			}
+			return signal.SignalName + ":" + payload, nil
+		}
+		env := newEnv()
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var result string
+		require.NoError(t, env.GetWorkflowResult(&result))
+		assert.Equal(t, "count:not-a-number", result)
+	})
+}
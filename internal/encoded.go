@@ -64,8 +64,49 @@ type (
 		FromData(input []byte, valuePtr ...interface{}) error
 	}
 
+	// DataConverterOptions configures the JSON encoding of the DataConverter created with NewDataConverter. The zero
+	// value is the encoding of the default data converter.
+	DataConverterOptions struct {
+		// DisableUseNumber decodes JSON numbers into interface{} values as float64, instead of json.Number.
+		DisableUseNumber bool
+
+		// DisallowUnknownFields fails decoding a JSON object into a struct which has no field for one of its keys,
+		// instead of ignoring the key.
+		DisallowUnknownFields bool
+
+		// UseBinaryMarshaler encodes values implementing encoding.BinaryMarshaler, but neither json.Marshaler nor
+		// encoding.TextMarshaler, as a JSON string holding the base64 of their MarshalBinary. Such values are
+		// otherwise encoded as JSON objects of their exported fields. Decoding supports both forms whatever this
+		// option, so it can be enabled on the writers of a payload once all its readers run this version.
+		UseBinaryMarshaler bool
+
+		// PayloadFormat is how several JSON arguments are written in a payload. Decoding supports all the formats
+		// whatever this option, so a new format can be enabled on the writers of a payload once all its readers run
+		// this version.
+		// default: PayloadFormatNewlineDelimited
+		PayloadFormat PayloadFormat
+	}
+
+	// PayloadFormat is how the JSON arguments of a payload are laid out, see DataConverterOptions.PayloadFormat.
+	PayloadFormat int
+
 	// defaultDataConverter uses thrift encoder/decoder when possible, for everything else use json.
-	defaultDataConverter struct{}
+	defaultDataConverter struct {
+		json jsonEncoding
+	}
+)
+
+const (
+	// PayloadFormatNewlineDelimited writes every argument as a JSON value followed by a newline. It is the format
+	// of the Go client, which other clients cannot split into arguments.
+	PayloadFormatNewlineDelimited PayloadFormat = iota
+	// PayloadFormatJSONArray writes a single argument as its JSON value and several arguments as a JSON array of
+	// them, like the JSON data converter of the Java client.
+	PayloadFormatJSONArray
+	// PayloadFormatEnvelope writes the arguments in a versioned JSON object,
+	// {"cadencePayload":1,"args":[<arguments>]}, which states the number of arguments even for a single one, so
+	// that clients in any language can decode it without knowing the signature of the workflow or activity.
+	PayloadFormatEnvelope
 )
 
 var defaultJSONDataConverter = &defaultDataConverter{}
@@ -78,6 +119,19 @@ func getDefaultDataConverter() DataConverter {
 	return defaultJSONDataConverter
 }
 
+// NewDataConverter returns the default data converter, which uses thrift for thrift structs and JSON for
+// everything else, with the JSON encoding configured by options.
+func NewDataConverter(options DataConverterOptions) DataConverter {
+	return &defaultDataConverter{
+		json: jsonEncoding{
+			disableUseNumber:      options.DisableUseNumber,
+			disallowUnknownFields: options.DisallowUnknownFields,
+			useBinaryMarshaler:    options.UseBinaryMarshaler,
+			payloadFormat:         options.PayloadFormat,
+		},
+	}
+}
+
 func (dc *defaultDataConverter) ToData(r ...interface{}) ([]byte, error) {
 	if len(r) == 1 && util.IsTypeByteSlice(reflect.TypeOf(r[0])) {
 		return r[0].([]byte), nil
@@ -87,7 +141,7 @@ func (dc *defaultDataConverter) ToData(r ...interface{}) ([]byte, error) {
 	if common.IsUseThriftEncoding(r) {
 		encoder = &thriftEncoding{}
 	} else {
-		encoder = &jsonEncoding{}
+		encoder = dc.json
 	}
 
 	data, err := encoder.Marshal(r)
@@ -107,7 +161,7 @@ func (dc *defaultDataConverter) FromData(data []byte, to ...interface{}) error {
 	if common.IsUseThriftDecoding(to) {
 		encoder = &thriftEncoding{}
 	} else {
-		encoder = &jsonEncoding{}
+		encoder = dc.json
 	}
 
 	return encoder.Unmarshal(data, to)
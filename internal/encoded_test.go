@@ -23,6 +23,7 @@ package internal
 import (
 	"bytes"
 	"encoding/gob"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing"
@@ -128,3 +129,147 @@ func TestDecodeArg(t *testing.T) {
 	require.NoError(t, err)
 	require.Error(t, decodeArg(dc, b, &r))
 }
+
+// testBinaryValue implements encoding.BinaryMarshaler only, encoding/json encodes it as an object of its fields.
+type testBinaryValue struct {
+	A, B byte
+}
+
+func (v testBinaryValue) MarshalBinary() ([]byte, error) {
+	return []byte{v.A, v.B}, nil
+}
+
+func (v *testBinaryValue) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return fmt.Errorf("invalid length %d", len(data))
+	}
+	v.A, v.B = data[0], data[1]
+	return nil
+}
+
+func TestDataConverterOptions(t *testing.T) {
+	t.Parallel()
+	type payload struct {
+		Name  string
+		Value interface{}
+	}
+
+	t.Run("raw message", func(t *testing.T) {
+		t.Parallel()
+		dc := getDefaultDataConverter()
+		raw := json.RawMessage("{\"b\": 1,\n  \"a\": [1, 2]}")
+		data, err := dc.ToData(raw, "next", json.RawMessage(nil))
+		require.NoError(t, err)
+		// the raw message is compacted but keeps the order of its fields
+		require.Equal(t, "{\"b\":1,\"a\":[1,2]}\n\"next\"\nnull\n", string(data))
+
+		var decoded json.RawMessage
+		var next string
+		require.NoError(t, dc.FromData(data, &decoded, &next, new(json.RawMessage)))
+		require.Equal(t, json.RawMessage(`{"b":1,"a":[1,2]}`), decoded)
+		require.Equal(t, "next", next)
+
+		_, err = dc.ToData(json.RawMessage(`{"invalid"`))
+		require.Error(t, err)
+	})
+	t.Run("use number", func(t *testing.T) {
+		t.Parallel()
+		data, err := getDefaultDataConverter().ToData(payload{Value: 1})
+		require.NoError(t, err)
+
+		var decoded payload
+		require.NoError(t, getDefaultDataConverter().FromData(data, &decoded))
+		require.Equal(t, json.Number("1"), decoded.Value)
+		require.NoError(t, NewDataConverter(DataConverterOptions{DisableUseNumber: true}).FromData(data, &decoded))
+		require.Equal(t, float64(1), decoded.Value)
+	})
+	t.Run("unknown fields", func(t *testing.T) {
+		t.Parallel()
+		data := []byte(`{"Name": "name", "Unknown": true}`)
+		var decoded payload
+		require.NoError(t, getDefaultDataConverter().FromData(data, &decoded))
+		require.Equal(t, "name", decoded.Name)
+		err := NewDataConverter(DataConverterOptions{DisallowUnknownFields: true}).FromData(data, &decoded)
+		require.ErrorContains(t, err, "unknown field")
+	})
+	t.Run("binary marshaler", func(t *testing.T) {
+		t.Parallel()
+		value := testBinaryValue{A: 1, B: 2}
+		legacy, err := getDefaultDataConverter().ToData(value)
+		require.NoError(t, err)
+		require.Equal(t, "{\"A\":1,\"B\":2}\n", string(legacy))
+
+		dc := NewDataConverter(DataConverterOptions{UseBinaryMarshaler: true})
+		data, err := dc.ToData(value, &value)
+		require.NoError(t, err)
+		require.Equal(t, "\"AQI=\"\n\"AQI=\"\n", string(data))
+
+		// both forms are decoded whatever the options
+		for _, input := range [][]byte{legacy, data} {
+			var decoded testBinaryValue
+			require.NoError(t, getDefaultDataConverter().FromData(input, &decoded))
+			require.Equal(t, value, decoded)
+		}
+	})
+	t.Run("payload formats", func(t *testing.T) {
+		t.Parallel()
+		item := payload{Name: "<item>"}
+		tests := map[PayloadFormat]struct {
+			one, several, none string
+		}{
+			PayloadFormatNewlineDelimited: {
+				one:     "{\"Name\":\"\\u003citem\\u003e\",\"Value\":null}\n",
+				several: "\"a\"\n[1,2]\n{\"x\":1}\n",
+				none:    "",
+			},
+			PayloadFormatJSONArray: {
+				one:     "{\"Name\":\"\\u003citem\\u003e\",\"Value\":null}",
+				several: "[\"a\",[1,2],{\"x\":1}]",
+				none:    "",
+			},
+			PayloadFormatEnvelope: {
+				one:     "{\"cadencePayload\":1,\"args\":[{\"Name\":\"\\u003citem\\u003e\",\"Value\":null}]}",
+				several: "{\"cadencePayload\":1,\"args\":[\"a\",[1,2],{\"x\":1}]}",
+				none:    "{\"cadencePayload\":1,\"args\":[]}",
+			},
+		}
+		for format, expected := range tests {
+			dc := NewDataConverter(DataConverterOptions{PayloadFormat: format})
+
+			data, err := dc.ToData(item)
+			require.NoError(t, err)
+			require.Equal(t, expected.one, string(data))
+			var decodedItem payload
+			require.NoError(t, getDefaultDataConverter().FromData(data, &decodedItem))
+			require.Equal(t, item, decodedItem)
+
+			data, err = dc.ToData("a", []int{1, 2}, json.RawMessage(`{"x": 1}`))
+			require.NoError(t, err)
+			require.Equal(t, expected.several, string(data))
+			var s string
+			var ints []int
+			var raw json.RawMessage
+			require.NoError(t, getDefaultDataConverter().FromData(data, &s, &ints, &raw))
+			require.Equal(t, "a", s)
+			require.Equal(t, []int{1, 2}, ints)
+			require.Equal(t, `{"x":1}`, string(raw))
+
+			data, err = dc.ToData()
+			require.NoError(t, err)
+			require.Equal(t, expected.none, string(data))
+		}
+
+		// a newline delimited payload whose first argument is an array is not mistaken for a JSON array payload
+		var ints []int
+		var s string
+		require.NoError(t, getDefaultDataConverter().FromData([]byte("[1,2]\n\"a\"\n"), &ints, &s))
+		require.Equal(t, []int{1, 2}, ints)
+		require.Equal(t, "a", s)
+
+		// none of the formats accepts fewer arguments than expected
+		require.ErrorContains(t, getDefaultDataConverter().FromData([]byte("\"a\"\n"), &s, &ints), "unable to decode argument: 1, *[]int, with json error: EOF")
+		require.ErrorContains(t, getDefaultDataConverter().FromData([]byte(`["a"]`), &s, &ints), "payload has 1 arguments, 2 expected")
+		require.ErrorContains(t, getDefaultDataConverter().FromData([]byte(`{"cadencePayload":1,"args":["a"]}`), &s, &ints), "payload has 1 arguments, 2 expected")
+		require.ErrorContains(t, getDefaultDataConverter().FromData([]byte(`{"cadencePayload":2,"args":[]}`), &s), "unsupported payload envelope version 2")
+	})
+}
@@ -22,10 +22,12 @@ package internal
 
 import (
 	"bytes"
+	stdencoding "encoding"
 	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
+	"strconv"
 
 	"github.com/apache/thrift/lib/go/thrift"
 
@@ -38,32 +40,118 @@ type encoding interface {
 	Unmarshal([]byte, []interface{}) error
 }
 
-// jsonEncoding encapsulates json encoding and decoding
+// jsonEncoding encapsulates json encoding and decoding.
+// json.RawMessage values are written compacted but otherwise unmodified, and values implementing encoding.BinaryUnmarshaler can be decoded
+// from the base64 of their binary form, see DataConverterOptions.
+// Arguments are written in payloadFormat, and decoded from any PayloadFormat.
 type jsonEncoding struct {
+	disableUseNumber      bool
+	disallowUnknownFields bool
+	useBinaryMarshaler    bool
+	payloadFormat         PayloadFormat
 }
 
+var (
+	jsonMarshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType   = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType     = reflect.TypeOf((*stdencoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*stdencoding.TextUnmarshaler)(nil)).Elem()
+	binaryMarshalerType   = reflect.TypeOf((*stdencoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*stdencoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
 // Marshal encodes an array of object into bytes
 func (g jsonEncoding) Marshal(objs []interface{}) ([]byte, error) {
-	var buf bytes.Buffer
-	enc := json.NewEncoder(&buf)
+	args := make([]json.RawMessage, len(objs))
 	for i, obj := range objs {
-		if err := enc.Encode(obj); err != nil {
-			if err == io.EOF {
-				return nil, fmt.Errorf("missing argument at index %d of type %T", i, obj)
-			}
+		arg, err := g.marshalArg(i, obj)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = arg
+	}
+
+	switch g.payloadFormat {
+	case PayloadFormatJSONArray:
+		if len(args) == 0 {
+			return nil, nil
+		}
+		if len(args) == 1 {
+			return args[0], nil
+		}
+		return joinJSONArray(nil, args), nil
+	case PayloadFormatEnvelope:
+		prefix := common.PayloadEnvelopePrefix
+		data := append(prefix[:len(prefix):len(prefix)], strconv.Itoa(common.PayloadEnvelopeVersion)...)
+		data = append(data, `,"args":`...)
+		data = joinJSONArray(data, args)
+		return append(data, '}'), nil
+	default:
+		var buf bytes.Buffer
+		for _, arg := range args {
+			buf.Write(arg)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// joinJSONArray appends the JSON array of args to data, keeping the arguments unmodified.
+func joinJSONArray(data []byte, args []json.RawMessage) []byte {
+	data = append(data, '[')
+	for i, arg := range args {
+		if i > 0 {
+			data = append(data, ',')
+		}
+		data = append(data, arg...)
+	}
+	return append(data, ']')
+}
+
+func (g jsonEncoding) marshalArg(i int, obj interface{}) (json.RawMessage, error) {
+	if raw, ok := asRawMessage(obj); ok {
+		if len(raw) == 0 {
+			raw = json.RawMessage("null")
+		}
+		if !json.Valid(raw) {
+			return nil, fmt.Errorf("unable to encode argument: %d, %v, with json error: invalid raw message", i, reflect.TypeOf(obj))
+		}
+		// the newlines of a raw message would split it into several arguments in PayloadFormatNewlineDelimited
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			return nil, fmt.Errorf("unable to encode argument: %d, %v, with json error: %v", i, reflect.TypeOf(obj), err)
+		}
+		return buf.Bytes(), nil
+	}
+	if g.useBinaryMarshaler && usesBinaryMarshaler(obj) {
+		data, err := obj.(stdencoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
 			return nil, fmt.Errorf(
-				"unable to encode argument: %d, %v, with json error: %v", i, reflect.TypeOf(obj), err)
+				"unable to encode argument: %d, %v, with binary marshaler error: %v", i, reflect.TypeOf(obj), err)
 		}
+		obj = data
 	}
-	return buf.Bytes(), nil
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"unable to encode argument: %d, %v, with json error: %v", i, reflect.TypeOf(obj), err)
+	}
+	return data, nil
 }
 
 // Unmarshal decodes a byte array into the passed in objects
 func (g jsonEncoding) Unmarshal(data []byte, objs []interface{}) error {
-	dec := json.NewDecoder(bytes.NewBuffer(data))
-	dec.UseNumber()
+	args, ok, err := common.SplitJSONPayload(data, len(objs))
+	if err != nil {
+		return err
+	}
+	if ok {
+		return g.unmarshalArgs(args, objs)
+	}
+
+	dec := g.newDecoder(bytes.NewReader(data))
 	for i, obj := range objs {
-		if err := dec.Decode(obj); err != nil {
+		if err := g.decodeArg(dec, obj); err != nil {
 			return fmt.Errorf(
 				"unable to decode argument: %d, %v, with json error: %v", i, reflect.TypeOf(obj), err)
 		}
@@ -71,6 +159,88 @@ func (g jsonEncoding) Unmarshal(data []byte, objs []interface{}) error {
 	return nil
 }
 
+func (g jsonEncoding) unmarshalArgs(args []json.RawMessage, objs []interface{}) error {
+	if len(args) < len(objs) {
+		return fmt.Errorf("payload has %d arguments, %d expected", len(args), len(objs))
+	}
+	for i, obj := range objs {
+		if err := g.decodeArg(g.newDecoder(bytes.NewReader(args[i])), obj); err != nil {
+			return fmt.Errorf(
+				"unable to decode argument: %d, %v, with json error: %v", i, reflect.TypeOf(obj), err)
+		}
+	}
+	return nil
+}
+
+func (g jsonEncoding) decodeArg(dec *json.Decoder, obj interface{}) error {
+	if usesBinaryUnmarshaler(obj) {
+		return g.decodeBinaryUnmarshaler(dec, obj)
+	}
+	return dec.Decode(obj)
+}
+
+func (g jsonEncoding) newDecoder(r io.Reader) *json.Decoder {
+	dec := json.NewDecoder(r)
+	if !g.disableUseNumber {
+		dec.UseNumber()
+	}
+	if g.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	return dec
+}
+
+// decodeBinaryUnmarshaler decodes obj from a JSON string holding the base64 of its binary form, or from its JSON
+// object form.
+func (g jsonEncoding) decodeBinaryUnmarshaler(dec *json.Decoder, obj interface{}) error {
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	if len(raw) == 0 || raw[0] != '"' {
+		return g.newDecoder(bytes.NewReader(raw)).Decode(obj)
+	}
+	var data []byte
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return err
+	}
+	return obj.(stdencoding.BinaryUnmarshaler).UnmarshalBinary(data)
+}
+
+func asRawMessage(obj interface{}) (json.RawMessage, bool) {
+	switch raw := obj.(type) {
+	case json.RawMessage:
+		return raw, true
+	case *json.RawMessage:
+		if raw == nil {
+			return nil, true
+		}
+		return *raw, true
+	}
+	return nil, false
+}
+
+// usesBinaryMarshaler returns whether obj implements encoding.BinaryMarshaler, and has no JSON or text form which
+// encoding/json would use instead.
+func usesBinaryMarshaler(obj interface{}) bool {
+	t := reflect.TypeOf(obj)
+	if t == nil || !t.Implements(binaryMarshalerType) || t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return false
+	}
+	v := reflect.ValueOf(obj)
+	return t.Kind() != reflect.Ptr || !v.IsNil()
+}
+
+// usesBinaryUnmarshaler returns whether obj implements encoding.BinaryUnmarshaler, and has no JSON or text form which
+// encoding/json would use instead. Values of string kinds are decoded by encoding/json from JSON strings.
+func usesBinaryUnmarshaler(obj interface{}) bool {
+	t := reflect.TypeOf(obj)
+	if t == nil || t.Kind() != reflect.Ptr || reflect.ValueOf(obj).IsNil() || t.Elem().Kind() == reflect.String {
+		return false
+	}
+	return t.Implements(binaryUnmarshalerType) && !t.Implements(jsonUnmarshalerType) && !t.Implements(textUnmarshalerType)
+}
+
 // thriftEncoding encapsulates thrift serializer/de-serializer.
 type thriftEncoding struct{}
 
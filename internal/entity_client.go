@@ -0,0 +1,107 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+type (
+	// EntityClientOptions configure an EntityClient.
+	EntityClientOptions struct {
+		// WorkflowIDPrefix - The prefix of the workflow IDs of the entities, which are followed by the key of the
+		// entity, e.g. "cart:" for the entities "cart:<user ID>".
+		// Optional: default is empty, i.e. the keys are the workflow IDs.
+		WorkflowIDPrefix string
+
+		// Workflow - The workflow of the entities, which typically calls RunEntity. Required.
+		Workflow interface{}
+
+		// WorkflowOptions - The options the workflows of the entities are started with. The ID is set from the key.
+		// TaskList and ExecutionStartToCloseTimeout are required.
+		WorkflowOptions StartWorkflowOptions
+
+		// WorkflowIDReusePolicy - The policy for starting the workflow of an entity whose previous workflow closed.
+		// The WorkflowIDReusePolicy of WorkflowOptions is ignored.
+		// Optional: defaulted to WorkflowIDReusePolicyAllowDuplicate, so the next signal starts the workflow of an
+		// entity again after it completed, e.g. after its EntityOptions.IdleTimeout.
+		WorkflowIDReusePolicy *WorkflowIDReusePolicy
+	}
+
+	// EntityClient sends signals to entities, i.e. long-running singleton workflows per key, which are started by
+	// the first signal sent to their key. See RunEntity for running the workflow of an entity.
+	EntityClient struct {
+		client        Client
+		options       EntityClientOptions
+		dataConverter DataConverter // of the client, which encodes the payloads of EntityCommandRequest
+	}
+)
+
+// NewEntityClient returns a client for the entities of the given options, which sends the signals with client.
+func NewEntityClient(client Client, options EntityClientOptions) (*EntityClient, error) {
+	if options.Workflow == nil {
+		return nil, errors.New("entity client Workflow is required")
+	}
+	policy := WorkflowIDReusePolicyAllowDuplicate
+	if options.WorkflowIDReusePolicy != nil {
+		policy = *options.WorkflowIDReusePolicy
+	}
+	options.WorkflowOptions.WorkflowIDReusePolicy = policy
+	dataConverter := getDefaultDataConverter()
+	if wc, ok := client.(*workflowClient); ok {
+		dataConverter = wc.dataConverter
+	}
+	return &EntityClient{client: client, options: options, dataConverter: dataConverter}, nil
+}
+
+// WorkflowID returns the workflow ID of the entity with the given key.
+func (e *EntityClient) WorkflowID(key string) string {
+	return e.options.WorkflowIDPrefix + key
+}
+
+// Signal sends a signal to the entity with the given key, and starts its workflow with workflowArgs if it is not
+// running, see Client.SignalWithStartWorkflow. It returns the current run of the workflow of the entity.
+func (e *EntityClient) Signal(ctx context.Context, key string, signalName string, signalArg interface{},
+	workflowArgs ...interface{}) (*WorkflowExecution, error) {
+	options := e.options.WorkflowOptions
+	options.ID = e.WorkflowID(key)
+	return e.client.SignalWithStartWorkflow(ctx, options.ID, signalName, signalArg, options, e.options.Workflow,
+		workflowArgs...)
+}
+
+// Heartbeat queries the EntityHeartbeat of the running workflow of the entity with the given key. An answer
+// verifies that the workflow is running and that a worker is processing its decisions.
+// The errors it can return:
+//   - EntityNotExistsError, if the entity was never started
+//   - QueryFailError, if the workflow of the entity does not run RunEntity
+//   - InternalServiceError
+func (e *EntityClient) Heartbeat(ctx context.Context, key string) (*EntityHeartbeat, error) {
+	value, err := e.client.QueryWorkflow(ctx, e.WorkflowID(key), "", EntityHeartbeatQueryType)
+	if err != nil {
+		return nil, err
+	}
+	var heartbeat EntityHeartbeat
+	if err := value.Get(&heartbeat); err != nil {
+		return nil, err
+	}
+	return &heartbeat, nil
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pborman/uuid"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+)
+
+const (
+	entityCommandInitialPollInterval = 50 * time.Millisecond
+	entityCommandMaximumPollInterval = time.Second
+)
+
+// ErrUnknownEntityCommand is returned by EntityCommandFuture.Get when the entity did not receive the command, or no
+// longer retains its result, see CommandEntityOptions.MaximumRetainedResults.
+var ErrUnknownEntityCommand = errors.New("entity command is unknown or its result is no longer retained")
+
+type (
+	// EntityCommandStub sends the commands of type C with results of type R named Name to the entities of an
+	// EntityClient, whose workflows run RunCommandEntity.
+	EntityCommandStub[C, R any] struct {
+		entities *EntityClient
+		name     string
+	}
+
+	// EntityCommandFuture is the result of a command sent with EntityCommandStub.Send.
+	EntityCommandFuture[R any] struct {
+		entities *EntityClient
+		key      string
+		id       string
+	}
+
+	// EntityCommandError is returned by EntityCommandFuture.Get when the handler of the command failed.
+	EntityCommandError struct {
+		Command string
+		Message string
+	}
+)
+
+// NewEntityCommandStub returns a stub sending the command with the given name to the entities of entities.
+func NewEntityCommandStub[C, R any](entities *EntityClient, name string) *EntityCommandStub[C, R] {
+	return &EntityCommandStub[C, R]{entities: entities, name: name}
+}
+
+// Send sends the command to the entity with the given key, starting its workflow with a nil *EntitySnapshot if it is
+// not running, and returns the future of its result.
+func (s *EntityCommandStub[C, R]) Send(ctx context.Context, key string, command C) (*EntityCommandFuture[R], error) {
+	payload, err := encodeArg(s.entities.dataConverter, command)
+	if err != nil {
+		return nil, err
+	}
+	request := EntityCommandRequest{ID: uuid.New(), Name: s.name, Payload: payload}
+	var snapshot interface{} // nil *EntitySnapshot of any state
+	if _, err := s.entities.Signal(ctx, key, EntityCommandSignalName, request, snapshot); err != nil {
+		return nil, err
+	}
+	return &EntityCommandFuture[R]{entities: s.entities, key: key, id: request.ID}, nil
+}
+
+// Execute sends the command to the entity with the given key, and waits for its result.
+func (s *EntityCommandStub[C, R]) Execute(ctx context.Context, key string, command C) (R, error) {
+	future, err := s.Send(ctx, key, command)
+	if err != nil {
+		var zero R
+		return zero, err
+	}
+	return future.Get(ctx)
+}
+
+// CommandID returns the ID of the command.
+func (f *EntityCommandFuture[R]) CommandID() string {
+	return f.id
+}
+
+// Get waits for the entity to handle the command, by polling its EntityCommandResultQueryType query with strong
+// consistency until ctx is done. It returns *EntityCommandError if the handler of the command failed, and
+// ErrUnknownEntityCommand without waiting if the entity does not know the command.
+func (f *EntityCommandFuture[R]) Get(ctx context.Context) (R, error) {
+	var value R
+	interval := entityCommandInitialPollInterval
+	for {
+		result, err := f.query(ctx)
+		if err != nil {
+			return value, err
+		}
+		if result.Unknown {
+			return value, ErrUnknownEntityCommand
+		}
+		if result.Handled {
+			if result.Error != "" {
+				return value, &EntityCommandError{Command: f.id, Message: result.Error}
+			}
+			err = decodeArg(f.entities.dataConverter, result.Result, &value)
+			return value, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return value, ctx.Err()
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > entityCommandMaximumPollInterval {
+			interval = entityCommandMaximumPollInterval
+		}
+	}
+}
+
+func (f *EntityCommandFuture[R]) query(ctx context.Context) (EntityCommandResult, error) {
+	// a strong query applies the signals accepted before it, so that a sent command is not reported as unknown
+	var result EntityCommandResult
+	response, err := f.entities.client.QueryWorkflowWithOptions(ctx, &QueryWorkflowWithOptionsRequest{
+		WorkflowID:            f.entities.WorkflowID(f.key),
+		QueryType:             EntityCommandResultQueryType,
+		Args:                  []interface{}{f.id},
+		QueryConsistencyLevel: s.QueryConsistencyLevelStrong.Ptr(),
+	})
+	if err != nil {
+		return result, err
+	}
+	err = response.QueryResult.Get(&result)
+	return result, err
+}
+
+func (e *EntityCommandError) Error() string {
+	return "entity command " + e.Command + " failed: " + e.Message
+}
@@ -25,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime"
 	"strings"
 
 	"go.uber.org/cadence/.gen/go/shared"
@@ -82,6 +83,8 @@ if err != nil {
 }
 
 Errors from child workflow should be handled in a similar way, except that there should be no *PanicError from child workflow.
+A *CustomError keeps its reason and details even when the child workflow returns it wrapped, e.g. with fmt.Errorf("%w"),
+so it can be extracted with errors.As, while the error keeps the message of the wrapping error. If the child workflow continued as new, the error is the one of its last run.
 When panic happen in workflow implementation code, cadence client library catches that panic and causing the decision timeout.
 That decision task will be retried at a later time (with exponential backoff retry intervals).
 */
@@ -98,6 +101,13 @@ type (
 		err string
 	}
 
+	// wrappedCustomError is a *CustomError returned by a child workflow wrapped in another error, e.g. with
+	// fmt.Errorf("%w"), which keeps the message of the wrapping error.
+	wrappedCustomError struct {
+		message string
+		err     *CustomError
+	}
+
 	// TimeoutError returned when activity or child workflow timed out.
 	TimeoutError struct {
 		timeoutType shared.TimeoutType
@@ -116,6 +126,7 @@ type (
 	// PanicError contains information about panicked workflow/activity.
 	PanicError struct {
 		value      interface{}
+		cause      PanicCause
 		stackTrace string
 	}
 
@@ -123,9 +134,14 @@ type (
 	// Used to distinguish go panic in the workflow code from a PanicError returned from a workflow function.
 	workflowPanicError struct {
 		value      interface{}
+		cause      PanicCause
 		stackTrace string
 	}
 
+	// PanicCause classifies the value a workflow or an activity panicked with, so that panics can be grouped by
+	// their kind rather than by their message.
+	PanicCause int
+
 	// NonDeterministicError contains some structured data related to a non-deterministic
 	// replay failure, and is primarily intended for allowing richer error reporting.
 	//
@@ -181,6 +197,16 @@ type (
 	// UnknownExternalWorkflowExecutionError can be returned when external workflow doesn't exist
 	UnknownExternalWorkflowExecutionError struct{}
 
+	// ExternalWorkflowAlreadyCompletedError can be returned when external workflow was already completed when the
+	// signal was delivered.
+	ExternalWorkflowAlreadyCompletedError struct{}
+
+	// SignalExternalWorkflowError is returned when signaling an external workflow failed with a cause that does not
+	// have a dedicated error type. Cause holds the failure cause reported by the server.
+	SignalExternalWorkflowError struct {
+		Cause string
+	}
+
 	// ErrorDetailsValues is a type alias used hold error details objects.
 	ErrorDetailsValues []interface{}
 )
@@ -190,6 +216,9 @@ const (
 	errReasonGeneric  = "cadenceInternal:Generic"
 	errReasonCanceled = "cadenceInternal:Canceled"
 	errReasonTimeout  = "cadenceInternal:Timeout"
+
+	// errReasonWrappedBy separates the reason of a wrapped *CustomError from the message of the error wrapping it.
+	errReasonWrappedBy = "\ncadenceInternal:WrappedBy:"
 )
 
 // ErrNoData is returned when trying to extract strong typed data while there is no data available.
@@ -322,6 +351,16 @@ func (e *GenericError) Error() string {
 	return e.err
 }
 
+// Error from error interface
+func (e *wrappedCustomError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the wrapped *CustomError, so it can be matched with errors.As.
+func (e *wrappedCustomError) Unwrap() error {
+	return e.err
+}
+
 // Error from error interface
 func (e *TimeoutError) Error() string {
 	return fmt.Sprintf("TimeoutType: %v", e.timeoutType)
@@ -363,12 +402,61 @@ func (e *CanceledError) Details(d ...interface{}) error {
 	return e.details.Get(d...)
 }
 
+const (
+	// PanicCauseUser means the code called panic with its own value.
+	PanicCauseUser PanicCause = iota
+	// PanicCauseNilMapWrite means the code assigned to an entry of a nil map.
+	PanicCauseNilMapWrite
+	// PanicCauseIndexOutOfRange means the code indexed or sliced an array, a slice or a string out of its range.
+	PanicCauseIndexOutOfRange
+	// PanicCauseNilPointerDereference means the code dereferenced a nil pointer.
+	PanicCauseNilPointerDereference
+	// PanicCauseRuntime means the go runtime panicked for another reason, e.g. a failed type assertion or a
+	// division by zero.
+	PanicCauseRuntime
+)
+
+// String returns the name of the cause.
+func (c PanicCause) String() string {
+	switch c {
+	case PanicCauseUser:
+		return "User"
+	case PanicCauseNilMapWrite:
+		return "NilMapWrite"
+	case PanicCauseIndexOutOfRange:
+		return "IndexOutOfRange"
+	case PanicCauseNilPointerDereference:
+		return "NilPointerDereference"
+	case PanicCauseRuntime:
+		return "Runtime"
+	}
+	return "Unknown"
+}
+
+// classifyPanic returns the cause of a panic with the given value.
+func classifyPanic(value interface{}) PanicCause {
+	runtimeErr, ok := value.(runtime.Error)
+	if !ok {
+		return PanicCauseUser
+	}
+	msg := runtimeErr.Error()
+	switch {
+	case strings.Contains(msg, "assignment to entry in nil map"):
+		return PanicCauseNilMapWrite
+	case strings.Contains(msg, "index out of range"), strings.Contains(msg, "slice bounds out of range"):
+		return PanicCauseIndexOutOfRange
+	case strings.Contains(msg, "nil pointer dereference"):
+		return PanicCauseNilPointerDereference
+	}
+	return PanicCauseRuntime
+}
+
 func newPanicError(value interface{}, stackTrace string) *PanicError {
-	return &PanicError{value: value, stackTrace: stackTrace}
+	return &PanicError{value: value, cause: classifyPanic(value), stackTrace: stackTrace}
 }
 
 func newWorkflowPanicError(value interface{}, stackTrace string) *workflowPanicError {
-	return &workflowPanicError{value: value, stackTrace: stackTrace}
+	return &workflowPanicError{value: value, cause: classifyPanic(value), stackTrace: stackTrace}
 }
 
 // Error from error interface
@@ -381,6 +469,23 @@ func (e *PanicError) StackTrace() string {
 	return e.stackTrace
 }
 
+// Value returns the value passed to panic. For a panic which happened in another process, e.g. in an activity run
+// by another worker, it is the string representation of the value.
+func (e *PanicError) Value() interface{} {
+	return e.value
+}
+
+// Cause returns the classification of the panic value.
+func (e *PanicError) Cause() PanicCause {
+	return e.cause
+}
+
+// Unwrap returns the panic value if it is an error, e.g. a runtime.Error, so it can be matched with errors.As.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.value.(error)
+	return err
+}
+
 // Error from error interface
 func (e *workflowPanicError) Error() string {
 	return fmt.Sprintf("%v", e.value)
@@ -391,6 +496,22 @@ func (e *workflowPanicError) StackTrace() string {
 	return e.stackTrace
 }
 
+// Value returns the value passed to panic.
+func (e *workflowPanicError) Value() interface{} {
+	return e.value
+}
+
+// Cause returns the classification of the panic value.
+func (e *workflowPanicError) Cause() PanicCause {
+	return e.cause
+}
+
+// Unwrap returns the panic value if it is an error, e.g. a runtime.Error, so it can be matched with errors.As.
+func (e *workflowPanicError) Unwrap() error {
+	err, _ := e.value.(error)
+	return err
+}
+
 // Error from error interface
 func (e *ContinueAsNewError) Error() string {
 	return "ContinueAsNew"
@@ -441,6 +562,21 @@ func (e *UnknownExternalWorkflowExecutionError) Error() string {
 	return "UnknownExternalWorkflowExecution"
 }
 
+// newExternalWorkflowAlreadyCompletedError creates ExternalWorkflowAlreadyCompletedError instance
+func newExternalWorkflowAlreadyCompletedError() *ExternalWorkflowAlreadyCompletedError {
+	return &ExternalWorkflowAlreadyCompletedError{}
+}
+
+// Error from error interface
+func (e *ExternalWorkflowAlreadyCompletedError) Error() string {
+	return "ExternalWorkflowAlreadyCompleted"
+}
+
+// Error from error interface
+func (e *SignalExternalWorkflowError) Error() string {
+	return fmt.Sprintf("signal external workflow failed, %v", e.Cause)
+}
+
 // HasValues return whether there are values.
 func (b ErrorDetailsValues) HasValues() bool {
 	return b != nil && len(b) != 0
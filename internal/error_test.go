@@ -23,7 +23,10 @@ package internal
 import (
 	"errors"
 	"fmt"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"go.uber.org/cadence/internal/common/testlogger"
 
@@ -436,34 +439,54 @@ func TestErrorDetailsValues_AssignableType(t *testing.T) {
 }
 
 func Test_SignalExternalWorkflowExecutionFailedError(t *testing.T) {
-	context := &workflowEnvironmentImpl{
-		decisionsHelper: newDecisionsHelper(),
-		dataConverter:   getDefaultDataConverter(),
-	}
-	h := newDecisionsHelper()
-	var actualErr error
-	var initiatedEventID int64 = 101
-	signalID := "signalID"
-	context.decisionsHelper.scheduledEventIDToSignalID[initiatedEventID] = signalID
-	di := h.newSignalExternalWorkflowStateMachine(
-		&shared.SignalExternalWorkflowExecutionDecisionAttributes{},
-		signalID,
-	)
-	di.state = decisionStateInitiated
-	di.setData(&scheduledSignal{
-		callback: func(r []byte, e error) {
-			actualErr = e
+	tests := map[string]struct {
+		cause    shared.SignalExternalWorkflowExecutionFailedCause
+		expected error
+	}{
+		"unknown external workflow": {
+			cause:    shared.SignalExternalWorkflowExecutionFailedCauseUnknownExternalWorkflowExecution,
+			expected: &UnknownExternalWorkflowExecutionError{},
 		},
-	})
-	context.decisionsHelper.addDecision(di)
-	weh := &workflowExecutionEventHandlerImpl{context, nil}
-	event := createTestEventSignalExternalWorkflowExecutionFailed(1, &shared.SignalExternalWorkflowExecutionFailedEventAttributes{
-		InitiatedEventId: common.Int64Ptr(initiatedEventID),
-		Cause:            shared.SignalExternalWorkflowExecutionFailedCauseUnknownExternalWorkflowExecution.Ptr(),
-	})
-	weh.handleSignalExternalWorkflowExecutionFailed(event)
-	_, ok := actualErr.(*UnknownExternalWorkflowExecutionError)
-	require.True(t, ok)
+		"workflow already completed": {
+			cause:    shared.SignalExternalWorkflowExecutionFailedCauseWorkflowAlreadyCompleted,
+			expected: &ExternalWorkflowAlreadyCompletedError{},
+		},
+		"unrecognized cause": {
+			cause:    shared.SignalExternalWorkflowExecutionFailedCause(42),
+			expected: &SignalExternalWorkflowError{Cause: "SignalExternalWorkflowExecutionFailedCause(42)"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			context := &workflowEnvironmentImpl{
+				decisionsHelper: newDecisionsHelper(),
+				dataConverter:   getDefaultDataConverter(),
+			}
+			h := newDecisionsHelper()
+			var actualErr error
+			var initiatedEventID int64 = 101
+			signalID := "signalID"
+			context.decisionsHelper.scheduledEventIDToSignalID[initiatedEventID] = signalID
+			di := h.newSignalExternalWorkflowStateMachine(
+				&shared.SignalExternalWorkflowExecutionDecisionAttributes{},
+				signalID,
+			)
+			di.state = decisionStateInitiated
+			di.setData(&scheduledSignal{
+				callback: func(r []byte, e error) {
+					actualErr = e
+				},
+			})
+			context.decisionsHelper.addDecision(di)
+			weh := &workflowExecutionEventHandlerImpl{context, nil}
+			event := createTestEventSignalExternalWorkflowExecutionFailed(1, &shared.SignalExternalWorkflowExecutionFailedEventAttributes{
+				InitiatedEventId: common.Int64Ptr(initiatedEventID),
+				Cause:            tt.cause.Ptr(),
+			})
+			weh.handleSignalExternalWorkflowExecutionFailed(event)
+			require.Equal(t, tt.expected, actualErr)
+		})
+	}
 }
 
 func Test_ContinueAsNewError(t *testing.T) {
@@ -506,3 +529,128 @@ func Test_ContinueAsNewError(t *testing.T) {
 	require.Equal(t, a2, stringArg)
 	require.Equal(t, header, continueAsNewErr.params.header)
 }
+
+func Test_ChildWorkflowCustomError(t *testing.T) {
+	childWorkflowFn := func(ctx Context, continued bool) error {
+		if !continued {
+			return NewContinueAsNewError(ctx, "childWorkflowFn", true)
+		}
+		return fmt.Errorf("charging card: %w", NewCustomError(customErrReasonA, testErrorDetails1, testErrorDetails3))
+	}
+	parentWorkflowFn := func(ctx Context) (string, error) {
+		ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
+			ExecutionStartToCloseTimeout: time.Minute,
+			TaskStartToCloseTimeout:      time.Minute,
+		})
+		err := ExecuteChildWorkflow(ctx, "childWorkflowFn", false).Get(ctx, nil)
+		if !strings.HasPrefix(err.Error(), "charging card: ") {
+			return "", fmt.Errorf("child workflow error lost its wrapper: %v", err)
+		}
+
+		var customErr *CustomError
+		if !errors.As(err, &customErr) {
+			return "", fmt.Errorf("unexpected child workflow error: %v", err)
+		}
+		var details string
+		var info testStruct
+		if err := customErr.Details(&details, &info); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v %v %v", customErr.Reason(), details, info.Name), nil
+	}
+
+	wfEnv := newTestWorkflowEnv(t)
+	wfEnv.RegisterWorkflowWithOptions(childWorkflowFn, RegisterWorkflowOptions{Name: "childWorkflowFn"})
+	wfEnv.RegisterWorkflow(parentWorkflowFn)
+	wfEnv.ExecuteWorkflow(parentWorkflowFn)
+	require.NoError(t, wfEnv.GetWorkflowError())
+	var result string
+	require.NoError(t, wfEnv.GetWorkflowResult(&result))
+	require.Equal(t, customErrReasonA+" "+testErrorDetails1+" "+testErrorDetails3.Name, result)
+}
+
+func Test_GetChildWorkflowErrorDetails(t *testing.T) {
+	dc := getDefaultDataConverter()
+
+	reason, details := getChildWorkflowErrorDetails(fmt.Errorf("wrapped: %w", NewCustomError(customErrReasonA, testErrorDetails1)), dc)
+	err := constructError(reason, details, dc)
+	require.Equal(t, "wrapped: "+customErrReasonA, err.Error())
+	var customErr *CustomError
+	require.True(t, errors.As(err, &customErr))
+	require.Equal(t, customErrReasonA, customErr.Reason())
+	var detail string
+	require.NoError(t, customErr.Details(&detail))
+	require.Equal(t, testErrorDetails1, detail)
+
+	reason, details = getChildWorkflowErrorDetails(NewCustomError(customErrReasonA), dc)
+	require.Equal(t, customErrReasonA, reason)
+	require.IsType(t, &CustomError{}, constructError(reason, details, dc))
+
+	reason, details = getChildWorkflowErrorDetails(fmt.Errorf("wrapped: %w", errors.New("plain")), dc)
+	require.Equal(t, &GenericError{err: "wrapped: plain"}, constructError(reason, details, dc))
+
+	// the other errors are not unwrapped, e.g. a workflow panicking with a *CustomError keeps the panic message
+	reason, details = getErrorDetails(fmt.Errorf("wrapped: %w", NewCustomError(customErrReasonA)), dc)
+	require.Equal(t, &GenericError{err: "wrapped: " + customErrReasonA}, constructError(reason, details, dc))
+	reason, details = getErrorDetails(newWorkflowPanicError(NewCustomError(customErrReasonA), "stack"), dc)
+	require.Equal(t, errReasonGeneric, reason)
+	require.Equal(t, customErrReasonA, string(details))
+}
+
+func Test_WorkflowPanicError_Cause(t *testing.T) {
+	recovered := func(f func()) (value interface{}) {
+		defer func() {
+			value = recover()
+		}()
+		f()
+		return nil
+	}
+	dc := getDefaultDataConverter()
+	var nilMap map[string]int
+	var nilPtr *struct{ a int }
+	var slice []int
+	var i interface{} = "string"
+
+	tests := []struct {
+		name  string
+		value interface{}
+		cause PanicCause
+	}{
+		{"user", recovered(func() { panic("boom") }), PanicCauseUser},
+		{"user error", recovered(func() { panic(errors.New("boom")) }), PanicCauseUser},
+		{"nil map write", recovered(func() { nilMap["a"] = 1 }), PanicCauseNilMapWrite},
+		{"index out of range", recovered(func() { _ = slice[len(slice)] }), PanicCauseIndexOutOfRange},
+		{"nil pointer dereference", recovered(func() { _ = nilPtr.a }), PanicCauseNilPointerDereference},
+		{"runtime", recovered(func() { _ = i.(int) }), PanicCauseRuntime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newWorkflowPanicError(tt.value, "stack")
+			require.Equal(t, tt.cause, err.Cause())
+			require.Equal(t, tt.value, err.Value())
+			require.Equal(t, "stack", err.StackTrace())
+
+			var runtimeErr runtime.Error
+			_, isRuntimeErr := tt.value.(runtime.Error)
+			require.Equal(t, isRuntimeErr, errors.As(err, &runtimeErr))
+			require.Equal(t, tt.cause, newPanicError(tt.value, "stack").Cause())
+
+			// the cause is kept when the panic is returned by another process, e.g. by an activity
+			reason, details := getErrorDetails(newPanicError(tt.value, "stack"), dc)
+			var remoteErr *PanicError
+			require.True(t, errors.As(constructError(reason, details, dc), &remoteErr))
+			require.Equal(t, tt.cause, remoteErr.Cause())
+			require.Equal(t, fmt.Sprint(tt.value), remoteErr.Error())
+			require.Equal(t, "stack", remoteErr.StackTrace())
+		})
+	}
+
+	// prior client versions do not encode the cause
+	legacyDetails, err := encodeArgs(dc, []interface{}{"boom", "stack"})
+	require.NoError(t, err)
+	var legacyErr *PanicError
+	require.True(t, errors.As(constructError(errReasonPanic, legacyDetails, dc), &legacyErr))
+	require.Equal(t, PanicCauseUser, legacyErr.Cause())
+	require.Equal(t, "boom", legacyErr.Error())
+	require.Equal(t, "stack", legacyErr.StackTrace())
+}
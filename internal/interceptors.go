@@ -48,6 +48,9 @@ type WorkflowInterceptor interface {
 	// workflowType argument is for information purposes only and should not be mutated.
 	ExecuteWorkflow(ctx Context, workflowType string, args ...interface{}) []interface{}
 
+	// ExecuteActivity and ExecuteLocalActivity intercept the scheduling of activities and local activities. The
+	// context propagators inject the headers of both from the ctx forwarded to the end of the chain, so values an
+	// interceptor adds to ctx are propagated into the context.Context of the activity.
 	ExecuteActivity(ctx Context, activityType string, args ...interface{}) Future
 	ExecuteLocalActivity(ctx Context, activityType string, args ...interface{}) Future
 	ExecuteChildWorkflow(ctx Context, childWorkflowType string, args ...interface{}) ChildWorkflowFuture
@@ -68,6 +71,17 @@ type WorkflowInterceptor interface {
 	IsReplaying(ctx Context) bool
 	HasLastCompletionResult(ctx Context) bool
 	GetLastCompletionResult(ctx Context, d ...interface{}) error
+
+	// HandleSignal intercepts a signal delivered to the workflow before it is added to the signal channel.
+	// arg holds the signal payload and must be forwarded unchanged; an interceptor drops the signal by not
+	// forwarding the call.
+	HandleSignal(ctx Context, signalName string, arg Value)
+
+	// HandleQuery intercepts a query answered by a handler registered with SetQueryHandler. args are the query
+	// arguments decoded for the handler, without its QueryContext parameter. Built-in queries like
+	// QueryTypeStackTrace are not intercepted.
+	// The handler runs outside of the workflow coroutines, so HandleQuery must not block.
+	HandleQuery(ctx Context, queryType string, args ...interface{}) (interface{}, error)
 }
 
 var _ WorkflowInterceptor = (*WorkflowInterceptorBase)(nil)
@@ -181,3 +195,13 @@ func (t *WorkflowInterceptorBase) HasLastCompletionResult(ctx Context) bool {
 func (t *WorkflowInterceptorBase) GetLastCompletionResult(ctx Context, d ...interface{}) error {
 	return t.Next.GetLastCompletionResult(ctx, d...)
 }
+
+// HandleSignal forwards to t.Next
+func (t *WorkflowInterceptorBase) HandleSignal(ctx Context, signalName string, arg Value) {
+	t.Next.HandleSignal(ctx, signalName, arg)
+}
+
+// HandleQuery forwards to t.Next
+func (t *WorkflowInterceptorBase) HandleQuery(ctx Context, queryType string, args ...interface{}) (interface{}, error) {
+	return t.Next.HandleQuery(ctx, queryType, args...)
+}
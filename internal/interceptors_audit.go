@@ -0,0 +1,206 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Operations recorded by the interceptor created with NewAuditInterceptorFactory.
+const (
+	// AuditOperationSignal is a signal received by the workflow.
+	AuditOperationSignal AuditOperation = "signal"
+	// AuditOperationSignalExternal is a signal sent by the workflow to another workflow.
+	AuditOperationSignalExternal AuditOperation = "signal-external"
+	// AuditOperationQuery is a query answered by a handler registered with SetQueryHandler.
+	AuditOperationQuery AuditOperation = "query"
+	// AuditOperationActivity is an activity scheduled by the workflow.
+	AuditOperationActivity AuditOperation = "activity"
+	// AuditOperationLocalActivity is a local activity scheduled by the workflow.
+	AuditOperationLocalActivity AuditOperation = "local-activity"
+	// AuditOperationChildWorkflow is a child workflow started by the workflow.
+	AuditOperationChildWorkflow AuditOperation = "child-workflow"
+)
+
+type (
+	// AuditOperation is the kind of operation described by an AuditRecord.
+	AuditOperation string
+
+	// AuditRecord describes an operation of a workflow recorded by the audit interceptor.
+	AuditRecord struct {
+		Operation AuditOperation
+		// Name is the signal name, query type, activity type or child workflow type.
+		Name string
+		// ArgsDigest is the hex encoded SHA-256 digest of the arguments encoded with the data converter of the
+		// workflow, so records can be correlated with payloads without storing them. Empty if the arguments could
+		// not be encoded.
+		ArgsDigest string
+		// Caller is the identity returned by AuditOptions.CallerIdentity.
+		Caller string
+
+		WorkflowType string
+		WorkflowID   string
+		RunID        string
+		// Time is the workflow time of the operation, see Now.
+		Time time.Time
+	}
+
+	// AuditSink stores the records of the audit interceptor. Record is called from workflow code, so it must not
+	// block, and it is called concurrently for the workflows of a worker.
+	AuditSink interface {
+		Record(record AuditRecord)
+	}
+
+	// AuditOptions configure NewAuditInterceptorFactory.
+	AuditOptions struct {
+		// Sink stores the audit records.
+		// Mandatory: No default.
+		Sink AuditSink
+
+		// Optional: CallerIdentity returns the identity of the caller on whose behalf the workflow runs, typically a
+		// value a ContextPropagator extracted from the headers of the request starting the workflow.
+		// default: no caller identity
+		CallerIdentity func(ctx Context) string
+	}
+
+	auditInterceptorFactory struct {
+		options AuditOptions
+	}
+
+	auditInterceptor struct {
+		WorkflowInterceptorBase
+		options AuditOptions
+		info    *WorkflowInfo
+	}
+
+	loggerAuditSink struct {
+		logger *zap.Logger
+	}
+)
+
+// NewAuditInterceptorFactory returns a WorkflowInterceptorFactory recording every received and sent signal, query,
+// activity and local activity schedule, and child workflow start of the workflows of a worker to options.Sink.
+// Operations are recorded once per workflow execution, not again when the workflow is replayed. Queries are
+// recorded every time they are answered.
+func NewAuditInterceptorFactory(options AuditOptions) WorkflowInterceptorFactory {
+	return &auditInterceptorFactory{options: options}
+}
+
+// NewLoggerAuditSink returns an AuditSink writing every record as an info log entry of logger.
+func NewLoggerAuditSink(logger *zap.Logger) AuditSink {
+	return &loggerAuditSink{logger: logger}
+}
+
+func (s *loggerAuditSink) Record(record AuditRecord) {
+	s.logger.Info("Workflow audit record.",
+		zap.String(tagAuditOperation, string(record.Operation)),
+		zap.String(tagAuditName, record.Name),
+		zap.String(tagAuditArgsDigest, record.ArgsDigest),
+		zap.String(tagAuditCaller, record.Caller),
+		zap.String(tagWorkflowType, record.WorkflowType),
+		zap.String(tagWorkflowID, record.WorkflowID),
+		zap.String(tagRunID, record.RunID),
+		zap.Time(tagAuditTime, record.Time),
+	)
+}
+
+func (f *auditInterceptorFactory) NewInterceptor(info *WorkflowInfo, next WorkflowInterceptor) WorkflowInterceptor {
+	return &auditInterceptor{
+		WorkflowInterceptorBase: WorkflowInterceptorBase{Next: next},
+		options:                 f.options,
+		info:                    info,
+	}
+}
+
+func (t *auditInterceptor) ExecuteActivity(ctx Context, activityType string, args ...interface{}) Future {
+	t.record(ctx, AuditOperationActivity, activityType, t.digestArgs(ctx, args))
+	return t.Next.ExecuteActivity(ctx, activityType, args...)
+}
+
+func (t *auditInterceptor) ExecuteLocalActivity(ctx Context, activityType string, args ...interface{}) Future {
+	t.record(ctx, AuditOperationLocalActivity, activityType, t.digestArgs(ctx, args))
+	return t.Next.ExecuteLocalActivity(ctx, activityType, args...)
+}
+
+func (t *auditInterceptor) ExecuteChildWorkflow(ctx Context, childWorkflowType string, args ...interface{}) ChildWorkflowFuture {
+	t.record(ctx, AuditOperationChildWorkflow, childWorkflowType, t.digestArgs(ctx, args))
+	return t.Next.ExecuteChildWorkflow(ctx, childWorkflowType, args...)
+}
+
+func (t *auditInterceptor) SignalExternalWorkflow(ctx Context, workflowID, runID, signalName string, arg interface{}) Future {
+	t.record(ctx, AuditOperationSignalExternal, signalName, t.digestArgs(ctx, []interface{}{arg}))
+	return t.Next.SignalExternalWorkflow(ctx, workflowID, runID, signalName, arg)
+}
+
+func (t *auditInterceptor) HandleSignal(ctx Context, signalName string, arg Value) {
+	var digest string
+	if encoded, ok := arg.(EncodedValue); ok {
+		digest = digestPayload(encoded.value)
+	}
+	t.record(ctx, AuditOperationSignal, signalName, digest)
+	t.Next.HandleSignal(ctx, signalName, arg)
+}
+
+func (t *auditInterceptor) HandleQuery(ctx Context, queryType string, args ...interface{}) (interface{}, error) {
+	// queries are not replayed, so they are recorded regardless of IsReplaying
+	t.options.Sink.Record(t.newRecord(ctx, AuditOperationQuery, queryType, t.digestArgs(ctx, args)))
+	return t.Next.HandleQuery(ctx, queryType, args...)
+}
+
+func (t *auditInterceptor) record(ctx Context, operation AuditOperation, name, digest string) {
+	if t.Next.IsReplaying(ctx) {
+		return
+	}
+	t.options.Sink.Record(t.newRecord(ctx, operation, name, digest))
+}
+
+func (t *auditInterceptor) newRecord(ctx Context, operation AuditOperation, name, digest string) AuditRecord {
+	record := AuditRecord{
+		Operation:    operation,
+		Name:         name,
+		ArgsDigest:   digest,
+		WorkflowType: t.info.WorkflowType.Name,
+		WorkflowID:   t.info.WorkflowExecution.ID,
+		RunID:        t.info.WorkflowExecution.RunID,
+		Time:         t.Next.Now(ctx),
+	}
+	if t.options.CallerIdentity != nil {
+		record.Caller = t.options.CallerIdentity(ctx)
+	}
+	return record
+}
+
+func (t *auditInterceptor) digestArgs(ctx Context, args []interface{}) string {
+	payload, err := encodeArgs(getDataConverterFromWorkflowContext(ctx), args)
+	if err != nil {
+		return ""
+	}
+	return digestPayload(payload)
+}
+
+func digestPayload(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type auditCallerKey struct{}
+
+type recordingAuditSink struct {
+	sync.Mutex
+	records []AuditRecord
+}
+
+func (s *recordingAuditSink) Record(record AuditRecord) {
+	s.Lock()
+	defer s.Unlock()
+	s.records = append(s.records, record)
+}
+
+func TestAuditInterceptor(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	sink := &recordingAuditSink{}
+	env.SetWorkerOptions(WorkerOptions{
+		WorkflowInterceptorChainFactories: []WorkflowInterceptorFactory{NewAuditInterceptorFactory(AuditOptions{
+			Sink: sink,
+			CallerIdentity: func(ctx Context) string {
+				caller, _ := ctx.Value(auditCallerKey{}).(string)
+				return caller
+			},
+		})},
+	})
+
+	activityFn := func(ctx context.Context, name string) (string, error) {
+		return "hello " + name, nil
+	}
+	childFn := func(ctx Context) error {
+		return nil
+	}
+	env.RegisterActivityWithOptions(activityFn, RegisterActivityOptions{Name: "greet"})
+	env.RegisterWorkflowWithOptions(childFn, RegisterWorkflowOptions{Name: "child"})
+	workflowFn := func(ctx Context) error {
+		ctx = WithValue(ctx, auditCallerKey{}, "alice")
+		if err := SetQueryHandler(ctx, "status", func(verbose bool) (string, error) {
+			return "ok", nil
+		}); err != nil {
+			return err
+		}
+		GetSignalChannel(ctx, "approve").Receive(ctx, nil)
+
+		ctx = WithActivityOptions(ctx, ActivityOptions{ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: time.Minute})
+		if err := ExecuteActivity(ctx, "greet", "bob").Get(ctx, nil); err != nil {
+			return err
+		}
+		ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{ExecutionStartToCloseTimeout: time.Minute})
+		return ExecuteChildWorkflow(ctx, "child").Get(ctx, nil)
+	}
+	env.RegisterWorkflowWithOptions(workflowFn, RegisterWorkflowOptions{Name: "audited"})
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("approve", "yes")
+	}, time.Second)
+	env.ExecuteWorkflow("audited")
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	_, err := env.QueryWorkflow("status", true)
+	require.NoError(t, err)
+
+	var parentRecords []AuditRecord
+	for _, record := range sink.records {
+		if record.WorkflowType == "audited" {
+			assert.Equal(t, defaultTestWorkflowID, record.WorkflowID)
+			assert.NotEmpty(t, record.RunID)
+			assert.Len(t, record.ArgsDigest, 64)
+			record.ArgsDigest, record.WorkflowID, record.RunID, record.Time = "", "", "", time.Time{}
+			parentRecords = append(parentRecords, record)
+		}
+	}
+	assert.Equal(t, []AuditRecord{
+		{Operation: AuditOperationSignal, Name: "approve", WorkflowType: "audited"},
+		{Operation: AuditOperationActivity, Name: "greet", Caller: "alice", WorkflowType: "audited"},
+		{Operation: AuditOperationChildWorkflow, Name: "child", Caller: "alice", WorkflowType: "audited"},
+		{Operation: AuditOperationQuery, Name: "status", Caller: "alice", WorkflowType: "audited"},
+	}, parentRecords)
+
+	// equal arguments have equal digests
+	assert.Equal(t, digestPayload([]byte(`"yes"`+"\n")), sink.records[0].ArgsDigest)
+}
+
+func TestLoggerAuditSink(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	NewLoggerAuditSink(zap.New(core)).Record(AuditRecord{
+		Operation:    AuditOperationSignal,
+		Name:         "approve",
+		WorkflowType: "audited",
+		WorkflowID:   "wid",
+		RunID:        "rid",
+	})
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "signal", fields[tagAuditOperation])
+	assert.Equal(t, "approve", fields[tagAuditName])
+	assert.Equal(t, "wid", fields[tagWorkflowID])
+}
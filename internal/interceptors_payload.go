@@ -0,0 +1,86 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+type (
+	// WorkflowPayloadHooks validate or transform the payloads of every workflow executed by a worker. They are applied
+	// by the interceptor created with NewWorkflowPayloadInterceptorFactory.
+	WorkflowPayloadHooks struct {
+		// Optional: Input is called with the decoded workflow arguments before the workflow function runs. The
+		// arguments are pointers to the decoded values (a single []byte argument is passed as is), so they can be
+		// modified in place. Returning an error fails the workflow without running the workflow function.
+		Input func(ctx Context, workflowType string, args ...interface{}) error
+
+		// Optional: Output is called with the workflow result when the workflow function returns without an error.
+		// result is nil for workflow functions that only return an error. The returned value replaces the result of
+		// workflow functions returning one, and returning an error fails the workflow.
+		Output func(ctx Context, workflowType string, result interface{}) (interface{}, error)
+	}
+
+	workflowPayloadInterceptorFactory struct {
+		hooks WorkflowPayloadHooks
+	}
+
+	workflowPayloadInterceptor struct {
+		WorkflowInterceptorBase
+		hooks WorkflowPayloadHooks
+	}
+)
+
+// NewWorkflowPayloadInterceptorFactory returns a WorkflowInterceptorFactory applying hooks to the input and the
+// result of every workflow. Add it to WorkerOptions.WorkflowInterceptorChainFactories to enforce contracts on
+// workflow payloads, like schema validation or redaction, for all workflows of a worker.
+func NewWorkflowPayloadInterceptorFactory(hooks WorkflowPayloadHooks) WorkflowInterceptorFactory {
+	return &workflowPayloadInterceptorFactory{hooks: hooks}
+}
+
+func (f *workflowPayloadInterceptorFactory) NewInterceptor(info *WorkflowInfo, next WorkflowInterceptor) WorkflowInterceptor {
+	return &workflowPayloadInterceptor{
+		WorkflowInterceptorBase: WorkflowInterceptorBase{Next: next},
+		hooks:                   f.hooks,
+	}
+}
+
+func (t *workflowPayloadInterceptor) ExecuteWorkflow(ctx Context, workflowType string, args ...interface{}) []interface{} {
+	if t.hooks.Input != nil {
+		if err := t.hooks.Input(ctx, workflowType, args...); err != nil {
+			return []interface{}{nil, err}
+		}
+	}
+
+	results := t.Next.ExecuteWorkflow(ctx, workflowType, args...)
+	if t.hooks.Output == nil || len(results) == 0 || results[len(results)-1] != nil {
+		return results
+	}
+
+	var result interface{}
+	if len(results) > 1 {
+		result = results[0]
+	}
+	result, err := t.hooks.Output(ctx, workflowType, result)
+	if err != nil {
+		return []interface{}{nil, err}
+	}
+	if len(results) == 1 {
+		return results
+	}
+	return []interface{}{result, nil}
+}
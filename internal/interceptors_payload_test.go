@@ -0,0 +1,106 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowPayloadInterceptor(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	type request struct {
+		Name     string
+		Password string
+	}
+	workflowFn := func(ctx Context, r request) (request, error) {
+		return r, nil
+	}
+	errorOnlyWorkflowFn := func(ctx Context, r request) error {
+		return nil
+	}
+	hooks := WorkflowPayloadHooks{
+		Input: func(ctx Context, workflowType string, args ...interface{}) error {
+			r := args[0].(*request)
+			if r.Name == "" {
+				return errors.New("name is required")
+			}
+			r.Name = strings.ToUpper(r.Name)
+			return nil
+		},
+		Output: func(ctx Context, workflowType string, result interface{}) (interface{}, error) {
+			r, ok := result.(request)
+			if !ok {
+				return result, nil
+			}
+			if r.Name == "ADMIN" {
+				return nil, errors.New("admin result is not allowed")
+			}
+			r.Password = "***"
+			return r, nil
+		},
+	}
+	newEnv := func() *TestWorkflowEnvironment {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.SetWorkerOptions(WorkerOptions{
+			WorkflowInterceptorChainFactories: []WorkflowInterceptorFactory{NewWorkflowPayloadInterceptorFactory(hooks)},
+		})
+		return env
+	}
+
+	t.Run("transform", func(t *testing.T) {
+		env := newEnv()
+		env.ExecuteWorkflow(workflowFn, request{Name: "user", Password: "secret"})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var result request
+		require.NoError(t, env.GetWorkflowResult(&result))
+		assert.Equal(t, request{Name: "USER", Password: "***"}, result)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		env := newEnv()
+		env.ExecuteWorkflow(workflowFn, request{})
+		require.True(t, env.IsWorkflowCompleted())
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "name is required")
+	})
+
+	t.Run("invalid output", func(t *testing.T) {
+		env := newEnv()
+		env.ExecuteWorkflow(workflowFn, request{Name: "admin"})
+		require.True(t, env.IsWorkflowCompleted())
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "admin result is not allowed")
+	})
+
+	t.Run("error only workflow", func(t *testing.T) {
+		env := newEnv()
+		env.ExecuteWorkflow(errorOnlyWorkflowFn, request{Name: "admin"})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+	})
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
 	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
 )
@@ -135,6 +136,10 @@ type (
 		workerStopChannel  <-chan struct{}
 		contextPropagators []ContextPropagator
 		tracer             opentracing.Tracer
+		// workflowService is only set for local activities, which can use it on behalf of their workflow.
+		workflowService workflowserviceclient.Interface
+		featureFlags    FeatureFlags
+		headers         map[string]string // the headers of the workflow, see StartWorkflowOptions.Headers
 	}
 
 	// context.WithValue need this type instead of basic type string to avoid lint error
@@ -173,30 +178,33 @@ func getLocalActivityOptions(ctx Context) *localActivityOptions {
 
 func getValidatedActivityOptions(ctx Context) (*activityOptions, error) {
 	p := getActivityOptions(ctx)
-	if p == nil {
+	defaults := getDefaultActivityOptions(ctx)
+	if p == nil && defaults.activity() == nil {
 		// We need task list as a compulsory parameter. This can be removed after registration
 		return nil, errActivityParamsBadRequest
 	}
+	if p == nil {
+		p = &activityOptions{}
+	}
+	defaults.applyToActivity(p)
 	if p.TaskListName == "" {
 		// We default to origin task list name.
 		p.TaskListName = p.OriginalTaskListName
 	}
-	if p.ScheduleToStartTimeoutSeconds <= 0 {
-		return nil, errors.New("missing or negative ScheduleToStartTimeoutSeconds")
-	}
-	if p.StartToCloseTimeoutSeconds <= 0 {
-		return nil, errors.New("missing or negative StartToCloseTimeoutSeconds")
-	}
-	if p.ScheduleToCloseTimeoutSeconds < 0 {
-		return nil, errors.New("invalid negative ScheduleToCloseTimeoutSeconds")
+	v := newOptionsValidator("ActivityOptions")
+	v.activityTimeouts(
+		secondsToDuration(p.ScheduleToStartTimeoutSeconds),
+		secondsToDuration(p.StartToCloseTimeoutSeconds),
+		secondsToDuration(p.ScheduleToCloseTimeoutSeconds),
+		secondsToDuration(p.HeartbeatTimeoutSeconds),
+	)
+	if err := v.result(); err != nil {
+		return nil, err
 	}
 	if p.ScheduleToCloseTimeoutSeconds == 0 {
 		// This is a optional parameter, we default to sum of the other two timeouts.
 		p.ScheduleToCloseTimeoutSeconds = p.ScheduleToStartTimeoutSeconds + p.StartToCloseTimeoutSeconds
 	}
-	if p.HeartbeatTimeoutSeconds < 0 {
-		return nil, errors.New("invalid negative HeartbeatTimeoutSeconds")
-	}
 	if err := validateRetryPolicy(p.RetryPolicy); err != nil {
 		return nil, err
 	}
@@ -206,11 +214,18 @@ func getValidatedActivityOptions(ctx Context) (*activityOptions, error) {
 
 func getValidatedLocalActivityOptions(ctx Context) (*localActivityOptions, error) {
 	p := getLocalActivityOptions(ctx)
-	if p == nil {
+	defaults := getDefaultActivityOptions(ctx)
+	if p == nil && defaults.localActivity() == nil {
 		return nil, errLocalActivityParamsBadRequest
 	}
-	if p.ScheduleToCloseTimeoutSeconds <= 0 {
-		return nil, errors.New("missing or negative ScheduleToCloseTimeoutSeconds")
+	if p == nil {
+		p = &localActivityOptions{}
+	}
+	defaults.applyToLocalActivity(p)
+	v := newOptionsValidator("LocalActivityOptions")
+	v.localActivityTimeouts(secondsToDuration(p.ScheduleToCloseTimeoutSeconds))
+	if err := v.result(); err != nil {
+		return nil, err
 	}
 
 	return p, nil
@@ -619,6 +619,82 @@ func TestAsyncSendClosedChannel(t *testing.T) {
 	require.True(t, d.IsDone())
 }
 
+func TestBufferedChannelResize(t *testing.T) {
+	var history []string
+	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
+		c := NewResizableBufferedChannel(ctx, 1)
+		require.True(t, c.SendAsync("one"))
+
+		Go(ctx, func(ctx Context) {
+			c.Send(ctx, "blocked")
+			history = append(history, "sent")
+		})
+		done := NewChannel(ctx)
+		Go(ctx, func(ctx Context) {
+			c.Resize(0)
+			require.Equal(t, 0, c.Cap())
+			require.Equal(t, 1, c.Len(), "shrinking must not drop buffered values")
+			require.False(t, c.SendAsync("rejected"))
+
+			c.Resize(3)
+			require.Equal(t, 3, c.Cap())
+			require.Equal(t, 2, c.Len(), "growing must move blocked sends into the buffer")
+			history = append(history, "resized")
+			done.Send(ctx, nil)
+		})
+		done.Receive(ctx, nil)
+
+		var value string
+		c.Receive(ctx, &value)
+		history = append(history, value)
+		c.Receive(ctx, &value)
+		history = append(history, value)
+	})
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.True(t, d.IsDone())
+	require.EqualValues(t, []string{"resized", "one", "blocked", "sent"}, history)
+}
+
+func TestBufferedChannelOnDrop(t *testing.T) {
+	var dropped []interface{}
+	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
+		c := NewResizableBufferedChannel(ctx, 1)
+		c.OnDrop(func(v interface{}) {
+			dropped = append(dropped, v)
+		})
+		require.True(t, c.SendAsync(1))
+		require.False(t, c.SendAsync(2))
+		require.False(t, c.SendAsync(3))
+
+		c.Resize(2)
+		require.True(t, c.SendAsync(4))
+
+		c.OnDrop(nil)
+		require.False(t, c.SendAsync(5))
+	})
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.True(t, d.IsDone())
+	require.Equal(t, []interface{}{2, 3}, dropped)
+}
+
+func TestBufferedChannelNegativeResize(t *testing.T) {
+	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
+		defer func() {
+			require.NotNil(t, recover(), "panic expected")
+		}()
+		c := NewResizableBufferedChannel(ctx, 1)
+		c.Resize(-1)
+	})
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.True(t, d.IsDone())
+}
+
+func TestNewBufferedChannelOutsideCoroutine(t *testing.T) {
+	c := NewBufferedChannel(createRootTestContext(t), 1)
+	require.True(t, c.SendAsync("value"))
+	require.False(t, c.SendAsync("dropped"))
+}
+
 func TestDispatchClose(t *testing.T) {
 	var history []string
 	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
@@ -1004,6 +1080,62 @@ func TestSelectFuture(t *testing.T) {
 	require.EqualValues(t, expected, history)
 }
 
+func TestSelectWithPriority(t *testing.T) {
+	var history []string
+	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
+		busy := NewBufferedChannel(ctx, 3)
+		urgent := NewBufferedChannel(ctx, 3)
+		future1, settable1 := NewFuture(ctx)
+		future2, settable2 := NewFuture(ctx)
+		receive := func(name string) func(c Channel, more bool) {
+			return func(c Channel, more bool) {
+				var v string
+				c.Receive(ctx, &v)
+				history = append(history, fmt.Sprintf("%v-%v", name, v))
+			}
+		}
+		get := func(name string) func(f Future) {
+			return func(f Future) {
+				var v string
+				require.NoError(t, f.Get(ctx, &v))
+				history = append(history, fmt.Sprintf("%v-%v", name, v))
+			}
+		}
+
+		s := NewSelector(ctx).
+			AddReceive(busy, receive("busy")).
+			AddReceiveWithPriority(urgent, 1, receive("urgent")).
+			AddFutureWithPriority(future1, 2, get("future1")).
+			AddFutureWithPriority(future2, 2, get("future2"))
+		busy.SendAsync("one")
+		busy.SendAsync("two")
+		urgent.SendAsync("one")
+		settable2.SetValue("two")
+		settable1.SetValue("one")
+		for i := 0; i < 5; i++ {
+			s.Select(ctx)
+		}
+
+		// when no case is ready, the first one becoming ready is chosen
+		Go(ctx, func(ctx Context) {
+			busy.Send(ctx, "three")
+		})
+		s.Select(ctx)
+	})
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.True(t, d.IsDone())
+
+	expected := []string{
+		"future1-one",
+		"future2-two",
+		"urgent-one",
+		"busy-one",
+		"busy-two",
+		"busy-three",
+	}
+	require.EqualValues(t, expected, history)
+}
+
 func TestSelectDecodeFuture(t *testing.T) {
 	var history []string
 	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
@@ -1203,3 +1335,18 @@ func TestChainedFuture(t *testing.T) {
 	require.NoError(t, env.GetWorkflowResult(&out))
 	require.Equal(t, 5, out)
 }
+
+func TestBlockedOnNamedFutureAndChannelStackTrace(t *testing.T) {
+	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
+		GoNamed(ctx, "buffered", func(ctx Context) {
+			NewBufferedChannel(ctx, 1).Receive(ctx, nil)
+		})
+		f, _ := NewNamedFuture(ctx, "approval")
+		_ = f.Get(ctx, nil)
+	})
+	defer d.Close()
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	stack := d.StackTrace()
+	require.Contains(t, stack, "coroutine 1 [blocked on approval.Receive]:")
+	require.Regexp(t, `coroutine buffered \[blocked on chan-\d+\.Receive\]:`, stack)
+}
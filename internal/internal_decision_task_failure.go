@@ -0,0 +1,178 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+)
+
+// decisionTaskFailureDetailsPrefix starts the details of the decision task failures which carry their origin, see
+// decisionTaskFailureDetails.
+var decisionTaskFailureDetailsPrefix = []byte(`{"cadenceDecisionTaskFailure":`)
+
+type (
+	// DecisionTaskFailure describes the failure of the pending decision task of a workflow execution, see
+	// Client.GetDecisionTaskFailure.
+	DecisionTaskFailure struct {
+		// Cause is why the decision task failed, e.g. WORKFLOW_WORKER_UNHANDLED_FAILURE for a panic or a
+		// nondeterministic workflow, or one of the BAD_* causes for a decision rejected by the server.
+		Cause s.DecisionTaskFailedCause
+		// Message is the error reported by the worker or the server.
+		Message string
+		// Decision is the decision related to the failure, e.g. "ScheduleActivityTask MyActivity": the replayed
+		// decision which did not match the history of a nondeterministic workflow, or the last decision the workflow
+		// made before it panicked. It is empty if the failure was not reported by this client.
+		Decision string
+		// CallSite is the workflow code which panicked, e.g. "main.MyWorkflow (/src/workflow.go:42)". It is empty if
+		// the workflow did not panic.
+		CallSite string
+		// Identity is the identity of the worker which failed the decision task.
+		Identity string
+		// BinaryChecksum is the binary checksum of the worker which failed the decision task.
+		BinaryChecksum string
+		// Time is when the first failed attempt of the decision task failed, the server does not record the next
+		// ones.
+		Time time.Time
+		// Attempt is the attempt of the pending decision task, it keeps growing while the decision task fails.
+		Attempt int64
+	}
+
+	// decisionTaskFailureOrigin is what produced the failure of a decision task, it is reported in the logs and in
+	// the details of the failure.
+	decisionTaskFailureOrigin struct {
+		decision string
+		callSite string
+	}
+
+	// decisionTaskFailureDetails are the details of a decision task failure which carry its origin. The details of
+	// the error are kept as they are, so they can still be decoded by the data converter once parsed.
+	decisionTaskFailureDetails struct {
+		Origin struct {
+			Decision string `json:"decision,omitempty"`
+			CallSite string `json:"callSite,omitempty"`
+		} `json:"cadenceDecisionTaskFailure"`
+		Details []byte `json:"details,omitempty"`
+	}
+)
+
+// newDecisionTaskFailureOrigin returns the origin of err, with the last of the pending decisions of the task, if any.
+func newDecisionTaskFailureOrigin(err error, decisions []*s.Decision) decisionTaskFailureOrigin {
+	var origin decisionTaskFailureOrigin
+	var nonDeterministicErr *NonDeterministicError
+	if errors.As(err, &nonDeterministicErr) {
+		origin.decision = nonDeterministicErr.DecisionText
+	}
+	if panicErr, ok := err.(*workflowPanicError); ok {
+		origin.callSite = panicCallSite(panicErr.StackTrace())
+	}
+	if origin.decision == "" && len(decisions) > 0 {
+		origin.decision = describeDecision(decisions[len(decisions)-1])
+	}
+	return origin
+}
+
+// encodeDetails returns the details of a decision task failure with the origin, or the details unchanged if the
+// origin is unknown.
+func (o decisionTaskFailureOrigin) encodeDetails(details []byte) []byte {
+	if o == (decisionTaskFailureOrigin{}) {
+		return details
+	}
+	var d decisionTaskFailureDetails
+	d.Origin.Decision = o.decision
+	d.Origin.CallSite = o.callSite
+	d.Details = details
+	encoded, err := json.Marshal(d)
+	if err != nil {
+		return details
+	}
+	return encoded
+}
+
+// describeDecision returns the type of the decision and what it refers to, e.g. the activity type.
+func describeDecision(d *s.Decision) string {
+	decisionType := d.GetDecisionType().String()
+	switch d.GetDecisionType() {
+	case s.DecisionTypeScheduleActivityTask:
+		return decisionType + " " + d.ScheduleActivityTaskDecisionAttributes.GetActivityType().GetName()
+	case s.DecisionTypeRequestCancelActivityTask:
+		return decisionType + " " + d.RequestCancelActivityTaskDecisionAttributes.GetActivityId()
+	case s.DecisionTypeStartTimer:
+		return decisionType + " " + d.StartTimerDecisionAttributes.GetTimerId()
+	case s.DecisionTypeCancelTimer:
+		return decisionType + " " + d.CancelTimerDecisionAttributes.GetTimerId()
+	case s.DecisionTypeRecordMarker:
+		return decisionType + " " + d.RecordMarkerDecisionAttributes.GetMarkerName()
+	case s.DecisionTypeStartChildWorkflowExecution:
+		return decisionType + " " + d.StartChildWorkflowExecutionDecisionAttributes.GetWorkflowType().GetName()
+	case s.DecisionTypeSignalExternalWorkflowExecution:
+		return decisionType + " " + d.SignalExternalWorkflowExecutionDecisionAttributes.GetSignalName()
+	case s.DecisionTypeRequestCancelExternalWorkflowExecution:
+		return decisionType + " " + d.RequestCancelExternalWorkflowExecutionDecisionAttributes.GetWorkflowId()
+	case s.DecisionTypeContinueAsNewWorkflowExecution:
+		return decisionType + " " + d.ContinueAsNewWorkflowExecutionDecisionAttributes.GetWorkflowType().GetName()
+	}
+	return decisionType
+}
+
+// panicCallSite returns the first frame of the cleaned stack trace of a panic which is not in the cadence client,
+// the go runtime or reflect, i.e. the workflow code which panicked or called the cadence API which panicked.
+func panicCallSite(stackTrace string) string {
+	lines := strings.Split(stackTrace, "\n")
+	for i := 0; i+1 < len(lines); i++ {
+		function := lines[i]
+		if function == "" || strings.HasPrefix(function, "\t") || strings.HasPrefix(function, "goroutine ") ||
+			strings.HasPrefix(function, "coroutine ") {
+			continue
+		}
+		location := strings.TrimSpace(lines[i+1])
+		if !strings.HasPrefix(lines[i+1], "\t") {
+			continue
+		}
+		if strings.HasPrefix(function, "go.uber.org/cadence/") || strings.HasPrefix(function, "runtime.") ||
+			strings.HasPrefix(function, "reflect.") || strings.HasPrefix(function, "panic(") {
+			continue
+		}
+		if idx := strings.LastIndex(location, " +0x"); idx >= 0 {
+			location = location[:idx]
+		}
+		if idx := strings.LastIndex(function, "("); idx > 0 {
+			function = function[:idx]
+		}
+		return function + " (" + location + ")"
+	}
+	return ""
+}
+
+// parseDecisionTaskFailureDetails splits the details of a decision task failure into the details of the error and
+// its origin, the details are returned unchanged if they do not carry an origin.
+func parseDecisionTaskFailureDetails(details []byte) ([]byte, decisionTaskFailureOrigin) {
+	var d decisionTaskFailureDetails
+	if !bytes.HasPrefix(details, decisionTaskFailureDetailsPrefix) || json.Unmarshal(details, &d) != nil {
+		return details, decisionTaskFailureOrigin{}
+	}
+	return d.Details, decisionTaskFailureOrigin{decision: d.Origin.Decision, callSite: d.Origin.CallSite}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestPanicCallSite(t *testing.T) {
+	stackTrace := `coroutine root [panic]:
+panic({0x1234, 0x5678})
+	/usr/local/go/src/runtime/panic.go:770 +0x124
+go.uber.org/cadence/internal.(*decisionStateMachineBase).failStateTransition(...)
+	/go/pkg/mod/go.uber.org/cadence/internal/internal_decision_state_machine.go:120 +0x1a
+github.com/acme/orders.(*Workflows).Charge(0xc000010000, {0x1, 0x2})
+	/src/orders/workflow.go:42 +0x2b
+go.uber.org/cadence/internal.(*workflowEnvironmentInterceptor).ExecuteWorkflow(...)
+	/go/pkg/mod/go.uber.org/cadence/internal/workflow.go:600 +0x3c`
+	assert.Equal(t, "github.com/acme/orders.(*Workflows).Charge (/src/orders/workflow.go:42)", panicCallSite(stackTrace))
+	assert.Equal(t, "", panicCallSite("coroutine root [panic]:"))
+}
+
+func TestDecisionTaskFailureOrigin(t *testing.T) {
+	decisions := []*s.Decision{
+		{
+			DecisionType:                 s.DecisionTypeStartTimer.Ptr(),
+			StartTimerDecisionAttributes: &s.StartTimerDecisionAttributes{TimerId: common.StringPtr("1")},
+		},
+		{
+			DecisionType: s.DecisionTypeScheduleActivityTask.Ptr(),
+			ScheduleActivityTaskDecisionAttributes: &s.ScheduleActivityTaskDecisionAttributes{
+				ActivityType: &s.ActivityType{Name: common.StringPtr("charge")},
+			},
+		},
+	}
+
+	origin := newDecisionTaskFailureOrigin(newWorkflowPanicError("boom", "coroutine root [panic]:\nmain.MyWorkflow(...)\n\t/src/workflow.go:42 +0x2b"), decisions)
+	assert.Equal(t, decisionTaskFailureOrigin{decision: "ScheduleActivityTask charge", callSite: "main.MyWorkflow (/src/workflow.go:42)"}, origin)
+
+	// the details of the error are kept as they are, even if they look like the origin
+	for _, details := range [][]byte{[]byte("boom"), []byte("boom\nFailed decision: x"), {0xff, 0x00, '\n'}, nil} {
+		parsedDetails, parsed := parseDecisionTaskFailureDetails(origin.encodeDetails(details))
+		assert.Equal(t, details, parsedDetails)
+		assert.Equal(t, origin, parsed)
+	}
+	parsedDetails, parsed := parseDecisionTaskFailureDetails([]byte(`{"cadenceDecisionTaskFailure":`))
+	assert.Equal(t, []byte(`{"cadenceDecisionTaskFailure":`), parsedDetails)
+	assert.Equal(t, decisionTaskFailureOrigin{}, parsed)
+
+	origin = newDecisionTaskFailureOrigin(&NonDeterministicError{Reason: "extra replay decision", DecisionText: "StartTimer: 1"}, nil)
+	assert.Equal(t, decisionTaskFailureOrigin{decision: "StartTimer: 1"}, origin)
+	assert.Equal(t, []byte("error"), decisionTaskFailureOrigin{}.encodeDetails([]byte("error")))
+}
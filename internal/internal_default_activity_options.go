@@ -0,0 +1,143 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"encoding/json"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+// defaultActivityOptionsHeaderKey is the workflow header field carrying StartWorkflowOptions.DefaultActivityOptions
+// and StartWorkflowOptions.DefaultLocalActivityOptions to the workflow.
+const defaultActivityOptionsHeaderKey = "cadence-default-activity-options"
+
+// defaultActivityOptions are the activity options a workflow applies to activities which leave a field unset.
+type defaultActivityOptions struct {
+	Activity      *ActivityOptions      `json:"activity,omitempty"`
+	LocalActivity *LocalActivityOptions `json:"localActivity,omitempty"`
+}
+
+func newDefaultActivityOptions(options StartWorkflowOptions) *defaultActivityOptions {
+	if options.DefaultActivityOptions == nil && options.DefaultLocalActivityOptions == nil {
+		return nil
+	}
+	return &defaultActivityOptions{
+		Activity:      options.DefaultActivityOptions,
+		LocalActivity: options.DefaultLocalActivityOptions,
+	}
+}
+
+func getDefaultActivityOptions(ctx Context) *defaultActivityOptions {
+	options := getWorkflowEnvOptions(ctx)
+	if options == nil {
+		return nil
+	}
+	return options.defaultActivityOptions
+}
+
+func writeDefaultActivityOptionsHeader(header *s.Header, d *defaultActivityOptions) error {
+	if d == nil {
+		return nil
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	if header.Fields == nil {
+		header.Fields = make(map[string][]byte)
+	}
+	header.Fields[defaultActivityOptionsHeaderKey] = data
+	return nil
+}
+
+func readDefaultActivityOptionsHeader(header *s.Header) (*defaultActivityOptions, error) {
+	if header == nil {
+		return nil, nil
+	}
+	data, ok := header.Fields[defaultActivityOptionsHeaderKey]
+	if !ok {
+		return nil, nil
+	}
+	var d defaultActivityOptions
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (d *defaultActivityOptions) activity() *ActivityOptions {
+	if d == nil {
+		return nil
+	}
+	return d.Activity
+}
+
+func (d *defaultActivityOptions) localActivity() *LocalActivityOptions {
+	if d == nil {
+		return nil
+	}
+	return d.LocalActivity
+}
+
+// applyToActivity sets every unset field of p to its default value.
+func (d *defaultActivityOptions) applyToActivity(p *activityOptions) {
+	defaults := d.activity()
+	if defaults == nil {
+		return
+	}
+	if p.TaskListName == "" {
+		p.TaskListName = defaults.TaskList
+	}
+	if p.ScheduleToCloseTimeoutSeconds == 0 {
+		p.ScheduleToCloseTimeoutSeconds = common.Int32Ceil(defaults.ScheduleToCloseTimeout.Seconds())
+	}
+	if p.ScheduleToStartTimeoutSeconds == 0 {
+		p.ScheduleToStartTimeoutSeconds = common.Int32Ceil(defaults.ScheduleToStartTimeout.Seconds())
+	}
+	if p.StartToCloseTimeoutSeconds == 0 {
+		p.StartToCloseTimeoutSeconds = common.Int32Ceil(defaults.StartToCloseTimeout.Seconds())
+	}
+	if p.HeartbeatTimeoutSeconds == 0 {
+		p.HeartbeatTimeoutSeconds = common.Int32Ceil(defaults.HeartbeatTimeout.Seconds())
+	}
+	if !p.WaitForCancellation {
+		p.WaitForCancellation = defaults.WaitForCancellation
+	}
+	if p.RetryPolicy == nil {
+		p.RetryPolicy = convertRetryPolicy(defaults.RetryPolicy)
+	}
+}
+
+// applyToLocalActivity sets every unset field of p to its default value.
+func (d *defaultActivityOptions) applyToLocalActivity(p *localActivityOptions) {
+	defaults := d.localActivity()
+	if defaults == nil {
+		return
+	}
+	if p.ScheduleToCloseTimeoutSeconds == 0 {
+		p.ScheduleToCloseTimeoutSeconds = common.Int32Ceil(defaults.ScheduleToCloseTimeout.Seconds())
+	}
+	if p.RetryPolicy == nil {
+		p.RetryPolicy = defaults.RetryPolicy
+	}
+}
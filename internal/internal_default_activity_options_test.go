@@ -0,0 +1,124 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+func TestDefaultActivityOptionsHeader(t *testing.T) {
+	header := &shared.Header{}
+	require.NoError(t, writeDefaultActivityOptionsHeader(header, newDefaultActivityOptions(StartWorkflowOptions{})))
+	assert.Empty(t, header.Fields)
+
+	options := StartWorkflowOptions{
+		DefaultActivityOptions: &ActivityOptions{
+			StartToCloseTimeout: time.Minute,
+			RetryPolicy:         &RetryPolicy{InitialInterval: time.Second, MaximumAttempts: 3},
+		},
+		DefaultLocalActivityOptions: &LocalActivityOptions{ScheduleToCloseTimeout: time.Second},
+	}
+	require.NoError(t, writeDefaultActivityOptionsHeader(header, newDefaultActivityOptions(options)))
+	defaults, err := readDefaultActivityOptionsHeader(header)
+	require.NoError(t, err)
+	assert.Equal(t, options.DefaultActivityOptions, defaults.Activity)
+	assert.Equal(t, options.DefaultLocalActivityOptions, defaults.LocalActivity)
+
+	header.Fields[defaultActivityOptionsHeaderKey] = []byte("not json")
+	_, err = readDefaultActivityOptionsHeader(header)
+	assert.Error(t, err)
+}
+
+func TestDefaultActivityOptionsInWorkflow(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	t.Run("unset fields use the defaults", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.SetDefaultActivityOptions(ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			HeartbeatTimeout:       10 * time.Second,
+		})
+		var heartbeatTimeouts []time.Duration
+		activityFn := func(ctx context.Context) error {
+			heartbeatTimeouts = append(heartbeatTimeouts, GetActivityInfo(ctx).HeartbeatTimeout)
+			return nil
+		}
+		env.RegisterActivityWithOptions(activityFn, RegisterActivityOptions{Name: "activity"})
+
+		env.ExecuteWorkflow(func(ctx Context) error {
+			// no activity options at all
+			if err := ExecuteActivity(ctx, "activity").Get(ctx, nil); err != nil {
+				return err
+			}
+			// options overriding a single field
+			ctx = WithActivityOptions(ctx, ActivityOptions{HeartbeatTimeout: 20 * time.Second})
+			return ExecuteActivity(ctx, "activity").Get(ctx, nil)
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, []time.Duration{10 * time.Second, 20 * time.Second}, heartbeatTimeouts)
+	})
+
+	t.Run("local activities use the defaults", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.SetDefaultLocalActivityOptions(LocalActivityOptions{ScheduleToCloseTimeout: time.Minute})
+		env.ExecuteWorkflow(func(ctx Context) error {
+			return ExecuteLocalActivity(ctx, func() error { return nil }).Get(ctx, nil)
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+	})
+
+	t.Run("without defaults options are still required", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) error {
+			return ExecuteLocalActivity(ctx, func() error { return nil }).Get(ctx, nil)
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		assert.EqualError(t, env.GetWorkflowError(), errLocalActivityParamsBadRequest.Error())
+	})
+}
+
+func TestStartRequestCarriesDefaultActivityOptions(t *testing.T) {
+	wc, ok := NewClient(nil, "test-domain", &ClientOptions{Identity: "test-identity"}).(*workflowClient)
+	require.True(t, ok)
+
+	options := StartWorkflowOptions{
+		TaskList:                     "tl",
+		ExecutionStartToCloseTimeout: time.Minute,
+		DefaultActivityOptions:       &ActivityOptions{StartToCloseTimeout: time.Minute},
+	}
+	request, err := wc.getWorkflowStartRequest(context.Background(), "", options, func(ctx Context) {})
+	require.NoError(t, err)
+
+	defaults, err := readDefaultActivityOptionsHeader(request.Header)
+	require.NoError(t, err)
+	assert.Equal(t, options.DefaultActivityOptions, defaults.Activity)
+	assert.Nil(t, defaults.LocalActivity)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common/metrics"
+)
+
+type (
+	// DiagnosticsSamplingOptions select the workflow executions for which a worker emits verbose diagnostics, see
+	// WorkerOptions.DiagnosticsSampling. Each decision task of a sampled execution is logged with its latency, the
+	// size of the payloads of its decisions and the YieldDiagnostics of its coroutines, and reported in the
+	// cadence-sampled-decision-* metrics tagged by workflow type.
+	DiagnosticsSamplingOptions struct {
+		// Optional: Fraction of the workflow executions to sample, between 0 and 1. Executions are selected by a hash
+		// of their workflow ID, so every run and decision task of a sampled workflow is sampled, on every worker.
+		// default: 0, which samples no execution
+		Rate float64
+
+		// Optional: Rate used for the workflow types of this map instead of Rate.
+		WorkflowTypeRates map[string]float64
+
+		// Optional: Selects the sampled executions instead of the rates.
+		Sampler func(info *WorkflowInfo) bool
+	}
+)
+
+// enabled returns whether any execution can be sampled.
+func (o DiagnosticsSamplingOptions) enabled() bool {
+	return o.Rate > 0 || len(o.WorkflowTypeRates) > 0 || o.Sampler != nil
+}
+
+// sampled returns whether verbose diagnostics are emitted for the execution described by info.
+func (o DiagnosticsSamplingOptions) sampled(info *WorkflowInfo) bool {
+	if o.Sampler != nil {
+		return o.Sampler(info)
+	}
+	rate, ok := o.WorkflowTypeRates[info.WorkflowType.Name]
+	if !ok {
+		rate = o.Rate
+	}
+	switch {
+	case rate <= 0:
+		return false
+	case rate >= 1:
+		return true
+	}
+	return float64(StableHash([]byte(info.WorkflowExecution.ID))) < rate*math.MaxUint64
+}
+
+// logSampledDecisionTask emits the verbose diagnostics of a decision task of a sampled workflow execution.
+func (wth *workflowTaskHandlerImpl) logSampledDecisionTask(
+	task *s.PollForDecisionTaskResponse,
+	response interface{},
+	yields *yieldRecorder,
+	latency time.Duration,
+) {
+	var decisions []*s.Decision
+	if completed, ok := response.(*s.RespondDecisionTaskCompletedRequest); ok {
+		decisions = completed.Decisions
+	}
+	payloadSize := 0
+	for _, d := range decisions {
+		payloadSize += decisionPayloadSize(d)
+	}
+
+	scope := wth.metricsScope.GetTaggedScope(tagWorkflowType, task.WorkflowType.GetName())
+	scope.Timer(metrics.SampledDecisionTaskLatency).Record(latency)
+	scope.Gauge(metrics.SampledDecisionPayloadSize).Update(float64(payloadSize))
+
+	fields := []zap.Field{
+		zap.String(tagWorkflowType, task.WorkflowType.GetName()),
+		zap.String(tagWorkflowID, task.WorkflowExecution.GetWorkflowId()),
+		zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
+		zap.Duration("Latency", latency),
+		zap.Int("HistoryEvents", len(task.History.GetEvents())),
+		zap.Int("Decisions", len(decisions)),
+		zap.Int("DecisionPayloadSize", payloadSize),
+	}
+	if yields != nil {
+		fields = append(fields, zap.Reflect("YieldDiagnostics", yields.diagnostics()))
+	}
+	wth.logger.Info("Sampled decision task.", fields...)
+}
+
+// decisionPayloadSize returns the size of the payloads carried by a decision.
+func decisionPayloadSize(d *s.Decision) int {
+	switch d.GetDecisionType() {
+	case s.DecisionTypeScheduleActivityTask:
+		return len(d.ScheduleActivityTaskDecisionAttributes.GetInput())
+	case s.DecisionTypeCompleteWorkflowExecution:
+		return len(d.CompleteWorkflowExecutionDecisionAttributes.GetResult())
+	case s.DecisionTypeFailWorkflowExecution:
+		return len(d.FailWorkflowExecutionDecisionAttributes.GetDetails())
+	case s.DecisionTypeCancelWorkflowExecution:
+		return len(d.CancelWorkflowExecutionDecisionAttributes.GetDetails())
+	case s.DecisionTypeRecordMarker:
+		return len(d.RecordMarkerDecisionAttributes.GetDetails())
+	case s.DecisionTypeContinueAsNewWorkflowExecution:
+		return len(d.ContinueAsNewWorkflowExecutionDecisionAttributes.GetInput())
+	case s.DecisionTypeStartChildWorkflowExecution:
+		return len(d.StartChildWorkflowExecutionDecisionAttributes.GetInput())
+	case s.DecisionTypeSignalExternalWorkflowExecution:
+		return len(d.SignalExternalWorkflowExecutionDecisionAttributes.GetInput())
+	}
+	return 0
+}
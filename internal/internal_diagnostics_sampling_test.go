@@ -0,0 +1,67 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnosticsSamplingOptions(t *testing.T) {
+	info := func(workflowType, workflowID string) *WorkflowInfo {
+		return &WorkflowInfo{
+			WorkflowType:      WorkflowType{Name: workflowType},
+			WorkflowExecution: WorkflowExecution{ID: workflowID},
+		}
+	}
+
+	assert.False(t, DiagnosticsSamplingOptions{}.enabled())
+	assert.True(t, DiagnosticsSamplingOptions{Rate: 0.1}.enabled())
+	assert.False(t, DiagnosticsSamplingOptions{}.sampled(info("a", "id")))
+	assert.True(t, DiagnosticsSamplingOptions{Rate: 1}.sampled(info("a", "id")))
+
+	options := DiagnosticsSamplingOptions{Rate: 1, WorkflowTypeRates: map[string]float64{"b": 0}}
+	assert.True(t, options.sampled(info("a", "id")))
+	assert.False(t, options.sampled(info("b", "id")))
+
+	options = DiagnosticsSamplingOptions{
+		Rate: 1,
+		Sampler: func(info *WorkflowInfo) bool {
+			return info.WorkflowExecution.ID == "debug-me"
+		},
+	}
+	assert.True(t, options.sampled(info("a", "debug-me")))
+	assert.False(t, options.sampled(info("a", "id")))
+
+	options = DiagnosticsSamplingOptions{Rate: 0.25}
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("workflow-%d", i)
+		if options.sampled(info("a", id)) {
+			sampled++
+			// the decision only depends on the workflow ID
+			assert.True(t, options.sampled(info("b", id)))
+		}
+	}
+	assert.InDelta(t, 250, sampled, 50)
+}
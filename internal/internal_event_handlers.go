@@ -28,6 +28,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -108,6 +109,7 @@ type (
 
 		completeHandler completionHandler               // events completion handler
 		cancelHandler   func()                          // A cancel handler to be invoked on a cancel notification
+		cancellation    *CancellationDetails            // Details of the cancel request, if any
 		signalHandler   func(name string, input []byte) // A signal handler to be invoked on a signal event
 		queryHandler    func(queryType string, queryArgs []byte) ([]byte, error)
 
@@ -121,6 +123,11 @@ type (
 		contextPropagators           []ContextPropagator
 		tracer                       opentracing.Tracer
 		workflowInterceptorFactories []WorkflowInterceptorFactory
+		profile                      *executionProfileTracker // nil unless WorkerOptions.EnableExecutionProfile
+		payloadRedactor              PayloadRedactor
+		namingPolicies               NamingPolicies
+		yields                       *yieldRecorder // nil unless WorkerOptions.EnableYieldDiagnostics
+		decisionDeadline             time.Time      // local time the current decision task times out at
 	}
 
 	localActivityTask struct {
@@ -205,6 +212,10 @@ func newWorkflowExecutionEventHandler(
 	contextPropagators []ContextPropagator,
 	tracer opentracing.Tracer,
 	workflowInterceptorFactories []WorkflowInterceptorFactory,
+	enableExecutionProfile bool,
+	payloadRedactor PayloadRedactor,
+	namingPolicies NamingPolicies,
+	enableYieldDiagnostics bool,
 ) workflowExecutionEventHandler {
 	context := &workflowEnvironmentImpl{
 		workflowInfo:                 workflowInfo,
@@ -222,6 +233,8 @@ func newWorkflowExecutionEventHandler(
 		contextPropagators:           contextPropagators,
 		tracer:                       tracer,
 		workflowInterceptorFactories: workflowInterceptorFactories,
+		payloadRedactor:              payloadRedactor,
+		namingPolicies:               namingPolicies,
 	}
 	context.logger = logger.With(
 		zapcore.Field{Key: tagWorkflowType, Type: zapcore.StringType, String: workflowInfo.WorkflowType.Name},
@@ -229,6 +242,13 @@ func newWorkflowExecutionEventHandler(
 		zapcore.Field{Key: tagRunID, Type: zapcore.StringType, String: workflowInfo.WorkflowExecution.RunID},
 	).WithOptions(zap.WrapCore(wrapLogger(&context.isReplay, &context.enableLoggingInReplay)))
 
+	if enableExecutionProfile {
+		context.profile = newExecutionProfileTracker()
+	}
+	if enableYieldDiagnostics {
+		context.yields = newYieldRecorder()
+	}
+
 	if scope != nil {
 		context.metricsScope = tagScope(metrics.WrapScope(&context.isReplay, scope, context),
 			tagWorkflowType, workflowInfo.WorkflowType.Name)
@@ -370,6 +390,10 @@ func validateAndSerializeSearchAttributes(attributes map[string]interface{}) (*s
 	return attr, nil
 }
 
+func (wc *workflowEnvironmentImpl) GetCancellationDetails() *CancellationDetails {
+	return wc.cancellation
+}
+
 func (wc *workflowEnvironmentImpl) RegisterCancelHandler(handler func()) {
 	wc.cancelHandler = handler
 }
@@ -774,6 +798,36 @@ func (wc *workflowEnvironmentImpl) GetWorkflowInterceptors() []WorkflowIntercept
 	return wc.workflowInterceptorFactories
 }
 
+func (wc *workflowEnvironmentImpl) GetPayloadRedactor() PayloadRedactor {
+	return wc.payloadRedactor
+}
+
+func (wc *workflowEnvironmentImpl) GetNamingPolicies() NamingPolicies {
+	return wc.namingPolicies
+}
+
+func (wc *workflowEnvironmentImpl) GetTracer() opentracing.Tracer {
+	return wc.tracer
+}
+
+func (wc *workflowEnvironmentImpl) GetYieldRecorder() *yieldRecorder {
+	return wc.yields
+}
+
+func (wc *workflowEnvironmentImpl) GetChangeVersions() map[string]Version {
+	return wc.changeVersions
+}
+
+func (wc *workflowEnvironmentImpl) GetDecisionTimeRemaining() time.Duration {
+	if wc.decisionDeadline.IsZero() {
+		return time.Duration(wc.workflowInfo.TaskStartToCloseTimeoutSeconds) * time.Second
+	}
+	if remaining := time.Until(wc.decisionDeadline); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
 func (weh *workflowExecutionEventHandlerImpl) ProcessEvent(
 	event *m.HistoryEvent,
 	isReplay bool,
@@ -788,7 +842,7 @@ func (weh *workflowExecutionEventHandlerImpl) ProcessEvent(
 			topLine := fmt.Sprintf("process event for %s [panic]:", weh.workflowInfo.TaskListName)
 			st := getStackTraceRaw(topLine, 7, 0)
 			weh.logger.Error("ProcessEvent panic.",
-				zap.String(tagPanicError, fmt.Sprintf("%v", p)),
+				zap.String(tagPanicError, redactPayload(weh.payloadRedactor, fmt.Sprintf("%v", p))),
 				zap.String(tagPanicStack, st))
 
 			weh.Complete(nil, newWorkflowPanicError(p, st))
@@ -802,6 +856,10 @@ func (weh *workflowExecutionEventHandlerImpl) ProcessEvent(
 			zap.String(tagEventType, event.GetEventType().String()))
 	})
 
+	if weh.profile != nil {
+		weh.profile.processEvent(event)
+	}
+
 	switch event.GetEventType() {
 	// Noops
 	case m.EventTypeWorkflowExecutionCompleted,
@@ -849,7 +907,7 @@ func (weh *workflowExecutionEventHandlerImpl) ProcessEvent(
 	case m.EventTypeCancelTimerFailed:
 		weh.decisionsHelper.handleCancelTimerFailed(event.CancelTimerFailedEventAttributes.GetTimerId())
 	case m.EventTypeWorkflowExecutionCancelRequested:
-		weh.handleWorkflowExecutionCancelRequested()
+		weh.handleWorkflowExecutionCancelRequested(event.WorkflowExecutionCancelRequestedEventAttributes)
 	case m.EventTypeRequestCancelExternalWorkflowExecutionInitiated:
 		weh.handleRequestCancelExternalWorkflowExecutionInitiated(event)
 	case m.EventTypeRequestCancelExternalWorkflowExecutionFailed:
@@ -918,27 +976,43 @@ func (weh *workflowExecutionEventHandlerImpl) ProcessQuery(queryType string, que
 		return weh.encodeArg(weh.getOpenSessions())
 	case QueryTypeQueryTypes:
 		return weh.encodeArg(weh.KnownQueryTypes())
-	default:
-		result, err := weh.queryHandler(queryType, queryArgs)
-		if err != nil {
-			return nil, err
+	case QueryTypeExecutionProfile:
+		if weh.profile != nil {
+			return weh.encodeArg(weh.profile.profile)
 		}
-
-		resultSize := len(result)
-		if resultSize > queryResultSizeLimit {
-			weh.logger.Error("Query result size exceeds limit.",
-				zap.String(tagQueryType, queryType),
-				zap.String(tagWorkflowID, weh.workflowInfo.WorkflowExecution.ID),
-				zap.String(tagRunID, weh.workflowInfo.WorkflowExecution.RunID))
-			return nil, fmt.Errorf("query result size (%v) exceeds limit (%v)", resultSize, queryResultSizeLimit)
+	case QueryTypeYieldDiagnostics:
+		if weh.yields != nil {
+			return weh.encodeArg(weh.yields.diagnostics())
 		}
+	}
+
+	result, err := weh.queryHandler(queryType, queryArgs)
+	if err != nil {
+		return nil, err
+	}
 
-		return result, nil
+	resultSize := len(result)
+	if resultSize > queryResultSizeLimit {
+		weh.logger.Error("Query result size exceeds limit.",
+			zap.String(tagQueryType, queryType),
+			zap.String(tagWorkflowID, weh.workflowInfo.WorkflowExecution.ID),
+			zap.String(tagRunID, weh.workflowInfo.WorkflowExecution.RunID))
+		return nil, fmt.Errorf("query result size (%v) exceeds limit (%v)", resultSize, queryResultSizeLimit)
 	}
+
+	return result, nil
 }
 
 func (weh *workflowExecutionEventHandlerImpl) KnownQueryTypes() []string {
-	return weh.workflowDefinition.KnownQueryTypes()
+	queryTypes := weh.workflowDefinition.KnownQueryTypes()
+	if weh.profile != nil {
+		queryTypes = append(queryTypes, QueryTypeExecutionProfile)
+	}
+	if weh.yields != nil {
+		queryTypes = append(queryTypes, QueryTypeYieldDiagnostics)
+	}
+	sort.Strings(queryTypes)
+	return queryTypes
 }
 
 func (weh *workflowExecutionEventHandlerImpl) StackTrace() string {
@@ -1037,7 +1111,9 @@ func (weh *workflowExecutionEventHandlerImpl) handleTimerFired(event *m.HistoryE
 	timer.handle(nil, nil)
 }
 
-func (weh *workflowExecutionEventHandlerImpl) handleWorkflowExecutionCancelRequested() {
+func (weh *workflowExecutionEventHandlerImpl) handleWorkflowExecutionCancelRequested(
+	attributes *m.WorkflowExecutionCancelRequestedEventAttributes) {
+	weh.cancellation = newWorkflowCancellationDetails(attributes)
 	weh.cancelHandler()
 }
 
@@ -1320,8 +1396,10 @@ func (weh *workflowExecutionEventHandlerImpl) handleSignalExternalWorkflowExecut
 	switch attributes.GetCause() {
 	case shared.SignalExternalWorkflowExecutionFailedCauseUnknownExternalWorkflowExecution:
 		err = newUnknownExternalWorkflowExecutionError()
+	case shared.SignalExternalWorkflowExecutionFailedCauseWorkflowAlreadyCompleted:
+		err = newExternalWorkflowAlreadyCompletedError()
 	default:
-		err = fmt.Errorf("signal external workflow failed, %v", attributes.GetCause())
+		err = &SignalExternalWorkflowError{Cause: attributes.GetCause().String()}
 	}
 
 	signal.handle(nil, err)
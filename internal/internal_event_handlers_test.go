@@ -284,6 +284,17 @@ func Test_GetChangeVersion(t *testing.T) {
 	}
 }
 
+func Test_GetDecisionTimeRemaining(t *testing.T) {
+	env := &workflowEnvironmentImpl{workflowInfo: &WorkflowInfo{TaskStartToCloseTimeoutSeconds: 10}}
+	assert.Equal(t, 10*time.Second, env.GetDecisionTimeRemaining())
+
+	env.decisionDeadline = time.Now().Add(time.Minute)
+	assert.InDelta(t, time.Minute, env.GetDecisionTimeRemaining(), float64(time.Second))
+
+	env.decisionDeadline = time.Now().Add(-time.Second)
+	assert.Equal(t, time.Duration(0), env.GetDecisionTimeRemaining())
+}
+
 func Test_GetChangeVersions(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -413,7 +424,7 @@ func TestProcessQuery_KnownQueryTypes(t *testing.T) {
 
 	result, err := weh.ProcessQuery(QueryTypeQueryTypes, nil)
 	assert.NoError(t, err)
-	assert.Equal(t, "[\"__open_sessions\",\"__query_types\",\"__stack_trace\",\"a\"]\n", string(result))
+	assert.Equal(t, "[\"__open_sessions\",\"__query_types\",\"__stack_trace\",\"__unhandled_signals\",\"__versions\",\"a\"]\n", string(result))
 }
 
 func TestWorkflowExecutionEventHandler_ProcessEvent_WorkflowExecutionStarted(t *testing.T) {
@@ -993,6 +1004,10 @@ func testWorkflowExecutionEventHandler(t *testing.T, registry *registry) *workfl
 		nil,
 		opentracing.NoopTracer{},
 		nil,
+		false,
+		nil,
+		NamingPolicies{},
+		false,
 	).(*workflowExecutionEventHandlerImpl)
 }
 
@@ -0,0 +1,146 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"time"
+
+	"github.com/uber-go/tally"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common/metrics"
+)
+
+type (
+	// ExecutionProfile is the cost profile of a workflow execution, returned by the QueryTypeExecutionProfile query
+	// of workers with WorkerOptions.EnableExecutionProfile. It is built from the history of the execution, so it is
+	// the same on every worker and survives worker restarts.
+	ExecutionProfile struct {
+		// Decisions is the number of decision tasks started for the execution, including failed and timed out ones.
+		Decisions int
+		// Activities is the profile of the activities of the execution by activity type.
+		// Local activities are not included.
+		Activities map[string]*ActivityProfile
+	}
+
+	// ActivityProfile accumulates the activities of one activity type within a workflow execution.
+	ActivityProfile struct {
+		// Scheduled is the number of activities scheduled.
+		Scheduled int
+		// Closed is the number of activities which completed, failed, timed out or were canceled.
+		Closed int
+		// Failed is the number of activities which failed or timed out after all retries.
+		Failed int
+		// Retries is the number of retried attempts of the closed activities.
+		Retries int
+		// TotalDuration is the sum of the time from scheduling to closing of the closed activities, retries included.
+		TotalDuration time.Duration
+		// MaxDuration is the longest time from scheduling to closing of a closed activity.
+		MaxDuration time.Duration
+	}
+
+	// executionProfileTracker builds the ExecutionProfile of a workflow execution from its history events.
+	executionProfileTracker struct {
+		profile   ExecutionProfile
+		scheduled map[int64]*profiledActivity // by ActivityTaskScheduled event ID
+	}
+
+	profiledActivity struct {
+		activityType  string
+		scheduledTime int64 // event timestamp in nanoseconds
+		attempt       int32
+	}
+)
+
+func newExecutionProfileTracker() *executionProfileTracker {
+	return &executionProfileTracker{
+		profile:   ExecutionProfile{Activities: make(map[string]*ActivityProfile)},
+		scheduled: make(map[int64]*profiledActivity),
+	}
+}
+
+func (t *executionProfileTracker) processEvent(event *s.HistoryEvent) {
+	switch event.GetEventType() {
+	case s.EventTypeDecisionTaskStarted:
+		t.profile.Decisions++
+	case s.EventTypeActivityTaskScheduled:
+		activityType := event.ActivityTaskScheduledEventAttributes.GetActivityType().GetName()
+		t.activityProfile(activityType).Scheduled++
+		t.scheduled[event.GetEventId()] = &profiledActivity{activityType: activityType, scheduledTime: event.GetTimestamp()}
+	case s.EventTypeActivityTaskStarted:
+		if activity, ok := t.scheduled[event.ActivityTaskStartedEventAttributes.GetScheduledEventId()]; ok {
+			activity.attempt = event.ActivityTaskStartedEventAttributes.GetAttempt()
+		}
+	case s.EventTypeActivityTaskCompleted:
+		t.closeActivity(event.ActivityTaskCompletedEventAttributes.GetScheduledEventId(), event.GetTimestamp(), false)
+	case s.EventTypeActivityTaskFailed:
+		t.closeActivity(event.ActivityTaskFailedEventAttributes.GetScheduledEventId(), event.GetTimestamp(), true)
+	case s.EventTypeActivityTaskTimedOut:
+		t.closeActivity(event.ActivityTaskTimedOutEventAttributes.GetScheduledEventId(), event.GetTimestamp(), true)
+	case s.EventTypeActivityTaskCanceled:
+		t.closeActivity(event.ActivityTaskCanceledEventAttributes.GetScheduledEventId(), event.GetTimestamp(), false)
+	}
+}
+
+func (t *executionProfileTracker) activityProfile(activityType string) *ActivityProfile {
+	profile, ok := t.profile.Activities[activityType]
+	if !ok {
+		profile = &ActivityProfile{}
+		t.profile.Activities[activityType] = profile
+	}
+	return profile
+}
+
+func (t *executionProfileTracker) closeActivity(scheduledEventID int64, closedTime int64, failed bool) {
+	activity, ok := t.scheduled[scheduledEventID]
+	if !ok {
+		return
+	}
+	delete(t.scheduled, scheduledEventID)
+
+	profile := t.activityProfile(activity.activityType)
+	profile.Closed++
+	profile.Retries += int(activity.attempt)
+	if failed {
+		profile.Failed++
+	}
+	duration := time.Duration(closedTime - activity.scheduledTime)
+	profile.TotalDuration += duration
+	if duration > profile.MaxDuration {
+		profile.MaxDuration = duration
+	}
+}
+
+// emitMetrics reports the totals of the profile when the workflow execution closes. The scope is expected to be
+// tagged with the workflow type.
+func (t *executionProfileTracker) emitMetrics(scope tally.Scope) {
+	var activities, retries int
+	var duration time.Duration
+	for _, profile := range t.profile.Activities {
+		activities += profile.Closed
+		retries += profile.Retries
+		duration += profile.TotalDuration
+	}
+	scope.Counter(metrics.WorkflowProfileDecisions).Inc(int64(t.profile.Decisions))
+	scope.Counter(metrics.WorkflowProfileActivities).Inc(int64(activities))
+	scope.Counter(metrics.WorkflowProfileActivityRetries).Inc(int64(retries))
+	scope.Timer(metrics.WorkflowProfileActivityDuration).Record(duration)
+}
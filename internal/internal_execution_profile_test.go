@@ -0,0 +1,86 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestExecutionProfileTracker(t *testing.T) {
+	scheduled := func(eventID int64, activityType string, at time.Duration) *s.HistoryEvent {
+		event := createTestEventActivityTaskScheduled(eventID, &s.ActivityTaskScheduledEventAttributes{
+			ActivityType: &s.ActivityType{Name: common.StringPtr(activityType)},
+		})
+		event.Timestamp = common.Int64Ptr(int64(at))
+		return event
+	}
+	withTimestamp := func(event *s.HistoryEvent, at time.Duration) *s.HistoryEvent {
+		event.Timestamp = common.Int64Ptr(int64(at))
+		return event
+	}
+
+	events := []*s.HistoryEvent{
+		createTestEventDecisionTaskStarted(1),
+		scheduled(2, "charge", 0),
+		scheduled(3, "charge", 0),
+		scheduled(4, "ship", time.Second),
+		scheduled(5, "notify", time.Second),
+		withTimestamp(createTestEventActivityTaskCompleted(6, &s.ActivityTaskCompletedEventAttributes{
+			ScheduledEventId: common.Int64Ptr(2),
+		}), time.Second),
+		createTestEventActivityTaskStarted(7, &s.ActivityTaskStartedEventAttributes{
+			ScheduledEventId: common.Int64Ptr(3),
+			Attempt:          common.Int32Ptr(3),
+		}),
+		withTimestamp(&s.HistoryEvent{
+			EventId:   common.Int64Ptr(8),
+			EventType: common.EventTypePtr(s.EventTypeActivityTaskFailed),
+			ActivityTaskFailedEventAttributes: &s.ActivityTaskFailedEventAttributes{
+				ScheduledEventId: common.Int64Ptr(3),
+			},
+		}, 5*time.Second),
+		withTimestamp(createTestEventActivityTaskTimedOut(9, &s.ActivityTaskTimedOutEventAttributes{
+			ScheduledEventId: common.Int64Ptr(4),
+		}), 3*time.Second),
+		createTestEventDecisionTaskStarted(10),
+		// closing an activity scheduled before the tracked history, e.g. by a reset, is ignored
+		createTestEventActivityTaskCompleted(11, &s.ActivityTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(1)}),
+	}
+
+	tracker := newExecutionProfileTracker()
+	for _, event := range events {
+		tracker.processEvent(event)
+	}
+	assert.Equal(t, ExecutionProfile{
+		Decisions: 2,
+		Activities: map[string]*ActivityProfile{
+			"charge": {Scheduled: 2, Closed: 2, Failed: 1, Retries: 3, TotalDuration: 6 * time.Second, MaxDuration: 5 * time.Second},
+			"ship":   {Scheduled: 1, Closed: 1, Failed: 1, TotalDuration: 2 * time.Second, MaxDuration: 2 * time.Second},
+			"notify": {Scheduled: 1},
+		},
+	}, tracker.profile)
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"sort"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"go.uber.org/cadence/internal/common/metrics"
+)
+
+const (
+	defaultHistoryCountLimit = 200 * 1024
+	defaultHistorySizeLimit  = 200 * 1024 * 1024
+
+	historyLimitTypeCount = "count"
+	historyLimitTypeSize  = "size"
+)
+
+var defaultHistoryLimitWarningThresholds = []float64{0.25, 0.5, 0.75}
+
+type (
+	// historyGuardrails warns when workflow histories approach the server limits.
+	historyGuardrails struct {
+		countLimit int64
+		sizeLimit  int64
+		thresholds []float64 // sorted ascending
+	}
+
+	// historyGuardrailsState tracks the thresholds already reported for a workflow execution.
+	historyGuardrailsState struct {
+		countReported int // number of thresholds reported for the history length
+		sizeReported  int // number of thresholds reported for the history size
+	}
+)
+
+func newHistoryGuardrails(options WorkerOptions) *historyGuardrails {
+	if options.DisableHistoryLimitWarnings {
+		return nil
+	}
+	g := &historyGuardrails{
+		countLimit: options.HistoryCountLimit,
+		sizeLimit:  options.HistorySizeLimit,
+		thresholds: append([]float64(nil), options.HistoryLimitWarningThresholds...),
+	}
+	if g.countLimit == 0 {
+		g.countLimit = defaultHistoryCountLimit
+	}
+	if g.sizeLimit == 0 {
+		g.sizeLimit = defaultHistorySizeLimit
+	}
+	if len(g.thresholds) == 0 {
+		g.thresholds = append(g.thresholds, defaultHistoryLimitWarningThresholds...)
+	}
+	sort.Float64s(g.thresholds)
+	return g
+}
+
+// check reports the thresholds crossed by the workflow since the last check.
+func (g *historyGuardrails) check(
+	state *historyGuardrailsState,
+	info *WorkflowInfo,
+	metricsScope *metrics.TaggedScope,
+	logger *zap.Logger,
+) {
+	if g == nil {
+		return
+	}
+	historySize := info.HistoryBytesServer
+	if historySize == 0 {
+		historySize = info.TotalHistoryBytes
+	}
+	state.countReported = g.report(historyLimitTypeCount, info.HistoryCount, g.countLimit, state.countReported, info, metricsScope, logger)
+	state.sizeReported = g.report(historyLimitTypeSize, historySize, g.sizeLimit, state.sizeReported, info, metricsScope, logger)
+}
+
+func (g *historyGuardrails) report(
+	limitType string,
+	value int64,
+	limit int64,
+	reported int,
+	info *WorkflowInfo,
+	metricsScope *metrics.TaggedScope,
+	logger *zap.Logger,
+) int {
+	crossed := reported
+	for crossed < len(g.thresholds) && float64(value) >= g.thresholds[crossed]*float64(limit) {
+		crossed++
+	}
+	if crossed == reported {
+		return reported
+	}
+
+	threshold := g.thresholds[crossed-1]
+	metricsScope.GetTaggedScope(
+		tagWorkflowType, info.WorkflowType.Name,
+		tagHistoryLimitType, limitType,
+		tagHistoryLimitThreshold, strconv.FormatFloat(threshold, 'f', -1, 64),
+	).Counter(metrics.HistoryLimitWarningCounter).Inc(1)
+	logger.Warn("Workflow history is approaching the server limit.",
+		zap.String(tagWorkflowType, info.WorkflowType.Name),
+		zap.String(tagWorkflowID, info.WorkflowExecution.ID),
+		zap.String(tagRunID, info.WorkflowExecution.RunID),
+		zap.String(tagHistoryLimitType, limitType),
+		zap.Float64(tagHistoryLimitThreshold, threshold),
+		zap.Int64("Value", value),
+		zap.Int64("Limit", limit),
+	)
+	return crossed
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"go.uber.org/cadence/internal/common/metrics"
+)
+
+func TestHistoryGuardrails(t *testing.T) {
+	guardrails := newHistoryGuardrails(WorkerOptions{
+		HistoryLimitWarningThresholds: []float64{0.8, 0.5},
+		HistoryCountLimit:             100,
+		HistorySizeLimit:              1000,
+	})
+	require.Equal(t, []float64{0.5, 0.8}, guardrails.thresholds)
+
+	scope := tally.NewTestScope("", nil)
+	obs, logs := observer.New(zap.WarnLevel)
+	info := &WorkflowInfo{
+		WorkflowType:      WorkflowType{Name: "wf-type"},
+		WorkflowExecution: WorkflowExecution{ID: "wf-id", RunID: "run-id"},
+	}
+	state := &historyGuardrailsState{}
+	check := func(count, size int64) {
+		info.HistoryCount = count
+		info.HistoryBytesServer = size
+		guardrails.check(state, info, metrics.NewTaggedScope(scope), zap.New(obs))
+	}
+	warnings := func() map[string]int64 {
+		result := make(map[string]int64)
+		for _, counter := range scope.Snapshot().Counters() {
+			if counter.Name() == metrics.HistoryLimitWarningCounter {
+				tags := counter.Tags()
+				assert.Equal(t, "wf-type", tags[tagWorkflowType])
+				result[tags[tagHistoryLimitType]+"/"+tags[tagHistoryLimitThreshold]] = counter.Value()
+			}
+		}
+		return result
+	}
+
+	check(10, 100)
+	assert.Empty(t, warnings())
+	assert.Zero(t, logs.Len())
+
+	check(50, 100)
+	assert.Equal(t, map[string]int64{"count/0.5": 1}, warnings())
+
+	// thresholds are reported once, and skipped thresholds only report the highest one
+	check(60, 900)
+	check(70, 900)
+	assert.Equal(t, map[string]int64{"count/0.5": 1, "size/0.8": 1}, warnings())
+
+	entries := logs.FilterMessage("Workflow history is approaching the server limit.").All()
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, "wf-id", findLogField(entry, tagWorkflowID).String)
+		assert.Equal(t, "run-id", findLogField(entry, tagRunID).String)
+	}
+}
+
+func TestHistoryGuardrailsDefaults(t *testing.T) {
+	guardrails := newHistoryGuardrails(WorkerOptions{})
+	assert.Equal(t, int64(defaultHistoryCountLimit), guardrails.countLimit)
+	assert.Equal(t, int64(defaultHistorySizeLimit), guardrails.sizeLimit)
+	assert.Equal(t, defaultHistoryLimitWarningThresholds, guardrails.thresholds)
+
+	disabled := newHistoryGuardrails(WorkerOptions{DisableHistoryLimitWarnings: true})
+	assert.Nil(t, disabled)
+	assert.NotPanics(t, func() {
+		disabled.check(&historyGuardrailsState{}, &WorkflowInfo{}, nil, nil)
+	})
+
+	assert.Error(t, WorkerOptions{HistoryLimitWarningThresholds: []float64{1.5}}.Validate())
+	assert.Error(t, WorkerOptions{HistoryCountLimit: -1}.Validate())
+	assert.NoError(t, WorkerOptions{HistoryLimitWarningThresholds: []float64{0.5, 1}}.Validate())
+}
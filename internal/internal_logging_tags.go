@@ -38,11 +38,26 @@ const (
 	tagLocalActivityType           = "LocalActivityType"
 	tagLocalActivityID             = "LocalActivityID"
 	tagQueryType                   = "QueryType"
+	tagDecisionType                = "DecisionType"
+	tagHistoryLimitType            = "HistoryLimitType"
+	tagHistoryLimitThreshold       = "HistoryLimitThreshold"
 	tagVisibilityQuery             = "VisibilityQuery"
+	tagReplayFailureType           = "ReplayFailureType"
+	tagAuditOperation              = "AuditOperation"
+	tagAuditName                   = "AuditName"
+	tagAuditArgsDigest             = "AuditArgsDigest"
+	tagAuditCaller                 = "AuditCaller"
+	tagAuditTime                   = "AuditTime"
 	tagPanicError                  = "PanicError"
 	tagPanicStack                  = "PanicStack"
+	tagPanicCause                  = "PanicCause"
+	tagFailedDecision              = "FailedDecision"
+	tagFailedCallSite              = "FailedCallSite"
+	tagRateLimitKey                = "RateLimitKey"
 	causeTag                       = "pollerrorcause"
 	tagWorkflowRuntimeLength       = "workflowruntimelength"
+	tagEvictionCause               = "evictioncause"
+	tagReplayCause                 = "replaycause"
 	tagNonDeterminismDetectionType = "NonDeterminismDetectionType"
 )
 
@@ -52,3 +67,20 @@ const (
 	nonDeterminismDetectionTypeIllegalStatePanic nonDeterminismDetectionType = "illegalstatepanic"
 	nonDeterminismDetectionTypeReplayComparison  nonDeterminismDetectionType = "replaycomparison"
 )
+
+// the causes of evictions from the workflow cache, see WorkerOptions.LogWorkflowCacheEvictions
+const (
+	evictionCauseCachePressure  = "cache_pressure"  // the least recently used workflow made room in a full cache
+	evictionCauseTaskFailure    = "task_failure"    // a decision or query task of the workflow failed
+	evictionCauseCompleted      = "completed"       // the workflow completed
+	evictionCauseStickyDisabled = "sticky_disabled" // sticky execution is disabled, the state is not kept
+)
+
+// the causes of full history replays
+const (
+	replayCauseCacheMiss  = "cache_miss"  // a sticky task of a workflow which is no longer cached
+	replayCauseNonSticky  = "non_sticky"  // a task on the normal task list, e.g. after the sticky task list timed out
+	replayCauseQuery      = "query"       // a query task with the full history, which does not use the cache
+	replayCauseStaleCache = "stale_cache" // the cached state missed events of the task
+	replayCauseDestroyed  = "destroyed"   // the cached state was destroyed by a concurrent failed task
+)
@@ -0,0 +1,241 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pborman/uuid"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/backoff"
+)
+
+// scheduleClient implements ScheduleClient on top of the workflow client.
+type scheduleClient struct {
+	workflowClient *workflowClient
+}
+
+func (sc *scheduleClient) CreateSchedule(
+	ctx context.Context,
+	options ScheduleOptions,
+	workflow interface{},
+	args ...interface{},
+) (*WorkflowExecution, error) {
+	if options.ID == "" {
+		return nil, errors.New("schedule ID is required")
+	}
+	if options.CronSchedule == "" {
+		return nil, errors.New("schedule CronSchedule is required")
+	}
+	workflowOptions := options.WorkflowOptions
+	workflowOptions.ID = options.ID
+	workflowOptions.CronSchedule = options.CronSchedule
+	memo := make(map[string]interface{}, len(workflowOptions.Memo)+1)
+	for k, v := range workflowOptions.Memo {
+		memo[k] = v
+	}
+	memo[ScheduleMemoKey] = scheduleMemo{CronSchedule: options.CronSchedule, Description: options.Description}
+	workflowOptions.Memo = memo
+	return sc.workflowClient.StartWorkflow(ctx, workflowOptions, workflow, args...)
+}
+
+func (sc *scheduleClient) ListSchedules(ctx context.Context) ([]*Schedule, error) {
+	timeFilter := &shared.StartTimeFilter{
+		EarliestTime: common.Int64Ptr(0),
+		LatestTime:   common.Int64Ptr(time.Now().UnixNano()),
+	}
+
+	var schedules []*Schedule
+	running := make(map[string]bool)
+	var nextPageToken []byte
+	for {
+		response, err := sc.workflowClient.ListOpenWorkflow(ctx, &shared.ListOpenWorkflowExecutionsRequest{
+			StartTimeFilter: timeFilter,
+			NextPageToken:   nextPageToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range response.GetExecutions() {
+			if schedule, ok := sc.toSchedule(info); ok {
+				schedules = append(schedules, schedule)
+				running[schedule.ID] = true
+			}
+		}
+		if nextPageToken = response.GetNextPageToken(); len(nextPageToken) == 0 {
+			break
+		}
+	}
+
+	// a schedule may have been paused and resumed several times, so only its latest run tells if it is paused.
+	checked := make(map[string]bool)
+	for {
+		response, err := sc.workflowClient.ListClosedWorkflow(ctx, &shared.ListClosedWorkflowExecutionsRequest{
+			StartTimeFilter: timeFilter,
+			StatusFilter:    shared.WorkflowExecutionCloseStatusTerminated.Ptr(),
+			NextPageToken:   nextPageToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range response.GetExecutions() {
+			workflowID := info.GetExecution().GetWorkflowId()
+			if running[workflowID] || checked[workflowID] {
+				continue
+			}
+			if _, ok := sc.toSchedule(info); !ok {
+				continue
+			}
+			checked[workflowID] = true
+			schedule, err := sc.getPausedSchedule(ctx, workflowID)
+			if err == ErrScheduleNotPaused || err == ErrScheduleNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			schedules = append(schedules, schedule)
+		}
+		if nextPageToken = response.GetNextPageToken(); len(nextPageToken) == 0 {
+			break
+		}
+	}
+	return schedules, nil
+}
+
+func (sc *scheduleClient) PauseSchedule(ctx context.Context, scheduleID string, note string) error {
+	response, err := sc.workflowClient.DescribeWorkflowExecution(ctx, scheduleID, "")
+	if err != nil {
+		return err
+	}
+	info := response.GetWorkflowExecutionInfo()
+	if _, ok := sc.toSchedule(info); !ok {
+		return ErrScheduleNotFound
+	}
+	if info.CloseStatus != nil {
+		return &shared.EntityNotExistsError{Message: fmt.Sprintf("schedule %v is not running", scheduleID)}
+	}
+	return sc.workflowClient.TerminateWorkflow(ctx, scheduleID, info.GetExecution().GetRunId(), SchedulePausedReason, []byte(note))
+}
+
+func (sc *scheduleClient) ResumeSchedule(ctx context.Context, scheduleID string) error {
+	schedule, err := sc.getPausedSchedule(ctx, scheduleID)
+	if err != nil {
+		return err
+	}
+	iter := sc.workflowClient.GetWorkflowHistory(ctx, scheduleID, schedule.Execution.RunID, false, shared.HistoryEventFilterTypeAllEvent)
+	if !iter.HasNext() {
+		return fmt.Errorf("schedule %v has no history", scheduleID)
+	}
+	event, err := iter.Next()
+	if err != nil {
+		return err
+	}
+	attributes := event.GetWorkflowExecutionStartedEventAttributes()
+	if attributes == nil {
+		return fmt.Errorf("schedule %v history starts with %v", scheduleID, event.GetEventType())
+	}
+
+	wc := sc.workflowClient
+	request := &shared.StartWorkflowExecutionRequest{
+		Domain:                              common.StringPtr(wc.domain),
+		RequestId:                           common.StringPtr(uuid.New()),
+		WorkflowId:                          common.StringPtr(scheduleID),
+		WorkflowType:                        attributes.WorkflowType,
+		TaskList:                            attributes.TaskList,
+		Input:                               attributes.Input,
+		ExecutionStartToCloseTimeoutSeconds: attributes.ExecutionStartToCloseTimeoutSeconds,
+		TaskStartToCloseTimeoutSeconds:      attributes.TaskStartToCloseTimeoutSeconds,
+		Identity:                            common.StringPtr(wc.identity),
+		WorkflowIdReusePolicy:               shared.WorkflowIdReusePolicyAllowDuplicate.Ptr(),
+		RetryPolicy:                         attributes.RetryPolicy,
+		CronSchedule:                        attributes.CronSchedule,
+		Memo:                                attributes.Memo,
+		SearchAttributes:                    attributes.SearchAttributes,
+		Header:                              attributes.Header,
+	}
+	return backoff.RetryWithClock(ctx, wc.clock,
+		func() error {
+			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
+			defer cancel()
+			_, err := wc.workflowService.StartWorkflowExecution(tchCtx, request, opt...)
+			return err
+		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+}
+
+// getPausedSchedule returns the schedule if the latest run of its cron workflow was terminated by PauseSchedule.
+func (sc *scheduleClient) getPausedSchedule(ctx context.Context, scheduleID string) (*Schedule, error) {
+	response, err := sc.workflowClient.DescribeWorkflowExecution(ctx, scheduleID, "")
+	if err != nil {
+		return nil, err
+	}
+	info := response.GetWorkflowExecutionInfo()
+	schedule, ok := sc.toSchedule(info)
+	if !ok {
+		return nil, ErrScheduleNotFound
+	}
+	if info.GetCloseStatus() != shared.WorkflowExecutionCloseStatusTerminated {
+		return nil, ErrScheduleNotPaused
+	}
+
+	iter := sc.workflowClient.GetWorkflowHistory(ctx, scheduleID, schedule.Execution.RunID, false, shared.HistoryEventFilterTypeCloseEvent)
+	if !iter.HasNext() {
+		return nil, ErrScheduleNotPaused
+	}
+	event, err := iter.Next()
+	if err != nil {
+		return nil, err
+	}
+	attributes := event.GetWorkflowExecutionTerminatedEventAttributes()
+	if attributes.GetReason() != SchedulePausedReason {
+		return nil, ErrScheduleNotPaused
+	}
+	schedule.Paused = true
+	schedule.PauseNote = string(attributes.Details)
+	return schedule, nil
+}
+
+// toSchedule returns the schedule of a workflow started by CreateSchedule, and false for other workflows.
+func (sc *scheduleClient) toSchedule(info *shared.WorkflowExecutionInfo) (*Schedule, bool) {
+	data, ok := info.GetMemo().GetFields()[ScheduleMemoKey]
+	if !ok {
+		return nil, false
+	}
+	var memo scheduleMemo
+	if err := decodeArg(sc.workflowClient.dataConverter, data, &memo); err != nil {
+		return nil, false
+	}
+	return &Schedule{
+		ID:           info.GetExecution().GetWorkflowId(),
+		CronSchedule: memo.CronSchedule,
+		Description:  memo.Description,
+		WorkflowType: info.GetType().GetName(),
+		Execution: WorkflowExecution{
+			ID:    info.GetExecution().GetWorkflowId(),
+			RunID: info.GetExecution().GetRunId(),
+		},
+	}, true
+}
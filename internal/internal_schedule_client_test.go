@@ -0,0 +1,199 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+const scheduleID = "nightly-report"
+
+func newTestScheduleClient(t *testing.T) (ScheduleClient, *workflowservicetest.MockClient) {
+	service := workflowservicetest.NewMockClient(gomock.NewController(t))
+	return NewScheduleClient(service, domain, &ClientOptions{Identity: identity}), service
+}
+
+func newTestScheduleExecutionInfo(t *testing.T, runID string, closeStatus *shared.WorkflowExecutionCloseStatus) *shared.WorkflowExecutionInfo {
+	memo, err := encodeArg(getDefaultDataConverter(), scheduleMemo{CronSchedule: "0 2 * * *", Description: "report"})
+	require.NoError(t, err)
+	return &shared.WorkflowExecutionInfo{
+		Execution:   &shared.WorkflowExecution{WorkflowId: common.StringPtr(scheduleID), RunId: common.StringPtr(runID)},
+		Type:        &shared.WorkflowType{Name: common.StringPtr(workflowType)},
+		CloseStatus: closeStatus,
+		Memo:        &shared.Memo{Fields: map[string][]byte{ScheduleMemoKey: memo}},
+	}
+}
+
+func expectScheduleCloseEvent(service *workflowservicetest.MockClient, reason string) {
+	service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).
+		DoAndReturn(func(_ context.Context, req *shared.GetWorkflowExecutionHistoryRequest, _ ...interface{}) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+			if req.GetHistoryEventFilterType() != shared.HistoryEventFilterTypeCloseEvent {
+				return nil, &shared.BadRequestError{Message: "expected close event filter"}
+			}
+			return &shared.GetWorkflowExecutionHistoryResponse{History: &shared.History{Events: []*shared.HistoryEvent{{
+				EventType: shared.EventTypeWorkflowExecutionTerminated.Ptr(),
+				WorkflowExecutionTerminatedEventAttributes: &shared.WorkflowExecutionTerminatedEventAttributes{
+					Reason:  common.StringPtr(reason),
+					Details: []byte("maintenance"),
+				},
+			}}}}, nil
+		})
+}
+
+func TestScheduleClient_CreateSchedule(t *testing.T) {
+	client, service := newTestScheduleClient(t)
+	service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil).
+		Do(func(_ context.Context, req *shared.StartWorkflowExecutionRequest, _ ...interface{}) {
+			assert.Equal(t, scheduleID, req.GetWorkflowId())
+			assert.Equal(t, "0 2 * * *", req.GetCronSchedule())
+			assert.Contains(t, req.GetMemo().GetFields(), "owner")
+			var memo scheduleMemo
+			require.NoError(t, decodeArg(getDefaultDataConverter(), req.GetMemo().GetFields()[ScheduleMemoKey], &memo))
+			assert.Equal(t, scheduleMemo{CronSchedule: "0 2 * * *", Description: "report"}, memo)
+		})
+
+	execution, err := client.CreateSchedule(context.Background(), ScheduleOptions{
+		ID:           scheduleID,
+		CronSchedule: "0 2 * * *",
+		Description:  "report",
+		WorkflowOptions: StartWorkflowOptions{
+			TaskList:                     tasklist,
+			ExecutionStartToCloseTimeout: timeoutInSeconds,
+			Memo:                         map[string]interface{}{"owner": "reports"},
+		},
+	}, workflowType)
+	require.NoError(t, err)
+	assert.Equal(t, &WorkflowExecution{ID: scheduleID, RunID: runID}, execution)
+
+	_, err = client.CreateSchedule(context.Background(), ScheduleOptions{ID: scheduleID}, workflowType)
+	assert.Error(t, err)
+}
+
+func TestScheduleClient_PauseSchedule(t *testing.T) {
+	client, service := newTestScheduleClient(t)
+	service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.DescribeWorkflowExecutionResponse{WorkflowExecutionInfo: newTestScheduleExecutionInfo(t, runID, nil)}, nil)
+	service.EXPECT().TerminateWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).Return(nil).
+		Do(func(_ context.Context, req *shared.TerminateWorkflowExecutionRequest, _ ...interface{}) {
+			assert.Equal(t, scheduleID, req.GetWorkflowExecution().GetWorkflowId())
+			assert.Equal(t, runID, req.GetWorkflowExecution().GetRunId())
+			assert.Equal(t, SchedulePausedReason, req.GetReason())
+			assert.Equal(t, "maintenance", string(req.Details))
+		})
+	require.NoError(t, client.PauseSchedule(context.Background(), scheduleID, "maintenance"))
+
+	service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.DescribeWorkflowExecutionResponse{WorkflowExecutionInfo: &shared.WorkflowExecutionInfo{}}, nil)
+	assert.Equal(t, ErrScheduleNotFound, client.PauseSchedule(context.Background(), workflowID, ""))
+}
+
+func TestScheduleClient_ResumeSchedule(t *testing.T) {
+	client, service := newTestScheduleClient(t)
+	info := newTestScheduleExecutionInfo(t, runID, shared.WorkflowExecutionCloseStatusTerminated.Ptr())
+	service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.DescribeWorkflowExecutionResponse{WorkflowExecutionInfo: info}, nil)
+	expectScheduleCloseEvent(service, SchedulePausedReason)
+	started := &shared.WorkflowExecutionStartedEventAttributes{
+		WorkflowType:                        &shared.WorkflowType{Name: common.StringPtr(workflowType)},
+		TaskList:                            &shared.TaskList{Name: common.StringPtr(tasklist)},
+		Input:                               []byte("input"),
+		ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(60),
+		TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(10),
+		CronSchedule:                        common.StringPtr("0 2 * * *"),
+		Memo:                                info.Memo,
+	}
+	service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.GetWorkflowExecutionHistoryResponse{History: &shared.History{Events: []*shared.HistoryEvent{{
+			EventType:                               shared.EventTypeWorkflowExecutionStarted.Ptr(),
+			WorkflowExecutionStartedEventAttributes: started,
+		}}}}, nil)
+	service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.StartWorkflowExecutionResponse{RunId: common.StringPtr("new-run")}, nil).
+		Do(func(_ context.Context, req *shared.StartWorkflowExecutionRequest, _ ...interface{}) {
+			assert.Equal(t, scheduleID, req.GetWorkflowId())
+			assert.Equal(t, started.WorkflowType, req.WorkflowType)
+			assert.Equal(t, started.TaskList, req.TaskList)
+			assert.Equal(t, started.Input, req.Input)
+			assert.Equal(t, started.CronSchedule, req.CronSchedule)
+			assert.Equal(t, started.Memo, req.Memo)
+			assert.Equal(t, shared.WorkflowIdReusePolicyAllowDuplicate, req.GetWorkflowIdReusePolicy())
+		})
+	require.NoError(t, client.ResumeSchedule(context.Background(), scheduleID))
+
+	// a schedule terminated for another reason was deleted rather than paused
+	service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.DescribeWorkflowExecutionResponse{WorkflowExecutionInfo: info}, nil)
+	expectScheduleCloseEvent(service, "deleted")
+	assert.Equal(t, ErrScheduleNotPaused, client.ResumeSchedule(context.Background(), scheduleID))
+}
+
+func TestScheduleClient_ListSchedules(t *testing.T) {
+	client, service := newTestScheduleClient(t)
+	running := newTestScheduleExecutionInfo(t, runID, nil)
+	running.Execution.WorkflowId = common.StringPtr("hourly-sync")
+	service.EXPECT().ListOpenWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.ListOpenWorkflowExecutionsResponse{
+			Executions: []*shared.WorkflowExecutionInfo{running, {Execution: &shared.WorkflowExecution{WorkflowId: common.StringPtr(workflowID)}}},
+		}, nil)
+	paused := newTestScheduleExecutionInfo(t, "paused-run", shared.WorkflowExecutionCloseStatusTerminated.Ptr())
+	olderRun := newTestScheduleExecutionInfo(t, "older-run", shared.WorkflowExecutionCloseStatusTerminated.Ptr())
+	service.EXPECT().ListClosedWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.ListClosedWorkflowExecutionsResponse{
+			Executions: []*shared.WorkflowExecutionInfo{paused, olderRun},
+		}, nil).
+		Do(func(_ context.Context, req *shared.ListClosedWorkflowExecutionsRequest, _ ...interface{}) {
+			assert.Equal(t, shared.WorkflowExecutionCloseStatusTerminated, req.GetStatusFilter())
+		})
+	service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.DescribeWorkflowExecutionResponse{WorkflowExecutionInfo: paused}, nil)
+	expectScheduleCloseEvent(service, SchedulePausedReason)
+
+	schedules, err := client.ListSchedules(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*Schedule{
+		{
+			ID:           "hourly-sync",
+			CronSchedule: "0 2 * * *",
+			Description:  "report",
+			WorkflowType: workflowType,
+			Execution:    WorkflowExecution{ID: "hourly-sync", RunID: runID},
+		},
+		{
+			ID:           scheduleID,
+			CronSchedule: "0 2 * * *",
+			Description:  "report",
+			WorkflowType: workflowType,
+			Paused:       true,
+			PauseNote:    "maintenance",
+			Execution:    WorkflowExecution{ID: scheduleID, RunID: "paused-run"},
+		},
+	}, schedules)
+}
@@ -0,0 +1,336 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/zap"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestDialService_Validation(t *testing.T) {
+	_, err := DialService("", nil)
+	assert.Error(t, err)
+
+	_, err = DialService("localhost:7833", &ClientOptions{Connection: ConnectionOptions{
+		Dialer: func(ctx context.Context, hostPort string) (net.Conn, error) {
+			return nil, errors.New("unused")
+		},
+		ProxyURL: &url.URL{Scheme: "http", Host: "proxy:3128"},
+	}})
+	assert.Error(t, err)
+
+	_, err = DialService("localhost:7833", &ClientOptions{Connection: ConnectionOptions{
+		ProxyURL: &url.URL{Scheme: "ftp", Host: "proxy:21"},
+	}})
+	assert.Error(t, err)
+}
+
+func TestDialService_PeerChooserAndDialer(t *testing.T) {
+	var mutex sync.Mutex
+	var dialed []string
+	service, err := DialService("", &ClientOptions{Connection: ConnectionOptions{
+		PeerChooser: func(ctx context.Context, procedure string) (string, error) {
+			return "gateway.test:7833", nil
+		},
+		Dialer: func(ctx context.Context, hostPort string) (net.Conn, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			dialed = append(dialed, hostPort)
+			return nil, errors.New("gateway unavailable")
+		},
+	}})
+	require.NoError(t, err)
+	defer service.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = service.DescribeDomain(ctx, &shared.DescribeDomainRequest{Name: common.StringPtr("test-domain")})
+	assert.Error(t, err)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.NotEmpty(t, dialed)
+	assert.Equal(t, "gateway.test:7833", dialed[0])
+}
+
+func TestDialService_Tuning(t *testing.T) {
+	service, err := DialService("localhost:7833", &ClientOptions{Connection: ConnectionOptions{
+		KeepAliveTime:      time.Minute,
+		ConnectionPoolSize: 2,
+		CallTimeout:        time.Second,
+		LongPollTimeout:    time.Minute,
+		DNSRefreshInterval: time.Minute,
+	}})
+	require.NoError(t, err)
+	assert.NoError(t, service.Close())
+}
+
+func TestHTTPProxyDialer(t *testing.T) {
+	// the target echoes what it receives
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	proxyListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer proxyListener.Close()
+	go func() {
+		for {
+			conn, err := proxyListener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestHTTPProxy(conn)
+		}
+	}()
+
+	dial, err := newProxyDialer(&url.URL{Scheme: "http", Host: proxyListener.Addr().String(), User: url.UserPassword("user", "secret")})
+	require.NoError(t, err)
+	conn, err := dial(context.Background(), target.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	buffer := make([]byte, 4)
+	_, err = io.ReadFull(conn, buffer)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buffer))
+
+	dial, err = newProxyDialer(&url.URL{Scheme: "http", Host: proxyListener.Addr().String()})
+	require.NoError(t, err)
+	_, err = dial(context.Background(), target.Addr().String())
+	assert.ErrorContains(t, err, "407")
+}
+
+// serveTestHTTPProxy tunnels a CONNECT request authenticated as user:secret.
+func serveTestHTTPProxy(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	request, err := http.ReadRequest(reader)
+	if err != nil {
+		return
+	}
+	if request.Method != http.MethodConnect || request.Header.Get("Proxy-Authorization") != "Basic dXNlcjpzZWNyZXQ=" {
+		_, _ = conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n"))
+		return
+	}
+	upstream, err := net.Dial("tcp", request.Host)
+	if err != nil {
+		_, _ = conn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
+		return
+	}
+	defer upstream.Close()
+	_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+	go func() {
+		_, _ = io.Copy(upstream, reader)
+	}()
+	_, _ = io.Copy(conn, upstream)
+}
+
+type testPeerTransport struct {
+	retained map[string]int
+}
+
+func (t *testPeerTransport) RetainPeer(id peer.Identifier, _ peer.Subscriber) (peer.Peer, error) {
+	t.retained[id.Identifier()]++
+	return testPeer{id: id.Identifier()}, nil
+}
+
+func (t *testPeerTransport) ReleasePeer(id peer.Identifier, _ peer.Subscriber) error {
+	t.retained[id.Identifier()]--
+	return nil
+}
+
+type testPeer struct {
+	id string
+}
+
+func (p testPeer) Identifier() string  { return p.id }
+func (p testPeer) Status() peer.Status { return peer.Status{ConnectionStatus: peer.Available} }
+func (p testPeer) StartRequest()       {}
+func (p testPeer) EndRequest()         {}
+
+func TestFuncPeerChooser(t *testing.T) {
+	peerTransport := &testPeerTransport{retained: make(map[string]int)}
+	chooser := newFuncPeerChooser(peerTransport, func(ctx context.Context, procedure string) (string, error) {
+		if procedure == "fail" {
+			return "", errors.New("no gateway")
+		}
+		return "gateway-" + procedure + ":7833", nil
+	})
+	require.NoError(t, chooser.Start())
+
+	for _, procedure := range []string{"a", "b", "a"} {
+		p, onFinish, err := chooser.Choose(context.Background(), &transport.Request{Procedure: procedure})
+		require.NoError(t, err)
+		assert.Equal(t, "gateway-"+procedure+":7833", p.Identifier())
+		onFinish(nil)
+	}
+	_, _, err := chooser.Choose(context.Background(), &transport.Request{Procedure: "fail"})
+	assert.Error(t, err)
+	// peers are retained once and kept across requests
+	assert.Equal(t, map[string]int{"gateway-a:7833": 1, "gateway-b:7833": 1}, peerTransport.retained)
+
+	require.NoError(t, chooser.Stop())
+	assert.Equal(t, map[string]int{"gateway-a:7833": 0, "gateway-b:7833": 0}, peerTransport.retained)
+}
+
+func TestConnectionOptions_KeepAlive(t *testing.T) {
+	options, err := ConnectionOptions{}.dialOptions()
+	require.NoError(t, err)
+	assert.Empty(t, options)
+
+	options, err = ConnectionOptions{KeepAliveTime: time.Minute, TLSConfig: &tls.Config{}}.dialOptions()
+	require.NoError(t, err)
+	assert.Len(t, options, 2)
+}
+
+func TestPooledPeerChooser(t *testing.T) {
+	transports := []*testPeerTransport{
+		{retained: make(map[string]int)},
+		{retained: make(map[string]int)},
+	}
+	choosers := make([]peer.Chooser, len(transports))
+	for i, peerTransport := range transports {
+		choosers[i] = newFuncPeerChooser(peerTransport, func(ctx context.Context, procedure string) (string, error) {
+			return "frontend:7833", nil
+		})
+	}
+	chooser := &pooledPeerChooser{choosers: choosers}
+	require.NoError(t, chooser.Start())
+
+	for i := 0; i < 4; i++ {
+		_, _, err := chooser.Choose(context.Background(), &transport.Request{Procedure: "a"})
+		require.NoError(t, err)
+	}
+	// each connection of the pool retains its own peer
+	for _, peerTransport := range transports {
+		assert.Equal(t, map[string]int{"frontend:7833": 1}, peerTransport.retained)
+	}
+	require.NoError(t, chooser.Stop())
+}
+
+func TestResolvingPeerChooser(t *testing.T) {
+	peerTransport := &testPeerTransport{retained: make(map[string]int)}
+	addresses := []string{"10.0.0.1", "10.0.0.2"}
+	lookupHost := func(ctx context.Context, host string) ([]string, error) {
+		if addresses == nil {
+			return nil, errors.New("no such host")
+		}
+		return addresses, nil
+	}
+	chooser := newResolvingPeerChooser(peerTransport, "frontend", "7833", time.Hour, lookupHost, zap.NewNop())
+	require.NoError(t, chooser.Start())
+	assert.Equal(t, map[string]int{"10.0.0.1:7833": 1, "10.0.0.2:7833": 1}, peerTransport.retained)
+
+	chosen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		p, onFinish, err := chooser.Choose(context.Background(), &transport.Request{Procedure: "a"})
+		require.NoError(t, err)
+		chosen[p.Identifier()] = true
+		onFinish(nil)
+	}
+	assert.Equal(t, map[string]bool{"10.0.0.1:7833": true, "10.0.0.2:7833": true}, chosen)
+
+	addresses = []string{"10.0.0.2", "10.0.0.3"}
+	require.NoError(t, chooser.resolve())
+	assert.Equal(t, map[string]int{"10.0.0.1:7833": 0, "10.0.0.2:7833": 1, "10.0.0.3:7833": 1}, peerTransport.retained)
+
+	// the peers are kept when the host can't be resolved
+	addresses = nil
+	assert.Error(t, chooser.resolve())
+	assert.Equal(t, map[string]int{"10.0.0.1:7833": 0, "10.0.0.2:7833": 1, "10.0.0.3:7833": 1}, peerTransport.retained)
+
+	require.NoError(t, chooser.Stop())
+}
+
+type testUnaryOutbound struct {
+	transport.UnaryOutbound
+	timeout time.Duration
+}
+
+func (o *testUnaryOutbound) Call(ctx context.Context, request *transport.Request) (*transport.Response, error) {
+	deadline, _ := ctx.Deadline()
+	o.timeout = time.Until(deadline).Round(time.Second)
+	return &transport.Response{}, nil
+}
+
+func TestCallTimeoutMiddleware(t *testing.T) {
+	middleware := &callTimeoutMiddleware{
+		callTimeout:     2 * time.Second,
+		longPollTimeout: 60 * time.Second,
+		callTimeouts:    map[string]time.Duration{"StartWorkflowExecution": 5 * time.Second},
+	}
+	callWithHeaders := func(procedure string, headers transport.Headers, timeout time.Duration) time.Duration {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		out := &testUnaryOutbound{}
+		_, err := middleware.Call(ctx, &transport.Request{Procedure: procedure, Headers: headers}, out)
+		require.NoError(t, err)
+		return out.timeout
+	}
+	call := func(procedure string, timeout time.Duration) time.Duration {
+		return callWithHeaders(procedure, transport.Headers{}, timeout)
+	}
+
+	assert.Equal(t, 2*time.Second, call("uber.cadence.api.v1.WorkflowAPI::DescribeWorkflowExecution", 10*time.Second))
+	assert.Equal(t, time.Second, call("uber.cadence.api.v1.WorkflowAPI::DescribeWorkflowExecution", time.Second))
+	assert.Equal(t, 60*time.Second, call("uber.cadence.api.v1.WorkerAPI::PollForDecisionTask", 150*time.Second))
+	assert.Equal(t, 5*time.Second, call("uber.cadence.api.v1.WorkflowAPI::StartWorkflowExecution", 10*time.Second))
+	// only the history requests waiting for new events are long polls
+	assert.Equal(t, 2*time.Second, call("uber.cadence.api.v1.WorkflowAPI::GetWorkflowExecutionHistory", 10*time.Second))
+	longPoll := transport.NewHeaders().With(longPollHeaderName, "true")
+	assert.Equal(t, 60*time.Second,
+		callWithHeaders("uber.cadence.api.v1.WorkflowAPI::GetWorkflowExecutionHistory", longPoll, 90*time.Second))
+
+	middleware = &callTimeoutMiddleware{}
+	assert.Equal(t, 10*time.Second, call("uber.cadence.api.v1.WorkflowAPI::DescribeWorkflowExecution", 10*time.Second))
+}
@@ -29,10 +29,12 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/opentracing/opentracing-go"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
@@ -113,6 +115,13 @@ type (
 		currentDecisionTask *s.PollForDecisionTaskResponse
 		laTunnel            *localActivityTunnel
 		decisionStartTime   time.Time
+
+		historyGuardrailsState historyGuardrailsState
+
+		sampled bool // verbose diagnostics are emitted, see WorkerOptions.DiagnosticsSampling
+
+		replayCause   string // why the current task replays the full history, empty if it does not
+		evictionCause string // why the workflow is removed from the cache, empty for cache pressure
 	}
 
 	// workflowTaskHandlerImpl is the implementation of WorkflowTaskHandler
@@ -132,6 +141,17 @@ type (
 		tracer                         opentracing.Tracer
 		workflowInterceptorFactories   []WorkflowInterceptorFactory
 		disableStrictNonDeterminism    bool
+		slowQueryThreshold             time.Duration
+		historyGuardrails              *historyGuardrails
+		enableExecutionProfile         bool
+		payloadRedactor                PayloadRedactor
+		namingPolicies                 NamingPolicies
+		enableYieldDiagnostics         bool
+		yieldDiagnosticsThreshold      time.Duration
+		diagnosticsSampling            DiagnosticsSamplingOptions
+		historyEventTap                HistoryEventTap
+		logCacheEvictions              bool
+		workerStopChannel              <-chan struct{}
 	}
 
 	activityProvider func(name string) activity
@@ -146,6 +166,8 @@ type (
 		lastEventID    int64 // last expected eventID, zero indicates read until end of stream
 		next           []*s.HistoryEvent
 		binaryChecksum *string
+		tapEvents      bool              // collect the new events in tappedEvents, for WorkerOptions.HistoryEventTap
+		tappedEvents   []*s.HistoryEvent // events added after the previous decision task, in order
 	}
 
 	decisionHeartbeatError struct {
@@ -326,6 +348,9 @@ OrderEvents:
 		}
 
 		eh.nextEventID++
+		if eh.tapEvents && eventID > eh.workflowTask.task.GetPreviousStartedEventId() {
+			eh.tappedEvents = append(eh.tappedEvents, event)
+		}
 
 		switch event.GetEventType() {
 		case s.EventTypeDecisionTaskStarted:
@@ -387,6 +412,17 @@ func newWorkflowTaskHandler(
 		tracer:                         params.Tracer,
 		workflowInterceptorFactories:   params.WorkflowInterceptorChainFactories,
 		disableStrictNonDeterminism:    params.WorkerBugPorts.DisableStrictNonDeterminismCheck,
+		slowQueryThreshold:             params.SlowQueryThreshold,
+		historyGuardrails:              newHistoryGuardrails(params.WorkerOptions),
+		enableExecutionProfile:         params.EnableExecutionProfile,
+		payloadRedactor:                params.PayloadRedactor,
+		namingPolicies:                 params.NamingPolicies,
+		enableYieldDiagnostics:         params.EnableYieldDiagnostics,
+		yieldDiagnosticsThreshold:      params.YieldDiagnosticsThreshold,
+		diagnosticsSampling:            params.DiagnosticsSampling,
+		historyEventTap:                params.HistoryEventTap,
+		logCacheEvictions:              params.LogWorkflowCacheEvictions,
+		workerStopChannel:              params.WorkerStopChannel,
 	}
 
 	traceLog(func() {
@@ -465,6 +501,9 @@ func newWorkflowExecutionContext(
 		workflowInfo:      workflowInfo,
 		wth:               taskHandler,
 	}
+	if taskHandler.diagnosticsSampling.enabled() {
+		workflowContext.sampled = taskHandler.diagnosticsSampling.sampled(workflowInfo)
+	}
 	workflowContext.createEventHandler()
 	return workflowContext
 }
@@ -481,6 +520,14 @@ func (w *workflowExecutionContextImpl) Unlock(err error) {
 		// error to indicate the close failure case. This should be rare case. For now, always remove the cache, and
 		// if the close decision failed, the next decision will have to rebuild the state.
 		if cached {
+			switch {
+			case err != nil || w.err != nil:
+				w.evictionCause = evictionCauseTaskFailure
+			case w.isWorkflowCompleted:
+				w.evictionCause = evictionCauseCompleted
+			default:
+				w.evictionCause = evictionCauseStickyDisabled
+			}
 			// also clears state asynchronously via cache eviction
 			removeWorkflowContext(w.workflowInfo.WorkflowExecution.RunID)
 		} else {
@@ -509,6 +556,13 @@ func (w *workflowExecutionContextImpl) getEventHandler() *workflowExecutionEvent
 	return eventHandlerImpl
 }
 
+func (w *workflowExecutionContextImpl) getYieldRecorder() *yieldRecorder {
+	if eventHandler := w.getEventHandler(); eventHandler != nil {
+		return eventHandler.yields
+	}
+	return nil
+}
+
 func (w *workflowExecutionContextImpl) completeWorkflow(result []byte, err error) {
 	w.isWorkflowCompleted = true
 	w.result = result
@@ -536,6 +590,7 @@ func (w *workflowExecutionContextImpl) onEviction() {
 		w.queueResetStickinessTask()
 	}
 
+	w.recordEviction()
 	w.clearState()
 	w.mutex.Unlock()
 }
@@ -590,6 +645,10 @@ func (w *workflowExecutionContextImpl) createEventHandler() {
 		w.wth.contextPropagators,
 		w.wth.tracer,
 		w.wth.workflowInterceptorFactories,
+		w.wth.enableExecutionProfile,
+		w.wth.payloadRedactor,
+		w.wth.namingPolicies,
+		w.wth.enableYieldDiagnostics || w.sampled,
 	)
 	w.eventHandler.Store(eventHandler)
 }
@@ -674,6 +733,7 @@ func (wth *workflowTaskHandlerImpl) getOrCreateWorkflowContext(
 		workflowContext = getWorkflowContext(runID)
 	}
 
+	replayCause := ""
 	if workflowContext != nil {
 		workflowContext.Lock()
 		// add new tag on metrics scope with workflow runtime length category
@@ -687,6 +747,10 @@ func (wth *workflowTaskHandlerImpl) getOrCreateWorkflowContext(
 		} else {
 			// non query task and cached state is missing events, we need to discard the cached state and rebuild one.
 			workflowContext.ResetIfStale(task, historyIterator)
+			replayCause = replayCauseStaleCache
+		}
+		if workflowContext.IsDestroyed() {
+			replayCause = replayCauseDestroyed
 		}
 	} else {
 		if !isFullHistory {
@@ -696,6 +760,11 @@ func (wth *workflowTaskHandlerImpl) getOrCreateWorkflowContext(
 			if history, err = resetHistory(task, historyIterator); err != nil {
 				return
 			}
+			replayCause = replayCauseCacheMiss
+		} else if task.Query != nil {
+			replayCause = replayCauseQuery
+		} else if task.GetPreviousStartedEventId() > 0 {
+			replayCause = replayCauseNonSticky
 		}
 
 		if workflowContext, err = wth.createWorkflowContext(task); err != nil {
@@ -708,6 +777,7 @@ func (wth *workflowTaskHandlerImpl) getOrCreateWorkflowContext(
 		workflowContext.Lock()
 	}
 
+	workflowContext.replayCause = replayCause
 	err = workflowContext.resetStateIfDestroyed(task, historyIterator)
 	if err != nil {
 		workflowContext.Unlock(err)
@@ -761,6 +831,11 @@ func (wth *workflowTaskHandlerImpl) ProcessWorkflowTask(
 		return nil, errors.New("invalid query decision task")
 	}
 
+	span := createOpenTracingDecisionTaskSpan(wth.tracer, task)
+	defer func() {
+		finishOpenTracingSpan(span, errRet)
+	}()
+
 	runID := task.WorkflowExecution.GetRunId()
 	workflowID := task.WorkflowExecution.GetWorkflowId()
 	traceLog(func() {
@@ -780,6 +855,32 @@ func (wth *workflowTaskHandlerImpl) ProcessWorkflowTask(
 		workflowContext.Unlock(errRet)
 	}()
 
+	if replayCause := workflowContext.replayCause; replayCause != "" {
+		taskStartTime := time.Now()
+		defer func() {
+			wth.recordFullReplay(task, replayCause, time.Since(taskStartTime))
+		}()
+	}
+
+	if task.Query == nil {
+		if yields := workflowContext.getYieldRecorder(); yields != nil {
+			yields.reset()
+			taskStartTime := time.Now()
+			defer func() {
+				wth.logSlowDecisionTask(task, yields, time.Since(taskStartTime))
+			}()
+		}
+		if workflowContext.sampled {
+			taskStartTime := time.Now()
+			yields := workflowContext.getYieldRecorder()
+			defer func() {
+				if errRet == nil {
+					wth.logSampledDecisionTask(task, completeRequest, yields, time.Since(taskStartTime))
+				}
+			}()
+		}
+	}
+
 	var response interface{}
 process_Workflow_Loop:
 	for {
@@ -805,6 +906,14 @@ process_Workflow_Loop:
 					}
 					continue process_Workflow_Loop
 
+				case <-wth.workerStopChannel:
+					// the worker is stopping, process the local activity results which are already available
+					response, err = wth.drainLocalActivityResults(workflowContext, workflowTask)
+					if err != nil || response != nil {
+						break process_Workflow_Loop
+					}
+					return wth.checkpointLocalActivities(workflowContext, workflowTask), nil
+
 				case lar := <-workflowTask.laResultCh:
 					// local activity result ready
 					response, err = workflowContext.ProcessLocalActivityResult(workflowTask, lar)
@@ -822,6 +931,47 @@ process_Workflow_Loop:
 	return response, err
 }
 
+// drainLocalActivityResults processes the local activity results which are ready to be received without blocking.
+func (wth *workflowTaskHandlerImpl) drainLocalActivityResults(
+	workflowContext *workflowExecutionContextImpl,
+	workflowTask *workflowTask,
+) (interface{}, error) {
+	for {
+		select {
+		case lar := <-workflowTask.laResultCh:
+			response, err := workflowContext.ProcessLocalActivityResult(workflowTask, lar)
+			if err != nil || response != nil {
+				return response, err
+			}
+		default:
+			return nil, nil
+		}
+	}
+}
+
+// checkpointLocalActivities completes the decision task waiting for local activities when the worker stops, with the
+// markers of the local activities which completed so far. The next decision task is created right away, and is not
+// returned to this worker, so the local activities which completed are not executed again, and the ones which did not
+// are executed by the worker processing it.
+func (wth *workflowTaskHandlerImpl) checkpointLocalActivities(
+	workflowContext *workflowExecutionContextImpl,
+	workflowTask *workflowTask,
+) interface{} {
+	completeRequest := workflowContext.CompleteDecisionTask(workflowTask, false)
+	if request, ok := completeRequest.(*s.RespondDecisionTaskCompletedRequest); ok {
+		request.ForceCreateNewDecisionTask = common.BoolPtr(true)
+		request.ReturnNewDecisionTask = common.BoolPtr(false)
+		wth.metricsScope.GetTaggedScope(tagWorkflowType, workflowContext.workflowInfo.WorkflowType.Name).
+			Counter(metrics.DecisionTaskShutdownCheckpoint).Inc(1)
+		wth.logger.Info("Worker is stopping, completing the decision task with the completed local activities.",
+			zap.String(tagWorkflowType, workflowContext.workflowInfo.WorkflowType.Name),
+			zap.String(tagWorkflowID, workflowContext.workflowInfo.WorkflowExecution.ID),
+			zap.String(tagRunID, workflowContext.workflowInfo.WorkflowExecution.RunID),
+			zap.Int("Decisions", len(request.Decisions)))
+	}
+	return completeRequest
+}
+
 func (w *workflowExecutionContextImpl) ProcessWorkflowTask(workflowTask *workflowTask) (interface{}, error) {
 	task := workflowTask.task
 	historyIterator := workflowTask.historyIterator
@@ -830,12 +980,14 @@ func (w *workflowExecutionContextImpl) ProcessWorkflowTask(workflowTask *workflo
 	if err := w.ResetIfStale(task, historyIterator); err != nil {
 		return nil, err
 	}
+	w.wth.historyGuardrails.check(&w.historyGuardrailsState, w.workflowInfo, w.wth.metricsScope, w.wth.logger)
 	w.SetCurrentTask(task)
 
 	eventHandler := w.getEventHandler()
 	reorderedHistory := newHistory(workflowTask, eventHandler)
 	var replayDecisions []*s.Decision
 	var respondEvents []*s.HistoryEvent
+	reorderedHistory.tapEvents = w.wth.historyEventTap != nil && task.Query == nil
 
 	skipReplayCheck := w.skipReplayCheck()
 	isReplayTest := task.GetPreviousStartedEventId() == replayPreviousStartedEventID
@@ -927,6 +1079,10 @@ ProcessEvents:
 		}
 	}
 
+	if len(reorderedHistory.tappedEvents) > 0 {
+		w.wth.tapHistoryEvents(w.workflowInfo, reorderedHistory.tappedEvents)
+	}
+
 	// Non-deterministic error could happen in 2 different places:
 	//   1) the replay decisions does not match to history events. This is usually due to non backwards compatible code
 	// change to decider logic. For example, change calling one activity to a different activity.
@@ -1157,6 +1313,9 @@ func (w *workflowExecutionContextImpl) SetCurrentTask(task *s.PollForDecisionTas
 		w.previousStartedEventID = task.GetStartedEventId()
 	}
 	w.decisionStartTime = time.Now()
+	if eventHandler := w.getEventHandler(); eventHandler != nil {
+		eventHandler.decisionDeadline = w.decisionStartTime.Add(w.GetDecisionTimeout())
+	}
 }
 
 func (w *workflowExecutionContextImpl) ResetIfStale(task *s.PollForDecisionTaskResponse, historyIterator HistoryIterator) error {
@@ -1216,12 +1375,12 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 			wth.logger.Warn("Encountered PanicError in workflow query task",
 				zap.String(tagWorkflowID, task.WorkflowExecution.GetWorkflowId()),
 				zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
-				zap.String(tagPanicError, panicErr.Error()),
+				zap.String(tagPanicError, redactPayload(wth.payloadRedactor, panicErr.Error())),
 				zap.String(tagPanicStack, panicErr.StackTrace()),
 			)
 
 			queryCompletedRequest.CompletedType = common.QueryTaskCompletedTypePtr(s.QueryTaskCompletedTypeFailed)
-			queryCompletedRequest.ErrorMessage = common.StringPtr("Workflow panic: " + panicErr.Error())
+			queryCompletedRequest.ErrorMessage = common.StringPtr("Workflow panic: " + redactPayload(wth.payloadRedactor, panicErr.Error()))
 			return queryCompletedRequest
 		}
 
@@ -1231,13 +1390,13 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 			wth.logger.Warn("Ignored workflow panic error for query, query result may be partial",
 				zap.String(tagWorkflowID, task.WorkflowExecution.GetWorkflowId()),
 				zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
-				zap.String(tagPanicError, workflowPanicErr.Error()),
+				zap.String(tagPanicError, redactPayload(wth.payloadRedactor, workflowPanicErr.Error())),
 				zap.String(tagPanicStack, workflowPanicErr.StackTrace()),
 				zap.Int64("PreviousStartedEventID", task.GetPreviousStartedEventId()),
 			)
 		}
 
-		result, err := eventHandler.ProcessQuery(task.Query.GetQueryType(), task.Query.QueryArgs)
+		result, err := wth.processQuery(eventHandler, task.Query.GetQueryType(), task.Query.QueryArgs)
 		if err != nil {
 			queryCompletedRequest.CompletedType = common.QueryTaskCompletedTypePtr(s.QueryTaskCompletedTypeFailed)
 			queryCompletedRequest.ErrorMessage = common.StringPtr(err.Error())
@@ -1253,14 +1412,23 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 	// fail decision task on decider panic
 	if panicErr, ok := workflowContext.err.(*workflowPanicError); ok {
 		// Workflow panic
+		origin := newDecisionTaskFailureOrigin(panicErr, decisions)
+		decisionType := "none"
+		if len(decisions) > 0 {
+			decisionType = decisions[len(decisions)-1].GetDecisionType().String()
+		}
 		metricsScope.Counter(metrics.DecisionTaskPanicCounter).Inc(1)
+		metricsScope.Tagged(map[string]string{tagDecisionType: decisionType}).Counter(metrics.DecisionTaskPanicByDecisionCounter).Inc(1)
 		wth.logger.Error("Workflow panic.",
 			zap.String(tagWorkflowType, task.WorkflowType.GetName()),
 			zap.String(tagWorkflowID, task.WorkflowExecution.GetWorkflowId()),
 			zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
-			zap.String(tagPanicError, panicErr.Error()),
+			zap.String(tagPanicError, redactPayload(wth.payloadRedactor, panicErr.Error())),
+			zap.Stringer(tagPanicCause, panicErr.Cause()),
+			zap.String(tagFailedDecision, origin.decision),
+			zap.String(tagFailedCallSite, origin.callSite),
 			zap.String(tagPanicStack, panicErr.StackTrace()))
-		return errorToFailDecisionTask(task.TaskToken, panicErr, wth.identity)
+		return errorToFailDecisionTask(task.TaskToken, panicErr, origin, wth.identity)
 	}
 
 	// complete decision task
@@ -1293,6 +1461,9 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 		metricsScope.Counter(metrics.WorkflowFailedCounter).Inc(1)
 		closeDecision = createNewDecision(s.DecisionTypeFailWorkflowExecution)
 		reason, details := getErrorDetails(workflowContext.err, wth.dataConverter)
+		if workflowContext.workflowInfo.ParentWorkflowExecution != nil {
+			reason, details = getChildWorkflowErrorDetails(workflowContext.err, wth.dataConverter)
+		}
 		closeDecision.FailWorkflowExecutionDecisionAttributes = &s.FailWorkflowExecutionDecisionAttributes{
 			Reason:  common.StringPtr(reason),
 			Details: details,
@@ -1310,14 +1481,19 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 		decisions = append(decisions, closeDecision)
 		elapsed := time.Since(workflowContext.workflowStartTime)
 		metricsScope.Timer(metrics.WorkflowEndToEndLatency).Record(elapsed)
+		if eventHandler.profile != nil {
+			eventHandler.profile.emitMetrics(metricsScope)
+		}
 		forceNewDecision = false
 	}
 
+	emitDecisionMetrics(metricsScope, decisions)
+
 	var queryResults map[string]*s.WorkflowQueryResult
 	if len(task.Queries) != 0 {
 		queryResults = make(map[string]*s.WorkflowQueryResult)
 		for queryID, query := range task.Queries {
-			result, err := eventHandler.ProcessQuery(query.GetQueryType(), query.QueryArgs)
+			result, err := wth.processQuery(eventHandler, query.GetQueryType(), query.QueryArgs)
 			if err != nil {
 				queryResults[queryID] = &s.WorkflowQueryResult{
 					ResultType:   common.QueryResultTypePtr(s.QueryResultTypeFailed),
@@ -1343,13 +1519,139 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 	}
 }
 
-func errorToFailDecisionTask(taskToken []byte, err error, identity string) *s.RespondDecisionTaskFailedRequest {
+// emitDecisionMetrics counts the decisions of a completed decision task by decision type.
+func emitDecisionMetrics(metricsScope tally.Scope, decisions []*s.Decision) {
+	counts := make(map[s.DecisionType]int64)
+	for _, d := range decisions {
+		counts[d.GetDecisionType()]++
+	}
+	for decisionType, count := range counts {
+		metricsScope.Tagged(map[string]string{tagDecisionType: decisionType.String()}).Counter(metrics.DecisionCounter).Inc(count)
+	}
+}
+
+// processQuery answers a query and records its metrics. Queries slower than slowQueryThreshold are logged.
+func (wth *workflowTaskHandlerImpl) processQuery(
+	eventHandler *workflowExecutionEventHandlerImpl,
+	queryType string,
+	queryArgs []byte,
+) ([]byte, error) {
+	workflowInfo := eventHandler.workflowEnvironmentImpl.workflowInfo
+	metricsScope := wth.metricsScope.GetTaggedScope(
+		tagWorkflowType, workflowInfo.WorkflowType.Name,
+		tagQueryType, queryType,
+	)
+	metricsScope.Counter(metrics.QueryCounter).Inc(1)
+
+	startTime := time.Now()
+	result, err := eventHandler.ProcessQuery(queryType, queryArgs)
+	latency := time.Since(startTime)
+
+	metricsScope.Timer(metrics.QueryLatency).Record(latency)
+	if err != nil {
+		metricsScope.Counter(metrics.QueryFailedCounter).Inc(1)
+		err = redactError(wth.payloadRedactor, err)
+	}
+	if wth.slowQueryThreshold > 0 && latency > wth.slowQueryThreshold {
+		metricsScope.Counter(metrics.QuerySlowCounter).Inc(1)
+		wth.logger.Warn("Slow query handler.",
+			zap.String(tagWorkflowType, workflowInfo.WorkflowType.Name),
+			zap.String(tagWorkflowID, workflowInfo.WorkflowExecution.ID),
+			zap.String(tagRunID, workflowInfo.WorkflowExecution.RunID),
+			zap.String(tagQueryType, queryType),
+			zap.Duration("Latency", latency),
+			zap.Error(err))
+	}
+	return result, err
+}
+
+// tapHistoryEvents calls the HistoryEventTap of the worker. Panics of the tap are logged, so that they do not fail
+// the decision task.
+func (wth *workflowTaskHandlerImpl) tapHistoryEvents(info *WorkflowInfo, events []*s.HistoryEvent) {
+	defer func() {
+		if p := recover(); p != nil {
+			wth.logger.Error("History event tap panicked.",
+				zap.String(tagWorkflowType, info.WorkflowType.Name),
+				zap.String(tagWorkflowID, info.WorkflowExecution.ID),
+				zap.String(tagRunID, info.WorkflowExecution.RunID),
+				zap.String(tagPanicError, fmt.Sprintf("%v", p)),
+				zap.String(tagPanicStack, string(debug.Stack())))
+		}
+	}()
+	wth.historyEventTap(info, events)
+}
+
+// logSlowDecisionTask logs the yield diagnostics of decision tasks slower than yieldDiagnosticsThreshold.
+func (wth *workflowTaskHandlerImpl) logSlowDecisionTask(
+	task *s.PollForDecisionTaskResponse,
+	yields *yieldRecorder,
+	latency time.Duration,
+) {
+	if wth.yieldDiagnosticsThreshold <= 0 || latency <= wth.yieldDiagnosticsThreshold {
+		return
+	}
+	wth.logger.Warn("Slow decision task.",
+		zap.String(tagWorkflowType, task.WorkflowType.GetName()),
+		zap.String(tagWorkflowID, task.WorkflowExecution.GetWorkflowId()),
+		zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
+		zap.Duration("Latency", latency),
+		zap.Reflect("YieldDiagnostics", yields.diagnostics()))
+}
+
+// recordFullReplay emits the metrics of a task which replayed the full history of the workflow, and logs it with
+// WorkerOptions.LogWorkflowCacheEvictions.
+func (wth *workflowTaskHandlerImpl) recordFullReplay(
+	task *s.PollForDecisionTaskResponse,
+	cause string,
+	latency time.Duration,
+) {
+	historyLength := task.GetNextEventId() - 1
+	if events := task.History.GetEvents(); len(events) > 0 && events[len(events)-1].GetEventId() > historyLength {
+		historyLength = events[len(events)-1].GetEventId()
+	}
+	scope := wth.metricsScope.GetTaggedScope(tagWorkflowType, task.WorkflowType.GetName(), tagReplayCause, cause)
+	scope.Counter(metrics.FullReplayCounter).Inc(1)
+	scope.Counter(metrics.FullReplayEvents).Inc(historyLength)
+	scope.Timer(metrics.FullReplayLatency).Record(latency)
+	if wth.logCacheEvictions {
+		wth.logger.Info("Replayed full workflow history.",
+			zap.String(tagWorkflowType, task.WorkflowType.GetName()),
+			zap.String(tagWorkflowID, task.WorkflowExecution.GetWorkflowId()),
+			zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
+			zap.String(tagReplayCause, cause),
+			zap.Int64("HistoryLength", historyLength),
+			zap.Duration("Latency", latency))
+	}
+}
+
+// recordEviction emits the metric of the eviction of the workflow from the cache, and logs the evictions of running
+// workflows with WorkerOptions.LogWorkflowCacheEvictions.
+func (w *workflowExecutionContextImpl) recordEviction() {
+	cause := w.evictionCause
+	if cause == "" {
+		cause = evictionCauseCachePressure
+	}
+	w.evictionCause = ""
+	w.wth.metricsScope.
+		GetTaggedScope(tagWorkflowType, w.workflowInfo.WorkflowType.Name, tagEvictionCause, cause).
+		Counter(metrics.WorkflowCacheEviction).Inc(1)
+	if w.wth.logCacheEvictions && cause != evictionCauseCompleted {
+		w.wth.logger.Info("Workflow evicted from cache.",
+			zap.String(tagWorkflowType, w.workflowInfo.WorkflowType.Name),
+			zap.String(tagWorkflowID, w.workflowInfo.WorkflowExecution.ID),
+			zap.String(tagRunID, w.workflowInfo.WorkflowExecution.RunID),
+			zap.String(tagEvictionCause, cause),
+			zap.Int("CacheSize", getWorkflowCache().Size()))
+	}
+}
+
+func errorToFailDecisionTask(taskToken []byte, err error, origin decisionTaskFailureOrigin, identity string) *s.RespondDecisionTaskFailedRequest {
 	failedCause := s.DecisionTaskFailedCauseWorkflowWorkerUnhandledFailure
 	_, details := getErrorDetails(err, nil)
 	return &s.RespondDecisionTaskFailedRequest{
 		TaskToken:      taskToken,
 		Cause:          &failedCause,
-		Details:        details,
+		Details:        origin.encodeDetails(details),
 		Identity:       common.StringPtr(identity),
 		BinaryChecksum: common.StringPtr(getBinaryChecksum()),
 	}
@@ -1377,16 +1679,18 @@ type cadenceInvoker struct {
 	service               workflowserviceclient.Interface
 	taskToken             []byte
 	cancelHandler         func()
-	heartBeatTimeoutInSec int32       // The heart beat interval configured for this activity.
-	hbBatchEndTimer       *time.Timer // Whether we started a batch of operations that need to be reported in the cycle. This gets started on a user call.
-	detailsToReport       *[]byte     // Details to be reported in the next reporting interval.
-	lastDetailsReported   *[]byte     // Details that were reported in the last reporting interval.
+	cancellation          *CancellationDetails
+	heartBeatTimeoutInSec int32           // The heart beat interval configured for this activity.
+	hbBatchEndTimer       clockwork.Timer // Whether we started a batch of operations that need to be reported in the cycle. This gets started on a user call.
+	detailsToReport       *[]byte         // Details to be reported in the next reporting interval.
+	lastDetailsReported   *[]byte         // Details that were reported in the last reporting interval.
 	closeCh               chan struct{}
 	workerStopChannel     <-chan struct{}
 	featureFlags          FeatureFlags
 	logger                *zap.Logger
 	workflowType          string
 	activityType          string
+	clock                 clockwork.Clock
 }
 
 func (i *cadenceInvoker) Heartbeat(details []byte) error {
@@ -1451,11 +1755,12 @@ func (i *cadenceInvoker) heartbeatAndScheduleNextRun(details []byte) error {
 
 		// We set a deadline at 80% of the timeout.
 		duration := time.Duration(0.8*float32(deadlineToTrigger)) * time.Second
-		i.hbBatchEndTimer = time.NewTimer(duration)
+		timer := i.getClock().NewTimer(duration)
+		i.hbBatchEndTimer = timer
 
 		go func() {
 			select {
-			case <-i.hbBatchEndTimer.C:
+			case <-timer.Chan():
 				// We are close to deadline.
 			case <-i.workerStopChannel:
 				// Activity worker is close to stop. This does the same steps as batch timer ends.
@@ -1510,12 +1815,19 @@ func (i *cadenceInvoker) internalHeartBeat(details []byte) (bool, error) {
 	switch err.(type) {
 	case *CanceledError:
 		// We are asked to cancel. inform the activity about cancellation through context.
+		i.cancellation = &CancellationDetails{Source: CancellationSourceWorkflow}
 		i.cancelHandler()
 		isActivityCancelled = true
 
-	case *s.EntityNotExistsError, *s.WorkflowExecutionAlreadyCompletedError, *s.DomainNotActiveError:
+	case *s.EntityNotExistsError, *s.WorkflowExecutionAlreadyCompletedError:
 		// We will pass these through as cancellation for now but something we can change
 		// later when we have setter on cancel handler.
+		i.cancellation = &CancellationDetails{Source: CancellationSourceWorkflowClosed}
+		i.cancelHandler()
+		isActivityCancelled = true
+
+	case *s.DomainNotActiveError:
+		i.cancellation = &CancellationDetails{Source: CancellationSourceUnknown}
 		i.cancelHandler()
 		isActivityCancelled = true
 	}
@@ -1525,6 +1837,12 @@ func (i *cadenceInvoker) internalHeartBeat(details []byte) (bool, error) {
 	return isActivityCancelled, err
 }
 
+func (i *cadenceInvoker) getCancellationDetails() *CancellationDetails {
+	i.Lock()
+	defer i.Unlock()
+	return i.cancellation
+}
+
 func (i *cadenceInvoker) Close(flushBufferedHeartbeat bool) {
 	i.Lock()
 	defer i.Unlock()
@@ -1554,6 +1872,7 @@ func newServiceInvoker(
 	logger *zap.Logger,
 	workflowType string,
 	activityType string,
+	clock clockwork.Clock,
 ) ServiceInvoker {
 	return &cadenceInvoker{
 		taskToken:             taskToken,
@@ -1567,7 +1886,15 @@ func newServiceInvoker(
 		logger:                logger,
 		workflowType:          workflowType,
 		activityType:          activityType,
+		clock:                 clock,
+	}
+}
+
+func (i *cadenceInvoker) getClock() clockwork.Clock {
+	if i.clock == nil {
+		return clockwork.NewRealClock()
 	}
+	return i.clock
 }
 
 func createNewDecision(decisionType s.DecisionType) *s.Decision {
@@ -35,9 +35,12 @@ import (
 	"go.uber.org/cadence/internal/common/testlogger"
 
 	"github.com/golang/mock/gomock"
+	"github.com/jonboulle/clockwork"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
 	"go.uber.org/goleak"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -46,6 +49,7 @@ import (
 	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
 	s "go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/metrics"
 )
 
 const (
@@ -346,6 +350,269 @@ func (t *TaskHandlersTestSuite) TestWorkflowTask_WorkflowExecutionStarted_WithDa
 	t.testWorkflowTaskWorkflowExecutionStartedHelper(params)
 }
 
+func (t *TaskHandlersTestSuite) TestWorkflowTask_ExecutionProfile() {
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+		createTestEventDecisionTaskCompleted(4, &s.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(2)}),
+		createTestEventActivityTaskScheduled(5, &s.ActivityTaskScheduledEventAttributes{
+			ActivityId:   common.StringPtr("0"),
+			ActivityType: &s.ActivityType{Name: common.StringPtr("Greeter_Activity")},
+			TaskList:     &s.TaskList{Name: &taskList},
+		}),
+		createTestEventActivityTaskStarted(6, &s.ActivityTaskStartedEventAttributes{
+			ScheduledEventId: common.Int64Ptr(5),
+			Attempt:          common.Int32Ptr(2),
+		}),
+		createTestEventActivityTaskCompleted(7, &s.ActivityTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(5)}),
+		createTestEventDecisionTaskScheduled(8, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(9),
+	}
+	testEvents[4].Timestamp = common.Int64Ptr(int64(time.Second))
+	testEvents[6].Timestamp = common.Int64Ptr(int64(4 * time.Second))
+	queries := map[string]*s.WorkflowQuery{
+		"id1": {QueryType: common.StringPtr(QueryTypeExecutionProfile)},
+	}
+	task := createWorkflowTaskWithQueries(testEvents, 3, "HelloWorld_Workflow", queries)
+
+	scope := tally.NewTestScope("", nil)
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity:               "test-id-1",
+			Logger:                 t.logger,
+			MetricsScope:           scope,
+			EnableExecutionProfile: true,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	response := request.(*s.RespondDecisionTaskCompletedRequest)
+	t.Equal(s.DecisionTypeCompleteWorkflowExecution, response.Decisions[0].GetDecisionType())
+
+	var profile ExecutionProfile
+	t.Equal(s.QueryResultTypeAnswered, response.QueryResults["id1"].GetResultType())
+	t.NoError(json.Unmarshal(response.QueryResults["id1"].Answer, &profile))
+	t.Equal(ExecutionProfile{
+		Decisions: 2,
+		Activities: map[string]*ActivityProfile{
+			"Greeter_Activity": {Scheduled: 1, Closed: 1, Retries: 2, TotalDuration: 3 * time.Second, MaxDuration: 3 * time.Second},
+		},
+	}, profile)
+
+	counters := make(map[string]int64)
+	for _, counter := range scope.Snapshot().Counters() {
+		if counter.Tags()[tagWorkflowType] == "HelloWorld_Workflow" {
+			counters[counter.Name()] = counter.Value()
+		}
+	}
+	t.Equal(int64(2), counters[metrics.WorkflowProfileDecisions])
+	t.Equal(int64(1), counters[metrics.WorkflowProfileActivities])
+	t.Equal(int64(2), counters[metrics.WorkflowProfileActivityRetries])
+
+	// clean up workflow left in cache
+	getWorkflowCache().Delete(*task.WorkflowExecution.RunId)
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_YieldDiagnostics() {
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+	}
+	queries := map[string]*s.WorkflowQuery{
+		"id1": {QueryType: common.StringPtr(QueryTypeYieldDiagnostics)},
+	}
+	task := createWorkflowTaskWithQueries(testEvents, 0, "HelloWorld_Workflow", queries)
+	obs, logs := observer.New(zap.WarnLevel)
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity:                  "test-id-1",
+			Logger:                    zap.New(obs),
+			EnableYieldDiagnostics:    true,
+			YieldDiagnosticsThreshold: time.Nanosecond,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	response := request.(*s.RespondDecisionTaskCompletedRequest)
+	t.Equal(s.QueryResultTypeAnswered, response.QueryResults["id1"].GetResultType())
+
+	var diagnostics YieldDiagnostics
+	t.NoError(decodeArg(getDefaultDataConverter(), response.QueryResults["id1"].Answer, &diagnostics))
+	// the root coroutine yields once before running the workflow function, and once blocked on the activity result
+	t.Equal(2, diagnostics.Yields)
+	t.Len(diagnostics.Coroutines, 1)
+	t.Equal(1, diagnostics.Coroutines[0].Reasons["yield before executing to setup state"])
+
+	slowTasks := logs.FilterMessage("Slow decision task.").All()
+	t.Len(slowTasks, 1)
+	t.Equal(task.WorkflowExecution.GetWorkflowId(), findLogField(slowTasks[0], tagWorkflowID).String)
+	t.NotNil(findLogField(slowTasks[0], "YieldDiagnostics"))
+
+	// clean up workflow left in cache
+	getWorkflowCache().Delete(*task.WorkflowExecution.RunId)
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_DiagnosticsSampling() {
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+	}
+	process := func(sampling DiagnosticsSamplingOptions) *observer.ObservedLogs {
+		task := createWorkflowTask(testEvents, 0, "HelloWorld_Workflow")
+		obs, logs := observer.New(zap.InfoLevel)
+		params := workerExecutionParameters{
+			TaskList: taskList,
+			WorkerOptions: WorkerOptions{
+				Identity:            "test-id-1",
+				Logger:              zap.New(obs),
+				DiagnosticsSampling: sampling,
+			},
+		}
+		taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+		_, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+		t.NoError(err)
+		// clean up workflow left in cache
+		getWorkflowCache().Delete(*task.WorkflowExecution.RunId)
+		return logs
+	}
+
+	logs := process(DiagnosticsSamplingOptions{
+		WorkflowTypeRates: map[string]float64{"HelloWorld_Workflow": 1},
+	})
+	sampled := logs.FilterMessage("Sampled decision task.").All()
+	t.Len(sampled, 1)
+	t.Equal("HelloWorld_Workflow", findLogField(sampled[0], tagWorkflowType).String)
+	// the workflow function schedules its activity
+	t.Equal(int64(1), findLogField(sampled[0], "Decisions").Integer)
+	t.NotNil(findLogField(sampled[0], "DecisionPayloadSize"))
+	t.NotNil(findLogField(sampled[0], "YieldDiagnostics"))
+
+	logs = process(DiagnosticsSamplingOptions{
+		Rate:              1,
+		WorkflowTypeRates: map[string]float64{"HelloWorld_Workflow": 0},
+	})
+	t.Empty(logs.FilterMessage("Sampled decision task.").All())
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_TracingSpans() {
+	taskList := "tl1"
+	tracer := mocktracer.New()
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity: "test-id-1",
+			Logger:   t.logger,
+			Tracer:   tracer,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+
+	// the first decision task runs the workflow function, which blocks on its activity
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+	}
+	task := createWorkflowTask(testEvents, 0, "HelloWorld_Workflow")
+	_, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	spans := tracer.FinishedSpans()
+	t.Len(spans, 1)
+	t.Equal("ProcessDecisionTask-HelloWorld_Workflow", spans[0].OperationName)
+	t.Equal(task.WorkflowExecution.GetWorkflowId(), spans[0].Tag(workflowTag))
+	t.Equal(task.WorkflowExecution.GetRunId(), spans[0].Tag(runTag))
+	getWorkflowCache().Delete(*task.WorkflowExecution.RunId)
+
+	// the start of the workflow is replayed, so no workflow span is created again
+	tracer.Reset()
+	testEvents = []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+		createTestEventDecisionTaskCompleted(4, &s.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(2)}),
+		createTestEventActivityTaskScheduled(5, &s.ActivityTaskScheduledEventAttributes{
+			ActivityId:   common.StringPtr("0"),
+			ActivityType: &s.ActivityType{Name: common.StringPtr("Greeter_Activity")},
+			TaskList:     &s.TaskList{Name: &taskList},
+		}),
+		createTestEventActivityTaskStarted(6, &s.ActivityTaskStartedEventAttributes{ScheduledEventId: common.Int64Ptr(5)}),
+		createTestEventActivityTaskCompleted(7, &s.ActivityTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(5)}),
+		createTestEventDecisionTaskScheduled(8, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(9),
+	}
+	task = createWorkflowTask(testEvents, 3, "HelloWorld_Workflow")
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	response := request.(*s.RespondDecisionTaskCompletedRequest)
+	t.Equal(s.DecisionTypeCompleteWorkflowExecution, response.Decisions[0].GetDecisionType())
+	spans = tracer.FinishedSpans()
+	t.Len(spans, 1)
+	t.Equal("ProcessDecisionTask-HelloWorld_Workflow", spans[0].OperationName)
+	getWorkflowCache().Delete(*task.WorkflowExecution.RunId)
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_HistoryEventTap() {
+	taskList := "tl1"
+	var tapped [][]int64
+	var workflowType string
+	tap := func(info *WorkflowInfo, events []*s.HistoryEvent) {
+		workflowType = info.WorkflowType.Name
+		var ids []int64
+		for _, event := range events {
+			ids = append(ids, event.GetEventId())
+		}
+		tapped = append(tapped, ids)
+	}
+	newTaskHandler := func(tap HistoryEventTap) WorkflowTaskHandler {
+		params := workerExecutionParameters{
+			TaskList: taskList,
+			WorkerOptions: WorkerOptions{
+				Identity:        "test-id-1",
+				Logger:          t.logger,
+				HistoryEventTap: tap,
+			},
+		}
+		return newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	}
+
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+		createTestEventDecisionTaskCompleted(4, &s.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(2)}),
+		createTestEventActivityTaskScheduled(5, &s.ActivityTaskScheduledEventAttributes{
+			ActivityId:   common.StringPtr("0"),
+			ActivityType: &s.ActivityType{Name: common.StringPtr("Greeter_Activity")},
+			TaskList:     &s.TaskList{Name: &taskList},
+		}),
+		createTestEventActivityTaskStarted(6, &s.ActivityTaskStartedEventAttributes{ScheduledEventId: common.Int64Ptr(5)}),
+		createTestEventActivityTaskCompleted(7, &s.ActivityTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(5)}),
+		createTestEventDecisionTaskScheduled(8, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(9),
+	}
+	// the events up to the previous decision task are replayed, not tapped
+	task := createWorkflowTask(testEvents, 3, "HelloWorld_Workflow")
+	_, err := newTaskHandler(tap).ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.Equal([][]int64{{4, 5, 6, 7, 8, 9}}, tapped)
+	t.Equal("HelloWorld_Workflow", workflowType)
+	getWorkflowCache().Delete(*task.WorkflowExecution.RunId)
+
+	// a panicking tap does not fail the decision task
+	task = createWorkflowTask(testEvents, 3, "HelloWorld_Workflow")
+	request, err := newTaskHandler(func(info *WorkflowInfo, events []*s.HistoryEvent) {
+		panic("tap failure")
+	}).ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	response := request.(*s.RespondDecisionTaskCompletedRequest)
+	t.Equal(s.DecisionTypeCompleteWorkflowExecution, response.Decisions[0].GetDecisionType())
+	getWorkflowCache().Delete(*task.WorkflowExecution.RunId)
+}
+
 func (t *TaskHandlersTestSuite) TestWorkflowTask_BinaryChecksum() {
 	taskList := "tl1"
 	checksum1 := "chck1"
@@ -1132,6 +1399,224 @@ func (t *TaskHandlersTestSuite) TestConsistentQuery_Success() {
 	getWorkflowCache().Delete(*task.WorkflowExecution.RunId)
 }
 
+func (t *TaskHandlersTestSuite) TestWorkflowTask_DecisionMetrics() {
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+		createTestEventDecisionTaskCompleted(4, &s.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(2)}),
+		createTestEventActivityTaskScheduled(5, &s.ActivityTaskScheduledEventAttributes{
+			ActivityId:   common.StringPtr("0"),
+			ActivityType: &s.ActivityType{Name: common.StringPtr("Greeter_Activity")},
+			TaskList:     &s.TaskList{Name: &taskList},
+		}),
+		createTestEventActivityTaskStarted(6, &s.ActivityTaskStartedEventAttributes{}),
+		createTestEventActivityTaskCompleted(7, &s.ActivityTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(5)}),
+		createTestEventDecisionTaskStarted(8),
+	}
+	scope := tally.NewTestScope("", nil)
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity:     "test-id-1",
+			Logger:       t.logger,
+			MetricsScope: scope,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+
+	decisionCounts := func() map[string]int64 {
+		counts := make(map[string]int64)
+		for _, counter := range scope.Snapshot().Counters() {
+			if counter.Name() == metrics.DecisionCounter && counter.Tags()[tagWorkflowType] == "HelloWorld_Workflow" {
+				counts[counter.Tags()[tagDecisionType]] = counter.Value()
+			}
+		}
+		return counts
+	}
+
+	task := createWorkflowTask(testEvents[0:3], 0, "HelloWorld_Workflow")
+	_, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.Equal(map[string]int64{s.DecisionTypeScheduleActivityTask.String(): 1}, decisionCounts())
+
+	task = createWorkflowTask(testEvents, 3, "HelloWorld_Workflow")
+	_, err = taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.Equal(map[string]int64{
+		s.DecisionTypeScheduleActivityTask.String():      1,
+		s.DecisionTypeCompleteWorkflowExecution.String(): 1,
+	}, decisionCounts())
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_CacheEvictionAndFullReplay() {
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+		createTestEventDecisionTaskCompleted(4, &s.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(2)}),
+		createTestEventActivityTaskScheduled(5, &s.ActivityTaskScheduledEventAttributes{
+			ActivityId:   common.StringPtr("0"),
+			ActivityType: &s.ActivityType{Name: common.StringPtr("Greeter_Activity")},
+			TaskList:     &s.TaskList{Name: &taskList},
+		}),
+		createTestEventActivityTaskStarted(6, &s.ActivityTaskStartedEventAttributes{}),
+		createTestEventActivityTaskCompleted(7, &s.ActivityTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(5)}),
+		createTestEventDecisionTaskStarted(8),
+	}
+	scope := tally.NewTestScope("", nil)
+	obs, logs := observer.New(zap.InfoLevel)
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity:                  "test-id-1",
+			Logger:                    zap.New(obs),
+			MetricsScope:              scope,
+			LogWorkflowCacheEvictions: true,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	counters := func(name, tag string) map[string]int64 {
+		values := make(map[string]int64)
+		for _, counter := range scope.Snapshot().Counters() {
+			if counter.Name() == name && counter.Tags()[tagWorkflowType] == "HelloWorld_Workflow" {
+				values[counter.Tags()[tag]] = counter.Value()
+			}
+		}
+		return values
+	}
+
+	// the first decision task does not replay
+	task := createWorkflowTask(testEvents[0:3], 0, "HelloWorld_Workflow")
+	_, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.Empty(counters(metrics.FullReplayCounter, tagReplayCause))
+
+	getWorkflowCache().Delete(task.WorkflowExecution.GetRunId())
+	t.Eventually(func() bool {
+		return logs.FilterMessage("Workflow evicted from cache.").Len() == 1
+	}, time.Second, 10*time.Millisecond)
+	evicted := logs.FilterMessage("Workflow evicted from cache.").All()[0]
+	t.Equal(evictionCauseCachePressure, findLogField(evicted, tagEvictionCause).String)
+	t.Equal(map[string]int64{evictionCauseCachePressure: 1}, counters(metrics.WorkflowCacheEviction, tagEvictionCause))
+
+	// the next decision task is delivered with the full history on the normal task list
+	task = createWorkflowTask(testEvents, 3, "HelloWorld_Workflow")
+	task.NextEventId = common.Int64Ptr(9)
+	_, err = taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.Equal(map[string]int64{replayCauseNonSticky: 1}, counters(metrics.FullReplayCounter, tagReplayCause))
+	t.Equal(map[string]int64{replayCauseNonSticky: 8}, counters(metrics.FullReplayEvents, tagReplayCause))
+	replays := logs.FilterMessage("Replayed full workflow history.").All()
+	t.Len(replays, 1)
+	t.Equal(replayCauseNonSticky, findLogField(replays[0], tagReplayCause).String)
+	t.Equal(int64(8), findLogField(replays[0], "HistoryLength").Integer)
+
+	// completed workflows are removed from the cache without logging
+	t.Eventually(func() bool {
+		return counters(metrics.WorkflowCacheEviction, tagEvictionCause)[evictionCauseCompleted] == 1
+	}, time.Second, 10*time.Millisecond)
+	t.Equal(1, logs.FilterMessage("Workflow evicted from cache.").Len())
+}
+
+func (t *TaskHandlersTestSuite) TestConsistentQuery_RedactedError() {
+	taskList := "tl1"
+	numberOfSignalsToComplete, err := getDefaultDataConverter().ToData(2)
+	t.NoError(err)
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{
+			TaskList: &s.TaskList{Name: &taskList},
+			Input:    numberOfSignalsToComplete,
+		}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{}),
+		createTestEventDecisionTaskStarted(3),
+	}
+	queries := map[string]*s.WorkflowQuery{
+		"id1": {QueryType: common.StringPtr(errQueryType)},
+	}
+	task := createWorkflowTaskWithQueries(testEvents, 0, "QuerySignalWorkflow", queries)
+
+	redactor := NewTruncatingPayloadRedactor(5)
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity:        "test-id-1",
+			Logger:          t.logger,
+			PayloadRedactor: redactor,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	response := request.(*s.RespondDecisionTaskCompletedRequest)
+	t.Equal(redactor.Redact(queryErr), response.QueryResults["id1"].GetErrorMessage())
+
+	// clean up workflow left in cache
+	getWorkflowCache().Delete(*task.WorkflowExecution.RunId)
+}
+
+func (t *TaskHandlersTestSuite) TestConsistentQuery_MetricsAndSlowQueryLog() {
+	taskList := "tl1"
+	numberOfSignalsToComplete, err := getDefaultDataConverter().ToData(2)
+	t.NoError(err)
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{
+			TaskList: &s.TaskList{Name: &taskList},
+			Input:    numberOfSignalsToComplete,
+		}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{}),
+		createTestEventDecisionTaskStarted(3),
+	}
+
+	queries := map[string]*s.WorkflowQuery{
+		"id1": {QueryType: common.StringPtr(queryType)},
+		"id2": {QueryType: common.StringPtr(errQueryType)},
+	}
+	task := createWorkflowTaskWithQueries(testEvents, 0, "QuerySignalWorkflow", queries)
+
+	scope := tally.NewTestScope("", nil)
+	obs, logs := observer.New(zap.WarnLevel)
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity:           "test-id-1",
+			Logger:             zap.New(obs),
+			MetricsScope:       scope,
+			SlowQueryThreshold: time.Nanosecond,
+		},
+	}
+
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	_, err = taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+
+	counters := make(map[string]int64)
+	for _, counter := range scope.Snapshot().Counters() {
+		if counter.Tags()[tagWorkflowType] == "QuerySignalWorkflow" && counter.Tags()[tagQueryType] != "" {
+			counters[counter.Name()+"/"+counter.Tags()[tagQueryType]] = counter.Value()
+		}
+	}
+	t.Equal(map[string]int64{
+		metrics.QueryCounter + "/" + queryType:          1,
+		metrics.QuerySlowCounter + "/" + queryType:      1,
+		metrics.QueryCounter + "/" + errQueryType:       1,
+		metrics.QueryFailedCounter + "/" + errQueryType: 1,
+		metrics.QuerySlowCounter + "/" + errQueryType:   1,
+	}, counters)
+
+	slowQueries := logs.FilterMessage("Slow query handler.").All()
+	t.Len(slowQueries, 2)
+	for _, entry := range slowQueries {
+		t.Equal(task.WorkflowExecution.GetWorkflowId(), findLogField(entry, tagWorkflowID).String)
+		t.Equal(task.WorkflowExecution.GetRunId(), findLogField(entry, tagRunID).String)
+	}
+
+	// clean up workflow left in cache
+	getWorkflowCache().Delete(*task.WorkflowExecution.RunId)
+}
+
 func (t *TaskHandlersTestSuite) assertQueryResultsEqual(expected map[string]*s.WorkflowQueryResult, actual map[string]*s.WorkflowQueryResult) {
 	t.Equal(len(expected), len(actual))
 	for expectedID, expectedResult := range expected {
@@ -1259,7 +1744,7 @@ func (t *TaskHandlersTestSuite) TestLocalActivityRetry_DecisionHeartbeatFail() {
 	t.True(ok)
 	taskHandlerImpl.laTunnel = laTunnel
 
-	laTaskPoller := newLocalActivityPoller(params, laTunnel)
+	laTaskPoller := newLocalActivityPoller(params, laTunnel, nil)
 	doneCh := make(chan struct{})
 	go func() {
 		// laTaskPoller needs to poll the local activity and process it
@@ -1293,6 +1778,78 @@ func (t *TaskHandlersTestSuite) TestLocalActivityRetry_DecisionHeartbeatFail() {
 	<-doneCh
 }
 
+func (t *TaskHandlersTestSuite) TestLocalActivity_CheckpointOnWorkerStop() {
+	checkpointWorkflowFunc := func(ctx Context, input []byte) error {
+		ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: time.Minute})
+		for i := 0; i < 2; i++ {
+			if err := ExecuteLocalActivity(ctx, func() error { return nil }).Get(ctx, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	t.registry.RegisterWorkflowWithOptions(
+		checkpointWorkflowFunc,
+		RegisterWorkflowOptions{Name: "CheckpointLocalActivityWorkflow"},
+	)
+
+	decisionTaskStartedEvent := createTestEventDecisionTaskStarted(3)
+	decisionTaskStartedEvent.Timestamp = common.Int64Ptr(time.Now().UnixNano())
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{
+			TaskStartToCloseTimeoutSeconds: common.Int32Ptr(60),
+			TaskList:                       &s.TaskList{Name: &testWorkflowTaskTasklist}},
+		),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{}),
+		decisionTaskStartedEvent,
+	}
+
+	task := createWorkflowTask(testEvents, 0, "CheckpointLocalActivityWorkflow")
+	stopCh := make(chan struct{})
+	params := workerExecutionParameters{
+		TaskList: testWorkflowTaskTasklist,
+		WorkerOptions: WorkerOptions{
+			Identity: "test-id-1",
+			Logger:   t.logger,
+		},
+		WorkerStopChannel: stopCh,
+	}
+
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	laTunnel := newLocalActivityTunnel(params.WorkerStopChannel)
+	taskHandlerImpl, ok := taskHandler.(*workflowTaskHandlerImpl)
+	t.True(ok)
+	taskHandlerImpl.laTunnel = laTunnel
+
+	laTaskPoller := newLocalActivityPoller(params, laTunnel, nil)
+	go func() {
+		// complete the first local activity, then stop the worker while the second one is pending
+		task, err := laTaskPoller.PollTask()
+		t.NoError(err)
+		err = laTaskPoller.ProcessTask(task)
+		t.NoError(err)
+		close(stopCh)
+	}()
+
+	response, err := taskHandler.ProcessWorkflowTask(
+		&workflowTask{
+			task:       task,
+			laResultCh: make(chan *localActivityResult),
+		},
+		func(response interface{}, startTime time.Time) (*workflowTask, error) {
+			t.Fail("decision task should not be heartbeated")
+			return nil, nil
+		})
+	t.NoError(err)
+	request, ok := response.(*s.RespondDecisionTaskCompletedRequest)
+	t.True(ok)
+	t.True(request.GetForceCreateNewDecisionTask())
+	t.False(request.GetReturnNewDecisionTask())
+	t.Len(request.Decisions, 1)
+	t.Equal(s.DecisionTypeRecordMarker, request.Decisions[0].GetDecisionType())
+	t.Equal(localActivityMarkerName, request.Decisions[0].RecordMarkerDecisionAttributes.GetMarkerName())
+}
+
 func (t *TaskHandlersTestSuite) TestHeartBeat_NoError() {
 	mockCtrl := gomock.NewController(t.T())
 	mockService := workflowservicetest.NewMockClient(mockCtrl)
@@ -1436,6 +1993,7 @@ func (t *TaskHandlersTestSuite) TestHeartBeat_NilResponseWithError() {
 		logger,
 		testWorkflowType,
 		testActivityType,
+		clockwork.NewRealClock(),
 	)
 
 	heartbeatErr := cadenceInvoker.BatchHeartbeat(nil)
@@ -1466,6 +2024,7 @@ func (t *TaskHandlersTestSuite) TestHeartBeat_NilResponseWithDomainNotActiveErro
 		logger,
 		testWorkflowType,
 		testActivityType,
+		clockwork.NewRealClock(),
 	)
 
 	heartbeatErr := cadenceInvoker.BatchHeartbeat(nil)
@@ -143,6 +143,9 @@ type (
 		contextPropagators []ContextPropagator
 		tracer             opentracing.Tracer
 		activityTracker    debug.ActivityTracker
+		payloadRedactor    PayloadRedactor
+		service            workflowserviceclient.Interface
+		featureFlags       FeatureFlags
 	}
 
 	localActivityResult struct {
@@ -418,13 +421,15 @@ func (wtp *workflowTaskPoller) RespondTaskCompletedWithMetrics(completedRequest
 	metricsScope := wtp.metricsScope.GetTaggedScope(tagWorkflowType, task.WorkflowType.GetName())
 	if taskErr != nil {
 		metricsScope.Counter(metrics.DecisionExecutionFailedCounter).Inc(1)
+		origin := newDecisionTaskFailureOrigin(taskErr, nil)
 		wtp.logger.Warn("Failed to process decision task.",
 			zap.String(tagWorkflowType, task.WorkflowType.GetName()),
 			zap.String(tagWorkflowID, task.WorkflowExecution.GetWorkflowId()),
 			zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
+			zap.String(tagFailedDecision, origin.decision),
 			zap.Error(taskErr))
 		// convert err to DecisionTaskFailed
-		completedRequest = errorToFailDecisionTask(task.TaskToken, taskErr, wtp.identity)
+		completedRequest = errorToFailDecisionTask(task.TaskToken, taskErr, origin, wtp.identity)
 	} else {
 		metricsScope.Counter(metrics.DecisionTaskCompletedCounter).Inc(1)
 	}
@@ -553,7 +558,11 @@ func (wtp *workflowTaskPoller) handleDecisionTaskCompletedRequest(ctx context.Co
 	return wtp.service.RespondDecisionTaskCompleted(ctx, request, opts...)
 }
 
-func newLocalActivityPoller(params workerExecutionParameters, laTunnel *localActivityTunnel) *localActivityTaskPoller {
+func newLocalActivityPoller(
+	params workerExecutionParameters,
+	laTunnel *localActivityTunnel,
+	service workflowserviceclient.Interface,
+) *localActivityTaskPoller {
 	if params.Tracer == nil {
 		params.Tracer = opentracing.NoopTracer{}
 	}
@@ -568,6 +577,9 @@ func newLocalActivityPoller(params workerExecutionParameters, laTunnel *localAct
 		contextPropagators: params.ContextPropagators,
 		tracer:             params.Tracer,
 		activityTracker:    params.WorkerStats.ActivityTracker,
+		payloadRedactor:    params.PayloadRedactor,
+		service:            service,
+		featureFlags:       params.FeatureFlags,
 	}
 	return &localActivityTaskPoller{
 		basePoller:   basePoller{shutdownC: params.WorkerStopChannel},
@@ -626,17 +638,21 @@ func (lath *localActivityTaskHandler) executeLocalActivityTask(task *localActivi
 	workflowTypeLocal := task.params.WorkflowInfo.WorkflowType
 
 	ctx := context.WithValue(rootCtx, activityEnvContextKey, &activityEnvironment{
-		workflowType:      &workflowTypeLocal,
-		workflowDomain:    task.params.WorkflowInfo.Domain,
-		taskList:          task.params.WorkflowInfo.TaskListName,
-		activityType:      ActivityType{Name: activityType},
-		activityID:        fmt.Sprintf("%v", task.activityID),
-		workflowExecution: task.params.WorkflowInfo.WorkflowExecution,
-		logger:            logger,
-		metricsScope:      metricsScope,
-		isLocalActivity:   true,
-		dataConverter:     lath.dataConverter,
-		attempt:           task.attempt,
+		workflowType:       &workflowTypeLocal,
+		workflowDomain:     task.params.WorkflowInfo.Domain,
+		taskList:           task.params.WorkflowInfo.TaskListName,
+		activityType:       ActivityType{Name: activityType},
+		activityID:         fmt.Sprintf("%v", task.activityID),
+		workflowExecution:  task.params.WorkflowInfo.WorkflowExecution,
+		logger:             logger,
+		metricsScope:       metricsScope,
+		isLocalActivity:    true,
+		dataConverter:      lath.dataConverter,
+		attempt:            task.attempt,
+		contextPropagators: lath.contextPropagators,
+		tracer:             lath.tracer,
+		workflowService:    lath.service,
+		featureFlags:       lath.featureFlags,
 	})
 
 	// propagate context information into the local activity activity context from the headers
@@ -646,11 +662,16 @@ func (lath *localActivityTaskHandler) executeLocalActivityTask(task *localActivi
 			result = &localActivityResult{
 				task:   task,
 				result: nil,
-				err:    fmt.Errorf("unable to propagate context %v", err),
+				err:    fmt.Errorf("unable to propagate context %w", err),
 			}
 			return result
 		}
 	}
+	headers, headersErr := readWorkflowHeadersHeader(task.header)
+	if headersErr != nil {
+		return &localActivityResult{task: task, err: fmt.Errorf("unable to read workflow headers %w", headersErr)}
+	}
+	ctx.Value(activityEnvContextKey).(*activityEnvironment).headers = headers
 
 	// count all failures beyond this point, as they come from the activity itself
 	defer func() {
@@ -688,7 +709,7 @@ func (lath *localActivityTaskHandler) executeLocalActivityTask(task *localActivi
 				topLine := fmt.Sprintf("local activity for %s [panic]:", activityType)
 				st := getStackTraceRaw(topLine, 7, 0)
 				logger.Error("LocalActivity panic.",
-					zap.String(tagPanicError, fmt.Sprintf("%v", p)),
+					zap.String(tagPanicError, redactPayload(lath.payloadRedactor, fmt.Sprintf("%v", p))),
 					zap.String(tagPanicStack, st))
 				metricsScope.Counter(metrics.LocalActivityPanicCounter).Inc(1)
 				err = newPanicError(p, st)
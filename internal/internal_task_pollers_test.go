@@ -183,6 +183,88 @@ func TestLocalActivityPanic(t *testing.T) {
 	assert.Contains(t, perr.StackTrace(), t.Name(), "should mention the source location of the local activity that panicked")
 }
 
+func TestLocalActivityContextPropagation(t *testing.T) {
+	t.Run("propagators are available to the local activity", func(t *testing.T) {
+		s := WorkflowTestSuite{logger: testlogger.NewZap(t)}
+		s.SetContextPropagators([]ContextPropagator{NewStringMapPropagator([]string{testHeader})})
+		env := s.NewTestWorkflowEnvironment()
+
+		var propagators []ContextPropagator
+		env.ExecuteWorkflow(func(ctx Context) error {
+			ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: time.Second})
+			return ExecuteLocalActivity(ctx, func(ctx context.Context) error {
+				propagators = getActivityEnv(ctx).contextPropagators
+				return nil
+			}).Get(ctx, nil)
+		})
+		require.NoError(t, env.GetWorkflowError())
+		assert.Len(t, propagators, 1)
+	})
+
+	t.Run("interceptors can add propagated values", func(t *testing.T) {
+		s := WorkflowTestSuite{logger: testlogger.NewZap(t)}
+		s.SetContextPropagators([]ContextPropagator{NewStringMapPropagator([]string{testHeader})})
+		env := s.NewTestWorkflowEnvironment()
+		env.SetWorkerOptions(WorkerOptions{
+			WorkflowInterceptorChainFactories: []WorkflowInterceptorFactory{localActivityHeaderInterceptorFactory{}},
+		})
+
+		var value interface{}
+		env.ExecuteWorkflow(func(ctx Context) error {
+			ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: time.Second})
+			return ExecuteLocalActivity(ctx, func(ctx context.Context) error {
+				value = ctx.Value(contextKey(testHeader))
+				return nil
+			}).Get(ctx, nil)
+		})
+		require.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, "from-interceptor", value)
+	})
+
+	t.Run("extract errors fail the local activity", func(t *testing.T) {
+		s := WorkflowTestSuite{logger: testlogger.NewZap(t)}
+		s.SetContextPropagators([]ContextPropagator{extractFailingPropagator{
+			ContextPropagator: NewStringMapPropagator([]string{testHeader}),
+		}})
+		env := s.NewTestWorkflowEnvironment()
+
+		var laErr error
+		env.ExecuteWorkflow(func(ctx Context) error {
+			ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: time.Second})
+			laErr = ExecuteLocalActivity(ctx, func(ctx context.Context) error {
+				return nil
+			}).Get(ctx, nil)
+			return nil
+		})
+		require.NoError(t, env.GetWorkflowError())
+		assert.ErrorContains(t, laErr, "unable to propagate context")
+	})
+}
+
+type localActivityHeaderInterceptorFactory struct{}
+
+func (localActivityHeaderInterceptorFactory) NewInterceptor(info *WorkflowInfo, next WorkflowInterceptor) WorkflowInterceptor {
+	return &localActivityHeaderInterceptor{WorkflowInterceptorBase{Next: next}}
+}
+
+type localActivityHeaderInterceptor struct {
+	WorkflowInterceptorBase
+}
+
+func (i *localActivityHeaderInterceptor) ExecuteLocalActivity(ctx Context, activityType string, args ...interface{}) Future {
+	ctx = WithValue(ctx, contextKey(testHeader), "from-interceptor")
+	return i.Next.ExecuteLocalActivity(ctx, activityType, args...)
+}
+
+// extractFailingPropagator only fails to extract the context of activities.
+type extractFailingPropagator struct {
+	ContextPropagator
+}
+
+func (p extractFailingPropagator) Extract(ctx context.Context, reader HeaderReader) (context.Context, error) {
+	return nil, assert.AnError
+}
+
 func TestRespondTaskCompleted_failed(t *testing.T) {
 	t.Run("fail sends RespondDecisionTaskFailedRequest", func(t *testing.T) {
 		testTaskToken := []byte("test-task-token")
@@ -216,7 +298,7 @@ func TestRespondTaskCompleted_failed(t *testing.T) {
 		}, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(assert.AnError)
 
 		// We cannot test RespondTaskCompleted since it uses backoff and has a hardcoded retry mechanism for 60 seconds.
-		_, err := poller.respondTaskCompletedAttempt(errorToFailDecisionTask(testTaskToken, assert.AnError, _testIdentity), &s.PollForDecisionTaskResponse{
+		_, err := poller.respondTaskCompletedAttempt(errorToFailDecisionTask(testTaskToken, assert.AnError, decisionTaskFailureOrigin{}, _testIdentity), &s.PollForDecisionTaskResponse{
 			TaskToken: testTaskToken,
 			Attempt:   common.Int64Ptr(0),
 		})
@@ -25,6 +25,7 @@ package internal
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -61,6 +62,10 @@ const (
 
 	clientFeatureFlagsHeaderName = "cadence-client-feature-flags"
 
+	// longPollHeaderName marks the long polls of the client, like the GetWorkflowExecutionHistory requests waiting for
+	// new events, so that the connection caps their timeout as the polls of the workers.
+	longPollHeaderName = "cadence-client-long-poll"
+
 	// defaultRPCTimeout is the default tchannel rpc call timeout
 	defaultRPCTimeout = 10 * time.Second
 	//minRPCTimeout is minimum rpc call timeout allowed
@@ -270,7 +275,7 @@ func getErrorDetails(err error, dataConverter DataConverter) (string, []byte) {
 		}
 		return errReasonCanceled, data
 	case *PanicError:
-		data, err0 := encodeArgs(dataConverter, []interface{}{err.Error(), err.StackTrace()})
+		data, err0 := encodeArgs(dataConverter, []interface{}{err.Error(), err.StackTrace(), err.Cause()})
 		if err0 != nil {
 			panic(err0)
 		}
@@ -296,8 +301,25 @@ func getErrorDetails(err error, dataConverter DataConverter) (string, []byte) {
 	}
 }
 
+// getChildWorkflowErrorDetails returns the reason and details of the failure of a child workflow. When err wraps a
+// *CustomError, e.g. with fmt.Errorf("%w"), they are the ones of the *CustomError, followed in the reason by the
+// message of err, so the parent gets both the message and the *CustomError with errors.As.
+func getChildWorkflowErrorDetails(err error, dataConverter DataConverter) (string, []byte) {
+	var customErr *CustomError
+	if _, ok := err.(*CustomError); ok || !errors.As(err, &customErr) {
+		return getErrorDetails(err, dataConverter)
+	}
+	reason, details := getErrorDetails(customErr, dataConverter)
+	return reason + errReasonWrappedBy + err.Error(), details
+}
+
 // constructError construct error from reason and details sending down from server.
 func constructError(reason string, details []byte, dataConverter DataConverter) error {
+	if i := strings.Index(reason, errReasonWrappedBy); i >= 0 {
+		if customErr, ok := constructError(reason[:i], details, dataConverter).(*CustomError); ok {
+			return &wrappedCustomError{message: reason[i+len(errReasonWrappedBy):], err: customErr}
+		}
+	}
 	if strings.HasPrefix(reason, errReasonTimeout) {
 		details := newEncodedValues(details, dataConverter)
 		timeoutType, err := getTimeoutTypeFromErrReason(reason)
@@ -315,9 +337,14 @@ func constructError(reason string, details []byte, dataConverter DataConverter)
 	case errReasonPanic:
 		// panic error
 		var msg, st string
+		var cause PanicCause
 		details := newEncodedValues(details, dataConverter)
-		details.Get(&msg, &st)
-		return newPanicError(msg, st)
+		if err := details.Get(&msg, &st, &cause); err != nil {
+			// prior client versions do not encode the cause
+			details.Get(&msg, &st)
+			return newPanicError(msg, st)
+		}
+		return &PanicError{value: msg, cause: cause, stackTrace: st}
 	case errReasonGeneric:
 		// errors created other than using NewCustomError() API.
 		return &GenericError{err: string(details)}
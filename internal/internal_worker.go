@@ -43,6 +43,7 @@ import (
 
 	"go.uber.org/cadence/internal/common/isolationgroup"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pborman/uuid"
 	"github.com/uber-go/tally"
@@ -148,6 +149,10 @@ type (
 
 		// SessionResourceID is a unique identifier of the resource the session will consume
 		SessionResourceID string
+
+		// FatalErrorHandler is called when a poller receives a non-retriable error from the service.
+		// If not set, the process is interrupted.
+		FatalErrorHandler func(error)
 	}
 )
 
@@ -201,6 +206,15 @@ func ensureRequiredParams(params *workerExecutionParameters) {
 	}
 }
 
+func getWorkerClock(options WorkerOptions) clockwork.Clock {
+	if options.Clock != nil {
+		return options.Clock
+	}
+	return clockwork.NewRealClock()
+}
+
+var errDomainDeleted = errors.New("domain is deleted")
+
 // verifyDomainExist does a DescribeDomain operation on the specified domain with backoff/retry
 // It returns an error, if the server returns an EntityNotExist or BadRequest error
 // On any other transient error, this method will just return success
@@ -214,7 +228,7 @@ func verifyDomainExist(
 	descDomainOp := func() error {
 		tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
 		defer cancel()
-		_, err := client.DescribeDomain(tchCtx, &shared.DescribeDomainRequest{Name: &domain}, opt...)
+		response, err := client.DescribeDomain(tchCtx, &shared.DescribeDomainRequest{Name: &domain}, opt...)
 		if err != nil {
 			if _, ok := err.(*shared.EntityNotExistsError); ok {
 				logger.Error("domain does not exist", zap.String("domain", domain), zap.Error(err))
@@ -224,8 +238,17 @@ func verifyDomainExist(
 				logger.Error("domain does not exist", zap.String("domain", domain), zap.Error(err))
 				return err
 			}
+			if _, ok := err.(*shared.ClientVersionNotSupportedError); ok {
+				logger.Error("client version is not supported", zap.String("domain", domain), zap.Error(err))
+				return err
+			}
 			// on any other error, just return true
 			logger.Warn("unable to verify if domain exist", zap.String("domain", domain), zap.Error(err))
+			return nil
+		}
+		if response.GetDomainInfo().GetStatus() == shared.DomainStatusDeleted {
+			logger.Error("domain is deleted", zap.String("domain", domain))
+			return errDomainDeleted
 		}
 		return nil
 	}
@@ -235,7 +258,23 @@ func verifyDomainExist(
 	}
 
 	// exponential backoff retry for upto a minute
-	return backoff.Retry(ctx, descDomainOp, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+	err := backoff.Retry(ctx, descDomainOp, createDynamicServiceRetryPolicy(ctx), func(err error) bool {
+		return err != errDomainDeleted && isServiceTransientError(err)
+	})
+	if err == errDomainDeleted {
+		return fmt.Errorf("domain %q: %w", domain, err)
+	}
+	switch err.(type) {
+	case *shared.EntityNotExistsError:
+		return fmt.Errorf("domain %q does not exist, register it with `cadence --domain %v domain register` "+
+			"or fix the domain passed to the worker: %w", domain, domain, err)
+	case *shared.BadRequestError:
+		return fmt.Errorf("domain %q is invalid: %w", domain, err)
+	case *shared.ClientVersionNotSupportedError:
+		return fmt.Errorf("the Cadence service does not support client version %v, upgrade go.uber.org/cadence: %w", LibraryVersion, err)
+	default:
+		return err
+	}
 }
 
 func newWorkflowWorkerInternal(
@@ -295,6 +334,9 @@ func newWorkflowTaskWorkerInternal(
 		workerType:        "DecisionWorker",
 		shutdownTimeout:   params.WorkerStopTimeout,
 		pollerTracker:     params.WorkerStats.PollerTracker,
+		fatalErrorHandler: params.FatalErrorHandler,
+		maxPollDuration:   livenessThreshold,
+		maxTaskDuration:   livenessThreshold,
 	},
 		params.Logger,
 		params.MetricsScope,
@@ -310,7 +352,7 @@ func newWorkflowTaskWorkerInternal(
 	}
 
 	// 2) local activity task poller will poll from laTunnel, and result will be pushed to laTunnel
-	localActivityTaskPoller := newLocalActivityPoller(params, laTunnel)
+	localActivityTaskPoller := newLocalActivityPoller(params, laTunnel, service)
 	localActivityWorker := newBaseWorker(baseWorkerOptions{
 		pollerCount:       1, // 1 poller (from local channel) is enough for local activity
 		maxConcurrentTask: params.MaxConcurrentLocalActivityExecutionSize,
@@ -466,6 +508,15 @@ func newActivityWorker(
 	return newActivityTaskWorker(service, domain, params, sessionTokenBucket, workerStopChannel, taskPoller, workerType)
 }
 
+// shareActivityTaskLimits makes w take the execution slots and the rate limit of primary, so that the activity
+// workers of the task lists a worker polls stay within its MaxConcurrentActivityExecutionSize and
+// WorkerActivitiesPerSecond together. w must not be started yet.
+func shareActivityTaskLimits(w *activityWorker, primary *activityWorker) *activityWorker {
+	w.worker.concurrency.TaskPermit = primary.worker.concurrency.TaskPermit
+	w.worker.taskLimiter = primary.worker.taskLimiter
+	return w
+}
+
 func newActivityTaskWorker(
 	service workflowserviceclient.Interface,
 	domain string,
@@ -476,6 +527,16 @@ func newActivityTaskWorker(
 	workerType string,
 ) (worker *activityWorker) {
 	ensureRequiredParams(&workerParams)
+	// only polls to the service are bounded, activities may legitimately run for a long time
+	var maxPollDuration time.Duration
+	if _, ok := poller.(*activityTaskPoller); ok {
+		maxPollDuration = livenessThreshold
+	}
+	// the store is only consulted when the activities of the task list are limited
+	var rateLimitStore RateLimitStore
+	if workerParams.WorkerActivitiesPerSecond != defaultWorkerActivitiesPerSecond {
+		rateLimitStore = workerParams.ActivityRateLimitStore
+	}
 	base := newBaseWorker(
 		baseWorkerOptions{
 			pollerAutoScaler: pollerAutoScalerOptions{
@@ -497,6 +558,11 @@ func newActivityTaskWorker(
 			shutdownTimeout:   workerParams.WorkerStopTimeout,
 			userContextCancel: workerParams.UserContextCancel,
 			pollerTracker:     workerParams.WorkerStats.PollerTracker,
+			fatalErrorHandler: workerParams.FatalErrorHandler,
+			maxPollDuration:   maxPollDuration,
+
+			taskRateLimitStore: rateLimitStore,
+			taskRateLimitKey:   activityRateLimitKey(domain, workerParams.TaskList),
 		},
 
 		workerParams.Logger,
@@ -612,7 +678,7 @@ func decodeArgsToValues(dc DataConverter, fnType reflect.Type, data []byte) (res
 argsLoop:
 	for i := 0; i < fnType.NumIn(); i++ {
 		argT := fnType.In(i)
-		if i == 0 && (isActivityContext(argT) || isWorkflowContext(argT)) {
+		if i == 0 && (isActivityContext(argT) || isWorkflowContext(argT) || isQueryContext(argT)) {
 			continue argsLoop
 		}
 		arg := reflect.New(argT).Interface()
@@ -798,11 +864,21 @@ type aggregatedWorker struct {
 	workflowWorker                  *workflowWorker
 	activityWorker                  *activityWorker
 	locallyDispatchedActivityWorker *activityWorker
+	taskListActivityWorkers         []*activityWorker // polling the priority and additional task lists
 	sessionWorker                   *sessionWorker
 	shadowWorker                    *shadowWorker
+	registryPublisher               *workerRegistryPublisher
 	logger                          *zap.Logger
 	registry                        *registry
 	workerstats                     debug.WorkerStats
+	fatalErrors                     *fatalErrorNotifier
+	service                         workflowserviceclient.Interface
+	domain                          string
+	featureFlags                    FeatureFlags
+	requiredCapabilities            []ServiceCapability
+	onStartHooks                    []WorkerHook
+	onStopHooks                     []WorkerHook
+	hooksStarted                    bool
 }
 
 var _ debug.Debugger = &aggregatedWorker{}
@@ -842,10 +918,32 @@ func (aw *aggregatedWorker) RegisterActivityWithOptions(a interface{}, options R
 }
 
 func (aw *aggregatedWorker) Start() error {
+	if err := aw.runStartHooks(context.Background()); err != nil {
+		return err
+	}
+	if err := aw.startPollers(); err != nil {
+		aw.runStopHooks()
+		return err
+	}
+	return nil
+}
+
+func (aw *aggregatedWorker) startPollers() error {
 	if _, err := initBinaryChecksum(); err != nil {
 		return fmt.Errorf("failed to get executable checksum: %v", err)
 	}
 
+	if len(aw.requiredCapabilities) > 0 {
+		// workers verify the domain when they start, verify it first here so that
+		// a missing domain is not reported as a missing capability
+		if err := verifyDomainExist(aw.service, aw.domain, aw.logger, aw.featureFlags); err != nil {
+			return err
+		}
+		if err := verifyServiceCapabilities(aw.service, aw.domain, aw.requiredCapabilities, aw.logger, aw.featureFlags); err != nil {
+			return err
+		}
+	}
+
 	if aw.workflowWorker != nil {
 		if len(aw.registry.GetRegisteredWorkflowTypes()) == 0 {
 			aw.logger.Info(
@@ -881,6 +979,21 @@ func (aw *aggregatedWorker) Start() error {
 					return err
 				}
 			}
+			for i, worker := range aw.taskListActivityWorkers {
+				if err := worker.Start(); err != nil {
+					if aw.workflowWorker != nil {
+						aw.workflowWorker.Stop()
+					}
+					aw.activityWorker.Stop()
+					if aw.locallyDispatchedActivityWorker != nil {
+						aw.locallyDispatchedActivityWorker.Stop()
+					}
+					for _, started := range aw.taskListActivityWorkers[:i] {
+						started.Stop()
+					}
+					return err
+				}
+			}
 			aw.logger.Info("Started Activity Worker")
 		}
 	}
@@ -897,6 +1010,9 @@ func (aw *aggregatedWorker) Start() error {
 			if aw.locallyDispatchedActivityWorker != nil {
 				aw.locallyDispatchedActivityWorker.Stop()
 			}
+			for _, worker := range aw.taskListActivityWorkers {
+				worker.Stop()
+			}
 			return err
 		}
 		aw.logger.Info("Started Session Worker")
@@ -913,6 +1029,9 @@ func (aw *aggregatedWorker) Start() error {
 			if aw.locallyDispatchedActivityWorker != nil {
 				aw.locallyDispatchedActivityWorker.Stop()
 			}
+			for _, worker := range aw.taskListActivityWorkers {
+				worker.Stop()
+			}
 			if aw.sessionWorker != nil {
 				aw.sessionWorker.Stop()
 			}
@@ -921,6 +1040,10 @@ func (aw *aggregatedWorker) Start() error {
 		aw.logger.Info("Started Shadow Worker")
 	}
 
+	if aw.registryPublisher != nil {
+		aw.registryPublisher.Start()
+	}
+
 	return nil
 }
 
@@ -983,16 +1106,24 @@ func getBinaryChecksum() string {
 }
 
 func (aw *aggregatedWorker) Run() error {
-	if err := aw.Start(); err != nil {
-		return err
-	}
-	d := <-getKillSignal()
-	aw.logger.Info("Worker has been killed", zap.String("Signal", d.String()))
-	aw.Stop()
-	return nil
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case d := <-getKillSignal():
+			aw.logger.Info("Worker has been killed", zap.String("Signal", d.String()))
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return aw.RunContext(ctx)
 }
 
 func (aw *aggregatedWorker) Stop() {
+	if aw.registryPublisher != nil {
+		// deregister first, so that the worker is not discovered while it stops
+		aw.registryPublisher.Stop()
+	}
 	if aw.workflowWorker != nil {
 		aw.workflowWorker.Stop()
 	}
@@ -1002,12 +1133,16 @@ func (aw *aggregatedWorker) Stop() {
 	if aw.locallyDispatchedActivityWorker != nil {
 		aw.locallyDispatchedActivityWorker.Stop()
 	}
+	for _, worker := range aw.taskListActivityWorkers {
+		worker.Stop()
+	}
 	if aw.sessionWorker != nil {
 		aw.sessionWorker.Stop()
 	}
 	if aw.shadowWorker != nil {
 		aw.shadowWorker.Stop()
 	}
+	aw.runStopHooks()
 	aw.logger.Info("Stopped Worker")
 }
 
@@ -1034,12 +1169,14 @@ func newAggregatedWorker(
 		ctx = context.Background()
 	}
 	backgroundActivityContext, backgroundActivityContextCancel := context.WithCancel(ctx)
+	fatalErrors := &fatalErrorNotifier{}
 
 	workerParams := workerExecutionParameters{
 		WorkerOptions:     wOptions,
 		TaskList:          taskList,
 		UserContext:       backgroundActivityContext,
 		UserContextCancel: backgroundActivityContextCancel,
+		FatalErrorHandler: fatalErrors.notify,
 	}
 
 	ensureRequiredParams(&workerParams)
@@ -1066,6 +1203,7 @@ func newAggregatedWorker(
 	var ldaTunnel *locallyDispatchedActivityTunnel
 
 	// activity types.
+	var taskListActivityWorkers []*activityWorker
 	var activityWorker, locallyDispatchedActivityWorker *activityWorker
 
 	if !wOptions.DisableActivityWorker {
@@ -1092,6 +1230,10 @@ func newAggregatedWorker(
 			ldaTunnel = locallyDispatchedActivityWorker.poller.(*locallyDispatchedActivityTaskPoller).ldaTunnel
 			ldaTunnel.metricsScope = metrics.NewTaggedScope(workerParams.MetricsScope)
 		}
+		taskListActivityWorkers = append(
+			newPriorityActivityWorkers(service, domain, workerParams, registry, activityWorker),
+			newAdditionalActivityWorkers(service, domain, workerParams, registry, activityWorker)...,
+		)
 	}
 
 	// workflow factory.
@@ -1149,15 +1291,27 @@ func newAggregatedWorker(
 		)
 	}
 
+	var registryPublisher *workerRegistryPublisher
+	if wOptions.WorkerRegistry != nil {
+		registryPublisher = newWorkerRegistryPublisher(domain, taskList, workerParams, registry)
+	}
+
 	return &aggregatedWorker{
 		workflowWorker:                  workflowWorker,
 		activityWorker:                  activityWorker,
 		locallyDispatchedActivityWorker: locallyDispatchedActivityWorker,
+		taskListActivityWorkers:         taskListActivityWorkers,
 		sessionWorker:                   sessionWorker,
 		shadowWorker:                    shadowWorker,
+		registryPublisher:               registryPublisher,
 		logger:                          logger,
 		registry:                        registry,
 		workerstats:                     workerParams.WorkerStats,
+		fatalErrors:                     fatalErrors,
+		service:                         service,
+		domain:                          domain,
+		featureFlags:                    wOptions.FeatureFlags,
+		requiredCapabilities:            wOptions.RequiredServiceCapabilities,
 	}, nil
 }
 
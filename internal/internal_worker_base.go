@@ -35,6 +35,7 @@ import (
 	"go.uber.org/cadence/internal/common/debug"
 	"go.uber.org/cadence/internal/worker"
 
+	"github.com/opentracing/opentracing-go"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -80,6 +81,7 @@ type (
 		WorkflowInfo() *WorkflowInfo
 		Complete(result []byte, err error)
 		RegisterCancelHandler(handler func())
+		GetCancellationDetails() *CancellationDetails
 		RequestCancelChildWorkflow(domainName, workflowID string)
 		RequestCancelExternalWorkflow(domainName, workflowID, runID string, callback resultHandler)
 		ExecuteChildWorkflow(params executeWorkflowParams, callback resultHandler, startedHandler func(r WorkflowExecution, e error)) error
@@ -97,6 +99,12 @@ type (
 		UpsertSearchAttributes(attributes map[string]interface{}) error
 		GetRegistry() *registry
 		GetWorkflowInterceptors() []WorkflowInterceptorFactory
+		GetPayloadRedactor() PayloadRedactor
+		GetNamingPolicies() NamingPolicies
+		GetTracer() opentracing.Tracer
+		GetYieldRecorder() *yieldRecorder
+		GetChangeVersions() map[string]Version
+		GetDecisionTimeRemaining() time.Duration
 	}
 
 	// WorkflowDefinition wraps the code that can execute a workflow.
@@ -126,6 +134,15 @@ type (
 		userContextCancel context.CancelFunc
 		host              string
 		pollerTracker     debug.PollerTracker
+		fatalErrorHandler func(error)
+		// maxPollDuration and maxTaskDuration bound how long a poll or a task may be in flight before
+		// the worker fails its liveness check, zero disables the check.
+		maxPollDuration time.Duration
+		maxTaskDuration time.Duration
+		// taskRateLimitStore enforces maxTaskPerSecond across the workers sharing it under taskRateLimitKey,
+		// nil enforces it per worker.
+		taskRateLimitStore RateLimitStore
+		taskRateLimitKey   string
 	}
 
 	// baseWorker that wraps worker activities.
@@ -135,7 +152,7 @@ type (
 		shutdownCh           chan struct{}  // Channel used to shut down the go routines.
 		shutdownWG           sync.WaitGroup // The WaitGroup for shutting down existing routines.
 		pollLimiter          *rate.Limiter
-		taskLimiter          *rate.Limiter
+		taskLimiter          taskRateLimiter
 		limiterContext       context.Context
 		limiterContextCancel func()
 		retrier              *backoff.ConcurrentRetrier // Service errors back off retrier
@@ -146,6 +163,8 @@ type (
 		pollerAutoScaler   *pollerAutoScaler
 		taskQueueCh        chan interface{}
 		sessionTokenBucket *sessionTokenBucket
+		inflightPolls      inflightTracker
+		inflightTasks      inflightTracker
 	}
 
 	polledTask struct {
@@ -185,7 +204,7 @@ func newBaseWorker(options baseWorkerOptions, logger *zap.Logger, metricsScope t
 	bw := &baseWorker{
 		options:              options,
 		shutdownCh:           make(chan struct{}),
-		taskLimiter:          rate.NewLimiter(rate.Limit(options.maxTaskPerSecond), 1),
+		taskLimiter:          newTaskRateLimiter(options, logger),
 		retrier:              backoff.NewConcurrentRetrier(pollOperationRetryPolicy),
 		logger:               logger.With(zapcore.Field{Key: tagWorkerType, Type: zapcore.StringType, String: options.workerType}),
 		metricsScope:         tagScope(metricsScope, tagWorkerType, options.workerType),
@@ -311,15 +330,21 @@ func (bw *baseWorker) pollTask() {
 
 	bw.retrier.Throttle()
 	if bw.pollLimiter == nil || bw.pollLimiter.Wait(bw.limiterContext) == nil {
+		pollDone := bw.inflightPolls.begin(time.Now())
 		task, err = bw.options.taskWorker.PollTask()
+		pollDone()
 		if err != nil && enableVerboseLogging {
 			bw.logger.Debug("Failed to poll for task.", zap.Error(err))
 		}
 		if err != nil {
 			if isNonRetriableError(err) {
 				bw.logger.Error("Worker received non-retriable error. Shutting down.", zap.Error(err))
-				p, _ := os.FindProcess(os.Getpid())
-				p.Signal(syscall.SIGINT)
+				if bw.options.fatalErrorHandler != nil {
+					bw.options.fatalErrorHandler(err)
+				} else {
+					p, _ := os.FindProcess(os.Getpid())
+					p.Signal(syscall.SIGINT)
+				}
 				return
 			}
 			bw.retrier.Failed()
@@ -359,6 +384,7 @@ func isNonRetriableError(err error) bool {
 
 func (bw *baseWorker) processTask(task interface{}) {
 	defer bw.shutdownWG.Done()
+	defer bw.inflightTasks.begin(time.Now())()
 	// If the task is from poller, after processing it we would need to request a new poll. Otherwise, the task is from
 	// local activity worker, we don't need a new poll from server.
 	polledTask, isPolledTask := task.(*polledTask)
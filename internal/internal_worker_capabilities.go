@@ -0,0 +1,159 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/backoff"
+)
+
+// ServiceCapability is an optional feature of the Cadence service a worker may depend on,
+// see WorkerOptions.RequiredServiceCapabilities.
+type ServiceCapability string
+
+const (
+	// ServiceCapabilityAdvancedVisibility is required by workflows relying on search attributes, list and count
+	// queries, or by Worker.Validate.
+	ServiceCapabilityAdvancedVisibility ServiceCapability = "advanced-visibility"
+	// ServiceCapabilityAsyncAPIs is required by workflows starting or signaling other workflows asynchronously,
+	// e.g. with Client.StartWorkflowAsync.
+	ServiceCapabilityAsyncAPIs ServiceCapability = "async-apis"
+)
+
+// defaults of the blob size limits enforced by the Cadence server, see ClusterInfo
+const (
+	defaultServerBlobSizeLimit     = 2 * 1024 * 1024
+	defaultServerBlobSizeWarnLimit = 512 * 1024
+)
+
+// serviceCapabilities lists all known capabilities in the order they are probed by Client.GetClusterInfo.
+var serviceCapabilities = []ServiceCapability{
+	ServiceCapabilityAdvancedVisibility,
+	ServiceCapabilityAsyncAPIs,
+}
+
+// ServiceCapabilityError is returned by Worker.Start when the Cadence service does not provide one of
+// WorkerOptions.RequiredServiceCapabilities.
+type ServiceCapabilityError struct {
+	Capability ServiceCapability
+	Err        error
+}
+
+var serviceCapabilityHints = map[ServiceCapability]string{
+	ServiceCapabilityAdvancedVisibility: "enable advanced visibility (ElasticSearch, OpenSearch or Pinot) on the Cadence cluster",
+	ServiceCapabilityAsyncAPIs:          "upgrade the Cadence frontend and configure an async workflow queue for the domain",
+}
+
+// Error from error interface
+func (e *ServiceCapabilityError) Error() string {
+	return fmt.Sprintf("Cadence service does not support %v, %v: %v", e.Capability, serviceCapabilityHints[e.Capability], e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ServiceCapabilityError) Unwrap() error {
+	return e.Err
+}
+
+// verifyServiceCapabilities probes the service for each of the capabilities and returns a *ServiceCapabilityError for
+// the first one which is missing. Errors which do not tell whether a capability is supported, e.g. transient
+// connection errors, are logged and ignored, like in verifyDomainExist.
+func verifyServiceCapabilities(
+	service workflowserviceclient.Interface,
+	domain string,
+	capabilities []ServiceCapability,
+	logger *zap.Logger,
+	featureFlags FeatureFlags,
+) error {
+	ctx := context.Background()
+	for _, capability := range capabilities {
+		err := probeServiceCapability(ctx, service, domain, capability, featureFlags)
+		if err == nil {
+			continue
+		}
+		if isMissingServiceCapabilityError(err) {
+			logger.Error("Cadence service does not support a required capability", zap.String("Capability", string(capability)), zap.Error(err))
+			return &ServiceCapabilityError{Capability: capability, Err: err}
+		}
+		logger.Warn("unable to verify Cadence service capability", zap.String("Capability", string(capability)), zap.Error(err))
+	}
+	return nil
+}
+
+// probeServiceCapability calls an API of the service which requires the capability, retrying transient errors.
+// It returns nil if the capability is supported, and an error matching isMissingServiceCapabilityError if it is not.
+func probeServiceCapability(
+	ctx context.Context,
+	service workflowserviceclient.Interface,
+	domain string,
+	capability ServiceCapability,
+	featureFlags FeatureFlags,
+) error {
+	var probe func() error
+	switch capability {
+	case ServiceCapabilityAdvancedVisibility:
+		probe = func() error {
+			tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
+			defer cancel()
+			_, err := service.CountWorkflowExecutions(tchCtx, &shared.CountWorkflowExecutionsRequest{
+				Domain: common.StringPtr(domain),
+			}, opt...)
+			return err
+		}
+	case ServiceCapabilityAsyncAPIs:
+		probe = func() error {
+			tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
+			defer cancel()
+			// the request lacks a workflow ID, so it is rejected as a bad request by frontends supporting async APIs
+			_, err := service.StartWorkflowExecutionAsync(tchCtx, &shared.StartWorkflowExecutionAsyncRequest{
+				Request: &shared.StartWorkflowExecutionRequest{Domain: common.StringPtr(domain)},
+			}, opt...)
+			if _, ok := err.(*shared.BadRequestError); ok {
+				return nil
+			}
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown service capability %q", capability)
+	}
+
+	return backoff.Retry(ctx, probe, createDynamicServiceRetryPolicy(ctx), func(err error) bool {
+		return !isMissingServiceCapabilityError(err) && isServiceTransientError(err)
+	})
+}
+
+func isMissingServiceCapabilityError(err error) bool {
+	if target := (*shared.BadRequestError)(nil); errors.As(err, &target) {
+		return true
+	}
+	if target := (*shared.FeatureNotEnabledError)(nil); errors.As(err, &target) {
+		return true
+	}
+	return yarpcerrors.IsUnimplemented(err)
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common/testlogger"
+)
+
+func TestVerifyDomainExist_ActionableErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *shared.DescribeDomainResponse
+		err      error
+		contains string
+	}{
+		{
+			name:     "domain does not exist",
+			err:      &shared.EntityNotExistsError{Message: "not found"},
+			contains: "register it with `cadence --domain testDomain domain register`",
+		},
+		{
+			name:     "client version not supported",
+			err:      &shared.ClientVersionNotSupportedError{},
+			contains: "upgrade go.uber.org/cadence",
+		},
+		{
+			name:     "domain deleted",
+			response: &shared.DescribeDomainResponse{DomainInfo: &shared.DomainInfo{Status: shared.DomainStatusDeleted.Ptr()}},
+			contains: `domain "testDomain": domain is deleted`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := workflowservicetest.NewMockClient(gomock.NewController(t))
+			service.EXPECT().DescribeDomain(gomock.Any(), gomock.Any(), callOptions()...).Return(tt.response, tt.err).Times(1)
+
+			err := verifyDomainExist(service, "testDomain", zap.NewNop(), FeatureFlags{})
+			assert.ErrorContains(t, err, tt.contains)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerifyServiceCapabilities(t *testing.T) {
+	tests := []struct {
+		name       string
+		capability ServiceCapability
+		setup      func(service *workflowservicetest.MockClient)
+		missing    bool
+	}{
+		{
+			name:       "advanced visibility supported",
+			capability: ServiceCapabilityAdvancedVisibility,
+			setup: func(service *workflowservicetest.MockClient) {
+				service.EXPECT().CountWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.CountWorkflowExecutionsResponse{}, nil)
+			},
+		},
+		{
+			name:       "advanced visibility not supported",
+			capability: ServiceCapabilityAdvancedVisibility,
+			setup: func(service *workflowservicetest.MockClient) {
+				service.EXPECT().CountWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).Return(nil, &shared.BadRequestError{})
+			},
+			missing: true,
+		},
+		{
+			name:       "async APIs supported",
+			capability: ServiceCapabilityAsyncAPIs,
+			setup: func(service *workflowservicetest.MockClient) {
+				service.EXPECT().StartWorkflowExecutionAsync(gomock.Any(), gomock.Any(), callOptions()...).Return(nil, &shared.BadRequestError{})
+			},
+		},
+		{
+			name:       "async APIs not implemented",
+			capability: ServiceCapabilityAsyncAPIs,
+			setup: func(service *workflowservicetest.MockClient) {
+				service.EXPECT().StartWorkflowExecutionAsync(gomock.Any(), gomock.Any(), callOptions()...).Return(nil, yarpcerrors.UnimplementedErrorf("unknown method"))
+			},
+			missing: true,
+		},
+		{
+			name:       "async APIs not enabled",
+			capability: ServiceCapabilityAsyncAPIs,
+			setup: func(service *workflowservicetest.MockClient) {
+				service.EXPECT().StartWorkflowExecutionAsync(gomock.Any(), gomock.Any(), callOptions()...).Return(nil, &shared.FeatureNotEnabledError{})
+			},
+			missing: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := workflowservicetest.NewMockClient(gomock.NewController(t))
+			tt.setup(service)
+
+			err := verifyServiceCapabilities(service, "testDomain", []ServiceCapability{tt.capability}, zap.NewNop(), FeatureFlags{})
+			if !tt.missing {
+				assert.NoError(t, err)
+				return
+			}
+			var capabilityErr *ServiceCapabilityError
+			require.True(t, errors.As(err, &capabilityErr))
+			assert.Equal(t, tt.capability, capabilityErr.Capability)
+			assert.Contains(t, err.Error(), serviceCapabilityHints[tt.capability])
+		})
+	}
+}
+
+func TestWorkerStartFailsWithMissingCapability(t *testing.T) {
+	service := workflowservicetest.NewMockClient(gomock.NewController(t))
+	service.EXPECT().DescribeDomain(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.DescribeDomainResponse{}, nil).AnyTimes()
+	service.EXPECT().CountWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).Return(nil, &shared.BadRequestError{})
+	w, err := NewWorker(service, "testDomain", "testTaskList", WorkerOptions{
+		Logger:                      testlogger.NewZap(t),
+		DisableActivityWorker:       true,
+		RequiredServiceCapabilities: []ServiceCapability{ServiceCapabilityAdvancedVisibility},
+	})
+	require.NoError(t, err)
+	w.RegisterWorkflow(testReplayWorkflow)
+
+	var capabilityErr *ServiceCapabilityError
+	assert.True(t, errors.As(w.Start(), &capabilityErr))
+}
+
+func TestWorkerOptionsValidate_UnknownCapability(t *testing.T) {
+	err := WorkerOptions{RequiredServiceCapabilities: []ServiceCapability{"grpc"}}.Validate()
+	assert.EqualError(t, err, `unknown service capability "grpc" in RequiredServiceCapabilities`)
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+// livenessThreshold is how long a poll or a decision task may be in flight before the worker is considered
+// deadlocked. Polls are bounded by pollTaskServiceTimeOut, and decision tasks should complete within seconds.
+const livenessThreshold = 2 * pollTaskServiceTimeOut
+
+var errWorkerNotStarted = errors.New("worker is not started")
+
+type (
+	// inflightTracker keeps the start times of the operations in flight, e.g. polls or tasks being processed.
+	inflightTracker struct {
+		sync.Mutex
+		nextID  uint64
+		started map[uint64]time.Time
+	}
+)
+
+// begin records the start of an operation and returns the function to call once it is done.
+func (t *inflightTracker) begin(now time.Time) func() {
+	t.Lock()
+	defer t.Unlock()
+	if t.started == nil {
+		t.started = make(map[uint64]time.Time)
+	}
+	id := t.nextID
+	t.nextID++
+	t.started[id] = now
+	return func() {
+		t.Lock()
+		defer t.Unlock()
+		delete(t.started, id)
+	}
+}
+
+// oldest returns the start time of the oldest operation in flight, if any.
+func (t *inflightTracker) oldest() (time.Time, bool) {
+	t.Lock()
+	defer t.Unlock()
+	var oldest time.Time
+	for _, started := range t.started {
+		if oldest.IsZero() || started.Before(oldest) {
+			oldest = started
+		}
+	}
+	return oldest, !oldest.IsZero()
+}
+
+// checkLiveness returns an error if a poll or task has been in flight for longer than the worker allows.
+func (bw *baseWorker) checkLiveness(now time.Time) error {
+	if limit := bw.options.maxPollDuration; limit > 0 {
+		if started, ok := bw.inflightPolls.oldest(); ok && now.Sub(started) > limit {
+			return fmt.Errorf("%v poll has been in flight for %v", bw.options.workerType, now.Sub(started))
+		}
+	}
+	if limit := bw.options.maxTaskDuration; limit > 0 {
+		if started, ok := bw.inflightTasks.oldest(); ok && now.Sub(started) > limit {
+			return fmt.Errorf("%v task has been processing for %v", bw.options.workerType, now.Sub(started))
+		}
+	}
+	return nil
+}
+
+// baseWorkers returns the base workers of all the workers hosted by the aggregated worker.
+func (aw *aggregatedWorker) baseWorkers() []*baseWorker {
+	var workers []*baseWorker
+	if aw.workflowWorker != nil {
+		workers = append(workers, aw.workflowWorker.worker, aw.workflowWorker.localActivityWorker)
+	}
+	if aw.activityWorker != nil {
+		workers = append(workers, aw.activityWorker.worker)
+	}
+	if aw.locallyDispatchedActivityWorker != nil {
+		workers = append(workers, aw.locallyDispatchedActivityWorker.worker)
+	}
+	if aw.sessionWorker != nil {
+		workers = append(workers, aw.sessionWorker.creationWorker.worker, aw.sessionWorker.activityWorker.worker)
+	}
+	if aw.shadowWorker != nil {
+		workers = append(workers, aw.shadowWorker.activityWorker.worker)
+	}
+	return workers
+}
+
+// CheckReadiness returns nil if the worker is ready to process tasks: its pollers are running, and the Cadence
+// frontend is reachable and confirms that the worker's domain is registered.
+func (aw *aggregatedWorker) CheckReadiness(ctx context.Context) error {
+	started := 0
+	for _, bw := range aw.baseWorkers() {
+		if !bw.isWorkerStarted {
+			continue
+		}
+		if bw.isShutdown() {
+			return fmt.Errorf("%v is stopped", bw.options.workerType)
+		}
+		started++
+	}
+	if started == 0 {
+		return errWorkerNotStarted
+	}
+
+	tchCtx, cancel, opt := newChannelContext(ctx, aw.featureFlags)
+	defer cancel()
+	response, err := aw.service.DescribeDomain(tchCtx, &shared.DescribeDomainRequest{Name: &aw.domain}, opt...)
+	if err != nil {
+		return fmt.Errorf("failed to describe domain %v: %w", aw.domain, err)
+	}
+	if status := response.GetDomainInfo().GetStatus(); status != shared.DomainStatusRegistered {
+		return fmt.Errorf("domain %v is %v", aw.domain, status)
+	}
+	return nil
+}
+
+// CheckLiveness returns nil unless the worker is deadlocked: a poll is stuck for much longer than the long poll
+// timeout, or a decision task has been processing for minutes. Long running activities do not affect liveness.
+func (aw *aggregatedWorker) CheckLiveness(ctx context.Context) error {
+	now := time.Now()
+	for _, bw := range aw.baseWorkers() {
+		if !bw.isWorkerStarted || bw.isShutdown() {
+			continue
+		}
+		if err := bw.checkLiveness(now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewHealthHandler returns an http.Handler which responds with 200 if check succeeds, and with 503 and the error
+// otherwise, e.g. to serve Worker.CheckReadiness and Worker.CheckLiveness to Kubernetes probes.
+func NewHealthHandler(check func(ctx context.Context) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := check(r.Context()); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, err.Error())
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+func TestWorkerHealthChecks(t *testing.T) {
+	service := workflowservicetest.NewMockClient(gomock.NewController(t))
+	service.EXPECT().PollForDecisionTask(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.PollForDecisionTaskResponse{}, nil).AnyTimes()
+	w := newRunTestWorker(t, service, nil)
+	ctx := context.Background()
+
+	assert.Equal(t, errWorkerNotStarted, w.CheckReadiness(ctx))
+	assert.NoError(t, w.CheckLiveness(ctx))
+
+	require.NoError(t, w.Start())
+	assert.NoError(t, w.CheckReadiness(ctx))
+	assert.NoError(t, w.CheckLiveness(ctx))
+
+	// a decision task stuck for longer than the threshold fails the liveness check
+	done := w.workflowWorker.worker.inflightTasks.begin(time.Now().Add(-livenessThreshold - time.Second))
+	assert.ErrorContains(t, w.CheckLiveness(ctx), "DecisionWorker task has been processing for")
+	done()
+	assert.NoError(t, w.CheckLiveness(ctx))
+
+	w.Stop()
+	assert.ErrorContains(t, w.CheckReadiness(ctx), "is stopped")
+}
+
+func TestWorkerHealthChecks_DomainNotRegistered(t *testing.T) {
+	service := workflowservicetest.NewMockClient(gomock.NewController(t))
+	service.EXPECT().PollForDecisionTask(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.PollForDecisionTaskResponse{}, nil).AnyTimes()
+	service.EXPECT().DescribeDomain(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.DescribeDomainResponse{
+		DomainInfo: &shared.DomainInfo{Status: shared.DomainStatusDeprecated.Ptr()},
+	}, nil).AnyTimes()
+	w := newRunTestWorker(t, service, nil)
+
+	require.NoError(t, w.Start())
+	defer w.Stop()
+	assert.EqualError(t, w.CheckReadiness(context.Background()), "domain testDomain is DEPRECATED")
+}
+
+func TestInflightTracker(t *testing.T) {
+	var tracker inflightTracker
+	_, ok := tracker.oldest()
+	assert.False(t, ok)
+
+	now := time.Now()
+	done1 := tracker.begin(now)
+	done2 := tracker.begin(now.Add(-time.Minute))
+	oldest, ok := tracker.oldest()
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-time.Minute), oldest)
+
+	done2()
+	oldest, _ = tracker.oldest()
+	assert.Equal(t, now, oldest)
+	done1()
+	_, ok = tracker.oldest()
+	assert.False(t, ok)
+}
+
+func TestNewHealthHandler(t *testing.T) {
+	var checkErr error
+	handler := NewHealthHandler(func(ctx context.Context) error { return checkErr })
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "ok\n", recorder.Body.String())
+
+	checkErr = errors.New("not ready")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "not ready\n", recorder.Body.String())
+}
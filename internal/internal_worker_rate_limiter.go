@@ -0,0 +1,92 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+type (
+	// taskRateLimiter paces the tasks processed by a base worker.
+	taskRateLimiter interface {
+		Wait(ctx context.Context) error
+	}
+
+	// sharedTaskRateLimiter takes the permits of a RateLimitStore shared with other workers, and falls back to the
+	// limiter of the worker while the store fails.
+	sharedTaskRateLimiter struct {
+		store     RateLimitStore
+		key       string
+		perSecond float64
+		fallback  *rate.Limiter
+		logger    *zap.Logger
+	}
+)
+
+var _ taskRateLimiter = (*rate.Limiter)(nil)
+
+func newTaskRateLimiter(options baseWorkerOptions, logger *zap.Logger) taskRateLimiter {
+	limiter := rate.NewLimiter(rate.Limit(options.maxTaskPerSecond), 1)
+	if options.taskRateLimitStore == nil {
+		return limiter
+	}
+	return &sharedTaskRateLimiter{
+		store:     options.taskRateLimitStore,
+		key:       options.taskRateLimitKey,
+		perSecond: options.maxTaskPerSecond,
+		fallback:  limiter,
+		logger:    logger,
+	}
+}
+
+func (l *sharedTaskRateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay, err := l.store.Take(ctx, l.key, l.perSecond)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			l.logger.Warn("Rate limit store failed, falling back to the worker rate limit.",
+				zap.String(tagRateLimitKey, l.key), zap.Error(err))
+			return l.fallback.Wait(ctx)
+		}
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// activityRateLimitKey is the key of the activity rate limit of a task list in a RateLimitStore.
+func activityRateLimitKey(domain string, taskList string) string {
+	return "cadence-activities/" + domain + "/" + taskList
+}
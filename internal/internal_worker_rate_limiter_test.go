@@ -0,0 +1,119 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/time/rate"
+)
+
+type fakeRateLimitStore struct {
+	sync.Mutex
+	delays []time.Duration // returned by the successive calls to Take, 0 once exhausted
+	err    error
+	keys   []string
+	rates  []float64
+}
+
+func (s *fakeRateLimitStore) Take(ctx context.Context, key string, perSecond float64) (time.Duration, error) {
+	s.Lock()
+	defer s.Unlock()
+	s.keys = append(s.keys, key)
+	s.rates = append(s.rates, perSecond)
+	if s.err != nil {
+		return 0, s.err
+	}
+	if len(s.delays) == 0 {
+		return 0, nil
+	}
+	delay := s.delays[0]
+	s.delays = s.delays[1:]
+	return delay, nil
+}
+
+func TestSharedTaskRateLimiter(t *testing.T) {
+	newLimiter := func(store *fakeRateLimitStore) taskRateLimiter {
+		return newTaskRateLimiter(baseWorkerOptions{
+			maxTaskPerSecond:   5,
+			taskRateLimitStore: store,
+			taskRateLimitKey:   "key",
+		}, zaptest.NewLogger(t))
+	}
+
+	t.Run("per worker without a store", func(t *testing.T) {
+		limiter := newTaskRateLimiter(baseWorkerOptions{maxTaskPerSecond: 5}, zaptest.NewLogger(t))
+		assert.IsType(t, &rate.Limiter{}, limiter)
+	})
+
+	t.Run("waits for the store", func(t *testing.T) {
+		store := &fakeRateLimitStore{delays: []time.Duration{10 * time.Millisecond, 10 * time.Millisecond}}
+		start := time.Now()
+		require.NoError(t, newLimiter(store).Wait(context.Background()))
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		assert.Equal(t, []string{"key", "key", "key"}, store.keys)
+		assert.Equal(t, []float64{5, 5, 5}, store.rates)
+	})
+
+	t.Run("falls back to the worker limit", func(t *testing.T) {
+		store := &fakeRateLimitStore{err: errors.New("store is down")}
+		limiter := newLimiter(store)
+		require.NoError(t, limiter.Wait(context.Background()))
+		assert.False(t, limiter.(*sharedTaskRateLimiter).fallback.Allow(), "the fallback limiter took the permit")
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		store := &fakeRateLimitStore{delays: []time.Duration{time.Hour}}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, newLimiter(store).Wait(ctx))
+	})
+}
+
+func TestActivityWorker_RateLimitStore(t *testing.T) {
+	store := &fakeRateLimitStore{}
+	newWorker := func(perSecond float64) *activityWorker {
+		params := workerExecutionParameters{
+			TaskList: "tl",
+			WorkerOptions: WorkerOptions{
+				WorkerActivitiesPerSecond: perSecond,
+				ActivityRateLimitStore:    store,
+				Logger:                    zaptest.NewLogger(t),
+			},
+		}
+		return newActivityWorker(nil, "domain", params, nil, newRegistry(), nil)
+	}
+
+	limiter, ok := newWorker(10).worker.taskLimiter.(*sharedTaskRateLimiter)
+	require.True(t, ok)
+	assert.Equal(t, "cadence-activities/domain/tl", limiter.key)
+	assert.Equal(t, float64(10), limiter.perSecond)
+
+	assert.IsType(t, &rate.Limiter{}, newWorker(defaultWorkerActivitiesPerSecond).worker.taskLimiter,
+		"the store is not used when the activities are not limited")
+}
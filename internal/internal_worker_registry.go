@@ -0,0 +1,285 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultWorkerRegistryHeartbeatInterval = 30 * time.Second
+
+	// workerRegistrationExpiryIntervals is the number of heartbeat intervals after which a registration which was not
+	// refreshed is considered to belong to a dead worker.
+	workerRegistrationExpiryIntervals = 3
+
+	workerRegistryDeregisterTimeout = 10 * time.Second
+)
+
+type (
+	// WorkerRegistration describes a running worker: the task list it polls and the workflow and activity types it
+	// serves on that task list.
+	WorkerRegistration struct {
+		Domain        string
+		TaskList      string
+		Identity      string
+		WorkflowTypes []string
+		ActivityTypes []string
+		// HeartbeatInterval is how often the worker refreshes its registration. Registrations which were not refreshed
+		// for three intervals belong to dead workers and are not listed anymore.
+		HeartbeatInterval time.Duration
+		// LastHeartbeat is when the worker last refreshed its registration.
+		LastHeartbeat time.Time
+	}
+
+	// WorkerRegistry stores the registrations workers publish, see WorkerOptions.WorkerRegistry. Implementations
+	// backed by a shared store, like a database or a service discovery system, let platform teams discover which
+	// task lists serve a workflow type across services with FindWorkflowTaskLists.
+	WorkerRegistry interface {
+		// Heartbeat publishes the registration of a worker, or refreshes it. A registration is identified by its
+		// domain, task list and identity.
+		Heartbeat(ctx context.Context, registration WorkerRegistration) error
+		// Deregister removes the registration of a stopped worker.
+		Deregister(ctx context.Context, domain string, taskList string, identity string) error
+		// ListWorkers returns the registrations of the live workers of the domain.
+		ListWorkers(ctx context.Context, domain string) ([]WorkerRegistration, error)
+	}
+
+	// inMemoryWorkerRegistry is a WorkerRegistry for the workers of the current process.
+	inMemoryWorkerRegistry struct {
+		mutex         sync.Mutex
+		registrations map[workerRegistrationKey]WorkerRegistration
+		now           func() time.Time
+	}
+
+	workerRegistrationKey struct {
+		domain, taskList, identity string
+	}
+
+	// workerRegistryPublisher heartbeats the registration of a worker while it is running.
+	workerRegistryPublisher struct {
+		registry     WorkerRegistry
+		domain       string
+		taskList     string
+		identity     string
+		interval     time.Duration
+		typeRegistry *registry
+		logger       *zap.Logger
+
+		workflows  bool
+		activities bool
+
+		stopC chan struct{}
+		wg    sync.WaitGroup
+	}
+)
+
+// NewInMemoryWorkerRegistry returns a WorkerRegistry storing registrations in memory, for the workers of the current
+// process, e.g. in tests or in services discovering the task lists of their own workers.
+func NewInMemoryWorkerRegistry() WorkerRegistry {
+	return &inMemoryWorkerRegistry{
+		registrations: make(map[workerRegistrationKey]WorkerRegistration),
+		now:           time.Now,
+	}
+}
+
+// FindWorkflowTaskLists returns the sorted task lists of the domain polled by live workers serving workflowType,
+// according to the registrations in registry.
+func FindWorkflowTaskLists(ctx context.Context, registry WorkerRegistry, domain string, workflowType string) ([]string, error) {
+	return findTaskLists(ctx, registry, domain, func(registration WorkerRegistration) []string {
+		return registration.WorkflowTypes
+	}, workflowType)
+}
+
+// FindActivityTaskLists returns the sorted task lists of the domain polled by live workers serving activityType,
+// according to the registrations in registry.
+func FindActivityTaskLists(ctx context.Context, registry WorkerRegistry, domain string, activityType string) ([]string, error) {
+	return findTaskLists(ctx, registry, domain, func(registration WorkerRegistration) []string {
+		return registration.ActivityTypes
+	}, activityType)
+}
+
+func findTaskLists(ctx context.Context, registry WorkerRegistry, domain string, types func(WorkerRegistration) []string, typeName string) ([]string, error) {
+	if registry == nil {
+		return nil, errors.New("worker registry is required")
+	}
+	registrations, err := registry.ListWorkers(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var taskLists []string
+	for _, registration := range registrations {
+		if seen[registration.TaskList] {
+			continue
+		}
+		for _, t := range types(registration) {
+			if t == typeName {
+				seen[registration.TaskList] = true
+				taskLists = append(taskLists, registration.TaskList)
+				break
+			}
+		}
+	}
+	sort.Strings(taskLists)
+	return taskLists, nil
+}
+
+func (r *inMemoryWorkerRegistry) Heartbeat(_ context.Context, registration WorkerRegistration) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	registration.LastHeartbeat = r.now()
+	r.registrations[workerRegistrationKey{registration.Domain, registration.TaskList, registration.Identity}] = registration
+	return nil
+}
+
+func (r *inMemoryWorkerRegistry) Deregister(_ context.Context, domain string, taskList string, identity string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.registrations, workerRegistrationKey{domain, taskList, identity})
+	return nil
+}
+
+func (r *inMemoryWorkerRegistry) ListWorkers(_ context.Context, domain string) ([]WorkerRegistration, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := r.now()
+	var result []WorkerRegistration
+	for key, registration := range r.registrations {
+		if registration.expired(now) {
+			delete(r.registrations, key)
+			continue
+		}
+		if registration.Domain == domain {
+			result = append(result, registration)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TaskList != result[j].TaskList {
+			return result[i].TaskList < result[j].TaskList
+		}
+		return result[i].Identity < result[j].Identity
+	})
+	return result, nil
+}
+
+func (r WorkerRegistration) expired(now time.Time) bool {
+	if r.HeartbeatInterval <= 0 {
+		return false
+	}
+	return now.Sub(r.LastHeartbeat) > workerRegistrationExpiryIntervals*r.HeartbeatInterval
+}
+
+func newWorkerRegistryPublisher(
+	domain string,
+	taskList string,
+	params workerExecutionParameters,
+	typeRegistry *registry,
+) *workerRegistryPublisher {
+	interval := params.WorkerRegistryHeartbeatInterval
+	if interval <= 0 {
+		interval = defaultWorkerRegistryHeartbeatInterval
+	}
+	return &workerRegistryPublisher{
+		registry:     params.WorkerRegistry,
+		domain:       domain,
+		taskList:     taskList,
+		identity:     params.Identity,
+		interval:     interval,
+		typeRegistry: typeRegistry,
+		logger:       params.Logger,
+		workflows:    !params.DisableWorkflowWorker,
+		activities:   !params.DisableActivityWorker,
+	}
+}
+
+// Start publishes the registration of the worker, and refreshes it until Stop. Failures to publish are logged and
+// retried on the next heartbeat, they do not prevent the worker from starting.
+func (p *workerRegistryPublisher) Start() {
+	p.stopC = make(chan struct{})
+	registration := p.registration()
+	p.heartbeat(registration)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stopC:
+				return
+			case <-ticker.C:
+				p.heartbeat(registration)
+			}
+		}
+	}()
+}
+
+// Stop stops the heartbeats and removes the registration of the worker.
+func (p *workerRegistryPublisher) Stop() {
+	if p.stopC == nil {
+		return
+	}
+	close(p.stopC)
+	p.wg.Wait()
+	p.stopC = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), workerRegistryDeregisterTimeout)
+	defer cancel()
+	if err := p.registry.Deregister(ctx, p.domain, p.taskList, p.identity); err != nil {
+		p.logger.Warn("Failed to deregister worker from worker registry.", zap.Error(err))
+	}
+}
+
+func (p *workerRegistryPublisher) heartbeat(registration WorkerRegistration) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+	defer cancel()
+	if err := p.registry.Heartbeat(ctx, registration); err != nil {
+		p.logger.Warn("Failed to publish worker to worker registry.", zap.Error(err))
+	}
+}
+
+func (p *workerRegistryPublisher) registration() WorkerRegistration {
+	registration := WorkerRegistration{
+		Domain:            p.domain,
+		TaskList:          p.taskList,
+		Identity:          p.identity,
+		HeartbeatInterval: p.interval,
+	}
+	if p.workflows {
+		registration.WorkflowTypes = p.typeRegistry.GetRegisteredWorkflowTypes()
+		sort.Strings(registration.WorkflowTypes)
+	}
+	if p.activities {
+		for _, a := range p.typeRegistry.getRegisteredActivities() {
+			registration.ActivityTypes = append(registration.ActivityTypes, a.ActivityType().Name)
+		}
+		sort.Strings(registration.ActivityTypes)
+	}
+	return registration
+}
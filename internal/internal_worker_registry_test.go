@@ -0,0 +1,159 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestInMemoryWorkerRegistry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	registry := NewInMemoryWorkerRegistry().(*inMemoryWorkerRegistry)
+	registry.now = func() time.Time { return now }
+
+	register := func(taskList, identity string, workflowTypes ...string) {
+		require.NoError(t, registry.Heartbeat(ctx, WorkerRegistration{
+			Domain:            "domain",
+			TaskList:          taskList,
+			Identity:          identity,
+			WorkflowTypes:     workflowTypes,
+			ActivityTypes:     []string{"activity-" + taskList},
+			HeartbeatInterval: time.Minute,
+		}))
+	}
+	register("tl-b", "worker-1", "order", "payment")
+	register("tl-a", "worker-2", "order")
+	register("tl-a", "worker-3", "order")
+	require.NoError(t, registry.Heartbeat(ctx, WorkerRegistration{Domain: "other", TaskList: "tl-c", WorkflowTypes: []string{"order"}}))
+
+	workers, err := registry.ListWorkers(ctx, "domain")
+	require.NoError(t, err)
+	require.Len(t, workers, 3)
+	assert.Equal(t, "worker-2", workers[0].Identity)
+	assert.Equal(t, now, workers[0].LastHeartbeat)
+
+	taskLists, err := FindWorkflowTaskLists(ctx, registry, "domain", "order")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tl-a", "tl-b"}, taskLists)
+	taskLists, err = FindWorkflowTaskLists(ctx, registry, "domain", "payment")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tl-b"}, taskLists)
+	taskLists, err = FindWorkflowTaskLists(ctx, registry, "domain", "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, taskLists)
+	taskLists, err = FindActivityTaskLists(ctx, registry, "domain", "activity-tl-a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tl-a"}, taskLists)
+
+	// worker-1 stops heartbeating and expires, worker-3 deregisters
+	now = now.Add(2 * time.Minute)
+	register("tl-a", "worker-2", "order")
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, registry.Deregister(ctx, "domain", "tl-a", "worker-3"))
+	workers, err = registry.ListWorkers(ctx, "domain")
+	require.NoError(t, err)
+	require.Len(t, workers, 1)
+	assert.Equal(t, "worker-2", workers[0].Identity)
+
+	_, err = FindWorkflowTaskLists(ctx, nil, "domain", "order")
+	assert.Error(t, err)
+}
+
+type recordingWorkerRegistry struct {
+	mutex        sync.Mutex
+	heartbeats   []WorkerRegistration
+	deregistered []string
+	err          error
+}
+
+func (r *recordingWorkerRegistry) Heartbeat(_ context.Context, registration WorkerRegistration) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.heartbeats = append(r.heartbeats, registration)
+	return r.err
+}
+
+func (r *recordingWorkerRegistry) Deregister(_ context.Context, domain string, taskList string, identity string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.deregistered = append(r.deregistered, domain+"/"+taskList+"/"+identity)
+	return r.err
+}
+
+func (r *recordingWorkerRegistry) ListWorkers(context.Context, string) ([]WorkerRegistration, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *recordingWorkerRegistry) heartbeatCount() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.heartbeats)
+}
+
+func TestWorkerRegistryPublisher(t *testing.T) {
+	typeRegistry := newRegistry()
+	typeRegistry.RegisterWorkflowWithOptions(testReplayWorkflow, RegisterWorkflowOptions{Name: "workflow-b"})
+	typeRegistry.RegisterWorkflowWithOptions(testReplayWorkflow, RegisterWorkflowOptions{Name: "workflow-a"})
+	typeRegistry.RegisterActivityWithOptions(testActivityNoResult, RegisterActivityOptions{Name: "activity"})
+
+	for _, failing := range []bool{false, true} {
+		registry := &recordingWorkerRegistry{}
+		if failing {
+			registry.err = errors.New("registry unavailable")
+		}
+		params := workerExecutionParameters{
+			WorkerOptions: WorkerOptions{
+				Identity:                        "worker",
+				Logger:                          zaptest.NewLogger(t),
+				WorkerRegistry:                  registry,
+				WorkerRegistryHeartbeatInterval: 10 * time.Millisecond,
+				DisableActivityWorker:           failing,
+			},
+		}
+		publisher := newWorkerRegistryPublisher("domain", "task-list", params, typeRegistry)
+		publisher.Start()
+		assert.Eventually(t, func() bool { return registry.heartbeatCount() >= 3 }, 5*time.Second, time.Millisecond)
+		publisher.Stop()
+		publisher.Stop()
+
+		registration := registry.heartbeats[0]
+		assert.Equal(t, "domain", registration.Domain)
+		assert.Equal(t, "task-list", registration.TaskList)
+		assert.Equal(t, "worker", registration.Identity)
+		assert.Equal(t, 10*time.Millisecond, registration.HeartbeatInterval)
+		assert.Equal(t, []string{"workflow-a", "workflow-b"}, registration.WorkflowTypes)
+		if failing {
+			assert.Empty(t, registration.ActivityTypes)
+		} else {
+			assert.Equal(t, []string{"activity"}, registration.ActivityTypes)
+		}
+		assert.Equal(t, []string{"domain/task-list/worker"}, registry.deregistered)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+type (
+	// WorkerRegistrationError is returned by Worker.RunContext when the worker has nothing to run,
+	// e.g. because no workflows or activities were registered with it.
+	WorkerRegistrationError struct {
+		Err error
+	}
+
+	// WorkerPollerStartError is returned by Worker.RunContext when the worker failed to start its pollers,
+	// e.g. because its domain does not exist.
+	WorkerPollerStartError struct {
+		Err error
+	}
+
+	// WorkerFatalServiceError is returned by Worker.RunContext when a poller received an error from the Cadence
+	// service which can not be recovered from by retrying, e.g. because the client version is not supported.
+	WorkerFatalServiceError struct {
+		Err error
+	}
+
+	// WorkerHook is a function registered with Worker.OnStart or Worker.OnStop.
+	WorkerHook func(ctx context.Context) error
+
+	// fatalErrorNotifier forwards the non-retriable errors received by pollers to a running Worker.RunContext.
+	// When no RunContext is running it keeps the legacy behavior of interrupting the process, which stops Worker.Run
+	// and kills workers which were started with Worker.Start.
+	fatalErrorNotifier struct {
+		sync.Mutex
+		ch chan error
+	}
+)
+
+var errNothingRegistered = errors.New("no workflows or activities are registered")
+
+// Error from error interface
+func (e *WorkerRegistrationError) Error() string {
+	return fmt.Sprintf("worker registration error: %v", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *WorkerRegistrationError) Unwrap() error {
+	return e.Err
+}
+
+// Error from error interface
+func (e *WorkerPollerStartError) Error() string {
+	return fmt.Sprintf("failed to start worker pollers: %v", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *WorkerPollerStartError) Unwrap() error {
+	return e.Err
+}
+
+// Error from error interface
+func (e *WorkerFatalServiceError) Error() string {
+	return fmt.Sprintf("worker received a fatal service error: %v", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *WorkerFatalServiceError) Unwrap() error {
+	return e.Err
+}
+
+func (n *fatalErrorNotifier) subscribe() <-chan error {
+	n.Lock()
+	defer n.Unlock()
+	n.ch = make(chan error, 1)
+	return n.ch
+}
+
+func (n *fatalErrorNotifier) unsubscribe() {
+	n.Lock()
+	defer n.Unlock()
+	n.ch = nil
+}
+
+func (n *fatalErrorNotifier) notify(err error) {
+	n.Lock()
+	defer n.Unlock()
+	if n.ch == nil {
+		p, _ := os.FindProcess(os.Getpid())
+		p.Signal(syscall.SIGINT)
+		return
+	}
+	// only the first error is reported, pollers keep failing until the worker is stopped
+	select {
+	case n.ch <- err:
+	default:
+	}
+}
+
+// OnStart registers a hook which is called before the worker starts polling, e.g. to initialize resources used by
+// activities. Hooks are called in registration order and the worker does not start if any of them fails.
+func (aw *aggregatedWorker) OnStart(hook WorkerHook) {
+	aw.onStartHooks = append(aw.onStartHooks, hook)
+}
+
+// OnStop registers a hook which is called after the worker stopped polling, e.g. to release resources used by
+// activities. Hooks are called in reverse registration order, and only if all OnStart hooks succeeded.
+// Errors returned by OnStop hooks are logged.
+func (aw *aggregatedWorker) OnStop(hook WorkerHook) {
+	aw.onStopHooks = append(aw.onStopHooks, hook)
+}
+
+// RunContext starts the worker and blocks until ctx is done, then stops the worker and returns nil.
+// It returns early with a *WorkerRegistrationError if nothing is registered with the worker, a
+// *WorkerPollerStartError if the pollers can not be started, a *WorkerFatalServiceError if a poller receives a
+// non-retriable error from the service, or the error of a failed OnStart hook.
+func (aw *aggregatedWorker) RunContext(ctx context.Context) error {
+	if len(aw.registry.GetRegisteredWorkflowTypes()) == 0 && len(aw.registry.getRegisteredActivities()) == 0 && aw.shadowWorker == nil {
+		return &WorkerRegistrationError{Err: errNothingRegistered}
+	}
+
+	fatalErrCh := aw.fatalErrors.subscribe()
+	defer aw.fatalErrors.unsubscribe()
+
+	if err := aw.runStartHooks(ctx); err != nil {
+		return err
+	}
+	if err := aw.startPollers(); err != nil {
+		aw.runStopHooks()
+		return &WorkerPollerStartError{Err: err}
+	}
+
+	select {
+	case <-ctx.Done():
+		aw.logger.Info("Worker context is done", zap.Error(ctx.Err()))
+		aw.Stop()
+		return nil
+	case err := <-fatalErrCh:
+		aw.Stop()
+		return &WorkerFatalServiceError{Err: err}
+	}
+}
+
+func (aw *aggregatedWorker) runStartHooks(ctx context.Context) error {
+	for _, hook := range aw.onStartHooks {
+		if err := hook(ctx); err != nil {
+			return fmt.Errorf("worker OnStart hook failed: %w", err)
+		}
+	}
+	aw.hooksStarted = true
+	return nil
+}
+
+func (aw *aggregatedWorker) runStopHooks() {
+	if !aw.hooksStarted {
+		return
+	}
+	aw.hooksStarted = false
+	for i := len(aw.onStopHooks) - 1; i >= 0; i-- {
+		if err := aw.onStopHooks[i](context.Background()); err != nil {
+			aw.logger.Error("Worker OnStop hook failed", zap.Error(err))
+		}
+	}
+}
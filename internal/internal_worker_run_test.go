@@ -0,0 +1,132 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common/testlogger"
+)
+
+func newRunTestWorker(t *testing.T, service *workflowservicetest.MockClient, describeDomainErr error) *aggregatedWorker {
+	service.EXPECT().DescribeDomain(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.DescribeDomainResponse{}, describeDomainErr).AnyTimes()
+	w, err := NewWorker(service, "testDomain", "testTaskList", WorkerOptions{
+		Logger:                testlogger.NewZap(t),
+		DisableActivityWorker: true,
+	})
+	require.NoError(t, err)
+	w.RegisterWorkflow(testReplayWorkflow)
+	return w
+}
+
+func TestWorkerRunContext(t *testing.T) {
+	service := workflowservicetest.NewMockClient(gomock.NewController(t))
+	service.EXPECT().PollForDecisionTask(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.PollForDecisionTaskResponse{}, nil).AnyTimes()
+	w := newRunTestWorker(t, service, nil)
+
+	type ctxKey struct{}
+	var calls []string
+	w.OnStart(func(ctx context.Context) error {
+		assert.Equal(t, "value", ctx.Value(ctxKey{}))
+		calls = append(calls, "start1")
+		return nil
+	})
+	w.OnStart(func(ctx context.Context) error {
+		calls = append(calls, "start2")
+		return nil
+	})
+	w.OnStop(func(ctx context.Context) error {
+		calls = append(calls, "stop1")
+		return nil
+	})
+	w.OnStop(func(ctx context.Context) error {
+		calls = append(calls, "stop2")
+		return errors.New("logged")
+	})
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "value"), 100*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, w.RunContext(ctx))
+	assert.Equal(t, []string{"start1", "start2", "stop2", "stop1"}, calls)
+}
+
+func TestWorkerRunContext_Errors(t *testing.T) {
+	t.Run("nothing registered", func(t *testing.T) {
+		w := newRunTestWorker(t, workflowservicetest.NewMockClient(gomock.NewController(t)), nil)
+		w.registry = newRegistry()
+
+		err := w.RunContext(context.Background())
+		var registrationErr *WorkerRegistrationError
+		require.True(t, errors.As(err, &registrationErr))
+		assert.Equal(t, errNothingRegistered, registrationErr.Err)
+	})
+
+	t.Run("start hook failed", func(t *testing.T) {
+		w := newRunTestWorker(t, workflowservicetest.NewMockClient(gomock.NewController(t)), nil)
+		hookErr := errors.New("hook failed")
+		w.OnStart(func(ctx context.Context) error { return hookErr })
+		w.OnStop(func(ctx context.Context) error {
+			assert.Fail(t, "stop hook must not be called when a start hook failed")
+			return nil
+		})
+
+		assert.ErrorIs(t, w.RunContext(context.Background()), hookErr)
+	})
+
+	t.Run("poller start failed", func(t *testing.T) {
+		domainErr := &shared.EntityNotExistsError{}
+		w := newRunTestWorker(t, workflowservicetest.NewMockClient(gomock.NewController(t)), domainErr)
+		stopped := false
+		w.OnStop(func(ctx context.Context) error {
+			stopped = true
+			return nil
+		})
+
+		err := w.RunContext(context.Background())
+		var pollerErr *WorkerPollerStartError
+		require.True(t, errors.As(err, &pollerErr))
+		assert.ErrorIs(t, pollerErr, domainErr)
+		assert.True(t, stopped)
+	})
+
+	t.Run("fatal service error", func(t *testing.T) {
+		service := workflowservicetest.NewMockClient(gomock.NewController(t))
+		serviceErr := &shared.ClientVersionNotSupportedError{}
+		service.EXPECT().PollForDecisionTask(gomock.Any(), gomock.Any(), callOptions()...).Return(nil, serviceErr).AnyTimes()
+		w := newRunTestWorker(t, service, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err := w.RunContext(ctx)
+		var fatalErr *WorkerFatalServiceError
+		require.True(t, errors.As(err, &fatalErr))
+		assert.Equal(t, serviceErr, fatalErr.Err)
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/backoff"
+)
+
+type (
+	// WorkerValidationError is returned by Worker.Validate when recent histories of the registered workflow types
+	// can not be replayed by the worker's code.
+	WorkerValidationError struct {
+		Failures []WorkerValidationFailure
+	}
+
+	// WorkerValidationFailure describes a workflow execution that failed replay during Worker.Validate.
+	WorkerValidationFailure struct {
+		WorkflowType string
+		Execution    WorkflowExecution
+		Err          error
+	}
+)
+
+var errValidateSampleSize = errors.New("sample size must be greater than 0")
+
+// Error from error interface
+func (e *WorkerValidationError) Error() string {
+	failures := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		failures = append(failures, fmt.Sprintf("%v (%v/%v): %v", f.WorkflowType, f.Execution.ID, f.Execution.RunID, f.Err))
+	}
+	return fmt.Sprintf("worker validation failed for %d workflow executions: %v", len(e.Failures), strings.Join(failures, "; "))
+}
+
+// Validate replays up to sampleSize of the most recent executions of each registered workflow type, found through
+// visibility, against the workflows registered on this worker.
+// Executions that fail with nondeterminism or an unknown workflow type are reported in a *WorkerValidationError.
+// Other replay errors, like histories that are too short to replay, are logged and skipped.
+// Validate requires advanced visibility and does nothing when the workflow worker is disabled.
+func (aw *aggregatedWorker) Validate(ctx context.Context, sampleSize int) error {
+	if sampleSize <= 0 {
+		return errValidateSampleSize
+	}
+	if aw.workflowWorker == nil {
+		return nil
+	}
+
+	params := aw.workflowWorker.executionParameters
+	replayOptions := ReplayOptions{
+		DataConverter:                     params.DataConverter,
+		WorkflowInterceptorChainFactories: params.WorkflowInterceptorChainFactories,
+		ContextPropagators:                params.ContextPropagators,
+		Tracer:                            params.Tracer,
+		FeatureFlags:                      params.FeatureFlags,
+	}
+	augmentReplayOptions(&replayOptions)
+	replayer := &WorkflowReplayer{registry: aw.registry, options: replayOptions}
+
+	workflowTypes := aw.registry.GetRegisteredWorkflowTypes()
+	sort.Strings(workflowTypes)
+
+	validationErr := &WorkerValidationError{}
+	for _, workflowType := range workflowTypes {
+		executions, err := aw.listRecentExecutions(ctx, workflowType, sampleSize)
+		if err != nil {
+			return err
+		}
+		for _, execution := range executions {
+			err := replayer.ReplayWorkflowExecution(ctx, aw.workflowWorker.workflowService, aw.logger, aw.workflowWorker.domain, execution)
+			if err == nil {
+				continue
+			}
+			if isNondeterministicErr(err) || isWorkflowTypeNotRegisteredError(err) {
+				validationErr.Failures = append(validationErr.Failures, WorkerValidationFailure{
+					WorkflowType: workflowType,
+					Execution:    execution,
+					Err:          err,
+				})
+				continue
+			}
+			aw.logger.Info("Skipped validating workflow execution",
+				zap.String(tagWorkflowType, workflowType),
+				zap.String(tagWorkflowID, execution.ID),
+				zap.String(tagRunID, execution.RunID),
+				zap.Error(err))
+		}
+	}
+
+	if len(validationErr.Failures) > 0 {
+		return validationErr
+	}
+	return nil
+}
+
+func (aw *aggregatedWorker) listRecentExecutions(ctx context.Context, workflowType string, sampleSize int) ([]WorkflowExecution, error) {
+	request := &shared.ListWorkflowExecutionsRequest{
+		Domain:   common.StringPtr(aw.workflowWorker.domain),
+		PageSize: common.Int32Ptr(int32(sampleSize)),
+		Query:    common.StringPtr(NewQueryBuilder().WorkflowTypes([]string{workflowType}).Build()),
+	}
+
+	var response *shared.ListWorkflowExecutionsResponse
+	if err := backoff.Retry(ctx,
+		func() error {
+			tchCtx, cancel, opt := newChannelContext(ctx, aw.workflowWorker.executionParameters.FeatureFlags)
+			defer cancel()
+
+			var err error
+			response, err = aw.workflowWorker.workflowService.ListWorkflowExecutions(tchCtx, request, opt...)
+			return err
+		},
+		createDynamicServiceRetryPolicy(ctx),
+		isServiceTransientError,
+	); err != nil {
+		return nil, err
+	}
+
+	executions := make([]WorkflowExecution, 0, len(response.Executions))
+	for _, info := range response.Executions {
+		executions = append(executions, WorkflowExecution{
+			ID:    info.Execution.GetWorkflowId(),
+			RunID: info.Execution.GetRunId(),
+		})
+	}
+	if len(executions) > sampleSize {
+		executions = executions[:sampleSize]
+	}
+	return executions, nil
+}
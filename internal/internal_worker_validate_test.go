@@ -0,0 +1,102 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestAggregatedWorkerValidate(t *testing.T) {
+	const workflowType = "go.uber.org/cadence/internal.testReplayWorkflow"
+
+	newWorker := func(t *testing.T, service *workflowservicetest.MockClient) *aggregatedWorker {
+		w, err := newAggregatedWorker(service, "validate-domain", "validate-tl", WorkerOptions{Logger: zaptest.NewLogger(t)})
+		require.NoError(t, err)
+		w.RegisterWorkflow(testReplayWorkflow)
+		return w
+	}
+	executionInfo := func(id string) *shared.WorkflowExecutionInfo {
+		return &shared.WorkflowExecutionInfo{
+			Execution: &shared.WorkflowExecution{WorkflowId: common.StringPtr(id), RunId: common.StringPtr(id + "-run")},
+			Type:      &shared.WorkflowType{Name: common.StringPtr(workflowType)},
+		}
+	}
+	// the worker registry falls back to the global registry, so only answer for the test workflow type
+	listExecutions := func(executions ...*shared.WorkflowExecutionInfo) func(context.Context, *shared.ListWorkflowExecutionsRequest, ...interface{}) (*shared.ListWorkflowExecutionsResponse, error) {
+		return func(_ context.Context, request *shared.ListWorkflowExecutionsRequest, _ ...interface{}) (*shared.ListWorkflowExecutionsResponse, error) {
+			assert.Equal(t, "validate-domain", request.GetDomain())
+			if !strings.Contains(request.GetQuery(), workflowType) {
+				return &shared.ListWorkflowExecutionsResponse{}, nil
+			}
+			return &shared.ListWorkflowExecutionsResponse{Executions: executions}, nil
+		}
+	}
+
+	t.Run("reports nondeterministic executions", func(t *testing.T) {
+		service := workflowservicetest.NewMockClient(gomock.NewController(t))
+		w := newWorker(t, service)
+
+		service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).
+			DoAndReturn(listExecutions(executionInfo("good"), executionInfo("bad"))).MinTimes(1)
+		service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).
+			DoAndReturn(func(_ context.Context, request *shared.GetWorkflowExecutionHistoryRequest, _ ...interface{}) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+				if request.Execution.GetWorkflowId() == "bad" {
+					return &shared.GetWorkflowExecutionHistoryResponse{History: getTestReplayWorkflowMismatchHistory(t)}, nil
+				}
+				return &shared.GetWorkflowExecutionHistoryResponse{History: getTestReplayWorkflowFullHistory(t)}, nil
+			}).Times(2)
+
+		err := w.Validate(context.Background(), 2)
+		var validationErr *WorkerValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Len(t, validationErr.Failures, 1)
+		assert.Equal(t, workflowType, validationErr.Failures[0].WorkflowType)
+		assert.Equal(t, WorkflowExecution{ID: "bad", RunID: "bad-run"}, validationErr.Failures[0].Execution)
+	})
+
+	t.Run("succeeds when all executions replay", func(t *testing.T) {
+		service := workflowservicetest.NewMockClient(gomock.NewController(t))
+		w := newWorker(t, service)
+
+		service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).
+			DoAndReturn(listExecutions(executionInfo("good"))).MinTimes(1)
+		service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).
+			Return(&shared.GetWorkflowExecutionHistoryResponse{History: getTestReplayWorkflowFullHistory(t)}, nil)
+
+		assert.NoError(t, w.Validate(context.Background(), 5))
+	})
+
+	t.Run("invalid sample size", func(t *testing.T) {
+		w := newWorker(t, workflowservicetest.NewMockClient(gomock.NewController(t)))
+		assert.Equal(t, errValidateSampleSize, w.Validate(context.Background(), 0))
+	})
+}
@@ -73,6 +73,25 @@ type (
 	}
 
 	// Implements WaitGroup interface
+	semaphoreImpl struct {
+		size    int64              // the number of permits
+		cur     int64              // the number of permits held
+		waiters []*semaphoreWaiter // in the order they started waiting
+	}
+
+	semaphoreWaiter struct {
+		n        int64
+		settable Settable // set once the permits are granted to the waiter
+	}
+
+	// Implements ErrGroup interface
+	errGroupImpl struct {
+		ctx    Context    // the context of the group, passed to the coroutines
+		cancel CancelFunc // cancels ctx
+		wg     WaitGroup  // the coroutines of the group
+		err    error      // the first error returned by a coroutine
+	}
+
 	waitGroupImpl struct {
 		n        int      // the number of coroutines to wait on
 		waiting  bool     // indicates whether WaitGroup.Wait() has been called yet for the WaitGroup
@@ -122,6 +141,9 @@ type (
 		recValue        *interface{}       // Used only while receiving value, this is used as pre-fetch buffer value from the channel.
 		dataConverter   DataConverter      // for decode data
 		env             workflowEnvironment
+		dropCallback    func(v interface{}) // invoked when SendAsync fails due to a full buffer.
+		signalName      string
+		corruptSignals  *corruptSignalOptions // captures values failing to decode, set for signal channels only.
 	}
 
 	// Single case statement of the Select
@@ -133,6 +155,7 @@ type (
 		sendValue  *interface{}    // value to send to the channel. Used only for send case.
 		future     asyncFuture     // Used for future case
 		futureFunc *func(f Future) // function to call when Future is ready
+		priority   int             // cases are checked by decreasing priority, see Selector.AddFutureWithPriority
 	}
 
 	// Implements Selector interface
@@ -166,6 +189,11 @@ type (
 		executing        bool       // currently running ExecuteUntilAllBlocked. Used to avoid recursive calls to it.
 		mutex            sync.Mutex // used to synchronize executing
 		closed           bool
+		yields           *yieldRecorder // nil unless WorkerOptions.EnableYieldDiagnostics
+		backoffSeed      *int64         // seed of the jitter of Backoff, recorded with SideEffect on first use
+
+		// called once all the coroutines are blocked, see TestWorkflowEnvironment.SetQueryHandlerChecks
+		afterAllBlocked func() error
 	}
 
 	// The current timeout resolution implementation is in seconds and uses math.Ceil() as the duration. But is
@@ -178,7 +206,10 @@ type (
 		workflowID                          string
 		waitForCancellation                 bool
 		signalChannels                      map[string]Channel
-		queryHandlers                       map[string]func([]byte) ([]byte, error)
+		queryHandlers                       map[string]*queryHandler
+		state                               *workflowState
+		unhandledSignalOptions              *UnhandledSignalOptions
+		corruptSignalOptions                *corruptSignalOptions
 		workflowIDReusePolicy               WorkflowIDReusePolicy
 		dataConverter                       DataConverter
 		retryPolicy                         *shared.RetryPolicy
@@ -188,6 +219,9 @@ type (
 		searchAttributes                    map[string]interface{}
 		parentClosePolicy                   ParentClosePolicy
 		bugports                            Bugports
+		defaultActivityOptions              *defaultActivityOptions
+		headers                             map[string]string
+		pendingCallIDs                      *pendingCallIDs
 	}
 
 	executeWorkflowParams struct {
@@ -235,6 +269,7 @@ type (
 		fn            interface{}
 		queryType     string
 		dataConverter DataConverter
+		ctx           Context // passed to the handler as QueryContext if it declares one
 	}
 )
 
@@ -249,9 +284,11 @@ const (
 )
 
 // Assert that structs do indeed implement the interfaces
-var _ Channel = (*channelImpl)(nil)
+var _ BufferedChannel = (*channelImpl)(nil)
 var _ Selector = (*selectorImpl)(nil)
 var _ WaitGroup = (*waitGroupImpl)(nil)
+var _ Semaphore = (*semaphoreImpl)(nil)
+var _ ErrGroup = (*errGroupImpl)(nil)
 var _ dispatcher = (*dispatcherImpl)(nil)
 
 var stackBuf [100000]byte
@@ -325,6 +362,10 @@ func (f *futureImpl) Get(ctx Context, value interface{}) error {
 	return f.err
 }
 
+func (f *futureImpl) GetWithTimeout(ctx Context, timeout time.Duration, value interface{}) (bool, error) {
+	return getWithTimeout(ctx, f, timeout, value)
+}
+
 // Used by selectorImpl
 // If Future is ready returns its value immediately.
 // If not registers callback which is called when it is ready.
@@ -467,8 +508,10 @@ func (d *syncWorkflowDefinition) Execute(env workflowEnvironment, header *shared
 		state := getState(d.rootCtx)
 		state.yield("yield before executing to setup state")
 
-		// TODO: @shreyassrivatsan - add workflow trace span here
-		r.workflowResult, r.error = d.workflow.Execute(d.rootCtx, input)
+		spanCtx, span := createOpenTracingWorkflowExecutionSpan(d.rootCtx, env)
+		r.workflowResult, r.error = d.workflow.Execute(spanCtx, input)
+		finishOpenTracingSpan(span, r.error)
+		r.error = handleUnhandledSignals(d.rootCtx, r.error)
 		rpp := getWorkflowResultPointerPointer(ctx)
 		*rpp = r
 	})
@@ -481,8 +524,23 @@ func (d *syncWorkflowDefinition) Execute(env workflowEnvironment, header *shared
 		}
 	}
 
+	// set the default activity options of the workflow start request
+	defaults, err := readDefaultActivityOptionsHeader(header)
+	if err != nil {
+		panic(fmt.Sprintf("Unable to read default activity options %v", err))
+	}
+	getWorkflowEnvOptions(rootCtx).defaultActivityOptions = defaults
+
+	// set the headers of the workflow start request
+	headers, err := readWorkflowHeadersHeader(header)
+	if err != nil {
+		panic(fmt.Sprintf("Unable to read workflow headers %v", err))
+	}
+	getWorkflowEnvOptions(rootCtx).headers = headers
+
 	d.rootCtx, d.cancel = WithCancel(rootCtx)
 	d.dispatcher = dispatcher
+	dispatcher.yields = env.GetYieldRecorder()
 
 	getWorkflowEnvironment(d.rootCtx).RegisterCancelHandler(func() {
 		// It is ok to call this method multiple times.
@@ -491,22 +549,23 @@ func (d *syncWorkflowDefinition) Execute(env workflowEnvironment, header *shared
 	})
 
 	getWorkflowEnvironment(d.rootCtx).RegisterSignalHandler(func(name string, result []byte) {
-		eo := getWorkflowEnvOptions(d.rootCtx)
-		// We don't want this code to be blocked ever, using sendAsync().
-		ch := eo.getSignalChannel(d.rootCtx, name).(*channelImpl)
-		ok := ch.SendAsync(result)
-		if !ok {
-			panic(fmt.Sprintf("Exceeded channel buffer size for signal: %v", name))
-		}
+		arg := EncodedValue{value: result, dataConverter: getDataConverterFromWorkflowContext(d.rootCtx)}
+		getWorkflowInterceptor(d.rootCtx).HandleSignal(d.rootCtx, name, arg)
 	})
 
 	getWorkflowEnvironment(d.rootCtx).RegisterQueryHandler(func(queryType string, queryArgs []byte) ([]byte, error) {
 		eo := getWorkflowEnvOptions(d.rootCtx)
+		switch queryType {
+		case QueryTypeUnhandledSignals:
+			return encodeArg(eo.dataConverter, eo.getUnhandledSignalCounts())
+		case QueryTypeVersions:
+			return encodeArg(eo.dataConverter, env.GetChangeVersions())
+		}
 		handler, ok := eo.queryHandlers[queryType]
 		if !ok {
 			return nil, fmt.Errorf("unknown queryType %v. KnownQueryTypes=%v", queryType, eo.KnownQueryTypes())
 		}
-		return handler(queryArgs)
+		return handler.execute(queryArgs)
 	})
 }
 
@@ -555,7 +614,10 @@ func executeDispatcher(ctx Context, dispatcher dispatcher) {
 
 	us := getWorkflowEnvOptions(ctx).getUnhandledSignalNames()
 	if len(us) > 0 {
-		env.GetLogger().Info("Workflow has unhandled signals", zap.Strings("SignalNames", us))
+		// the workflow logger is already tagged with the workflow type, ID and run ID.
+		env.GetLogger().Info("Workflow has unhandled signals",
+			zap.Strings("SignalNames", us),
+			zap.Any("SignalCounts", getWorkflowEnvOptions(ctx).getUnhandledSignalCounts()))
 		env.GetMetricsScope().Counter(metrics.UnhandledSignalsCounter).Inc(1)
 	}
 
@@ -740,7 +802,11 @@ func (c *channelImpl) Send(ctx Context, v interface{}) {
 }
 
 func (c *channelImpl) SendAsync(v interface{}) (ok bool) {
-	return c.sendAsyncImpl(v, nil)
+	ok = c.sendAsyncImpl(v, nil)
+	if !ok && c.dropCallback != nil {
+		c.dropCallback(v)
+	}
+	return ok
 }
 
 func (c *channelImpl) sendAsyncImpl(v interface{}, pair *sendCallback) (ok bool) {
@@ -766,6 +832,34 @@ func (c *channelImpl) sendAsyncImpl(v interface{}, pair *sendCallback) (ok bool)
 	return false
 }
 
+func (c *channelImpl) Len() int {
+	return len(c.buffer)
+}
+
+func (c *channelImpl) Cap() int {
+	return c.size
+}
+
+func (c *channelImpl) Resize(size int) {
+	if size < 0 {
+		panic(fmt.Sprintf("negative buffer size %v for channel %s", size, c.name))
+	}
+	c.size = size
+	// Move blocked sends into the grown buffer
+	for len(c.buffer) < c.size && len(c.blockedSends) > 0 {
+		b := c.blockedSends[0]
+		c.blockedSends[0] = nil
+		c.blockedSends = c.blockedSends[1:]
+		if b.fn() {
+			c.buffer = append(c.buffer, b.value)
+		}
+	}
+}
+
+func (c *channelImpl) OnDrop(callback func(v interface{})) {
+	c.dropCallback = callback
+}
+
 func (c *channelImpl) Close() {
 	c.closed = true
 	// Use a copy of blockedReceives for iteration as invoking callback could result in modification
@@ -781,8 +875,12 @@ func (c *channelImpl) assignValue(from interface{}, to interface{}) error {
 	err := decodeAndAssignValue(c.dataConverter, from, to)
 	// add to metrics
 	if err != nil {
-		c.env.GetLogger().Error(fmt.Sprintf("Corrupt signal received on channel %s. Error deserializing", c.name), zap.Error(err))
+		c.env.GetLogger().Error(fmt.Sprintf("Corrupt signal received on channel %s. Error deserializing", c.name),
+			zap.Error(redactError(c.env.GetPayloadRedactor(), err)))
 		c.env.GetMetricsScope().Counter(metrics.CorruptedSignalsCounter).Inc(1)
+		if c.corruptSignals != nil {
+			c.corruptSignals.capture(c.env, c.signalName, from, err)
+		}
 	}
 	return err
 }
@@ -806,6 +904,9 @@ func (s *coroutineState) initialYield(stackDepth int, status string) {
 // yield indicates that coroutine cannot make progress and should sleep
 // this call blocks
 func (s *coroutineState) yield(status string) {
+	if yields := s.dispatcher.yields; yields != nil {
+		yields.record(s.name, status)
+	}
 	s.aboutToBlock <- true
 	s.initialYield(3, status) // omit three levels of stack. To adjust change to 0 and count the lines to remove.
 	s.keptBlocked = true
@@ -947,6 +1048,9 @@ func (d *dispatcherImpl) ExecuteUntilAllBlocked() (err error) {
 			break
 		}
 	}
+	if d.afterAllBlocked != nil && len(d.coroutines) > 0 {
+		return d.afterAllBlocked()
+	}
 	return nil
 }
 
@@ -1003,6 +1107,32 @@ func (s *selectorImpl) AddFuture(future Future, f func(future Future)) Selector
 	return s
 }
 
+func (s *selectorImpl) AddReceiveWithPriority(c Channel, priority int, f func(c Channel, more bool)) Selector {
+	s.AddReceive(c, f)
+	s.cases[len(s.cases)-1].priority = priority
+	return s
+}
+
+func (s *selectorImpl) AddFutureWithPriority(future Future, priority int, f func(future Future)) Selector {
+	s.AddFuture(future, f)
+	s.cases[len(s.cases)-1].priority = priority
+	return s
+}
+
+// casesByPriority returns the cases in the order they are checked by Select.
+func (s *selectorImpl) casesByPriority() []*selectCase {
+	for _, c := range s.cases {
+		if c.priority != 0 {
+			cases := append([]*selectCase(nil), s.cases...)
+			sort.SliceStable(cases, func(i, j int) bool {
+				return cases[i].priority > cases[j].priority
+			})
+			return cases
+		}
+	}
+	return s.cases
+}
+
 func (s *selectorImpl) AddDefault(f func()) {
 	s.defaultFunc = &f
 }
@@ -1017,7 +1147,7 @@ func (s *selectorImpl) Select(ctx Context) {
 		}
 	}()
 
-	for _, pair := range s.cases {
+	for _, pair := range s.casesByPriority() {
 		if pair.receiveFunc != nil {
 			f := *pair.receiveFunc
 			c := pair.channel
@@ -1178,15 +1308,24 @@ func getValidatedWorkflowOptions(ctx Context) (*workflowOptions, error) {
 		// default to use current workflow's task list
 		p.taskListName = common.StringPtr(info.TaskListName)
 	}
-	if p.taskStartToCloseTimeoutSeconds == nil || *p.taskStartToCloseTimeoutSeconds < 0 {
-		return nil, errors.New("missing or negative DecisionTaskStartToCloseTimeout")
+	var executionTimeout, taskTimeout int32
+	if p.executionStartToCloseTimeoutSeconds != nil {
+		executionTimeout = *p.executionStartToCloseTimeoutSeconds
+	}
+	if p.taskStartToCloseTimeoutSeconds != nil {
+		taskTimeout = *p.taskStartToCloseTimeoutSeconds
+	}
+	v := newOptionsValidator("ChildWorkflowOptions")
+	if p.taskStartToCloseTimeoutSeconds == nil {
+		v.add("TaskStartToCloseTimeout", "is not set", "set it with WithChildWorkflowOptions or WithWorkflowTaskStartToCloseTimeout")
+	}
+	v.workflowTimeouts(secondsToDuration(executionTimeout), secondsToDuration(taskTimeout), "TaskStartToCloseTimeout")
+	if err := v.result(); err != nil {
+		return nil, err
 	}
 	if *p.taskStartToCloseTimeoutSeconds == 0 {
 		p.taskStartToCloseTimeoutSeconds = common.Int32Ptr(defaultDecisionTaskTimeoutInSecs)
 	}
-	if p.executionStartToCloseTimeoutSeconds == nil || *p.executionStartToCloseTimeoutSeconds <= 0 {
-		return nil, errors.New("missing or invalid ExecutionStartToCloseTimeout")
-	}
 	if err := validateRetryPolicy(p.retryPolicy); err != nil {
 		return nil, err
 	}
@@ -1221,7 +1360,11 @@ func setWorkflowEnvOptionsIfNotExist(ctx Context) Context {
 		newOptions = *options
 	} else {
 		newOptions.signalChannels = make(map[string]Channel)
-		newOptions.queryHandlers = make(map[string]func([]byte) ([]byte, error))
+		newOptions.queryHandlers = make(map[string]*queryHandler)
+		newOptions.state = newWorkflowState()
+		newOptions.pendingCallIDs = newPendingCallIDs()
+		newOptions.unhandledSignalOptions = &UnhandledSignalOptions{}
+		newOptions.corruptSignalOptions = &corruptSignalOptions{}
 	}
 	if newOptions.dataConverter == nil {
 		newOptions.dataConverter = getDefaultDataConverter()
@@ -1255,6 +1398,8 @@ func getHeadersFromContext(ctx Context) *shared.Header {
 	for _, ctxProp := range contextPropagators {
 		ctxProp.InjectFromWorkflow(ctx, NewHeaderWriter(header))
 	}
+	// the headers were decoded from the header of this workflow, so encoding them again can not fail
+	_ = writeWorkflowHeadersHeader(header, getWorkflowEnvOptions(ctx).headers)
 	return header
 }
 
@@ -1263,7 +1408,9 @@ func (w *workflowOptions) getSignalChannel(ctx Context, signalName string) Chann
 	if ch, ok := w.signalChannels[signalName]; ok {
 		return ch
 	}
-	ch := NewBufferedChannel(ctx, defaultSignalChannelSize)
+	ch := NewNamedBufferedChannel(ctx, "signal:"+signalName, defaultSignalChannelSize).(*channelImpl)
+	ch.signalName = signalName
+	ch.corruptSignals = w.corruptSignalOptions
 	w.signalChannels[signalName] = ch
 	return ch
 }
@@ -1287,6 +1434,22 @@ func (w *workflowOptions) getUnhandledSignalNames() []string {
 	return unhandledSignals
 }
 
+// getUnhandledSignalCounts returns the number of unconsumed signals per signal name.
+func (w *workflowOptions) getUnhandledSignalCounts() map[string]int {
+	counts := make(map[string]int)
+	for k, c := range w.signalChannels {
+		ch := c.(*channelImpl)
+		count := len(ch.buffer) + len(ch.blockedSends)
+		if ch.recValue != nil {
+			count++
+		}
+		if count > 0 {
+			counts[k] = count
+		}
+	}
+	return counts
+}
+
 // KnownQueryTypes returns a list of known query types of the workflowOptions with BuiltinQueryTypes
 func (w *workflowOptions) KnownQueryTypes() []string {
 	keys := BuiltinQueryTypes()
@@ -1299,6 +1462,10 @@ func (w *workflowOptions) KnownQueryTypes() []string {
 	return keys
 }
 
+func (d *decodeFutureImpl) GetWithTimeout(ctx Context, timeout time.Duration, value interface{}) (bool, error) {
+	return getWithTimeout(ctx, d, timeout, value)
+}
+
 func (d *decodeFutureImpl) Get(ctx Context, value interface{}) error {
 	more := d.futureImpl.channel.Receive(ctx, nil)
 	if more {
@@ -1330,15 +1497,22 @@ func newDecodeFuture(ctx Context, fn interface{}) (Future, Settable) {
 	return impl, impl
 }
 
+// newNamedDecodeFuture is newDecodeFuture with a name, which appears in stack traces that are blocked on the future.
+func newNamedDecodeFuture(ctx Context, name string, fn interface{}) (Future, Settable) {
+	impl := &decodeFutureImpl{
+		&futureImpl{channel: NewNamedChannel(ctx, name).(*channelImpl)}, fn}
+	return impl, impl
+}
+
 // setQueryHandler sets query handler for given queryType.
 func setQueryHandler(ctx Context, queryType string, handler interface{}) error {
-	qh := &queryHandler{fn: handler, queryType: queryType, dataConverter: getDataConverterFromWorkflowContext(ctx)}
+	qh := &queryHandler{fn: handler, queryType: queryType, dataConverter: getDataConverterFromWorkflowContext(ctx), ctx: ctx}
 	err := qh.validateHandlerFn()
 	if err != nil {
 		return err
 	}
 
-	getWorkflowEnvOptions(ctx).queryHandlers[queryType] = qh.execute
+	getWorkflowEnvOptions(ctx).queryHandlers[queryType] = qh
 	return nil
 }
 
@@ -1383,41 +1557,87 @@ func (h *queryHandler) execute(input []byte) (result []byte, err error) {
 		}
 	}()
 
-	fnType := reflect.TypeOf(h.fn)
-	var args []reflect.Value
+	args, err := h.decodeArgs(input)
+	if err != nil {
+		return nil, err
+	}
 
-	if fnType.NumIn() == 1 && util.IsTypeByteSlice(fnType.In(0)) {
-		args = append(args, reflect.ValueOf(input))
-	} else {
-		decoded, err := decodeArgs(h.dataConverter, fnType, input)
-		if err != nil {
-			return nil, fmt.Errorf("unable to decode the input for queryType: %v, with error: %v", h.queryType, err)
-		}
-		args = append(args, decoded...)
+	// invoke the query handler through the interceptor chain.
+	retValue, err := getWorkflowInterceptor(h.ctx).HandleQuery(h.ctx, h.queryType, args...)
+	// as when the handler is called directly, only nil pointers are not encoded, e.g. a nil interface{} is "null"
+	if h.isNilPointer(retValue) {
+		return nil, err
+	}
+	result, encodeErr := encodeArg(h.dataConverter, retValue)
+	if encodeErr != nil {
+		return nil, encodeErr
+	}
+	return result, err
+}
+
+// isNilPointer returns whether the result of the handler is a nil pointer, a nil result is one if the handler returns
+// a pointer.
+func (h *queryHandler) isNilPointer(retValue interface{}) bool {
+	if retValue == nil {
+		return reflect.TypeOf(h.fn).Out(0).Kind() == reflect.Ptr
 	}
+	rv := reflect.ValueOf(retValue)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
 
-	// invoke the query handler with arguments.
-	fnValue := reflect.ValueOf(h.fn)
-	retValues := fnValue.Call(args)
+// decodeArgs decodes the query input into the arguments of the handler, without its QueryContext parameter.
+func (h *queryHandler) decodeArgs(input []byte) ([]interface{}, error) {
+	fnType := reflect.TypeOf(h.fn)
+	numIn := fnType.NumIn()
+	if numIn > 0 && isQueryContext(fnType.In(0)) {
+		numIn--
+	}
+	if numIn == 1 && util.IsTypeByteSlice(fnType.In(fnType.NumIn()-1)) {
+		return []interface{}{input}, nil
+	}
 
-	// we already verified (in validateHandlerFn()) that the query handler returns 2 values
-	retValue := retValues[0]
-	if retValue.Kind() != reflect.Ptr || !retValue.IsNil() {
-		result, err = encodeArg(h.dataConverter, retValue.Interface())
-		if err != nil {
-			return nil, err
+	decoded, err := decodeArgsToValues(h.dataConverter, fnType, input)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the input for queryType: %v, with error: %v", h.queryType, err)
+	}
+	args := make([]interface{}, len(decoded))
+	for i, arg := range decoded {
+		args[i] = reflect.ValueOf(arg).Elem().Interface()
+	}
+	return args, nil
+}
+
+// invoke calls the query handler with args, passing it a QueryContext if it declares one.
+func (h *queryHandler) invoke(args []interface{}) (interface{}, error) {
+	fnType := reflect.TypeOf(h.fn)
+	var in []reflect.Value
+	if fnType.NumIn() > 0 && isQueryContext(fnType.In(0)) {
+		in = append(in, reflect.ValueOf(newQueryContext(h.ctx)))
+	}
+	for i, arg := range args {
+		argType := fnType.In(len(in))
+		if arg == nil {
+			in = append(in, reflect.Zero(argType))
+			continue
+		}
+		argValue := reflect.ValueOf(arg)
+		if !argValue.Type().AssignableTo(argType) {
+			return nil, fmt.Errorf("argument %d of queryType %v: %v is not assignable to %v", i, h.queryType, argValue.Type(), argType)
 		}
+		in = append(in, argValue)
 	}
 
+	// we already verified (in validateHandlerFn()) that the query handler returns 2 values
+	retValues := reflect.ValueOf(h.fn).Call(in)
 	errValue := retValues[1]
 	if errValue.IsNil() {
-		return result, nil
+		return retValues[0].Interface(), nil
 	}
 	err, ok := errValue.Interface().(error)
 	if !ok {
 		return nil, fmt.Errorf("failed to parse error result as it is not of error interface: %v", errValue)
 	}
-	return result, err
+	return retValues[0].Interface(), err
 }
 
 // Add adds delta, which may be negative, to the WaitGroup counter.
@@ -1471,3 +1691,89 @@ func (wg *waitGroupImpl) Wait(ctx Context) {
 	}
 	wg.future, wg.settable = NewFuture(ctx)
 }
+
+// Go runs f in a new coroutine with the context of the group.
+func (g *errGroupImpl) Go(f func(ctx Context) error) {
+	g.wg.Add(1)
+	GoNamed(g.ctx, "errgroup", func(ctx Context) {
+		defer g.wg.Done()
+		if err := f(ctx); err != nil && g.err == nil {
+			g.err = err
+			g.cancel()
+		}
+	})
+}
+
+// Wait blocks until all the coroutines of the group returned, and returns the first error.
+func (g *errGroupImpl) Wait(ctx Context) error {
+	g.wg.Wait(ctx)
+	g.cancel()
+	return g.err
+}
+
+func (s *semaphoreImpl) Acquire(ctx Context, n int64) error {
+	if n > s.size {
+		return fmt.Errorf("semaphore: acquiring %d permits of a semaphore of size %d", n, s.size)
+	}
+	if s.size-s.cur >= n && len(s.waiters) == 0 {
+		s.cur += n
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	granted, settable := NewFuture(ctx)
+	waiter := &semaphoreWaiter{n: n, settable: settable}
+	s.waiters = append(s.waiters, waiter)
+	selector := NewSelector(ctx).AddFuture(granted, func(Future) {})
+	if ctx.Done() != nil {
+		selector.AddReceive(ctx.Done(), func(Channel, bool) {})
+	}
+	selector.Select(ctx)
+	if ctx.Err() == nil {
+		return nil
+	}
+	if granted.IsReady() {
+		// canceled after the permits were granted, hand them over to the next waiters
+		s.Release(n)
+		return ctx.Err()
+	}
+	for i, w := range s.waiters {
+		if w == waiter {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			break
+		}
+	}
+	// the canceled waiter may have been blocking the waiters behind it
+	s.notifyWaiters()
+	return ctx.Err()
+}
+
+func (s *semaphoreImpl) TryAcquire(n int64) bool {
+	if s.size-s.cur >= n && len(s.waiters) == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+func (s *semaphoreImpl) Release(n int64) {
+	s.cur -= n
+	if s.cur < 0 {
+		panic("semaphore: released more than held")
+	}
+	s.notifyWaiters()
+}
+
+// notifyWaiters grants permits to the waiters in order, until the first waiter whose permits are not available.
+func (s *semaphoreImpl) notifyWaiters() {
+	for len(s.waiters) > 0 {
+		waiter := s.waiters[0]
+		if s.size-s.cur < waiter.n {
+			return
+		}
+		s.cur += waiter.n
+		s.waiters = s.waiters[1:]
+		waiter.settable.Set(nil, nil)
+	}
+}
@@ -31,8 +31,10 @@ import (
 
 	"go.uber.org/cadence/internal/common/serializer"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pborman/uuid"
+	"go.uber.org/yarpc"
 
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
 	s "go.uber.org/cadence/.gen/go/shared"
@@ -68,6 +70,9 @@ type (
 		contextPropagators []ContextPropagator
 		tracer             opentracing.Tracer
 		featureFlags       FeatureFlags
+		clock              clockwork.Clock
+		namingPolicies     NamingPolicies
+		completions        *completionListener
 	}
 
 	// WorkflowRun represents a started non child workflow
@@ -156,7 +161,7 @@ func (wc *workflowClient) StartWorkflow(
 	var response *s.StartWorkflowExecutionResponse
 
 	// Start creating workflow request.
-	err = backoff.Retry(ctx,
+	err = backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
@@ -200,7 +205,7 @@ func (wc *workflowClient) StartWorkflowAsync(
 	}
 
 	// Start creating workflow request.
-	err = backoff.Retry(ctx,
+	err = backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
@@ -225,6 +230,33 @@ func (wc *workflowClient) StartWorkflowAsync(
 	return executionInfo, nil
 }
 
+// StartWorkflowIfNotRunning behaves like StartWorkflow, except that when a run with the same workflow ID is already
+// running, its execution is returned instead of a WorkflowExecutionAlreadyStartedError. The run of the error is
+// described to tell it apart from a closed run rejected by the WorkflowIDReusePolicy.
+func (wc *workflowClient) StartWorkflowIfNotRunning(
+	ctx context.Context,
+	options StartWorkflowOptions,
+	workflowFunc interface{},
+	args ...interface{},
+) (*WorkflowExecution, error) {
+	executionInfo, err := wc.StartWorkflow(ctx, options, workflowFunc, args...)
+	alreadyStartedErr, ok := err.(*s.WorkflowExecutionAlreadyStartedError)
+	if !ok || options.ID == "" {
+		return executionInfo, err
+	}
+	response, describeErr := wc.DescribeWorkflowExecution(ctx, options.ID, alreadyStartedErr.GetRunId())
+	if describeErr != nil {
+		return nil, describeErr
+	}
+	if response.GetWorkflowExecutionInfo().CloseStatus != nil {
+		return nil, err
+	}
+	return &WorkflowExecution{
+		ID:    options.ID,
+		RunID: alreadyStartedErr.GetRunId(),
+	}, nil
+}
+
 // ExecuteWorkflow starts a workflow execution and returns a WorkflowRun that will allow you to wait until this workflow
 // reaches the end state, such as workflow finished successfully or timeout.
 // The user can use this to start using a functor like below and get the workflow execution result, as Value
@@ -294,6 +326,9 @@ func (wc *workflowClient) GetWorkflow(ctx context.Context, workflowID string, ru
 
 // SignalWorkflow signals a workflow in execution.
 func (wc *workflowClient) SignalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error {
+	if err := wc.namingPolicies.validateSignalName(signalName); err != nil {
+		return err
+	}
 	input, err := encodeArg(wc.dataConverter, arg)
 	if err != nil {
 		return err
@@ -320,7 +355,7 @@ func (wc *workflowClient) SignalWithStartWorkflow(
 	var response *s.StartWorkflowExecutionResponse
 
 	// Start creating workflow request.
-	err = backoff.Retry(ctx,
+	err = backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
@@ -366,7 +401,7 @@ func (wc *workflowClient) SignalWithStartWorkflowAsync(
 		Request: signalWithStartRequest,
 	}
 
-	err = backoff.Retry(ctx,
+	err = backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
@@ -412,7 +447,7 @@ func (wc *workflowClient) CancelWorkflow(ctx context.Context, workflowID string,
 		}
 	}
 
-	return backoff.Retry(ctx,
+	return backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
@@ -435,7 +470,7 @@ func (wc *workflowClient) TerminateWorkflow(ctx context.Context, workflowID stri
 		Identity: common.StringPtr(wc.identity),
 	}
 
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
@@ -453,9 +488,23 @@ func (wc *workflowClient) GetWorkflowHistory(
 	isLongPoll bool,
 	filterType s.HistoryEventFilterType,
 ) HistoryEventIterator {
+	return &historyEventIteratorImpl{
+		paginate: wc.getWorkflowHistoryPaginator(ctx, workflowID, runID, isLongPoll, filterType),
+	}
+}
+
+// getWorkflowHistoryPaginator returns a func which fetches the history page identified by the given next page token,
+// retrying transient errors and skipping empty long poll responses.
+func (wc *workflowClient) getWorkflowHistoryPaginator(
+	ctx context.Context,
+	workflowID string,
+	runID string,
+	isLongPoll bool,
+	filterType s.HistoryEventFilterType,
+) func(nextToken []byte) (*s.GetWorkflowExecutionHistoryResponse, error) {
 
 	domain := wc.domain
-	paginate := func(nextToken []byte) (*s.GetWorkflowExecutionHistoryResponse, error) {
+	return func(nextToken []byte) (*s.GetWorkflowExecutionHistoryResponse, error) {
 		request := &s.GetWorkflowExecutionHistoryRequest{
 			Domain: common.StringPtr(domain),
 			Execution: &s.WorkflowExecution{
@@ -473,7 +522,7 @@ func (wc *workflowClient) GetWorkflowHistory(
 	Loop:
 		for {
 			var isFinalLongPoll bool
-			err = backoff.Retry(ctx,
+			err = backoff.RetryWithClock(ctx, wc.clock,
 				func() error {
 					var err1 error
 					tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags, func(builder *contextBuilder) {
@@ -487,6 +536,9 @@ func (wc *workflowClient) GetWorkflowHistory(
 						}
 					})
 					defer cancel()
+					if isLongPoll {
+						opt = append(opt, yarpc.WithHeader(longPollHeaderName, "true"))
+					}
 					response, err1 = wc.workflowService.GetWorkflowExecutionHistory(tchCtx, request, opt...)
 
 					if err1 != nil {
@@ -526,10 +578,6 @@ func (wc *workflowClient) GetWorkflowHistory(
 		}
 		return response, nil
 	}
-
-	return &historyEventIteratorImpl{
-		paginate: paginate,
-	}
 }
 
 func isEntityNonExistFromPassive(err error) bool {
@@ -615,7 +663,7 @@ func (wc *workflowClient) ListClosedWorkflow(ctx context.Context, request *s.Lis
 		request.Domain = common.StringPtr(wc.domain)
 	}
 	var response *s.ListClosedWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
@@ -639,7 +687,7 @@ func (wc *workflowClient) ListOpenWorkflow(ctx context.Context, request *s.ListO
 		request.Domain = common.StringPtr(wc.domain)
 	}
 	var response *s.ListOpenWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
@@ -659,7 +707,7 @@ func (wc *workflowClient) ListWorkflow(ctx context.Context, request *s.ListWorkf
 		request.Domain = common.StringPtr(wc.domain)
 	}
 	var response *s.ListWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
@@ -679,7 +727,7 @@ func (wc *workflowClient) ListArchivedWorkflow(ctx context.Context, request *s.L
 		request.Domain = common.StringPtr(wc.domain)
 	}
 	var response *s.ListArchivedWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			var err1 error
 			timeout := maxListArchivedWorkflowTimeout
@@ -711,7 +759,7 @@ func (wc *workflowClient) ScanWorkflow(ctx context.Context, request *s.ListWorkf
 		request.Domain = common.StringPtr(wc.domain)
 	}
 	var response *s.ListWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
@@ -731,7 +779,7 @@ func (wc *workflowClient) CountWorkflow(ctx context.Context, request *s.CountWor
 		request.Domain = common.StringPtr(wc.domain)
 	}
 	var response *s.CountWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
@@ -751,7 +799,7 @@ func (wc *workflowClient) ResetWorkflow(ctx context.Context, request *s.ResetWor
 		request.Domain = common.StringPtr(wc.domain)
 	}
 	var response *s.ResetWorkflowExecutionResponse
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
@@ -768,7 +816,7 @@ func (wc *workflowClient) ResetWorkflow(ctx context.Context, request *s.ResetWor
 // GetSearchAttributes implementation
 func (wc *workflowClient) GetSearchAttributes(ctx context.Context) (*s.GetSearchAttributesResponse, error) {
 	var response *s.GetSearchAttributesResponse
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
@@ -782,6 +830,51 @@ func (wc *workflowClient) GetSearchAttributes(ctx context.Context) (*s.GetSearch
 	return response, nil
 }
 
+// GetClusterInfo returns the supported client versions and the capabilities of the Cadence cluster.
+// The errors it can return:
+//   - InternalServiceError
+//   - ServiceBusyError
+func (wc *workflowClient) GetClusterInfo(ctx context.Context) (*ClusterInfo, error) {
+	var response *s.ClusterInfo
+	err := backoff.RetryWithClock(ctx, wc.clock,
+		func() error {
+			var err1 error
+			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
+			defer cancel()
+			response, err1 = wc.workflowService.GetClusterInfo(tchCtx, opt...)
+			return err1
+		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ClusterInfo{
+		SupportedGoSDKVersion:   response.GetSupportedClientVersions().GetGoSdk(),
+		SupportedJavaSDKVersion: response.GetSupportedClientVersions().GetJavaSdk(),
+		BlobSizeLimit:           defaultServerBlobSizeLimit,
+		BlobSizeWarnLimit:       defaultServerBlobSizeWarnLimit,
+	}
+	for _, capability := range serviceCapabilities {
+		err := probeServiceCapability(ctx, wc.workflowService, wc.domain, capability, wc.featureFlags)
+		if err == nil {
+			info.Capabilities = append(info.Capabilities, capability)
+		} else if !isMissingServiceCapabilityError(err) {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// HasCapability reports whether the cluster provides the capability.
+func (c *ClusterInfo) HasCapability(capability ServiceCapability) bool {
+	for _, cc := range c.Capabilities {
+		if cc == capability {
+			return true
+		}
+	}
+	return false
+}
+
 // DescribeWorkflowExecution returns information about the specified workflow execution.
 // The errors it can return:
 //   - BadRequestError
@@ -796,7 +889,7 @@ func (wc *workflowClient) DescribeWorkflowExecution(ctx context.Context, workflo
 		},
 	}
 	var response *s.DescribeWorkflowExecutionResponse
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
@@ -810,6 +903,80 @@ func (wc *workflowClient) DescribeWorkflowExecution(ctx context.Context, workflo
 	return response, nil
 }
 
+// ListPendingActivities returns the scheduled or running activities of a workflow execution.
+// - workflow ID of the workflow.
+// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
+// The errors it can return:
+//   - BadRequestError
+//   - InternalServiceError
+//   - EntityNotExistError
+func (wc *workflowClient) ListPendingActivities(ctx context.Context, workflowID, runID string) ([]*s.PendingActivityInfo, error) {
+	response, err := wc.DescribeWorkflowExecution(ctx, workflowID, runID)
+	if err != nil {
+		return nil, err
+	}
+	return response.GetPendingActivities(), nil
+}
+
+// GetDecisionTaskFailure returns the failure of the pending decision task of a workflow execution while it keeps
+// failing, or nil if the pending decision task, if any, is on its first attempt. The server records only the first
+// failed attempt of a decision task in the history, so the failure describes that attempt, and its Attempt is the
+// attempt of the pending decision task.
+// - workflow ID of the workflow.
+// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
+// The errors it can return:
+//   - BadRequestError
+//   - InternalServiceError
+//   - EntityNotExistError
+func (wc *workflowClient) GetDecisionTaskFailure(ctx context.Context, workflowID, runID string) (*DecisionTaskFailure, error) {
+	response, err := wc.DescribeWorkflowExecution(ctx, workflowID, runID)
+	if err != nil {
+		return nil, err
+	}
+	pendingDecision := response.GetPendingDecision()
+	if pendingDecision == nil || pendingDecision.GetAttempt() == 0 {
+		return nil, nil
+	}
+
+	var failure *DecisionTaskFailure
+	iter := wc.GetWorkflowHistory(ctx, workflowID, response.GetWorkflowExecutionInfo().GetExecution().GetRunId(),
+		false, s.HistoryEventFilterTypeAllEvent)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		switch event.GetEventType() {
+		case s.EventTypeDecisionTaskCompleted:
+			// the failure was followed by a successful attempt, so it is not the one of the pending decision task
+			failure = nil
+			continue
+		case s.EventTypeDecisionTaskFailed:
+		default:
+			continue
+		}
+		attributes := event.DecisionTaskFailedEventAttributes
+		details, origin := parseDecisionTaskFailureDetails(attributes.Details)
+		message := string(details)
+		if message == "" {
+			message = attributes.GetReason()
+		}
+		failure = &DecisionTaskFailure{
+			Cause:          attributes.GetCause(),
+			Message:        message,
+			Decision:       origin.decision,
+			CallSite:       origin.callSite,
+			Identity:       attributes.GetIdentity(),
+			BinaryChecksum: attributes.GetBinaryChecksum(),
+			Time:           time.Unix(0, event.GetTimestamp()),
+		}
+	}
+	if failure != nil {
+		failure.Attempt = pendingDecision.GetAttempt()
+	}
+	return failure, nil
+}
+
 // QueryWorkflow queries a given workflow execution
 // workflowID and queryType are required, other parameters are optional.
 // - workflow ID of the workflow.
@@ -863,6 +1030,16 @@ type QueryWorkflowWithOptionsRequest struct {
 	// QueryConsistencyLevelEventual means that query will eventually reflect up to date state of a workflow.
 	// QueryConsistencyLevelStrong means that query will reflect a workflow state of having applied all events which came before the query.
 	QueryConsistencyLevel *s.QueryConsistencyLevel
+
+	// HedgeDelay is an optional field to hedge the query: when the query has not returned after HedgeDelay, the
+	// sticky task list of the workflow is reset and the same query is sent again, and the first successful response
+	// is returned while the other request is canceled. The reset makes the server send the second request to the task
+	// list of the workflow, so that it is not stuck behind the busy sticky worker the first one may be waiting for,
+	// at the cost of a replay of the workflow history by the worker of its next decision task. The "query-hedge" and
+	// "query-hedge-win" counters report how many queries were hedged and how many of them were answered by the second
+	// request.
+	// Optional: default is no hedging.
+	HedgeDelay time.Duration
 }
 
 // QueryWorkflowWithOptionsResponse is the response to QueryWorkflowWithOptions
@@ -883,6 +1060,9 @@ type QueryWorkflowWithOptionsResponse struct {
 //   - EntityNotExistError
 //   - QueryFailError
 func (wc *workflowClient) QueryWorkflowWithOptions(ctx context.Context, request *QueryWorkflowWithOptionsRequest) (*QueryWorkflowWithOptionsResponse, error) {
+	if err := wc.namingPolicies.validateQueryType(request.QueryType); err != nil {
+		return nil, err
+	}
 	var input []byte
 	if len(request.Args) > 0 {
 		var err error
@@ -905,12 +1085,10 @@ func (wc *workflowClient) QueryWorkflowWithOptions(ctx context.Context, request
 	}
 
 	var resp *s.QueryWorkflowResponse
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
-			tchCtx, cancel, opt := newChannelContextForQuery(ctx, wc.featureFlags)
-			defer cancel()
 			var err error
-			resp, err = wc.workflowService.QueryWorkflow(tchCtx, req, opt...)
+			resp, err = wc.queryWorkflowHedged(ctx, req, request.HedgeDelay)
 			return err
 		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
 	if err != nil {
@@ -929,6 +1107,73 @@ func (wc *workflowClient) QueryWorkflowWithOptions(ctx context.Context, request
 	}, nil
 }
 
+// queryWorkflowHedged sends the query, and sends it again after resetting the sticky task list of the workflow when it
+// has not returned after hedgeDelay. It returns the first successful response, or the last error.
+func (wc *workflowClient) queryWorkflowHedged(ctx context.Context, req *s.QueryWorkflowRequest, hedgeDelay time.Duration) (*s.QueryWorkflowResponse, error) {
+	query := func(ctx context.Context) (*s.QueryWorkflowResponse, error) {
+		tchCtx, cancel, opt := newChannelContextForQuery(ctx, wc.featureFlags)
+		defer cancel()
+		return wc.workflowService.QueryWorkflow(tchCtx, req, opt...)
+	}
+	if hedgeDelay <= 0 {
+		return query(ctx)
+	}
+
+	type queryResult struct {
+		resp  *s.QueryWorkflowResponse
+		err   error
+		hedge bool
+	}
+	// the request which did not return first is canceled
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan queryResult, 2)
+	send := func(hedge bool) {
+		go func() {
+			if hedge {
+				// the hedge is still sent when the reset fails, it may then be answered by the same worker
+				tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
+				_, _ = wc.workflowService.ResetStickyTaskList(tchCtx, &s.ResetStickyTaskListRequest{
+					Domain:    req.Domain,
+					Execution: req.Execution,
+				}, opt...)
+				cancel()
+			}
+			resp, err := query(ctx)
+			results <- queryResult{resp: resp, err: err, hedge: hedge}
+		}()
+	}
+
+	send(false)
+	pending := 1
+	timer := wc.clock.NewTimer(hedgeDelay)
+	defer timer.Stop()
+	for {
+		var result queryResult
+		select {
+		case <-timer.Chan():
+			if wc.metricsScope != nil {
+				wc.metricsScope.GetTaggedScope(tagQueryType, req.Query.GetQueryType()).Counter(metrics.QueryHedgeCounter).Inc(1)
+			}
+			send(true)
+			pending++
+			continue
+		case result = <-results:
+			pending--
+		}
+		if result.err != nil {
+			if pending > 0 {
+				continue // wait for the other request
+			}
+			return nil, result.err
+		}
+		if result.hedge && wc.metricsScope != nil {
+			wc.metricsScope.GetTaggedScope(tagQueryType, req.Query.GetQueryType()).Counter(metrics.QueryHedgeWins).Inc(1)
+		}
+		return result.resp, nil
+	}
+}
+
 // DescribeTaskList returns information about the target tasklist, right now this API returns the
 // pollers which polled this tasklist in last few minutes.
 // - tasklist name of tasklist
@@ -945,7 +1190,7 @@ func (wc *workflowClient) DescribeTaskList(ctx context.Context, tasklist string,
 	}
 
 	var resp *s.DescribeTaskListResponse
-	err := backoff.Retry(ctx,
+	err := backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
@@ -977,7 +1222,7 @@ func (wc *workflowClient) RefreshWorkflowTasks(ctx context.Context, workflowID,
 		},
 	}
 
-	return backoff.Retry(ctx,
+	return backoff.RetryWithClock(ctx, wc.clock,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
@@ -1006,21 +1251,16 @@ func (wc *workflowClient) getWorkflowStartRequest(
 	workflowID := options.ID
 	if len(workflowID) == 0 {
 		workflowID = uuid.NewRandom().String()
+	} else if err := wc.namingPolicies.validateWorkflowID(workflowID); err != nil {
+		return nil, err
 	}
 
-	if options.TaskList == "" {
-		return nil, errors.New("missing TaskList")
+	if err := options.Validate(); err != nil {
+		return nil, err
 	}
 
 	executionTimeout := common.Int32Ceil(options.ExecutionStartToCloseTimeout.Seconds())
-	if executionTimeout <= 0 {
-		return nil, errors.New("missing or invalid ExecutionStartToCloseTimeout")
-	}
-
 	decisionTaskTimeout := common.Int32Ceil(options.DecisionTaskStartToCloseTimeout.Seconds())
-	if decisionTaskTimeout < 0 {
-		return nil, errors.New("negative DecisionTaskStartToCloseTimeout provided")
-	}
 	if decisionTaskTimeout == 0 {
 		decisionTaskTimeout = defaultDecisionTaskTimeoutInSecs
 	}
@@ -1042,22 +1282,11 @@ func (wc *workflowClient) getWorkflowStartRequest(
 	}
 
 	delayStartSeconds := common.Int32Ceil(options.DelayStart.Seconds())
-	if delayStartSeconds < 0 {
-		return nil, errors.New("Invalid DelayStart option")
-	}
-
 	jitterStartSeconds := common.Int32Ceil(options.JitterStart.Seconds())
-	if jitterStartSeconds < 0 {
-		return nil, errors.New("Invalid JitterStart option")
-	}
-
 	firstRunAtTimestamp := options.FirstRunAt.UnixNano()
 	if options.FirstRunAt.IsZero() {
 		firstRunAtTimestamp = 0
 	}
-	if firstRunAtTimestamp < 0 {
-		return nil, errors.New("Invalid FirstRunAt option")
-	}
 
 	// create a workflow start span and attach it to the context object.
 	// N.B. we need to finish this immediately as jaeger does not give us a way
@@ -1071,11 +1300,17 @@ func (wc *workflowClient) getWorkflowStartRequest(
 
 	// get workflow headers from the context
 	header := wc.getWorkflowHeader(ctx)
+	if err := writeDefaultActivityOptionsHeader(header, newDefaultActivityOptions(options)); err != nil {
+		return nil, err
+	}
+	if err := writeWorkflowHeadersHeader(header, options.Headers); err != nil {
+		return nil, err
+	}
 
 	// run propagators to extract information about tracing and other stuff, store in headers field
 	startRequest := &s.StartWorkflowExecutionRequest{
 		Domain:                              common.StringPtr(wc.domain),
-		RequestId:                           common.StringPtr(uuid.New()),
+		RequestId:                           common.StringPtr(getRequestID(options.RequestID)),
 		WorkflowId:                          common.StringPtr(workflowID),
 		WorkflowType:                        workflowTypePtr(*workflowType),
 		TaskList:                            common.TaskListPtr(s.TaskList{Name: common.StringPtr(options.TaskList)}),
@@ -1106,6 +1341,10 @@ func (wc *workflowClient) getSignalWithStartRequest(
 	workflowArgs ...interface{},
 ) (*s.SignalWithStartWorkflowExecutionRequest, error) {
 
+	if err := wc.namingPolicies.validateSignalName(signalName); err != nil {
+		return nil, err
+	}
+
 	signalInput, err := encodeArg(wc.dataConverter, signalArg)
 	if err != nil {
 		return nil, err
@@ -1113,21 +1352,16 @@ func (wc *workflowClient) getSignalWithStartRequest(
 
 	if workflowID == "" {
 		workflowID = uuid.NewRandom().String()
+	} else if err := wc.namingPolicies.validateWorkflowID(workflowID); err != nil {
+		return nil, err
 	}
 
-	if options.TaskList == "" {
-		return nil, errors.New("missing TaskList")
+	if err := options.Validate(); err != nil {
+		return nil, err
 	}
 
 	executionTimeout := common.Int32Ceil(options.ExecutionStartToCloseTimeout.Seconds())
-	if executionTimeout <= 0 {
-		return nil, errors.New("missing or invalid ExecutionStartToCloseTimeout")
-	}
-
 	decisionTaskTimeout := common.Int32Ceil(options.DecisionTaskStartToCloseTimeout.Seconds())
-	if decisionTaskTimeout < 0 {
-		return nil, errors.New("negative DecisionTaskStartToCloseTimeout provided")
-	}
 	if decisionTaskTimeout == 0 {
 		decisionTaskTimeout = defaultDecisionTaskTimeoutInSecs
 	}
@@ -1149,22 +1383,11 @@ func (wc *workflowClient) getSignalWithStartRequest(
 	}
 
 	delayStartSeconds := common.Int32Ceil(options.DelayStart.Seconds())
-	if delayStartSeconds < 0 {
-		return nil, errors.New("Invalid DelayStart option")
-	}
-
 	jitterStartSeconds := common.Int32Ceil(options.JitterStart.Seconds())
-	if jitterStartSeconds < 0 {
-		return nil, errors.New("Invalid JitterStart option")
-	}
-
 	firstRunAtTimestamp := options.FirstRunAt.UnixNano()
 	if options.FirstRunAt.IsZero() {
 		firstRunAtTimestamp = 0
 	}
-	if firstRunAtTimestamp < 0 {
-		return nil, errors.New("Invalid FirstRunAt option")
-	}
 
 	// create a workflow start span and attach it to the context object. finish it immediately
 	ctx, span := createOpenTracingWorkflowSpan(ctx, wc.tracer, time.Now(), fmt.Sprintf("%s-%s", tracePrefix, workflowType.Name), workflowID)
@@ -1172,10 +1395,16 @@ func (wc *workflowClient) getSignalWithStartRequest(
 
 	// get workflow headers from the context
 	header := wc.getWorkflowHeader(ctx)
+	if err := writeDefaultActivityOptionsHeader(header, newDefaultActivityOptions(options)); err != nil {
+		return nil, err
+	}
+	if err := writeWorkflowHeadersHeader(header, options.Headers); err != nil {
+		return nil, err
+	}
 
 	signalWithStartRequest := &s.SignalWithStartWorkflowExecutionRequest{
 		Domain:                              common.StringPtr(wc.domain),
-		RequestId:                           common.StringPtr(uuid.New()),
+		RequestId:                           common.StringPtr(getRequestID(options.RequestID)),
 		WorkflowId:                          common.StringPtr(workflowID),
 		WorkflowType:                        workflowTypePtr(*workflowType),
 		TaskList:                            common.TaskListPtr(s.TaskList{Name: common.StringPtr(options.TaskList)}),
@@ -1199,6 +1428,13 @@ func (wc *workflowClient) getSignalWithStartRequest(
 	return signalWithStartRequest, nil
 }
 
+func getRequestID(requestID string) string {
+	if requestID == "" {
+		return uuid.New()
+	}
+	return requestID
+}
+
 func getRunID(runID string) *string {
 	if runID == "" {
 		// Cadence Server will pick current runID if provided empty.
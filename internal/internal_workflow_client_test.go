@@ -25,14 +25,17 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/jonboulle/clockwork"
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
 	"go.uber.org/yarpc"
 
 	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
@@ -152,6 +155,7 @@ func (s *historyEventIteratorSuite) SetupTest() {
 	s.wfClient = &workflowClient{
 		workflowService: s.workflowServiceClient,
 		domain:          domain,
+		clock:           clockwork.NewRealClock(),
 	}
 }
 
@@ -424,7 +428,7 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoDup_Success() {
 		},
 		NextPageToken: nil,
 	}
-	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptions()...).Return(getResponse, nil).Times(1)
+	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptionsWithLongPollHeader()...).Return(getResponse, nil).Times(1)
 
 	workflowRun, err := s.workflowClient.ExecuteWorkflow(
 		context.Background(),
@@ -472,7 +476,7 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoDup_RawHistory_Success() {
 		},
 		NextPageToken: nil,
 	}
-	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptions()...).Return(getResponse, nil).Times(1)
+	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptionsWithLongPollHeader()...).Return(getResponse, nil).Times(1)
 
 	workflowRun, err := s.workflowClient.ExecuteWorkflow(
 		context.Background(),
@@ -518,9 +522,9 @@ func (s *workflowRunSuite) TestExecuteWorkflowWorkflowExecutionAlreadyStartedErr
 		},
 		NextPageToken: nil,
 	}
-	getHistory := s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).
+	getHistory := s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptionsWithLongPollHeader()...).
 		Return(getResponse, nil).Times(1)
-	getHistory.Do(func(ctx interface{}, getRequest *shared.GetWorkflowExecutionHistoryRequest, opt1 interface{}, opt2 interface{}, opt3 interface{}, opt4 interface{}) {
+	getHistory.Do(func(ctx interface{}, getRequest *shared.GetWorkflowExecutionHistoryRequest, opt1 interface{}, opt2 interface{}, opt3 interface{}, opt4 interface{}, opt5 interface{}) {
 		workflowID := getRequest.Execution.WorkflowId
 		s.NotNil(workflowID)
 		s.NotEmpty(*workflowID)
@@ -574,9 +578,9 @@ func (s *workflowRunSuite) TestExecuteWorkflowWorkflowExecutionAlreadyStartedErr
 		},
 		NextPageToken: nil,
 	}
-	getHistory := s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).
+	getHistory := s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptionsWithLongPollHeader()...).
 		Return(getResponse, nil).Times(1)
-	getHistory.Do(func(ctx interface{}, getRequest *shared.GetWorkflowExecutionHistoryRequest, opt1 interface{}, opt2 interface{}, opt3 interface{}, opt4 interface{}) {
+	getHistory.Do(func(ctx interface{}, getRequest *shared.GetWorkflowExecutionHistoryRequest, opt1 interface{}, opt2 interface{}, opt3 interface{}, opt4 interface{}, opt5 interface{}) {
 		workflowID := getRequest.Execution.WorkflowId
 		s.NotNil(workflowID)
 		s.NotEmpty(*workflowID)
@@ -626,8 +630,8 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoIdInOptions() {
 		NextPageToken: nil,
 	}
 	var wid *string
-	getHistory := s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(getResponse, nil).Times(1)
-	getHistory.Do(func(ctx interface{}, getRequest *shared.GetWorkflowExecutionHistoryRequest, opt1 interface{}, opt2 interface{}, opt3 interface{}, opt4 interface{}) {
+	getHistory := s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptionsWithLongPollHeader()...).Return(getResponse, nil).Times(1)
+	getHistory.Do(func(ctx interface{}, getRequest *shared.GetWorkflowExecutionHistoryRequest, opt1 interface{}, opt2 interface{}, opt3 interface{}, opt4 interface{}, opt5 interface{}) {
 		wid = getRequest.Execution.WorkflowId
 		s.NotNil(wid)
 		s.NotEmpty(*wid)
@@ -679,8 +683,8 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoIdInOptions_RawHistory() {
 		NextPageToken: nil,
 	}
 	var wid *string
-	getHistory := s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(getResponse, nil).Times(1)
-	getHistory.Do(func(ctx interface{}, getRequest *shared.GetWorkflowExecutionHistoryRequest, opt1 interface{}, opt2 interface{}, opt3 interface{}, opt4 interface{}) {
+	getHistory := s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptionsWithLongPollHeader()...).Return(getResponse, nil).Times(1)
+	getHistory.Do(func(ctx interface{}, getRequest *shared.GetWorkflowExecutionHistoryRequest, opt1 interface{}, opt2 interface{}, opt3 interface{}, opt4 interface{}, opt5 interface{}) {
 		wid = getRequest.Execution.WorkflowId
 		s.NotNil(wid)
 		s.NotEmpty(*wid)
@@ -728,7 +732,7 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoDup_Cancelled() {
 		},
 		NextPageToken: nil,
 	}
-	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptions()...).Return(getResponse, nil).Times(1)
+	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptionsWithLongPollHeader()...).Return(getResponse, nil).Times(1)
 
 	workflowRun, err := s.workflowClient.ExecuteWorkflow(
 		context.Background(),
@@ -778,7 +782,7 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoDup_Failed() {
 		},
 		NextPageToken: nil,
 	}
-	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptions()...).Return(getResponse, nil).Times(1)
+	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptionsWithLongPollHeader()...).Return(getResponse, nil).Times(1)
 
 	workflowRun, err := s.workflowClient.ExecuteWorkflow(
 		context.Background(),
@@ -819,7 +823,7 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoDup_Terminated() {
 		},
 		NextPageToken: nil,
 	}
-	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptions()...).Return(getResponse, nil).Times(1)
+	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptionsWithLongPollHeader()...).Return(getResponse, nil).Times(1)
 
 	workflowRun, err := s.workflowClient.ExecuteWorkflow(
 		context.Background(),
@@ -863,7 +867,7 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoDup_TimedOut() {
 		},
 		NextPageToken: nil,
 	}
-	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptions()...).Return(getResponse, nil).Times(1)
+	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptionsWithLongPollHeader()...).Return(getResponse, nil).Times(1)
 
 	workflowRun, err := s.workflowClient.ExecuteWorkflow(
 		context.Background(),
@@ -910,7 +914,7 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoDup_ContinueAsNew() {
 		},
 		NextPageToken: nil,
 	}
-	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest1, callOptions()...).Return(getResponse1, nil).Times(1)
+	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest1, callOptionsWithLongPollHeader()...).Return(getResponse1, nil).Times(1)
 
 	workflowResult := time.Hour * 59
 	encodedResult, _ := encodeArg(getDefaultDataConverter(), workflowResult)
@@ -930,7 +934,7 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoDup_ContinueAsNew() {
 		},
 		NextPageToken: nil,
 	}
-	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest2, callOptions()...).Return(getResponse2, nil).Times(1)
+	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest2, callOptionsWithLongPollHeader()...).Return(getResponse2, nil).Times(1)
 
 	workflowRun, err := s.workflowClient.ExecuteWorkflow(
 		context.Background(),
@@ -970,7 +974,7 @@ func (s *workflowRunSuite) TestGetWorkflow() {
 		},
 		NextPageToken: nil,
 	}
-	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptions()...).Return(getResponse, nil).Times(1)
+	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptionsWithLongPollHeader()...).Return(getResponse, nil).Times(1)
 
 	workflowID := workflowID
 	runID := runID
@@ -1082,7 +1086,7 @@ func (s *workflowClientTestSuite) TestSignalWithStartWorkflow_RPCError() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 	resp, err := s.client.SignalWithStartWorkflow(ctx, workflowID, signalName, signalInput, options, workflowType)
-	s.Equal(errors.New("missing TaskList"), err)
+	s.ErrorContains(err, "TaskList is not set")
 	s.Nil(resp)
 
 	// Pass a context with a deadline so error retry doesn't take forever
@@ -1263,7 +1267,7 @@ func (s *workflowClientTestSuite) TestStartWorkflow_RequestCreationFails() {
 	}
 
 	_, err := client.StartWorkflow(context.Background(), options, f1, []byte("test"))
-	s.ErrorContains(err, "missing TaskList")
+	s.ErrorContains(err, "TaskList is not set")
 }
 
 func (s *workflowClientTestSuite) TestStartWorkflow_RPCError() {
@@ -1287,6 +1291,77 @@ func (s *workflowClientTestSuite) TestStartWorkflow_RPCError() {
 	s.Error(err)
 }
 
+func (s *workflowClientTestSuite) TestStartWorkflow_WithRequestID() {
+	options := StartWorkflowOptions{
+		ID:                              workflowID,
+		RequestID:                       "my-request-id",
+		TaskList:                        tasklist,
+		ExecutionStartToCloseTimeout:    timeoutInSeconds,
+		DecisionTaskStartToCloseTimeout: timeoutInSeconds,
+	}
+	wf := func(ctx Context) string {
+		return "result"
+	}
+	startResp := &shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}
+
+	s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(startResp, nil).
+		Do(func(_ interface{}, req *shared.StartWorkflowExecutionRequest, _ ...interface{}) {
+			s.Equal("my-request-id", req.GetRequestId())
+		})
+	_, err := s.client.StartWorkflow(context.Background(), options, wf)
+	s.NoError(err)
+}
+
+func (s *workflowClientTestSuite) TestStartWorkflowIfNotRunning() {
+	options := StartWorkflowOptions{
+		ID:                              workflowID,
+		TaskList:                        tasklist,
+		ExecutionStartToCloseTimeout:    timeoutInSeconds,
+		DecisionTaskStartToCloseTimeout: timeoutInSeconds,
+	}
+	wf := func(ctx Context) string {
+		return "result"
+	}
+
+	s.Run("started", func() {
+		startResp := &shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}
+		s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(startResp, nil)
+		resp, err := s.client.StartWorkflowIfNotRunning(context.Background(), options, wf)
+		s.NoError(err)
+		s.Equal(&WorkflowExecution{ID: workflowID, RunID: runID}, resp)
+	})
+	s.Run("already running", func() {
+		alreadyStarted := &shared.WorkflowExecutionAlreadyStartedError{RunId: common.StringPtr("existing-run-id")}
+		s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, alreadyStarted)
+		s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&shared.DescribeWorkflowExecutionResponse{WorkflowExecutionInfo: &shared.WorkflowExecutionInfo{}}, nil).
+			Do(func(_ interface{}, req *shared.DescribeWorkflowExecutionRequest, _ ...interface{}) {
+				s.Equal(workflowID, req.GetExecution().GetWorkflowId())
+				s.Equal("existing-run-id", req.GetExecution().GetRunId())
+			})
+		resp, err := s.client.StartWorkflowIfNotRunning(context.Background(), options, wf)
+		s.NoError(err)
+		s.Equal(&WorkflowExecution{ID: workflowID, RunID: "existing-run-id"}, resp)
+	})
+	s.Run("already closed", func() {
+		alreadyStarted := &shared.WorkflowExecutionAlreadyStartedError{RunId: common.StringPtr("closed-run-id")}
+		s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, alreadyStarted)
+		s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&shared.DescribeWorkflowExecutionResponse{WorkflowExecutionInfo: &shared.WorkflowExecutionInfo{
+				CloseStatus: shared.WorkflowExecutionCloseStatusCompleted.Ptr(),
+			}}, nil)
+		resp, err := s.client.StartWorkflowIfNotRunning(context.Background(), options, wf)
+		s.Equal(alreadyStarted, err)
+		s.Nil(resp)
+	})
+	s.Run("other error", func() {
+		s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &shared.BadRequestError{})
+		resp, err := s.client.StartWorkflowIfNotRunning(context.Background(), options, wf)
+		s.Error(err)
+		s.Nil(resp)
+	})
+}
+
 func (s *workflowClientTestSuite) TestSignalWithStartWorkflow_WithMemoAndSearchAttr() {
 	memo := map[string]interface{}{
 		"testMemo": "memo value",
@@ -1363,7 +1438,7 @@ func (s *workflowClientTestSuite) TestSignalWithStartWorkflow_RequestCreationFai
 	}
 
 	_, err := s.client.SignalWithStartWorkflow(context.Background(), "wid", "signal", "value", options, wf)
-	s.ErrorContains(err, "missing TaskList")
+	s.ErrorContains(err, "TaskList is not set")
 }
 
 func (s *workflowClientTestSuite) TestSignalWithStartWorkflowAsync_RequestCreationFails() {
@@ -1378,7 +1453,7 @@ func (s *workflowClientTestSuite) TestSignalWithStartWorkflowAsync_RequestCreati
 	}
 
 	_, err := s.client.SignalWithStartWorkflowAsync(context.Background(), "wid", "signal", "value", options, wf)
-	s.ErrorContains(err, "missing TaskList")
+	s.ErrorContains(err, "TaskList is not set")
 }
 
 func (s *workflowClientTestSuite) TestSignalWithStartWorkflowAsync_RPCError() {
@@ -1522,7 +1597,7 @@ func (s *workflowClientTestSuite) TestStartWorkflowAsync_RequestCreationFails()
 		return "result"
 	}
 	_, err := client.StartWorkflowAsync(context.Background(), options, f1, []byte("test"))
-	s.ErrorContains(err, "missing TaskList")
+	s.ErrorContains(err, "TaskList is not set")
 }
 
 func (s *workflowClientTestSuite) TestStartWorkflowAsync_RPCError() {
@@ -1696,6 +1771,350 @@ func (s *workflowClientTestSuite) TestGetSearchAttributes() {
 	s.Equal(responseErr, err)
 }
 
+func (s *workflowClientTestSuite) TestGetClusterInfo() {
+	s.service.EXPECT().GetClusterInfo(gomock.Any(), gomock.Any()).Return(&shared.ClusterInfo{
+		SupportedClientVersions: &shared.SupportedClientVersions{GoSdk: common.StringPtr(">=1.0.0 <2.0.0")},
+	}, nil)
+	s.service.EXPECT().CountWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.CountWorkflowExecutionsResponse{}, nil).
+		Do(func(_ interface{}, req *shared.CountWorkflowExecutionsRequest, _ ...interface{}) {
+			s.Equal(domain, req.GetDomain())
+		})
+	s.service.EXPECT().StartWorkflowExecutionAsync(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &shared.FeatureNotEnabledError{})
+
+	info, err := s.client.GetClusterInfo(context.Background())
+	s.NoError(err)
+	s.Equal(&ClusterInfo{
+		SupportedGoSDKVersion: ">=1.0.0 <2.0.0",
+		Capabilities:          []ServiceCapability{ServiceCapabilityAdvancedVisibility},
+		BlobSizeLimit:         2 * 1024 * 1024,
+		BlobSizeWarnLimit:     512 * 1024,
+	}, info)
+	s.True(info.HasCapability(ServiceCapabilityAdvancedVisibility))
+	s.False(info.HasCapability(ServiceCapabilityAsyncAPIs))
+
+	responseErr := &shared.AccessDeniedError{}
+	s.service.EXPECT().GetClusterInfo(gomock.Any(), gomock.Any()).Return(&shared.ClusterInfo{}, nil)
+	s.service.EXPECT().CountWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, responseErr)
+	_, err = s.client.GetClusterInfo(context.Background())
+	s.Equal(responseErr, err)
+}
+
+func (s *workflowClientTestSuite) TestNamingPolicies() {
+	client := NewClient(s.service, domain, &ClientOptions{
+		Identity: identity,
+		NamingPolicies: NamingPolicies{
+			WorkflowID: NamingPolicy{MaxLength: 8},
+			SignalName: NamingPolicy{Pattern: regexp.MustCompile(`^[a-z-]+$`)},
+			QueryType:  NamingPolicy{Pattern: regexp.MustCompile(`^[a-z-]+$`)},
+		},
+	})
+	options := StartWorkflowOptions{
+		ID:                              "order-123456",
+		TaskList:                        tasklist,
+		ExecutionStartToCloseTimeout:    timeoutInSeconds,
+		DecisionTaskStartToCloseTimeout: timeoutInSeconds,
+	}
+
+	// invalid names are rejected without calling the service
+	_, err := client.StartWorkflow(context.Background(), options, workflowType)
+	s.EqualError(err, `invalid workflow ID "order-123456": length 12 exceeds the maximum of 8 bytes`)
+	err = client.SignalWorkflow(context.Background(), workflowID, runID, "Payment_Received", nil)
+	s.IsType(&NamingPolicyError{}, err)
+	_, err = client.SignalWithStartWorkflow(context.Background(), "order-1", "Payment_Received", nil, options, workflowType)
+	s.IsType(&NamingPolicyError{}, err)
+	_, err = client.QueryWorkflow(context.Background(), workflowID, runID, "State")
+	s.IsType(&NamingPolicyError{}, err)
+
+	// generated workflow IDs and built-in query types are not checked
+	options.ID = ""
+	s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil)
+	_, err = client.StartWorkflow(context.Background(), options, workflowType)
+	s.NoError(err)
+	s.service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.QueryWorkflowResponse{QueryResult: []byte(`"stack"`)}, nil)
+	_, err = client.QueryWorkflow(context.Background(), workflowID, runID, QueryTypeStackTrace)
+	s.NoError(err)
+}
+
+func (s *workflowClientTestSuite) TestEntityClient() {
+	entities, err := NewEntityClient(s.client, EntityClientOptions{
+		WorkflowIDPrefix: "cart:",
+		Workflow:         workflowType,
+		WorkflowOptions: StartWorkflowOptions{
+			ID:                              "ignored",
+			TaskList:                        tasklist,
+			ExecutionStartToCloseTimeout:    timeoutInSeconds,
+			DecisionTaskStartToCloseTimeout: timeoutInSeconds,
+			WorkflowIDReusePolicy:           WorkflowIDReusePolicyRejectDuplicate,
+		},
+	})
+	s.NoError(err)
+	s.Equal("cart:alice", entities.WorkflowID("alice"))
+
+	s.service.EXPECT().SignalWithStartWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil).
+		Do(func(_ interface{}, req *shared.SignalWithStartWorkflowExecutionRequest, _ ...interface{}) {
+			s.Equal("cart:alice", req.GetWorkflowId())
+			s.Equal("add-item", req.GetSignalName())
+			s.Equal(shared.WorkflowIdReusePolicyAllowDuplicate, req.GetWorkflowIdReusePolicy())
+		})
+	execution, err := entities.Signal(context.Background(), "alice", "add-item", "book")
+	s.NoError(err)
+	s.Equal(WorkflowExecution{ID: "cart:alice", RunID: runID}, *execution)
+
+	heartbeat := EntityHeartbeat{WorkflowID: "cart:alice", RunID: runID, Signals: 3}
+	result, err := getDefaultDataConverter().ToData(heartbeat)
+	s.NoError(err)
+	s.service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.QueryWorkflowResponse{QueryResult: result}, nil).
+		Do(func(_ interface{}, req *shared.QueryWorkflowRequest, _ ...interface{}) {
+			s.Equal("cart:alice", req.GetExecution().GetWorkflowId())
+			s.Equal(EntityHeartbeatQueryType, req.GetQuery().GetQueryType())
+		})
+	queried, err := entities.Heartbeat(context.Background(), "alice")
+	s.NoError(err)
+	s.Equal(heartbeat, *queried)
+
+	_, err = NewEntityClient(s.client, EntityClientOptions{})
+	s.Error(err)
+}
+
+func (s *workflowClientTestSuite) TestEntityCommandStub() {
+	entities, err := NewEntityClient(s.client, EntityClientOptions{
+		WorkflowIDPrefix: "cart:",
+		Workflow:         workflowType,
+		WorkflowOptions: StartWorkflowOptions{
+			TaskList:                        tasklist,
+			ExecutionStartToCloseTimeout:    timeoutInSeconds,
+			DecisionTaskStartToCloseTimeout: timeoutInSeconds,
+		},
+	})
+	s.NoError(err)
+	dc := getDefaultDataConverter()
+	add := NewEntityCommandStub[string, int](entities, "add")
+
+	var request EntityCommandRequest
+	s.service.EXPECT().SignalWithStartWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil).
+		Do(func(_ interface{}, req *shared.SignalWithStartWorkflowExecutionRequest, _ ...interface{}) {
+			s.Equal(EntityCommandSignalName, req.GetSignalName())
+			s.NoError(dc.FromData(req.SignalInput, &request))
+			var snapshot *EntitySnapshot[struct{}]
+			s.NoError(dc.FromData(req.Input, &snapshot))
+			s.Nil(snapshot)
+		})
+	future, err := add.Send(context.Background(), "alice", "book")
+	s.NoError(err)
+	s.Equal(future.CommandID(), request.ID)
+	s.Equal("add", request.Name)
+	var item string
+	s.NoError(dc.FromData(request.Payload, &item))
+	s.Equal("book", item)
+
+	queryResult := func(result EntityCommandResult) *shared.QueryWorkflowResponse {
+		data, err := dc.ToData(result)
+		s.NoError(err)
+		return &shared.QueryWorkflowResponse{QueryResult: data}
+	}
+	count, err := dc.ToData(1)
+	s.NoError(err)
+	gomock.InOrder(
+		s.service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), callOptions()...).
+			Return(queryResult(EntityCommandResult{ID: request.ID}), nil),
+		s.service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), callOptions()...).
+			Return(queryResult(EntityCommandResult{ID: request.ID, Handled: true, Result: count}), nil).
+			Do(func(_ interface{}, req *shared.QueryWorkflowRequest, _ ...interface{}) {
+				s.Equal("cart:alice", req.GetExecution().GetWorkflowId())
+				s.Equal(EntityCommandResultQueryType, req.GetQuery().GetQueryType())
+				s.Equal(shared.QueryConsistencyLevelStrong, req.GetQueryConsistencyLevel())
+				var id string
+				s.NoError(dc.FromData(req.GetQuery().GetQueryArgs(), &id))
+				s.Equal(request.ID, id)
+			}),
+		s.service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), callOptions()...).
+			Return(queryResult(EntityCommandResult{ID: request.ID, Handled: true, Error: "cart is full"}), nil),
+		s.service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), callOptions()...).
+			Return(queryResult(EntityCommandResult{ID: request.ID, Unknown: true}), nil),
+	)
+	result, err := future.Get(context.Background())
+	s.NoError(err)
+	s.Equal(1, result)
+
+	_, err = future.Get(context.Background())
+	s.Equal(&EntityCommandError{Command: request.ID, Message: "cart is full"}, err)
+
+	_, err = future.Get(context.Background())
+	s.Equal(ErrUnknownEntityCommand, err)
+}
+
+func (s *workflowClientTestSuite) TestWorkflowRequestClient() {
+	requests, err := NewWorkflowRequestClient(s.client, WorkflowRequestClientOptions{TaskList: tasklist})
+	s.NoError(err)
+	dc := getDefaultDataConverter()
+	closeEvent := func(eventType shared.EventType, response WorkflowResponse) *shared.GetWorkflowExecutionHistoryResponse {
+		event := &shared.HistoryEvent{EventType: eventType.Ptr()}
+		if eventType == shared.EventTypeWorkflowExecutionCompleted {
+			result, err := dc.ToData(response)
+			s.NoError(err)
+			event.WorkflowExecutionCompletedEventAttributes = &shared.WorkflowExecutionCompletedEventAttributes{Result: result}
+		} else {
+			event.WorkflowExecutionTimedOutEventAttributes = &shared.WorkflowExecutionTimedOutEventAttributes{
+				TimeoutType: shared.TimeoutTypeStartToClose.Ptr(),
+			}
+		}
+		return &shared.GetWorkflowExecutionHistoryResponse{History: &shared.History{Events: []*shared.HistoryEvent{event}}}
+	}
+
+	var request WorkflowRequest
+	s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil).
+		Do(func(_ interface{}, req *shared.StartWorkflowExecutionRequest, _ ...interface{}) {
+			s.Equal(WorkflowResponseListenerName, req.GetWorkflowType().GetName())
+			s.Equal(tasklist, req.GetTaskList().GetName())
+			s.Equal(int32(60), req.GetExecutionStartToCloseTimeoutSeconds())
+		}).Times(3)
+	s.service.EXPECT().SignalWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(nil).
+		Do(func(_ interface{}, req *shared.SignalWorkflowExecutionRequest, _ ...interface{}) {
+			s.Equal("pricing", req.GetWorkflowExecution().GetWorkflowId())
+			s.Equal("quote", req.GetSignalName())
+			s.NoError(dc.FromData(req.Input, &request))
+		}).Times(3)
+	price, err := dc.ToData(42)
+	s.NoError(err)
+	gomock.InOrder(
+		s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptionsWithLongPollHeader()...).
+			DoAndReturn(func(_ interface{}, req *shared.GetWorkflowExecutionHistoryRequest, _ ...interface{}) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+				s.Equal(request.ReplyTo.ID, req.GetExecution().GetWorkflowId())
+				return closeEvent(shared.EventTypeWorkflowExecutionCompleted, WorkflowResponse{ID: request.ID, Payload: price}), nil
+			}),
+		s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptionsWithLongPollHeader()...).
+			Return(closeEvent(shared.EventTypeWorkflowExecutionCompleted, WorkflowResponse{Error: "out of stock"}), nil),
+		s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptionsWithLongPollHeader()...).
+			Return(closeEvent(shared.EventTypeWorkflowExecutionTimedOut, WorkflowResponse{}), nil),
+	)
+
+	var result int
+	s.NoError(requests.Request(context.Background(), "pricing", "", "quote", "book", &result))
+	s.Equal(42, result)
+	s.Equal("cadence-workflow-response:"+request.ID, request.ReplyTo.ID)
+	s.Equal(runID, request.ReplyTo.RunID)
+	s.Equal(DefaultWorkflowResponseSignalName, request.ResponseSignalName)
+	var item string
+	s.NoError(dc.FromData(request.Payload, &item))
+	s.Equal("book", item)
+
+	err = requests.Request(context.Background(), "pricing", "", "quote", "pen", &result)
+	s.Equal(&WorkflowRequestError{Message: "out of stock"}, err)
+	err = requests.Request(context.Background(), "pricing", "", "quote", "ink", &result)
+	s.Equal(ErrWorkflowRequestTimeout, err)
+
+	_, err = NewWorkflowRequestClient(s.client, WorkflowRequestClientOptions{})
+	s.Error(err)
+}
+
+func (s *workflowClientTestSuite) TestListPendingActivities() {
+	pending := []*shared.PendingActivityInfo{
+		{ActivityID: common.StringPtr("1"), ActivityType: &shared.ActivityType{Name: common.StringPtr("charge")}},
+		{ActivityID: common.StringPtr("2"), ActivityType: &shared.ActivityType{Name: common.StringPtr("ship")}},
+	}
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.DescribeWorkflowExecutionResponse{PendingActivities: pending}, nil).
+		Do(func(_ interface{}, req *shared.DescribeWorkflowExecutionRequest, _ ...interface{}) {
+			s.Equal(domain, req.GetDomain())
+			s.Equal(workflowID, req.GetExecution().GetWorkflowId())
+			s.Equal(runID, req.GetExecution().GetRunId())
+		})
+	activities, err := s.client.ListPendingActivities(context.Background(), workflowID, runID)
+	s.NoError(err)
+	s.Equal(pending, activities)
+
+	responseErr := &shared.EntityNotExistsError{}
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, responseErr)
+	activities, err = s.client.ListPendingActivities(context.Background(), workflowID, runID)
+	s.Equal(responseErr, err)
+	s.Nil(activities)
+}
+
+func (s *workflowClientTestSuite) TestGetDecisionTaskFailure() {
+	failedEvent := func(eventID int64, details string) *shared.HistoryEvent {
+		return &shared.HistoryEvent{
+			EventId:   common.Int64Ptr(eventID),
+			EventType: shared.EventTypeDecisionTaskFailed.Ptr(),
+			Timestamp: common.Int64Ptr(eventID),
+			DecisionTaskFailedEventAttributes: &shared.DecisionTaskFailedEventAttributes{
+				Cause:          shared.DecisionTaskFailedCauseWorkflowWorkerUnhandledFailure.Ptr(),
+				Details:        []byte(details),
+				Identity:       common.StringPtr("worker"),
+				BinaryChecksum: common.StringPtr("checksum"),
+			},
+		}
+	}
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &shared.WorkflowExecutionInfo{
+				Execution: &shared.WorkflowExecution{WorkflowId: common.StringPtr(workflowID), RunId: common.StringPtr(runID)},
+			},
+			PendingDecision: &shared.PendingDecisionInfo{Attempt: common.Int64Ptr(3)},
+		}, nil)
+	s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.GetWorkflowExecutionHistoryResponse{
+			History: &shared.History{Events: []*shared.HistoryEvent{
+				createTestEventWorkflowExecutionStarted(1, &shared.WorkflowExecutionStartedEventAttributes{}),
+				failedEvent(4, "earlier"),
+				createTestEventDecisionTaskCompleted(5, &shared.DecisionTaskCompletedEventAttributes{}),
+				failedEvent(7, string(decisionTaskFailureOrigin{
+					decision: "ScheduleActivityTask charge",
+					callSite: "main.MyWorkflow (/src/workflow.go:42)",
+				}.encodeDetails([]byte("boom")))),
+			}},
+		}, nil).
+		Do(func(_ interface{}, req *shared.GetWorkflowExecutionHistoryRequest, _ ...interface{}) {
+			s.Equal(runID, req.GetExecution().GetRunId())
+		})
+	failure, err := s.client.GetDecisionTaskFailure(context.Background(), workflowID, "")
+	s.NoError(err)
+	s.Equal(&DecisionTaskFailure{
+		Cause:          shared.DecisionTaskFailedCauseWorkflowWorkerUnhandledFailure,
+		Message:        "boom",
+		Decision:       "ScheduleActivityTask charge",
+		CallSite:       "main.MyWorkflow (/src/workflow.go:42)",
+		Identity:       "worker",
+		BinaryChecksum: "checksum",
+		Time:           time.Unix(0, 7),
+		Attempt:        3,
+	}, failure)
+
+	// the decision task timed out since its last success
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &shared.WorkflowExecutionInfo{
+				Execution: &shared.WorkflowExecution{WorkflowId: common.StringPtr(workflowID), RunId: common.StringPtr(runID)},
+			},
+			PendingDecision: &shared.PendingDecisionInfo{Attempt: common.Int64Ptr(1)},
+		}, nil)
+	s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.GetWorkflowExecutionHistoryResponse{
+			History: &shared.History{Events: []*shared.HistoryEvent{
+				createTestEventWorkflowExecutionStarted(1, &shared.WorkflowExecutionStartedEventAttributes{}),
+				failedEvent(4, "earlier"),
+				createTestEventDecisionTaskCompleted(5, &shared.DecisionTaskCompletedEventAttributes{}),
+			}},
+		}, nil)
+	failure, err = s.client.GetDecisionTaskFailure(context.Background(), workflowID, runID)
+	s.NoError(err)
+	s.Nil(failure)
+
+	// the decision task is not failing
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.DescribeWorkflowExecutionResponse{PendingDecision: &shared.PendingDecisionInfo{Attempt: common.Int64Ptr(0)}}, nil)
+	failure, err = s.client.GetDecisionTaskFailure(context.Background(), workflowID, runID)
+	s.NoError(err)
+	s.Nil(failure)
+}
+
 func serializeEvents(events []*shared.HistoryEvent) *shared.DataBlob {
 
 	blob, _ := serializer.SerializeBatchEvents(events, shared.EncodingTypeThriftRW)
@@ -1768,6 +2187,106 @@ func (s *workflowClientTestSuite) TestTerminateWorkflow() {
 	s.NoError(err)
 }
 
+func (s *workflowClientTestSuite) TestStopWorkflow() {
+	closedHistory := &shared.GetWorkflowExecutionHistoryResponse{
+		History: &shared.History{Events: []*shared.HistoryEvent{
+			createTestEventWorkflowExecutionCompleted(5, &shared.WorkflowExecutionCompletedEventAttributes{}),
+		}},
+	}
+	reason := "stopped by {{.Operator}} ({{.Mode}}) for {{.WorkflowID}}/{{.RunID}}"
+	expectedReason := "stopped by alice (CancelThenTerminate) for " + workflowID + "/" + runID
+
+	s.Run("cancel honored", func() {
+		s.service.EXPECT().RequestCancelWorkflowExecution(gomock.Any(), newPartialCancelRequestMatcher(common.StringPtr(workflowID), common.StringPtr(expectedReason)), gomock.Any()).Return(nil)
+		s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).Return(closedHistory, nil)
+		err := s.client.StopWorkflow(context.Background(), workflowID, runID, StopWorkflowOptions{Reason: reason, Operator: "alice"})
+		s.NoError(err)
+	})
+	s.Run("escalates to terminate", func() {
+		s.service.EXPECT().RequestCancelWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _ *shared.GetWorkflowExecutionHistoryRequest, _ ...yarpc.CallOption) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}).AnyTimes()
+		s.service.EXPECT().TerminateWorkflowExecution(gomock.Any(), &shared.TerminateWorkflowExecutionRequest{
+			Domain: common.StringPtr(domain),
+			WorkflowExecution: &shared.WorkflowExecution{
+				WorkflowId: common.StringPtr(workflowID),
+				RunId:      common.StringPtr(runID),
+			},
+			Reason:   common.StringPtr(expectedReason),
+			Details:  []byte("details"),
+			Identity: common.StringPtr(identity),
+		}, gomock.Any()).Return(nil)
+		err := s.client.StopWorkflow(context.Background(), workflowID, runID, StopWorkflowOptions{
+			TerminateAfter: 10 * time.Millisecond,
+			Reason:         reason,
+			Details:        []byte("details"),
+			Operator:       "alice",
+		})
+		s.NoError(err)
+	})
+	s.Run("resolves current run", func() {
+		s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(&shared.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &shared.WorkflowExecutionInfo{
+				Execution: &shared.WorkflowExecution{WorkflowId: common.StringPtr(workflowID), RunId: common.StringPtr(runID)},
+			},
+		}, nil)
+		s.service.EXPECT().TerminateWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, request *shared.TerminateWorkflowExecutionRequest, _ ...yarpc.CallOption) error {
+				s.Equal(runID, request.GetWorkflowExecution().GetRunId())
+				s.Equal("stopped by alice (Terminate) for "+workflowID+"/"+runID, request.GetReason())
+				return nil
+			})
+		err := s.client.StopWorkflow(context.Background(), workflowID, "", StopWorkflowOptions{
+			Mode:     StopWorkflowModeTerminate,
+			Reason:   reason,
+			Operator: "alice",
+		})
+		s.NoError(err)
+	})
+	s.Run("cancel only", func() {
+		s.service.EXPECT().RequestCancelWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(&shared.WorkflowExecutionAlreadyCompletedError{})
+		err := s.client.StopWorkflow(context.Background(), workflowID, runID, StopWorkflowOptions{Mode: StopWorkflowModeCancel})
+		s.NoError(err)
+
+		s.service.EXPECT().RequestCancelWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(&shared.CancellationAlreadyRequestedError{})
+		err = s.client.StopWorkflow(context.Background(), workflowID, runID, StopWorkflowOptions{Mode: StopWorkflowModeCancel})
+		s.NoError(err)
+
+		s.service.EXPECT().RequestCancelWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(&shared.BadRequestError{})
+		err = s.client.StopWorkflow(context.Background(), workflowID, runID, StopWorkflowOptions{Mode: StopWorkflowModeCancel})
+		s.IsType(&shared.BadRequestError{}, err)
+	})
+	s.Run("already closed", func() {
+		s.service.EXPECT().RequestCancelWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(&shared.WorkflowExecutionAlreadyCompletedError{})
+		err := s.client.StopWorkflow(context.Background(), workflowID, runID, StopWorkflowOptions{})
+		s.NoError(err)
+	})
+	s.Run("escalates when cancellation was already requested", func() {
+		s.service.EXPECT().RequestCancelWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(&shared.CancellationAlreadyRequestedError{})
+		s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _ *shared.GetWorkflowExecutionHistoryRequest, _ ...yarpc.CallOption) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}).AnyTimes()
+		s.service.EXPECT().TerminateWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		err := s.client.StopWorkflow(context.Background(), workflowID, runID, StopWorkflowOptions{TerminateAfter: 10 * time.Millisecond})
+		s.NoError(err)
+	})
+	s.Run("invalid reason", func() {
+		err := s.client.StopWorkflow(context.Background(), workflowID, runID, StopWorkflowOptions{Reason: "{{.Unknown"})
+		s.ErrorContains(err, "invalid stop workflow reason template")
+	})
+}
+
+func TestWithOperatorHeader(t *testing.T) {
+	opts := []yarpc.CallOption{yarpc.WithHeader("a", "b")}
+	assert.Len(t, withOperatorHeader(opts, ""), 1)
+	assert.Len(t, withOperatorHeader(opts, "alice"), 2)
+}
+
 func (s *workflowClientTestSuite) TestDescribeTaskList() {
 	testcases := []struct {
 		name     string
@@ -2461,6 +2980,83 @@ func (s *workflowClientTestSuite) TestQueryWorkflowWithOptions() {
 	}
 }
 
+func (s *workflowClientTestSuite) TestQueryWorkflowWithOptions_Hedged() {
+	scope := tally.NewTestScope("", nil)
+	client := NewClient(s.service, domain, &ClientOptions{Identity: identity, MetricsScope: scope})
+	hedgeCounts := func() (hedges, wins int64) {
+		for _, counter := range scope.Snapshot().Counters() {
+			switch counter.Name() {
+			case metrics.QueryHedgeCounter:
+				hedges = counter.Value()
+			case metrics.QueryHedgeWins:
+				wins = counter.Value()
+			}
+		}
+		return hedges, wins
+	}
+	request := &QueryWorkflowWithOptionsRequest{
+		WorkflowID: workflowID,
+		QueryType:  queryType,
+		HedgeDelay: 10 * time.Millisecond,
+	}
+	response := &shared.QueryWorkflowResponse{QueryResult: []byte("\"result\"")}
+
+	// answered before the hedge delay
+	s.service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), gomock.Any()).Return(response, nil)
+	resp, err := client.QueryWorkflowWithOptions(context.Background(), request)
+	s.Require().NoError(err)
+	s.NotNil(resp.QueryResult)
+	hedges, wins := hedgeCounts()
+	s.Zero(hedges)
+	s.Zero(wins)
+
+	// the sticky task list is reset before every hedge, so that it is not sent to the same worker
+	s.service.EXPECT().ResetStickyTaskList(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.ResetStickyTaskListResponse{}, nil).
+		Do(func(_ interface{}, req *shared.ResetStickyTaskListRequest, _ ...interface{}) {
+			s.Equal(domain, req.GetDomain())
+			s.Equal(workflowID, req.GetExecution().GetWorkflowId())
+		}).
+		Times(2)
+
+	// the first request is stuck until it is canceled, and the hedged one answers
+	canceled := make(chan struct{})
+	gomock.InOrder(
+		s.service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ *shared.QueryWorkflowRequest, _ ...yarpc.CallOption) (*shared.QueryWorkflowResponse, error) {
+				<-ctx.Done()
+				close(canceled)
+				return nil, ctx.Err()
+			}),
+		s.service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), gomock.Any()).Return(response, nil),
+	)
+	resp, err = client.QueryWorkflowWithOptions(context.Background(), request)
+	s.Require().NoError(err)
+	var result string
+	s.NoError(resp.QueryResult.Get(&result))
+	s.Equal("result", result)
+	<-canceled
+	hedges, wins = hedgeCounts()
+	s.Equal(int64(1), hedges)
+	s.Equal(int64(1), wins)
+
+	// the hedged request fails, and the first one answers
+	gomock.InOrder(
+		s.service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ *shared.QueryWorkflowRequest, _ ...yarpc.CallOption) (*shared.QueryWorkflowResponse, error) {
+				time.Sleep(50 * time.Millisecond)
+				return response, nil
+			}),
+		s.service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &shared.AccessDeniedError{}),
+	)
+	resp, err = client.QueryWorkflowWithOptions(context.Background(), request)
+	s.Require().NoError(err)
+	s.NotNil(resp.QueryResult)
+	hedges, wins = hedgeCounts()
+	s.Equal(int64(2), hedges)
+	s.Equal(int64(1), wins)
+}
+
 func (s *workflowClientTestSuite) TestGetWorkflowHistory() {
 	// Page 1 of 2
 	//// Events
@@ -2571,7 +3167,7 @@ func TestGetWorkflowStartRequest(t *testing.T) {
 				JitterStart:                     0 * time.Second,
 			},
 			workflowFunc: func(ctx Context) {},
-			wantErr:      "missing TaskList",
+			wantErr:      "TaskList is not set",
 		},
 		{
 			name: "invalid ExecutionStartToCloseTimeout",
@@ -2584,7 +3180,7 @@ func TestGetWorkflowStartRequest(t *testing.T) {
 				JitterStart:                     0 * time.Second,
 			},
 			workflowFunc: func(ctx Context) {},
-			wantErr:      "missing or invalid ExecutionStartToCloseTimeout",
+			wantErr:      "ExecutionStartToCloseTimeout is not set",
 		},
 		{
 			name: "negative DecisionTaskStartToCloseTimeout",
@@ -2597,7 +3193,7 @@ func TestGetWorkflowStartRequest(t *testing.T) {
 				JitterStart:                     0 * time.Second,
 			},
 			workflowFunc: func(ctx Context) {},
-			wantErr:      "negative DecisionTaskStartToCloseTimeout provided",
+			wantErr:      "DecisionTaskStartToCloseTimeout is negative",
 		},
 		{
 			name: "negative DelayStart",
@@ -2610,7 +3206,7 @@ func TestGetWorkflowStartRequest(t *testing.T) {
 				JitterStart:                     0 * time.Second,
 			},
 			workflowFunc: func(ctx Context) {},
-			wantErr:      "Invalid DelayStart option",
+			wantErr:      "DelayStart is negative",
 		},
 		{
 			name: "negative JitterStart",
@@ -2623,7 +3219,7 @@ func TestGetWorkflowStartRequest(t *testing.T) {
 				JitterStart:                     -1 * time.Second, // this causes error
 			},
 			workflowFunc: func(ctx Context) {},
-			wantErr:      "Invalid JitterStart option",
+			wantErr:      "JitterStart is negative",
 		},
 		{
 			name: "negative firstRunAtTimestamp",
@@ -2636,7 +3232,7 @@ func TestGetWorkflowStartRequest(t *testing.T) {
 				FirstRunAt:                      time.Unix(-12, 0), // this causes error
 			},
 			workflowFunc: func(ctx Context) {},
-			wantErr:      "Invalid FirstRunAt option",
+			wantErr:      "FirstRunAt is before the Unix epoch",
 		},
 		{
 			name: "invalid workflow func",
@@ -2707,7 +3303,7 @@ func TestGetSignalWithStartRequest(t *testing.T) {
 				FirstRunAt:                      time.Unix(-12, 0),
 			},
 			workflowFunc: func(ctx Context) {},
-			wantErr:      "Invalid FirstRunAt option",
+			wantErr:      "FirstRunAt is before the Unix epoch",
 		},
 	}
 	for _, tc := range tests {
@@ -0,0 +1,266 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+)
+
+const (
+	defaultCompletionListenerConcurrency = 10
+
+	// completionPollTimeout bounds a long poll of one execution, so that the pollers rotate through the executions
+	// when more are awaited than there are pollers.
+	completionPollTimeout = time.Minute
+
+	completionRetryInitialInterval = time.Second
+	completionRetryMaxInterval     = time.Minute
+)
+
+type (
+	// WorkflowCompletion is the outcome of a closed workflow execution, delivered by Client.OnWorkflowCompletion.
+	WorkflowCompletion struct {
+		// WorkflowID and RunID identify the execution. RunID is the last run when the workflow continued as new.
+		WorkflowID string
+		RunID      string
+		// Err is nil if the workflow completed successfully. Otherwise it is the error Get of a WorkflowRun returns,
+		// like CustomError, CanceledError, TerminatedError or TimeoutError, or EntityNotExistsError if the
+		// execution does not exist.
+		Err error
+
+		result        []byte
+		dataConverter DataConverter
+	}
+
+	// WorkflowCompletionCallback is called with the outcome of a workflow execution awaited with
+	// Client.OnWorkflowCompletion. Returning an error, or panicking, makes the completion delivered again later.
+	WorkflowCompletionCallback func(completion WorkflowCompletion) error
+
+	// completionListener waits for the executions awaited with Client.OnWorkflowCompletion with a bounded number of
+	// poller goroutines, which exit once no execution is awaited anymore.
+	completionListener struct {
+		client      *workflowClient
+		concurrency int
+
+		pollTimeout          time.Duration
+		retryInitialInterval time.Duration
+		retryMaxInterval     time.Duration
+
+		mutex   sync.Mutex
+		pending []*pendingCompletion
+		pollers int
+	}
+
+	pendingCompletion struct {
+		ctx        context.Context
+		workflowID string
+		runID      string
+		callback   WorkflowCompletionCallback
+
+		completion *WorkflowCompletion // set once the execution is closed, until the callback succeeds
+		attempt    int                 // failed attempts since the last progress, for the retry backoff
+	}
+)
+
+// Get decodes the result of a successfully completed workflow into valuePtr, or returns Err.
+func (c WorkflowCompletion) Get(valuePtr interface{}) error {
+	if c.Err != nil {
+		return c.Err
+	}
+	if valuePtr == nil || c.result == nil {
+		return nil
+	}
+	return decodeArg(c.dataConverter, c.result, valuePtr)
+}
+
+func newCompletionListener(client *workflowClient, concurrency int) *completionListener {
+	if concurrency <= 0 {
+		concurrency = defaultCompletionListenerConcurrency
+	}
+	return &completionListener{
+		client:               client,
+		concurrency:          concurrency,
+		pollTimeout:          completionPollTimeout,
+		retryInitialInterval: completionRetryInitialInterval,
+		retryMaxInterval:     completionRetryMaxInterval,
+	}
+}
+
+// OnWorkflowCompletion calls callback once the workflow execution is closed.
+func (wc *workflowClient) OnWorkflowCompletion(ctx context.Context, workflowID string, runID string, callback WorkflowCompletionCallback) error {
+	if workflowID == "" {
+		return errors.New("workflow ID is required")
+	}
+	if callback == nil {
+		return errors.New("callback is required")
+	}
+	if wc.completions == nil {
+		return errors.New("client does not support OnWorkflowCompletion, create it with NewClient")
+	}
+	wc.completions.add(&pendingCompletion{
+		ctx:        ctx,
+		workflowID: workflowID,
+		runID:      runID,
+		callback:   callback,
+	})
+	return nil
+}
+
+// add queues p and starts a poller if the listener has less than its concurrency.
+func (l *completionListener) add(p *pendingCompletion) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.pending = append(l.pending, p)
+	if l.pollers < l.concurrency {
+		l.pollers++
+		go l.poll()
+	}
+}
+
+func (l *completionListener) poll() {
+	for {
+		l.mutex.Lock()
+		if len(l.pending) == 0 {
+			l.pollers--
+			l.mutex.Unlock()
+			return
+		}
+		p := l.pending[0]
+		l.pending[0] = nil
+		l.pending = l.pending[1:]
+		l.mutex.Unlock()
+
+		l.process(p)
+	}
+}
+
+// process polls the execution of p once, and delivers its completion when it is closed. p is queued again when the
+// execution is still running, and after a backoff when polling or the callback failed.
+func (l *completionListener) process(p *pendingCompletion) {
+	if p.ctx.Err() != nil {
+		return
+	}
+	if p.completion == nil {
+		completion, err := l.pollCompletion(p)
+		if err != nil {
+			l.retry(p)
+			return
+		}
+		if completion == nil {
+			l.add(p)
+			return
+		}
+		p.completion = completion
+		p.attempt = 0
+	}
+	if err := deliverWorkflowCompletion(p.callback, *p.completion); err != nil {
+		l.retry(p)
+	}
+}
+
+func (l *completionListener) retry(p *pendingCompletion) {
+	if p.ctx.Err() != nil {
+		return
+	}
+	delay := l.retryInitialInterval << p.attempt
+	if delay <= 0 || delay > l.retryMaxInterval {
+		delay = l.retryMaxInterval
+	} else {
+		p.attempt++
+	}
+	time.AfterFunc(delay, func() {
+		l.add(p)
+	})
+}
+
+// pollCompletion long polls the close event of the execution of p for up to pollTimeout, following the runs the
+// workflow continued as new to. It returns nil if the execution is still running.
+func (l *completionListener) pollCompletion(p *pendingCompletion) (*WorkflowCompletion, error) {
+	ctx, cancel := context.WithTimeout(p.ctx, l.pollTimeout)
+	defer cancel()
+	for {
+		paginate := l.client.getWorkflowHistoryPaginator(ctx, p.workflowID, p.runID, true, s.HistoryEventFilterTypeCloseEvent)
+		response, err := paginate(nil)
+		switch err.(type) {
+		case nil:
+		case *s.EntityNotExistsError, *s.BadRequestError:
+			return &WorkflowCompletion{WorkflowID: p.workflowID, RunID: p.runID, Err: err}, nil
+		default:
+			if ctx.Err() != nil && p.ctx.Err() == nil {
+				// the execution is still running, poll the next one
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		events := response.GetHistory().GetEvents()
+		if len(events) == 0 {
+			return nil, nil
+		}
+		closeEvent := events[len(events)-1]
+		if closeEvent.GetEventType() == s.EventTypeWorkflowExecutionContinuedAsNew {
+			p.runID = closeEvent.WorkflowExecutionContinuedAsNewEventAttributes.GetNewExecutionRunId()
+			continue
+		}
+		return l.client.newWorkflowCompletion(p.workflowID, p.runID, closeEvent), nil
+	}
+}
+
+func (wc *workflowClient) newWorkflowCompletion(workflowID, runID string, closeEvent *s.HistoryEvent) *WorkflowCompletion {
+	completion := &WorkflowCompletion{
+		WorkflowID:    workflowID,
+		RunID:         runID,
+		dataConverter: wc.dataConverter,
+	}
+	switch closeEvent.GetEventType() {
+	case s.EventTypeWorkflowExecutionCompleted:
+		completion.result = closeEvent.WorkflowExecutionCompletedEventAttributes.Result
+	case s.EventTypeWorkflowExecutionFailed:
+		attributes := closeEvent.WorkflowExecutionFailedEventAttributes
+		completion.Err = constructError(attributes.GetReason(), attributes.Details, wc.dataConverter)
+	case s.EventTypeWorkflowExecutionCanceled:
+		attributes := closeEvent.WorkflowExecutionCanceledEventAttributes
+		completion.Err = NewCanceledError(newEncodedValues(attributes.Details, wc.dataConverter))
+	case s.EventTypeWorkflowExecutionTerminated:
+		completion.Err = newTerminatedError()
+	case s.EventTypeWorkflowExecutionTimedOut:
+		completion.Err = NewTimeoutError(closeEvent.WorkflowExecutionTimedOutEventAttributes.GetTimeoutType())
+	default:
+		completion.Err = fmt.Errorf("unexpected event type %s when handling workflow execution result", closeEvent.GetEventType())
+	}
+	return completion
+}
+
+// deliverWorkflowCompletion calls callback, turning its panics into errors.
+func deliverWorkflowCompletion(callback WorkflowCompletionCallback, completion WorkflowCompletion) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("workflow completion callback panicked: %v", p)
+		}
+	}()
+	return callback(completion)
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func (s *workflowClientTestSuite) TestOnWorkflowCompletion() {
+	closeEventResponse := func(event *shared.HistoryEvent) *shared.GetWorkflowExecutionHistoryResponse {
+		return &shared.GetWorkflowExecutionHistoryResponse{History: &shared.History{Events: []*shared.HistoryEvent{event}}}
+	}
+	completedResponse := func() *shared.GetWorkflowExecutionHistoryResponse {
+		result, err := encodeArg(getDefaultDataConverter(), "result")
+		s.NoError(err)
+		return closeEventResponse(&shared.HistoryEvent{
+			EventId:   common.Int64Ptr(10),
+			EventType: shared.EventTypeWorkflowExecutionCompleted.Ptr(),
+			WorkflowExecutionCompletedEventAttributes: &shared.WorkflowExecutionCompletedEventAttributes{Result: result},
+		})
+	}
+	expectPoll := func(runID string, response *shared.GetWorkflowExecutionHistoryResponse, err error) *gomock.Call {
+		return s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+			Do(func(_ interface{}, req *shared.GetWorkflowExecutionHistoryRequest, _ ...interface{}) {
+				s.Equal(workflowID, req.GetExecution().GetWorkflowId())
+				s.Equal(runID, req.GetExecution().GetRunId())
+				s.True(req.GetWaitForNewEvent())
+				s.Equal(shared.HistoryEventFilterTypeCloseEvent, req.GetHistoryEventFilterType())
+			}).Return(response, err)
+	}
+	await := func(runID string, callback WorkflowCompletionCallback) <-chan WorkflowCompletion {
+		listener := s.client.(*workflowClient).completions
+		listener.retryInitialInterval = time.Millisecond
+		listener.retryMaxInterval = time.Millisecond
+		ch := make(chan WorkflowCompletion, 10)
+		err := s.client.OnWorkflowCompletion(context.Background(), workflowID, runID, func(completion WorkflowCompletion) error {
+			if err := callback(completion); err != nil {
+				return err
+			}
+			ch <- completion
+			return nil
+		})
+		s.NoError(err)
+		return ch
+	}
+	succeed := func(WorkflowCompletion) error { return nil }
+	receive := func(ch <-chan WorkflowCompletion) WorkflowCompletion {
+		select {
+		case completion := <-ch:
+			return completion
+		case <-time.After(5 * time.Second):
+			s.FailNow("workflow completion not delivered")
+			return WorkflowCompletion{}
+		}
+	}
+
+	s.Run("completed", func() {
+		expectPoll(runID, completedResponse(), nil)
+		completion := receive(await(runID, succeed))
+		s.Equal(workflowID, completion.WorkflowID)
+		s.Equal(runID, completion.RunID)
+		var result string
+		s.NoError(completion.Get(&result))
+		s.Equal("result", result)
+	})
+	s.Run("failed", func() {
+		details, err := encodeArg(getDefaultDataConverter(), "details")
+		s.NoError(err)
+		expectPoll(runID, closeEventResponse(&shared.HistoryEvent{
+			EventId:   common.Int64Ptr(10),
+			EventType: shared.EventTypeWorkflowExecutionFailed.Ptr(),
+			WorkflowExecutionFailedEventAttributes: &shared.WorkflowExecutionFailedEventAttributes{
+				Reason:  common.StringPtr("reason"),
+				Details: details,
+			},
+		}), nil)
+		completion := receive(await(runID, succeed))
+		var customErr *CustomError
+		s.True(errors.As(completion.Err, &customErr))
+		s.Equal("reason", customErr.Reason())
+		var result string
+		s.Equal(completion.Err, completion.Get(&result))
+	})
+	s.Run("continued as new", func() {
+		newRunID := "new-" + runID
+		expectPoll("", closeEventResponse(&shared.HistoryEvent{
+			EventId:   common.Int64Ptr(10),
+			EventType: shared.EventTypeWorkflowExecutionContinuedAsNew.Ptr(),
+			WorkflowExecutionContinuedAsNewEventAttributes: &shared.WorkflowExecutionContinuedAsNewEventAttributes{
+				NewExecutionRunId: common.StringPtr(newRunID),
+			},
+		}), nil)
+		expectPoll(newRunID, completedResponse(), nil)
+		completion := receive(await("", succeed))
+		s.Equal(newRunID, completion.RunID)
+		s.NoError(completion.Err)
+	})
+	s.Run("not exists", func() {
+		expectPoll(runID, nil, &shared.EntityNotExistsError{})
+		completion := receive(await(runID, succeed))
+		s.IsType(&shared.EntityNotExistsError{}, completion.Err)
+	})
+	s.Run("callback retried", func() {
+		expectPoll(runID, completedResponse(), nil)
+		attempts := 0
+		completion := receive(await(runID, func(WorkflowCompletion) error {
+			attempts++
+			switch attempts {
+			case 1:
+				return errors.New("failed")
+			case 2:
+				panic("panicked")
+			}
+			return nil
+		}))
+		s.Equal(3, attempts)
+		s.NoError(completion.Err)
+	})
+	s.Run("canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		called := make(chan struct{}, 1)
+		s.NoError(s.client.OnWorkflowCompletion(ctx, workflowID, runID, func(WorkflowCompletion) error {
+			called <- struct{}{}
+			return nil
+		}))
+		select {
+		case <-called:
+			s.Fail("callback called after the context was canceled")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+	s.Run("invalid arguments", func() {
+		s.Error(s.client.OnWorkflowCompletion(context.Background(), "", runID, succeed))
+		s.Error(s.client.OnWorkflowCompletion(context.Background(), workflowID, runID, nil))
+	})
+}
+
+func (s *workflowClientTestSuite) TestOnWorkflowCompletion_Rotates() {
+	listener := s.client.(*workflowClient).completions
+	listener.concurrency = 1
+	listener.pollTimeout = 50 * time.Millisecond
+
+	// the poll of the first workflow times out, so the single poller moves on to the second one
+	s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *shared.GetWorkflowExecutionHistoryRequest, _ ...interface{}) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+			if req.GetExecution().GetWorkflowId() == "running" {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &shared.GetWorkflowExecutionHistoryResponse{History: &shared.History{Events: []*shared.HistoryEvent{{
+				EventId:   common.Int64Ptr(10),
+				EventType: shared.EventTypeWorkflowExecutionTerminated.Ptr(),
+			}}}}, nil
+		}).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.NoError(s.client.OnWorkflowCompletion(ctx, "running", "", func(WorkflowCompletion) error {
+		s.Fail("running workflow reported as closed")
+		return nil
+	}))
+	ch := make(chan WorkflowCompletion, 1)
+	s.NoError(s.client.OnWorkflowCompletion(ctx, "terminated", "", func(completion WorkflowCompletion) error {
+		ch <- completion
+		return nil
+	}))
+	select {
+	case completion := <-ch:
+		s.IsType(&TerminatedError{}, completion.Err)
+	case <-time.After(5 * time.Second):
+		s.Fail("workflow completion not delivered")
+	}
+
+	// the running workflow is dropped once its context is canceled
+	cancel()
+	s.Eventually(func() bool {
+		listener.mutex.Lock()
+		defer listener.mutex.Unlock()
+		return listener.pollers == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+)
+
+// workflowHeadersHeaderKey is the workflow header field carrying StartWorkflowOptions.Headers to the workflow, its
+// activities, its child workflows and its continued-as-new runs.
+const workflowHeadersHeaderKey = "cadence-workflow-headers"
+
+// GetWorkflowHeaders returns a copy of the headers the workflow was started with, see StartWorkflowOptions.Headers.
+func GetWorkflowHeaders(ctx Context) map[string]string {
+	options := getWorkflowEnvOptions(ctx)
+	if options == nil {
+		return nil
+	}
+	return copyWorkflowHeaders(options.headers)
+}
+
+// GetActivityHeaders returns a copy of the headers the workflow of the activity was started with, see
+// StartWorkflowOptions.Headers.
+func GetActivityHeaders(ctx context.Context) map[string]string {
+	return copyWorkflowHeaders(getActivityEnv(ctx).headers)
+}
+
+func writeWorkflowHeadersHeader(header *s.Header, headers map[string]string) error {
+	if len(headers) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	if header.Fields == nil {
+		header.Fields = make(map[string][]byte)
+	}
+	header.Fields[workflowHeadersHeaderKey] = data
+	return nil
+}
+
+func readWorkflowHeadersHeader(header *s.Header) (map[string]string, error) {
+	if header == nil {
+		return nil, nil
+	}
+	data, ok := header.Fields[workflowHeadersHeaderKey]
+	if !ok {
+		return nil, nil
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(data, &headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+func copyWorkflowHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	result := make(map[string]string, len(headers))
+	for k, v := range headers {
+		result[k] = v
+	}
+	return result
+}
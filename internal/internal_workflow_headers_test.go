@@ -0,0 +1,88 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+func TestWorkflowHeadersHeader(t *testing.T) {
+	header := &shared.Header{}
+	require.NoError(t, writeWorkflowHeadersHeader(header, nil))
+	assert.Empty(t, header.Fields)
+
+	headers := map[string]string{"tenant": "acme", "caller": "billing"}
+	require.NoError(t, writeWorkflowHeadersHeader(header, headers))
+	read, err := readWorkflowHeadersHeader(header)
+	require.NoError(t, err)
+	assert.Equal(t, headers, read)
+
+	header.Fields[workflowHeadersHeaderKey] = []byte("not json")
+	_, err = readWorkflowHeadersHeader(header)
+	assert.Error(t, err)
+}
+
+func TestWorkflowHeaders(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	activityFn := func(ctx context.Context) (string, error) {
+		return GetActivityHeaders(ctx)["tenant"], nil
+	}
+	childFn := func(ctx Context) (string, error) {
+		return GetWorkflowHeaders(ctx)["tenant"], nil
+	}
+	workflowFn := func(ctx Context) ([]string, error) {
+		headers := GetWorkflowHeaders(ctx)
+		headers["tenant"] = "modified"
+
+		var fromActivity, fromLocalActivity, fromChild string
+		ctx = WithActivityOptions(ctx, ActivityOptions{ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: time.Minute})
+		if err := ExecuteActivity(ctx, activityFn).Get(ctx, &fromActivity); err != nil {
+			return nil, err
+		}
+		ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: time.Minute})
+		if err := ExecuteLocalActivity(ctx, activityFn).Get(ctx, &fromLocalActivity); err != nil {
+			return nil, err
+		}
+		ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{ExecutionStartToCloseTimeout: time.Minute})
+		if err := ExecuteChildWorkflow(ctx, childFn).Get(ctx, &fromChild); err != nil {
+			return nil, err
+		}
+		return []string{GetWorkflowHeaders(ctx)["tenant"], fromActivity, fromLocalActivity, fromChild}, nil
+	}
+
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activityFn)
+	env.RegisterWorkflow(childFn)
+	env.SetHeaders(map[string]string{"tenant": "acme"})
+	env.ExecuteWorkflow(workflowFn)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var result []string
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, []string{"acme", "acme", "acme", "acme"}, result)
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"text/template"
+	"time"
+
+	"go.uber.org/yarpc"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/backoff"
+)
+
+const (
+	// operatorHeaderName is the header recording the operator stopping a workflow with Client.StopWorkflow.
+	operatorHeaderName = "cadence-operator"
+
+	defaultStopTerminateAfter = time.Minute
+)
+
+// StopWorkflowMode is how Client.StopWorkflow stops a workflow execution.
+type StopWorkflowMode int
+
+const (
+	// StopWorkflowModeCancelThenTerminate requests cancellation of the workflow, and terminates it if it did not
+	// close within StopWorkflowOptions.TerminateAfter.
+	StopWorkflowModeCancelThenTerminate StopWorkflowMode = iota
+	// StopWorkflowModeCancel only requests cancellation of the workflow.
+	StopWorkflowModeCancel
+	// StopWorkflowModeTerminate terminates the workflow right away.
+	StopWorkflowModeTerminate
+)
+
+type (
+	// StopWorkflowOptions configure Client.StopWorkflow.
+	StopWorkflowOptions struct {
+		// Mode is how the workflow is stopped.
+		// default: StopWorkflowModeCancelThenTerminate
+		Mode StopWorkflowMode
+
+		// TerminateAfter is how long StopWorkflowModeCancelThenTerminate waits for the workflow to close after
+		// requesting its cancellation, before terminating it.
+		// default: 1 minute
+		TerminateAfter time.Duration
+
+		// Reason is recorded as the cancellation cause and the termination reason. It is a text/template executed
+		// with StopWorkflowReason, e.g. "stopped by {{.Operator}}: incident 1234".
+		Reason string
+
+		// Details are recorded with the termination.
+		Details []byte
+
+		// Operator is the identity of the person or system stopping the workflow. It is sent in the
+		// "cadence-operator" header of the cancel and terminate requests.
+		Operator string
+	}
+
+	// StopWorkflowReason is the data StopWorkflowOptions.Reason is executed with.
+	StopWorkflowReason struct {
+		WorkflowID string
+		RunID      string
+		Operator   string
+		Mode       StopWorkflowMode
+	}
+)
+
+// String returns the name of the mode.
+func (m StopWorkflowMode) String() string {
+	switch m {
+	case StopWorkflowModeCancelThenTerminate:
+		return "CancelThenTerminate"
+	case StopWorkflowModeCancel:
+		return "Cancel"
+	case StopWorkflowModeTerminate:
+		return "Terminate"
+	}
+	return "Unknown"
+}
+
+// StopWorkflow stops a workflow execution. See Client.StopWorkflow.
+func (wc *workflowClient) StopWorkflow(ctx context.Context, workflowID string, runID string, options StopWorkflowOptions) error {
+	if workflowID == "" {
+		return errors.New("workflowID is required")
+	}
+	if runID == "" {
+		// resolve the current run, so that a new run is not terminated when escalating
+		response, err := wc.DescribeWorkflowExecution(ctx, workflowID, "")
+		if err != nil {
+			return err
+		}
+		runID = response.GetWorkflowExecutionInfo().GetExecution().GetRunId()
+	}
+	reason, err := getStopWorkflowReason(options.Reason, StopWorkflowReason{
+		WorkflowID: workflowID,
+		RunID:      runID,
+		Operator:   options.Operator,
+		Mode:       options.Mode,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch options.Mode {
+	case StopWorkflowModeTerminate:
+		return wc.terminateWorkflowWithOperator(ctx, workflowID, runID, reason, options)
+	case StopWorkflowModeCancel:
+		err := wc.cancelWorkflowWithOperator(ctx, workflowID, runID, reason, options)
+		if isWorkflowStoppingOrClosedError(err) {
+			return nil
+		}
+		return err
+	case StopWorkflowModeCancelThenTerminate:
+	default:
+		return fmt.Errorf("unknown stop workflow mode: %v", options.Mode)
+	}
+
+	err = wc.cancelWorkflowWithOperator(ctx, workflowID, runID, reason, options)
+	switch err.(type) {
+	case nil, *s.CancellationAlreadyRequestedError:
+		// wait for the workflow to honor the cancellation, and escalate otherwise
+	case *s.WorkflowExecutionAlreadyCompletedError:
+		return nil
+	default:
+		return err
+	}
+	terminateAfter := options.TerminateAfter
+	if terminateAfter <= 0 {
+		terminateAfter = defaultStopTerminateAfter
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, terminateAfter)
+	defer cancel()
+	_, err = wc.getWorkflowHistoryPaginator(waitCtx, workflowID, runID, true, s.HistoryEventFilterTypeCloseEvent)(nil)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	err = wc.terminateWorkflowWithOperator(ctx, workflowID, runID, reason, options)
+	if _, ok := err.(*s.WorkflowExecutionAlreadyCompletedError); ok {
+		// the workflow closed while escalating
+		return nil
+	}
+	return err
+}
+
+func (wc *workflowClient) cancelWorkflowWithOperator(ctx context.Context, workflowID, runID, reason string, options StopWorkflowOptions) error {
+	request := &s.RequestCancelWorkflowExecutionRequest{
+		Domain: common.StringPtr(wc.domain),
+		WorkflowExecution: &s.WorkflowExecution{
+			WorkflowId: common.StringPtr(workflowID),
+			RunId:      getRunID(runID),
+		},
+		Identity: common.StringPtr(wc.identity),
+		Cause:    common.StringPtr(reason),
+	}
+	return backoff.RetryWithClock(ctx, wc.clock,
+		func() error {
+			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
+			defer cancel()
+			return wc.workflowService.RequestCancelWorkflowExecution(tchCtx, request, withOperatorHeader(opt, options.Operator)...)
+		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+}
+
+func (wc *workflowClient) terminateWorkflowWithOperator(ctx context.Context, workflowID, runID, reason string, options StopWorkflowOptions) error {
+	request := &s.TerminateWorkflowExecutionRequest{
+		Domain: common.StringPtr(wc.domain),
+		WorkflowExecution: &s.WorkflowExecution{
+			WorkflowId: common.StringPtr(workflowID),
+			RunId:      getRunID(runID),
+		},
+		Reason:   common.StringPtr(reason),
+		Details:  options.Details,
+		Identity: common.StringPtr(wc.identity),
+	}
+	return backoff.RetryWithClock(ctx, wc.clock,
+		func() error {
+			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
+			defer cancel()
+			return wc.workflowService.TerminateWorkflowExecution(tchCtx, request, withOperatorHeader(opt, options.Operator)...)
+		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+}
+
+// isWorkflowStoppingOrClosedError returns true for the errors of a cancellation request of a workflow which is
+// already being canceled or already closed.
+func isWorkflowStoppingOrClosedError(err error) bool {
+	switch err.(type) {
+	case *s.CancellationAlreadyRequestedError, *s.WorkflowExecutionAlreadyCompletedError:
+		return true
+	}
+	return false
+}
+
+func withOperatorHeader(opts []yarpc.CallOption, operator string) []yarpc.CallOption {
+	if operator == "" {
+		return opts
+	}
+	return append(opts, yarpc.WithHeader(operatorHeaderName, operator))
+}
+
+func getStopWorkflowReason(reason string, data StopWorkflowReason) (string, error) {
+	tmpl, err := template.New("reason").Parse(reason)
+	if err != nil {
+		return "", fmt.Errorf("invalid stop workflow reason template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid stop workflow reason template: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -127,21 +127,21 @@ func (s *WorkflowUnitTest) Test_SingleActivityWorkflowIsErrorMessagesMatched() {
 			ScheduleToStartTimeout: 0 * time.Second,
 			StartToCloseTimeout:    5 * time.Second,
 			ScheduleToCloseTimeout: 0 * time.Second,
-			expectedErrorMessage:   "missing or negative ScheduleToStartTimeoutSeconds",
+			expectedErrorMessage:   "ScheduleToStartTimeout is not set",
 		},
 		{
 			name:                   "ZeroStartToCloseTimeout",
 			ScheduleToStartTimeout: 10 * time.Second,
 			StartToCloseTimeout:    0 * time.Second,
 			ScheduleToCloseTimeout: 0 * time.Second,
-			expectedErrorMessage:   "missing or negative StartToCloseTimeoutSeconds",
+			expectedErrorMessage:   "StartToCloseTimeout is not set",
 		},
 		{
 			name:                   "NegativeScheduleToCloseTimeout",
 			ScheduleToStartTimeout: 10 * time.Second,
 			StartToCloseTimeout:    5 * time.Second,
 			ScheduleToCloseTimeout: -1 * time.Second,
-			expectedErrorMessage:   "invalid negative ScheduleToCloseTimeoutSeconds",
+			expectedErrorMessage:   "ScheduleToCloseTimeout is negative",
 		},
 	}
 
@@ -264,6 +264,7 @@ func TestWorkflowPanic(t *testing.T) {
 	resultErr := env.GetWorkflowError().(*PanicError)
 	require.EqualValues(t, "simulated", resultErr.Error())
 	require.Contains(t, resultErr.StackTrace(), "cadence/internal.splitJoinActivityWorkflow")
+	require.Equal(t, PanicCauseUser, resultErr.Cause())
 }
 
 func TestWorkflowReturnsPanic(t *testing.T) {
@@ -1310,6 +1311,103 @@ func (t *tracingInterceptor) ExecuteWorkflow(ctx Context, workflowType string, a
 	return result
 }
 
+func TestInterceptorHandleSignalAndQuery(t *testing.T) {
+	env := newTestWorkflowEnv(t)
+	interceptor := &signalQueryInterceptor{}
+	env.SetWorkerOptions(WorkerOptions{WorkflowInterceptorChainFactories: []WorkflowInterceptorFactory{interceptor}})
+
+	workflowFn := func(ctx Context) ([]string, error) {
+		var received []string
+		err := SetQueryHandler(ctx, "received", func(prefix string) ([]string, error) {
+			var result []string
+			for _, r := range received {
+				result = append(result, prefix+r)
+			}
+			return result, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = SetQueryHandler(ctx, "secret", func() (string, error) {
+			return "secret", nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		ch := GetSignalChannel(ctx, "signal")
+		for len(received) < 2 {
+			var value string
+			ch.Receive(ctx, &value)
+			received = append(received, value)
+		}
+		return received, nil
+	}
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("signal", "a")
+	}, time.Minute)
+	env.RegisterDelayedCallback(func() {
+		value, err := env.QueryWorkflow("received", "got ")
+		require.NoError(t, err)
+		var result []string
+		require.NoError(t, value.Get(&result))
+		require.Equal(t, []string{"got a"}, result)
+
+		_, err = env.QueryWorkflow("secret")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "query secret is not allowed")
+	}, 2*time.Minute)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("signal", "dropped")
+		env.SignalWorkflow("signal", "b")
+	}, 3*time.Minute)
+
+	env.ExecuteWorkflow(workflowFn)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var result []string
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, []string{"a", "b"}, result)
+	require.Equal(t, []string{
+		"HandleSignal signal a",
+		"HandleQuery received [got ]",
+		"HandleQuery secret []",
+		"HandleSignal signal dropped",
+		"HandleSignal signal b",
+	}, interceptor.trace)
+}
+
+var _ WorkflowInterceptorFactory = (*signalQueryInterceptor)(nil)
+
+type signalQueryInterceptor struct {
+	WorkflowInterceptorBase
+	trace []string
+}
+
+func (t *signalQueryInterceptor) NewInterceptor(info *WorkflowInfo, next WorkflowInterceptor) WorkflowInterceptor {
+	t.Next = next
+	return t
+}
+
+func (t *signalQueryInterceptor) HandleSignal(ctx Context, signalName string, arg Value) {
+	var value string
+	if err := arg.Get(&value); err != nil {
+		panic(err)
+	}
+	t.trace = append(t.trace, fmt.Sprintf("HandleSignal %v %v", signalName, value))
+	if value == "dropped" {
+		return
+	}
+	t.Next.HandleSignal(ctx, signalName, arg)
+}
+
+func (t *signalQueryInterceptor) HandleQuery(ctx Context, queryType string, args ...interface{}) (interface{}, error) {
+	t.trace = append(t.trace, fmt.Sprintf("HandleQuery %v %v", queryType, args))
+	if queryType == "secret" {
+		return nil, fmt.Errorf("query %v is not allowed", queryType)
+	}
+	return t.Next.HandleQuery(ctx, queryType, args...)
+}
+
 type WorkflowOptionTest struct {
 	suite.Suite
 }
@@ -1319,18 +1417,20 @@ func TestWorkflowOption(t *testing.T) {
 }
 
 func (t *WorkflowOptionTest) TestKnowQueryType_NoHandlers() {
-	wo := workflowOptions{queryHandlers: make(map[string]func([]byte) ([]byte, error))}
+	wo := workflowOptions{queryHandlers: make(map[string]*queryHandler)}
 	t.ElementsMatch(
 		[]string{
 			QueryTypeStackTrace,
 			QueryTypeOpenSessions,
 			QueryTypeQueryTypes,
+			QueryTypeUnhandledSignals,
+			QueryTypeVersions,
 		},
 		wo.KnownQueryTypes())
 }
 
 func (t *WorkflowOptionTest) TestKnowQueryType_WithHandlers() {
-	wo := workflowOptions{queryHandlers: map[string]func([]byte) ([]byte, error){
+	wo := workflowOptions{queryHandlers: map[string]*queryHandler{
 		"a": nil,
 		"b": nil,
 	}}
@@ -1340,6 +1440,8 @@ func (t *WorkflowOptionTest) TestKnowQueryType_WithHandlers() {
 			QueryTypeStackTrace,
 			QueryTypeOpenSessions,
 			QueryTypeQueryTypes,
+			QueryTypeUnhandledSignals,
+			QueryTypeVersions,
 			"a",
 			"b",
 		},
@@ -58,6 +58,7 @@ const (
 	defaultTestWorkflowTypeName = "default-test-workflow-type-name"
 	defaultTestDomainName       = "default-test-domain-name"
 	workflowTypeNotSpecified    = "workflow-type-not-specified"
+	testWorkflowRequesterID     = "test-workflow-requester-id"
 )
 
 type (
@@ -73,9 +74,10 @@ type (
 	}
 
 	testActivityHandle struct {
-		callback         resultHandler
-		activityType     string
-		heartbeatDetails []byte
+		callback          resultHandler
+		activityType      string
+		heartbeatDetails  []byte
+		lastHeartbeatTime time.Time
 	}
 
 	testWorkflowHandle struct {
@@ -145,6 +147,18 @@ type (
 
 		expectedMockCalls map[string]struct{}
 
+		// propagatedHeadersLock is separate from locker, which is held while the workflow code runs
+		propagatedHeadersLock sync.Mutex
+		propagatedHeaders     []PropagatedHeader
+
+		// traceLock guards the operations of the tested workflow recorded for AssertTrace
+		traceLock sync.Mutex
+		trace     []string
+
+		// workflowRequestCallbacks receive the responses to the requests sent with SendWorkflowRequest by request ID
+		workflowRequestLock      sync.Mutex
+		workflowRequestCallbacks map[string]func(response WorkflowResponse)
+
 		onActivityStartedListener        func(activityInfo *ActivityInfo, ctx context.Context, args Values)
 		onActivityCompletedListener      func(activityInfo *ActivityInfo, result Value, err error)
 		onActivityCanceledListener       func(activityInfo *ActivityInfo)
@@ -160,6 +174,11 @@ type (
 		onTimerCancelledListener         func(timerID string)
 
 		cronMaxIterations int
+
+		breakpoints []*TestBreakpoint // not reached yet, only accessed by the main loop
+
+		decisionTimeRemaining func() time.Duration // see TestWorkflowEnvironment.SetDecisionTimeRemaining
+		checkQueryHandlers    bool                 // see TestWorkflowEnvironment.SetQueryHandlerChecks
 	}
 
 	// testWorkflowEnvironmentImpl is the environment that runs the workflow/activity unit tests.
@@ -175,6 +194,7 @@ type (
 		openSessions   map[string]*SessionInfo
 
 		workflowCancelHandler func()
+		cancellation          *CancellationDetails
 		signalHandler         func(name string, input []byte)
 		queryHandler          func(string, []byte) ([]byte, error)
 		startedHandler        func(r WorkflowExecution, e error)
@@ -298,6 +318,7 @@ func newTestWorkflowEnvironmentImpl(s *WorkflowTestSuite, parentRegistry *regist
 			return &shared.EntityNotExistsError{}
 		}
 		activityHandle.heartbeatDetails = r.Details
+		activityHandle.lastHeartbeatTime = env.Now()
 		activityInfo := env.getActivityInfo(activityID, activityHandle.activityType)
 		env.postCallback(func() {
 			if env.onActivityHeartbeatListener != nil {
@@ -323,6 +344,14 @@ func newTestWorkflowEnvironmentImpl(s *WorkflowTestSuite, parentRegistry *regist
 		// em.Return(&shared.RecordActivityTaskHeartbeatResponse{CancelRequested: common.BoolPtr(false)}, err)
 		mockHeartbeatFn(ctx, r, opts...)
 	}).AnyTimes()
+	mockService.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), callOptions...).
+		DoAndReturn(func(ctx context.Context, r *shared.DescribeWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.DescribeWorkflowExecutionResponse, error) {
+			env.locker.Lock() // need lock as this is running in local activity worker's goroutinue
+			defer env.locker.Unlock()
+			return &shared.DescribeWorkflowExecutionResponse{
+				PendingActivities: env.getPendingActivities(r.Execution.GetRunId()),
+			}, nil
+		}).AnyTimes()
 
 	env.service = mockService
 
@@ -355,6 +384,77 @@ func (env *testWorkflowEnvironmentImpl) setCronSchedule(cronSchedule string) {
 	env.workflowInfo.CronSchedule = &cronSchedule
 }
 
+func (env *testWorkflowEnvironmentImpl) recordPropagatedHeader(kind PropagatedHeaderKind, name string, header *shared.Header) {
+	fields := make(map[string][]byte)
+	if header != nil {
+		for k, v := range header.Fields {
+			fields[k] = v
+		}
+	}
+	env.propagatedHeadersLock.Lock()
+	defer env.propagatedHeadersLock.Unlock()
+	env.propagatedHeaders = append(env.propagatedHeaders, PropagatedHeader{Kind: kind, Name: name, Fields: fields})
+}
+
+func (env *testWorkflowEnvironmentImpl) getPropagatedHeaders() []PropagatedHeader {
+	env.propagatedHeadersLock.Lock()
+	defer env.propagatedHeadersLock.Unlock()
+	return append([]PropagatedHeader(nil), env.propagatedHeaders...)
+}
+
+// recordTrace records an operation of the tested workflow. Operations of its child workflows are not recorded.
+func (env *testWorkflowEnvironmentImpl) recordTrace(format string, args ...interface{}) {
+	if env.isChildWorkflow() {
+		return
+	}
+	env.traceLock.Lock()
+	defer env.traceLock.Unlock()
+	env.trace = append(env.trace, fmt.Sprintf(format, args...))
+}
+
+func (env *testWorkflowEnvironmentImpl) getTrace() []string {
+	env.traceLock.Lock()
+	defer env.traceLock.Unlock()
+	return append([]string(nil), env.trace...)
+}
+
+// setDefaultActivityOptions updates the default activity options carried by the header of the tested workflow. The
+// header is copied, as it may be shared with other environments of the same test suite.
+func (env *testWorkflowEnvironmentImpl) setDefaultActivityOptions(update func(d *defaultActivityOptions)) {
+	defaults, err := readDefaultActivityOptionsHeader(env.header)
+	if err != nil || defaults == nil {
+		defaults = &defaultActivityOptions{}
+	}
+	update(defaults)
+
+	header := &shared.Header{Fields: make(map[string][]byte)}
+	if env.header != nil {
+		for k, v := range env.header.Fields {
+			header.Fields[k] = v
+		}
+	}
+	if err := writeDefaultActivityOptionsHeader(header, defaults); err != nil {
+		panic(err)
+	}
+	env.header = header
+}
+
+// setHeaders sets the headers carried by the header of the tested workflow. The header is copied, as it may be shared
+// with other environments of the same test suite.
+func (env *testWorkflowEnvironmentImpl) setHeaders(headers map[string]string) {
+	header := &shared.Header{Fields: make(map[string][]byte)}
+	if env.header != nil {
+		for k, v := range env.header.Fields {
+			header.Fields[k] = v
+		}
+	}
+	delete(header.Fields, workflowHeadersHeaderKey)
+	if err := writeWorkflowHeadersHeader(header, headers); err != nil {
+		panic(err)
+	}
+	env.header = header
+}
+
 func (env *testWorkflowEnvironmentImpl) setCronMaxIterationas(cronMaxIterations int) {
 	env.cronMaxIterations = cronMaxIterations
 }
@@ -441,6 +541,13 @@ func (env *testWorkflowEnvironmentImpl) setWorkerOptions(options WorkerOptions)
 	if options.Logger != nil {
 		env.workerOptions.Logger = options.Logger
 	}
+	if options.PayloadRedactor != nil {
+		env.workerOptions.PayloadRedactor = options.PayloadRedactor
+	}
+	if options.Tracer != nil {
+		env.workerOptions.Tracer = options.Tracer
+	}
+	env.workerOptions.NamingPolicies = options.NamingPolicies
 	env.workflowInterceptors = options.WorkflowInterceptorChainFactories
 }
 
@@ -518,6 +625,9 @@ func (env *testWorkflowEnvironmentImpl) executeWorkflowInternal(delayStart time.
 		}, timeoutDuration)
 	}
 	env.startMainLoop()
+	if !env.isChildWorkflow() {
+		env.releaseBreakpoints()
+	}
 }
 
 func (env *testWorkflowEnvironmentImpl) getWorkflowDefinition(wt WorkflowType) (workflowDefinition, error) {
@@ -646,10 +756,35 @@ func (env *testWorkflowEnvironmentImpl) executeLocalActivity(
 
 func (env *testWorkflowEnvironmentImpl) startDecisionTask() {
 	if !env.isTestCompleted {
+		if definition, ok := env.workflowDef.(*syncWorkflowDefinition); ok && env.checkQueryHandlers {
+			if dispatcher, ok := definition.dispatcher.(*dispatcherImpl); ok {
+				dispatcher.afterAllBlocked = func() error {
+					return env.executeQueryHandlers(dispatcher)
+				}
+			}
+		}
 		env.workflowDef.OnDecisionTaskStarted()
 	}
 }
 
+// executeQueryHandlers executes the query handlers of the workflow which take no argument once all the coroutines of
+// the dispatcher are blocked, and returns an error with the stack traces of the coroutines if one of them fails.
+func (env *testWorkflowEnvironmentImpl) executeQueryHandlers(dispatcher *dispatcherImpl) error {
+	definition, ok := env.workflowDef.(*syncWorkflowDefinition)
+	if !ok || definition.rootCtx == nil {
+		return nil
+	}
+	for _, handler := range getWorkflowEnvOptions(definition.rootCtx).queryHandlers {
+		if reflect.TypeOf(handler.fn).NumIn() != 0 {
+			continue
+		}
+		if _, err := handler.execute(nil); err != nil {
+			return fmt.Errorf("query %v failed after decision task: %v\n%v", handler.queryType, err, dispatcher.StackTrace())
+		}
+	}
+	return nil
+}
+
 func (env *testWorkflowEnvironmentImpl) isChildWorkflow() bool {
 	return env.parentEnv != nil
 }
@@ -668,7 +803,11 @@ func (env *testWorkflowEnvironmentImpl) startMainLoop() {
 			// this will drain the callbackChannel
 			c.processCallback()
 		default:
-			// nothing to process, main thread is blocked at this moment, now check if we should auto fire next timer
+			// nothing to process, main thread is blocked at this moment, pause at the reached breakpoints before
+			// checking if we should auto fire next timer
+			if env.pauseAtBreakpoints() {
+				continue
+			}
 			if !env.autoFireNextTimer() {
 				if env.isTestCompleted {
 					return
@@ -690,6 +829,40 @@ func (env *testWorkflowEnvironmentImpl) startMainLoop() {
 	}
 }
 
+func (env *testWorkflowEnvironmentImpl) setBreakpoint(breakpoint *TestBreakpoint, at time.Time) {
+	env.postCallback(func() {
+		if !at.IsZero() && at.After(env.mockClock.Now()) {
+			// stop the mock clock at the breakpoint, even if the workflow has no timer firing then
+			env.newTimer(at.Sub(env.mockClock.Now()), func(result []byte, err error) {}, false)
+		}
+		env.breakpoints = append(env.breakpoints, breakpoint)
+	}, false)
+}
+
+// pauseAtBreakpoints pauses the main loop at the first reached breakpoint until the test resumes it, and returns
+// whether it did.
+func (env *testWorkflowEnvironmentImpl) pauseAtBreakpoints() bool {
+	for i, breakpoint := range env.breakpoints {
+		if !breakpoint.isReached(env) {
+			continue
+		}
+		env.breakpoints = append(env.breakpoints[:i], env.breakpoints[i+1:]...)
+		breakpoint.reached = true
+		close(breakpoint.doneCh)
+		<-breakpoint.resumeCh
+		return true
+	}
+	return false
+}
+
+// releaseBreakpoints unblocks the tests waiting for breakpoints the workflow completed without reaching.
+func (env *testWorkflowEnvironmentImpl) releaseBreakpoints() {
+	for _, breakpoint := range env.breakpoints {
+		close(breakpoint.doneCh)
+	}
+	env.breakpoints = nil
+}
+
 func (env *testWorkflowEnvironmentImpl) registerDelayedCallback(f func(), delayDuration time.Duration) {
 	timerCallback := func(result []byte, err error) {
 		f()
@@ -844,6 +1017,9 @@ func (env *testWorkflowEnvironmentImpl) Complete(result []byte, err error) {
 			env.testError = newPanicError(err.value, err.stackTrace)
 		default:
 			reason, details := getErrorDetails(err, dc)
+			if env.isChildWorkflow() {
+				reason, details = getChildWorkflowErrorDetails(err, dc)
+			}
 			env.testError = constructError(reason, details, dc)
 		}
 	} else {
@@ -917,6 +1093,22 @@ func (h *testWorkflowHandle) rerun(asChild bool) bool {
 	if asChild {
 		params.lastCompletionResult = result
 
+		if contErr, ok := env.testError.(*ContinueAsNewError); ok {
+			// like the server, start the new run of the child under the same workflow ID, so the parent receives the
+			// result or the error of the last run instead of the ContinueAsNewError.
+			delete(env.runningWorkflows, env.workflowInfo.WorkflowExecution.ID)
+			params.workflowType = contErr.params.workflowType
+			params.input = contErr.params.input
+			params.header = contErr.params.header
+			params.taskListName = contErr.params.taskListName
+			params.executionStartToCloseTimeoutSeconds = contErr.params.executionStartToCloseTimeoutSeconds
+			params.taskStartToCloseTimeoutSeconds = contErr.params.taskStartToCloseTimeoutSeconds
+			params.attempt = 0
+			params.scheduledTime = env.Now()
+			env.parentEnv.executeChildWorkflowWithDelay(0, *params, h.callback, nil /* child workflow already started */)
+			return true
+		}
+
 		if params.retryPolicy != nil && env.testError != nil {
 			errReason, _ := getErrorDetails(env.testError, env.GetDataConverter())
 			var expireTime time.Time
@@ -1039,6 +1231,8 @@ func (env *testWorkflowEnvironmentImpl) ExecuteActivity(parameters executeActivi
 	} else {
 		activityID = *parameters.ActivityID
 	}
+	env.recordPropagatedHeader(PropagatedHeaderActivity, parameters.ActivityType.Name, parameters.Header)
+	env.recordTrace("activity %v", parameters.ActivityType.Name)
 	activityInfo := &activityInfo{activityID: activityID}
 	task := newTestActivityTask(
 		defaultTestWorkflowID,
@@ -1094,6 +1288,28 @@ func (env *testWorkflowEnvironmentImpl) setActivityHandle(activityID string, han
 	env.activities[env.makeUniqueID(activityID)] = handle
 }
 
+// getPendingActivities returns the running activities of the workflow run, as reported by DescribeWorkflowExecution.
+func (env *testWorkflowEnvironmentImpl) getPendingActivities(runID string) []*shared.PendingActivityInfo {
+	prefix := runID + "_"
+	var pending []*shared.PendingActivityInfo
+	for id, handle := range env.activities {
+		if !strings.HasPrefix(id, prefix) {
+			continue
+		}
+		info := &shared.PendingActivityInfo{
+			ActivityID:       common.StringPtr(strings.TrimPrefix(id, prefix)),
+			ActivityType:     &shared.ActivityType{Name: common.StringPtr(handle.activityType)},
+			State:            shared.PendingActivityStateStarted.Ptr(),
+			HeartbeatDetails: handle.heartbeatDetails,
+		}
+		if !handle.lastHeartbeatTime.IsZero() {
+			info.LastHeartbeatTimestamp = common.Int64Ptr(handle.lastHeartbeatTime.UnixNano())
+		}
+		pending = append(pending, info)
+	}
+	return pending
+}
+
 func (env *testWorkflowEnvironmentImpl) deleteHandle(activityID string) {
 	delete(env.activities, env.makeUniqueID(activityID))
 }
@@ -1192,6 +1408,8 @@ func (env *testWorkflowEnvironmentImpl) ExecuteLocalActivity(params executeLocal
 		return aew.ExecuteWithActualArgs(ctx, params.InputArgs)
 	}
 
+	env.recordPropagatedHeader(PropagatedHeaderLocalActivity, ae.name, params.Header)
+	env.recordTrace("local activity %v", ae.name)
 	task := newLocalActivityTask(params, callback, activityID)
 	taskHandler := localActivityTaskHandler{
 		userContext:        wOptions.BackgroundActivityContext,
@@ -1201,6 +1419,7 @@ func (env *testWorkflowEnvironmentImpl) ExecuteLocalActivity(params executeLocal
 		tracer:             wOptions.Tracer,
 		contextPropagators: wOptions.ContextPropagators,
 		activityTracker:    debug.NewNoopActivityTracker(),
+		service:            env.service,
 	}
 
 	env.localActivities[activityID] = task
@@ -1257,7 +1476,7 @@ func (env *testWorkflowEnvironmentImpl) handleActivityResult(activityID string,
 		return
 	}
 
-	delete(env.activities, activityID)
+	env.deleteHandle(activityID)
 
 	var blob []byte
 	var err error
@@ -1734,6 +1953,7 @@ func (env *testWorkflowEnvironmentImpl) newTimer(d time.Duration, callback resul
 }
 
 func (env *testWorkflowEnvironmentImpl) NewTimer(d time.Duration, callback resultHandler) *timerInfo {
+	env.recordTrace("timer %v", d)
 	return env.newTimer(d, callback, true)
 }
 
@@ -1765,6 +1985,10 @@ func (env *testWorkflowEnvironmentImpl) RegisterCancelHandler(handler func()) {
 	env.workflowCancelHandler = handler
 }
 
+func (env *testWorkflowEnvironmentImpl) GetCancellationDetails() *CancellationDetails {
+	return env.cancellation
+}
+
 func (env *testWorkflowEnvironmentImpl) RegisterSignalHandler(handler func(name string, input []byte)) {
 	env.signalHandler = handler
 }
@@ -1777,14 +2001,18 @@ func (env *testWorkflowEnvironmentImpl) RequestCancelChildWorkflow(domainName, w
 	if childHandle, ok := env.runningWorkflows[workflowID]; ok && !childHandle.handled {
 		// current workflow is a parent workflow, and we are canceling a child workflow
 		childEnv := childHandle.env
-		childEnv.cancelWorkflow(func(result []byte, err error) {})
+		childEnv.cancelWorkflow(env.newChildCancellationDetails(), func(result []byte, err error) {})
 		return
 	}
 }
 
 func (env *testWorkflowEnvironmentImpl) RequestCancelExternalWorkflow(domainName, workflowID, runID string, callback resultHandler) {
-	if env.workflowInfo.WorkflowExecution.ID == workflowID {
+	if env.isCurrentWorkflow(domainName, workflowID) {
 		// cancel current workflow
+		if env.cancellation == nil {
+			execution := env.workflowInfo.WorkflowExecution
+			env.cancellation = &CancellationDetails{Source: CancellationSourceWorkflow, ExternalWorkflowExecution: &execution}
+		}
 		env.workflowCancelHandler()
 		// check if current workflow is a child workflow
 		if env.isChildWorkflow() && env.onChildWorkflowCanceledListener != nil {
@@ -1793,7 +2021,7 @@ func (env *testWorkflowEnvironmentImpl) RequestCancelExternalWorkflow(domainName
 			}, false)
 		}
 		return
-	} else if childHandle, ok := env.runningWorkflows[workflowID]; ok && !childHandle.handled {
+	} else if childHandle, ok := env.getRunningWorkflow(domainName, workflowID); ok && !childHandle.handled {
 		// current workflow is a parent workflow, and we are canceling a child workflow
 		if !childHandle.params.waitForCancellation {
 			childHandle.env.Complete(nil, ErrCanceled)
@@ -1802,7 +2030,7 @@ func (env *testWorkflowEnvironmentImpl) RequestCancelExternalWorkflow(domainName
 		env.postCallback(func() {
 			callback(nil, nil)
 		}, true)
-		childEnv.cancelWorkflow(callback)
+		childEnv.cancelWorkflow(env.newChildCancellationDetails(), callback)
 		return
 	}
 
@@ -1839,9 +2067,31 @@ func (env *testWorkflowEnvironmentImpl) IsCron() bool {
 	return env.workflowInfo.CronSchedule != nil && len(*env.workflowInfo.CronSchedule) > 0
 }
 
+// isCurrentWorkflow returns true if the domain and workflow ID identify the workflow running in this env.
+// An empty domain matches any domain.
+func (env *testWorkflowEnvironmentImpl) isCurrentWorkflow(domainName, workflowID string) bool {
+	return env.workflowInfo.WorkflowExecution.ID == workflowID &&
+		(domainName == "" || domainName == env.workflowInfo.Domain)
+}
+
+// getRunningWorkflow returns the running workflow with the given workflow ID, if it runs in the given domain.
+// The domain of the calling workflow, which is the default target domain, matches any running workflow. Other
+// domains are simulated with mocks, even if their workflow ID matches a running workflow.
+func (env *testWorkflowEnvironmentImpl) getRunningWorkflow(domainName, workflowID string) (*testWorkflowHandle, bool) {
+	handle, ok := env.runningWorkflows[workflowID]
+	if !ok {
+		return nil, false
+	}
+	if domainName != "" && domainName != env.workflowInfo.Domain && domainName != handle.env.workflowInfo.Domain {
+		return nil, false
+	}
+	return handle, true
+}
+
 func (env *testWorkflowEnvironmentImpl) SignalExternalWorkflow(domainName, workflowID, runID, signalName string, input []byte, arg interface{}, childWorkflowOnly bool, callback resultHandler) {
+	env.recordTrace("signal external %v %v", workflowID, signalName)
 	// check if target workflow is a known workflow
-	if childHandle, ok := env.runningWorkflows[workflowID]; ok {
+	if childHandle, ok := env.getRunningWorkflow(domainName, workflowID); ok {
 		// target workflow is a child
 		childEnv := childHandle.env
 		if childEnv.isTestCompleted {
@@ -1856,6 +2106,15 @@ func (env *testWorkflowEnvironmentImpl) SignalExternalWorkflow(domainName, workf
 		return
 	}
 
+	// the response to a request sent with SendWorkflowRequest
+	if response, ok := arg.(WorkflowResponse); ok && workflowID == testWorkflowRequesterID {
+		if requestCallback := env.takeWorkflowRequestCallback(response.ID); requestCallback != nil {
+			requestCallback(response)
+			callback(nil, nil)
+			return
+		}
+	}
+
 	// here we signal a child workflow but we cannot find it
 	if childWorkflowOnly {
 		err := newUnknownExternalWorkflowExecutionError()
@@ -1887,6 +2146,7 @@ func (env *testWorkflowEnvironmentImpl) SignalExternalWorkflow(domainName, workf
 }
 
 func (env *testWorkflowEnvironmentImpl) ExecuteChildWorkflow(params executeWorkflowParams, callback resultHandler, startedHandler func(r WorkflowExecution, e error)) error {
+	env.recordTrace("child workflow %v", params.workflowType.Name)
 	return env.executeChildWorkflowWithDelay(0, params, callback, startedHandler)
 }
 
@@ -1914,6 +2174,7 @@ func (env *testWorkflowEnvironmentImpl) executeChildWorkflowWithDelay(delayStart
 		env.logger.Sugar().Infof("ExecuteChildWorkflow failed: %v", err)
 		return err
 	}
+	env.recordPropagatedHeader(PropagatedHeaderChildWorkflow, params.workflowType.Name, params.header)
 
 	env.logger.Sugar().Infof("ExecuteChildWorkflow: %v", params.workflowType.Name)
 	env.runningCount++
@@ -1932,13 +2193,13 @@ func (env *testWorkflowEnvironmentImpl) GetVersion(changeID string, minSupported
 	if mockVersion, ok := env.getMockedVersion(changeID, changeID, minSupported, maxSupported); ok {
 		// GetVersion for changeID is mocked
 		env.UpsertSearchAttributes(createSearchAttributesForChangeVersion(changeID, mockVersion, env.changeVersions))
-		env.changeVersions[changeID] = mockVersion
+		env.setChangeVersion(changeID, mockVersion)
 		return mockVersion
 	}
 	if mockVersion, ok := env.getMockedVersion(mock.Anything, changeID, minSupported, maxSupported); ok {
 		// GetVersion is mocked with any changeID.
 		env.UpsertSearchAttributes(createSearchAttributesForChangeVersion(changeID, mockVersion, env.changeVersions))
-		env.changeVersions[changeID] = mockVersion
+		env.setChangeVersion(changeID, mockVersion)
 		return mockVersion
 	}
 
@@ -1948,10 +2209,17 @@ func (env *testWorkflowEnvironmentImpl) GetVersion(changeID string, minSupported
 		return version
 	}
 	env.UpsertSearchAttributes(createSearchAttributesForChangeVersion(changeID, maxSupported, env.changeVersions))
-	env.changeVersions[changeID] = maxSupported
+	env.setChangeVersion(changeID, maxSupported)
 	return maxSupported
 }
 
+func (env *testWorkflowEnvironmentImpl) setChangeVersion(changeID string, version Version) {
+	if current, ok := env.changeVersions[changeID]; !ok || current != version {
+		env.recordTrace("version %v %v", changeID, version)
+	}
+	env.changeVersions[changeID] = version
+}
+
 func (env *testWorkflowEnvironmentImpl) getMockedVersion(mockedChangeID, changeID string, minSupported, maxSupported Version) (Version, bool) {
 	mockMethod := getMockMethodForGetVersion(mockedChangeID)
 	if _, ok := env.expectedMockCalls[mockMethod]; !ok {
@@ -2042,8 +2310,17 @@ func (env *testWorkflowEnvironmentImpl) getActivityInfo(activityID, activityType
 	}
 }
 
-func (env *testWorkflowEnvironmentImpl) cancelWorkflow(callback resultHandler) {
+// newChildCancellationDetails returns the details of a cancellation of a child workflow requested by this workflow.
+func (env *testWorkflowEnvironmentImpl) newChildCancellationDetails() *CancellationDetails {
+	execution := env.workflowInfo.WorkflowExecution
+	return &CancellationDetails{Source: CancellationSourceWorkflow, ExternalWorkflowExecution: &execution}
+}
+
+func (env *testWorkflowEnvironmentImpl) cancelWorkflow(details *CancellationDetails, callback resultHandler) {
 	env.postCallback(func() {
+		if env.cancellation == nil {
+			env.cancellation = details
+		}
 		// RequestCancelWorkflow needs to be run in main thread
 		env.RequestCancelExternalWorkflow(
 			env.workflowInfo.Domain,
@@ -2060,6 +2337,7 @@ func (env *testWorkflowEnvironmentImpl) signalWorkflow(name string, input interf
 		panic(err)
 	}
 	env.postCallback(func() {
+		env.recordTrace("signal %v", name)
 		env.signalHandler(name, data)
 	}, startDecisionTask)
 }
@@ -2095,6 +2373,23 @@ func (env *testWorkflowEnvironmentImpl) queryWorkflow(queryType string, args ...
 	return newEncodedValue(blob, env.GetDataConverter()), nil
 }
 
+func (env *testWorkflowEnvironmentImpl) setWorkflowRequestCallback(requestID string, callback func(response WorkflowResponse)) {
+	env.workflowRequestLock.Lock()
+	defer env.workflowRequestLock.Unlock()
+	if env.workflowRequestCallbacks == nil {
+		env.workflowRequestCallbacks = make(map[string]func(response WorkflowResponse))
+	}
+	env.workflowRequestCallbacks[requestID] = callback
+}
+
+func (env *testWorkflowEnvironmentImpl) takeWorkflowRequestCallback(requestID string) func(response WorkflowResponse) {
+	env.workflowRequestLock.Lock()
+	defer env.workflowRequestLock.Unlock()
+	callback := env.workflowRequestCallbacks[requestID]
+	delete(env.workflowRequestCallbacks, requestID)
+	return callback
+}
+
 func (env *testWorkflowEnvironmentImpl) getMockRunFn(callWrapper *MockCallWrapper) func(args mock.Arguments) {
 	env.locker.Lock()
 	defer env.locker.Unlock()
@@ -2129,6 +2424,35 @@ func (env *testWorkflowEnvironmentImpl) GetWorkflowInterceptors() []WorkflowInte
 	return env.workflowInterceptors
 }
 
+func (env *testWorkflowEnvironmentImpl) GetPayloadRedactor() PayloadRedactor {
+	return env.workerOptions.PayloadRedactor
+}
+
+func (env *testWorkflowEnvironmentImpl) GetNamingPolicies() NamingPolicies {
+	return env.workerOptions.NamingPolicies
+}
+
+func (env *testWorkflowEnvironmentImpl) GetTracer() opentracing.Tracer {
+	return env.workerOptions.Tracer
+}
+
+func (env *testWorkflowEnvironmentImpl) GetChangeVersions() map[string]Version {
+	return env.changeVersions
+}
+
+func (env *testWorkflowEnvironmentImpl) GetDecisionTimeRemaining() time.Duration {
+	if env.decisionTimeRemaining != nil {
+		return env.decisionTimeRemaining()
+	}
+	// the test environment has no decision tasks, every decision gets the full decision task timeout
+	return time.Duration(env.workflowInfo.TaskStartToCloseTimeoutSeconds) * time.Second
+}
+
+func (env *testWorkflowEnvironmentImpl) GetYieldRecorder() *yieldRecorder {
+	// yield diagnostics are recorded per decision task, which the test environment does not have
+	return nil
+}
+
 func newTestSessionEnvironment(testWorkflowEnvironment *testWorkflowEnvironmentImpl,
 	params *workerExecutionParameters, concurrentSessionExecutionSize int) *testSessionEnvironmentImpl {
 	resourceID := params.SessionResourceID
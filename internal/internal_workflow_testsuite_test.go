@@ -227,6 +227,27 @@ func (s *WorkflowTestSuiteUnitTest) Test_TimerWorkflow_ClockAutoFastForward() {
 	s.Equal([]string{"t2", "t3", "t1", "t4"}, firedTimerRecord)
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_SleepWithJitter() {
+	workflowFn := func(ctx Context) (time.Duration, error) {
+		start := Now(ctx)
+		if err := SleepWithJitter(ctx, time.Minute, time.Minute); err != nil {
+			return 0, err
+		}
+		return Now(ctx).Sub(start), nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var slept time.Duration
+	s.NoError(env.GetWorkflowResult(&slept))
+	s.GreaterOrEqual(slept, time.Minute)
+	s.Less(slept, 2*time.Minute+time.Second)
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_WorkflowAutoForwardClock() {
 	workflowFn := func(ctx Context) (string, error) {
 		// Schedule a timer with long duration. In this test, we won't actually wait for that long, because the test suite
@@ -1633,6 +1654,40 @@ func (s *WorkflowTestSuiteUnitTest) Test_WorkflowHeaderContext() {
 	s.NoError(env.GetWorkflowError())
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_LocalActivityContextPropagation() {
+	localActivityFn := func(ctx context.Context) (string, error) {
+		if val, ok := ctx.Value(contextKey(testHeader)).(string); ok {
+			return val, nil
+		}
+		return "", fmt.Errorf("context did not propagate to local activity")
+	}
+
+	workflowFn := func(ctx Context) error {
+		ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: time.Minute})
+		laCtx := WithValue(ctx, contextKey(testHeader), "test-data-for-local-activity")
+		var result string
+		if err := ExecuteLocalActivity(laCtx, localActivityFn).Get(ctx, &result); err != nil {
+			return err
+		}
+		s.Equal("test-data-for-local-activity", result)
+		return nil
+	}
+
+	s.SetContextPropagators([]ContextPropagator{NewStringMapPropagator([]string{testHeader})})
+	s.SetHeader(&shared.Header{
+		Fields: map[string][]byte{
+			testHeader: []byte("test-data"),
+		},
+	})
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_ChildWorkflowContextPropagation() {
 
 	childWorkflowFn := func(ctx Context) error {
@@ -1709,6 +1764,33 @@ func (s *WorkflowTestSuiteUnitTest) Test_WorkflowUnknownName() {
 	s.Fail("Should have panic'ed at ExecuteWorkflow")
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_QueryWorkflow_NilResult() {
+	workflowFn := func(ctx Context) error {
+		if err := SetQueryHandler(ctx, "interface", func() (interface{}, error) { return nil, nil }); err != nil {
+			return err
+		}
+		if err := SetQueryHandler(ctx, "pointer", func() (*string, error) { return nil, nil }); err != nil {
+			return err
+		}
+		return Sleep(ctx, time.Hour)
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterDelayedCallback(func() {
+		encodedValue, err := env.QueryWorkflow("interface")
+		s.NoError(err)
+		s.True(encodedValue.HasValue(), "a nil interface{} is encoded as null")
+
+		encodedValue, err = env.QueryWorkflow("pointer")
+		s.NoError(err)
+		s.False(encodedValue.HasValue(), "a nil pointer is not encoded")
+	}, time.Minute)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_QueryWorkflow() {
 	queryType := "state"
 	stateWaitSignal, stateWaitActivity, stateDone := "wait for signal", "wait for activity", "done"
@@ -3108,6 +3190,164 @@ func (s *WorkflowTestSuiteUnitTest) Test_AwaitWithTimeout() {
 	s.False(result)
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_AwaitWithTimeoutResult() {
+	workflowFn := func(ctx Context, timeout time.Duration) (string, error) {
+		approved := false
+		GetSignalChannel(ctx, "approve").Receive(ctx, nil)
+		approved = true
+		ok, err := AwaitWithTimeout(ctx, timeout, func() bool { return approved })
+		if err != nil {
+			return "", err
+		}
+		start := Now(ctx)
+		ok2, err := AwaitWithTimeout(ctx, timeout, func() bool { return false })
+		return fmt.Sprintf("%v %v %v", ok, ok2, Now(ctx).Sub(start)), err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("approve", nil)
+	}, time.Minute)
+	env.ExecuteWorkflow(workflowFn, time.Hour)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("true false 1h0m0s", result)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_AwaitWithTimeoutNotPositive() {
+	workflowFn := func(ctx Context) error {
+		for _, timeout := range []time.Duration{0, -time.Second} {
+			if _, err := AwaitWithTimeout(ctx, timeout, func() bool { return true }); err != errAwaitTimeoutNotPositive {
+				return fmt.Errorf("unexpected error for timeout %v: %v", timeout, err)
+			}
+		}
+		return nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_AwaitWithTimeoutCanceled() {
+	workflowFn := func(ctx Context) error {
+		_, err := AwaitWithTimeout(ctx, time.Hour, func() bool { return false })
+		return err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterDelayedCallback(func() {
+		env.CancelWorkflow()
+	}, time.Minute)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	var canceledErr *CanceledError
+	s.ErrorAs(env.GetWorkflowError(), &canceledErr)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_FutureGetWithTimeout() {
+	activityFn := func(ctx context.Context) (string, error) {
+		return "hello", nil
+	}
+	workflowFn := func(ctx Context) (string, error) {
+		future, settable := NewFuture(ctx)
+		Go(ctx, func(ctx Context) {
+			_ = Sleep(ctx, 2*time.Minute)
+			settable.SetValue(42)
+		})
+		var value int
+		ok1, err := future.GetWithTimeout(ctx, time.Minute, &value)
+		if err != nil {
+			return "", err
+		}
+		ok2, err := future.GetWithTimeout(ctx, time.Hour, &value)
+		if err != nil {
+			return "", err
+		}
+
+		ctx = WithActivityOptions(ctx, ActivityOptions{ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: time.Minute})
+		var greeting string
+		ok3, err := ExecuteActivity(ctx, activityFn).GetWithTimeout(ctx, time.Minute, &greeting)
+		return fmt.Sprintf("%v %v %v %v %v", ok1, ok2, value, ok3, greeting), err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFn)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("false true 42 true hello", result)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_FutureGetWithTimeoutCanceled() {
+	workflowFn := func(ctx Context) error {
+		future, _ := NewFuture(ctx)
+		_, err := future.GetWithTimeout(ctx, time.Hour, nil)
+		return err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterDelayedCallback(func() {
+		env.CancelWorkflow()
+	}, time.Minute)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	var canceledErr *CanceledError
+	s.ErrorAs(env.GetWorkflowError(), &canceledErr)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_GetDecisionTimeRemaining() {
+	// splits the work into decisions, yielding with a timer when the decision task is about to time out
+	workflowFn := func(ctx Context, items int) (int, error) {
+		decisions := 1
+		for i := 0; i < items; i++ {
+			var yield bool
+			if err := SideEffect(ctx, func(ctx Context) interface{} {
+				return GetDecisionTimeRemaining(ctx) < time.Second
+			}).Get(&yield); err != nil {
+				return 0, err
+			}
+			if yield {
+				if err := Sleep(ctx, time.Millisecond); err != nil {
+					return 0, err
+				}
+				decisions++
+			}
+		}
+		return decisions, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.ExecuteWorkflow(func(ctx Context) (time.Duration, error) {
+		return GetDecisionTimeRemaining(ctx), nil
+	})
+	var remaining time.Duration
+	s.NoError(env.GetWorkflowResult(&remaining))
+	s.Equal(time.Duration(env.impl.workflowInfo.TaskStartToCloseTimeoutSeconds)*time.Second, remaining)
+
+	env = s.NewTestWorkflowEnvironment()
+	calls := 0
+	env.SetDecisionTimeRemaining(func() time.Duration {
+		calls++
+		return time.Duration(calls%3) * time.Second // every third item is close to the timeout
+	})
+	env.ExecuteWorkflow(workflowFn, 8)
+	s.NoError(env.GetWorkflowError())
+	var decisions int
+	s.NoError(env.GetWorkflowResult(&decisions))
+	s.Equal(3, decisions)
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_Regression_ExecuteChildWorkflowWithCanceledContext() {
 	// cancelTime of:
 	// - <0 == do not cancel
@@ -3206,3 +3446,270 @@ func TestRegression_LocalActivityErrorEncoding(t *testing.T) {
 	assert.Contains(t, err.Error(), "sentinel error value", "should contain the user error text")
 	assert.NotContains(t, err.Error(), "need to be encoded", "should not contain the wrong-err-type branch message")
 }
+
+func TestPropagatedHeaders(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	testSuite.SetContextPropagators([]ContextPropagator{NewStringMapPropagator([]string{testHeader})})
+	testSuite.SetHeader(&shared.Header{Fields: map[string][]byte{testHeader: []byte("test-data")}})
+
+	activityFn := func(ctx context.Context) (string, error) {
+		return ctx.Value(contextKey(testHeader)).(string), nil
+	}
+	childWorkflowFn := func(ctx Context) (string, error) {
+		ctx = WithActivityOptions(ctx, ActivityOptions{ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: time.Minute})
+		var result string
+		err := ExecuteActivity(ctx, "headerActivity").Get(ctx, &result)
+		return result, err
+	}
+	workflowFn := func(ctx Context) error {
+		ctx = WithActivityOptions(ctx, ActivityOptions{ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: time.Minute})
+		ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: time.Minute})
+		ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{ExecutionStartToCloseTimeout: time.Minute})
+		if err := ExecuteActivity(ctx, "headerActivity").Get(ctx, nil); err != nil {
+			return err
+		}
+		if err := ExecuteLocalActivity(ctx, activityFn).Get(ctx, nil); err != nil {
+			return err
+		}
+		childCtx := WithValue(ctx, contextKey(testHeader), "test-data-for-child")
+		var childResult string
+		if err := ExecuteChildWorkflow(childCtx, "headerChild").Get(ctx, &childResult); err != nil {
+			return err
+		}
+		if childResult != "test-data-for-child" {
+			return fmt.Errorf("unexpected child result %q", childResult)
+		}
+		return nil
+	}
+
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivityWithOptions(activityFn, RegisterActivityOptions{Name: "headerActivity"})
+	env.RegisterWorkflowWithOptions(childWorkflowFn, RegisterWorkflowOptions{Name: "headerChild"})
+	env.ExecuteWorkflow(workflowFn)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	headers := env.GetPropagatedHeaders()
+	require.Len(t, headers, 4)
+	assert.Equal(t, PropagatedHeaderActivity, headers[0].Kind)
+	assert.Equal(t, PropagatedHeaderLocalActivity, headers[1].Kind)
+	assert.Equal(t, PropagatedHeader{
+		Kind:   PropagatedHeaderChildWorkflow,
+		Name:   "headerChild",
+		Fields: map[string][]byte{testHeader: []byte("test-data-for-child")},
+	}, headers[2])
+	assert.Equal(t, PropagatedHeaderActivity, headers[3].Kind)
+
+	assert.True(t, env.AssertPropagatedHeader(t, PropagatedHeaderChildWorkflow, "headerChild", testHeader, []byte("test-data-for-child")))
+
+	// every header sent to the activity must match
+	recorder := &testingTRecorder{}
+	assert.False(t, env.AssertPropagatedHeader(recorder, PropagatedHeaderActivity, "headerActivity", testHeader, []byte("test-data")))
+	assert.Len(t, recorder.errors, 1)
+
+	recorder = &testingTRecorder{}
+	assert.False(t, env.AssertPropagatedHeader(recorder, PropagatedHeaderActivity, "unknownActivity", testHeader, nil))
+	assert.Equal(t, []string{"no header was sent to activity unknownActivity"}, recorder.errors)
+}
+
+type testingTRecorder struct {
+	errors []string
+}
+
+func (r *testingTRecorder) Logf(format string, args ...interface{}) {}
+
+func (r *testingTRecorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *testingTRecorder) FailNow() {}
+
+func TestTrace(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	activityFn := func(ctx context.Context) error { return nil }
+	childWorkflowFn := func(ctx Context) error {
+		return NewTimer(ctx, time.Second).Get(ctx, nil)
+	}
+	workflowFn := func(ctx Context) error {
+		ctx = WithActivityOptions(ctx, ActivityOptions{ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: time.Minute})
+		ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{ExecutionStartToCloseTimeout: time.Minute})
+		GetVersion(ctx, "change", DefaultVersion, 2)
+		GetVersion(ctx, "change", DefaultVersion, 2)
+		if err := ExecuteActivity(ctx, "traceActivity").Get(ctx, nil); err != nil {
+			return err
+		}
+		if err := NewTimer(ctx, time.Minute).Get(ctx, nil); err != nil {
+			return err
+		}
+		GetSignalChannel(ctx, "go").Receive(ctx, nil)
+		return ExecuteChildWorkflow(ctx, "traceChild").Get(ctx, nil)
+	}
+
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivityWithOptions(activityFn, RegisterActivityOptions{Name: "traceActivity"})
+	env.RegisterWorkflowWithOptions(childWorkflowFn, RegisterWorkflowOptions{Name: "traceChild"})
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("go", nil)
+	}, time.Hour)
+	env.ExecuteWorkflow(workflowFn)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	// the timer of the child workflow is not recorded
+	assert.Equal(t, []string{
+		"version change 2",
+		"activity traceActivity",
+		"timer 1m0s",
+		"signal go",
+		"child workflow traceChild",
+	}, env.Trace())
+	assert.True(t, env.AssertTrace(t, "activity traceActivity", "signal go"))
+
+	recorder := &testingTRecorder{}
+	assert.False(t, env.AssertTrace(recorder, "signal go", "activity traceActivity"))
+	require.Len(t, recorder.errors, 1)
+	assert.Contains(t, recorder.errors[0], `trace does not contain "activity traceActivity" after ["signal go"]`)
+}
+
+func TestBreakpoints(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	workflowFn := func(ctx Context) (int, error) {
+		ticks := 0
+		if err := SetQueryHandler(ctx, "ticks", func() (int, error) { return ticks, nil }); err != nil {
+			return 0, err
+		}
+		stop := GetSignalChannel(ctx, "stop")
+		for {
+			selector := NewSelector(ctx)
+			stopped := false
+			selector.AddReceive(stop, func(c Channel, more bool) {
+				c.Receive(ctx, nil)
+				stopped = true
+			})
+			selector.AddFuture(NewTimer(ctx, time.Hour), func(f Future) {
+				ticks++
+			})
+			selector.Select(ctx)
+			if stopped || ticks == 10 {
+				return ticks, nil
+			}
+		}
+	}
+	queryTicks := func(env *TestWorkflowEnvironment) int {
+		value, err := env.QueryWorkflow("ticks")
+		require.NoError(t, err)
+		var ticks int
+		require.NoError(t, value.Get(&ticks))
+		return ticks
+	}
+
+	env := testSuite.NewTestWorkflowEnvironment()
+	start := env.Now()
+	atTime := env.SetBreakpointAt(start.Add(150 * time.Minute))
+	afterOperations := env.SetBreakpointAfterOperations(5)
+	unreached := env.SetBreakpointAt(start.Add(24 * time.Hour))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		env.ExecuteWorkflow(workflowFn)
+	}()
+
+	require.True(t, atTime.Wait())
+	assert.Equal(t, 150*time.Minute, env.Now().Sub(start))
+	assert.Equal(t, 2, queryTicks(env))
+	atTime.Resume()
+
+	require.True(t, afterOperations.Wait())
+	assert.Equal(t, 4, queryTicks(env))
+	env.SignalWorkflow("stop", nil)
+	afterOperations.Resume()
+
+	assert.False(t, unreached.Wait())
+	<-done
+	require.True(t, env.IsWorkflowCompleted())
+	var ticks int
+	require.NoError(t, env.GetWorkflowResult(&ticks))
+	assert.Equal(t, 4, ticks)
+	assert.Equal(t, "signal stop", env.Trace()[len(env.Trace())-1])
+}
+
+func TestQueryHandlerChecks(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	var queries, queriesWithArgs int
+	newWorkflowFn := func(updateAcrossSleep bool) func(ctx Context) (int, error) {
+		return func(ctx Context) (int, error) {
+			var items []int
+			var size int
+			if err := SetQueryHandler(ctx, "size", func() (int, error) {
+				queries++
+				if len(items) != size {
+					return 0, errors.New("size does not match the items")
+				}
+				return size, nil
+			}); err != nil {
+				return 0, err
+			}
+			if err := SetQueryHandler(ctx, "plus", func(n int) (int, error) {
+				queriesWithArgs++
+				return size + n, nil
+			}); err != nil {
+				return 0, err
+			}
+			for i := 0; i < 3; i++ {
+				items = append(items, i)
+				if updateAcrossSleep {
+					if err := Sleep(ctx, time.Minute); err != nil {
+						return 0, err
+					}
+					size++
+				} else {
+					size++
+					if err := Sleep(ctx, time.Minute); err != nil {
+						return 0, err
+					}
+				}
+			}
+			return size, nil
+		}
+	}
+
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.ExecuteWorkflow(newWorkflowFn(true))
+	require.NoError(t, env.GetWorkflowError())
+	assert.Zero(t, queries)
+
+	env = testSuite.NewTestWorkflowEnvironment().SetQueryHandlerChecks(true)
+	env.ExecuteWorkflow(newWorkflowFn(false))
+	require.NoError(t, env.GetWorkflowError())
+	// the query handlers are executed after each decision task but the last one, which completes the workflow
+	assert.Equal(t, 3, queries)
+	assert.Zero(t, queriesWithArgs)
+
+	env = testSuite.NewTestWorkflowEnvironment().SetQueryHandlerChecks(true)
+	env.ExecuteWorkflow(newWorkflowFn(true))
+	err := env.GetWorkflowError()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "query size failed after decision task: size does not match the items")
+	assert.Contains(t, err.Error(), "TestQueryHandlerChecks")
+
+	// the state between the runs of two coroutines of a decision task cannot be queried
+	env = testSuite.NewTestWorkflowEnvironment().SetQueryHandlerChecks(true)
+	env.ExecuteWorkflow(func(ctx Context) error {
+		var items []int
+		var size int
+		if err := SetQueryHandler(ctx, "size", func() (int, error) {
+			if len(items) != size {
+				return 0, errors.New("size does not match the items")
+			}
+			return size, nil
+		}); err != nil {
+			return err
+		}
+		items = append(items, 1)
+		Go(ctx, func(ctx Context) {
+			size++
+		})
+		return Sleep(ctx, time.Minute)
+	})
+	require.NoError(t, env.GetWorkflowError())
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+)
+
+type (
+	// WorkflowEventType is the kind of lifecycle event delivered by Client.WatchWorkflow.
+	WorkflowEventType int
+
+	// WatchWorkflowOptions configure Client.WatchWorkflow.
+	WatchWorkflowOptions struct {
+		// RunID - The run to watch.
+		// Optional: defaulted to the current run of the workflow ID.
+		RunID string
+
+		// ResumeToken - The WorkflowEvent.ResumeToken of the last event which was processed by a previous watch.
+		// Only events which happened after that event are delivered. RunID is ignored when ResumeToken is set.
+		// Optional: by default all events are delivered, starting from WorkflowEventStarted.
+		ResumeToken []byte
+
+		// BufferSize - The capacity of the returned channel.
+		// Optional: defaulted to 0, i.e. unbuffered.
+		BufferSize int
+	}
+
+	// WorkflowEvent is a lifecycle event of a watched workflow execution.
+	WorkflowEvent struct {
+		// Type is the kind of the event. It is not set when Err is not nil.
+		Type WorkflowEventType
+		// WorkflowID and RunID identify the watched execution.
+		WorkflowID string
+		RunID      string
+		// HistoryEvent is the history event this event was created from.
+		HistoryEvent *s.HistoryEvent
+		// ResumeToken can be passed to WatchWorkflowOptions.ResumeToken to continue watching after this event.
+		ResumeToken []byte
+		// Err is set when the watch failed, this is always the last event sent before the channel is closed.
+		Err error
+	}
+
+	// watchResumeState is the content of WorkflowEvent.ResumeToken
+	watchResumeState struct {
+		RunID         string `json:"runID"`
+		NextPageToken []byte `json:"nextPageToken,omitempty"`
+		LastEventID   int64  `json:"lastEventID"`
+	}
+)
+
+const (
+	// WorkflowEventStarted is sent when the workflow execution is started.
+	WorkflowEventStarted WorkflowEventType = iota
+	// WorkflowEventDecisionCompleted is sent when a decision task of the workflow is completed.
+	WorkflowEventDecisionCompleted
+	// WorkflowEventActivityScheduled is sent when an activity is scheduled.
+	WorkflowEventActivityScheduled
+	// WorkflowEventActivityCompleted is sent when an activity is closed, i.e. it has completed, failed, timed out
+	// or was canceled. Check the type of the HistoryEvent to tell these apart.
+	WorkflowEventActivityCompleted
+	// WorkflowEventSignaled is sent when the workflow execution receives a signal.
+	WorkflowEventSignaled
+	// WorkflowEventClosed is sent when the workflow execution is closed, no more events follow it.
+	// Check the type of the HistoryEvent for how the execution was closed.
+	WorkflowEventClosed
+)
+
+// String returns the name of the event type.
+func (t WorkflowEventType) String() string {
+	switch t {
+	case WorkflowEventStarted:
+		return "Started"
+	case WorkflowEventDecisionCompleted:
+		return "DecisionCompleted"
+	case WorkflowEventActivityScheduled:
+		return "ActivityScheduled"
+	case WorkflowEventActivityCompleted:
+		return "ActivityCompleted"
+	case WorkflowEventSignaled:
+		return "Signaled"
+	case WorkflowEventClosed:
+		return "Closed"
+	default:
+		return fmt.Sprintf("WorkflowEventType(%d)", int(t))
+	}
+}
+
+// WatchWorkflow returns a channel of the lifecycle events of a workflow execution.
+func (wc *workflowClient) WatchWorkflow(ctx context.Context, workflowID string, options WatchWorkflowOptions) (<-chan WorkflowEvent, error) {
+	state := watchResumeState{RunID: options.RunID}
+	if len(options.ResumeToken) != 0 {
+		if err := json.Unmarshal(options.ResumeToken, &state); err != nil {
+			return nil, fmt.Errorf("invalid resume token: %w", err)
+		}
+	}
+	if state.RunID == "" {
+		// pin the current run, so the resume tokens of the events refer to the run they were read from
+		response, err := wc.DescribeWorkflowExecution(ctx, workflowID, "")
+		if err != nil {
+			return nil, err
+		}
+		state.RunID = response.GetWorkflowExecutionInfo().GetExecution().GetRunId()
+	}
+
+	events := make(chan WorkflowEvent, options.BufferSize)
+	go wc.watchWorkflow(ctx, workflowID, state, events)
+	return events, nil
+}
+
+func (wc *workflowClient) watchWorkflow(ctx context.Context, workflowID string, state watchResumeState, events chan<- WorkflowEvent) {
+	defer close(events)
+
+	send := func(event WorkflowEvent) bool {
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	paginate := wc.getWorkflowHistoryPaginator(ctx, workflowID, state.RunID, true, s.HistoryEventFilterTypeAllEvent)
+	for {
+		response, err := paginate(state.NextPageToken)
+		if err != nil {
+			if ctx.Err() == nil {
+				send(WorkflowEvent{WorkflowID: workflowID, RunID: state.RunID, Err: err})
+			}
+			return
+		}
+
+		for _, historyEvent := range response.GetHistory().GetEvents() {
+			if historyEvent.GetEventId() <= state.LastEventID {
+				// already delivered before the watch was resumed
+				continue
+			}
+			state.LastEventID = historyEvent.GetEventId()
+
+			eventType, ok := getWorkflowEventType(historyEvent.GetEventType())
+			if !ok {
+				continue
+			}
+			resumeToken, err := json.Marshal(state)
+			if err != nil {
+				send(WorkflowEvent{WorkflowID: workflowID, RunID: state.RunID, Err: err})
+				return
+			}
+			if !send(WorkflowEvent{
+				Type:         eventType,
+				WorkflowID:   workflowID,
+				RunID:        state.RunID,
+				HistoryEvent: historyEvent,
+				ResumeToken:  resumeToken,
+			}) {
+				return
+			}
+		}
+
+		if len(response.NextPageToken) == 0 {
+			// the execution is closed and all of its events were read
+			return
+		}
+		state.NextPageToken = response.NextPageToken
+	}
+}
+
+func getWorkflowEventType(eventType s.EventType) (WorkflowEventType, bool) {
+	switch eventType {
+	case s.EventTypeWorkflowExecutionStarted:
+		return WorkflowEventStarted, true
+	case s.EventTypeDecisionTaskCompleted:
+		return WorkflowEventDecisionCompleted, true
+	case s.EventTypeActivityTaskScheduled:
+		return WorkflowEventActivityScheduled, true
+	case s.EventTypeActivityTaskCompleted,
+		s.EventTypeActivityTaskFailed,
+		s.EventTypeActivityTaskTimedOut,
+		s.EventTypeActivityTaskCanceled:
+		return WorkflowEventActivityCompleted, true
+	case s.EventTypeWorkflowExecutionSignaled:
+		return WorkflowEventSignaled, true
+	case s.EventTypeWorkflowExecutionCompleted,
+		s.EventTypeWorkflowExecutionFailed,
+		s.EventTypeWorkflowExecutionTimedOut,
+		s.EventTypeWorkflowExecutionCanceled,
+		s.EventTypeWorkflowExecutionTerminated,
+		s.EventTypeWorkflowExecutionContinuedAsNew:
+		return WorkflowEventClosed, true
+	default:
+		return 0, false
+	}
+}
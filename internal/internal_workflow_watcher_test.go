@@ -0,0 +1,125 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func (s *workflowClientTestSuite) TestWatchWorkflow() {
+	newEvent := func(eventID int64, eventType shared.EventType) *shared.HistoryEvent {
+		return &shared.HistoryEvent{EventId: common.Int64Ptr(eventID), EventType: eventType.Ptr()}
+	}
+	page1 := &shared.GetWorkflowExecutionHistoryResponse{
+		History: &shared.History{Events: []*shared.HistoryEvent{
+			newEvent(1, shared.EventTypeWorkflowExecutionStarted),
+			newEvent(2, shared.EventTypeDecisionTaskScheduled),
+			newEvent(3, shared.EventTypeDecisionTaskStarted),
+			newEvent(4, shared.EventTypeDecisionTaskCompleted),
+			newEvent(5, shared.EventTypeActivityTaskScheduled),
+		}},
+		NextPageToken: []byte("page2"),
+	}
+	page2 := &shared.GetWorkflowExecutionHistoryResponse{
+		History: &shared.History{Events: []*shared.HistoryEvent{
+			newEvent(6, shared.EventTypeWorkflowExecutionSignaled),
+			newEvent(7, shared.EventTypeActivityTaskStarted),
+			newEvent(8, shared.EventTypeActivityTaskFailed),
+			newEvent(9, shared.EventTypeWorkflowExecutionCompleted),
+		}},
+	}
+	describeResp := &shared.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &shared.WorkflowExecutionInfo{
+			Execution: &shared.WorkflowExecution{WorkflowId: common.StringPtr(workflowID), RunId: common.StringPtr(runID)},
+		},
+	}
+	expectPage := func(token []byte, response *shared.GetWorkflowExecutionHistoryResponse) {
+		s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+			Do(func(_ interface{}, req *shared.GetWorkflowExecutionHistoryRequest, _ ...interface{}) {
+				s.Equal(runID, req.GetExecution().GetRunId())
+				s.Equal(token, req.NextPageToken)
+				s.True(req.GetWaitForNewEvent())
+			}).Return(response, nil)
+	}
+
+	var events []WorkflowEvent
+	s.Run("all events", func() {
+		s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(describeResp, nil)
+		expectPage(nil, page1)
+		expectPage([]byte("page2"), page2)
+
+		ch, err := s.client.WatchWorkflow(context.Background(), workflowID, WatchWorkflowOptions{})
+		s.NoError(err)
+		for event := range ch {
+			s.NoError(event.Err)
+			s.Equal(runID, event.RunID)
+			events = append(events, event)
+		}
+
+		var types []WorkflowEventType
+		for _, event := range events {
+			types = append(types, event.Type)
+		}
+		s.Equal([]WorkflowEventType{
+			WorkflowEventStarted,
+			WorkflowEventDecisionCompleted,
+			WorkflowEventActivityScheduled,
+			WorkflowEventSignaled,
+			WorkflowEventActivityCompleted,
+			WorkflowEventClosed,
+		}, types)
+	})
+	s.Run("resume", func() {
+		// resume after the DecisionCompleted event, which was read from the first page
+		expectPage(nil, page1)
+		expectPage([]byte("page2"), page2)
+
+		ch, err := s.client.WatchWorkflow(context.Background(), workflowID, WatchWorkflowOptions{ResumeToken: events[1].ResumeToken})
+		s.NoError(err)
+		var eventIDs []int64
+		for event := range ch {
+			s.NoError(event.Err)
+			eventIDs = append(eventIDs, event.HistoryEvent.GetEventId())
+		}
+		s.Equal([]int64{5, 6, 8, 9}, eventIDs)
+	})
+	s.Run("error", func() {
+		s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, &shared.EntityNotExistsError{})
+
+		ch, err := s.client.WatchWorkflow(context.Background(), workflowID, WatchWorkflowOptions{RunID: runID})
+		s.NoError(err)
+		event, ok := <-ch
+		s.True(ok)
+		s.IsType(&shared.EntityNotExistsError{}, event.Err)
+		_, ok = <-ch
+		s.False(ok)
+	})
+	s.Run("invalid resume token", func() {
+		_, err := s.client.WatchWorkflow(context.Background(), workflowID, WatchWorkflowOptions{ResumeToken: []byte("bad")})
+		s.Error(err)
+	})
+}
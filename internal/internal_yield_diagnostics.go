@@ -0,0 +1,81 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+type (
+	// YieldDiagnostics tells why the coroutines of a workflow blocked during its last decision task. It is returned by
+	// the QueryTypeYieldDiagnostics query of workers with WorkerOptions.EnableYieldDiagnostics. A coroutine yields
+	// every time it blocks, so a high count for a reason points at a channel, selector or Await condition which keeps
+	// waking the workflow up without letting it make progress.
+	YieldDiagnostics struct {
+		// Yields is the number of yields of all coroutines during the decision task.
+		Yields int
+		// Coroutines are the yields of each coroutine which yielded during the decision task, sorted by name.
+		Coroutines []CoroutineYields
+	}
+
+	// CoroutineYields counts the yields of one coroutine of a workflow, see YieldDiagnostics.
+	CoroutineYields struct {
+		// Coroutine is the name of the coroutine, as given to workflow.GoNamed, or its sequence number.
+		Coroutine string
+		// Yields is the number of yields of the coroutine.
+		Yields int
+		// Reasons counts the yields by reason, such as "blocked on chan-5.Receive" or "Await".
+		Reasons map[string]int
+	}
+
+	// yieldRecorder records the yields of the coroutines of a workflow during a decision task.
+	yieldRecorder struct {
+		coroutines map[string]map[string]int
+	}
+)
+
+func newYieldRecorder() *yieldRecorder {
+	return &yieldRecorder{coroutines: make(map[string]map[string]int)}
+}
+
+func (r *yieldRecorder) record(coroutine, reason string) {
+	reasons, ok := r.coroutines[coroutine]
+	if !ok {
+		reasons = make(map[string]int)
+		r.coroutines[coroutine] = reasons
+	}
+	reasons[reason]++
+}
+
+// reset is called at the beginning of each decision task.
+func (r *yieldRecorder) reset() {
+	r.coroutines = make(map[string]map[string]int)
+}
+
+func (r *yieldRecorder) diagnostics() YieldDiagnostics {
+	var result YieldDiagnostics
+	for _, coroutine := range SortedKeys(r.coroutines) {
+		yields := CoroutineYields{Coroutine: coroutine, Reasons: make(map[string]int)}
+		for reason, count := range r.coroutines[coroutine] {
+			yields.Reasons[reason] = count
+			yields.Yields += count
+		}
+		result.Yields += yields.Yields
+		result.Coroutines = append(result.Coroutines, yields)
+	}
+	return result
+}
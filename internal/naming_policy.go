@@ -0,0 +1,118 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type (
+	// NamingPolicy constrains a name, e.g. a workflow ID or a signal name.
+	// The zero value accepts any name.
+	NamingPolicy struct {
+		// Pattern the name must match. Anchor it with ^ and $ to constrain the whole name.
+		// Optional: nil accepts any name.
+		Pattern *regexp.Regexp
+
+		// MaxLength is the maximum length of the name in bytes.
+		// Optional: 0 means no limit.
+		MaxLength int
+	}
+
+	// NamingPolicies are checked by the client and the worker before a name is sent to the server or registered,
+	// so names which do not follow the naming conventions of an organization are rejected early with a
+	// NamingPolicyError instead of being stored in the history of a workflow.
+	NamingPolicies struct {
+		// WorkflowID is checked by StartWorkflow, ExecuteWorkflow and SignalWithStartWorkflow for IDs set in
+		// StartWorkflowOptions. Generated IDs are not checked.
+		WorkflowID NamingPolicy
+
+		// SignalName is checked by SignalWorkflow and SignalWithStartWorkflow.
+		SignalName NamingPolicy
+
+		// QueryType is checked by QueryWorkflow, QueryWorkflowWithOptions and SetQueryHandler.
+		// Built-in query types, which start with "__", are not checked.
+		QueryType NamingPolicy
+	}
+
+	// NamingPolicyError is returned when a name does not follow a NamingPolicy.
+	NamingPolicyError struct {
+		// Kind of the name, e.g. "workflow ID".
+		Kind string
+		// Value is the rejected name.
+		Value string
+		// Problem describes which rule the name breaks.
+		Problem string
+	}
+)
+
+const (
+	namingKindWorkflowID = "workflow ID"
+	namingKindSignalName = "signal name"
+	namingKindQueryType  = "query type"
+)
+
+func (e *NamingPolicyError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.Kind, e.Value, e.Problem)
+}
+
+// validate returns a NamingPolicyError if name does not follow the policy.
+func (p NamingPolicy) validate(kind, name string) error {
+	if p.MaxLength > 0 && len(name) > p.MaxLength {
+		return &NamingPolicyError{
+			Kind:    kind,
+			Value:   name,
+			Problem: fmt.Sprintf("length %d exceeds the maximum of %d bytes", len(name), p.MaxLength),
+		}
+	}
+	if p.Pattern != nil && !p.Pattern.MatchString(name) {
+		return &NamingPolicyError{
+			Kind:    kind,
+			Value:   name,
+			Problem: fmt.Sprintf("does not match pattern %q", p.Pattern.String()),
+		}
+	}
+	return nil
+}
+
+func (p NamingPolicies) validateWorkflowID(workflowID string) error {
+	return p.WorkflowID.validate(namingKindWorkflowID, workflowID)
+}
+
+func (p NamingPolicies) validateSignalName(signalName string) error {
+	return p.SignalName.validate(namingKindSignalName, signalName)
+}
+
+func (p NamingPolicies) validateQueryType(queryType string) error {
+	if strings.HasPrefix(queryType, "__") {
+		return nil
+	}
+	return p.QueryType.validate(namingKindQueryType, queryType)
+}
+
+func getNamingPolicies(options *ClientOptions) NamingPolicies {
+	if options == nil {
+		return NamingPolicies{}
+	}
+	return options.NamingPolicies
+}
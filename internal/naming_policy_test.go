@@ -0,0 +1,83 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamingPolicy(t *testing.T) {
+	policy := NamingPolicy{Pattern: regexp.MustCompile(`^[a-z][a-z0-9-]*$`), MaxLength: 10}
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "valid", value: "order-42"},
+		{name: "too long", value: "order-12345", expected: `invalid workflow ID "order-12345": length 11 exceeds the maximum of 10 bytes`},
+		{name: "pattern mismatch", value: "Order-42", expected: `invalid workflow ID "Order-42": does not match pattern "^[a-z][a-z0-9-]*$"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.validate(namingKindWorkflowID, tt.value)
+			if tt.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expected)
+			assert.IsType(t, &NamingPolicyError{}, err)
+		})
+	}
+
+	assert.NoError(t, NamingPolicy{}.validate(namingKindSignalName, strings.Repeat("x", 1000)))
+}
+
+func TestNamingPolicies_BuiltinQueryTypesAreExempt(t *testing.T) {
+	policies := NamingPolicies{QueryType: NamingPolicy{Pattern: regexp.MustCompile(`^[a-z]+$`)}}
+	assert.NoError(t, policies.validateQueryType(QueryTypeStackTrace))
+	assert.NoError(t, policies.validateQueryType("state"))
+	assert.Error(t, policies.validateQueryType("State"))
+}
+
+func TestSetQueryHandler_NamingPolicy(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(WorkerOptions{
+		NamingPolicies: NamingPolicies{QueryType: NamingPolicy{Pattern: regexp.MustCompile(`^[a-z_]+$`)}},
+	})
+
+	workflowFn := func(ctx Context) error {
+		if err := SetQueryHandler(ctx, "current_state", func() (string, error) { return "", nil }); err != nil {
+			return err
+		}
+		return SetQueryHandler(ctx, "CurrentState", func() (string, error) { return "", nil })
+	}
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	err := env.GetWorkflowError()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid query type "CurrentState"`)
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type (
+	// OptionsValidationError is returned by the Validate methods of ActivityOptions, LocalActivityOptions,
+	// ChildWorkflowOptions and StartWorkflowOptions. It lists every invalid field, not only the first one.
+	OptionsValidationError struct {
+		// Options is the name of the validated options type, like "ActivityOptions".
+		Options  string
+		Problems []OptionsProblem
+	}
+
+	// OptionsProblem describes a single invalid field of an options struct.
+	OptionsProblem struct {
+		// Field is the name of the invalid field, like "StartToCloseTimeout" or "RetryPolicy.InitialInterval".
+		Field string
+		// Problem describes what is wrong with the field.
+		Problem string
+		// Suggestion describes how to fix the field.
+		Suggestion string
+	}
+
+	optionsValidator struct {
+		err OptionsValidationError
+	}
+)
+
+// Error from error interface
+func (e *OptionsValidationError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		problems = append(problems, p.String())
+	}
+	return fmt.Sprintf("invalid %v: %v", e.Options, strings.Join(problems, "; "))
+}
+
+// String returns the problem and the suggestion in a single sentence.
+func (p OptionsProblem) String() string {
+	if p.Suggestion == "" {
+		return fmt.Sprintf("%v %v", p.Field, p.Problem)
+	}
+	return fmt.Sprintf("%v %v, %v", p.Field, p.Problem, p.Suggestion)
+}
+
+// Validate checks the options the same way ExecuteActivity does, and returns an *OptionsValidationError listing all
+// invalid fields.
+func (o ActivityOptions) Validate() error {
+	v := newOptionsValidator("ActivityOptions")
+	v.activityTimeouts(o.ScheduleToStartTimeout, o.StartToCloseTimeout, o.ScheduleToCloseTimeout, o.HeartbeatTimeout)
+	v.retryPolicy(o.RetryPolicy)
+	return v.result()
+}
+
+// Validate checks the options the same way ExecuteLocalActivity does, and returns an *OptionsValidationError listing
+// all invalid fields.
+func (o LocalActivityOptions) Validate() error {
+	v := newOptionsValidator("LocalActivityOptions")
+	v.localActivityTimeouts(o.ScheduleToCloseTimeout)
+	return v.result()
+}
+
+// Validate checks the options the same way ExecuteChildWorkflow does, and returns an *OptionsValidationError listing
+// all invalid fields.
+func (o ChildWorkflowOptions) Validate() error {
+	v := newOptionsValidator("ChildWorkflowOptions")
+	v.workflowTimeouts(o.ExecutionStartToCloseTimeout, o.TaskStartToCloseTimeout, "TaskStartToCloseTimeout")
+	v.retryPolicy(o.RetryPolicy)
+	v.cronSchedule(o.CronSchedule)
+	return v.result()
+}
+
+// Validate checks the options the same way Client.StartWorkflow does, and returns an *OptionsValidationError listing
+// all invalid fields.
+func (o StartWorkflowOptions) Validate() error {
+	v := newOptionsValidator("StartWorkflowOptions")
+	v.startWorkflowOptions(o)
+	v.retryPolicy(o.RetryPolicy)
+	v.cronSchedule(o.CronSchedule)
+	return v.result()
+}
+
+func newOptionsValidator(options string) *optionsValidator {
+	return &optionsValidator{err: OptionsValidationError{Options: options}}
+}
+
+func (v *optionsValidator) add(field, problem, suggestion string) {
+	v.err.Problems = append(v.err.Problems, OptionsProblem{Field: field, Problem: problem, Suggestion: suggestion})
+}
+
+func (v *optionsValidator) result() error {
+	if len(v.err.Problems) == 0 {
+		return nil
+	}
+	err := v.err
+	return &err
+}
+
+func (v *optionsValidator) positive(field string, d time.Duration, suggestion string) {
+	if d == 0 {
+		v.add(field, "is not set", suggestion)
+	} else if d < 0 {
+		v.add(field, "is negative", suggestion)
+	}
+}
+
+func (v *optionsValidator) notNegative(field string, d time.Duration, suggestion string) {
+	if d < 0 {
+		v.add(field, "is negative", suggestion)
+	}
+}
+
+func (v *optionsValidator) activityTimeouts(scheduleToStart, startToClose, scheduleToClose, heartbeat time.Duration) {
+	v.positive("ScheduleToStartTimeout", scheduleToStart, "set it to the maximum time the activity may wait in the task list for a worker")
+	v.positive("StartToCloseTimeout", startToClose, "set it to the maximum duration of a single activity attempt")
+	v.notNegative("ScheduleToCloseTimeout", scheduleToClose, "leave it unset to default to ScheduleToStartTimeout + StartToCloseTimeout")
+	v.notNegative("HeartbeatTimeout", heartbeat, "leave it unset if the activity does not heartbeat")
+}
+
+func (v *optionsValidator) localActivityTimeouts(scheduleToClose time.Duration) {
+	v.positive("ScheduleToCloseTimeout", scheduleToClose, "set it to the maximum duration of the local activity, including retries")
+}
+
+func (v *optionsValidator) workflowTimeouts(executionTimeout, decisionTimeout time.Duration, decisionTimeoutField string) {
+	v.positive("ExecutionStartToCloseTimeout", executionTimeout, "set it to the maximum duration of the workflow")
+	v.notNegative(decisionTimeoutField, decisionTimeout, "leave it unset to use the default of 10 seconds")
+}
+
+func (v *optionsValidator) startWorkflowOptions(o StartWorkflowOptions) {
+	if o.TaskList == "" {
+		v.add("TaskList", "is not set", "set it to the task list polled by the workers of the workflow")
+	}
+	v.workflowTimeouts(o.ExecutionStartToCloseTimeout, o.DecisionTaskStartToCloseTimeout, "DecisionTaskStartToCloseTimeout")
+	v.notNegative("DelayStart", o.DelayStart, "leave it unset to start the workflow immediately")
+	v.notNegative("JitterStart", o.JitterStart, "leave it unset to start the workflow without jitter")
+	if !o.FirstRunAt.IsZero() && o.FirstRunAt.UnixNano() < 0 {
+		v.add("FirstRunAt", "is before the Unix epoch", "leave it unset to run the workflow on the first cron schedule")
+	}
+}
+
+// retryPolicy reports the first problem of p found by validateRetryPolicy, which validates the retry policies of the
+// requests.
+func (v *optionsValidator) retryPolicy(p *RetryPolicy) {
+	if err := validateRetryPolicy(convertRetryPolicy(p)); err != nil {
+		v.add("RetryPolicy", fmt.Sprintf("is invalid (%v)", err), "see RetryPolicy for the valid values of its fields")
+	}
+}
+
+func (v *optionsValidator) cronSchedule(cronSchedule string) {
+	if err := validateCronSchedule(cronSchedule); err != nil {
+		v.add("CronSchedule", fmt.Sprintf("is invalid (%v)", err), "use the standard cron format, like \"0 * * * *\"")
+	}
+}
+
+func secondsToDuration(seconds int32) time.Duration {
+	return time.Duration(seconds) * time.Second
+}
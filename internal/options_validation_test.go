@@ -0,0 +1,106 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityOptionsValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		o := ActivityOptions{ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: time.Minute}
+		assert.NoError(t, o.Validate())
+	})
+	t.Run("lists all problems", func(t *testing.T) {
+		o := ActivityOptions{
+			HeartbeatTimeout: -time.Second,
+			RetryPolicy:      &RetryPolicy{BackoffCoefficient: 0.5},
+		}
+		err := o.Validate()
+		var validationErr *OptionsValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, "ActivityOptions", validationErr.Options)
+
+		var fields []string
+		for _, p := range validationErr.Problems {
+			fields = append(fields, p.Field)
+			assert.NotEmpty(t, p.Suggestion)
+		}
+		assert.Equal(t, []string{
+			"ScheduleToStartTimeout",
+			"StartToCloseTimeout",
+			"HeartbeatTimeout",
+			"RetryPolicy",
+		}, fields)
+		assert.Contains(t, err.Error(), "invalid ActivityOptions: ScheduleToStartTimeout is not set, ")
+		assert.Contains(t, err.Error(), "; HeartbeatTimeout is negative, ")
+		assert.Contains(t, err.Error(), "; RetryPolicy is invalid (missing or negative InitialIntervalInSeconds on retry policy), ")
+	})
+	t.Run("retry policy", func(t *testing.T) {
+		o := ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			RetryPolicy:            &RetryPolicy{InitialInterval: time.Second, BackoffCoefficient: 0.5, MaximumAttempts: 3},
+		}
+		assert.EqualError(t, o.Validate(), "invalid ActivityOptions: RetryPolicy is invalid "+
+			"(BackoffCoefficient on retry policy cannot be less than 1.0), see RetryPolicy for the valid values of its fields")
+		o.RetryPolicy.BackoffCoefficient = 0
+		assert.NoError(t, o.Validate())
+	})
+}
+
+func TestLocalActivityOptionsValidate(t *testing.T) {
+	assert.NoError(t, LocalActivityOptions{ScheduleToCloseTimeout: time.Second}.Validate())
+	assert.EqualError(t, LocalActivityOptions{ScheduleToCloseTimeout: -time.Second}.Validate(),
+		"invalid LocalActivityOptions: ScheduleToCloseTimeout is negative, set it to the maximum duration of the local activity, including retries")
+}
+
+func TestChildWorkflowOptionsValidate(t *testing.T) {
+	assert.NoError(t, ChildWorkflowOptions{ExecutionStartToCloseTimeout: time.Minute}.Validate())
+
+	err := ChildWorkflowOptions{TaskStartToCloseTimeout: -time.Second, CronSchedule: "not a cron"}.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ExecutionStartToCloseTimeout is not set")
+	assert.Contains(t, err.Error(), "TaskStartToCloseTimeout is negative")
+	assert.Contains(t, err.Error(), "CronSchedule is invalid")
+}
+
+func TestStartWorkflowOptionsValidate(t *testing.T) {
+	valid := StartWorkflowOptions{TaskList: "tl", ExecutionStartToCloseTimeout: time.Minute}
+	assert.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.TaskList = ""
+	invalid.DelayStart = -time.Second
+	invalid.FirstRunAt = time.Unix(-1, 0)
+	err := invalid.Validate()
+	var validationErr *OptionsValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Problems, 3)
+	assert.Equal(t, "TaskList", validationErr.Problems[0].Field)
+	assert.Equal(t, "DelayStart", validationErr.Problems[1].Field)
+	assert.Equal(t, "FirstRunAt", validationErr.Problems[2].Field)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+type (
+	// PayloadRedactor redacts payloads, and values which may contain them like panic values and error messages,
+	// before they are written to worker logs or returned in query errors. See WorkerOptions.PayloadRedactor.
+	// Redact is called concurrently, so it must be safe for concurrent use.
+	PayloadRedactor interface {
+		Redact(payload string) string
+	}
+
+	truncatingPayloadRedactor struct {
+		maxLength int
+	}
+)
+
+// NewTruncatingPayloadRedactor returns a PayloadRedactor keeping at most the first maxLength bytes of a payload,
+// followed by the length and a SHA-256 digest of the whole payload, so equal payloads can still be correlated
+// across log entries. A maxLength of 0 keeps nothing of the payload.
+func NewTruncatingPayloadRedactor(maxLength int) PayloadRedactor {
+	return &truncatingPayloadRedactor{maxLength: maxLength}
+}
+
+func (r *truncatingPayloadRedactor) Redact(payload string) string {
+	prefix := payload
+	if len(prefix) > r.maxLength {
+		prefix = prefix[:r.maxLength]
+		// do not cut a multi-byte character in half
+		for len(prefix) > 0 && !utf8.RuneStart(payload[len(prefix)]) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	sum := sha256.Sum256([]byte(payload))
+	return fmt.Sprintf("%s[redacted len=%d sha256=%s]", prefix, len(payload), hex.EncodeToString(sum[:8]))
+}
+
+// redactPayload applies the redactor to payload, if there is one.
+func redactPayload(redactor PayloadRedactor, payload string) string {
+	if redactor == nil {
+		return payload
+	}
+	return redactor.Redact(payload)
+}
+
+// redactError returns an error with the message of err passed through the redactor, if there is one.
+func redactError(redactor PayloadRedactor, err error) error {
+	if redactor == nil || err == nil {
+		return err
+	}
+	return errors.New(redactor.Redact(err.Error()))
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTruncatingPayloadRedactor(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxLength int
+		payload   string
+		expected  string
+	}{
+		{
+			name:      "truncated",
+			maxLength: 4,
+			payload:   `{"ssn":"123-45-6789"}`,
+			expected:  `{"ss[redacted len=21 sha256=`,
+		},
+		{
+			name:      "shorter than max length",
+			maxLength: 64,
+			payload:   "abc",
+			expected:  "abc[redacted len=3 sha256=",
+		},
+		{
+			name:      "nothing kept",
+			maxLength: 0,
+			payload:   "secret",
+			expected:  "[redacted len=6 sha256=",
+		},
+		{
+			name:      "multi-byte character is not split",
+			maxLength: 2,
+			payload:   "aé",
+			expected:  "a[redacted len=3 sha256=",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redacted := NewTruncatingPayloadRedactor(tt.maxLength).Redact(tt.payload)
+			assert.Regexp(t, "^"+regexp.QuoteMeta(tt.expected)+"[0-9a-f]{16}]$", redacted)
+		})
+	}
+
+	redactor := NewTruncatingPayloadRedactor(0)
+	assert.Equal(t, redactor.Redact("same"), redactor.Redact("same"))
+	assert.NotEqual(t, redactor.Redact("same"), redactor.Redact("other"))
+}
+
+func TestCorruptSignalLogIsRedacted(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	testSuite := &WorkflowTestSuite{}
+	testSuite.SetLogger(zap.New(core))
+	env := testSuite.NewTestWorkflowEnvironment()
+	redactor := NewTruncatingPayloadRedactor(0)
+	env.SetWorkerOptions(WorkerOptions{PayloadRedactor: redactor})
+
+	workflowFn := func(ctx Context) (int, error) {
+		var value int
+		GetSignalChannel(ctx, "number").Receive(ctx, &value)
+		return value, nil
+	}
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("number", "not a number")
+		env.SignalWorkflow("number", 1)
+	}, 0)
+	env.ExecuteWorkflow(workflowFn)
+	assert.NoError(t, env.GetWorkflowError())
+
+	entries := logs.FilterMessageSnippet("Corrupt signal received").All()
+	if assert.Len(t, entries, 1) {
+		assert.Regexp(t, `^\[redacted len=\d+ sha256=[0-9a-f]{16}]$`, entries[0].ContextMap()["error"])
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	err := errors.New("secret")
+	assert.Equal(t, err, redactError(nil, err))
+	assert.NoError(t, redactError(NewTruncatingPayloadRedactor(0), nil))
+	assert.EqualError(t, redactError(NewTruncatingPayloadRedactor(0), err), NewTruncatingPayloadRedactor(0).Redact("secret"))
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/zap"
+)
+
+type (
+	// PriorityTaskLists emulates activity priorities, which the Cadence server does not support, with a task list per
+	// priority tier. Workflows route an activity to the task list of its priority with WithActivityPriority, and
+	// workers poll all the task lists, with more pollers for the higher tiers, see
+	// WorkerOptions.ActivityPriorityTaskLists. While the tiers have backlogs, the activities of a tier are then
+	// started at a rate proportional to its weight.
+	PriorityTaskLists struct {
+		// Tiers are the priority tiers, from the highest priority to the lowest.
+		Tiers []PriorityTier
+	}
+
+	// PriorityTier is a priority tier of PriorityTaskLists.
+	PriorityTier struct {
+		// TaskList is the task list of the activities of the tier. Required.
+		TaskList string
+
+		// Weight is the number of activity pollers a worker runs for the tier. Required.
+		Weight int
+	}
+)
+
+// TaskList returns the task list of the given priority, which is the index of its tier: 0 is the highest priority.
+// Priorities beyond the lowest tier map to the lowest tier, negative priorities to the highest one.
+func (p PriorityTaskLists) TaskList(priority int) string {
+	if len(p.Tiers) == 0 {
+		return ""
+	}
+	if priority < 0 {
+		priority = 0
+	} else if priority >= len(p.Tiers) {
+		priority = len(p.Tiers) - 1
+	}
+	return p.Tiers[priority].TaskList
+}
+
+func (p PriorityTaskLists) validate() error {
+	seen := make(map[string]bool, len(p.Tiers))
+	for i, tier := range p.Tiers {
+		if tier.TaskList == "" {
+			return fmt.Errorf("priority tier %d has no task list", i)
+		}
+		if tier.Weight <= 0 {
+			return fmt.Errorf("priority tier %q must have a positive weight", tier.TaskList)
+		}
+		if seen[tier.TaskList] {
+			return fmt.Errorf("priority tier task list %q is not unique", tier.TaskList)
+		}
+		seen[tier.TaskList] = true
+	}
+	return nil
+}
+
+// WithActivityPriority returns a copy of ctx whose activities are scheduled on the task list of the given priority
+// in tiers, see PriorityTaskLists.TaskList. It returns an error if tiers is invalid.
+func WithActivityPriority(ctx Context, tiers PriorityTaskLists, priority int) (Context, error) {
+	if len(tiers.Tiers) == 0 {
+		return ctx, errors.New("priority task lists have no tiers")
+	}
+	if err := tiers.validate(); err != nil {
+		return ctx, err
+	}
+	return WithTaskList(ctx, tiers.TaskList(priority)), nil
+}
+
+// newPriorityActivityWorkers returns an activity worker per tier, polling the task list of the tier with Weight
+// pollers. The workers share the execution slots and the rate limit of primary.
+func newPriorityActivityWorkers(
+	service workflowserviceclient.Interface,
+	domain string,
+	params workerExecutionParameters,
+	registry *registry,
+	primary *activityWorker,
+) []*activityWorker {
+	tiers := params.ActivityPriorityTaskLists.Tiers
+	workers := make([]*activityWorker, 0, len(tiers))
+	for _, tier := range tiers {
+		tierParams := params
+		tierParams.TaskList = tier.TaskList
+		tierParams.MaxConcurrentActivityTaskPollers = tier.Weight
+		if tierParams.MinConcurrentActivityTaskPollers > tier.Weight {
+			tierParams.MinConcurrentActivityTaskPollers = tier.Weight
+		}
+		tierParams.MetricsScope = tagScope(params.MetricsScope, tagTaskList, tier.TaskList)
+		tierParams.Logger = params.Logger.With(zap.String(tagTaskList, tier.TaskList))
+		workers = append(workers, shareActivityTaskLimits(newActivityWorker(service, domain, tierParams, nil, registry, nil), primary))
+	}
+	return workers
+}
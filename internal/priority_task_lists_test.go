@@ -0,0 +1,118 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityTaskLists(t *testing.T) {
+	tiers := PriorityTaskLists{Tiers: []PriorityTier{
+		{TaskList: "high", Weight: 3},
+		{TaskList: "normal", Weight: 2},
+		{TaskList: "low", Weight: 1},
+	}}
+	assert.Equal(t, "high", tiers.TaskList(-1))
+	assert.Equal(t, "high", tiers.TaskList(0))
+	assert.Equal(t, "normal", tiers.TaskList(1))
+	assert.Equal(t, "low", tiers.TaskList(2))
+	assert.Equal(t, "low", tiers.TaskList(10))
+	assert.Equal(t, "", PriorityTaskLists{}.TaskList(0))
+
+	t.Run("workflow", func(t *testing.T) {
+		var testSuite WorkflowTestSuite
+		env := testSuite.NewTestWorkflowEnvironment()
+		activityFn := func(ctx context.Context) (string, error) {
+			return GetActivityInfo(ctx).TaskList, nil
+		}
+		env.RegisterActivity(activityFn)
+		env.ExecuteWorkflow(func(ctx Context) ([]string, error) {
+			ctx = WithActivityOptions(ctx, ActivityOptions{
+				ScheduleToStartTimeout: time.Minute,
+				StartToCloseTimeout:    time.Minute,
+			})
+			var taskLists []string
+			for _, priority := range []int{0, 2, 5} {
+				priorityCtx, err := WithActivityPriority(ctx, tiers, priority)
+				if err != nil {
+					return nil, err
+				}
+				var taskList string
+				if err := ExecuteActivity(priorityCtx, activityFn).Get(ctx, &taskList); err != nil {
+					return nil, err
+				}
+				taskLists = append(taskLists, taskList)
+			}
+			return taskLists, nil
+		})
+		require.NoError(t, env.GetWorkflowError())
+		var taskLists []string
+		require.NoError(t, env.GetWorkflowResult(&taskLists))
+		assert.Equal(t, []string{"high", "low", "low"}, taskLists)
+	})
+
+	t.Run("worker", func(t *testing.T) {
+		aggWorker, err := newAggregatedWorker(nil, "priority-domain", "priority-tl", WorkerOptions{
+			MaxConcurrentActivityExecutionSize: 60,
+			ActivityPriorityTaskLists:          tiers,
+		})
+		require.NoError(t, err)
+		require.Len(t, aggWorker.taskListActivityWorkers, 3)
+		var taskLists []string
+		var pollers []int
+		primary := aggWorker.activityWorker.worker
+		for _, worker := range aggWorker.taskListActivityWorkers {
+			taskLists = append(taskLists, worker.executionParameters.TaskList)
+			pollers = append(pollers, worker.executionParameters.MaxConcurrentActivityTaskPollers)
+			// the tiers share the execution slots and the rate limit of the worker
+			assert.Same(t, primary.concurrency.TaskPermit, worker.worker.concurrency.TaskPermit)
+			assert.Same(t, primary.taskLimiter, worker.worker.taskLimiter)
+		}
+		assert.Equal(t, []string{"high", "normal", "low"}, taskLists)
+		assert.Equal(t, []int{3, 2, 1}, pollers)
+		assert.Equal(t, 60, primary.concurrency.TaskPermit.Quota())
+		assert.Equal(t, "priority-tl", aggWorker.activityWorker.executionParameters.TaskList)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		for _, invalid := range []PriorityTaskLists{
+			{Tiers: []PriorityTier{{TaskList: "", Weight: 1}}},
+			{Tiers: []PriorityTier{{TaskList: "a", Weight: 0}}},
+			{Tiers: []PriorityTier{{TaskList: "a", Weight: 1}, {TaskList: "a", Weight: 1}}},
+		} {
+			_, err := newAggregatedWorker(nil, "priority-domain", "priority-tl", WorkerOptions{ActivityPriorityTaskLists: invalid})
+			assert.ErrorContains(t, err, "invalid ActivityPriorityTaskLists")
+		}
+
+		var testSuite WorkflowTestSuite
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) error {
+			_, err := WithActivityPriority(ctx, PriorityTaskLists{}, 0)
+			return err
+		})
+		assert.EqualError(t, env.GetWorkflowError(), "priority task lists have no tiers")
+	})
+}
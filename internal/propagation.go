@@ -25,6 +25,9 @@ import (
 	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+
+	s "go.uber.org/cadence/.gen/go/shared"
 )
 
 const (
@@ -88,3 +91,63 @@ func createOpenTracingSpan(
 	ctx = opentracing.ContextWithSpan(ctx, span)
 	return ctx, span
 }
+
+// createOpenTracingWorkflowExecutionSpan creates a span around the execution of the workflow function, following the
+// span propagated by the starter of the workflow. When the start of the workflow is replayed, the span was already
+// emitted by the worker which first ran it, so it is recreated for the spans of the workflow to follow, but not
+// returned, to never be finished and emitted again. The returned span may be nil.
+func createOpenTracingWorkflowExecutionSpan(ctx Context, env workflowEnvironment) (Context, opentracing.Span) {
+	tracer := env.GetTracer()
+	if tracer == nil {
+		return ctx, nil
+	}
+	if _, ok := tracer.(opentracing.NoopTracer); ok {
+		return ctx, nil
+	}
+
+	info := env.WorkflowInfo()
+	options := []opentracing.StartSpanOption{
+		opentracing.Tags{
+			workflowTag: info.WorkflowExecution.ID,
+			runTag:      info.WorkflowExecution.RunID,
+		},
+	}
+	if parent := spanFromContext(ctx); parent != nil {
+		options = append(options, opentracing.FollowsFrom(parent))
+	}
+	span := tracer.StartSpan("RunWorkflow-"+info.WorkflowType.Name, options...)
+	if env.IsReplaying() {
+		return contextWithSpan(ctx, span.Context()), nil
+	}
+	return contextWithSpan(ctx, span.Context()), span
+}
+
+// createOpenTracingDecisionTaskSpan creates a span around the processing of a decision task. Decision tasks are
+// processed once, replaying the history of the workflow if needed, so they never produce duplicate spans.
+func createOpenTracingDecisionTaskSpan(tracer opentracing.Tracer, task *s.PollForDecisionTaskResponse) opentracing.Span {
+	if tracer == nil {
+		tracer = opentracing.NoopTracer{}
+	}
+	name := "ProcessDecisionTask-" + task.WorkflowType.GetName()
+	if task.Query != nil {
+		name = "ProcessQueryTask-" + task.WorkflowType.GetName()
+	}
+	tags := opentracing.Tags{
+		workflowTag: task.WorkflowExecution.GetWorkflowId(),
+		runTag:      task.WorkflowExecution.GetRunId(),
+	}
+	_, span := createOpenTracingSpan(context.Background(), tracer, time.Now(), name, tags)
+	return span
+}
+
+// finishOpenTracingSpan finishes the span, marking it as failed when err is not nil. It is a no-op for nil spans.
+func finishOpenTracingSpan(span opentracing.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogKV("error", err.Error())
+	}
+	span.Finish()
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+)
+
+// ScheduleMemoKey is the memo key marking the cron workflows started by ScheduleClient.CreateSchedule.
+// Its value holds the cron schedule and the description of the schedule.
+const ScheduleMemoKey = "cadence-schedule"
+
+// SchedulePausedReason is the reason ScheduleClient.PauseSchedule terminates the cron workflow of a schedule with.
+const SchedulePausedReason = "cadence-schedule-paused"
+
+// ErrScheduleNotFound is returned by ScheduleClient when the workflow with the schedule ID was not started by
+// CreateSchedule.
+var ErrScheduleNotFound = errors.New("workflow is not a schedule")
+
+// ErrScheduleNotPaused is returned by ScheduleClient.ResumeSchedule when the schedule is not paused.
+var ErrScheduleNotPaused = errors.New("schedule is not paused")
+
+type (
+	// ScheduleClient manages schedules, i.e. workflows started periodically on a cron schedule. Cadence has no native
+	// schedules, so a schedule is a cron workflow whose memo holds the schedule under ScheduleMemoKey, and its ID is
+	// the workflow ID of the cron workflow. Use Client.TerminateWorkflow to delete a running schedule.
+	ScheduleClient interface {
+		// CreateSchedule starts the cron workflow of a new schedule.
+		// The errors it can return:
+		//  - BadRequestError
+		//  - WorkflowExecutionAlreadyStartedError
+		//  - InternalServiceError
+		CreateSchedule(ctx context.Context, options ScheduleOptions, workflow interface{}, args ...interface{}) (*WorkflowExecution, error)
+
+		// ListSchedules returns the running and the paused schedules of the domain. It lists the open and the
+		// terminated workflows with visibility APIs, so it is meant for management tools rather than frequent calls.
+		// Paused schedules are only listed until their cron workflow exceeds the retention period of the domain.
+		ListSchedules(ctx context.Context) ([]*Schedule, error)
+
+		// PauseSchedule stops starting the workflow of a schedule by terminating its cron workflow with
+		// SchedulePausedReason. The note is recorded as termination details and returned by ListSchedules.
+		// The errors it can return:
+		//  - ErrScheduleNotFound
+		//  - EntityNotExistsError
+		//  - InternalServiceError
+		PauseSchedule(ctx context.Context, scheduleID string, note string) error
+
+		// ResumeSchedule restarts the cron workflow of a paused schedule with the workflow type, input and options of
+		// its last run.
+		// The errors it can return:
+		//  - ErrScheduleNotFound
+		//  - ErrScheduleNotPaused
+		//  - EntityNotExistsError
+		//  - InternalServiceError
+		ResumeSchedule(ctx context.Context, scheduleID string) error
+	}
+
+	// ScheduleOptions configure a schedule created by ScheduleClient.CreateSchedule.
+	ScheduleOptions struct {
+		// ID of the schedule, which is the workflow ID of its cron workflow.
+		// This field is required.
+		ID string
+
+		// CronSchedule the workflow is started on, see StartWorkflowOptions.CronSchedule.
+		// This field is required.
+		CronSchedule string
+
+		// Description of the schedule, returned by ListSchedules.
+		// Optional: default empty.
+		Description string
+
+		// WorkflowOptions of the started workflows. ID and CronSchedule are set from the schedule, and the memo is
+		// extended with ScheduleMemoKey. TaskList and ExecutionStartToCloseTimeout are required.
+		WorkflowOptions StartWorkflowOptions
+	}
+
+	// Schedule is a schedule returned by ScheduleClient.ListSchedules.
+	Schedule struct {
+		ID           string
+		CronSchedule string
+		Description  string
+		WorkflowType string
+		// Paused is true if the schedule was paused with ScheduleClient.PauseSchedule.
+		Paused bool
+		// PauseNote is the note the schedule was paused with.
+		PauseNote string
+		// Execution is the current run of the cron workflow of the schedule, or its last run if the schedule is paused.
+		Execution WorkflowExecution
+	}
+
+	// scheduleMemo is the value of ScheduleMemoKey in the memo of the cron workflow of a schedule.
+	scheduleMemo struct {
+		CronSchedule string
+		Description  string
+	}
+)
+
+// NewScheduleClient creates an instance of a schedule client, to manage the schedules of a domain.
+func NewScheduleClient(service workflowserviceclient.Interface, domain string, options *ClientOptions) ScheduleClient {
+	return &scheduleClient{workflowClient: NewClient(service, domain, options).(*workflowClient)}
+}
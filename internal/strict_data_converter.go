@@ -0,0 +1,228 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type (
+	// strictDataConverter decodes every payload it encodes and compares the result with the encoded values, see
+	// NewStrictDataConverter.
+	strictDataConverter struct {
+		dataConverter DataConverter
+	}
+
+	// DataConverterRoundTripError is returned by the DataConverter created with NewStrictDataConverter when a value
+	// does not survive being encoded and decoded back.
+	DataConverterRoundTripError struct {
+		// Index is the position of the value in the encoded values.
+		Index int
+		// Type is the type of the value.
+		Type string
+		// Path locates the first difference in the value, e.g. `.Items[2].Price` or `["count"]`. It is empty when the
+		// value differs as a whole or cannot be decoded.
+		Path string
+		// Detail describes the difference, or the decoding error.
+		Detail string
+	}
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// NewStrictDataConverter returns a DataConverter which decodes every payload encoded by dataConverter back into
+// values of the source types and fails the encoding with a *DataConverterRoundTripError when they differ from the
+// source values, e.g. because of unexported struct fields, which are not encoded, or of interface{} values whose type
+// changes, like JSON turning int into float64. It is meant for development and tests, where it catches serialization
+// bugs before payloads reach histories, at the cost of decoding every payload.
+// Times are compared with time.Time.Equal, and nil and empty slices and maps are considered equal.
+func NewStrictDataConverter(dataConverter DataConverter) DataConverter {
+	if dataConverter == nil {
+		dataConverter = getDefaultDataConverter()
+	}
+	return &strictDataConverter{dataConverter: dataConverter}
+}
+
+func (dc *strictDataConverter) ToData(values ...interface{}) ([]byte, error) {
+	data, err := dc.dataConverter.ToData(values...)
+	if err != nil {
+		return nil, err
+	}
+	if err := dc.verify(data, values); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (dc *strictDataConverter) FromData(data []byte, valuePtrs ...interface{}) error {
+	return dc.dataConverter.FromData(data, valuePtrs...)
+}
+
+func (dc *strictDataConverter) verify(data []byte, values []interface{}) error {
+	decoded := make([]interface{}, len(values))
+	for i, value := range values {
+		if value == nil {
+			// there is no type to decode into, so decode into an interface{}
+			var v interface{}
+			decoded[i] = &v
+			continue
+		}
+		decoded[i] = reflect.New(reflect.TypeOf(value)).Interface()
+	}
+	if err := dc.dataConverter.FromData(data, decoded...); err != nil {
+		return &DataConverterRoundTripError{Index: -1, Detail: fmt.Sprintf("decoding the payload failed: %v", err)}
+	}
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		path, detail := roundTripDiff(reflect.ValueOf(value), reflect.ValueOf(decoded[i]).Elem(), "")
+		if detail != "" {
+			return &DataConverterRoundTripError{
+				Index:  i,
+				Type:   reflect.TypeOf(value).String(),
+				Path:   path,
+				Detail: detail,
+			}
+		}
+	}
+	return nil
+}
+
+// roundTripDiff returns the path and the description of the first difference between the source value and the
+// decoded one, or an empty description if they are equal.
+func roundTripDiff(source, decoded reflect.Value, path string) (string, string) {
+	if !source.IsValid() || !decoded.IsValid() {
+		if source.IsValid() != decoded.IsValid() {
+			return path, fmt.Sprintf("%s decoded as %s", formatRoundTripValue(source), formatRoundTripValue(decoded))
+		}
+		return "", ""
+	}
+	if source.Type() != decoded.Type() {
+		return path, fmt.Sprintf("%s decoded as %s", formatRoundTripValue(source), formatRoundTripValue(decoded))
+	}
+
+	switch source.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if source.IsNil() || decoded.IsNil() {
+			if source.IsNil() != decoded.IsNil() {
+				return path, fmt.Sprintf("%s decoded as %s", formatRoundTripValue(source), formatRoundTripValue(decoded))
+			}
+			return "", ""
+		}
+		return roundTripDiff(source.Elem(), decoded.Elem(), path)
+	case reflect.Struct:
+		if source.Type() == timeType && source.CanInterface() {
+			if !source.Interface().(time.Time).Equal(decoded.Interface().(time.Time)) {
+				return path, fmt.Sprintf("%s decoded as %s", formatRoundTripValue(source), formatRoundTripValue(decoded))
+			}
+			return "", ""
+		}
+		for i := 0; i < source.NumField(); i++ {
+			field := source.Type().Field(i)
+			fieldPath, detail := roundTripDiff(source.Field(i), decoded.Field(i), path+"."+field.Name)
+			if detail != "" {
+				if !field.IsExported() && fieldPath == path+"."+field.Name {
+					detail += " (unexported fields are not encoded)"
+				}
+				return fieldPath, detail
+			}
+		}
+		return "", ""
+	case reflect.Slice, reflect.Array:
+		if source.Len() != decoded.Len() {
+			return path, fmt.Sprintf("length %d decoded as length %d", source.Len(), decoded.Len())
+		}
+		for i := 0; i < source.Len(); i++ {
+			if elemPath, detail := roundTripDiff(source.Index(i), decoded.Index(i), fmt.Sprintf("%s[%d]", path, i)); detail != "" {
+				return elemPath, detail
+			}
+		}
+		return "", ""
+	case reflect.Map:
+		if source.Len() != decoded.Len() {
+			return path, fmt.Sprintf("%d entries decoded as %d entries", source.Len(), decoded.Len())
+		}
+		iter := source.MapRange()
+		for iter.Next() {
+			keyPath := fmt.Sprintf("%s[%#v]", path, iter.Key())
+			decodedValue := decoded.MapIndex(iter.Key())
+			if !decodedValue.IsValid() {
+				return keyPath, "entry missing after decoding"
+			}
+			if entryPath, detail := roundTripDiff(iter.Value(), decodedValue, keyPath); detail != "" {
+				return entryPath, detail
+			}
+		}
+		return "", ""
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return path, fmt.Sprintf("%s values cannot be encoded", source.Kind())
+	case reflect.Bool:
+		if source.Bool() == decoded.Bool() {
+			return "", ""
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if source.Int() == decoded.Int() {
+			return "", ""
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if source.Uint() == decoded.Uint() {
+			return "", ""
+		}
+	case reflect.Float32, reflect.Float64:
+		if source.Float() == decoded.Float() {
+			return "", ""
+		}
+	case reflect.Complex64, reflect.Complex128:
+		if source.Complex() == decoded.Complex() {
+			return "", ""
+		}
+	case reflect.String:
+		if source.String() == decoded.String() {
+			return "", ""
+		}
+	}
+	return path, fmt.Sprintf("%s decoded as %s", formatRoundTripValue(source), formatRoundTripValue(decoded))
+}
+
+func formatRoundTripValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	return fmt.Sprintf("%s(%v)", v.Type(), v)
+}
+
+func (e *DataConverterRoundTripError) Error() string {
+	var b strings.Builder
+	b.WriteString("data converter round trip failed")
+	if e.Index >= 0 {
+		fmt.Fprintf(&b, " for value %d of type %s", e.Index, e.Type)
+	}
+	if e.Path != "" {
+		fmt.Fprintf(&b, " at %s", e.Path)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Detail)
+	return b.String()
+}
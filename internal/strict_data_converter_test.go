@@ -0,0 +1,108 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type strictTestItem struct {
+	Name  string
+	Price float64
+}
+
+type strictTestOrder struct {
+	ID       string
+	Items    []strictTestItem
+	Created  time.Time
+	Attrs    map[string]interface{}
+	internal int
+}
+
+func TestStrictDataConverter(t *testing.T) {
+	dc := NewStrictDataConverter(nil)
+
+	valid := strictTestOrder{
+		ID:      "order",
+		Items:   []strictTestItem{{Name: "item", Price: 1.5}},
+		Created: time.Now(), // monotonic clock reading and location are lost, but the time is equal
+		Attrs:   map[string]interface{}{"tag": "a", "flag": true},
+	}
+	data, err := dc.ToData(valid, "string", 1, nil, []byte(nil))
+	require.NoError(t, err)
+	var decoded strictTestOrder
+	var s string
+	var i int
+	require.NoError(t, dc.FromData(data, &decoded, &s, &i, new(interface{}), new([]byte)))
+	assert.Equal(t, "order", decoded.ID)
+
+	data, err = dc.ToData([]byte("raw"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("raw"), data)
+
+	tests := map[string]struct {
+		values []interface{}
+		index  int
+		path   string
+		detail string
+	}{
+		"unexported field": {
+			values: []interface{}{"first", strictTestOrder{ID: "order", internal: 3}},
+			index:  1,
+			path:   ".internal",
+			detail: "int(3) decoded as int(0) (unexported fields are not encoded)",
+		},
+		"interface number": {
+			values: []interface{}{strictTestOrder{Attrs: map[string]interface{}{"count": 5}}},
+			path:   `.Attrs["count"]`,
+			detail: "int(5) decoded as json.Number(5)", // the default data converter decodes numbers as json.Number
+		},
+		"nested interface struct": {
+			values: []interface{}{[]interface{}{strictTestItem{Name: "item"}}},
+			path:   "[0]",
+			detail: "decoded as map[string]interface {}",
+		},
+		"pointer to struct with unexported field": {
+			values: []interface{}{&strictTestOrder{internal: 1}},
+			path:   ".internal",
+			detail: "unexported fields are not encoded",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := dc.ToData(test.values...)
+			var roundTripErr *DataConverterRoundTripError
+			require.True(t, errors.As(err, &roundTripErr), "unexpected error %v", err)
+			assert.Equal(t, test.index, roundTripErr.Index)
+			assert.Equal(t, test.path, roundTripErr.Path)
+			assert.Contains(t, roundTripErr.Detail, test.detail)
+			assert.Contains(t, err.Error(), test.path)
+		})
+	}
+
+	_, err = dc.ToData(func() {})
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/zap"
+)
+
+const defaultTaskListRouterVirtualNodes = 100
+
+type (
+	// TaskListRouterOptions configure a TaskListRouter.
+	TaskListRouterOptions struct {
+		// TaskLists are the task lists activities are sharded across. Required.
+		TaskLists []string
+
+		// VirtualNodes is the number of points of each task list on the hash ring. More points spread the keys more
+		// evenly across the task lists.
+		// default: 100
+		VirtualNodes int
+	}
+
+	// TaskListRouter shards activities across task lists by a key with consistent hashing, so that the activities of
+	// a key run on the workers polling its task list, e.g. to reuse data those workers cached, without the session
+	// framework. Adding or removing a task list only moves the keys of the task lists next to it on the hash ring.
+	//
+	// Workflows route activities with WithActivityRoutingKey, and workers poll the task lists assigned to them by
+	// AssignedTaskLists with WorkerOptions.AdditionalActivityTaskLists. The task lists of the router, and so the
+	// routing of the keys, must not change while workflows using it run, unless the change is versioned.
+	TaskListRouter struct {
+		taskLists []string
+		ring      []taskListRingPoint
+	}
+
+	taskListRingPoint struct {
+		hash     uint64
+		taskList string
+	}
+)
+
+// NewTaskListRouter returns a TaskListRouter over the task lists of the options.
+func NewTaskListRouter(options TaskListRouterOptions) (*TaskListRouter, error) {
+	if len(options.TaskLists) == 0 {
+		return nil, errors.New("task list router has no task lists")
+	}
+	if err := validateTaskLists(options.TaskLists); err != nil {
+		return nil, err
+	}
+	virtualNodes := options.VirtualNodes
+	if virtualNodes <= 0 {
+		virtualNodes = defaultTaskListRouterVirtualNodes
+	}
+	ring := make([]taskListRingPoint, 0, len(options.TaskLists)*virtualNodes)
+	for _, taskList := range options.TaskLists {
+		for i := 0; i < virtualNodes; i++ {
+			ring = append(ring, taskListRingPoint{hash: hashRoutingKey(taskList + "#" + strconv.Itoa(i)), taskList: taskList})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		if ring[i].hash != ring[j].hash {
+			return ring[i].hash < ring[j].hash
+		}
+		return ring[i].taskList < ring[j].taskList
+	})
+	return &TaskListRouter{
+		taskLists: append([]string(nil), options.TaskLists...),
+		ring:      ring,
+	}, nil
+}
+
+// TaskList returns the task list of the key.
+func (r *TaskListRouter) TaskList(key string) string {
+	hash := hashRoutingKey(key)
+	i := sort.Search(len(r.ring), func(i int) bool { return r.ring[i].hash >= hash })
+	if i == len(r.ring) {
+		i = 0
+	}
+	return r.ring[i].taskList
+}
+
+// TaskLists returns the task lists of the router.
+func (r *TaskListRouter) TaskLists() []string {
+	return append([]string(nil), r.taskLists...)
+}
+
+// AssignedTaskLists returns the task lists the worker with the given index, out of workerCount workers, polls so
+// that every task list is polled: the task lists are dealt to the workers in turn, and workers beyond the number of
+// task lists share the task list of a lower index.
+func (r *TaskListRouter) AssignedTaskLists(workerIndex, workerCount int) []string {
+	if workerCount <= 0 || workerIndex < 0 || workerIndex >= workerCount {
+		return nil
+	}
+	if workerCount > len(r.taskLists) {
+		return []string{r.taskLists[workerIndex%len(r.taskLists)]}
+	}
+	var assigned []string
+	for i := workerIndex; i < len(r.taskLists); i += workerCount {
+		assigned = append(assigned, r.taskLists[i])
+	}
+	return assigned
+}
+
+// WithActivityRoutingKey returns a copy of ctx whose activities are scheduled on the task list of the key in router.
+func WithActivityRoutingKey(ctx Context, router *TaskListRouter, key string) Context {
+	return WithTaskList(ctx, router.TaskList(key))
+}
+
+func hashRoutingKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	// FNV alone clusters similar keys, like the virtual nodes of a task list, on the ring: mix its bits
+	hash := h.Sum64()
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+	return hash
+}
+
+func validateTaskLists(taskLists []string) error {
+	seen := make(map[string]bool, len(taskLists))
+	for _, taskList := range taskLists {
+		if taskList == "" {
+			return errors.New("task list name is empty")
+		}
+		if seen[taskList] {
+			return fmt.Errorf("task list %q is not unique", taskList)
+		}
+		seen[taskList] = true
+	}
+	return nil
+}
+
+// newAdditionalActivityWorkers returns an activity worker per task list of WorkerOptions.AdditionalActivityTaskLists.
+// The workers share the execution slots and the rate limit of primary.
+func newAdditionalActivityWorkers(
+	service workflowserviceclient.Interface,
+	domain string,
+	params workerExecutionParameters,
+	registry *registry,
+	primary *activityWorker,
+) []*activityWorker {
+	taskLists := params.AdditionalActivityTaskLists
+	workers := make([]*activityWorker, 0, len(taskLists))
+	for _, taskList := range taskLists {
+		taskListParams := params
+		taskListParams.TaskList = taskList
+		taskListParams.MetricsScope = tagScope(params.MetricsScope, tagTaskList, taskList)
+		taskListParams.Logger = params.Logger.With(zap.String(tagTaskList, taskList))
+		workers = append(workers, shareActivityTaskLimits(newActivityWorker(service, domain, taskListParams, nil, registry, nil), primary))
+	}
+	return workers
+}
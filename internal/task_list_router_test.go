@@ -0,0 +1,116 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskListRouter(t *testing.T) {
+	taskLists := []string{"shard-0", "shard-1", "shard-2", "shard-3"}
+	router, err := NewTaskListRouter(TaskListRouterOptions{TaskLists: taskLists})
+	require.NoError(t, err)
+	assert.Equal(t, taskLists, router.TaskLists())
+
+	counts := make(map[string]int)
+	routes := make(map[string]string)
+	for i := 0; i < 4000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		taskList := router.TaskList(key)
+		assert.Equal(t, taskList, router.TaskList(key))
+		counts[taskList]++
+		routes[key] = taskList
+	}
+	require.Len(t, counts, len(taskLists))
+	for _, count := range counts {
+		assert.InDelta(t, 1000, count, 300)
+	}
+
+	// adding a task list only moves keys to it
+	grown, err := NewTaskListRouter(TaskListRouterOptions{TaskLists: append(taskLists, "shard-4")})
+	require.NoError(t, err)
+	moved := 0
+	for key, taskList := range routes {
+		if newTaskList := grown.TaskList(key); newTaskList != taskList {
+			assert.Equal(t, "shard-4", newTaskList)
+			moved++
+		}
+	}
+	assert.InDelta(t, 800, moved, 300)
+
+	assert.Equal(t, []string{"shard-0", "shard-2"}, router.AssignedTaskLists(0, 2))
+	assert.Equal(t, []string{"shard-1", "shard-3"}, router.AssignedTaskLists(1, 2))
+	assert.Equal(t, []string{"shard-1"}, router.AssignedTaskLists(5, 6))
+	assert.Nil(t, router.AssignedTaskLists(2, 2))
+
+	_, err = NewTaskListRouter(TaskListRouterOptions{})
+	assert.Error(t, err)
+	_, err = NewTaskListRouter(TaskListRouterOptions{TaskLists: []string{"a", "a"}})
+	assert.EqualError(t, err, `task list "a" is not unique`)
+
+	t.Run("workflow", func(t *testing.T) {
+		var testSuite WorkflowTestSuite
+		env := testSuite.NewTestWorkflowEnvironment()
+		activityFn := func(ctx context.Context) (string, error) {
+			return GetActivityInfo(ctx).TaskList, nil
+		}
+		env.RegisterActivity(activityFn)
+		env.ExecuteWorkflow(func(ctx Context) (string, error) {
+			ctx = WithActivityOptions(ctx, ActivityOptions{
+				ScheduleToStartTimeout: time.Minute,
+				StartToCloseTimeout:    time.Minute,
+			})
+			var taskList string
+			err := ExecuteActivity(WithActivityRoutingKey(ctx, router, "key-7"), activityFn).Get(ctx, &taskList)
+			return taskList, err
+		})
+		require.NoError(t, env.GetWorkflowError())
+		var taskList string
+		require.NoError(t, env.GetWorkflowResult(&taskList))
+		assert.Equal(t, routes["key-7"], taskList)
+	})
+
+	t.Run("worker", func(t *testing.T) {
+		aggWorker, err := newAggregatedWorker(nil, "router-domain", "router-tl", WorkerOptions{
+			MaxConcurrentActivityExecutionSize: 10,
+			AdditionalActivityTaskLists:        router.AssignedTaskLists(0, 2),
+		})
+		require.NoError(t, err)
+		var polled []string
+		for _, worker := range aggWorker.taskListActivityWorkers {
+			polled = append(polled, worker.executionParameters.TaskList)
+			assert.Same(t, aggWorker.activityWorker.worker.concurrency.TaskPermit, worker.worker.concurrency.TaskPermit)
+			assert.Same(t, aggWorker.activityWorker.worker.taskLimiter, worker.worker.taskLimiter)
+		}
+		assert.Equal(t, []string{"shard-0", "shard-2"}, polled)
+
+		_, err = newAggregatedWorker(nil, "router-domain", "router-tl", WorkerOptions{
+			AdditionalActivityTaskLists: []string{""},
+		})
+		assert.ErrorContains(t, err, "invalid AdditionalActivityTaskLists")
+	})
+}
@@ -64,3 +64,15 @@ func callOptionsWithIsolationGroupHeader() []interface{} {
 		gomock.Any(), // isolation group header
 	}
 }
+
+// this is the mock for yarpcCallOptions, as gomock requires the num of arguments to be the same.
+// see getWorkflowHistoryPaginator for the long polls.
+func callOptionsWithLongPollHeader() []interface{} {
+	return []interface{}{
+		gomock.Any(), // library version
+		gomock.Any(), // feature version
+		gomock.Any(), // client name
+		gomock.Any(), // feature flags
+		gomock.Any(), // long poll header
+	}
+}
@@ -23,8 +23,10 @@ package internal
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	jaeger_config "github.com/uber/jaeger-client-go/config"
@@ -148,3 +150,66 @@ func TestConsistentInjectionExtraction(t *testing.T) {
 		return false
 	})
 }
+
+func TestWorkflowExecutionSpan(t *testing.T) {
+	tracer := mocktracer.New()
+	localActivity := func(ctx context.Context) error {
+		return nil
+	}
+	workflowFn := func(ctx Context) error {
+		ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: time.Minute})
+		return ExecuteLocalActivity(ctx, localActivity).Get(ctx, nil)
+	}
+
+	env := newTestWorkflowEnv(t)
+	env.SetWorkerOptions(WorkerOptions{
+		Tracer:             tracer,
+		ContextPropagators: []ContextPropagator{NewTracingContextPropagator(zap.NewNop(), tracer)},
+	})
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+	require.NoError(t, env.GetWorkflowError())
+
+	spans := make(map[string]*mocktracer.MockSpan)
+	for _, span := range tracer.FinishedSpans() {
+		spans[span.OperationName] = span
+	}
+	workflowSpan := spans["RunWorkflow-"+getFunctionName(workflowFn)]
+	require.NotNil(t, workflowSpan)
+	assert.Equal(t, defaultTestWorkflowID, workflowSpan.Tag(workflowTag))
+	assert.Nil(t, workflowSpan.Tag("error"))
+	localActivitySpan := spans[getFunctionName(localActivity)]
+	require.NotNil(t, localActivitySpan)
+	assert.Equal(t, workflowSpan.SpanContext.SpanID, localActivitySpan.ParentID)
+}
+
+// replayingWorkflowEnvironment is a workflowEnvironment replaying the start of a workflow.
+type replayingWorkflowEnvironment struct {
+	workflowEnvironment
+	tracer opentracing.Tracer
+}
+
+func (env *replayingWorkflowEnvironment) GetTracer() opentracing.Tracer { return env.tracer }
+
+func (env *replayingWorkflowEnvironment) IsReplaying() bool { return true }
+
+func (env *replayingWorkflowEnvironment) WorkflowInfo() *WorkflowInfo {
+	return &WorkflowInfo{
+		WorkflowType:      WorkflowType{Name: "workflow"},
+		WorkflowExecution: WorkflowExecution{ID: defaultTestWorkflowID, RunID: defaultTestRunID},
+	}
+}
+
+func TestWorkflowExecutionSpan_Replay(t *testing.T) {
+	tracer := mocktracer.New()
+	starter := tracer.StartSpan("StartWorkflow")
+	ctx := contextWithSpan(Background(), starter.Context())
+
+	spanCtx, span := createOpenTracingWorkflowExecutionSpan(ctx, &replayingWorkflowEnvironment{tracer: tracer})
+	assert.Nil(t, span)
+	// the spans of the workflow follow the recreated workflow span, which is never emitted again
+	workflowSpan := spanFromContext(spanCtx).(mocktracer.MockSpanContext)
+	assert.NotEqual(t, starter.Context().(mocktracer.MockSpanContext).SpanID, workflowSpan.SpanID)
+	assert.Equal(t, starter.Context().(mocktracer.MockSpanContext).TraceID, workflowSpan.TraceID)
+	assert.Empty(t, tracer.FinishedSpans())
+}
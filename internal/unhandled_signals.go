@@ -0,0 +1,106 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"sort"
+)
+
+// UnhandledSignalsReason is the reason of the CustomError a workflow fails with when UnhandledSignalOptions.FailWorkflow
+// is set and signals are left unhandled. The error details are a map of signal name to the number of unhandled signals.
+const UnhandledSignalsReason = "cadence:UnhandledSignals"
+
+type (
+	// UnhandledSignalOptions configures what happens to signals which were sent to the workflow but never received
+	// when the workflow function returns. By default they are logged, counted in the unhandled-signals metric and
+	// dropped.
+	UnhandledSignalOptions struct {
+		// DeadLetterHandler is called for every unhandled signal after the workflow function returns, ordered by signal
+		// name and then by the order the signals were received. It runs as workflow code, so it can block, e.g. to
+		// forward the signals to another workflow with SignalExternalWorkflow or to pass them to ContinueAsNew.
+		// Signals passed to the handler are considered handled.
+		// Optional: default is to drop unhandled signals.
+		DeadLetterHandler func(ctx Context, signalName string, value Value)
+
+		// FailWorkflow fails the workflow with a CustomError with UnhandledSignalsReason instead of completing it or
+		// continuing it as new when it has unhandled signals. Workflows which return any other error are not affected.
+		// It has no effect if DeadLetterHandler is set.
+		// Optional: default false.
+		FailWorkflow bool
+	}
+
+	// signalValue is a Value of a signal channel, which holds encoded signals or values sent from the workflow itself.
+	signalValue struct {
+		value         interface{}
+		dataConverter DataConverter
+	}
+)
+
+// SetUnhandledSignalOptions configures how the workflow handles signals that are still pending when the workflow
+// function returns. It replaces options set by previous calls.
+func SetUnhandledSignalOptions(ctx Context, options UnhandledSignalOptions) {
+	*getWorkflowEnvOptions(ctx).unhandledSignalOptions = options
+}
+
+// handleUnhandledSignals applies the UnhandledSignalOptions of the workflow once its function returned with err, and
+// returns the error the workflow completes with.
+func handleUnhandledSignals(ctx Context, err error) error {
+	eo := getWorkflowEnvOptions(ctx)
+	options := eo.unhandledSignalOptions
+	if options.DeadLetterHandler != nil {
+		// the handler can block, so keep draining until no new signals arrived while it ran.
+		for names := eo.getUnhandledSignalNames(); len(names) > 0; names = eo.getUnhandledSignalNames() {
+			sort.Strings(names)
+			for _, name := range names {
+				ch := eo.signalChannels[name].(*channelImpl)
+				for {
+					v, ok, _ := ch.receiveAsyncImpl(nil)
+					if !ok {
+						break
+					}
+					options.DeadLetterHandler(ctx, name, &signalValue{value: v, dataConverter: ch.dataConverter})
+				}
+			}
+		}
+		return err
+	}
+
+	if !options.FailWorkflow {
+		return err
+	}
+	if _, ok := err.(*ContinueAsNewError); err != nil && !ok {
+		return err
+	}
+	if counts := eo.getUnhandledSignalCounts(); len(counts) > 0 {
+		return NewCustomError(UnhandledSignalsReason, counts)
+	}
+	return err
+}
+
+// HasValue return whether there is value.
+func (v *signalValue) HasValue() bool {
+	return v.value != nil
+}
+
+// Get decodes an encoded signal, or assigns a value sent from the workflow, into valuePtr.
+func (v *signalValue) Get(valuePtr interface{}) error {
+	return decodeAndAssignValue(v.dataConverter, v.value, valuePtr)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnhandledSignals(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	newEnv := func(workflowFn interface{}) *TestWorkflowEnvironment {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterWorkflow(workflowFn)
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow("a", "a1")
+			env.SignalWorkflow("b", "b1")
+			env.SignalWorkflow("a", "a2")
+		}, time.Minute)
+		return env
+	}
+
+	t.Run("dropped by default", func(t *testing.T) {
+		workflowFn := func(ctx Context) error {
+			return Sleep(ctx, time.Hour)
+		}
+		env := newEnv(workflowFn)
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+
+		value, err := env.QueryWorkflow(QueryTypeUnhandledSignals)
+		require.NoError(t, err)
+		var counts map[string]int
+		require.NoError(t, value.Get(&counts))
+		assert.Equal(t, map[string]int{"a": 2, "b": 1}, counts)
+	})
+
+	t.Run("fail workflow", func(t *testing.T) {
+		workflowFn := func(ctx Context) error {
+			SetUnhandledSignalOptions(ctx, UnhandledSignalOptions{FailWorkflow: true})
+			if err := Sleep(ctx, time.Hour); err != nil {
+				return err
+			}
+			var value string
+			GetSignalChannel(ctx, "a").Receive(ctx, &value)
+			return nil
+		}
+		env := newEnv(workflowFn)
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+
+		err := env.GetWorkflowError()
+		require.Error(t, err)
+		customErr, ok := err.(*CustomError)
+		require.True(t, ok, "expected CustomError, got %T", err)
+		assert.Equal(t, UnhandledSignalsReason, customErr.Reason())
+		var counts map[string]int
+		require.NoError(t, customErr.Details(&counts))
+		assert.Equal(t, map[string]int{"a": 1, "b": 1}, counts)
+	})
+
+	t.Run("fail workflow keeps workflow errors", func(t *testing.T) {
+		workflowFn := func(ctx Context) error {
+			SetUnhandledSignalOptions(ctx, UnhandledSignalOptions{FailWorkflow: true})
+			if err := Sleep(ctx, time.Hour); err != nil {
+				return err
+			}
+			return NewCustomError("workflow-failed")
+		}
+		env := newEnv(workflowFn)
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+
+		customErr, ok := env.GetWorkflowError().(*CustomError)
+		require.True(t, ok)
+		assert.Equal(t, "workflow-failed", customErr.Reason())
+	})
+
+	t.Run("dead letter handler", func(t *testing.T) {
+		var received []string
+		workflowFn := func(ctx Context) error {
+			SetUnhandledSignalOptions(ctx, UnhandledSignalOptions{
+				DeadLetterHandler: func(ctx Context, signalName string, value Value) {
+					var payload string
+					require.NoError(t, value.Get(&payload))
+					// the handler runs as workflow code and may block.
+					require.NoError(t, Sleep(ctx, time.Second))
+					received = append(received, signalName+":"+payload)
+				},
+				FailWorkflow: true,
+			})
+			return Sleep(ctx, time.Hour)
+		}
+		env := newEnv(workflowFn)
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, []string{"a:a1", "a:a2", "b:b1"}, received)
+
+		value, err := env.QueryWorkflow(QueryTypeUnhandledSignals)
+		require.NoError(t, err)
+		var counts map[string]int
+		require.NoError(t, value.Get(&counts))
+		assert.Empty(t, counts)
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+const versionReportPageSize = 100
+
+// VersionReport aggregates the versions chosen with workflow.GetVersion by open workflow executions, see
+// GetVersionReport.
+type VersionReport struct {
+	// Executions is the number of open executions which reported their versions.
+	Executions int
+	// Failed lists the open executions which could not be queried, e.g. because no worker was polling their task list.
+	Failed []WorkflowExecution
+	// Changes counts the executions by change ID and by the version they chose for it.
+	Changes map[string]map[Version]int
+}
+
+// InUse returns if any of the executions in the report chose the version for the change ID. Once an old version is
+// not in use anymore, and no execution failed to report its versions, its branch can be deleted from the workflow code
+// and the minSupported version of the GetVersion call raised.
+func (r *VersionReport) InUse(changeID string, version Version) bool {
+	return r.Changes[changeID][version] > 0
+}
+
+// GetVersionReport lists the open workflow executions matching the visibility query and queries their versions with
+// QueryTypeVersions, e.g. to find out which branches of a workflow type are still used:
+//
+//	report, err := GetVersionReport(ctx, c, "WorkflowType = 'OrderWorkflow' AND CloseTime = missing")
+//	if err == nil && len(report.Failed) == 0 && !report.InUse("add-fraud-check", DefaultVersion) {
+//		// the code path of DefaultVersion can be deleted
+//	}
+//
+// Closed executions in the result of the query are skipped, as they do not run workflow code anymore. Executions
+// which do not answer the query are listed in VersionReport.Failed. The query requires advanced visibility.
+// Every execution is queried, which replays its history on a worker unless it is cached, so use a selective query for
+// workflow types with many open executions.
+func GetVersionReport(ctx context.Context, c Client, query string) (*VersionReport, error) {
+	report := &VersionReport{Changes: make(map[string]map[Version]int)}
+	request := &s.ListWorkflowExecutionsRequest{
+		PageSize: common.Int32Ptr(versionReportPageSize),
+		Query:    common.StringPtr(query),
+	}
+	for {
+		response, err := c.ListWorkflow(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range response.GetExecutions() {
+			if info.CloseStatus != nil {
+				continue
+			}
+			execution := WorkflowExecution{ID: info.GetExecution().GetWorkflowId(), RunID: info.GetExecution().GetRunId()}
+			if err := report.add(ctx, c, execution); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				report.Failed = append(report.Failed, execution)
+			}
+		}
+		if len(response.NextPageToken) == 0 {
+			return report, nil
+		}
+		request.NextPageToken = response.NextPageToken
+	}
+}
+
+func (r *VersionReport) add(ctx context.Context, c Client, execution WorkflowExecution) error {
+	value, err := c.QueryWorkflow(ctx, execution.ID, execution.RunID, QueryTypeVersions)
+	if err != nil {
+		return err
+	}
+	var versions map[string]Version
+	if err := value.Get(&versions); err != nil {
+		return err
+	}
+	r.Executions++
+	for changeID, version := range versions {
+		if r.Changes[changeID] == nil {
+			r.Changes[changeID] = make(map[Version]int)
+		}
+		r.Changes[changeID][version]++
+	}
+	return nil
+}
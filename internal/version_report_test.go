@@ -0,0 +1,128 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+type versionReportTestClient struct {
+	Client
+	pages    []*s.ListWorkflowExecutionsResponse
+	versions map[string]map[string]Version // by workflow ID, nil fails the query
+	queried  []string
+}
+
+func (c *versionReportTestClient) ListWorkflow(_ context.Context, request *s.ListWorkflowExecutionsRequest) (*s.ListWorkflowExecutionsResponse, error) {
+	page := 0
+	if request.NextPageToken != nil {
+		page = int(request.NextPageToken[0])
+	}
+	return c.pages[page], nil
+}
+
+func (c *versionReportTestClient) QueryWorkflow(_ context.Context, workflowID string, _ string, queryType string, _ ...interface{}) (Value, error) {
+	c.queried = append(c.queried, workflowID)
+	versions, ok := c.versions[workflowID]
+	if !ok || queryType != QueryTypeVersions {
+		return nil, errors.New("no worker")
+	}
+	data, err := encodeArg(getDefaultDataConverter(), versions)
+	if err != nil {
+		return nil, err
+	}
+	return newEncodedValue(data, getDefaultDataConverter()), nil
+}
+
+func newVersionReportTestExecution(workflowID string, closed bool) *s.WorkflowExecutionInfo {
+	info := &s.WorkflowExecutionInfo{
+		Execution: &s.WorkflowExecution{WorkflowId: common.StringPtr(workflowID), RunId: common.StringPtr("run")},
+	}
+	if closed {
+		info.CloseStatus = s.WorkflowExecutionCloseStatusCompleted.Ptr()
+	}
+	return info
+}
+
+func TestQueryTypeVersions(t *testing.T) {
+	workflowFn := func(ctx Context) error {
+		GetVersion(ctx, "add-fraud-check", DefaultVersion, 2)
+		GetVersion(ctx, "new-retry-policy", DefaultVersion, 1)
+		return nil
+	}
+	env := newTestWorkflowEnv(t)
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+	require.NoError(t, env.GetWorkflowError())
+
+	value, err := env.QueryWorkflow(QueryTypeVersions)
+	require.NoError(t, err)
+	var versions map[string]Version
+	require.NoError(t, value.Get(&versions))
+	assert.Equal(t, map[string]Version{"add-fraud-check": 2, "new-retry-policy": 1}, versions)
+}
+
+func TestGetVersionReport(t *testing.T) {
+	c := &versionReportTestClient{
+		pages: []*s.ListWorkflowExecutionsResponse{
+			{
+				Executions: []*s.WorkflowExecutionInfo{
+					newVersionReportTestExecution("old", false),
+					newVersionReportTestExecution("closed", true),
+				},
+				NextPageToken: []byte{1},
+			},
+			{
+				Executions: []*s.WorkflowExecutionInfo{
+					newVersionReportTestExecution("new", false),
+					newVersionReportTestExecution("unavailable", false),
+				},
+			},
+		},
+		versions: map[string]map[string]Version{
+			"old": {"add-fraud-check": DefaultVersion},
+			"new": {"add-fraud-check": 2, "new-retry-policy": 1},
+		},
+	}
+
+	report, err := GetVersionReport(context.Background(), c, "WorkflowType = 'OrderWorkflow'")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old", "new", "unavailable"}, c.queried)
+	assert.Equal(t, &VersionReport{
+		Executions: 2,
+		Failed:     []WorkflowExecution{{ID: "unavailable", RunID: "run"}},
+		Changes: map[string]map[Version]int{
+			"add-fraud-check":  {DefaultVersion: 1, 2: 1},
+			"new-retry-policy": {1: 1},
+		},
+	}, report)
+	assert.True(t, report.InUse("add-fraud-check", DefaultVersion))
+	assert.False(t, report.InUse("new-retry-policy", DefaultVersion))
+	assert.False(t, report.InUse("unknown", 1))
+}
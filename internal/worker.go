@@ -28,6 +28,7 @@ import (
 
 	"go.uber.org/cadence/internal/common/debug"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/opentracing/opentracing-go"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
@@ -55,6 +56,12 @@ type (
 		// The zero value of this uses the default value. Default: 100k
 		WorkerActivitiesPerSecond float64
 
+		// Optional: Store shared by the workers of the task list to enforce WorkerActivitiesPerSecond across all of them,
+		// instead of per worker, e.g. backed by Redis. The workers fall back to the per worker limit while the store
+		// fails. Note that TaskListActivitiesPerSecond is enforced by the server for the whole task list already.
+		// default: nil, WorkerActivitiesPerSecond is enforced per worker
+		ActivityRateLimitStore RateLimitStore
+
 		// Optional: To set the maximum concurrent local activity executions this worker can have.
 		// The zero value of this uses the default value.
 		// default: 1k
@@ -174,6 +181,101 @@ type (
 		// default: false
 		EnableLoggingInReplay bool
 
+		// Optional: Log query handlers taking longer than this duration, together with the workflow identity and the
+		// query type, and count them in the cadence-query-slow metric.
+		// default: 0, which disables slow query logging
+		SlowQueryThreshold time.Duration
+
+		// Optional: Track the cost profile of every workflow execution, i.e. its decision count and the durations and
+		// retries of its activities. The profile can be queried with QueryTypeExecutionProfile, and its totals are
+		// reported when the workflow closes in the cadence-workflow-profile-* metrics tagged by workflow type.
+		// default: false
+		EnableExecutionProfile bool
+
+		// Optional: Record why the coroutines of every workflow block, counted by coroutine and reason, during each
+		// decision task. The counts of the last decision task can be queried with QueryTypeYieldDiagnostics.
+		// It is meant for debugging, as it adds a map update to every blocking call of the workflows.
+		// default: false
+		EnableYieldDiagnostics bool
+
+		// Optional: Log the YieldDiagnostics of decision tasks taking longer than this duration to process. It is only
+		// used with EnableYieldDiagnostics.
+		// default: 0, which disables slow decision task logging
+		YieldDiagnosticsThreshold time.Duration
+
+		// Optional: Emit verbose diagnostics, i.e. the latency, decision payload sizes and YieldDiagnostics of every
+		// decision task, for a sample of the workflow executions, see DiagnosticsSamplingOptions. Unlike
+		// EnableYieldDiagnostics it only adds overhead to the sampled executions.
+		// default: no execution is sampled
+		DiagnosticsSampling DiagnosticsSamplingOptions
+
+		// Optional: Log the workflows evicted from the sticky workflow cache and the full history replays rebuilding
+		// the state of workflows which are not cached, with their cause, history length and duration. The
+		// workflow-cache-eviction, full-replay, full-replay-events and full-replay-latency metrics, tagged with the
+		// cause, are emitted regardless: many evictions for cache pressure and full replays for cache misses mean
+		// that SetStickyWorkflowCacheSize is too small for the workflows of the worker.
+		// default: false
+		LogWorkflowCacheEvictions bool
+
+		// Optional: Called with the new history events of every decision task the worker processes, i.e. the events
+		// added to the history of the workflow since its previous decision task, to export workflow progress in real
+		// time without polling histories. See HistoryEventTap.
+		// default: nil
+		HistoryEventTap HistoryEventTap
+
+		// Optional: Publishes the task list of the worker and the workflow and activity types it serves to a registry
+		// while the worker runs, so that which task lists serve a workflow type can be discovered with
+		// FindWorkflowTaskLists. Failures to publish are logged and do not stop the worker.
+		// default: nil, the worker is not published
+		WorkerRegistry WorkerRegistry
+
+		// Optional: How often the worker refreshes its registration in WorkerRegistry. Registrations not refreshed
+		// for three intervals are considered to belong to dead workers.
+		// default: 30s
+		WorkerRegistryHeartbeatInterval time.Duration
+
+		// Optional: Also poll the activity task lists of these priority tiers, which workflows route activities to
+		// with WithActivityPriority. The tiers get their weight in pollers, in addition to the pollers of the task
+		// list of the worker, and share its MaxConcurrentActivityExecutionSize and WorkerActivitiesPerSecond.
+		// default: no priority task lists are polled
+		ActivityPriorityTaskLists PriorityTaskLists
+
+		// Optional: Also poll these activity task lists, e.g. the task lists TaskListRouter.AssignedTaskLists assigns
+		// to the worker. Each task list is polled with MaxConcurrentActivityTaskPollers pollers, and shares the
+		// MaxConcurrentActivityExecutionSize and WorkerActivitiesPerSecond of the task list of the worker.
+		// default: no additional task lists are polled
+		AdditionalActivityTaskLists []string
+
+		// Optional: Redacts payloads, and values which may contain them, before the worker writes them to its logs or
+		// returns them in query errors: corrupt signal errors, workflow and activity panic values, and query errors.
+		// NewTruncatingPayloadRedactor returns a redactor keeping a short prefix and a digest of the payload.
+		// default: no redaction
+		PayloadRedactor PayloadRedactor
+
+		// Optional: Constrains the query types workflows register with SetQueryHandler, see NamingPolicies.
+		// Use the same policies as the ClientOptions of the clients querying the workflows.
+		// default: accepts any name
+		NamingPolicies NamingPolicies
+
+		// Optional: Fractions of HistoryCountLimit and HistorySizeLimit at which the worker logs a warning with the
+		// workflow identity and increments the cadence-history-limit-warning metric, so runaway workflows are noticed
+		// before the server terminates them. Each fraction is reported once per workflow execution cached by the worker.
+		// Values must be in the (0, 1] range.
+		// default: 0.25, 0.5 and 0.75
+		HistoryLimitWarningThresholds []float64
+
+		// Optional: The history length limit of the server, in events.
+		// default: 204800, the server default
+		HistoryCountLimit int64
+
+		// Optional: The history size limit of the server, in bytes.
+		// default: 200MB, the server default
+		HistorySizeLimit int64
+
+		// Optional: Disable history limit warnings.
+		// default: false
+		DisableHistoryLimitWarnings bool
+
 		// Optional: Disable running workflow workers.
 		// default: false
 		DisableWorkflowWorker bool
@@ -238,7 +340,8 @@ type (
 		// default: no ContextPropagators
 		ContextPropagators []ContextPropagator
 
-		// Optional: Sets opentracing Tracer that is to be used to emit tracing information
+		// Optional: Sets opentracing Tracer that is to be used to emit tracing information. The worker emits a span
+		// for each decision task, for the execution of the workflow function and for each activity and local activity.
 		// default: no tracer - opentracing.NoopTracer
 		Tracer opentracing.Tracer
 
@@ -276,6 +379,33 @@ type (
 		// default: noop implementation provided
 		// Deprecated: in development and very likely to change
 		WorkerStats debug.WorkerStats
+
+		// Optional: Clock is the source of wall-clock time used by the activity worker, e.g. to throttle
+		// activity heartbeats. Tests can use a fake clock like clockwork.NewFakeClock().
+		// default: real clock
+		Clock clockwork.Clock
+
+		// Optional: Cadence service capabilities the worker depends on, e.g. ServiceCapabilityAdvancedVisibility.
+		// Worker.Start verifies them along with the domain and fails with a *ServiceCapabilityError, instead of
+		// failing later in workflows or pollers, when the service does not provide one of them.
+		// default: no capabilities are verified
+		RequiredServiceCapabilities []ServiceCapability
+	}
+
+	// HistoryEventTap receives the new history events of the decision tasks processed by a worker, ordered by event
+	// ID, see WorkerOptions.HistoryEventTap. It is called outside of the workflow code, from the goroutine processing
+	// the decision task once its events are applied, so it must be fast, e.g. hand the events over to a queue.
+	// Events are delivered at least once: they are delivered again when a decision task fails or times out and is
+	// retried, so consumers should deduplicate them by event ID. info and events must not be modified.
+	HistoryEventTap func(info *WorkflowInfo, events []*shared.HistoryEvent)
+
+	// RateLimitStore coordinates a rate limit across processes, see WorkerOptions.ActivityRateLimitStore. Take takes a
+	// permit of the limit identified by key, which allows perSecond permits per second in total to all the callers of
+	// all processes, and returns 0 if the permit was taken, or how long to wait before trying again otherwise. It is
+	// called before every task, so it should be a single round trip to the store, e.g. a Redis script implementing the
+	// generic cell rate algorithm.
+	RateLimitStore interface {
+		Take(ctx context.Context, key string, perSecond float64) (time.Duration, error)
 	}
 
 	// WorkerBugPorts allows opt-in enabling of older, possibly buggy behavior, primarily intended to allow temporarily
@@ -387,5 +517,24 @@ func (o WorkerOptions) Validate() error {
 	if !o.DisableStickyExecution && (o.MaxConcurrentDecisionTaskPollers == 1 || o.MinConcurrentDecisionTaskPollers == 1) {
 		return fmt.Errorf("DecisionTaskPollers must be >= 2 or use default value")
 	}
+	for _, threshold := range o.HistoryLimitWarningThresholds {
+		if threshold <= 0 || threshold > 1 {
+			return fmt.Errorf("HistoryLimitWarningThresholds must be in the (0, 1] range, got %v", threshold)
+		}
+	}
+	if o.HistoryCountLimit < 0 || o.HistorySizeLimit < 0 {
+		return fmt.Errorf("HistoryCountLimit and HistorySizeLimit must not be negative")
+	}
+	for _, capability := range o.RequiredServiceCapabilities {
+		if _, ok := serviceCapabilityHints[capability]; !ok {
+			return fmt.Errorf("unknown service capability %q in RequiredServiceCapabilities", capability)
+		}
+	}
+	if err := o.ActivityPriorityTaskLists.validate(); err != nil {
+		return fmt.Errorf("invalid ActivityPriorityTaskLists: %w", err)
+	}
+	if err := validateTaskLists(o.AdditionalActivityTaskLists); err != nil {
+		return fmt.Errorf("invalid AdditionalActivityTaskLists: %w", err)
+	}
 	return nil
 }
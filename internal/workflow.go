@@ -24,6 +24,7 @@ package internal
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 	"reflect"
 	"strings"
 	"time"
@@ -38,12 +39,14 @@ import (
 
 var (
 	errDomainNotSet                  = errors.New("domain is not set")
+	errDomainInvalid                 = errors.New("domain must not contain whitespace")
 	errTaskListNotSet                = errors.New("task list is not set")
 	errWorkflowIDNotSet              = errors.New("workflowId is not set")
 	errLocalActivityParamsBadRequest = errors.New("missing local activity parameters through context, check LocalActivityOptions")
 	errActivityParamsBadRequest      = errors.New("missing activity parameters through context, check ActivityOptions")
 	errWorkflowOptionBadRequest      = errors.New("missing workflow options through context, check WorkflowOptions")
 	errSearchAttributesNotSet        = errors.New("search attributes is empty")
+	errAwaitTimeoutNotPositive       = errors.New("timeout of AwaitWithTimeout must be positive")
 )
 
 type (
@@ -181,6 +184,36 @@ type (
 		Close()
 	}
 
+	// BufferedChannel is a Channel whose buffer can be adjusted while the workflow is running.
+	//
+	// Use workflow.NewResizableBufferedChannel(ctx, size) or workflow.NewNamedResizableBufferedChannel(ctx, name, size)
+	// to create one.
+	// Resizing and drop callbacks are in-memory workflow state: they are not recorded in history, so they must be
+	// driven by deterministic workflow code like any other channel operation.
+	BufferedChannel interface {
+		Channel
+
+		// Len returns the number of values currently held in the buffer.
+		Len() int
+
+		// Cap returns the current capacity of the buffer.
+		Cap() int
+
+		// Resize changes the capacity of the buffer.
+		//
+		// Growing the buffer moves values of blocked senders into it, in the order they were sent.
+		// Shrinking the buffer below Len does not drop any values, subsequent sends block (or fail, for SendAsync)
+		// until enough values are received.
+		// Resize panics if size is negative.
+		Resize(size int)
+
+		// OnDrop registers a callback which is invoked with the value every time SendAsync fails because the buffer is
+		// full and no receiver is waiting, e.g. to count or log signals that were shed under load.
+		// Only one callback is kept, a nil callback removes it.
+		// The callback runs synchronously inside SendAsync and must not block.
+		OnDrop(callback func(v interface{}))
+	}
+
 	// Selector must be used in workflows instead of a native Go select statement.
 	//
 	// Use workflow.NewSelector(ctx) to create a Selector instance, and then add cases to it with its methods.
@@ -285,6 +318,16 @@ type (
 		//  	s.Select(ctx)
 		//  }
 		AddFuture(future Future, f func(f Future)) Selector
+		// AddReceiveWithPriority is AddReceive with a priority, see AddFutureWithPriority.
+		AddReceiveWithPriority(c Channel, priority int, f func(c Channel, ok bool)) Selector
+		// AddFutureWithPriority is AddFuture with a priority. When several conditions are met as Select is called, the
+		// callback of the one with the highest priority is invoked, instead of the first one added, so that conditions
+		// added later are not starved by busy ones added earlier. Conditions of the same priority are chosen in the
+		// order they were added, and conditions added without a priority have the priority 0.
+		//
+		// When no condition is met as Select is called, the callback of the first one to be met is invoked, whatever
+		// its priority.
+		AddFutureWithPriority(future Future, priority int, f func(f Future)) Selector
 		// AddDefault adds a default branch to the selector.
 		// f is invoked immediately when none of the other conditions (AddReceive, AddSend, AddFuture) are met for a
 		// Select call.
@@ -327,6 +370,32 @@ type (
 		Wait(ctx Context)
 	}
 
+	// Semaphore is a weighted semaphore to bound the concurrency of the coroutines of a workflow, e.g. the number of
+	// its in-flight activities or child workflows. It must be used instead of golang.org/x/sync/semaphore by
+	// workflow code. Waiters acquire permits in the order they started waiting. Use workflow.NewSemaphore(ctx, size)
+	// method to create a new Semaphore instance.
+	Semaphore interface {
+		// Acquire blocks until n permits are available and takes them. It returns ctx.Err() without taking any
+		// permit if ctx is canceled first, and an error if n is larger than the size of the semaphore.
+		Acquire(ctx Context, n int64) error
+		// TryAcquire takes n permits if they are available without waiting, and reports whether it did.
+		TryAcquire(n int64) bool
+		// Release returns n permits. It panics if more permits are released than are held.
+		Release(n int64)
+	}
+
+	// ErrGroup runs a group of coroutines working on subtasks of a common task and collects their errors, like
+	// golang.org/x/sync/errgroup which must not be used by workflow code. The first error cancels the context of
+	// the group. Use workflow.NewErrGroup(ctx) method to create a new ErrGroup instance.
+	ErrGroup interface {
+		// Go runs f in a new coroutine with the context of the group. The first f to return an error cancels the
+		// context of the group, and its error is returned by Wait.
+		Go(f func(ctx Context) error)
+		// Wait blocks until all the coroutines started with Go returned, then cancels the context of the group and
+		// returns the first error, if any.
+		Wait(ctx Context) error
+	}
+
 	// Future represents the result of an asynchronous computation.
 	Future interface {
 		// Get blocks until the future is ready.
@@ -359,6 +428,19 @@ type (
 		//  err := f.Get(ctx, &out) // err can only be the Future's contained error
 		Get(ctx Context, valuePtr interface{}) error
 
+		// GetWithTimeout blocks until the future is ready or the timeout elapses, whichever happens first, without
+		// the Selector otherwise needed to race the future against a timer. When the future is ready it returns true
+		// and the error of Get, after assigning the value to valuePtr like Get does. When the timeout elapses it
+		// returns false and no error, and the future can still be waited on. It returns false and *CanceledError
+		// if ctx is canceled first, and false and an error if the timeout is not positive.
+		//
+		//  var out string
+		//  ok, err := f.GetWithTimeout(ctx, time.Minute, &out)
+		//  if err == nil && !ok {
+		//  	// the future is not ready after a minute
+		//  }
+		GetWithTimeout(ctx Context, timeout time.Duration, valuePtr interface{}) (ok bool, err error)
+
 		// IsReady will return true Get is guaranteed to not block.
 		IsReady() bool
 	}
@@ -383,6 +465,8 @@ type (
 		//  if err := childWorkflowFuture.GetChildWorkflowExecution().Get(ctx, &childWE); err == nil {
 		//      // child workflow started, you can use childWE to get the WorkflowID and RunID of child workflow
 		//  }
+		// The RunID is the one of the first run of the child. It does not change when the child continues-as-new, use
+		// GetChildWorkflowState to get the current run.
 		GetChildWorkflowExecution() Future
 
 		// SignalWorkflowByID sends a signal to the child workflow. This call will block until child workflow is started.
@@ -626,6 +710,46 @@ func Await(ctx Context, condition func() bool) error {
 	return nil
 }
 
+// AwaitWithTimeout blocks the calling thread until condition() returns true or the timeout expires.
+// Returns true if the condition was met, false if the timeout expired first.
+// Returns CanceledError if the ctx is canceled, and an error if the timeout is not positive.
+func AwaitWithTimeout(ctx Context, timeout time.Duration, condition func() bool) (bool, error) {
+	if timeout <= 0 {
+		return false, errAwaitTimeoutNotPositive
+	}
+	state := getState(ctx)
+	defer state.unblocked()
+
+	if condition() {
+		return true, nil
+	}
+	timerCtx, cancel := WithCancel(ctx)
+	defer cancel()
+	timer := NewTimer(timerCtx, timeout)
+	for !condition() {
+		doneCh := ctx.Done()
+		if doneCh != nil {
+			if _, more := doneCh.ReceiveAsyncWithMoreFlag(nil); !more {
+				return false, NewCanceledError("AwaitWithTimeout context cancelled")
+			}
+		}
+		if timer.IsReady() {
+			// the timer fails instead of firing if it could not be started
+			return false, timer.Get(ctx, nil)
+		}
+		state.yield("AwaitWithTimeout")
+	}
+	return true, nil
+}
+
+// getWithTimeout implements Future.GetWithTimeout for any future, waiting for it with AwaitWithTimeout.
+func getWithTimeout(ctx Context, future Future, timeout time.Duration, valuePtr interface{}) (bool, error) {
+	if ok, err := AwaitWithTimeout(ctx, timeout, future.IsReady); !ok || err != nil {
+		return false, err
+	}
+	return true, future.Get(ctx, valuePtr)
+}
+
 // NewChannel create new Channel instance
 func NewChannel(ctx Context) Channel {
 	state := getState(ctx)
@@ -642,13 +766,29 @@ func NewNamedChannel(ctx Context, name string) Channel {
 
 // NewBufferedChannel create new buffered Channel instance
 func NewBufferedChannel(ctx Context, size int) Channel {
-	env := getWorkflowEnvironment(ctx)
-	return &channelImpl{size: size, dataConverter: getDataConverterFromWorkflowContext(ctx), env: env}
+	return NewResizableBufferedChannel(ctx, size)
 }
 
 // NewNamedBufferedChannel create new BufferedChannel instance with a given human readable name.
 // Name appears in stack traces that are blocked on this Channel.
 func NewNamedBufferedChannel(ctx Context, name string, size int) Channel {
+	return NewNamedResizableBufferedChannel(ctx, name, size)
+}
+
+// NewResizableBufferedChannel create new BufferedChannel instance, whose buffer can be resized.
+func NewResizableBufferedChannel(ctx Context, size int) BufferedChannel {
+	var name string
+	// buffered channels may be created outside of a coroutine, they are only named after the sequence within one
+	if state, ok := ctx.Value(coroutinesContextKey).(*coroutineState); ok && state.dispatcher.executing {
+		state.dispatcher.channelSequence++
+		name = fmt.Sprintf("chan-%v", state.dispatcher.channelSequence)
+	}
+	return NewNamedResizableBufferedChannel(ctx, name, size)
+}
+
+// NewNamedResizableBufferedChannel create new BufferedChannel instance, whose buffer can be resized, with a given
+// human readable name. Name appears in stack traces that are blocked on this Channel.
+func NewNamedResizableBufferedChannel(ctx Context, name string, size int) BufferedChannel {
 	env := getWorkflowEnvironment(ctx)
 	return &channelImpl{name: name, size: size, dataConverter: getDataConverterFromWorkflowContext(ctx), env: env}
 }
@@ -672,6 +812,18 @@ func NewWaitGroup(ctx Context) WaitGroup {
 	return &waitGroupImpl{future: f, settable: s}
 }
 
+// NewSemaphore creates a new Semaphore instance with size permits.
+func NewSemaphore(ctx Context, size int64) Semaphore {
+	return &semaphoreImpl{size: size}
+}
+
+// NewErrGroup creates a new ErrGroup instance, and the context of the group derived from ctx, which is canceled when
+// a coroutine of the group returns an error or when Wait returns.
+func NewErrGroup(ctx Context) (ErrGroup, Context) {
+	groupCtx, cancel := WithCancel(ctx)
+	return &errGroupImpl{ctx: groupCtx, cancel: cancel, wg: NewWaitGroup(ctx)}, groupCtx
+}
+
 // Go creates a new coroutine. It has similar semantic to goroutine in a context of the workflow.
 func Go(ctx Context, f func(ctx Context)) {
 	state := getState(ctx)
@@ -692,6 +844,13 @@ func NewFuture(ctx Context) (Future, Settable) {
 	return impl, impl
 }
 
+// NewNamedFuture creates a new future with a given human readable name as well as associated Settable that is used to
+// set its value. Name appears in stack traces that are blocked on this future.
+func NewNamedFuture(ctx Context, name string) (Future, Settable) {
+	impl := &futureImpl{channel: NewNamedChannel(ctx, name).(*channelImpl)}
+	return impl, impl
+}
+
 func (wc *workflowEnvironmentInterceptor) ExecuteWorkflow(ctx Context, workflowType string, inputArgs ...interface{}) (results []interface{}) {
 	args := []reflect.Value{reflect.ValueOf(ctx)}
 	for _, arg := range inputArgs {
@@ -729,6 +888,10 @@ func (wc *workflowEnvironmentInterceptor) ExecuteWorkflow(ctx Context, workflowT
 //
 //	ctx := WithTaskList(ctx, "exampleTaskList")
 //
+// Or to override options of this call only, pass CallOption values along the arguments
+//
+//	f := ExecuteActivity(ctx, activity, arg, CallTaskList("exampleTaskList"))
+//
 // Input activity is either an activity name (string) or a function representing an activity that is getting scheduled.
 // Input args are the arguments that need to be passed to the scheduled activity.
 //
@@ -739,9 +902,18 @@ func (wc *workflowEnvironmentInterceptor) ExecuteWorkflow(ctx Context, workflowT
 //
 // ExecuteActivity returns Future with activity result or failure.
 func ExecuteActivity(ctx Context, activity interface{}, args ...interface{}) Future {
+	ctx, args, err := withActivityCallOptions(ctx, args)
+	if err != nil {
+		future, settable := NewFuture(ctx)
+		settable.SetError(err)
+		return future
+	}
 	i := getWorkflowInterceptor(ctx)
 	registry := getRegistryFromWorkflowContext(ctx)
 	activityType := getActivityFunctionName(registry, activity)
+	if hedging := getActivityHedgingOptions(ctx); hedging != nil {
+		return executeHedgedActivity(ctx, i, activityType, *hedging, args)
+	}
 	return i.ExecuteActivity(ctx, activityType, args...)
 }
 
@@ -749,7 +921,7 @@ func (wc *workflowEnvironmentInterceptor) ExecuteActivity(ctx Context, typeName
 	// Validate type and its arguments.
 	dataConverter := getDataConverterFromWorkflowContext(ctx)
 	registry := getRegistryFromWorkflowContext(ctx)
-	future, settable := newDecodeFuture(ctx, typeName)
+	future, settable := newNamedDecodeFuture(ctx, "activity:"+typeName, typeName)
 	activityType, err := getValidatedActivityFunction(typeName, args, registry)
 	if err != nil {
 		settable.Set(nil, err)
@@ -761,6 +933,15 @@ func (wc *workflowEnvironmentInterceptor) ExecuteActivity(ctx Context, typeName
 		settable.Set(nil, err)
 		return future
 	}
+	var activityID string
+	if options.ActivityID != nil {
+		activityID = *options.ActivityID
+	}
+	releaseActivityID, err := reservePendingCallID(ctx, callIDKindActivity, activityID)
+	if err != nil {
+		settable.Set(nil, err)
+		return future
+	}
 
 	// Validate session state.
 	if sessionInfo := getSessionInfo(ctx); sessionInfo != nil {
@@ -798,6 +979,7 @@ func (wc *workflowEnvironmentInterceptor) ExecuteActivity(ctx Context, typeName
 	ctxDone, cancellable := ctx.Done().(*channelImpl)
 	cancellationCallback := &receiveCallback{}
 	a := getWorkflowEnvironment(ctx).ExecuteActivity(params, func(r []byte, e error) {
+		releaseActivityID()
 		settable.Set(r, e)
 		if cancellable {
 			// future is done, we don't need the cancellation callback anymore.
@@ -868,7 +1050,7 @@ func (wc *workflowEnvironmentInterceptor) ExecuteLocalActivity(ctx Context, acti
 		panic("ExecuteLocalActivity: Expected context key " + localActivityFnContextKey + " is missing")
 	}
 
-	future, settable := newDecodeFuture(ctx, activityFn)
+	future, settable := newNamedDecodeFuture(ctx, "local-activity:"+activityType, activityFn)
 	if err := validateFunctionArgs(activityFn, args, false); err != nil {
 		settable.Set(nil, err)
 		return future
@@ -968,13 +1150,25 @@ func (wc *workflowEnvironmentInterceptor) scheduleLocalActivity(ctx Context, par
 //	 ctx := WithChildWorkflowOptions(ctx, cwo)
 //
 // Input childWorkflow is either a workflow name or a workflow function that is getting scheduled.
-// Input args are the arguments that need to be passed to the child workflow function represented by childWorkflow.
+// Input args are the arguments that need to be passed to the child workflow function represented by childWorkflow,
+// and CallOption values overriding the options of this call only, see CallWorkflowID.
 // If the child workflow failed to complete then the future get error would indicate the failure and it can be one of
 // CustomError, TimeoutError, CanceledError, GenericError.
 // You can cancel the pending child workflow using context(workflow.WithCancel(ctx)) and that will fail the workflow with
 // error CanceledError.
 // ExecuteChildWorkflow returns ChildWorkflowFuture.
 func ExecuteChildWorkflow(ctx Context, childWorkflow interface{}, args ...interface{}) ChildWorkflowFuture {
+	ctx, args, err := withChildWorkflowCallOptions(ctx, args)
+	if err != nil {
+		mainFuture, mainSettable := newDecodeFuture(ctx, childWorkflow)
+		executionFuture, executionSettable := NewFuture(ctx)
+		mainSettable.SetError(err)
+		executionSettable.SetError(err)
+		return &childWorkflowFutureImpl{
+			decodeFutureImpl: mainFuture.(*decodeFutureImpl),
+			executionFuture:  executionFuture.(*futureImpl),
+		}
+	}
 	i := getWorkflowInterceptor(ctx)
 	env := getWorkflowEnvironment(ctx)
 	workflowType := getWorkflowFunctionName(env.GetRegistry(), childWorkflow)
@@ -982,8 +1176,8 @@ func ExecuteChildWorkflow(ctx Context, childWorkflow interface{}, args ...interf
 }
 
 func (wc *workflowEnvironmentInterceptor) ExecuteChildWorkflow(ctx Context, childWorkflowType string, args ...interface{}) ChildWorkflowFuture {
-	mainFuture, mainSettable := newDecodeFuture(ctx, childWorkflowType)
-	executionFuture, executionSettable := NewFuture(ctx)
+	mainFuture, mainSettable := newNamedDecodeFuture(ctx, "child-workflow:"+childWorkflowType, childWorkflowType)
+	executionFuture, executionSettable := NewNamedFuture(ctx, "child-workflow-execution:"+childWorkflowType)
 	result := &childWorkflowFutureImpl{
 		decodeFutureImpl: mainFuture.(*decodeFutureImpl),
 		executionFuture:  executionFuture.(*futureImpl),
@@ -1037,7 +1231,14 @@ func (wc *workflowEnvironmentInterceptor) ExecuteChildWorkflow(ctx Context, chil
 	ctxDone, cancellable := ctx.Done().(*channelImpl)
 	cancellationCallback := &receiveCallback{}
 	shouldCancelAsync := false
+	releaseWorkflowID, err := reservePendingCallID(ctx, callIDKindChildWorkflow, options.workflowID)
+	if err != nil {
+		executionSettable.Set(nil, err)
+		mainSettable.Set(nil, err)
+		return result
+	}
 	err = getWorkflowEnvironment(ctx).ExecuteChildWorkflow(params, func(r []byte, e error) {
+		releaseWorkflowID()
 		mainSettable.Set(r, e)
 		if cancellable {
 			// future is done, we don't need cancellation anymore
@@ -1060,6 +1261,7 @@ func (wc *workflowEnvironmentInterceptor) ExecuteChildWorkflow(ctx Context, chil
 	})
 
 	if err != nil {
+		releaseWorkflowID()
 		executionSettable.Set(nil, err)
 		mainSettable.Set(nil, err)
 		return result
@@ -1100,6 +1302,15 @@ func getWorkflowHeader(ctx Context, ctxProps []ContextPropagator) *s.Header {
 	for _, ctxProp := range ctxProps {
 		ctxProp.InjectFromWorkflow(ctx, writer)
 	}
+	if options := getWorkflowEnvOptions(ctx); options != nil && options.defaultActivityOptions != nil {
+		// default activity options are inherited by continued-as-new runs and child workflows. They were decoded
+		// from the header of this workflow, so encoding them again can not fail.
+		_ = writeDefaultActivityOptionsHeader(header, options.defaultActivityOptions)
+	}
+	if options := getWorkflowEnvOptions(ctx); options != nil {
+		// headers are inherited by continued-as-new runs and child workflows like the default activity options
+		_ = writeWorkflowHeadersHeader(header, options.headers)
+	}
 	return header
 }
 
@@ -1185,6 +1396,15 @@ func GetHistoryCount(ctx Context) int64 {
 	return i.GetWorkflowInfo(ctx).HistoryCount
 }
 
+// GetDecisionTimeRemaining returns the approximate time left before the current decision task times out, measured
+// from when the worker started processing the task. CPU heavy workflow code can use it to yield, e.g. with Sleep, or to
+// move the rest of its work to local activities before the task times out. The result differs between the execution
+// and the replays of the workflow, so choices based on it must be recorded, e.g. with SideEffect, to keep the
+// workflow deterministic.
+func GetDecisionTimeRemaining(ctx Context) time.Duration {
+	return getWorkflowEnvironment(ctx).GetDecisionTimeRemaining()
+}
+
 // Now returns the current time in UTC. It corresponds to the time when the decision task is started or replayed.
 // Workflow needs to use this method to get the wall clock time instead of the one from the golang library.
 func Now(ctx Context) time.Time {
@@ -1258,6 +1478,24 @@ func (wc *workflowEnvironmentInterceptor) Sleep(ctx Context, d time.Duration) (e
 	return
 }
 
+// SleepWithJitter pauses the current workflow for at least the duration d plus a random jitter in [0, maxJitter).
+// The jitter is recorded with SideEffect, so the workflow sleeps for the same duration when it is replayed.
+// Use it to spread out timers of many workflows which would otherwise fire at the same time.
+// SleepWithJitter() returns nil if the duration is passed, or it returns *CanceledError if the ctx is canceled.
+// As the timer resolution is in seconds, a maxJitter of less than a second has no effect.
+func SleepWithJitter(ctx Context, d, maxJitter time.Duration) error {
+	var jitter time.Duration
+	if maxJitter > 0 {
+		err := SideEffect(ctx, func(ctx Context) interface{} {
+			return time.Duration(rand.Int63n(int64(maxJitter)))
+		}).Get(&jitter)
+		if err != nil {
+			return err
+		}
+	}
+	return Sleep(ctx, d+jitter)
+}
+
 // RequestCancelExternalWorkflow can be used to request cancellation of an external workflow.
 // Input workflowID is the workflow ID of target workflow.
 // Input runID indicates the instance of a workflow. Input runID is optional (default is ""). When runID is not specified,
@@ -1278,8 +1516,8 @@ func (wc *workflowEnvironmentInterceptor) RequestCancelExternalWorkflow(ctx Cont
 	options := getWorkflowEnvOptions(ctx1)
 	future, settable := NewFuture(ctx1)
 
-	if options.domain == nil || *options.domain == "" {
-		settable.Set(nil, errDomainNotSet)
+	if err := validateExternalWorkflowDomain(options.domain); err != nil {
+		settable.Set(nil, err)
 		return future
 	}
 
@@ -1302,6 +1540,16 @@ func (wc *workflowEnvironmentInterceptor) RequestCancelExternalWorkflow(ctx Cont
 	return future
 }
 
+func validateExternalWorkflowDomain(domain *string) error {
+	if domain == nil || *domain == "" {
+		return errDomainNotSet
+	}
+	if strings.ContainsAny(*domain, " \t\r\n") {
+		return errDomainInvalid
+	}
+	return nil
+}
+
 // SignalExternalWorkflow can be used to send signal info to an external workflow.
 // Input workflowID is the workflow ID of target workflow.
 // Input runID indicates the instance of a workflow. Input runID is optional (default is ""). When runID is not specified,
@@ -1328,8 +1576,8 @@ func signalExternalWorkflow(ctx Context, workflowID, runID, signalName string, a
 	options := getWorkflowEnvOptions(ctx1)
 	future, settable := NewFuture(ctx1)
 
-	if options.domain == nil || *options.domain == "" {
-		settable.Set(nil, errDomainNotSet)
+	if err := validateExternalWorkflowDomain(options.domain); err != nil {
+		settable.Set(nil, err)
 		return future
 	}
 
@@ -1457,6 +1705,13 @@ func WithWorkflowID(ctx Context, workflowID string) Context {
 	return ctx1
 }
 
+// WithParentClosePolicy adds a ParentClosePolicy to the context.
+func WithParentClosePolicy(ctx Context, policy ParentClosePolicy) Context {
+	ctx1 := setWorkflowEnvOptionsIfNotExist(ctx)
+	getWorkflowEnvOptions(ctx1).parentClosePolicy = policy
+	return ctx1
+}
+
 // WithExecutionStartToCloseTimeout adds a workflow execution timeout to the context.
 // The current timeout resolution implementation is in seconds and uses math.Ceil(d.Seconds()) as the duration. But is
 // subjected to change in the future.
@@ -1649,6 +1904,8 @@ func (wc *workflowEnvironmentInterceptor) GetVersion(ctx Context, changeID strin
 // context to do things like workflow.NewChannel(), workflow.Go() or to call any workflow blocking functions like
 // Channel.Get() or Future.Get(). Trying to do so in query handler code will fail the query and client will receive
 // QueryFailedError.
+// The handler may declare a QueryContext as its first parameter to read the workflow info, the workflow time and
+// the state set by SetState, e.g. func(ctx workflow.QueryContext, field string) (string, error).
 // Example of workflow code that support query type "current_state":
 //
 //	func MyWorkflow(ctx workflow.Context, input string) error {
@@ -1687,9 +1944,32 @@ func (wc *workflowEnvironmentInterceptor) SetQueryHandler(ctx Context, queryType
 	if strings.HasPrefix(queryType, "__") {
 		return errors.New("queryType starts with '__' is reserved for internal use")
 	}
+	if err := wc.env.GetNamingPolicies().validateQueryType(queryType); err != nil {
+		return err
+	}
 	return setQueryHandler(ctx, queryType, handler)
 }
 
+func (wc *workflowEnvironmentInterceptor) HandleSignal(ctx Context, signalName string, arg Value) {
+	encoded, ok := arg.(EncodedValue)
+	if !ok {
+		panic(fmt.Sprintf("unexpected payload type %T for signal: %v", arg, signalName))
+	}
+	// We don't want this code to be blocked ever, using sendAsync().
+	ch := getWorkflowEnvOptions(ctx).getSignalChannel(ctx, signalName).(*channelImpl)
+	if !ch.SendAsync(encoded.value) {
+		panic(fmt.Sprintf("Exceeded channel buffer size for signal: %v", signalName))
+	}
+}
+
+func (wc *workflowEnvironmentInterceptor) HandleQuery(ctx Context, queryType string, args ...interface{}) (interface{}, error) {
+	handler, ok := getWorkflowEnvOptions(ctx).queryHandlers[queryType]
+	if !ok {
+		return nil, fmt.Errorf("unknown queryType %v", queryType)
+	}
+	return handler.invoke(args)
+}
+
 // IsReplaying returns whether the current workflow code is replaying.
 //
 // Warning! Never make decisions, like schedule activity/childWorkflow/timer or send/wait on future/channel, based on
@@ -1777,6 +2057,14 @@ func WithLocalActivityOptions(ctx Context, options LocalActivityOptions) Context
 	return ctx1
 }
 
+// WithActivityID adds an activity ID to the copy of the context. The ID must not be used by another pending activity
+// of the workflow, see IDCollisionError.
+func WithActivityID(ctx Context, activityID string) Context {
+	ctx1 := setActivityParametersIfNotExist(ctx)
+	getActivityOptions(ctx1).ActivityID = common.StringPtr(activityID)
+	return ctx1
+}
+
 // WithTaskList adds a task list to the copy of the context.
 // Note this shall not confuse with WithWorkflowTaskList. This is the tasklist for activities
 func WithTaskList(ctx Context, name string) Context {
@@ -0,0 +1,163 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+const activityProgressTimeout = 10 * time.Second
+
+// ErrActivityNotPending is returned by GetActivityProgress when the workflow has no scheduled or running activity
+// with the requested ID, e.g. because it already completed.
+var ErrActivityNotPending = errors.New("activity is not pending")
+
+type (
+	// ActivityProgress is the state of a scheduled or running activity of the workflow, see GetActivityProgress.
+	ActivityProgress struct {
+		ActivityID   string
+		ActivityType string
+		// Started is true once a worker picked up the current attempt of the activity.
+		Started bool
+		// CancelRequested is true once the workflow requested the cancellation of the activity.
+		CancelRequested bool
+		// Attempt of the activity, starting from 0.
+		Attempt int32
+		// LastStartedTime is the time the current attempt was started, zero if it was not started yet.
+		LastStartedTime time.Time
+		// LastHeartbeatTime is the time of the last heartbeat of the activity, zero if it did not heartbeat yet.
+		LastHeartbeatTime time.Time
+
+		heartbeatDetails Values
+	}
+
+	// activityProgressRecord is the result of the local activity reading the progress, which is recorded in the
+	// history of the workflow.
+	activityProgressRecord struct {
+		Pending           bool
+		ActivityID        string
+		ActivityType      string
+		State             s.PendingActivityState
+		Attempt           int32
+		LastStartedTime   time.Time
+		LastHeartbeatTime time.Time
+		HeartbeatDetails  []byte
+	}
+)
+
+// HasHeartbeatDetails returns if the activity recorded details with its last heartbeat.
+func (p *ActivityProgress) HasHeartbeatDetails() bool {
+	return p.heartbeatDetails != nil && p.heartbeatDetails.HasValues()
+}
+
+// HeartbeatDetails extracts the strong typed details of the last heartbeat of the activity.
+// If there is no details, it will return ErrNoData.
+func (p *ActivityProgress) HeartbeatDetails(d ...interface{}) error {
+	if !p.HasHeartbeatDetails() {
+		return ErrNoData
+	}
+	return p.heartbeatDetails.Get(d...)
+}
+
+// GetActivityProgress returns the state and the latest heartbeat details of a scheduled or running activity of the
+// workflow. A workflow can call it periodically to keep the progress reported by its query handlers up to date, or to
+// cancel the activity when it is stalled. Set ActivityOptions.ActivityID to know the ID of the activity:
+//
+//	ctx = WithActivityOptions(ctx, ActivityOptions{ActivityID: "import", HeartbeatTimeout: time.Minute, ...})
+//	future := ExecuteActivity(ctx, importActivity, file)
+//	...
+//	progress, err := GetActivityProgress(ctx, "import")
+//	var rowsImported int
+//	if err == nil && progress.HasHeartbeatDetails() {
+//		err = progress.HeartbeatDetails(&rowsImported)
+//	}
+//
+// Heartbeats are not part of the history of the workflow, so the progress is read from the Cadence service by a local
+// activity, which records the result in the history. It blocks until the local activity completes and must not be
+// called from query handlers. It returns ErrActivityNotPending if the workflow has no such activity.
+func GetActivityProgress(ctx Context, activityID string) (*ActivityProgress, error) {
+	ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: activityProgressTimeout})
+	var record activityProgressRecord
+	if err := ExecuteLocalActivity(ctx, getActivityProgressLocalActivity, activityID).Get(ctx, &record); err != nil {
+		return nil, err
+	}
+	if !record.Pending {
+		return nil, ErrActivityNotPending
+	}
+	return &ActivityProgress{
+		ActivityID:        record.ActivityID,
+		ActivityType:      record.ActivityType,
+		Started:           record.State != s.PendingActivityStateScheduled,
+		CancelRequested:   record.State == s.PendingActivityStateCancelRequested,
+		Attempt:           record.Attempt,
+		LastStartedTime:   record.LastStartedTime,
+		LastHeartbeatTime: record.LastHeartbeatTime,
+		heartbeatDetails:  newEncodedValues(record.HeartbeatDetails, getDataConverterFromWorkflowContext(ctx)),
+	}, nil
+}
+
+// getActivityProgressLocalActivity reads the pending activity of the calling workflow with the worker's service client.
+func getActivityProgressLocalActivity(ctx context.Context, activityID string) (*activityProgressRecord, error) {
+	env := getActivityEnv(ctx)
+	if env.workflowService == nil {
+		return nil, errors.New("activity progress is not available without a service client")
+	}
+	request := &s.DescribeWorkflowExecutionRequest{
+		Domain: common.StringPtr(env.workflowDomain),
+		Execution: &s.WorkflowExecution{
+			WorkflowId: common.StringPtr(env.workflowExecution.ID),
+			RunId:      common.StringPtr(env.workflowExecution.RunID),
+		},
+	}
+	tchCtx, cancel, opt := newChannelContext(ctx, env.featureFlags)
+	defer cancel()
+	response, err := env.workflowService.DescribeWorkflowExecution(tchCtx, request, opt...)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range response.GetPendingActivities() {
+		if info.GetActivityID() == activityID {
+			return &activityProgressRecord{
+				Pending:           true,
+				ActivityID:        info.GetActivityID(),
+				ActivityType:      info.GetActivityType().GetName(),
+				State:             info.GetState(),
+				Attempt:           info.GetAttempt(),
+				LastStartedTime:   unixNanoToTime(info.GetLastStartedTimestamp()),
+				LastHeartbeatTime: unixNanoToTime(info.GetLastHeartbeatTimestamp()),
+				HeartbeatDetails:  info.HeartbeatDetails,
+			}, nil
+		}
+	}
+	return &activityProgressRecord{}, nil
+}
+
+func unixNanoToTime(timestamp int64) time.Time {
+	if timestamp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, timestamp)
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestGetActivityProgress(t *testing.T) {
+	release := make(chan struct{})
+	importActivity := func(ctx context.Context) error {
+		RecordActivityHeartbeat(ctx, 42)
+		<-release
+		return nil
+	}
+	workflowFn := func(ctx Context) (int, error) {
+		ctx = WithActivityOptions(ctx, ActivityOptions{
+			ActivityID:             "import",
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			HeartbeatTimeout:       time.Minute,
+		})
+		future := ExecuteActivity(ctx, importActivity)
+		GetSignalChannel(ctx, "heartbeat").Receive(ctx, nil)
+
+		progress, err := GetActivityProgress(ctx, "import")
+		if err != nil {
+			return 0, err
+		}
+		if !progress.Started || progress.LastHeartbeatTime.IsZero() {
+			return 0, fmt.Errorf("unexpected progress %+v", progress)
+		}
+		var rows int
+		if err := progress.HeartbeatDetails(&rows); err != nil {
+			return 0, err
+		}
+
+		close(release)
+		if err := future.Get(ctx, nil); err != nil {
+			return 0, err
+		}
+		if _, err := GetActivityProgress(ctx, "import"); err != ErrActivityNotPending {
+			return 0, fmt.Errorf("unexpected error for a completed activity: %v", err)
+		}
+		return rows, nil
+	}
+
+	env := newTestWorkflowEnv(t)
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(importActivity)
+	env.SetOnActivityHeartbeatListener(func(*ActivityInfo, Values) {
+		env.SignalWorkflow("heartbeat", nil)
+	})
+	env.ExecuteWorkflow(workflowFn)
+
+	require.NoError(t, env.GetWorkflowError())
+	var rows int
+	require.NoError(t, env.GetWorkflowResult(&rows))
+	assert.Equal(t, 42, rows)
+}
+
+func TestGetActivityProgressLocalActivity(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(mockCtrl)
+	ctx := context.WithValue(context.Background(), activityEnvContextKey, &activityEnvironment{
+		workflowDomain:    "domain",
+		workflowExecution: WorkflowExecution{ID: "workflow", RunID: "run"},
+		workflowService:   service,
+	})
+	details, err := encodeArg(getDefaultDataConverter(), 42)
+	require.NoError(t, err)
+	heartbeat := time.Unix(1700000000, 0)
+	service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.DescribeWorkflowExecutionResponse{
+			PendingActivities: []*shared.PendingActivityInfo{
+				{ActivityID: common.StringPtr("other")},
+				{
+					ActivityID:             common.StringPtr("import"),
+					ActivityType:           &shared.ActivityType{Name: common.StringPtr("importActivity")},
+					State:                  shared.PendingActivityStateCancelRequested.Ptr(),
+					Attempt:                common.Int32Ptr(2),
+					HeartbeatDetails:       details,
+					LastHeartbeatTimestamp: common.Int64Ptr(heartbeat.UnixNano()),
+				},
+			},
+		}, nil).
+		Do(func(_ interface{}, req *shared.DescribeWorkflowExecutionRequest, _ ...interface{}) {
+			assert.Equal(t, "domain", req.GetDomain())
+			assert.Equal(t, "workflow", req.GetExecution().GetWorkflowId())
+			assert.Equal(t, "run", req.GetExecution().GetRunId())
+		}).Times(2)
+
+	record, err := getActivityProgressLocalActivity(ctx, "import")
+	require.NoError(t, err)
+	assert.Equal(t, &activityProgressRecord{
+		Pending:           true,
+		ActivityID:        "import",
+		ActivityType:      "importActivity",
+		State:             shared.PendingActivityStateCancelRequested,
+		Attempt:           2,
+		LastHeartbeatTime: heartbeat,
+		HeartbeatDetails:  details,
+	}, record)
+
+	record, err = getActivityProgressLocalActivity(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, record.Pending)
+
+	_, err = getActivityProgressLocalActivity(context.WithValue(context.Background(), activityEnvContextKey, &activityEnvironment{}), "import")
+	assert.Error(t, err)
+}
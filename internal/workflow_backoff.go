@@ -0,0 +1,136 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffPolicy describes the exponential backoff computed by Backoff and BackoffDuration.
+type BackoffPolicy struct {
+	// Backoff interval of the first attempt. Required, no default value.
+	InitialInterval time.Duration
+
+	// Coefficient used to calculate the next backoff interval.
+	// The next interval is previous interval multiplied by this coefficient.
+	// Must be 1 or larger. Default is 2.0.
+	BackoffCoefficient float64
+
+	// Maximum backoff interval. Exponential backoff leads to interval increase.
+	// This value is the cap of the interval. Default is 100x of initial interval.
+	MaximumInterval time.Duration
+
+	// Portion of the interval which is randomized, between 0 and 1: the interval is shortened by up to this
+	// fraction, to spread out the retries of many workflows. Default is 0, no jitter.
+	JitterFraction float64
+}
+
+// Backoff pauses the current workflow for the backoff interval of the attempt, starting from 0, of the policy. Use
+// it in retry loops which are not covered by the retry policies of activities and child workflows, e.g. waiting
+// for a signal or retrying a group of child workflows:
+//
+//	for attempt := 0; ; attempt++ {
+//		if err := ExecuteChildWorkflow(ctx, childFn).Get(ctx, nil); err == nil {
+//			break
+//		}
+//		if err := Backoff(ctx, policy, attempt); err != nil {
+//			return err
+//		}
+//	}
+//
+// The jitter is derived from a seed recorded once per workflow with SideEffect, so the workflow sleeps for the same
+// durations when it is replayed.
+// Backoff() returns nil if the interval is passed, or it returns *CanceledError if the ctx is canceled.
+func Backoff(ctx Context, policy BackoffPolicy, attempt int) error {
+	d, err := BackoffDuration(ctx, policy, attempt)
+	if err != nil {
+		return err
+	}
+	return Sleep(ctx, d)
+}
+
+// BackoffDuration returns the backoff interval of the attempt, starting from 0, of the policy, without sleeping.
+func BackoffDuration(ctx Context, policy BackoffPolicy, attempt int) (time.Duration, error) {
+	if err := policy.validate(); err != nil {
+		return 0, err
+	}
+	if attempt < 0 {
+		return 0, errors.New("backoff attempt must not be negative")
+	}
+	coefficient := policy.BackoffCoefficient
+	if coefficient == 0 {
+		coefficient = 2.0
+	}
+	maximumInterval := policy.MaximumInterval
+	if maximumInterval == 0 {
+		maximumInterval = 100 * policy.InitialInterval
+	}
+
+	interval := time.Duration(float64(policy.InitialInterval) * math.Pow(coefficient, float64(attempt)))
+	if interval <= 0 || interval > maximumInterval {
+		// math.Pow() could overflow
+		interval = maximumInterval
+	}
+	if policy.JitterFraction == 0 {
+		return interval, nil
+	}
+
+	seed, err := getBackoffSeed(ctx)
+	if err != nil {
+		return 0, err
+	}
+	r := rand.New(rand.NewSource(seed + int64(attempt)))
+	jitter := time.Duration(r.Float64() * policy.JitterFraction * float64(interval))
+	return interval - jitter, nil
+}
+
+func (p BackoffPolicy) validate() error {
+	if p.InitialInterval <= 0 {
+		return errors.New("backoff policy InitialInterval must be positive")
+	}
+	if p.BackoffCoefficient != 0 && p.BackoffCoefficient < 1 {
+		return errors.New("backoff policy BackoffCoefficient must be 1 or larger")
+	}
+	if p.MaximumInterval < 0 {
+		return errors.New("backoff policy MaximumInterval must not be negative")
+	}
+	if p.JitterFraction < 0 || p.JitterFraction > 1 {
+		return errors.New("backoff policy JitterFraction must be between 0 and 1")
+	}
+	return nil
+}
+
+// getBackoffSeed returns the seed of the backoff jitter of the workflow, recording it with SideEffect on first use.
+func getBackoffSeed(ctx Context) (int64, error) {
+	dispatcher := getState(ctx).dispatcher
+	if dispatcher.backoffSeed != nil {
+		return *dispatcher.backoffSeed, nil
+	}
+	seed, err := recordRandomSeed(ctx)
+	if err != nil {
+		return 0, err
+	}
+	dispatcher.backoffSeed = &seed
+	return seed, nil
+}
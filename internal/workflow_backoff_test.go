@@ -0,0 +1,101 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.ExecuteWorkflow(func(ctx Context) ([]time.Duration, error) {
+		policy := BackoffPolicy{InitialInterval: time.Second, MaximumInterval: 10 * time.Second}
+		var slept []time.Duration
+		for attempt := 0; attempt < 5; attempt++ {
+			start := Now(ctx)
+			if err := Backoff(ctx, policy, attempt); err != nil {
+				return nil, err
+			}
+			slept = append(slept, Now(ctx).Sub(start))
+		}
+		return slept, nil
+	})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var slept []time.Duration
+	require.NoError(t, env.GetWorkflowResult(&slept))
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}, slept)
+}
+
+func TestBackoffDuration_Jitter(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.ExecuteWorkflow(func(ctx Context) ([]time.Duration, error) {
+		policy := BackoffPolicy{InitialInterval: time.Minute, BackoffCoefficient: 1, JitterFraction: 0.5}
+		var durations []time.Duration
+		for i := 0; i < 2; i++ {
+			for attempt := 0; attempt < 3; attempt++ {
+				d, err := BackoffDuration(ctx, policy, attempt)
+				if err != nil {
+					return nil, err
+				}
+				durations = append(durations, d)
+			}
+		}
+		return durations, nil
+	})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var durations []time.Duration
+	require.NoError(t, env.GetWorkflowResult(&durations))
+	require.Len(t, durations, 6)
+	assert.Equal(t, durations[:3], durations[3:])
+	assert.NotEqual(t, durations[0], durations[1])
+	for _, d := range durations {
+		assert.True(t, d > 30*time.Second && d <= time.Minute, d)
+	}
+}
+
+func TestBackoffDuration_InvalidPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  BackoffPolicy
+		attempt int
+		err     string
+	}{
+		{"no initial interval", BackoffPolicy{}, 0, "backoff policy InitialInterval must be positive"},
+		{"coefficient", BackoffPolicy{InitialInterval: time.Second, BackoffCoefficient: 0.5}, 0, "backoff policy BackoffCoefficient must be 1 or larger"},
+		{"maximum interval", BackoffPolicy{InitialInterval: time.Second, MaximumInterval: -1}, 0, "backoff policy MaximumInterval must not be negative"},
+		{"jitter", BackoffPolicy{InitialInterval: time.Second, JitterFraction: 2}, 0, "backoff policy JitterFraction must be between 0 and 1"},
+		{"attempt", BackoffPolicy{InitialInterval: time.Second}, -1, "backoff attempt must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BackoffDuration(nil, tt.policy, tt.attempt)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// BatchFuture is a Future of a batch of futures created by NewBatchFuture. It is ready when all the futures of the
+// batch are ready. Like a Future created by NewFuture, it can be added to a Selector or chained to a Settable, which
+// is then set to the combined error of the futures, without a value.
+type BatchFuture interface {
+	Future
+	// GetFutures returns the futures of the batch, in the order of their factories, to access the result or the
+	// error of every item. A future is ready once the future created by its factory is ready. The returned slice must
+	// not be modified.
+	GetFutures() []Future
+}
+
+type batchFutureImpl struct {
+	*futureImpl // set when all the futures of the batch are ready
+	futures     []Future
+}
+
+var _ BatchFuture = (*batchFutureImpl)(nil)
+
+// NewBatchFuture calls the factories in order, running at most concurrency of the futures they create at the same
+// time, and returns a BatchFuture which is ready when all of them are ready. A factory is called once a future
+// created by a previous one is ready and frees a slot. If ctx is canceled before all the factories are called, the
+// futures of the remaining items fail with a CanceledError.
+func NewBatchFuture(ctx Context, concurrency int, factories []func(ctx Context) Future) (BatchFuture, error) {
+	if concurrency <= 0 {
+		return nil, fmt.Errorf("batch future concurrency must be positive, got %d", concurrency)
+	}
+
+	future, settable := NewFuture(ctx)
+	batch := &batchFutureImpl{futureImpl: future.(*futureImpl), futures: make([]Future, len(factories))}
+	settables := make([]Settable, len(factories))
+	for i := range factories {
+		batch.futures[i], settables[i] = NewFuture(ctx)
+	}
+
+	sem := NewSemaphore(ctx, int64(concurrency))
+	GoNamed(ctx, "batch-future", func(ctx Context) {
+		for i, factory := range factories {
+			err := ctx.Err()
+			if err == nil {
+				err = sem.Acquire(ctx, 1)
+			}
+			if err != nil {
+				for _, settable := range settables[i:] {
+					settable.Set(nil, err)
+				}
+				break
+			}
+			future := factory(ctx)
+			settables[i].Chain(future)
+			GoNamed(ctx, fmt.Sprintf("batch-future-%d", i), func(ctx Context) {
+				defer sem.Release(1)
+				_ = future.Get(ctx, nil)
+			})
+		}
+
+		var errs error
+		for _, future := range batch.futures {
+			errs = multierr.Append(errs, future.Get(ctx, nil))
+		}
+		settable.Set(nil, errs)
+	})
+	return batch, nil
+}
+
+// Get blocks until all the futures of the batch are ready. valuePtr is nil, or a pointer to a slice which is set to
+// the results of the futures, in the order of their factories. The errors of the futures are combined into the
+// returned error, use GetFutures to check the error of a single item.
+func (b *batchFutureImpl) Get(ctx Context, valuePtr interface{}) error {
+	var results reflect.Value
+	if valuePtr != nil {
+		v := reflect.ValueOf(valuePtr)
+		if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+			return errors.New("batch future value must be a pointer to a slice")
+		}
+		results = v.Elem()
+		results.Set(reflect.MakeSlice(results.Type(), len(b.futures), len(b.futures)))
+	}
+
+	// the futures of the batch are all ready once it is set
+	_ = b.futureImpl.Get(ctx, nil)
+	var errs error
+	for i, future := range b.futures {
+		var itemPtr interface{}
+		if results.IsValid() {
+			itemPtr = results.Index(i).Addr().Interface()
+		}
+		errs = multierr.Append(errs, future.Get(ctx, itemPtr))
+	}
+	return errs
+}
+
+// GetWithTimeout is like Get, but returns false without setting valuePtr when the futures of the batch are not all
+// ready after the timeout.
+func (b *batchFutureImpl) GetWithTimeout(ctx Context, timeout time.Duration, valuePtr interface{}) (bool, error) {
+	return getWithTimeout(ctx, b, timeout, valuePtr)
+}
+
+func (b *batchFutureImpl) GetFutures() []Future {
+	return b.futures
+}
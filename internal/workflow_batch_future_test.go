@@ -0,0 +1,218 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sleepingFactories returns factories of futures which are ready after i minutes, with the value i*10, or an error
+// for the items in failing. running tracks the futures which are not ready yet.
+func sleepingFactories(n int, failing map[int]bool, running *int, maxRunning *int) []func(ctx Context) Future {
+	var factories []func(ctx Context) Future
+	for i := 0; i < n; i++ {
+		i := i
+		factories = append(factories, func(ctx Context) Future {
+			*running++
+			*maxRunning = max(*maxRunning, *running)
+			future, settable := NewFuture(ctx)
+			Go(ctx, func(ctx Context) {
+				err := Sleep(ctx, time.Duration(i+1)*time.Minute)
+				*running--
+				if err == nil && failing[i] {
+					err = errors.New("item failed")
+				}
+				settable.Set(i*10, err)
+			})
+			return future
+		})
+	}
+	return factories
+}
+
+func TestBatchFuture(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	t.Run("bounded concurrency", func(t *testing.T) {
+		var running, maxRunning int
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) ([]int, error) {
+			batch, err := NewBatchFuture(ctx, 2, sleepingFactories(5, nil, &running, &maxRunning))
+			if err != nil {
+				return nil, err
+			}
+			var results []int
+			err = batch.Get(ctx, &results)
+			if !batch.IsReady() {
+				return nil, errors.New("batch is not ready")
+			}
+			return results, err
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var results []int
+		require.NoError(t, env.GetWorkflowResult(&results))
+		assert.Equal(t, []int{0, 10, 20, 30, 40}, results)
+		assert.Equal(t, 2, maxRunning)
+	})
+
+	t.Run("per item errors", func(t *testing.T) {
+		var running, maxRunning int
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) ([]string, error) {
+			batch, err := NewBatchFuture(ctx, 3, sleepingFactories(3, map[int]bool{1: true}, &running, &maxRunning))
+			if err != nil {
+				return nil, err
+			}
+			if err := batch.Get(ctx, nil); err == nil {
+				return nil, errors.New("batch did not fail")
+			}
+			var outcomes []string
+			for _, future := range batch.GetFutures() {
+				var result int
+				if err := future.Get(ctx, &result); err != nil {
+					outcomes = append(outcomes, err.Error())
+				} else {
+					outcomes = append(outcomes, "ok")
+				}
+			}
+			return outcomes, nil
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var outcomes []string
+		require.NoError(t, env.GetWorkflowResult(&outcomes))
+		assert.Equal(t, []string{"ok", "item failed", "ok"}, outcomes)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		var running, maxRunning int
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) ([]bool, error) {
+			batchCtx, cancel := WithCancel(ctx)
+			batch, err := NewBatchFuture(batchCtx, 1, sleepingFactories(3, nil, &running, &maxRunning))
+			if err != nil {
+				return nil, err
+			}
+			if err := Sleep(ctx, 30*time.Second); err != nil {
+				return nil, err
+			}
+			cancel()
+			_ = batch.Get(ctx, nil)
+			var canceled []bool
+			for _, future := range batch.GetFutures() {
+				var canceledErr *CanceledError
+				canceled = append(canceled, errors.As(future.Get(ctx, nil), &canceledErr))
+			}
+			return canceled, nil
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var canceled []bool
+		require.NoError(t, env.GetWorkflowResult(&canceled))
+		assert.Equal(t, []bool{true, true, true}, canceled)
+	})
+
+	t.Run("selector", func(t *testing.T) {
+		var running, maxRunning int
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) (string, error) {
+			batch, err := NewBatchFuture(ctx, 2, sleepingFactories(3, nil, &running, &maxRunning))
+			if err != nil {
+				return "", err
+			}
+			var winner string
+			NewSelector(ctx).
+				AddFuture(batch, func(f Future) {
+					winner = "batch"
+				}).
+				AddFuture(NewTimer(ctx, time.Hour), func(f Future) {
+					winner = "timer"
+				}).
+				Select(ctx)
+			return winner, nil
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var winner string
+		require.NoError(t, env.GetWorkflowResult(&winner))
+		assert.Equal(t, "batch", winner)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		var running, maxRunning int
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) ([]int, error) {
+			var inners []BatchFuture
+			inner := func(ctx Context) Future {
+				batch, err := NewBatchFuture(ctx, 1, sleepingFactories(2, nil, &running, &maxRunning))
+				if err != nil {
+					panic(err)
+				}
+				inners = append(inners, batch)
+				return batch
+			}
+			batch, err := NewBatchFuture(ctx, 2, []func(ctx Context) Future{inner, inner})
+			if err != nil {
+				return nil, err
+			}
+			if err := batch.Get(ctx, nil); err != nil {
+				return nil, err
+			}
+			var results []int
+			for _, future := range inners {
+				var items []int
+				if err := future.Get(ctx, &items); err != nil {
+					return nil, err
+				}
+				results = append(results, items...)
+			}
+			return results, nil
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var results []int
+		require.NoError(t, env.GetWorkflowResult(&results))
+		assert.Equal(t, []int{0, 10, 0, 10}, results)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) error {
+			if _, err := NewBatchFuture(ctx, 0, nil); err == nil {
+				return errors.New("zero concurrency is accepted")
+			}
+			batch, err := NewBatchFuture(ctx, 1, nil)
+			if err != nil {
+				return err
+			}
+			var result int
+			return batch.Get(ctx, &result)
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		assert.EqualError(t, env.GetWorkflowError(), "batch future value must be a pointer to a slice")
+	})
+}
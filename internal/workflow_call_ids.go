@@ -0,0 +1,85 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import "fmt"
+
+const (
+	callIDKindActivity      = "activity"
+	callIDKindChildWorkflow = "child workflow"
+)
+
+type (
+	// IDCollisionError is returned by ExecuteActivity and ExecuteChildWorkflow when the explicit activity ID or
+	// child workflow ID of the call, set with ActivityOptions.ActivityID, WithActivityID, CallActivityID,
+	// ChildWorkflowOptions.WorkflowID, WithWorkflowID or CallWorkflowID, is used by an activity or child workflow of
+	// the workflow which has not completed yet. IDs can be reused once their activity or child workflow completed.
+	IDCollisionError struct {
+		// Kind is "activity" or "child workflow".
+		Kind string
+		// ID is the colliding ID.
+		ID string
+	}
+
+	// pendingCallIDs are the explicit IDs of the activities and child workflows of a workflow which have not
+	// completed yet. It is shared by all the contexts of the workflow.
+	pendingCallIDs struct {
+		ids map[string]map[string]struct{} // by kind
+	}
+)
+
+func (e *IDCollisionError) Error() string {
+	return fmt.Sprintf("%s ID %q is already used by a pending %s of the workflow", e.Kind, e.ID, e.Kind)
+}
+
+func newPendingCallIDs() *pendingCallIDs {
+	return &pendingCallIDs{ids: make(map[string]map[string]struct{})}
+}
+
+// reserve marks the ID as pending until release is called, or returns an *IDCollisionError if it already is.
+func (p *pendingCallIDs) reserve(kind, id string) (release func(), err error) {
+	ids := p.ids[kind]
+	if ids == nil {
+		ids = make(map[string]struct{})
+		p.ids[kind] = ids
+	}
+	if _, ok := ids[id]; ok {
+		return nil, &IDCollisionError{Kind: kind, ID: id}
+	}
+	ids[id] = struct{}{}
+	released := false
+	return func() {
+		if !released {
+			released = true
+			delete(ids, id)
+		}
+	}, nil
+}
+
+// reservePendingCallID reserves the explicit ID of an activity or child workflow of the workflow of ctx. Calls
+// without an explicit ID are not checked.
+func reservePendingCallID(ctx Context, kind, id string) (release func(), err error) {
+	options := getWorkflowEnvOptions(ctx)
+	if id == "" || options == nil || options.pendingCallIDs == nil {
+		return func() {}, nil
+	}
+	return options.pendingCallIDs.reserve(kind, id)
+}
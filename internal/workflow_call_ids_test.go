@@ -0,0 +1,93 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingCallIDCollisions(t *testing.T) {
+	var testSuite WorkflowTestSuite
+	activityFn := func(ctx context.Context, name string) (string, error) {
+		return name, nil
+	}
+	childFn := func(ctx Context, name string) (string, error) {
+		return name, Sleep(ctx, time.Minute)
+	}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activityFn)
+	env.RegisterWorkflow(childFn)
+	env.ExecuteWorkflow(func(ctx Context) ([]string, error) {
+		ctx = WithActivityOptions(ctx, ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+		})
+		ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
+			ExecutionStartToCloseTimeout: time.Hour,
+			WorkflowIDReusePolicy:        WorkflowIDReusePolicyAllowDuplicate,
+		})
+		var results []string
+		get := func(f Future) {
+			var result string
+			err := f.Get(ctx, &result)
+			var collision *IDCollisionError
+			if errors.As(err, &collision) {
+				result = "collision:" + collision.Kind + ":" + collision.ID
+			} else if err != nil {
+				result = err.Error()
+			}
+			results = append(results, result)
+		}
+
+		first := ExecuteActivity(WithActivityID(ctx, "a"), activityFn, "first")
+		second := ExecuteActivity(ctx, activityFn, "second", CallActivityID("a"))
+		// the IDs of activities and child workflows are checked separately
+		firstChild := ExecuteChildWorkflow(ctx, childFn, "first child", CallWorkflowID("a"))
+		secondChild := ExecuteChildWorkflow(WithWorkflowID(ctx, "a"), childFn, "second child")
+		for _, f := range []Future{first, second, firstChild, secondChild} {
+			get(f)
+		}
+		// IDs can be reused once their activity or child workflow completed
+		get(ExecuteActivity(ctx, activityFn, "third", CallActivityID("a")))
+		get(ExecuteChildWorkflow(ctx, childFn, "third child", CallWorkflowID("a")))
+		return results, nil
+	})
+	require.NoError(t, env.GetWorkflowError())
+	var results []string
+	require.NoError(t, env.GetWorkflowResult(&results))
+	assert.Equal(t, []string{
+		"first",
+		"collision:activity:a",
+		"first child",
+		"collision:child workflow:a",
+		"third",
+		"third child",
+	}, results)
+
+	assert.EqualError(t, &IDCollisionError{Kind: callIDKindActivity, ID: "x"},
+		`activity ID "x" is already used by a pending activity of the workflow`)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/cadence/internal/common"
+)
+
+type (
+	// CallOption overrides an option of a single ExecuteActivity or ExecuteChildWorkflow call. Call options are
+	// passed after the activity or child workflow, in any position among its arguments, and are not passed to it:
+	//
+	//	f := workflow.ExecuteActivity(ctx, SendEmail, email, workflow.CallTaskList("emails"), workflow.CallActivityID(email.ID))
+	//
+	// They apply on top of the options of ctx, without a new context for every variation of the options.
+	CallOption interface {
+		applyToActivity(options *activityOptions) error
+		applyToChildWorkflow(options *workflowOptions) error
+	}
+
+	callOption struct {
+		name          string
+		activity      func(options *activityOptions)
+		childWorkflow func(options *workflowOptions)
+	}
+)
+
+// CallTaskList sets the task list of the activity or child workflow.
+func CallTaskList(name string) CallOption {
+	return &callOption{
+		name:          "CallTaskList",
+		activity:      func(options *activityOptions) { options.TaskListName = name },
+		childWorkflow: func(options *workflowOptions) { options.taskListName = common.StringPtr(name) },
+	}
+}
+
+// CallRetryPolicy sets the retry policy of the activity or child workflow.
+func CallRetryPolicy(retryPolicy RetryPolicy) CallOption {
+	return &callOption{
+		name:          "CallRetryPolicy",
+		activity:      func(options *activityOptions) { options.RetryPolicy = convertRetryPolicy(&retryPolicy) },
+		childWorkflow: func(options *workflowOptions) { options.retryPolicy = convertRetryPolicy(&retryPolicy) },
+	}
+}
+
+// CallStartToCloseTimeout sets the StartToCloseTimeout of the activity, or the ExecutionStartToCloseTimeout of the
+// child workflow.
+func CallStartToCloseTimeout(d time.Duration) CallOption {
+	return &callOption{
+		name:     "CallStartToCloseTimeout",
+		activity: func(options *activityOptions) { options.StartToCloseTimeoutSeconds = common.Int32Ceil(d.Seconds()) },
+		childWorkflow: func(options *workflowOptions) {
+			options.executionStartToCloseTimeoutSeconds = common.Int32Ptr(common.Int32Ceil(d.Seconds()))
+		},
+	}
+}
+
+// CallScheduleToCloseTimeout sets the ScheduleToCloseTimeout of the activity. It does not apply to child workflows.
+func CallScheduleToCloseTimeout(d time.Duration) CallOption {
+	return &callOption{
+		name:     "CallScheduleToCloseTimeout",
+		activity: func(options *activityOptions) { options.ScheduleToCloseTimeoutSeconds = common.Int32Ceil(d.Seconds()) },
+	}
+}
+
+// CallActivityID sets the ID of the activity. It does not apply to child workflows.
+func CallActivityID(activityID string) CallOption {
+	return &callOption{
+		name:     "CallActivityID",
+		activity: func(options *activityOptions) { options.ActivityID = common.StringPtr(activityID) },
+	}
+}
+
+// CallWorkflowID sets the workflow ID of the child workflow. It does not apply to activities.
+func CallWorkflowID(workflowID string) CallOption {
+	return &callOption{
+		name:          "CallWorkflowID",
+		childWorkflow: func(options *workflowOptions) { options.workflowID = workflowID },
+	}
+}
+
+func (o *callOption) applyToActivity(options *activityOptions) error {
+	if o.activity == nil {
+		return fmt.Errorf("%s does not apply to activities", o.name)
+	}
+	o.activity(options)
+	return nil
+}
+
+func (o *callOption) applyToChildWorkflow(options *workflowOptions) error {
+	if o.childWorkflow == nil {
+		return fmt.Errorf("%s does not apply to child workflows", o.name)
+	}
+	o.childWorkflow(options)
+	return nil
+}
+
+// splitCallOptions separates the call options from the arguments of a call.
+func splitCallOptions(args []interface{}) ([]interface{}, []CallOption) {
+	var callOptions []CallOption
+	for _, arg := range args {
+		if option, ok := arg.(CallOption); ok {
+			callOptions = append(callOptions, option)
+		}
+	}
+	if len(callOptions) == 0 {
+		return args, nil
+	}
+	remaining := make([]interface{}, 0, len(args)-len(callOptions))
+	for _, arg := range args {
+		if _, ok := arg.(CallOption); !ok {
+			remaining = append(remaining, arg)
+		}
+	}
+	return remaining, callOptions
+}
+
+// withActivityCallOptions returns a copy of ctx with the call options among args applied to its activity options,
+// and the remaining arguments.
+func withActivityCallOptions(ctx Context, args []interface{}) (Context, []interface{}, error) {
+	args, callOptions := splitCallOptions(args)
+	if len(callOptions) == 0 {
+		return ctx, args, nil
+	}
+	optionsCtx := setActivityParametersIfNotExist(ctx)
+	for _, option := range callOptions {
+		if err := option.applyToActivity(getActivityOptions(optionsCtx)); err != nil {
+			return ctx, args, err
+		}
+	}
+	return optionsCtx, args, nil
+}
+
+// withChildWorkflowCallOptions returns a copy of ctx with the call options among args applied to its child workflow
+// options, and the remaining arguments.
+func withChildWorkflowCallOptions(ctx Context, args []interface{}) (Context, []interface{}, error) {
+	args, callOptions := splitCallOptions(args)
+	if len(callOptions) == 0 {
+		return ctx, args, nil
+	}
+	optionsCtx := setWorkflowEnvOptionsIfNotExist(ctx)
+	for _, option := range callOptions {
+		if err := option.applyToChildWorkflow(getWorkflowEnvOptions(optionsCtx)); err != nil {
+			return ctx, args, err
+		}
+	}
+	return optionsCtx, args, nil
+}
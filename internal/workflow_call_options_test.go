@@ -0,0 +1,104 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallOptions(t *testing.T) {
+	var testSuite WorkflowTestSuite
+	activityFn := func(ctx context.Context, name string) (string, error) {
+		info := GetActivityInfo(ctx)
+		return name + "@" + info.TaskList + "/" + info.ActivityID, nil
+	}
+	childFn := func(ctx Context, name string) (string, error) {
+		info := GetWorkflowInfo(ctx)
+		return name + "@" + info.TaskListName + "/" + info.WorkflowExecution.ID, nil
+	}
+
+	t.Run("activity and child workflow", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivity(activityFn)
+		env.RegisterWorkflow(childFn)
+		env.ExecuteWorkflow(func(ctx Context) ([]string, error) {
+			ctx = WithActivityOptions(ctx, ActivityOptions{
+				TaskList:               "default",
+				ScheduleToStartTimeout: time.Minute,
+				StartToCloseTimeout:    time.Minute,
+			})
+			ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
+				TaskList:                     "default",
+				ExecutionStartToCloseTimeout: time.Minute,
+			})
+			var results []string
+			for _, f := range []Future{
+				ExecuteActivity(ctx, activityFn, CallTaskList("fast"), "a", CallActivityID("id-a")),
+				ExecuteActivity(ctx, activityFn, "b", CallRetryPolicy(RetryPolicy{InitialInterval: time.Second, MaximumAttempts: 2})),
+				ExecuteChildWorkflow(ctx, childFn, "c", CallWorkflowID("child-c"), CallTaskList("children")),
+				ExecuteChildWorkflow(ctx, childFn, "d", CallWorkflowID("child-d")),
+			} {
+				var result string
+				if err := f.Get(ctx, &result); err != nil {
+					return nil, err
+				}
+				results = append(results, result)
+			}
+			return results, nil
+		})
+		require.NoError(t, env.GetWorkflowError())
+		var results []string
+		require.NoError(t, env.GetWorkflowResult(&results))
+		assert.Equal(t, "a@fast/id-a", results[0])
+		// the call options of a call do not leak into the context
+		assert.Regexp(t, `^b@default/\d+$`, results[1])
+		assert.Equal(t, "c@children/child-c", results[2])
+		assert.Equal(t, "d@default/child-d", results[3])
+	})
+
+	t.Run("inapplicable options", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivity(activityFn)
+		env.RegisterWorkflow(childFn)
+		env.ExecuteWorkflow(func(ctx Context) ([]string, error) {
+			activityErr := ExecuteActivity(ctx, activityFn, "a", CallWorkflowID("id")).Get(ctx, nil)
+			child := ExecuteChildWorkflow(ctx, childFn, "b", CallActivityID("id"))
+			return []string{
+				activityErr.Error(),
+				child.Get(ctx, nil).Error(),
+				child.GetChildWorkflowExecution().Get(ctx, nil).Error(),
+			}, nil
+		})
+		require.NoError(t, env.GetWorkflowError())
+		var errs []string
+		require.NoError(t, env.GetWorkflowResult(&errs))
+		assert.Equal(t, []string{
+			"CallWorkflowID does not apply to activities",
+			"CallActivityID does not apply to child workflows",
+			"CallActivityID does not apply to child workflows",
+		}, errs)
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+// ChildHandle identifies a child workflow that was started with ParentClosePolicyAbandon. It only holds plain values,
+// so it can be returned from the parent, passed to activities or to other workflows, and used later to monitor the
+// child after its parent has closed.
+type ChildHandle struct {
+	Domain     string
+	WorkflowID string
+	RunID      string
+}
+
+// StartAbandonedChildWorkflow starts a child workflow with ParentClosePolicyAbandon and blocks until the child has
+// started, so the child is guaranteed to outlive the parent once this call returns without an error.
+// Child workflow options are taken from the context the same way as for ExecuteChildWorkflow, with the parent close
+// policy always overridden to ParentClosePolicyAbandon.
+//
+//	handle, err := StartAbandonedChildWorkflow(ctx, ChildWorkflow, arg)
+//	if err != nil {
+//	    return err
+//	}
+//	// pass handle to an activity, or return it from the workflow, and call handle.GetResult from there.
+//
+// The child result can not be retrieved from the returned handle inside the parent workflow, use ExecuteChildWorkflow
+// for children that need to be awaited.
+func StartAbandonedChildWorkflow(ctx Context, childWorkflow interface{}, args ...interface{}) (ChildHandle, error) {
+	ctx = WithParentClosePolicy(ctx, ParentClosePolicyAbandon)
+	future := ExecuteChildWorkflow(ctx, childWorkflow, args...)
+
+	var execution WorkflowExecution
+	if err := future.GetChildWorkflowExecution().Get(ctx, &execution); err != nil {
+		return ChildHandle{}, err
+	}
+
+	domain := GetWorkflowInfo(ctx).Domain
+	if d := getWorkflowEnvOptions(ctx).domain; d != nil && *d != "" {
+		domain = *d
+	}
+	return ChildHandle{
+		Domain:     domain,
+		WorkflowID: execution.ID,
+		RunID:      execution.RunID,
+	}, nil
+}
+
+// Execution returns the WorkflowExecution of the child workflow.
+func (h ChildHandle) Execution() WorkflowExecution {
+	return WorkflowExecution{ID: h.WorkflowID, RunID: h.RunID}
+}
+
+// GetResult blocks until the child workflow is closed and decodes its result into valuePtr.
+// The client must be created for the handle's Domain. It is meant to be called from activities or from code outside
+// of workflows, never from workflow code.
+func (h ChildHandle) GetResult(ctx context.Context, c Client, valuePtr interface{}) error {
+	if h.WorkflowID == "" {
+		return errors.New("child handle has no workflow ID")
+	}
+	return c.GetWorkflow(ctx, h.WorkflowID, h.RunID).Get(ctx, valuePtr)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type childHandleTestClient struct {
+	Client
+	run *childHandleTestRun
+}
+
+func (c *childHandleTestClient) GetWorkflow(_ context.Context, workflowID string, runID string) WorkflowRun {
+	c.run.workflowID = workflowID
+	c.run.runID = runID
+	return c.run
+}
+
+type childHandleTestRun struct {
+	WorkflowRun
+	workflowID string
+	runID      string
+	result     string
+}
+
+func (r *childHandleTestRun) Get(_ context.Context, valuePtr interface{}) error {
+	*valuePtr.(*string) = r.result
+	return nil
+}
+
+func TestStartAbandonedChildWorkflow(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	childFn := func(ctx Context, name string) (string, error) {
+		return "hello " + name, nil
+	}
+	parentFn := func(ctx Context) (ChildHandle, error) {
+		ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
+			WorkflowID:                   "child-id",
+			ExecutionStartToCloseTimeout: time.Minute,
+			ParentClosePolicy:            ParentClosePolicyTerminate,
+		})
+		return StartAbandonedChildWorkflow(ctx, childFn, "world")
+	}
+
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(childFn)
+	env.ExecuteWorkflow(parentFn)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var handle ChildHandle
+	require.NoError(t, env.GetWorkflowResult(&handle))
+	assert.Equal(t, defaultTestDomain, handle.Domain)
+	assert.Equal(t, "child-id", handle.WorkflowID)
+	assert.NotEmpty(t, handle.RunID)
+	assert.Equal(t, WorkflowExecution{ID: "child-id", RunID: handle.RunID}, handle.Execution())
+}
+
+func TestWithParentClosePolicy(t *testing.T) {
+	ctx := WithChildWorkflowOptions(Background(), ChildWorkflowOptions{ParentClosePolicy: ParentClosePolicyTerminate})
+	ctx = WithParentClosePolicy(ctx, ParentClosePolicyAbandon)
+	assert.Equal(t, ParentClosePolicyAbandon, getWorkflowEnvOptions(ctx).parentClosePolicy)
+}
+
+func TestChildHandleGetResult(t *testing.T) {
+	handle := ChildHandle{Domain: "domain", WorkflowID: "child-id", RunID: "child-run-id"}
+	run := &childHandleTestRun{result: "hello world"}
+
+	var result string
+	require.NoError(t, handle.GetResult(context.Background(), &childHandleTestClient{run: run}, &result))
+	assert.Equal(t, "hello world", result)
+	assert.Equal(t, "child-id", run.workflowID)
+	assert.Equal(t, "child-run-id", run.runID)
+
+	assert.Error(t, ChildHandle{}.GetResult(context.Background(), &childHandleTestClient{run: run}, &result))
+}
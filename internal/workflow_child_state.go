@@ -0,0 +1,173 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+const childWorkflowStateTimeout = 30 * time.Second
+
+type (
+	// ChildWorkflowState is the state of a child workflow as reported by the Cadence service, see
+	// GetChildWorkflowState.
+	ChildWorkflowState struct {
+		ID string
+		// FirstRunID is the run started by the parent, as returned by ChildWorkflowFuture.GetChildWorkflowExecution.
+		FirstRunID string
+		// CurrentRunID is the latest run of the child. It differs from FirstRunID once the child continued-as-new.
+		CurrentRunID string
+		// Running is false once the latest run of the child closed.
+		Running bool
+
+		queryResult Value
+	}
+
+	// childWorkflowStateRecord is the result of the local activity reading the state of the child, which is recorded
+	// in the history of the parent.
+	childWorkflowStateRecord struct {
+		CurrentRunID string
+		Running      bool
+		QueryResult  []byte
+	}
+)
+
+// ContinuedAsNew returns if the child workflow continued-as-new since it was started by the parent.
+func (c *ChildWorkflowState) ContinuedAsNew() bool {
+	return c.CurrentRunID != c.FirstRunID
+}
+
+// HasQueryResult returns if the child workflow returned a value for the query.
+func (c *ChildWorkflowState) HasQueryResult() bool {
+	return c.queryResult != nil && c.queryResult.HasValue()
+}
+
+// QueryResult extracts the strong typed result of the query. If there is no result, it will return ErrNoData.
+func (c *ChildWorkflowState) QueryResult(valuePtr interface{}) error {
+	if !c.HasQueryResult() {
+		return ErrNoData
+	}
+	return c.queryResult.Get(valuePtr)
+}
+
+// GetChildWorkflowState blocks until the child workflow is started and returns its current run and, when queryType is
+// not empty, the result of querying its latest run. It lets a parent monitor long-lived children that continue-as-new,
+// which the run ID returned by GetChildWorkflowExecution does not follow:
+//
+//	child := ExecuteChildWorkflow(ctx, ChildWorkflow)
+//	...
+//	state, err := GetChildWorkflowState(ctx, child, "progress")
+//	var progress int
+//	if err == nil && state.HasQueryResult() {
+//		err = state.QueryResult(&progress)
+//	}
+//
+// The context must carry the same child workflow options as the one passed to ExecuteChildWorkflow, so the child is
+// looked up in the right domain. The state is read from the Cadence service by a local activity, which records the
+// result in the history of the parent. It must not be called from query handlers.
+func GetChildWorkflowState(ctx Context, child ChildWorkflowFuture, queryType string, args ...interface{}) (*ChildWorkflowState, error) {
+	var execution WorkflowExecution
+	if err := child.GetChildWorkflowExecution().Get(ctx, &execution); err != nil {
+		return nil, err
+	}
+
+	dc := getDataConverterFromWorkflowContext(ctx)
+	var queryArgs []byte
+	if queryType != "" {
+		var err error
+		if queryArgs, err = encodeArgs(dc, args); err != nil {
+			return nil, err
+		}
+	}
+	domain := GetWorkflowInfo(ctx).Domain
+	if d := getWorkflowEnvOptions(ctx).domain; d != nil && *d != "" {
+		domain = *d
+	}
+
+	ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: childWorkflowStateTimeout})
+	var record childWorkflowStateRecord
+	err := ExecuteLocalActivity(ctx, getChildWorkflowStateLocalActivity, domain, execution.ID, queryType, queryArgs).Get(ctx, &record)
+	if err != nil {
+		return nil, err
+	}
+	state := &ChildWorkflowState{
+		ID:           execution.ID,
+		FirstRunID:   execution.RunID,
+		CurrentRunID: record.CurrentRunID,
+		Running:      record.Running,
+	}
+	if record.QueryResult != nil {
+		state.queryResult = newEncodedValue(record.QueryResult, dc)
+	}
+	return state, nil
+}
+
+// getChildWorkflowStateLocalActivity describes and optionally queries the latest run of the child workflow with the
+// worker's service client.
+func getChildWorkflowStateLocalActivity(ctx context.Context, domain, workflowID, queryType string, queryArgs []byte) (*childWorkflowStateRecord, error) {
+	env := getActivityEnv(ctx)
+	if env.workflowService == nil {
+		return nil, errors.New("child workflow state is not available without a service client")
+	}
+	describeRequest := &s.DescribeWorkflowExecutionRequest{
+		Domain:    common.StringPtr(domain),
+		Execution: &s.WorkflowExecution{WorkflowId: common.StringPtr(workflowID)},
+	}
+	tchCtx, cancel, opt := newChannelContext(ctx, env.featureFlags)
+	defer cancel()
+	response, err := env.workflowService.DescribeWorkflowExecution(tchCtx, describeRequest, opt...)
+	if err != nil {
+		return nil, err
+	}
+	info := response.GetWorkflowExecutionInfo()
+	record := &childWorkflowStateRecord{
+		CurrentRunID: info.GetExecution().GetRunId(),
+		Running:      info.CloseStatus == nil,
+	}
+	if queryType == "" {
+		return record, nil
+	}
+
+	queryRequest := &s.QueryWorkflowRequest{
+		Domain: common.StringPtr(domain),
+		Execution: &s.WorkflowExecution{
+			WorkflowId: common.StringPtr(workflowID),
+			RunId:      common.StringPtr(record.CurrentRunID),
+		},
+		Query: &s.WorkflowQuery{
+			QueryType: common.StringPtr(queryType),
+			QueryArgs: queryArgs,
+		},
+	}
+	queryCtx, queryCancel, queryOpt := newChannelContextForQuery(ctx, env.featureFlags)
+	defer queryCancel()
+	queryResponse, err := env.workflowService.QueryWorkflow(queryCtx, queryRequest, queryOpt...)
+	if err != nil {
+		return nil, err
+	}
+	record.QueryResult = queryResponse.QueryResult
+	return record, nil
+}
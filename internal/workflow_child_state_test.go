@@ -0,0 +1,115 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestGetChildWorkflowState(t *testing.T) {
+	childFn := func(ctx Context) error {
+		return Sleep(ctx, time.Hour)
+	}
+	parentFn := func(ctx Context) (*ChildWorkflowState, error) {
+		ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
+			WorkflowID:                   "child",
+			ExecutionStartToCloseTimeout: 2 * time.Hour,
+		})
+		child := ExecuteChildWorkflow(ctx, childFn)
+		state, err := GetChildWorkflowState(ctx, child, "progress", "verbose")
+		if err != nil {
+			return nil, err
+		}
+		return state, child.Get(ctx, nil)
+	}
+
+	env := newTestWorkflowEnv(t)
+	env.RegisterWorkflow(parentFn)
+	env.RegisterWorkflow(childFn)
+	progress, err := encodeArg(getDefaultDataConverter(), 75)
+	require.NoError(t, err)
+	env.OnActivity(getChildWorkflowStateLocalActivity, mock.Anything, defaultTestDomain, "child", "progress", mock.Anything).
+		Return(&childWorkflowStateRecord{CurrentRunID: "second-run", Running: true, QueryResult: progress}, nil).Once()
+	env.ExecuteWorkflow(parentFn)
+
+	require.NoError(t, env.GetWorkflowError())
+	var state ChildWorkflowState
+	require.NoError(t, env.GetWorkflowResult(&state))
+	assert.Equal(t, "child", state.ID)
+	assert.NotEmpty(t, state.FirstRunID)
+	assert.Equal(t, "second-run", state.CurrentRunID)
+	assert.True(t, state.Running)
+	assert.True(t, state.ContinuedAsNew())
+}
+
+func TestChildWorkflowState_QueryResult(t *testing.T) {
+	progress, err := encodeArg(getDefaultDataConverter(), 75)
+	require.NoError(t, err)
+	state := &ChildWorkflowState{queryResult: newEncodedValue(progress, getDefaultDataConverter())}
+	var value int
+	require.NoError(t, state.QueryResult(&value))
+	assert.Equal(t, 75, value)
+
+	assert.Equal(t, ErrNoData, (&ChildWorkflowState{}).QueryResult(&value))
+}
+
+func TestGetChildWorkflowStateLocalActivity(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(mockCtrl)
+	ctx := context.WithValue(context.Background(), activityEnvContextKey, &activityEnvironment{workflowService: service})
+	service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &shared.WorkflowExecutionInfo{
+				Execution: &shared.WorkflowExecution{WorkflowId: common.StringPtr("child"), RunId: common.StringPtr("second-run")},
+			},
+		}, nil).
+		Do(func(_ interface{}, req *shared.DescribeWorkflowExecutionRequest, _ ...interface{}) {
+			assert.Equal(t, "other-domain", req.GetDomain())
+			assert.Equal(t, "child", req.GetExecution().GetWorkflowId())
+			assert.Empty(t, req.GetExecution().GetRunId())
+		}).Times(2)
+	service.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.QueryWorkflowResponse{QueryResult: []byte("75")}, nil).
+		Do(func(_ interface{}, req *shared.QueryWorkflowRequest, _ ...interface{}) {
+			assert.Equal(t, "second-run", req.GetExecution().GetRunId())
+			assert.Equal(t, "progress", req.GetQuery().GetQueryType())
+			assert.Equal(t, []byte("args"), req.GetQuery().GetQueryArgs())
+		})
+
+	record, err := getChildWorkflowStateLocalActivity(ctx, "other-domain", "child", "progress", []byte("args"))
+	require.NoError(t, err)
+	assert.Equal(t, &childWorkflowStateRecord{CurrentRunID: "second-run", Running: true, QueryResult: []byte("75")}, record)
+
+	record, err = getChildWorkflowStateLocalActivity(ctx, "other-domain", "child", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, &childWorkflowStateRecord{CurrentRunID: "second-run", Running: true}, record)
+}
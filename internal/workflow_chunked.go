@@ -0,0 +1,136 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	defaultChunkedInitialChunkSize = 100
+	defaultChunkedMaxChunkSize     = 10000
+)
+
+type (
+	// ChunkedOptions configure ProcessInChunks.
+	ChunkedOptions struct {
+		// InitialChunkSize - The number of items of the first chunk.
+		// Optional: defaulted to 100.
+		InitialChunkSize int
+
+		// MaxChunkSize - The maximum number of items of a chunk.
+		// Optional: defaulted to 10000.
+		MaxChunkSize int
+
+		// TargetChunkDuration - The time a chunk should take to process. The size of every chunk is adjusted to the
+		// processing time per item of the previous chunk, at most doubling.
+		// Optional: defaulted to half the decision task timeout of the workflow.
+		TargetChunkDuration time.Duration
+	}
+
+	// chunkResult is the result of the local activity processing a chunk. Its duration is recorded with the result so
+	// the chunk sizes are the same when the workflow is replayed.
+	chunkResult[R any] struct {
+		Results  []R
+		Duration time.Duration
+	}
+)
+
+// ProcessInChunks calls fn with consecutive chunks of items in local activities, and returns the concatenation of
+// their results. The chunks are sized to take about options.TargetChunkDuration, so that workflows can process large
+// datasets without decision tasks timing out while they compute, and without an activity worker. fn must be a pure
+// function of its chunk. The local activities use the LocalActivityOptions of ctx; without them, they are retried
+// with the default retry policy and time out after ten times TargetChunkDuration. ProcessInChunks returns the first
+// error of fn, or *CanceledError if ctx is canceled. The results of every chunk are recorded in the history of the
+// workflow, so the items a single run can process are bounded by the history size limit of the server; workflows
+// processing more should continue as new with the remaining items.
+func ProcessInChunks[T, R any](
+	ctx Context,
+	items []T,
+	fn func(ctx context.Context, chunk []T) ([]R, error),
+	options ChunkedOptions,
+) ([]R, error) {
+	if options.InitialChunkSize < 0 || options.MaxChunkSize < 0 || options.TargetChunkDuration < 0 {
+		return nil, errors.New("chunked processing options must not be negative")
+	}
+	maxChunkSize := options.MaxChunkSize
+	if maxChunkSize == 0 {
+		maxChunkSize = defaultChunkedMaxChunkSize
+	}
+	chunkSize := options.InitialChunkSize
+	if chunkSize == 0 {
+		chunkSize = defaultChunkedInitialChunkSize
+	}
+	if chunkSize > maxChunkSize {
+		chunkSize = maxChunkSize
+	}
+	target := options.TargetChunkDuration
+	if target == 0 {
+		target = time.Duration(GetWorkflowInfo(ctx).TaskStartToCloseTimeoutSeconds) * time.Second / 2
+	}
+	if getLocalActivityOptions(ctx) == nil && getDefaultActivityOptions(ctx).localActivity() == nil {
+		ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: 10 * target})
+	}
+
+	processChunk := func(ctx context.Context, chunk []T) (chunkResult[R], error) {
+		start := time.Now()
+		results, err := fn(ctx, chunk)
+		return chunkResult[R]{Results: results, Duration: time.Since(start)}, err
+	}
+	results := make([]R, 0, len(items))
+	for start := 0; start < len(items); {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		end := start + chunkSize
+		if end > len(items) {
+			end = len(items)
+		}
+		var chunk chunkResult[R]
+		if err := ExecuteLocalActivity(ctx, processChunk, items[start:end]).Get(ctx, &chunk); err != nil {
+			return nil, err
+		}
+		results = append(results, chunk.Results...)
+		chunkSize = nextChunkSize(end-start, chunk.Duration, target, maxChunkSize)
+		start = end
+	}
+	return results, nil
+}
+
+// nextChunkSize returns the size of the chunk which should take target to process, given the size and processing
+// duration of the previous chunk. It at most doubles the size, and is at least 1.
+func nextChunkSize(size int, duration, target time.Duration, maxChunkSize int) int {
+	next := 2 * size
+	if duration > 0 {
+		if fitting := int(float64(size) * float64(target) / float64(duration)); fitting < next {
+			next = fitting
+		}
+	}
+	if next > maxChunkSize {
+		next = maxChunkSize
+	}
+	if next < 1 {
+		next = 1
+	}
+	return next
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessInChunks(t *testing.T) {
+	var testSuite WorkflowTestSuite
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = i
+	}
+	square := func(ctx context.Context, chunk []int) ([]int, error) {
+		results := make([]int, len(chunk))
+		for i, item := range chunk {
+			if item < 0 {
+				return nil, errors.New("negative item")
+			}
+			results[i] = item * item
+		}
+		return results, nil
+	}
+
+	t.Run("results", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		var chunkSizes []int
+		env.SetOnLocalActivityStartedListener(func(activityInfo *ActivityInfo, ctx context.Context, args []interface{}) {
+			chunkSizes = append(chunkSizes, len(args[0].([]int)))
+		})
+		env.ExecuteWorkflow(func(ctx Context) ([]int, error) {
+			return ProcessInChunks(ctx, items, square, ChunkedOptions{
+				InitialChunkSize:    10,
+				MaxChunkSize:        300,
+				TargetChunkDuration: time.Hour,
+			})
+		})
+		require.NoError(t, env.GetWorkflowError())
+		var results []int
+		require.NoError(t, env.GetWorkflowResult(&results))
+		require.Len(t, results, len(items))
+		for i, result := range results {
+			assert.Equal(t, i*i, result)
+		}
+		// the chunks double up to the maximum size, as they take far less than the target duration
+		assert.Equal(t, []int{10, 20, 40, 80, 160, 300, 300, 90}, chunkSizes)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) ([]int, error) {
+			ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: time.Minute})
+			return ProcessInChunks(ctx, append(items, -1), square, ChunkedOptions{})
+		})
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "negative item")
+	})
+}
+
+func TestNextChunkSize(t *testing.T) {
+	assert.Equal(t, 200, nextChunkSize(100, 0, time.Second, 1000))
+	assert.Equal(t, 200, nextChunkSize(100, time.Millisecond, time.Second, 1000))
+	assert.Equal(t, 1000, nextChunkSize(800, time.Millisecond, time.Second, 1000))
+	assert.Equal(t, 50, nextChunkSize(100, 2*time.Second, time.Second, 1000))
+	assert.Equal(t, 1, nextChunkSize(100, time.Hour, time.Second, 1000))
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// WorkflowConfigSignalName is the signal used by UpdateWorkflowConfig to change the config of a running workflow.
+	WorkflowConfigSignalName = "__workflow_config"
+
+	// WorkflowConfigMemoPrefix is the prefix of the memo keys holding the initial config values of a workflow. See
+	// WorkflowConfigMemo.
+	WorkflowConfigMemoPrefix = "config."
+)
+
+type (
+	// WorkflowConfigOptions configure NewWorkflowConfig.
+	WorkflowConfigOptions struct {
+		// SearchAttributes lists the config keys that are also search attributes of the workflow. Their initial
+		// values are read from the search attributes the workflow was started with, and updates received through
+		// UpdateWorkflowConfig are upserted so that the current values are visible in list workflow queries. The
+		// search attributes must be registered on the cluster.
+		SearchAttributes []string
+	}
+
+	// WorkflowConfig holds per-execution configuration values of a workflow. It is created from the memo and search
+	// attributes the workflow was started with, and is kept up to date with the values sent by UpdateWorkflowConfig.
+	// Updates are applied when the workflow receives the WorkflowConfigSignalName signal, so reading the config is
+	// deterministic.
+	WorkflowConfig struct {
+		values           map[string]interface{}
+		searchAttributes map[string]bool
+	}
+)
+
+// WorkflowConfigMemo returns the memo holding the initial config values of a workflow, to be merged into
+// StartWorkflowOptions.Memo.
+func WorkflowConfigMemo(values map[string]interface{}) map[string]interface{} {
+	memo := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		memo[WorkflowConfigMemoPrefix+k] = v
+	}
+	return memo
+}
+
+// UpdateWorkflowConfig changes the config values of a running workflow using WorkflowConfig. The values are merged
+// into the current config of the workflow; keys not present in values are left unchanged.
+func UpdateWorkflowConfig(ctx context.Context, c Client, workflowID, runID string, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return c.SignalWorkflow(ctx, workflowID, runID, WorkflowConfigSignalName, values)
+}
+
+// NewWorkflowConfig reads the config of the workflow from its memo and search attributes and starts applying the
+// updates sent by UpdateWorkflowConfig. It should be called once, at the start of the workflow.
+func NewWorkflowConfig(ctx Context, options WorkflowConfigOptions) (*WorkflowConfig, error) {
+	config := &WorkflowConfig{
+		values:           make(map[string]interface{}),
+		searchAttributes: make(map[string]bool, len(options.SearchAttributes)),
+	}
+	for _, key := range options.SearchAttributes {
+		config.searchAttributes[key] = true
+	}
+
+	info := GetWorkflowInfo(ctx)
+	if info.Memo != nil {
+		dc := getDataConverterFromWorkflowContext(ctx)
+		for k, data := range info.Memo.Fields {
+			if !strings.HasPrefix(k, WorkflowConfigMemoPrefix) {
+				continue
+			}
+			var value interface{}
+			if err := decodeArg(dc, data, &value); err != nil {
+				return nil, fmt.Errorf("unable to decode workflow config memo %q: %v", k, err)
+			}
+			config.values[strings.TrimPrefix(k, WorkflowConfigMemoPrefix)] = value
+		}
+	}
+	if info.SearchAttributes != nil {
+		for _, key := range options.SearchAttributes {
+			data, ok := info.SearchAttributes.IndexedFields[key]
+			if !ok {
+				continue
+			}
+			var value interface{}
+			if err := decodeArg(getDefaultDataConverter(), data, &value); err != nil {
+				return nil, fmt.Errorf("unable to decode workflow config search attribute %q: %v", key, err)
+			}
+			config.values[key] = value
+		}
+	}
+
+	ch := GetSignalChannel(ctx, WorkflowConfigSignalName)
+	Go(ctx, func(ctx Context) {
+		for {
+			var update map[string]interface{}
+			ch.Receive(ctx, &update)
+			if err := config.apply(ctx, update); err != nil {
+				GetLogger(ctx).Warn("Unable to upsert workflow config search attributes.", zap.Error(err))
+			}
+		}
+	})
+	return config, nil
+}
+
+func (c *WorkflowConfig) apply(ctx Context, update map[string]interface{}) error {
+	attributes := make(map[string]interface{})
+	for k, v := range update {
+		c.values[k] = v
+		if c.searchAttributes[k] {
+			attributes[k] = v
+		}
+	}
+	if len(attributes) == 0 {
+		return nil
+	}
+	return UpsertSearchAttributes(ctx, attributes)
+}
+
+// Value returns the raw value of key and whether it is set.
+func (c *WorkflowConfig) Value(key string) (interface{}, bool) {
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// Keys returns the sorted list of the config keys that are set.
+func (c *WorkflowConfig) Keys() []string {
+	return SortedKeys(c.values)
+}
+
+// String returns the value of key, or defaultValue if it is not set or not a string.
+func (c *WorkflowConfig) String(key string, defaultValue string) string {
+	if v, ok := c.values[key].(string); ok {
+		return v
+	}
+	return defaultValue
+}
+
+// Bool returns the value of key, or defaultValue if it is not set or not a bool.
+func (c *WorkflowConfig) Bool(key string, defaultValue bool) bool {
+	if v, ok := c.values[key].(bool); ok {
+		return v
+	}
+	return defaultValue
+}
+
+// Int returns the value of key, or defaultValue if it is not set or not an integer.
+func (c *WorkflowConfig) Int(key string, defaultValue int) int {
+	if v, ok := toFloat64(c.values[key]); ok && v == math.Trunc(v) {
+		return int(v)
+	}
+	return defaultValue
+}
+
+// Float64 returns the value of key, or defaultValue if it is not set or not a number.
+func (c *WorkflowConfig) Float64(key string, defaultValue float64) float64 {
+	if v, ok := toFloat64(c.values[key]); ok {
+		return v
+	}
+	return defaultValue
+}
+
+// Duration returns the value of key, or defaultValue if it is not set or not a duration. Durations are given either
+// as strings accepted by time.ParseDuration, like "1m30s", or as a number of seconds.
+func (c *WorkflowConfig) Duration(key string, defaultValue time.Duration) time.Duration {
+	switch v := c.values[key].(type) {
+	case time.Duration:
+		return v
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	default:
+		if seconds, ok := toFloat64(v); ok {
+			return time.Duration(seconds * float64(time.Second))
+		}
+	}
+	return defaultValue
+}
+
+// toFloat64 converts the numeric values produced by decoding config values into a float64.
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowConfigMemo(t *testing.T) {
+	memo := WorkflowConfigMemo(map[string]interface{}{"batch-size": 10})
+	assert.Equal(t, map[string]interface{}{WorkflowConfigMemoPrefix + "batch-size": 10}, memo)
+}
+
+func TestWorkflowConfig(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	type result struct {
+		BatchSize int
+		Ratio     float64
+		Enabled   bool
+		Mode      string
+		Timeout   time.Duration
+		Keys      []string
+	}
+	read := func(config *WorkflowConfig) result {
+		return result{
+			BatchSize: config.Int("batch-size", 1),
+			Ratio:     config.Float64("ratio", 0.5),
+			Enabled:   config.Bool("enabled", false),
+			Mode:      config.String("mode", "default"),
+			Timeout:   config.Duration("timeout", time.Minute),
+			Keys:      config.Keys(),
+		}
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		workflowFn := func(ctx Context) (result, error) {
+			config, err := NewWorkflowConfig(ctx, WorkflowConfigOptions{})
+			if err != nil {
+				return result{}, err
+			}
+			return read(config), nil
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var r result
+		require.NoError(t, env.GetWorkflowResult(&r))
+		assert.Equal(t, result{BatchSize: 1, Ratio: 0.5, Mode: "default", Timeout: time.Minute, Keys: []string{}}, r)
+	})
+
+	t.Run("start values", func(t *testing.T) {
+		workflowFn := func(ctx Context) (result, error) {
+			config, err := NewWorkflowConfig(ctx, WorkflowConfigOptions{SearchAttributes: []string{"CustomKeywordField"}})
+			if err != nil {
+				return result{}, err
+			}
+			r := read(config)
+			r.Mode = config.String("CustomKeywordField", "")
+			return r, nil
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		memo := WorkflowConfigMemo(map[string]interface{}{
+			"batch-size": 20,
+			"ratio":      0.25,
+			"enabled":    true,
+			"timeout":    "90s",
+		})
+		memo["unrelated"] = "ignored"
+		require.NoError(t, env.SetMemoOnStart(memo))
+		require.NoError(t, env.SetSearchAttributesOnStart(map[string]interface{}{
+			"CustomKeywordField": "fast",
+			"CustomIntField":     1,
+		}))
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var r result
+		require.NoError(t, env.GetWorkflowResult(&r))
+		assert.Equal(t, result{
+			BatchSize: 20,
+			Ratio:     0.25,
+			Enabled:   true,
+			Mode:      "fast",
+			Timeout:   90 * time.Second,
+			Keys:      []string{"CustomKeywordField", "batch-size", "enabled", "ratio", "timeout"},
+		}, r)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		workflowFn := func(ctx Context) ([]result, error) {
+			config, err := NewWorkflowConfig(ctx, WorkflowConfigOptions{SearchAttributes: []string{"CustomKeywordField"}})
+			if err != nil {
+				return nil, err
+			}
+			results := []result{read(config)}
+			if err := Sleep(ctx, time.Hour); err != nil {
+				return nil, err
+			}
+			results = append(results, read(config))
+
+			var upserted string
+			field := GetWorkflowInfo(ctx).SearchAttributes.IndexedFields["CustomKeywordField"]
+			if err := decodeArg(getDefaultDataConverter(), field, &upserted); err != nil {
+				return nil, err
+			}
+			results[1].Mode = upserted
+			return results, nil
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		require.NoError(t, env.SetMemoOnStart(WorkflowConfigMemo(map[string]interface{}{"batch-size": 20})))
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow(WorkflowConfigSignalName, map[string]interface{}{
+				"batch-size":         50,
+				"timeout":            30,
+				"CustomKeywordField": "slow",
+			})
+		}, time.Minute)
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var r []result
+		require.NoError(t, env.GetWorkflowResult(&r))
+		require.Len(t, r, 2)
+		assert.Equal(t, 20, r[0].BatchSize)
+		assert.Equal(t, time.Minute, r[0].Timeout)
+		assert.Equal(t, 50, r[1].BatchSize)
+		assert.Equal(t, 30*time.Second, r[1].Timeout)
+		assert.Equal(t, "slow", r[1].Mode)
+		assert.Equal(t, []string{"CustomKeywordField", "batch-size", "timeout"}, r[1].Keys)
+	})
+
+	t.Run("mismatched types", func(t *testing.T) {
+		workflowFn := func(ctx Context) (result, error) {
+			config, err := NewWorkflowConfig(ctx, WorkflowConfigOptions{})
+			if err != nil {
+				return result{}, err
+			}
+			return read(config), nil
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		require.NoError(t, env.SetMemoOnStart(WorkflowConfigMemo(map[string]interface{}{
+			"batch-size": 2.5,
+			"ratio":      "high",
+			"enabled":    "yes",
+			"mode":       3,
+			"timeout":    "soon",
+		})))
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var r result
+		require.NoError(t, env.GetWorkflowResult(&r))
+		assert.Equal(t, 1, r.BatchSize)
+		assert.Equal(t, 0.5, r.Ratio)
+		assert.False(t, r.Enabled)
+		assert.Equal(t, "default", r.Mode)
+		assert.Equal(t, time.Minute, r.Timeout)
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"cmp"
+	"hash/fnv"
+	"math/rand"
+	"slices"
+)
+
+// SortedKeys returns the keys of the map in ascending order. Ranging over a map visits the keys in a random order,
+// which makes workflows that schedule activities, child workflows or timers while ranging over a map
+// non-deterministic. Range over the sorted keys instead:
+//
+//	for _, region := range SortedKeys(regions) {
+//		futures = append(futures, ExecuteActivity(ctx, deploy, region, regions[region]))
+//	}
+func SortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := mapKeys(m)
+	slices.Sort(keys)
+	return keys
+}
+
+// SortedKeysFunc returns the keys of the map ordered by the comparison function, which must return a negative
+// number when a < b, a positive number when a > b and zero when a == b. Keys comparing as equal are returned in an
+// unspecified order, so the function must only return zero for equal keys.
+func SortedKeysFunc[K comparable, V any](m map[K]V, compare func(a, b K) int) []K {
+	keys := mapKeys(m)
+	slices.SortFunc(keys, compare)
+	return keys
+}
+
+// StableSortFunc sorts the slice by the comparison function, keeping the original order of elements comparing as
+// equal. Unlike sort.Slice, the resulting order does not depend on the sorting algorithm, so it is safe to use on
+// values whose order drives workflow decisions.
+func StableSortFunc[T any](s []T, compare func(a, b T) int) {
+	slices.SortStableFunc(s, compare)
+}
+
+// StableShuffle shuffles the slice in place in an order that only depends on the seed and on the length of the slice,
+// so replaying the workflow produces the same order. Use a seed that is stable across replays, such as the workflow
+// ID or run ID:
+//
+//	StableShuffle(GetWorkflowInfo(ctx).WorkflowExecution.RunID, hosts)
+func StableShuffle[T any](seed string, s []T) {
+	r := rand.New(rand.NewSource(int64(StableHash([]byte(seed)))))
+	r.Shuffle(len(s), func(i, j int) {
+		s[i], s[j] = s[j], s[i]
+	})
+}
+
+// StableHash returns the 64-bit FNV-1a hash of the data. Unlike hash/maphash, the hash does not depend on the
+// process, so it can be used by workflows to partition or route work deterministically.
+func StableHash(data []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	return h.Sum64()
+}
+
+func mapKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortedKeys(t *testing.T) {
+	m := map[string]int{"c": 3, "a": 1, "b": 2, "d": 4}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, []string{"a", "b", "c", "d"}, SortedKeys(m))
+	}
+	assert.Empty(t, SortedKeys(map[int]bool{}))
+
+	byLength := SortedKeysFunc(map[string]bool{"ccc": true, "a": true, "bb": true}, func(a, b string) int {
+		return len(a) - len(b)
+	})
+	assert.Equal(t, []string{"a", "bb", "ccc"}, byLength)
+}
+
+func TestStableSortFunc(t *testing.T) {
+	s := []string{"banana", "apple", "cherry", "avocado", "blueberry"}
+	StableSortFunc(s, func(a, b string) int {
+		return strings.Compare(a[:1], b[:1])
+	})
+	assert.Equal(t, []string{"apple", "avocado", "banana", "blueberry", "cherry"}, s)
+}
+
+func TestStableShuffle(t *testing.T) {
+	shuffle := func(seed string) []int {
+		s := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+		StableShuffle(seed, s)
+		return s
+	}
+	first := shuffle("run-id")
+	assert.Equal(t, first, shuffle("run-id"))
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, first)
+	assert.NotEqual(t, first, shuffle("other-run-id"))
+}
+
+func TestStableHash(t *testing.T) {
+	// the FNV-1a test vectors, the value must never change between releases
+	assert.Equal(t, uint64(0xcbf29ce484222325), StableHash(nil))
+	assert.Equal(t, uint64(0xaf63dc4c8601ec8c), StableHash([]byte("a")))
+	assert.NotEqual(t, StableHash([]byte("a")), StableHash([]byte("b")))
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"time"
+)
+
+// EntityHeartbeatQueryType is the query type of the EntityHeartbeat of a workflow run by RunEntity.
+const EntityHeartbeatQueryType = "__entity_heartbeat"
+
+const defaultEntityMaximumHistoryLength = 10000
+
+type (
+	// EntityOptions configure RunEntity.
+	EntityOptions struct {
+		// Handlers - The handlers of the signals of the entity by signal name. A handler must receive the signal from
+		// c, and returning an error stops the entity with it. Required.
+		Handlers map[string]func(ctx Context, c Channel) error
+
+		// IdleTimeout - The time without signals after which RunEntity returns nil, which completes the workflow of
+		// the entity until the next signal starts it again.
+		// Optional: defaulted to no timeout.
+		IdleTimeout time.Duration
+
+		// ContinueAsNew - Called once the history of the workflow has grown to MaximumHistoryLength events, after the
+		// signals which were received so far were handled. It should return a ContinueAsNewError carrying the state
+		// of the entity, which RunEntity returns.
+		// Optional: by default, RunEntity does not continue as new.
+		ContinueAsNew func(ctx Context) error
+
+		// MaximumHistoryLength - The length of the history at which ContinueAsNew is called.
+		// Optional: defaulted to 10000 events.
+		MaximumHistoryLength int64
+	}
+
+	// EntityHeartbeat is the liveness of a workflow run by RunEntity, returned by its EntityHeartbeatQueryType query.
+	EntityHeartbeat struct {
+		WorkflowID    string
+		RunID         string
+		RunStartTime  time.Time // when the current run started handling signals
+		LastSignal    time.Time // zero if the current run has not handled a signal yet
+		Signals       int       // the number of signals the current run handled
+		HistoryLength int64
+		Time          time.Time // the workflow time when the heartbeat was queried
+	}
+)
+
+// RunEntity runs the workflow of an entity, i.e. a long-running workflow per key which handles the signals sent to
+// the key, see EntityClient. It calls the handler of every signal received, in the order the signals are received,
+// and registers a query handler for the EntityHeartbeat of the workflow under EntityHeartbeatQueryType.
+// It returns nil after options.IdleTimeout, the error of a handler, the error of options.ContinueAsNew, or
+// *CanceledError if ctx is canceled. The signals which are buffered when the workflow completes or continues as new are
+// handled first.
+func RunEntity(ctx Context, options EntityOptions) error {
+	if len(options.Handlers) == 0 {
+		return errors.New("entity requires Handlers")
+	}
+	if options.IdleTimeout < 0 {
+		return errors.New("entity IdleTimeout must not be negative")
+	}
+	maximumHistoryLength := options.MaximumHistoryLength
+	if maximumHistoryLength <= 0 {
+		maximumHistoryLength = defaultEntityMaximumHistoryLength
+	}
+
+	info := GetWorkflowInfo(ctx)
+	heartbeat := EntityHeartbeat{
+		WorkflowID:   info.WorkflowExecution.ID,
+		RunID:        info.WorkflowExecution.RunID,
+		RunStartTime: Now(ctx),
+	}
+	err := setQueryHandler(ctx, EntityHeartbeatQueryType, func() (EntityHeartbeat, error) {
+		heartbeat.HistoryLength = GetHistoryCount(ctx)
+		heartbeat.Time = Now(ctx)
+		return heartbeat, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	names := SortedKeys(options.Handlers)
+	channels := make([]Channel, len(names))
+	for i, name := range names {
+		channels[i] = GetSignalChannel(ctx, name)
+	}
+	addHandlers := func(selector Selector, handlerErr *error) {
+		for i, c := range channels {
+			name := names[i]
+			selector.AddReceive(c, func(c Channel, more bool) {
+				heartbeat.Signals++
+				heartbeat.LastSignal = Now(ctx)
+				*handlerErr = options.Handlers[name](ctx, c)
+			})
+		}
+	}
+	// drain handles the signals buffered in the channels, which would be lost when the workflow completes or
+	// continues as new
+	drain := func() (bool, error) {
+		for drained := false; ; drained = true {
+			var handlerErr error
+			empty := false
+			selector := NewSelector(ctx)
+			addHandlers(selector, &handlerErr)
+			selector.AddDefault(func() { empty = true })
+			selector.Select(ctx)
+			if empty || handlerErr != nil {
+				return drained, handlerErr
+			}
+		}
+	}
+
+	for {
+		if options.ContinueAsNew != nil && GetHistoryCount(ctx) >= maximumHistoryLength {
+			if _, err := drain(); err != nil {
+				return err
+			}
+			return options.ContinueAsNew(ctx)
+		}
+
+		var handlerErr error
+		idle := false
+		timerCtx, cancelTimer := WithCancel(ctx)
+		selector := NewSelector(ctx)
+		addHandlers(selector, &handlerErr)
+		if options.IdleTimeout > 0 {
+			selector.AddFuture(NewTimer(timerCtx, options.IdleTimeout), func(f Future) {
+				idle = f.Get(ctx, nil) == nil
+			})
+		}
+		selector.AddReceive(ctx.Done(), func(c Channel, more bool) {})
+		selector.Select(ctx)
+		cancelTimer()
+
+		if handlerErr != nil {
+			return handlerErr
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if idle {
+			drained, err := drain()
+			if err != nil || !drained {
+				return err
+			}
+		}
+	}
+}
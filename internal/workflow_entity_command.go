@@ -0,0 +1,217 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// EntityCommandSignalName is the name of the signal delivering EntityCommandRequest to a workflow run by
+	// RunCommandEntity.
+	EntityCommandSignalName = "cadence-entity-command"
+
+	// EntityCommandResultQueryType is the query type of the EntityCommandResult of a command, which takes the ID of
+	// the command as its argument.
+	EntityCommandResultQueryType = "__entity_command_result"
+)
+
+const defaultEntityMaximumRetainedResults = 1000
+
+type (
+	// EntityCommandRequest is the payload of the EntityCommandSignalName signal, which asks an entity to handle a
+	// command.
+	EntityCommandRequest struct {
+		ID      string // unique per command, a command whose result is retained is not handled again
+		Name    string
+		Payload []byte // the command, encoded with the data converter
+	}
+
+	// EntityCommandResult is the result of a command handled by an entity, returned by its
+	// EntityCommandResultQueryType query.
+	EntityCommandResult struct {
+		ID      string
+		Handled bool   // false if the command was not handled yet, or its result is no longer retained
+		Result  []byte // the result of the command, encoded with the data converter
+		Error   string // the error of the command, empty if it succeeded
+		// Unknown is true if the command was not handled and no command is being handled, i.e. the entity did not
+		// receive the command before the query, or no longer retains its result.
+		Unknown bool
+	}
+
+	// EntitySnapshot is the state of an entity run by RunCommandEntity, with the results of its recent commands. It
+	// is passed to the next run of the workflow when the entity continues as new.
+	EntitySnapshot[S any] struct {
+		State   S
+		Results []EntityCommandResult // oldest first
+	}
+
+	// EntityCommandHandler handles a command of an entity with state S, see NewEntityCommandHandler.
+	EntityCommandHandler[S any] struct {
+		handle func(ctx Context, state *S, payload []byte) ([]byte, error)
+	}
+
+	// CommandEntityOptions configure RunCommandEntity.
+	CommandEntityOptions[S any] struct {
+		// Commands - The handlers of the commands of the entity by command name. Required.
+		Commands map[string]EntityCommandHandler[S]
+
+		// IdleTimeout - The time without commands after which the entity continues as new with its snapshot, which
+		// drops the history of the commands handled by the run. A run which did not handle any command keeps waiting.
+		// Optional: defaulted to no timeout.
+		IdleTimeout time.Duration
+
+		// ContinueAsNew - Called with the snapshot of the entity once the history of the workflow has grown to
+		// MaximumHistoryLength events, or after IdleTimeout. It should return a ContinueAsNewError which passes the
+		// snapshot to the new run, see RunCommandEntity.
+		// Optional: by default, RunCommandEntity continues as new the workflow type of the run, with a pointer to the
+		// snapshot as its only argument.
+		ContinueAsNew func(ctx Context, snapshot EntitySnapshot[S]) error
+
+		// MaximumHistoryLength - The length of the history at which ContinueAsNew is called.
+		// Optional: defaulted to 10000 events.
+		MaximumHistoryLength int64
+
+		// MaximumRetainedResults - The number of command results which are retained for their senders to query.
+		// Optional: defaulted to 1000.
+		MaximumRetainedResults int
+	}
+)
+
+// NewEntityCommandHandler returns the handler of a command of type C with a result of type R, which updates the state
+// of the entity. An error returned by fn is returned to the sender of the command, and does not stop the entity.
+func NewEntityCommandHandler[S, C, R any](fn func(ctx Context, state *S, command C) (R, error)) EntityCommandHandler[S] {
+	return EntityCommandHandler[S]{
+		handle: func(ctx Context, state *S, payload []byte) ([]byte, error) {
+			dc := getDataConverterFromWorkflowContext(ctx)
+			var command C
+			if err := decodeArg(dc, payload, &command); err != nil {
+				return nil, fmt.Errorf("unable to decode command: %v", err)
+			}
+			result, err := fn(ctx, state, command)
+			if err != nil {
+				return nil, err
+			}
+			return encodeArg(dc, result)
+		},
+	}
+}
+
+// RunCommandEntity runs the workflow of an entity with state S, which handles the commands sent with
+// EntityCommandStub one at a time, in the order they are received. It starts with the snapshot passed to the workflow,
+// which is nil when the entity is started by a command, and retains the results of the recent commands for their
+// senders to query. It returns the state of the entity with the error of options.ContinueAsNew, once the history
+// of the run grew to options.MaximumHistoryLength events or after options.IdleTimeout, or with *CanceledError if ctx
+// is canceled.
+func RunCommandEntity[S any](ctx Context, snapshot *EntitySnapshot[S], options CommandEntityOptions[S]) (S, error) {
+	var current EntitySnapshot[S]
+	if snapshot != nil {
+		current = *snapshot
+	}
+	if len(options.Commands) == 0 {
+		return current.State, errors.New("command entity requires Commands")
+	}
+	maximumRetainedResults := options.MaximumRetainedResults
+	if maximumRetainedResults <= 0 {
+		maximumRetainedResults = defaultEntityMaximumRetainedResults
+	}
+
+	var results map[string]int // index in current.Results by command ID
+	index := func() {
+		results = make(map[string]int, len(current.Results))
+		for i, result := range current.Results {
+			results[result.ID] = i
+		}
+	}
+	index()
+	retain := func(result EntityCommandResult) {
+		current.Results = append(current.Results, result)
+		results[result.ID] = len(current.Results) - 1
+		if evicted := len(current.Results) - maximumRetainedResults; evicted > 0 {
+			current.Results = append([]EntityCommandResult(nil), current.Results[evicted:]...)
+			index()
+		}
+	}
+
+	// the commands received while a command is being handled are buffered in the signal channel, so that the
+	// entity does not know about them yet
+	handling := false
+	handled := false // whether the run handled a command
+	err := setQueryHandler(ctx, EntityCommandResultQueryType, func(id string) (EntityCommandResult, error) {
+		if i, ok := results[id]; ok {
+			return current.Results[i], nil
+		}
+		return EntityCommandResult{ID: id, Unknown: !handling}, nil
+	})
+	if err != nil {
+		return current.State, err
+	}
+
+	entityOptions := EntityOptions{
+		Handlers: map[string]func(ctx Context, c Channel) error{
+			EntityCommandSignalName: func(ctx Context, c Channel) error {
+				var request EntityCommandRequest
+				if !c.ReceiveAsync(&request) {
+					return nil
+				}
+				if _, ok := results[request.ID]; ok {
+					return nil
+				}
+				result := EntityCommandResult{ID: request.ID, Handled: true}
+				command, ok := options.Commands[request.Name]
+				handling = true
+				if !ok {
+					result.Error = fmt.Sprintf("unknown command %q", request.Name)
+				} else if data, err := command.handle(ctx, &current.State, request.Payload); err != nil {
+					result.Error = err.Error()
+				} else {
+					result.Result = data
+				}
+				handling = false
+				handled = true
+				retain(result)
+				return nil
+			},
+		},
+		IdleTimeout:          options.IdleTimeout,
+		MaximumHistoryLength: options.MaximumHistoryLength,
+	}
+	continueAsNew := options.ContinueAsNew
+	if continueAsNew == nil {
+		continueAsNew = func(ctx Context, snapshot EntitySnapshot[S]) error {
+			return NewContinueAsNewError(ctx, GetWorkflowInfo(ctx).WorkflowType.Name, &snapshot)
+		}
+	}
+	entityOptions.ContinueAsNew = func(ctx Context) error {
+		return continueAsNew(ctx, current)
+	}
+	for {
+		if err := RunEntity(ctx, entityOptions); err != nil {
+			return current.State, err
+		}
+		// the entity was idle for options.IdleTimeout, a run without commands has no history to drop
+		if handled {
+			return current.State, continueAsNew(ctx, current)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	testCart struct {
+		Items []string
+	}
+
+	testAddItem struct {
+		Item string
+	}
+)
+
+func TestRunCommandEntity(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	var cartWorkflow func(ctx Context, snapshot *EntitySnapshot[testCart]) (testCart, error)
+	cartWorkflow = func(ctx Context, snapshot *EntitySnapshot[testCart]) (testCart, error) {
+		return RunCommandEntity(ctx, snapshot, CommandEntityOptions[testCart]{
+			Commands: map[string]EntityCommandHandler[testCart]{
+				"add": NewEntityCommandHandler(func(ctx Context, cart *testCart, command testAddItem) (int, error) {
+					if command.Item == "" {
+						return 0, errors.New("item is required")
+					}
+					cart.Items = append(cart.Items, command.Item)
+					// simulate the history growing with every command
+					GetWorkflowInfo(ctx).HistoryCount += 10
+					return len(cart.Items), nil
+				}),
+			},
+			IdleTimeout:            time.Hour,
+			MaximumHistoryLength:   30,
+			MaximumRetainedResults: 2,
+			ContinueAsNew: func(ctx Context, snapshot EntitySnapshot[testCart]) error {
+				return NewContinueAsNewError(ctx, cartWorkflow, &snapshot)
+			},
+		})
+	}
+	dc := getDefaultDataConverter()
+	sendCommand := func(env *TestWorkflowEnvironment, id, name string, command interface{}) {
+		payload, err := dc.ToData(command)
+		require.NoError(t, err)
+		env.SignalWorkflow(EntityCommandSignalName, EntityCommandRequest{ID: id, Name: name, Payload: payload})
+	}
+	queryResult := func(env *TestWorkflowEnvironment, id string) EntityCommandResult {
+		value, err := env.QueryWorkflow(EntityCommandResultQueryType, id)
+		require.NoError(t, err)
+		var result EntityCommandResult
+		require.NoError(t, value.Get(&result))
+		return result
+	}
+
+	t.Run("commands", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		startTime := env.Now()
+		var results []EntityCommandResult
+		env.RegisterDelayedCallback(func() {
+			sendCommand(env, "1", "add", testAddItem{Item: "book"})
+			sendCommand(env, "1", "add", testAddItem{Item: "book"}) // resent, not handled again
+			sendCommand(env, "2", "add", testAddItem{})
+			sendCommand(env, "3", "remove", testAddItem{Item: "book"})
+		}, time.Minute)
+		env.RegisterDelayedCallback(func() {
+			results = append(results, queryResult(env, "1"), queryResult(env, "2"), queryResult(env, "3"),
+				queryResult(env, "4"))
+		}, 2*time.Minute)
+		env.ExecuteWorkflow(cartWorkflow, nil)
+
+		// the entity continues as new with its state after the idle timeout
+		require.True(t, env.IsWorkflowCompleted())
+		var continueAsNew *ContinueAsNewError
+		require.True(t, errors.As(env.GetWorkflowError(), &continueAsNew), "%v", env.GetWorkflowError())
+		require.Len(t, continueAsNew.Args(), 1)
+		snapshot := continueAsNew.Args()[0].(*EntitySnapshot[testCart])
+		assert.Equal(t, []string{"book"}, snapshot.State.Items)
+		assert.Equal(t, time.Hour+time.Minute, env.Now().Sub(startTime))
+
+		require.Len(t, results, 4)
+		// the first result was evicted by the third one
+		assert.Equal(t, EntityCommandResult{ID: "1", Unknown: true}, results[0])
+		assert.Equal(t, EntityCommandResult{ID: "2", Handled: true, Error: "item is required"}, results[1])
+		assert.Equal(t, EntityCommandResult{ID: "3", Handled: true, Error: `unknown command "remove"`}, results[2])
+		assert.Equal(t, EntityCommandResult{ID: "4", Unknown: true}, results[3])
+	})
+
+	t.Run("idle without commands", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterDelayedCallback(func() {
+			env.CancelWorkflow()
+		}, 3*time.Hour)
+		env.ExecuteWorkflow(cartWorkflow, &EntitySnapshot[testCart]{State: testCart{Items: []string{"book"}}})
+
+		// the run has no history to drop, and keeps waiting for commands
+		require.True(t, env.IsWorkflowCompleted())
+		var canceled *CanceledError
+		require.True(t, errors.As(env.GetWorkflowError(), &canceled), "%v", env.GetWorkflowError())
+	})
+
+	t.Run("pending command", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		var pending EntityCommandResult
+		slowWorkflow := func(ctx Context, snapshot *EntitySnapshot[testCart]) (testCart, error) {
+			return RunCommandEntity(ctx, snapshot, CommandEntityOptions[testCart]{
+				Commands: map[string]EntityCommandHandler[testCart]{
+					"add": NewEntityCommandHandler(func(ctx Context, cart *testCart, command testAddItem) (int, error) {
+						if err := Sleep(ctx, time.Minute); err != nil {
+							return 0, err
+						}
+						cart.Items = append(cart.Items, command.Item)
+						return len(cart.Items), nil
+					}),
+				},
+				IdleTimeout: time.Hour,
+			})
+		}
+		env.RegisterWorkflow(slowWorkflow)
+		env.RegisterDelayedCallback(func() {
+			sendCommand(env, "1", "add", testAddItem{Item: "book"})
+		}, time.Minute)
+		env.RegisterDelayedCallback(func() {
+			pending = queryResult(env, "1")
+		}, 90*time.Second)
+		env.ExecuteWorkflow(slowWorkflow, nil)
+
+		assert.Equal(t, EntityCommandResult{ID: "1"}, pending)
+		// the default ContinueAsNew continues the workflow type with the snapshot
+		require.True(t, env.IsWorkflowCompleted())
+		var continueAsNew *ContinueAsNewError
+		require.True(t, errors.As(env.GetWorkflowError(), &continueAsNew), "%v", env.GetWorkflowError())
+		require.Len(t, continueAsNew.Args(), 1)
+		snapshot := continueAsNew.Args()[0].(*EntitySnapshot[testCart])
+		assert.Equal(t, []string{"book"}, snapshot.State.Items)
+	})
+
+	t.Run("continue as new", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterWorkflow(cartWorkflow)
+		var second EntityCommandResult
+		env.RegisterDelayedCallback(func() {
+			sendCommand(env, "1", "add", testAddItem{Item: "book"})
+			sendCommand(env, "2", "add", testAddItem{Item: "pen"})
+		}, time.Minute)
+		env.RegisterDelayedCallback(func() {
+			second = queryResult(env, "2")
+			sendCommand(env, "3", "add", testAddItem{Item: "ink"})
+		}, 2*time.Minute)
+		env.ExecuteWorkflow(cartWorkflow, nil)
+
+		require.True(t, env.IsWorkflowCompleted())
+		var continueAsNew *ContinueAsNewError
+		require.True(t, errors.As(env.GetWorkflowError(), &continueAsNew), "%v", env.GetWorkflowError())
+		require.Len(t, continueAsNew.Args(), 1)
+		snapshot := continueAsNew.Args()[0].(*EntitySnapshot[testCart])
+		assert.Equal(t, []string{"book", "pen", "ink"}, snapshot.State.Items)
+		require.Len(t, snapshot.Results, 2)
+		assert.Equal(t, second, snapshot.Results[0])
+		var count int
+		require.NoError(t, dc.FromData(snapshot.Results[1].Result, &count))
+		assert.Equal(t, 3, count)
+	})
+}
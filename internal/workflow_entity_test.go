@@ -0,0 +1,117 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunEntity(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	// counterWorkflow is an entity counting the values added to it, which carries the count over when it continues
+	// as new
+	var counterWorkflow func(ctx Context, count int) (int, error)
+	counterWorkflow = func(ctx Context, count int) (int, error) {
+		err := RunEntity(ctx, EntityOptions{
+			Handlers: map[string]func(ctx Context, c Channel) error{
+				"add": func(ctx Context, c Channel) error {
+					var n int
+					c.Receive(ctx, &n)
+					if n < 0 {
+						return errors.New("negative value")
+					}
+					count += n
+					// simulate the history growing with every signal
+					GetWorkflowInfo(ctx).HistoryCount += 10
+					return nil
+				},
+			},
+			IdleTimeout:          time.Hour,
+			MaximumHistoryLength: 100,
+			ContinueAsNew: func(ctx Context) error {
+				return NewContinueAsNewError(ctx, counterWorkflow, count)
+			},
+		})
+		return count, err
+	}
+
+	t.Run("idle timeout", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		var heartbeat EntityHeartbeat
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow("add", 2)
+			env.SignalWorkflow("add", 3)
+		}, time.Minute)
+		env.RegisterDelayedCallback(func() {
+			value, err := env.QueryWorkflow(EntityHeartbeatQueryType)
+			require.NoError(t, err)
+			require.NoError(t, value.Get(&heartbeat))
+			env.SignalWorkflow("add", 5)
+		}, 30*time.Minute)
+		env.ExecuteWorkflow(counterWorkflow, 0)
+
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var count int
+		require.NoError(t, env.GetWorkflowResult(&count))
+		assert.Equal(t, 10, count)
+
+		assert.Equal(t, 2, heartbeat.Signals)
+		assert.Equal(t, heartbeat.RunStartTime.Add(time.Minute), heartbeat.LastSignal)
+		assert.Equal(t, heartbeat.RunStartTime.Add(30*time.Minute), heartbeat.Time)
+		assert.Equal(t, defaultTestWorkflowID, heartbeat.WorkflowID)
+	})
+
+	t.Run("continue as new", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterWorkflow(counterWorkflow)
+		env.RegisterDelayedCallback(func() {
+			for i := 0; i < 12; i++ {
+				env.SignalWorkflow("add", 1)
+			}
+		}, time.Minute)
+		env.ExecuteWorkflow(counterWorkflow, 0)
+
+		require.True(t, env.IsWorkflowCompleted())
+		var continueAsNew *ContinueAsNewError
+		require.True(t, errors.As(env.GetWorkflowError(), &continueAsNew), "%v", env.GetWorkflowError())
+		// the history reaches its maximum length with the 10th signal
+		assert.Equal(t, []interface{}{10}, continueAsNew.Args())
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow("add", -1)
+		}, time.Minute)
+		env.ExecuteWorkflow(counterWorkflow, 0)
+
+		require.True(t, env.IsWorkflowCompleted())
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "negative value")
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrGroup(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	t.Run("first error cancels the group", func(t *testing.T) {
+		var canceled []int
+		workflowFn := func(ctx Context) error {
+			g, groupCtx := NewErrGroup(ctx)
+			for i := 1; i <= 3; i++ {
+				i := i
+				g.Go(func(ctx Context) error {
+					if err := Sleep(ctx, time.Duration(i)*time.Minute); err != nil {
+						canceled = append(canceled, i)
+						return err
+					}
+					return errors.New("failed")
+				})
+			}
+			err := g.Wait(ctx)
+			if groupCtx.Err() == nil {
+				return errors.New("group context is not canceled")
+			}
+			return err
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.Error(t, env.GetWorkflowError())
+		assert.Equal(t, "failed", env.GetWorkflowError().Error())
+		assert.Equal(t, []int{2, 3}, canceled)
+	})
+
+	t.Run("no error", func(t *testing.T) {
+		var done []int
+		workflowFn := func(ctx Context) error {
+			g, groupCtx := NewErrGroup(ctx)
+			for i := 1; i <= 3; i++ {
+				i := i
+				g.Go(func(ctx Context) error {
+					if err := Sleep(ctx, time.Duration(4-i)*time.Minute); err != nil {
+						return err
+					}
+					done = append(done, i)
+					return nil
+				})
+			}
+			if err := g.Wait(ctx); err != nil {
+				return err
+			}
+			if groupCtx.Err() == nil {
+				return errors.New("group context is not canceled")
+			}
+			return nil
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, []int{3, 2, 1}, done)
+	})
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import "reflect"
+
+const featureFlagMarkerIDPrefix = "__feature_flag_"
+
+type (
+	// FeatureFlagProvider decides whether feature flags are enabled for a workflow execution. It is installed on a
+	// worker with NewFeatureFlagInterceptorFactory and evaluated through IsFeatureEnabled. IsEnabled is called from
+	// workflow code, so it must not block, and it is called concurrently for the workflows of a worker.
+	FeatureFlagProvider interface {
+		IsEnabled(flag string, info *WorkflowInfo) bool
+	}
+
+	// FeatureFlagProviderFunc is an adapter to allow the use of ordinary functions as FeatureFlagProvider.
+	FeatureFlagProviderFunc func(flag string, info *WorkflowInfo) bool
+
+	featureFlagInterceptorFactory struct {
+		provider FeatureFlagProvider
+	}
+
+	featureFlagInterceptor struct {
+		WorkflowInterceptorBase
+		flags *featureFlags
+	}
+
+	// featureFlags holds the provider and the decisions already made for one workflow execution.
+	featureFlags struct {
+		provider  FeatureFlagProvider
+		info      *WorkflowInfo
+		decisions map[string]bool
+	}
+
+	featureFlagsContextKeyType struct{}
+)
+
+var featureFlagsContextKey = featureFlagsContextKeyType{}
+
+// IsEnabled calls f(flag, info).
+func (f FeatureFlagProviderFunc) IsEnabled(flag string, info *WorkflowInfo) bool {
+	return f(flag, info)
+}
+
+// NewFeatureFlagInterceptorFactory returns a WorkflowInterceptorFactory making provider available to IsFeatureEnabled
+// in every workflow of a worker.
+func NewFeatureFlagInterceptorFactory(provider FeatureFlagProvider) WorkflowInterceptorFactory {
+	return &featureFlagInterceptorFactory{provider: provider}
+}
+
+func (f *featureFlagInterceptorFactory) NewInterceptor(info *WorkflowInfo, next WorkflowInterceptor) WorkflowInterceptor {
+	return &featureFlagInterceptor{
+		WorkflowInterceptorBase: WorkflowInterceptorBase{Next: next},
+		flags: &featureFlags{
+			provider:  f.provider,
+			info:      info,
+			decisions: make(map[string]bool),
+		},
+	}
+}
+
+func (t *featureFlagInterceptor) ExecuteWorkflow(ctx Context, workflowType string, args ...interface{}) []interface{} {
+	return t.Next.ExecuteWorkflow(WithValue(ctx, featureFlagsContextKey, t.flags), workflowType, args...)
+}
+
+// IsFeatureEnabled returns whether flag is enabled for the workflow execution, as decided by the FeatureFlagProvider
+// installed with NewFeatureFlagInterceptorFactory. The provider is asked once per flag and execution, and its decision
+// is recorded in the workflow history as a marker, so it stays the same for the rest of the execution and when the
+// workflow is replayed, even if the provider changes its mind in the meantime. Flags are disabled when no provider is
+// installed; the decision is recorded in that case too, so installing a provider later does not break the replay of
+// running workflows.
+func IsFeatureEnabled(ctx Context, flag string) bool {
+	flags, _ := ctx.Value(featureFlagsContextKey).(*featureFlags)
+	if flags != nil {
+		if enabled, ok := flags.decisions[flag]; ok {
+			return enabled
+		}
+	}
+
+	var enabled bool
+	value := MutableSideEffect(ctx, featureFlagMarkerIDPrefix+flag, func(ctx Context) interface{} {
+		if flags == nil || flags.provider == nil {
+			return false
+		}
+		return flags.provider.IsEnabled(flag, flags.info)
+	}, func(a, b interface{}) bool {
+		return reflect.DeepEqual(a, b)
+	})
+	if err := value.Get(&enabled); err != nil {
+		panic(err)
+	}
+	if flags != nil {
+		flags.decisions[flag] = enabled
+	}
+	return enabled
+}
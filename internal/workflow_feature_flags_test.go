@@ -0,0 +1,75 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	workflowFn := func(ctx Context) ([]bool, error) {
+		results := []bool{IsFeatureEnabled(ctx, "a"), IsFeatureEnabled(ctx, "b")}
+		if err := Sleep(ctx, time.Minute); err != nil {
+			return nil, err
+		}
+		return append(results, IsFeatureEnabled(ctx, "a"), IsFeatureEnabled(ctx, "b")), nil
+	}
+
+	t.Run("provider", func(t *testing.T) {
+		calls := make(map[string]int)
+		var workflowType string
+		provider := FeatureFlagProviderFunc(func(flag string, info *WorkflowInfo) bool {
+			calls[flag]++
+			workflowType = info.WorkflowType.Name
+			// flip the decision on every call, IsFeatureEnabled must stick to the first one
+			return (flag == "a") == (calls[flag]%2 == 1)
+		})
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterWorkflowWithOptions(workflowFn, RegisterWorkflowOptions{Name: "flagged"})
+		env.SetWorkerOptions(WorkerOptions{
+			WorkflowInterceptorChainFactories: []WorkflowInterceptorFactory{NewFeatureFlagInterceptorFactory(provider)},
+		})
+		env.ExecuteWorkflow("flagged")
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var results []bool
+		require.NoError(t, env.GetWorkflowResult(&results))
+		assert.Equal(t, []bool{true, false, true, false}, results)
+		assert.Equal(t, map[string]int{"a": 1, "b": 1}, calls)
+		assert.Equal(t, "flagged", workflowType)
+	})
+
+	t.Run("no provider", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var results []bool
+		require.NoError(t, env.GetWorkflowResult(&results))
+		assert.Equal(t, []bool{false, false, false, false}, results)
+	})
+}
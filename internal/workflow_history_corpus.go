@@ -0,0 +1,340 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/backoff"
+	"go.uber.org/cadence/internal/common/serializer"
+)
+
+const (
+	defaultHistoryCorpusMaxExecutions = 100
+	historyCorpusListPageSize         = 100
+	historyCorpusFileExtension        = ".json"
+)
+
+type (
+	// HistoryCorpusOptions configures DownloadHistoryCorpus.
+	HistoryCorpusOptions struct {
+		// Query is the visibility query selecting the executions to download,
+		// e.g. "WorkflowType = 'MyWorkflow' AND CloseTime > 0".
+		// Optional: all executions of the domain are considered if empty.
+		Query string
+
+		// MaxExecutions is the maximum number of executions to download.
+		// Optional: defaults to 100.
+		MaxExecutions int
+
+		// Redactor is called on every history event before it is stored and may modify it in place,
+		// e.g. to strip payloads which must not leave the production environment. RedactHistoryPayloads
+		// can be used for that purpose.
+		// Optional: events are stored unmodified if nil.
+		Redactor func(event *shared.HistoryEvent)
+
+		// FeatureFlags are used when calling the Cadence service.
+		// Optional: default feature flags are used if not set.
+		FeatureFlags FeatureFlags
+	}
+
+	// HistoryCorpusEntry describes a single workflow history stored by DownloadHistoryCorpus.
+	HistoryCorpusEntry struct {
+		WorkflowType string
+		Execution    WorkflowExecution
+		// Path is the file the history was written to. It can be replayed with
+		// WorkflowReplayer.ReplayWorkflowHistoryFromJSONFile.
+		Path string
+		// EventCount is the number of events in the stored history.
+		EventCount int
+	}
+)
+
+// DownloadHistoryCorpus downloads the histories of the executions matching options.Query and stores them in dir,
+// building a golden corpus for replay tests. Each history is written as a JSON array of events, the format used
+// by the CLI and accepted by WorkflowReplayer, to dir/<workflow type>/<workflow ID>_<run ID>.json, with path
+// components escaped. Existing files are overwritten, so the corpus can be refreshed in place.
+//
+// Note that redacted payloads are replayed as-is: workflows which decode their input, activity results or signal
+// payloads may behave differently (or fail) when replaying a redacted history.
+func DownloadHistoryCorpus(
+	ctx context.Context,
+	service workflowserviceclient.Interface,
+	domain string,
+	dir string,
+	options HistoryCorpusOptions,
+) ([]HistoryCorpusEntry, error) {
+	maxExecutions := options.MaxExecutions
+	if maxExecutions <= 0 {
+		maxExecutions = defaultHistoryCorpusMaxExecutions
+	}
+
+	executions, err := listHistoryCorpusExecutions(ctx, service, domain, options.Query, maxExecutions, options.FeatureFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HistoryCorpusEntry, 0, len(executions))
+	for _, info := range executions {
+		execution := WorkflowExecution{
+			ID:    info.Execution.GetWorkflowId(),
+			RunID: info.Execution.GetRunId(),
+		}
+		events, err := getHistoryCorpusEvents(ctx, service, domain, execution, options.FeatureFlags)
+		if err != nil {
+			return entries, fmt.Errorf("failed to get history of workflow %v, run %v: %w", execution.ID, execution.RunID, err)
+		}
+		if options.Redactor != nil {
+			for _, event := range events {
+				options.Redactor(event)
+			}
+		}
+
+		workflowType := info.GetType().GetName()
+		path, err := writeHistoryCorpusFile(dir, workflowType, execution, events)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, HistoryCorpusEntry{
+			WorkflowType: workflowType,
+			Execution:    execution,
+			Path:         path,
+			EventCount:   len(events),
+		})
+	}
+	return entries, nil
+}
+
+// RedactHistoryPayloads is a HistoryCorpusOptions.Redactor which strips the user payloads (inputs, results,
+// failure details, signal data, memos and search attributes) of an event. Marker details are kept,
+// as versioning and side effect markers are required for a faithful replay.
+func RedactHistoryPayloads(event *shared.HistoryEvent) {
+	if attr := event.WorkflowExecutionStartedEventAttributes; attr != nil {
+		attr.Input = nil
+		attr.Memo = nil
+		attr.SearchAttributes = nil
+		attr.LastCompletionResult = nil
+		attr.ContinuedFailureDetails = nil
+	}
+	if attr := event.WorkflowExecutionCompletedEventAttributes; attr != nil {
+		attr.Result = nil
+	}
+	if attr := event.WorkflowExecutionFailedEventAttributes; attr != nil {
+		attr.Details = nil
+	}
+	if attr := event.WorkflowExecutionCanceledEventAttributes; attr != nil {
+		attr.Details = nil
+	}
+	if attr := event.WorkflowExecutionSignaledEventAttributes; attr != nil {
+		attr.Input = nil
+	}
+	if attr := event.WorkflowExecutionContinuedAsNewEventAttributes; attr != nil {
+		attr.Input = nil
+		attr.Memo = nil
+		attr.SearchAttributes = nil
+		attr.LastCompletionResult = nil
+		attr.FailureDetails = nil
+	}
+	if attr := event.ActivityTaskScheduledEventAttributes; attr != nil {
+		attr.Input = nil
+	}
+	if attr := event.ActivityTaskStartedEventAttributes; attr != nil {
+		attr.LastFailureDetails = nil
+	}
+	if attr := event.ActivityTaskCompletedEventAttributes; attr != nil {
+		attr.Result = nil
+	}
+	if attr := event.ActivityTaskFailedEventAttributes; attr != nil {
+		attr.Details = nil
+	}
+	if attr := event.ActivityTaskTimedOutEventAttributes; attr != nil {
+		attr.Details = nil
+		attr.LastFailureDetails = nil
+	}
+	if attr := event.ActivityTaskCanceledEventAttributes; attr != nil {
+		attr.Details = nil
+	}
+	if attr := event.SignalExternalWorkflowExecutionInitiatedEventAttributes; attr != nil {
+		attr.Input = nil
+	}
+	if attr := event.StartChildWorkflowExecutionInitiatedEventAttributes; attr != nil {
+		attr.Input = nil
+		attr.Memo = nil
+		attr.SearchAttributes = nil
+	}
+	if attr := event.ChildWorkflowExecutionCompletedEventAttributes; attr != nil {
+		attr.Result = nil
+	}
+	if attr := event.ChildWorkflowExecutionFailedEventAttributes; attr != nil {
+		attr.Details = nil
+	}
+	if attr := event.ChildWorkflowExecutionCanceledEventAttributes; attr != nil {
+		attr.Details = nil
+	}
+	if attr := event.UpsertWorkflowSearchAttributesEventAttributes; attr != nil {
+		attr.SearchAttributes = nil
+	}
+}
+
+func listHistoryCorpusExecutions(
+	ctx context.Context,
+	service workflowserviceclient.Interface,
+	domain string,
+	query string,
+	maxExecutions int,
+	featureFlags FeatureFlags,
+) ([]*shared.WorkflowExecutionInfo, error) {
+	var executions []*shared.WorkflowExecutionInfo
+	var nextPageToken []byte
+	for len(executions) < maxExecutions {
+		pageSize := min(maxExecutions-len(executions), historyCorpusListPageSize)
+		response, err := listWorkflowExecutionsPage(ctx, service, domain, query, pageSize, nextPageToken, featureFlags)
+		if err != nil {
+			return nil, err
+		}
+
+		executions = append(executions, response.Executions...)
+		nextPageToken = response.NextPageToken
+		if len(nextPageToken) == 0 {
+			break
+		}
+	}
+
+	if len(executions) > maxExecutions {
+		executions = executions[:maxExecutions]
+	}
+	return executions, nil
+}
+
+func listWorkflowExecutionsPage(
+	ctx context.Context,
+	service workflowserviceclient.Interface,
+	domain string,
+	query string,
+	pageSize int,
+	nextPageToken []byte,
+	featureFlags FeatureFlags,
+) (*shared.ListWorkflowExecutionsResponse, error) {
+	request := &shared.ListWorkflowExecutionsRequest{
+		Domain:        common.StringPtr(domain),
+		PageSize:      common.Int32Ptr(int32(pageSize)),
+		NextPageToken: nextPageToken,
+		Query:         common.StringPtr(query),
+	}
+
+	var response *shared.ListWorkflowExecutionsResponse
+	err := backoff.Retry(ctx,
+		func() error {
+			tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
+			defer cancel()
+
+			var err error
+			response, err = service.ListWorkflowExecutions(tchCtx, request, opt...)
+			return err
+		},
+		createDynamicServiceRetryPolicy(ctx),
+		isServiceTransientError,
+	)
+	return response, err
+}
+
+func getHistoryCorpusEvents(
+	ctx context.Context,
+	service workflowserviceclient.Interface,
+	domain string,
+	execution WorkflowExecution,
+	featureFlags FeatureFlags,
+) ([]*shared.HistoryEvent, error) {
+	var events []*shared.HistoryEvent
+	var nextPageToken []byte
+	for {
+		request := &shared.GetWorkflowExecutionHistoryRequest{
+			Domain: common.StringPtr(domain),
+			Execution: &shared.WorkflowExecution{
+				WorkflowId: common.StringPtr(execution.ID),
+				RunId:      common.StringPtr(execution.RunID),
+			},
+			NextPageToken: nextPageToken,
+		}
+
+		var response *shared.GetWorkflowExecutionHistoryResponse
+		if err := backoff.Retry(ctx,
+			func() error {
+				tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
+				defer cancel()
+
+				var err error
+				response, err = service.GetWorkflowExecutionHistory(tchCtx, request, opt...)
+				return err
+			},
+			createDynamicServiceRetryPolicy(ctx),
+			func(err error) bool {
+				if _, ok := err.(*shared.InternalServiceError); ok {
+					// treat InternalServiceError as non-retryable, as the workflow history may be corrupted
+					return false
+				}
+				return isServiceTransientError(err)
+			},
+		); err != nil {
+			return nil, err
+		}
+
+		if response.RawHistory != nil {
+			history, err := serializer.DeserializeBlobDataToHistoryEvents(response.RawHistory, shared.HistoryEventFilterTypeAllEvent)
+			if err != nil {
+				return nil, err
+			}
+			response.History = history
+		}
+
+		events = append(events, response.History.GetEvents()...)
+		nextPageToken = response.NextPageToken
+		if len(nextPageToken) == 0 {
+			return events, nil
+		}
+	}
+}
+
+func writeHistoryCorpusFile(dir string, workflowType string, execution WorkflowExecution, events []*shared.HistoryEvent) (string, error) {
+	typeDir := filepath.Join(dir, url.PathEscape(workflowType))
+	if err := os.MkdirAll(typeDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create corpus directory: %w", err)
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize history of workflow %v, run %v: %w", execution.ID, execution.RunID, err)
+	}
+
+	path := filepath.Join(typeDir, url.PathEscape(execution.ID)+"_"+url.PathEscape(execution.RunID)+historyCorpusFileExtension)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write history of workflow %v, run %v: %w", execution.ID, execution.RunID, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestDownloadHistoryCorpus(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(mockCtrl)
+
+	executionInfo := func(workflowID string) *shared.WorkflowExecutionInfo {
+		return &shared.WorkflowExecutionInfo{
+			Execution: &shared.WorkflowExecution{WorkflowId: common.StringPtr(workflowID), RunId: common.StringPtr("run")},
+			Type:      &shared.WorkflowType{Name: common.StringPtr("go.uber.org/cadence/internal.testReplayWorkflow")},
+		}
+	}
+	service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).
+		DoAndReturn(func(_ context.Context, request *shared.ListWorkflowExecutionsRequest, _ ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+			assert.Equal(t, "WorkflowType = 'testReplayWorkflow'", request.GetQuery())
+			assert.Equal(t, int32(2), request.GetPageSize())
+			return &shared.ListWorkflowExecutionsResponse{
+				Executions:    []*shared.WorkflowExecutionInfo{executionInfo("wid/1")},
+				NextPageToken: []byte("next"),
+			}, nil
+		})
+	service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).
+		DoAndReturn(func(_ context.Context, request *shared.ListWorkflowExecutionsRequest, _ ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+			assert.Equal(t, []byte("next"), request.NextPageToken)
+			assert.Equal(t, int32(1), request.GetPageSize())
+			return &shared.ListWorkflowExecutionsResponse{
+				Executions: []*shared.WorkflowExecutionInfo{executionInfo("wid2")},
+			}, nil
+		})
+
+	// every history is served in two pages
+	events := getTestReplayWorkflowFullHistory(t).Events
+	service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).
+		DoAndReturn(func(_ context.Context, request *shared.GetWorkflowExecutionHistoryRequest, _ ...yarpc.CallOption) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+			if request.NextPageToken == nil {
+				return &shared.GetWorkflowExecutionHistoryResponse{
+					History:       &shared.History{Events: events[:5]},
+					NextPageToken: []byte("next"),
+				}, nil
+			}
+			return &shared.GetWorkflowExecutionHistoryResponse{
+				History: &shared.History{Events: events[5:]},
+			}, nil
+		}).Times(4)
+
+	dir := t.TempDir()
+	redacted := 0
+	entries, err := DownloadHistoryCorpus(context.Background(), service, testDomain, dir, HistoryCorpusOptions{
+		Query:         "WorkflowType = 'testReplayWorkflow'",
+		MaxExecutions: 2,
+		Redactor: func(event *shared.HistoryEvent) {
+			redacted++
+			RedactHistoryPayloads(event)
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, 2*len(events), redacted)
+	assert.Equal(t, "wid/1", entries[0].Execution.ID)
+	assert.Equal(t, filepath.Join(dir, "go.uber.org%2Fcadence%2Finternal.testReplayWorkflow", "wid%2F1_run.json"), entries[0].Path)
+	assert.Equal(t, len(events), entries[0].EventCount)
+
+	replayer := NewWorkflowReplayer()
+	replayer.RegisterWorkflow(testReplayWorkflow)
+	for _, entry := range entries {
+		_, err := os.Stat(entry.Path)
+		require.NoError(t, err)
+		assert.NoError(t, replayer.ReplayWorkflowHistoryFromJSONFile(getTestLogger(t), entry.Path))
+	}
+}
+
+func TestRedactHistoryPayloads(t *testing.T) {
+	started := createTestEventWorkflowExecutionStarted(1, &shared.WorkflowExecutionStartedEventAttributes{
+		Input: []byte("secret"),
+		Memo:  &shared.Memo{Fields: map[string][]byte{"key": []byte("secret")}},
+	})
+	RedactHistoryPayloads(started)
+	assert.Nil(t, started.WorkflowExecutionStartedEventAttributes.Input)
+	assert.Nil(t, started.WorkflowExecutionStartedEventAttributes.Memo)
+
+	marker := &shared.HistoryEvent{
+		EventType: shared.EventTypeMarkerRecorded.Ptr(),
+		MarkerRecordedEventAttributes: &shared.MarkerRecordedEventAttributes{
+			MarkerName: common.StringPtr(versionMarkerName),
+			Details:    []byte("version"),
+		},
+	}
+	RedactHistoryPayloads(marker)
+	assert.Equal(t, []byte("version"), marker.MarkerRecordedEventAttributes.Details)
+}
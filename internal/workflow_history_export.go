@@ -0,0 +1,227 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+	commonhistory "go.uber.org/cadence/internal/common/history"
+)
+
+const historyExportListPageSize = 100
+
+type (
+	// HistoryExportOptions configures ExportHistories.
+	HistoryExportOptions struct {
+		// Query is the visibility query selecting the executions to export,
+		// e.g. "WorkflowType = 'MyWorkflow' AND CloseTime > 0".
+		// Optional: all executions of the domain are considered if empty.
+		// Executions which are still open are skipped.
+		Query string
+
+		// MaxExecutions is the maximum number of executions to export.
+		// Optional: all matching executions are exported if zero.
+		MaxExecutions int
+
+		// Extractor is called with the history of every exported execution and returns custom fields to add
+		// to its record, e.g. values decoded from the workflow input or result. An error aborts the export.
+		// Optional: records have no custom fields if nil.
+		Extractor func(events []*shared.HistoryEvent) (map[string]interface{}, error)
+
+		// FeatureFlags are used when calling the Cadence service.
+		// Optional: default feature flags are used if not set.
+		FeatureFlags FeatureFlags
+	}
+
+	// HistoryExportRecord is the flattened description of a closed workflow execution written by ExportHistories.
+	HistoryExportRecord struct {
+		Domain         string                       `json:"domain"`
+		WorkflowType   string                       `json:"workflowType"`
+		WorkflowID     string                       `json:"workflowId"`
+		RunID          string                       `json:"runId"`
+		TaskList       string                       `json:"taskList"`
+		CloseStatus    string                       `json:"closeStatus"`
+		StartTime      time.Time                    `json:"startTime"`
+		CloseTime      time.Time                    `json:"closeTime"`
+		DurationMillis int64                        `json:"durationMillis"`
+		HistoryLength  int                          `json:"historyLength"`
+		Activities     []HistoryExportActivityStats `json:"activities"`
+		// Fields are the custom fields returned by HistoryExportOptions.Extractor.
+		Fields map[string]interface{} `json:"fields,omitempty"`
+	}
+
+	// HistoryExportActivityStats aggregates the activities of a single type scheduled by an exported execution.
+	HistoryExportActivityStats struct {
+		ActivityType string `json:"activityType"`
+		Scheduled    int    `json:"scheduled"`
+		Completed    int    `json:"completed"`
+		Failed       int    `json:"failed"`
+		TimedOut     int    `json:"timedOut"`
+		Canceled     int    `json:"canceled"`
+		// Retries is the number of retries done by the server for all activities of the type.
+		Retries int64 `json:"retries"`
+		// TotalMillis and MaxMillis are the total and longest time from scheduling to closing the closed activities.
+		TotalMillis int64 `json:"totalMillis"`
+		MaxMillis   int64 `json:"maxMillis"`
+	}
+
+	// HistoryExportWriter writes the records of ExportHistories to a sink, e.g. a JSON Lines or a Parquet file.
+	HistoryExportWriter interface {
+		Write(record *HistoryExportRecord) error
+	}
+
+	jsonLinesHistoryExportWriter struct {
+		encoder *json.Encoder
+	}
+)
+
+// NewJSONLinesHistoryExportWriter returns a HistoryExportWriter which writes every record to w as a single line of
+// JSON, the format accepted by most data warehouse loaders.
+func NewJSONLinesHistoryExportWriter(w io.Writer) HistoryExportWriter {
+	return &jsonLinesHistoryExportWriter{encoder: json.NewEncoder(w)}
+}
+
+func (w *jsonLinesHistoryExportWriter) Write(record *HistoryExportRecord) error {
+	return w.encoder.Encode(record)
+}
+
+// ExportHistories streams the histories of the closed executions matching options.Query and writes a
+// HistoryExportRecord for each of them to writer, as soon as its history is fetched. It returns the number of
+// written records, which is also meaningful when an error is returned.
+func ExportHistories(
+	ctx context.Context,
+	service workflowserviceclient.Interface,
+	domain string,
+	writer HistoryExportWriter,
+	options HistoryExportOptions,
+) (int, error) {
+	exported := 0
+	var nextPageToken []byte
+	for options.MaxExecutions <= 0 || exported < options.MaxExecutions {
+		pageSize := historyExportListPageSize
+		if options.MaxExecutions > 0 {
+			pageSize = min(options.MaxExecutions-exported, pageSize)
+		}
+		response, err := listWorkflowExecutionsPage(ctx, service, domain, options.Query, pageSize, nextPageToken, options.FeatureFlags)
+		if err != nil {
+			return exported, err
+		}
+
+		for _, info := range response.Executions {
+			if info.CloseTime == nil || (options.MaxExecutions > 0 && exported >= options.MaxExecutions) {
+				continue
+			}
+			record, err := newHistoryExportRecord(ctx, service, domain, info, options)
+			if err != nil {
+				return exported, err
+			}
+			if err := writer.Write(record); err != nil {
+				return exported, fmt.Errorf("failed to write record of workflow %v, run %v: %w", record.WorkflowID, record.RunID, err)
+			}
+			exported++
+		}
+
+		nextPageToken = response.NextPageToken
+		if len(nextPageToken) == 0 {
+			break
+		}
+	}
+	return exported, nil
+}
+
+func newHistoryExportRecord(
+	ctx context.Context,
+	service workflowserviceclient.Interface,
+	domain string,
+	info *shared.WorkflowExecutionInfo,
+	options HistoryExportOptions,
+) (*HistoryExportRecord, error) {
+	execution := WorkflowExecution{
+		ID:    info.Execution.GetWorkflowId(),
+		RunID: info.Execution.GetRunId(),
+	}
+	events, err := getHistoryCorpusEvents(ctx, service, domain, execution, options.FeatureFlags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history of workflow %v, run %v: %w", execution.ID, execution.RunID, err)
+	}
+
+	startTime := time.Unix(0, info.GetStartTime()).UTC()
+	closeTime := time.Unix(0, info.GetCloseTime()).UTC()
+	record := &HistoryExportRecord{
+		Domain:         domain,
+		WorkflowType:   info.GetType().GetName(),
+		WorkflowID:     execution.ID,
+		RunID:          execution.RunID,
+		TaskList:       info.GetTaskList(),
+		CloseStatus:    info.GetCloseStatus().String(),
+		StartTime:      startTime,
+		CloseTime:      closeTime,
+		DurationMillis: closeTime.Sub(startTime).Milliseconds(),
+		HistoryLength:  len(events),
+		Activities:     historyExportActivityStats(events),
+	}
+	if options.Extractor != nil {
+		if record.Fields, err = options.Extractor(events); err != nil {
+			return nil, fmt.Errorf("failed to extract fields of workflow %v, run %v: %w", execution.ID, execution.RunID, err)
+		}
+	}
+	return record, nil
+}
+
+// historyExportActivityStats aggregates the activities of the history by type, in the order the types were first
+// scheduled.
+func historyExportActivityStats(events []*shared.HistoryEvent) []HistoryExportActivityStats {
+	stats := []HistoryExportActivityStats{}
+	index := make(map[string]int)
+	for _, activity := range commonhistory.Activities(events) {
+		i, ok := index[activity.ActivityType]
+		if !ok {
+			i = len(stats)
+			index[activity.ActivityType] = i
+			stats = append(stats, HistoryExportActivityStats{ActivityType: activity.ActivityType})
+		}
+		s := &stats[i]
+		s.Scheduled++
+		s.Retries += int64(activity.Attempt)
+		switch activity.State {
+		case commonhistory.ActivityStateCompleted:
+			s.Completed++
+		case commonhistory.ActivityStateFailed:
+			s.Failed++
+		case commonhistory.ActivityStateTimedOut:
+			s.TimedOut++
+		case commonhistory.ActivityStateCanceled:
+			s.Canceled++
+		}
+		if !activity.Closed.IsZero() {
+			millis := activity.Closed.Sub(activity.Scheduled).Milliseconds()
+			s.TotalMillis += millis
+			s.MaxMillis = max(s.MaxMillis, millis)
+		}
+	}
+	return stats
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestExportHistories(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(mockCtrl)
+
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	executionInfo := func(workflowID string, closed bool) *shared.WorkflowExecutionInfo {
+		info := &shared.WorkflowExecutionInfo{
+			Execution: &shared.WorkflowExecution{WorkflowId: common.StringPtr(workflowID), RunId: common.StringPtr("run")},
+			Type:      &shared.WorkflowType{Name: common.StringPtr("testReplayWorkflow")},
+			StartTime: common.Int64Ptr(startTime.UnixNano()),
+			TaskList:  common.StringPtr(testTaskList),
+		}
+		if closed {
+			info.CloseTime = common.Int64Ptr(startTime.Add(time.Minute).UnixNano())
+			info.CloseStatus = shared.WorkflowExecutionCloseStatusCompleted.Ptr()
+		}
+		return info
+	}
+	service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).
+		DoAndReturn(func(_ context.Context, request *shared.ListWorkflowExecutionsRequest, _ ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+			assert.Equal(t, "WorkflowType = 'testReplayWorkflow'", request.GetQuery())
+			assert.Equal(t, int32(2), request.GetPageSize())
+			return &shared.ListWorkflowExecutionsResponse{
+				Executions:    []*shared.WorkflowExecutionInfo{executionInfo("wid1", true), executionInfo("open", false)},
+				NextPageToken: []byte("next"),
+			}, nil
+		})
+	service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).
+		DoAndReturn(func(_ context.Context, request *shared.ListWorkflowExecutionsRequest, _ ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+			assert.Equal(t, []byte("next"), request.NextPageToken)
+			assert.Equal(t, int32(1), request.GetPageSize())
+			return &shared.ListWorkflowExecutionsResponse{
+				Executions:    []*shared.WorkflowExecutionInfo{executionInfo("wid2", true)},
+				NextPageToken: []byte("more"),
+			}, nil
+		})
+
+	events := getTestReplayWorkflowFullHistory(t).Events
+	service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.GetWorkflowExecutionHistoryResponse{History: &shared.History{Events: events}}, nil).
+		Times(2)
+
+	var output bytes.Buffer
+	exported, err := ExportHistories(context.Background(), service, testDomain, NewJSONLinesHistoryExportWriter(&output), HistoryExportOptions{
+		Query:         "WorkflowType = 'testReplayWorkflow'",
+		MaxExecutions: 2,
+		Extractor: func(events []*shared.HistoryEvent) (map[string]interface{}, error) {
+			return map[string]interface{}{"events": len(events)}, nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, exported)
+
+	decoder := json.NewDecoder(&output)
+	var records []HistoryExportRecord
+	for decoder.More() {
+		var record HistoryExportRecord
+		require.NoError(t, decoder.Decode(&record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "wid1", records[0].WorkflowID)
+	assert.Equal(t, "wid2", records[1].WorkflowID)
+
+	record := records[0]
+	assert.Equal(t, testDomain, record.Domain)
+	assert.Equal(t, "testReplayWorkflow", record.WorkflowType)
+	assert.Equal(t, testTaskList, record.TaskList)
+	assert.Equal(t, "COMPLETED", record.CloseStatus)
+	assert.Equal(t, startTime, record.StartTime)
+	assert.Equal(t, int64(time.Minute/time.Millisecond), record.DurationMillis)
+	assert.Equal(t, len(events), record.HistoryLength)
+	assert.Equal(t, []HistoryExportActivityStats{{ActivityType: "testActivity", Scheduled: 1, Completed: 1}}, record.Activities)
+	assert.Equal(t, map[string]interface{}{"events": float64(len(events))}, record.Fields)
+}
+
+func TestExportHistories_ExtractorError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(mockCtrl)
+	service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.ListWorkflowExecutionsResponse{
+			Executions: []*shared.WorkflowExecutionInfo{{
+				Execution: &shared.WorkflowExecution{WorkflowId: common.StringPtr("wid"), RunId: common.StringPtr("run")},
+				CloseTime: common.Int64Ptr(1),
+			}},
+		}, nil)
+	service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.GetWorkflowExecutionHistoryResponse{History: getTestReplayWorkflowFullHistory(t)}, nil)
+
+	var output bytes.Buffer
+	exported, err := ExportHistories(context.Background(), service, testDomain, NewJSONLinesHistoryExportWriter(&output), HistoryExportOptions{
+		Extractor: func(events []*shared.HistoryEvent) (map[string]interface{}, error) {
+			return nil, errors.New("undecodable input")
+		},
+	})
+	assert.ErrorContains(t, err, "undecodable input")
+	assert.Zero(t, exported)
+	assert.Zero(t, output.Len())
+}
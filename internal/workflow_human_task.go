@@ -0,0 +1,323 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// humanTaskQueryTypePrefix is the query type of the HumanTaskStatus of a human task, followed by its name.
+const humanTaskQueryTypePrefix = "__human_task:"
+
+const (
+	// HumanTaskPending is the state of a human task which was neither completed nor expired yet.
+	HumanTaskPending = "pending"
+	// HumanTaskCompleted is the state of a human task which received a valid completion signal.
+	HumanTaskCompleted = "completed"
+	// HumanTaskExpired is the state of a human task whose deadline passed before it was completed.
+	HumanTaskExpired = "expired"
+	// HumanTaskCanceled is the state of a human task whose wait was canceled.
+	HumanTaskCanceled = "canceled"
+)
+
+// ErrHumanTaskExpired is returned by HumanTask.Wait when HumanTaskOptions.Deadline passed before the task was completed.
+var ErrHumanTaskExpired = errors.New("human task expired before it was completed")
+
+type (
+	// HumanTaskOptions configure a HumanTask, see NewHumanTask.
+	HumanTaskOptions struct {
+		// Name - The name of the task, which distinguishes the tasks of a workflow in their query types. Required.
+		Name string
+
+		// SignalName - The name of the signal which completes the task, with the result of the task as its payload.
+		// Optional: defaulted to Name.
+		SignalName string
+
+		// Assignee - The person or group the task is assigned to, which is passed to the reminders.
+		// Optional: default is empty.
+		Assignee string
+
+		// Deadline - The time after creating the task after which Wait gives up and returns ErrHumanTaskExpired.
+		// Optional: defaulted to no deadline.
+		Deadline time.Duration
+
+		// ReminderInterval - The interval in which the Reminder activity is executed while the task is pending.
+		// Optional: defaulted to no reminders.
+		ReminderInterval time.Duration
+
+		// Reminder - The activity which reminds the assignee of the task, e.g. by sending an email. It is called with
+		// a HumanTaskReminder, using the activity options of the ctx passed to Wait. Failures are logged, and do not
+		// fail the task. Required when ReminderInterval is set.
+		Reminder interface{}
+
+		// Escalations - The escalations of the task while it is pending, in any order.
+		// Optional: default is no escalations.
+		Escalations []HumanTaskEscalation
+
+		// Validator - Validates the result of the task received with the signal. A result which fails to validate is
+		// recorded in HumanTaskStatus.InvalidCompletions, and the task keeps waiting for a valid one.
+		// Optional: by default, every result which decodes into the value passed to Wait is valid.
+		Validator func(value interface{}) error
+	}
+
+	// HumanTaskEscalation escalates a human task which is still pending some time after it was created.
+	HumanTaskEscalation struct {
+		// After - The time after creating the task at which it is escalated. Required.
+		After time.Duration
+
+		// Activity - The activity called with a HumanTaskReminder on escalation, e.g. to notify a manager.
+		// Optional: by default, no activity is executed.
+		Activity interface{}
+
+		// Assignee - The assignee the task is reassigned to, which is passed to the following reminders.
+		// Optional: by default, the assignee is kept.
+		Assignee string
+	}
+
+	// HumanTaskReminder is the argument of the reminder and escalation activities of a human task.
+	HumanTaskReminder struct {
+		Task            string
+		Assignee        string
+		Reminder        int // number of the reminder, starting with 1, or 0 for an escalation
+		EscalationLevel int // number of escalations which happened, including this one
+		Deadline        time.Time
+	}
+
+	// HumanTaskStatus is the status of a human task, returned by its query.
+	HumanTaskStatus struct {
+		Name               string
+		Assignee           string
+		State              string // HumanTaskPending, HumanTaskCompleted, HumanTaskExpired or HumanTaskCanceled
+		Created            time.Time
+		Deadline           time.Time // zero if the task has no deadline
+		Reminders          int
+		EscalationLevel    int
+		InvalidCompletions []string // the errors of the completion signals which were rejected
+		CompletedAt        time.Time
+	}
+
+	// HumanTask waits for a person to complete a task with a signal, see NewHumanTask.
+	HumanTask struct {
+		options HumanTaskOptions
+		status  HumanTaskStatus
+		waiting bool
+	}
+)
+
+// NewHumanTask validates options and returns a pending human task, which registers a query handler for its
+// HumanTaskStatus, see HumanTaskQueryType. Its deadline, reminders and escalations are relative to the time it was
+// created, but only happen while Wait is running.
+func NewHumanTask(ctx Context, options HumanTaskOptions) (*HumanTask, error) {
+	if options.Name == "" {
+		return nil, errors.New("human task Name is required")
+	}
+	if options.SignalName == "" {
+		options.SignalName = options.Name
+	}
+	if options.Deadline < 0 || options.ReminderInterval < 0 {
+		return nil, errors.New("human task Deadline and ReminderInterval must not be negative")
+	}
+	if options.ReminderInterval > 0 && options.Reminder == nil {
+		return nil, errors.New("human task Reminder is required when ReminderInterval is set")
+	}
+	escalations := make([]HumanTaskEscalation, len(options.Escalations))
+	copy(escalations, options.Escalations)
+	sort.SliceStable(escalations, func(i, j int) bool { return escalations[i].After < escalations[j].After })
+	for _, escalation := range escalations {
+		if escalation.After <= 0 {
+			return nil, errors.New("human task escalation After must be positive")
+		}
+	}
+	options.Escalations = escalations
+
+	t := &HumanTask{
+		options: options,
+		status: HumanTaskStatus{
+			Name:     options.Name,
+			Assignee: options.Assignee,
+			State:    HumanTaskPending,
+			Created:  Now(ctx),
+		},
+	}
+	if options.Deadline > 0 {
+		t.status.Deadline = t.status.Created.Add(options.Deadline)
+	}
+	err := setQueryHandler(ctx, HumanTaskQueryType(options.Name), func() (HumanTaskStatus, error) {
+		return t.Status(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// HumanTaskQueryType returns the query type of the HumanTaskStatus of the human task with the given name.
+func HumanTaskQueryType(name string) string {
+	return humanTaskQueryTypePrefix + name
+}
+
+// QueryHumanTaskStatus queries the HumanTaskStatus of the human task with the given name from the workflow run by the
+// test environment.
+func QueryHumanTaskStatus(env *TestWorkflowEnvironment, name string) (HumanTaskStatus, error) {
+	var status HumanTaskStatus
+	value, err := env.QueryWorkflow(HumanTaskQueryType(name))
+	if err != nil {
+		return status, err
+	}
+	err = value.Get(&status)
+	return status, err
+}
+
+// Status returns the current status of the task.
+func (t *HumanTask) Status() HumanTaskStatus {
+	status := t.status
+	status.InvalidCompletions = append([]string(nil), t.status.InvalidCompletions...)
+	return status
+}
+
+// Wait blocks until the task is completed with a signal whose payload decodes into valuePtr and passes the Validator,
+// and sends the reminders and escalations of the task meanwhile. It returns ErrHumanTaskExpired once the deadline
+// passed, or *CanceledError if ctx is canceled. Reminder and escalation activities still running when Wait returns
+// are canceled.
+func (t *HumanTask) Wait(ctx Context, valuePtr interface{}) error {
+	if rv := reflect.ValueOf(valuePtr); !rv.IsValid() || rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("human task value must be a non-nil pointer")
+	}
+	if t.status.State != HumanTaskPending {
+		return fmt.Errorf("human task %q is %s", t.options.Name, t.status.State)
+	}
+	if t.waiting {
+		return fmt.Errorf("human task %q is already being waited for", t.options.Name)
+	}
+	t.waiting = true
+	defer func() { t.waiting = false }()
+
+	waitCtx, cancel := WithCancel(ctx)
+	defer cancel()
+
+	var result error
+	done := false
+	finish := func(state string, err error) {
+		t.status.State = state
+		result = err
+		done = true
+	}
+
+	selector := NewSelector(waitCtx)
+	selector.AddReceive(ctx.Done(), func(c Channel, more bool) {
+		finish(HumanTaskCanceled, ctx.Err())
+	})
+	selector.AddReceive(GetSignalChannel(ctx, t.options.SignalName), func(c Channel, more bool) {
+		if t.receive(c, valuePtr) {
+			t.status.CompletedAt = Now(ctx)
+			finish(HumanTaskCompleted, nil)
+		}
+	})
+	onTimer := func(f Future, fn func()) {
+		if err := f.Get(waitCtx, nil); err != nil {
+			finish(HumanTaskCanceled, err)
+			return
+		}
+		fn()
+	}
+	// the timers are started relative to the creation of the task, so waiting again after a canceled wait resumes
+	// the schedule
+	elapsed := Now(ctx).Sub(t.status.Created)
+	if t.options.Deadline > 0 {
+		selector.AddFuture(NewTimer(waitCtx, t.options.Deadline-elapsed), func(f Future) {
+			onTimer(f, func() { finish(HumanTaskExpired, ErrHumanTaskExpired) })
+		})
+	}
+	var remind func(f Future)
+	remind = func(f Future) {
+		onTimer(f, func() {
+			t.status.Reminders++
+			t.executeActivity(waitCtx, selector, t.options.Reminder, HumanTaskReminder{Reminder: t.status.Reminders})
+			selector.AddFuture(NewTimer(waitCtx, t.options.ReminderInterval), remind)
+		})
+	}
+	if t.options.ReminderInterval > 0 {
+		next := t.options.ReminderInterval*time.Duration(t.status.Reminders+1) - elapsed
+		selector.AddFuture(NewTimer(waitCtx, next), remind)
+	}
+	for _, escalation := range t.options.Escalations[t.status.EscalationLevel:] {
+		escalation := escalation
+		selector.AddFuture(NewTimer(waitCtx, escalation.After-elapsed), func(f Future) {
+			onTimer(f, func() {
+				t.status.EscalationLevel++
+				if escalation.Assignee != "" {
+					t.status.Assignee = escalation.Assignee
+				}
+				if escalation.Activity != nil {
+					t.executeActivity(waitCtx, selector, escalation.Activity, HumanTaskReminder{})
+				}
+			})
+		})
+	}
+
+	for !done {
+		selector.Select(ctx)
+	}
+	if t.status.State == HumanTaskCanceled {
+		// a canceled wait leaves the task pending, to be waited for again
+		t.status.State = HumanTaskPending
+	}
+	return result
+}
+
+// receive receives a completion signal from c into valuePtr, and returns whether it completes the task.
+func (t *HumanTask) receive(c Channel, valuePtr interface{}) bool {
+	value := reflect.New(reflect.TypeOf(valuePtr).Elem())
+	if !c.ReceiveAsync(value.Interface()) {
+		// the channel drops the signals which fail to decode
+		t.status.InvalidCompletions = append(t.status.InvalidCompletions,
+			fmt.Sprintf("payload does not decode into %v", value.Type().Elem()))
+		return false
+	}
+	if t.options.Validator != nil {
+		if err := t.options.Validator(value.Elem().Interface()); err != nil {
+			t.status.InvalidCompletions = append(t.status.InvalidCompletions, err.Error())
+			return false
+		}
+	}
+	reflect.ValueOf(valuePtr).Elem().Set(value.Elem())
+	return true
+}
+
+// executeActivity starts a reminder or escalation activity, and logs its failure.
+func (t *HumanTask) executeActivity(ctx Context, selector Selector, activity interface{}, reminder HumanTaskReminder) {
+	reminder.Task = t.options.Name
+	reminder.Assignee = t.status.Assignee
+	reminder.EscalationLevel = t.status.EscalationLevel
+	reminder.Deadline = t.status.Deadline
+	selector.AddFuture(ExecuteActivity(ctx, activity, reminder), func(f Future) {
+		if err := f.Get(ctx, nil); err != nil && ctx.Err() == nil {
+			GetLogger(ctx).Warn("Human task activity failed.",
+				zap.String("HumanTask", t.options.Name), zap.Error(err))
+		}
+	})
+}
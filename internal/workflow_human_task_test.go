@@ -0,0 +1,160 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type approval struct {
+	Approved bool
+	Comment  string
+}
+
+func TestHumanTask(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	var mu sync.Mutex
+	var reminders, escalations []HumanTaskReminder
+	remindActivity := func(ctx context.Context, reminder HumanTaskReminder) error {
+		mu.Lock()
+		defer mu.Unlock()
+		reminders = append(reminders, reminder)
+		return nil
+	}
+	escalateActivity := func(ctx context.Context, reminder HumanTaskReminder) error {
+		mu.Lock()
+		defer mu.Unlock()
+		escalations = append(escalations, reminder)
+		return errors.New("manager is on vacation")
+	}
+	workflowFn := func(ctx Context) (approval, error) {
+		ctx = WithActivityOptions(ctx, ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+		})
+		task, err := NewHumanTask(ctx, HumanTaskOptions{
+			Name:             "approve",
+			Assignee:         "alice",
+			Deadline:         10 * time.Hour,
+			ReminderInterval: 2 * time.Hour,
+			Reminder:         remindActivity,
+			Escalations: []HumanTaskEscalation{
+				{After: 5 * time.Hour, Activity: escalateActivity, Assignee: "bob"},
+			},
+			Validator: func(value interface{}) error {
+				if a := value.(approval); !a.Approved && a.Comment == "" {
+					return errors.New("rejection needs a comment")
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			return approval{}, err
+		}
+		var result approval
+		err = task.Wait(ctx, &result)
+		return result, err
+	}
+	newEnv := func() *TestWorkflowEnvironment {
+		reminders, escalations = nil, nil
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivity(remindActivity)
+		env.RegisterActivity(escalateActivity)
+		return env
+	}
+
+	t.Run("completed", func(t *testing.T) {
+		env := newEnv()
+		var midway HumanTaskStatus
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow("approve", "not an approval")
+			env.SignalWorkflow("approve", approval{Approved: false})
+		}, time.Hour)
+		env.RegisterDelayedCallback(func() {
+			var err error
+			midway, err = QueryHumanTaskStatus(env, "approve")
+			require.NoError(t, err)
+			env.SignalWorkflow("approve", approval{Approved: true})
+		}, 5*time.Hour+30*time.Minute)
+		env.ExecuteWorkflow(workflowFn)
+
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var result approval
+		require.NoError(t, env.GetWorkflowResult(&result))
+		assert.True(t, result.Approved)
+
+		assert.Equal(t, HumanTaskPending, midway.State)
+		assert.Equal(t, "bob", midway.Assignee)
+		assert.Equal(t, 2, midway.Reminders)
+		assert.Equal(t, 1, midway.EscalationLevel)
+		assert.Equal(t, []string{"payload does not decode into internal.approval", "rejection needs a comment"},
+			midway.InvalidCompletions)
+
+		require.Len(t, reminders, 2)
+		assert.Equal(t, HumanTaskReminder{Task: "approve", Assignee: "alice", Reminder: 1,
+			Deadline: reminders[0].Deadline}, reminders[0])
+		assert.Equal(t, 2, reminders[1].Reminder)
+		require.Len(t, escalations, 1)
+		assert.Equal(t, "bob", escalations[0].Assignee)
+		assert.Equal(t, 1, escalations[0].EscalationLevel)
+
+		status, err := QueryHumanTaskStatus(env, "approve")
+		require.NoError(t, err)
+		assert.Equal(t, HumanTaskCompleted, status.State)
+		assert.Equal(t, status.Created.Add(5*time.Hour+30*time.Minute), status.CompletedAt)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		env := newEnv()
+		env.ExecuteWorkflow(workflowFn)
+
+		require.True(t, env.IsWorkflowCompleted())
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), ErrHumanTaskExpired.Error())
+		assert.Len(t, reminders, 4)
+		for _, reminder := range reminders[2:] {
+			assert.Equal(t, "bob", reminder.Assignee)
+		}
+
+		status, err := QueryHumanTaskStatus(env, "approve")
+		require.NoError(t, err)
+		assert.Equal(t, HumanTaskExpired, status.State)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		env := newEnv()
+		env.ExecuteWorkflow(func(ctx Context) error {
+			_, err := NewHumanTask(ctx, HumanTaskOptions{Name: "approve", ReminderInterval: time.Hour})
+			return err
+		})
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "Reminder is required")
+	})
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+const defaultPollMaximumHistoryLength = 10000
+
+// ErrPollExpired is returned by Poll when PollOptions.MaximumDuration passed before the probe reported completion.
+var ErrPollExpired = errors.New("polling expired before the probe reported completion")
+
+type (
+	// PollOptions configure Poll.
+	PollOptions struct {
+		// InitialInterval - The time to wait between the first and the second probe. Required.
+		InitialInterval time.Duration
+
+		// BackoffCoefficient - The factor the interval grows with after every probe. Must be 1 or larger.
+		// Optional: defaulted to 1, i.e. probes are done in a fixed interval.
+		BackoffCoefficient float64
+
+		// MaximumInterval - The limit of the interval between two probes.
+		// Optional: defaulted to no limit.
+		MaximumInterval time.Duration
+
+		// MaximumDuration - The limit of the time from the first probe, including previous runs of the workflow, after
+		// which Poll gives up and returns ErrPollExpired.
+		// Optional: defaulted to no limit.
+		MaximumDuration time.Duration
+
+		// ContinueAsNew - Called once the history of the workflow has grown to MaximumHistoryLength events, with the
+		// state of the poll. It should return a ContinueAsNewError which passes the state to the new run, which
+		// then sets it as State to resume the poll. Poll returns the error it returns.
+		// Optional: by default, Poll does not continue as new.
+		ContinueAsNew func(ctx Context, state PollState) error
+
+		// MaximumHistoryLength - The length of the history at which ContinueAsNew is called.
+		// Optional: defaulted to 10000 events.
+		MaximumHistoryLength int64
+
+		// State - The state of a poll which was started by a previous run of the workflow.
+		// Optional: by default, a new poll is started.
+		State *PollState
+	}
+
+	// PollState is the progress of a Poll, which is passed to the new run when it continues as new.
+	PollState struct {
+		// Attempt is the number of probes which were done.
+		Attempt int32
+		// StartTime is the time of the first probe.
+		StartTime time.Time
+	}
+)
+
+// Poll calls probe until it reports done or returns an error, sleeping with a backoff between the calls.
+// It is meant for waiting on a condition in an external system, typically by executing an activity in the probe:
+//
+//	var status string
+//	err := Poll(ctx, PollOptions{InitialInterval: time.Minute}, func(ctx Context) (bool, error) {
+//		err := ExecuteActivity(ctx, getStatusActivity, jobID).Get(ctx, &status)
+//		return status == "done", err
+//	})
+//
+// Poll returns nil once probe reports done, the error of probe if it fails, ErrPollExpired once
+// options.MaximumDuration passed, or the error of options.ContinueAsNew when the history became too long.
+func Poll(ctx Context, options PollOptions, probe func(ctx Context) (bool, error)) error {
+	if options.InitialInterval <= 0 {
+		return errors.New("poll InitialInterval must be positive")
+	}
+	coefficient := options.BackoffCoefficient
+	if coefficient == 0 {
+		coefficient = 1
+	} else if coefficient < 1 {
+		return errors.New("poll BackoffCoefficient must be 1 or larger")
+	}
+	maximumHistoryLength := options.MaximumHistoryLength
+	if maximumHistoryLength <= 0 {
+		maximumHistoryLength = defaultPollMaximumHistoryLength
+	}
+
+	state := PollState{StartTime: Now(ctx)}
+	if options.State != nil {
+		state = *options.State
+	}
+
+	for {
+		done, err := probe(ctx)
+		state.Attempt++
+		if err != nil || done {
+			return err
+		}
+
+		interval := getPollInterval(options, coefficient, state.Attempt)
+		if options.MaximumDuration > 0 && Now(ctx).Add(interval).Sub(state.StartTime) > options.MaximumDuration {
+			return ErrPollExpired
+		}
+		if err := Sleep(ctx, interval); err != nil {
+			return err
+		}
+
+		if options.ContinueAsNew != nil && GetHistoryCount(ctx) >= maximumHistoryLength {
+			return options.ContinueAsNew(ctx, state)
+		}
+	}
+}
+
+func getPollInterval(options PollOptions, coefficient float64, attempt int32) time.Duration {
+	interval := float64(options.InitialInterval) * math.Pow(coefficient, float64(attempt-1))
+	if options.MaximumInterval > 0 && interval > float64(options.MaximumInterval) {
+		return options.MaximumInterval
+	}
+	if interval >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(interval)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoll(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	t.Run("backoff", func(t *testing.T) {
+		var offsets []time.Duration
+		workflowFn := func(ctx Context) error {
+			start := Now(ctx)
+			options := PollOptions{InitialInterval: time.Minute, BackoffCoefficient: 2, MaximumInterval: 3 * time.Minute}
+			return Poll(ctx, options, func(ctx Context) (bool, error) {
+				offsets = append(offsets, Now(ctx).Sub(start))
+				return len(offsets) == 4, nil
+			})
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, []time.Duration{0, time.Minute, 3 * time.Minute, 6 * time.Minute}, offsets)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		probes := 0
+		workflowFn := func(ctx Context) error {
+			options := PollOptions{InitialInterval: time.Minute, MaximumDuration: 2 * time.Minute}
+			return Poll(ctx, options, func(ctx Context) (bool, error) {
+				probes++
+				return false, nil
+			})
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), ErrPollExpired.Error())
+		assert.Equal(t, 3, probes)
+	})
+
+	t.Run("probe error", func(t *testing.T) {
+		workflowFn := func(ctx Context) error {
+			return Poll(ctx, PollOptions{InitialInterval: time.Minute}, func(ctx Context) (bool, error) {
+				return false, errors.New("probe failed")
+			})
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "probe failed")
+	})
+
+	t.Run("continue as new", func(t *testing.T) {
+		var continuedState PollState
+		var workflowFn func(ctx Context, state *PollState) error
+		workflowFn = func(ctx Context, state *PollState) error {
+			options := PollOptions{
+				InitialInterval:      time.Minute,
+				MaximumHistoryLength: 100,
+				State:                state,
+				ContinueAsNew: func(ctx Context, state PollState) error {
+					continuedState = state
+					return NewContinueAsNewError(ctx, workflowFn, &state)
+				},
+			}
+			return Poll(ctx, options, func(ctx Context) (bool, error) {
+				// simulate the history growing with every probe
+				GetWorkflowInfo(ctx).HistoryCount += 50
+				return false, nil
+			})
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterWorkflow(workflowFn)
+		env.ExecuteWorkflow(workflowFn, &PollState{Attempt: 5, StartTime: time.Unix(0, 0)})
+		require.Error(t, env.GetWorkflowError())
+		assert.IsType(t, &ContinueAsNewError{}, env.GetWorkflowError())
+		assert.Equal(t, int32(7), continuedState.Attempt)
+		assert.True(t, time.Unix(0, 0).Equal(continuedState.StartTime))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		workflowFn := func(ctx Context) error {
+			return Poll(ctx, PollOptions{}, func(ctx Context) (bool, error) {
+				return true, nil
+			})
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.Error(t, env.GetWorkflowError())
+	})
+}
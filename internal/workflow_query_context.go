@@ -0,0 +1,74 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"reflect"
+	"time"
+)
+
+type (
+	// QueryContext is a read-only view of the workflow that query handlers can declare as their first parameter.
+	// Unlike the workflow Context, it only exposes non-blocking accessors, so it is safe to use while handling a
+	// query.
+	QueryContext interface {
+		// GetInfo returns information about the currently executing workflow.
+		GetInfo() *WorkflowInfo
+
+		// Now returns the current workflow time.
+		Now() time.Time
+
+		// GetState decodes the value stored under key by SetState into valuePtr. It returns false if the key was
+		// never set.
+		GetState(key string, valuePtr interface{}) (bool, error)
+
+		// GetStateKeys returns the sorted list of keys set by SetState.
+		GetStateKeys() []string
+	}
+
+	queryContextImpl struct {
+		ctx Context
+	}
+)
+
+func newQueryContext(ctx Context) QueryContext {
+	return &queryContextImpl{ctx: ctx}
+}
+
+func (q *queryContextImpl) GetInfo() *WorkflowInfo {
+	return GetWorkflowInfo(q.ctx)
+}
+
+func (q *queryContextImpl) Now() time.Time {
+	return Now(q.ctx)
+}
+
+func (q *queryContextImpl) GetState(key string, valuePtr interface{}) (bool, error) {
+	return GetState(q.ctx, key, valuePtr)
+}
+
+func (q *queryContextImpl) GetStateKeys() []string {
+	return GetStateKeys(q.ctx)
+}
+
+func isQueryContext(inType reflect.Type) bool {
+	return inType == reflect.TypeOf((*QueryContext)(nil)).Elem()
+}
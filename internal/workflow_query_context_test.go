@@ -0,0 +1,87 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryContext(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	workflowFn := func(ctx Context) error {
+		err := SetQueryHandler(ctx, "state", func(ctx QueryContext, key string) (string, error) {
+			var value string
+			ok, err := ctx.GetState(key, &value)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				return "", fmt.Errorf("unknown key %v", key)
+			}
+			return fmt.Sprintf("%v=%v at %v in %v", key, value, ctx.Now().Format(time.RFC3339), ctx.GetInfo().WorkflowType.Name), nil
+		})
+		if err != nil {
+			return err
+		}
+		err = SetQueryHandler(ctx, "keys", func(ctx QueryContext) ([]string, error) {
+			return ctx.GetStateKeys(), nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := SetState(ctx, "stage", "download"); err != nil {
+			return err
+		}
+		return Sleep(ctx, time.Hour)
+	}
+
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflowWithOptions(workflowFn, RegisterWorkflowOptions{Name: "queryContextWorkflow"})
+	var start time.Time
+	env.RegisterDelayedCallback(func() {
+		value, err := env.QueryWorkflow("state", "stage")
+		require.NoError(t, err)
+		var result string
+		require.NoError(t, value.Get(&result))
+		assert.Equal(t, "stage=download at "+start.Add(time.Minute).UTC().Format(time.RFC3339)+" in queryContextWorkflow", result)
+
+		_, err = env.QueryWorkflow("state", "missing")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown key missing")
+
+		value, err = env.QueryWorkflow("keys")
+		require.NoError(t, err)
+		var keys []string
+		require.NoError(t, value.Get(&keys))
+		assert.Equal(t, []string{"stage"}, keys)
+	}, time.Minute)
+
+	start = env.Now()
+	env.ExecuteWorkflow("queryContextWorkflow")
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"math/rand"
+)
+
+// NewRand returns a random number generator which produces the same sequence when the workflow is replayed, for
+// jitter, sampling or shuffling in workflow code:
+//
+//	r := NewRand(ctx)
+//	r.Shuffle(len(hosts), func(i, j int) { hosts[i], hosts[j] = hosts[j], hosts[i] })
+//
+// Its seed is recorded with SideEffect, so every call adds a marker to the history: create the generator once and
+// reuse it. Like SideEffect, it panics if the recorded seed cannot be decoded.
+func NewRand(ctx Context) *rand.Rand {
+	seed, err := recordRandomSeed(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// recordRandomSeed returns a random seed recorded with SideEffect.
+func recordRandomSeed(ctx Context) (int64, error) {
+	var seed int64
+	err := SideEffect(ctx, func(ctx Context) interface{} {
+		return rand.Int63()
+	}).Get(&seed)
+	return seed, err
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestNewRand(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.ExecuteWorkflow(func(ctx Context) ([][]int64, error) {
+		var sequences [][]int64
+		for i := 0; i < 2; i++ {
+			r := NewRand(ctx)
+			sequences = append(sequences, []int64{r.Int63(), r.Int63(), r.Int63()})
+		}
+		return sequences, nil
+	})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var sequences [][]int64
+	require.NoError(t, env.GetWorkflowResult(&sequences))
+	require.Len(t, sequences, 2)
+	assert.NotEqual(t, sequences[0], sequences[1], "every generator is seeded with its own side effect")
+}
+
+func TestNewRand_Replay(t *testing.T) {
+	const seed = int64(42)
+	expected := rand.New(rand.NewSource(seed)).Int63()
+	replayer := NewWorkflowReplayer()
+	replayer.RegisterWorkflowWithOptions(func(ctx Context) error {
+		if actual := NewRand(ctx).Int63(); actual != expected {
+			panic("unexpected random number on replay")
+		}
+		return nil
+	}, RegisterWorkflowOptions{Name: "randWorkflow"})
+
+	encodedSeed, err := encodeArg(getDefaultDataConverter(), seed)
+	require.NoError(t, err)
+	history := &shared.History{Events: []*shared.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &shared.WorkflowExecutionStartedEventAttributes{
+			WorkflowType: &shared.WorkflowType{Name: common.StringPtr("randWorkflow")},
+			TaskList:     &shared.TaskList{Name: common.StringPtr(testTaskList)},
+		}),
+		createTestEventDecisionTaskScheduled(2, &shared.DecisionTaskScheduledEventAttributes{}),
+		createTestEventDecisionTaskStarted(3),
+		createTestEventDecisionTaskCompleted(4, &shared.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(2)}),
+		{
+			EventId:   common.Int64Ptr(5),
+			EventType: shared.EventTypeMarkerRecorded.Ptr(),
+			MarkerRecordedEventAttributes: &shared.MarkerRecordedEventAttributes{
+				MarkerName:                   common.StringPtr(sideEffectMarkerName),
+				Details:                      getSerializedDetails(t, int32(0), encodedSeed),
+				DecisionTaskCompletedEventId: common.Int64Ptr(4),
+			},
+		},
+		createTestEventWorkflowExecutionCompleted(6, &shared.WorkflowExecutionCompletedEventAttributes{
+			DecisionTaskCompletedEventId: common.Int64Ptr(4),
+		}),
+	}}
+	assert.NoError(t, replayer.ReplayWorkflowHistory(getTestLogger(t), history))
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+type (
+	// RateLimiter paces workflow code with a token bucket, which is refilled based on the workflow time returned by
+	// Now(), and waits with workflow timers. So unlike a rate limiter based on the wall clock, it behaves the same way
+	// when the workflow is replayed.
+	RateLimiter interface {
+		// Wait blocks until a token is available, and takes it.
+		// It returns *CanceledError if the ctx is canceled.
+		Wait(ctx Context) error
+		// WaitN blocks until n tokens are available, and takes them.
+		// It returns *CanceledError if the ctx is canceled, or an error if n exceeds the burst of the limiter.
+		WaitN(ctx Context, n int) error
+		// Allow takes a token and returns true if one is available right now, or returns false otherwise.
+		Allow(ctx Context) bool
+	}
+
+	rateLimiterImpl struct {
+		perSecond float64
+		burst     int
+		tokens    float64
+		last      time.Time
+	}
+)
+
+// NewRateLimiter returns a RateLimiter which allows events up to perSecond per second, and bursts of at most burst
+// events. The bucket starts full.
+func NewRateLimiter(ctx Context, perSecond float64, burst int) RateLimiter {
+	return &rateLimiterImpl{
+		perSecond: perSecond,
+		burst:     burst,
+		tokens:    float64(burst),
+		last:      Now(ctx),
+	}
+}
+
+func (r *rateLimiterImpl) Wait(ctx Context) error {
+	return r.WaitN(ctx, 1)
+}
+
+func (r *rateLimiterImpl) WaitN(ctx Context, n int) error {
+	if n > r.burst {
+		return fmt.Errorf("rate limiter wait of %d tokens exceeds its burst of %d", n, r.burst)
+	}
+	if r.perSecond <= 0 && float64(n) > r.advance(ctx) {
+		return fmt.Errorf("rate limiter with a rate of %v can not refill %d tokens", r.perSecond, n)
+	}
+
+	// take the tokens up front, so coroutines which wait concurrently are served in order
+	r.tokens = r.advance(ctx) - float64(n)
+	if r.tokens >= 0 {
+		return nil
+	}
+	wait := time.Duration(math.Ceil(-r.tokens / r.perSecond * float64(time.Second)))
+	if err := Sleep(ctx, wait); err != nil {
+		r.tokens += float64(n)
+		return err
+	}
+	return nil
+}
+
+func (r *rateLimiterImpl) Allow(ctx Context) bool {
+	tokens := r.advance(ctx)
+	if tokens < 1 {
+		return false
+	}
+	r.tokens = tokens - 1
+	return true
+}
+
+// advance refills the bucket up to the current workflow time and returns the available tokens.
+func (r *rateLimiterImpl) advance(ctx Context) float64 {
+	now := Now(ctx)
+	if elapsed := now.Sub(r.last); elapsed > 0 {
+		r.tokens = math.Min(float64(r.burst), r.tokens+elapsed.Seconds()*r.perSecond)
+		r.last = now
+	}
+	return r.tokens
+}
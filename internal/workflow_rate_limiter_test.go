@@ -0,0 +1,101 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	t.Run("wait", func(t *testing.T) {
+		var offsets []time.Duration
+		workflowFn := func(ctx Context) error {
+			start := Now(ctx)
+			limiter := NewRateLimiter(ctx, 1, 2)
+			for i := 0; i < 5; i++ {
+				if err := limiter.Wait(ctx); err != nil {
+					return err
+				}
+				offsets = append(offsets, Now(ctx).Sub(start))
+			}
+			return nil
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, []time.Duration{0, 0, time.Second, 2 * time.Second, 3 * time.Second}, offsets)
+	})
+
+	t.Run("allow", func(t *testing.T) {
+		var allowed []bool
+		workflowFn := func(ctx Context) error {
+			limiter := NewRateLimiter(ctx, 0.5, 1)
+			allowed = append(allowed, limiter.Allow(ctx), limiter.Allow(ctx))
+			if err := Sleep(ctx, 2*time.Second); err != nil {
+				return err
+			}
+			allowed = append(allowed, limiter.Allow(ctx))
+			return nil
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, []bool{true, false, true}, allowed)
+	})
+
+	t.Run("exceeds burst", func(t *testing.T) {
+		workflowFn := func(ctx Context) error {
+			return NewRateLimiter(ctx, 1, 2).WaitN(ctx, 3)
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.Error(t, env.GetWorkflowError())
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		var waitErr error
+		var tokens float64
+		workflowFn := func(ctx Context) error {
+			limiter := NewRateLimiter(ctx, 0.1, 1)
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+			canceledCtx, cancel := WithCancel(ctx)
+			cancel()
+			waitErr = limiter.Wait(canceledCtx)
+			tokens = limiter.(*rateLimiterImpl).tokens
+			return nil
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.NoError(t, env.GetWorkflowError())
+		assert.IsType(t, &CanceledError{}, waitErr)
+		// the canceled wait returned its token
+		assert.Equal(t, float64(0), tokens)
+	})
+}
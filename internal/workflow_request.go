@@ -0,0 +1,197 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultWorkflowResponseSignalName is the default name of the signal delivering WorkflowResponse to a
+// WorkflowRequester.
+const DefaultWorkflowResponseSignalName = "cadence-workflow-response"
+
+// ErrWorkflowRequestTimeout is the error of the future returned by WorkflowRequester.Request when no response was
+// received within WorkflowRequesterOptions.Timeout.
+var ErrWorkflowRequestTimeout = errors.New("workflow request timed out waiting for the response")
+
+type (
+	// WorkflowRequesterOptions configure a WorkflowRequester.
+	WorkflowRequesterOptions struct {
+		// ResponseSignalName - The name of the signal the responses are sent back with. A workflow must not run
+		// several requesters with the same ResponseSignalName.
+		// Optional: defaulted to DefaultWorkflowResponseSignalName.
+		ResponseSignalName string
+
+		// Timeout - The time after sending a request after which its future fails with ErrWorkflowRequestTimeout.
+		// Optional: defaulted to no timeout.
+		Timeout time.Duration
+	}
+
+	// WorkflowRequest is the payload of the signal carrying a request from a WorkflowRequester to another workflow,
+	// which responds with RespondToWorkflowRequest.
+	WorkflowRequest struct {
+		ID                 string // correlates the response with the request
+		ReplyTo            WorkflowExecution
+		ResponseSignalName string
+		Payload            []byte // the argument of the request, encoded with the data converter
+	}
+
+	// WorkflowResponse is the payload of the signal carrying the response to a WorkflowRequest.
+	WorkflowResponse struct {
+		ID      string
+		Payload []byte // the result of the request, encoded with the data converter
+		Error   string // the error of the request, empty if it succeeded
+	}
+
+	// WorkflowRequestError is the error of the future returned by WorkflowRequester.Request when the other workflow
+	// responded with an error.
+	WorkflowRequestError struct {
+		Message string
+	}
+
+	// WorkflowRequester sends requests to other workflows with signals, and correlates the responses they send
+	// back with signals, see NewWorkflowRequester.
+	WorkflowRequester struct {
+		options WorkflowRequesterOptions
+		runID   string
+		seq     int
+		pending map[string]*pendingWorkflowRequest
+	}
+
+	pendingWorkflowRequest struct {
+		settable    Settable
+		cancelTimer CancelFunc
+	}
+)
+
+// NewWorkflowRequester returns a requester, and starts a coroutine which completes the futures of its requests with
+// the responses received.
+func NewWorkflowRequester(ctx Context, options WorkflowRequesterOptions) *WorkflowRequester {
+	if options.ResponseSignalName == "" {
+		options.ResponseSignalName = DefaultWorkflowResponseSignalName
+	}
+	r := &WorkflowRequester{
+		options: options,
+		runID:   GetWorkflowInfo(ctx).WorkflowExecution.RunID,
+		pending: make(map[string]*pendingWorkflowRequest),
+	}
+	responses := GetSignalChannel(ctx, options.ResponseSignalName)
+	Go(ctx, func(ctx Context) {
+		for {
+			var response WorkflowResponse
+			if !responses.Receive(ctx, &response) {
+				return
+			}
+			request, ok := r.pending[response.ID]
+			if !ok {
+				GetLogger(ctx).Warn("Dropped response to unknown or timed out workflow request.",
+					zap.String("RequestID", response.ID))
+				continue
+			}
+			var err error
+			if response.Error != "" {
+				err = &WorkflowRequestError{Message: response.Error}
+			}
+			r.complete(response.ID, request, response.Payload, err)
+		}
+	})
+	return r
+}
+
+// Request sends a signal with a WorkflowRequest carrying arg to the workflow with the given ID, and returns a future
+// of its response. The future fails with the error of sending the signal, ErrWorkflowRequestTimeout, or
+// *WorkflowRequestError if the other workflow responded with an error.
+func (r *WorkflowRequester) Request(ctx Context, workflowID, runID, signalName string, arg interface{}) Future {
+	future, settable := NewFuture(ctx)
+	payload, err := encodeArg(getDataConverterFromWorkflowContext(ctx), arg)
+	if err != nil {
+		settable.Set(nil, err)
+		return future
+	}
+	r.seq++
+	info := GetWorkflowInfo(ctx)
+	request := WorkflowRequest{
+		ID:                 fmt.Sprintf("%s:%d", r.runID, r.seq),
+		ReplyTo:            WorkflowExecution{ID: info.WorkflowExecution.ID},
+		ResponseSignalName: r.options.ResponseSignalName,
+		Payload:            payload,
+	}
+	pending := &pendingWorkflowRequest{settable: settable, cancelTimer: func() {}}
+	r.pending[request.ID] = pending
+
+	if r.options.Timeout > 0 {
+		var timerCtx Context
+		timerCtx, pending.cancelTimer = WithCancel(ctx)
+		timer := NewTimer(timerCtx, r.options.Timeout)
+		Go(ctx, func(ctx Context) {
+			if timer.Get(ctx, nil) == nil {
+				r.complete(request.ID, pending, nil, ErrWorkflowRequestTimeout)
+			}
+		})
+	}
+	signal := SignalExternalWorkflow(ctx, workflowID, runID, signalName, request)
+	Go(ctx, func(ctx Context) {
+		if err := signal.Get(ctx, nil); err != nil {
+			r.complete(request.ID, pending, nil, err)
+		}
+	})
+	return future
+}
+
+func (r *WorkflowRequester) complete(id string, request *pendingWorkflowRequest, payload []byte, err error) {
+	if r.pending[id] != request {
+		return
+	}
+	delete(r.pending, id)
+	request.cancelTimer()
+	if payload == nil {
+		request.settable.Set(nil, err)
+		return
+	}
+	request.settable.Set(payload, err)
+}
+
+// Get decodes the argument of the request into valuePtr.
+func (r WorkflowRequest) Get(ctx Context, valuePtr interface{}) error {
+	return decodeArg(getDataConverterFromWorkflowContext(ctx), r.Payload, valuePtr)
+}
+
+// RespondToWorkflowRequest sends the response to the request back to the workflow which sent it, with the result of
+// the request, or its error if err is not nil. It returns the future of sending the signal.
+func RespondToWorkflowRequest(ctx Context, request WorkflowRequest, result interface{}, err error) Future {
+	response := WorkflowResponse{ID: request.ID}
+	if err != nil {
+		response.Error = err.Error()
+	} else if response.Payload, err = encodeArg(getDataConverterFromWorkflowContext(ctx), result); err != nil {
+		future, settable := NewFuture(ctx)
+		settable.Set(nil, err)
+		return future
+	}
+	return SignalExternalWorkflow(ctx, request.ReplyTo.ID, request.ReplyTo.RunID, request.ResponseSignalName, response)
+}
+
+func (e *WorkflowRequestError) Error() string {
+	return e.Message
+}
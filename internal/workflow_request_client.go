@@ -0,0 +1,141 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+// WorkflowResponseListenerName is the workflow type WorkflowResponseListener must be registered with, for
+// WorkflowRequestClient to start it.
+const WorkflowResponseListenerName = "cadence-workflow-response-listener"
+
+const (
+	defaultWorkflowRequestClientTimeout     = time.Minute
+	defaultWorkflowResponseListenerIDPrefix = "cadence-workflow-response:"
+)
+
+type (
+	// WorkflowRequestClientOptions configure a WorkflowRequestClient.
+	WorkflowRequestClientOptions struct {
+		// TaskList - The task list of the workers which registered WorkflowResponseListener. Required.
+		TaskList string
+
+		// Timeout - The time a request waits for its response before failing with ErrWorkflowRequestTimeout.
+		// Optional: defaulted to 1 minute.
+		Timeout time.Duration
+
+		// ListenerWorkflowIDPrefix - The prefix of the workflow IDs of the listener workflows, which are followed by
+		// the ID of the request.
+		// Optional: defaulted to "cadence-workflow-response:".
+		ListenerWorkflowIDPrefix string
+	}
+
+	// WorkflowRequestClient sends requests to workflows from outside of workflows, and waits for the responses the
+	// workflows send back with RespondToWorkflowRequest. Workflows can only signal other workflows, so every request
+	// starts a listener workflow running WorkflowResponseListener, whose ID the request carries as the workflow to
+	// respond to, and which completes with the response.
+	WorkflowRequestClient struct {
+		client        Client
+		options       WorkflowRequestClientOptions
+		dataConverter DataConverter
+	}
+)
+
+// NewWorkflowRequestClient returns a client sending requests to workflows with client.
+func NewWorkflowRequestClient(client Client, options WorkflowRequestClientOptions) (*WorkflowRequestClient, error) {
+	if options.TaskList == "" {
+		return nil, errors.New("workflow request client TaskList is required")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = defaultWorkflowRequestClientTimeout
+	}
+	if options.ListenerWorkflowIDPrefix == "" {
+		options.ListenerWorkflowIDPrefix = defaultWorkflowResponseListenerIDPrefix
+	}
+	dataConverter := getDefaultDataConverter()
+	if wc, ok := client.(*workflowClient); ok {
+		dataConverter = wc.dataConverter
+	}
+	return &WorkflowRequestClient{client: client, options: options, dataConverter: dataConverter}, nil
+}
+
+// Request sends a signal with a WorkflowRequest carrying arg to the workflow with the given ID, waits for its
+// response, and decodes the result into valuePtr. It returns ErrWorkflowRequestTimeout if the workflow did not respond
+// within the timeout, *WorkflowRequestError if it responded with an error, or the error of starting the listener
+// workflow or of sending the signal.
+func (r *WorkflowRequestClient) Request(ctx context.Context, workflowID, runID, signalName string, arg interface{},
+	valuePtr interface{}) error {
+	payload, err := encodeArg(r.dataConverter, arg)
+	if err != nil {
+		return err
+	}
+	request := WorkflowRequest{
+		ID:                 uuid.New(),
+		ResponseSignalName: DefaultWorkflowResponseSignalName,
+		Payload:            payload,
+	}
+	request.ReplyTo.ID = r.options.ListenerWorkflowIDPrefix + request.ID
+	listener, err := r.client.StartWorkflow(ctx, StartWorkflowOptions{
+		ID:                           request.ReplyTo.ID,
+		TaskList:                     r.options.TaskList,
+		ExecutionStartToCloseTimeout: r.options.Timeout,
+	}, WorkflowResponseListenerName)
+	if err != nil {
+		return err
+	}
+	request.ReplyTo.RunID = listener.RunID
+
+	if err := r.client.SignalWorkflow(ctx, workflowID, runID, signalName, request); err != nil {
+		// best effort, the listener times out anyway
+		_ = r.client.TerminateWorkflow(ctx, listener.ID, listener.RunID, "workflow request was not sent", nil)
+		return err
+	}
+
+	var response WorkflowResponse
+	if err := r.client.GetWorkflow(ctx, listener.ID, listener.RunID).Get(ctx, &response); err != nil {
+		var timeoutErr *TimeoutError
+		if errors.As(err, &timeoutErr) {
+			return ErrWorkflowRequestTimeout
+		}
+		return err
+	}
+	if response.Error != "" {
+		return &WorkflowRequestError{Message: response.Error}
+	}
+	if valuePtr == nil {
+		return nil
+	}
+	return decodeArg(r.dataConverter, response.Payload, valuePtr)
+}
+
+// WorkflowResponseListener is the listener workflow started by WorkflowRequestClient for every request, which
+// completes with the response to the request. Workers on the task list of the client must register it with the name
+// WorkflowResponseListenerName.
+func WorkflowResponseListener(ctx Context) (WorkflowResponse, error) {
+	var response WorkflowResponse
+	GetSignalChannel(ctx, DefaultWorkflowResponseSignalName).Receive(ctx, &response)
+	return response, nil
+}
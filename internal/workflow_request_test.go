@@ -0,0 +1,148 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowRequester(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	quoteWorkflow := func(ctx Context, items []string) ([]string, error) {
+		requester := NewWorkflowRequester(ctx, WorkflowRequesterOptions{Timeout: time.Minute})
+		var futures []Future
+		for _, item := range items {
+			futures = append(futures, requester.Request(ctx, "pricing", "", "quote", item))
+		}
+		var quotes []string
+		for _, future := range futures {
+			var price int
+			if err := future.Get(ctx, &price); err != nil {
+				quotes = append(quotes, err.Error())
+			} else {
+				quotes = append(quotes, time.Duration(price).String())
+			}
+		}
+		return quotes, nil
+	}
+
+	t.Run("responses", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.OnWorkflowRequest("pricing", "quote", func(arg Value) (interface{}, error) {
+			var item string
+			require.NoError(t, arg.Get(&item))
+			switch item {
+			case "book":
+				return int(time.Second), nil
+			case "pen":
+				return nil, errors.New("out of stock")
+			}
+			return nil, nil
+		}).Times(2)
+		// the request for the last item is lost
+		env.OnSignalExternalWorkflow(mock.Anything, "pricing", mock.Anything, "quote", mock.Anything).Return(nil).Once()
+		env.ExecuteWorkflow(quoteWorkflow, []string{"book", "pen", "ink"})
+
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var quotes []string
+		require.NoError(t, env.GetWorkflowResult(&quotes))
+		assert.Equal(t, []string{"1s", "out of stock", ErrWorkflowRequestTimeout.Error()}, quotes)
+	})
+
+	t.Run("signal failure", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.OnSignalExternalWorkflow(mock.Anything, "pricing", mock.Anything, "quote", mock.Anything).
+			Return(errors.New("unknown external workflow"))
+		env.ExecuteWorkflow(quoteWorkflow, []string{"book"})
+
+		require.True(t, env.IsWorkflowCompleted())
+		var quotes []string
+		require.NoError(t, env.GetWorkflowResult(&quotes))
+		assert.Equal(t, []string{"unknown external workflow"}, quotes)
+	})
+}
+
+func TestRespondToWorkflowRequest(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	pricingWorkflow := func(ctx Context) error {
+		requests := GetSignalChannel(ctx, "quote")
+		for i := 0; i < 2; i++ {
+			var request WorkflowRequest
+			requests.Receive(ctx, &request)
+			var item string
+			if err := request.Get(ctx, &item); err != nil {
+				return err
+			}
+			var err error
+			if item != "book" {
+				err = errors.New("out of stock")
+			}
+			if err := RespondToWorkflowRequest(ctx, request, len(item), err).Get(ctx, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	env := testSuite.NewTestWorkflowEnvironment()
+	var price int
+	var priceErr error
+	env.RegisterDelayedCallback(func() {
+		env.SendWorkflowRequest("quote", "book", func(result Value, err error) {
+			require.NoError(t, err)
+			require.NoError(t, result.Get(&price))
+		})
+		env.SendWorkflowRequest("quote", "pen", func(result Value, err error) {
+			priceErr = err
+		})
+	}, time.Minute)
+	env.ExecuteWorkflow(pricingWorkflow)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	assert.Equal(t, 4, price)
+	assert.Equal(t, &WorkflowRequestError{Message: "out of stock"}, priceErr)
+	env.AssertExpectations(t)
+}
+
+func TestWorkflowResponseListener(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(DefaultWorkflowResponseSignalName, WorkflowResponse{ID: "1", Error: "out of stock"})
+	}, time.Second)
+	env.ExecuteWorkflow(WorkflowResponseListener)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var response WorkflowResponse
+	require.NoError(t, env.GetWorkflowResult(&response))
+	assert.Equal(t, WorkflowResponse{ID: "1", Error: "out of stock"}, response)
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemaphore(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	t.Run("bounded concurrency", func(t *testing.T) {
+		var inFlight, maxInFlight int
+		var order []int
+		workflowFn := func(ctx Context) error {
+			sem := NewSemaphore(ctx, 3)
+			wg := NewWaitGroup(ctx)
+			for i := 0; i < 10; i++ {
+				i := i
+				if err := sem.Acquire(ctx, 1); err != nil {
+					return err
+				}
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				wg.Add(1)
+				Go(ctx, func(ctx Context) {
+					defer wg.Done()
+					defer sem.Release(1)
+					_ = Sleep(ctx, time.Duration(10-i)*time.Second)
+					inFlight--
+					order = append(order, i)
+				})
+			}
+			wg.Wait(ctx)
+			return nil
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, 3, maxInFlight)
+		assert.Len(t, order, 10)
+	})
+
+	t.Run("weighted fifo", func(t *testing.T) {
+		var acquired []string
+		workflowFn := func(ctx Context) error {
+			sem := NewSemaphore(ctx, 4)
+			if !sem.TryAcquire(3) {
+				return assert.AnError
+			}
+			Go(ctx, func(ctx Context) {
+				_ = sem.Acquire(ctx, 3)
+				acquired = append(acquired, "large")
+			})
+			Go(ctx, func(ctx Context) {
+				// waits behind the large waiter, although a permit is free
+				_ = sem.Acquire(ctx, 1)
+				acquired = append(acquired, "small")
+			})
+			_ = Sleep(ctx, time.Second)
+			if sem.TryAcquire(1) {
+				return assert.AnError
+			}
+			acquired = append(acquired, "release")
+			sem.Release(3)
+			_ = Sleep(ctx, time.Second)
+			return nil
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, []string{"release", "large", "small"}, acquired)
+	})
+
+	t.Run("canceled waiter", func(t *testing.T) {
+		var acquired []string
+		workflowFn := func(ctx Context) error {
+			sem := NewSemaphore(ctx, 2)
+			_ = sem.Acquire(ctx, 1)
+			cancelCtx, cancel := WithCancel(ctx)
+			var canceledErr error
+			Go(cancelCtx, func(ctx Context) {
+				canceledErr = sem.Acquire(ctx, 2)
+			})
+			Go(ctx, func(ctx Context) {
+				_ = sem.Acquire(ctx, 1)
+				acquired = append(acquired, "small")
+			})
+			_ = Sleep(ctx, time.Second)
+			acquired = append(acquired, "cancel")
+			cancel()
+			_ = Sleep(ctx, time.Second)
+			if canceledErr != ErrCanceled {
+				return assert.AnError
+			}
+			return sem.Acquire(ctx, 3)
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "acquiring 3 permits of a semaphore of size 2")
+		assert.Equal(t, []string{"cancel", "small"}, acquired)
+	})
+
+	t.Run("release more than held", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) error {
+			NewSemaphore(ctx, 1).Release(1)
+			return nil
+		})
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "released more than held")
+	})
+}
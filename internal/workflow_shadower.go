@@ -31,11 +31,13 @@ import (
 	"time"
 
 	"github.com/facebookgo/clock"
+	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
 	"go.uber.org/cadence/.gen/go/shadower"
 	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/metrics"
 	"go.uber.org/cadence/internal/common/util"
 )
 
@@ -49,6 +51,15 @@ const (
 	defaultWaitDurationPerIteration = 5 * time.Minute
 )
 
+const (
+	// ReplayFailureTypeNonDeterministic is the failure type of a replay which detected a nondeterministic change
+	// in the workflow definition.
+	ReplayFailureTypeNonDeterministic = "nondeterministic"
+	// ReplayFailureTypeWorkflowTypeNotRegistered is the failure type of a replay whose workflow type is not
+	// registered with the shadower.
+	ReplayFailureTypeWorkflowTypeNotRegistered = "workflow-type-not-registered"
+)
+
 type (
 	// ShadowOptions is used to configure workflow shadowing.
 	ShadowOptions struct {
@@ -103,6 +114,40 @@ type (
 		// An error will be returned if it's set to be larger than 1 when used to NewWorkflowShadower
 		// default: 1
 		Concurrency int
+
+		// Optional: metrics scope used by the local WorkflowShadower to report its progress.
+		// Note: this field only applies to the local WorkflowShadower. The shadow worker reports
+		// to the MetricsScope in WorkerOptions.
+		// default: no metrics are reported
+		MetricsScope tally.Scope
+
+		// Optional: called with a summary of the shadowing when WorkflowShadower.Run returns,
+		// e.g. to let a deployment pipeline act on the shadowing result.
+		// Note: this field only applies to the local WorkflowShadower, as the shadowing workflow
+		// driving the shadow worker runs on the Cadence server.
+		// default: no callback
+		OnComplete func(ShadowResult)
+	}
+
+	// ShadowResult summarizes a run of the local WorkflowShadower.
+	ShadowResult struct {
+		// Scanned is the number of workflows matching the query, before sampling.
+		Scanned int
+		// Replayed is the number of sampled workflows the shadower attempted to replay.
+		Replayed int
+		// Succeeded is the number of workflows replayed successfully.
+		Succeeded int
+		// Skipped is the number of workflows which could not be replayed for reasons unrelated to the
+		// workflow definition, e.g. because their history was deleted.
+		Skipped int
+		// Failed is the number of workflows which failed to replay.
+		Failed int
+		// FailedByType breaks down Failed by failure type, e.g. ReplayFailureTypeNonDeterministic.
+		FailedByType map[string]int
+		// Duration is how long the shadowing ran.
+		Duration time.Duration
+		// Err is the error returned by WorkflowShadower.Run, if any.
+		Err error
 	}
 
 	// TimeFilter represents a time range through the min and max timestamp
@@ -134,7 +179,9 @@ type (
 		domain        string
 		shadowOptions ShadowOptions
 		logger        *zap.Logger
+		metricsScope  tally.Scope
 		replayer      *WorkflowReplayer
+		result        ShadowResult
 
 		status     int32
 		shutdownCh chan struct{}
@@ -187,7 +234,9 @@ func NewWorkflowShadower(
 		domain:        domain,
 		shadowOptions: shadowOptions,
 		logger:        logger,
+		metricsScope:  tagScope(shadowOptions.MetricsScope, tagDomain, domain),
 		replayer:      NewWorkflowReplayerWithOptions(replayOptions),
+		result:        ShadowResult{FailedByType: make(map[string]int)},
 
 		status:     statusInitialized,
 		shutdownCh: make(chan struct{}),
@@ -212,7 +261,14 @@ func (s *WorkflowShadower) Run() error {
 		return errors.New("Workflow shadower already started")
 	}
 
-	return s.shadowWorker()
+	startTime := s.clock.Now()
+	err := s.shadowWorker()
+	if s.shadowOptions.OnComplete != nil {
+		s.result.Duration = s.clock.Now().Sub(startTime)
+		s.result.Err = err
+		s.shadowOptions.OnComplete(s.result)
+	}
+	return err
 }
 
 // Stop stops WorkflowShadower and wait up to one minute for all goroutines to finish before returning
@@ -259,7 +315,8 @@ func (s *WorkflowShadower) shadowWorker() error {
 	}
 	rand.Seed(s.clock.Now().UnixNano())
 	for {
-		scanResult, err := scanWorkflowExecutionsHelper(ctx, s.service, scanRequest, s.logger)
+		scanResult, scanned, err := scanWorkflowExecutionsHelper(ctx, s.service, scanRequest, s.logger, s.metricsScope)
+		s.result.Scanned += scanned
 		if err != nil {
 			return err
 		}
@@ -269,17 +326,7 @@ func (s *WorkflowShadower) shadowWorker() error {
 				return nil
 			}
 
-			success, err := replayWorkflowExecutionHelper(
-				ctx,
-				s.replayer,
-				s.service,
-				s.logger,
-				s.domain,
-				WorkflowExecution{
-					ID:    execution.GetWorkflowId(),
-					RunID: execution.GetRunId(),
-				},
-			)
+			success, err := s.replay(ctx, execution.GetWorkflowId(), execution.GetRunId())
 			if err != nil {
 				return err
 			}
@@ -305,6 +352,33 @@ func (s *WorkflowShadower) shadowWorker() error {
 
 }
 
+// replay replays a single workflow execution and records the outcome in s.result and s.metricsScope.
+func (s *WorkflowShadower) replay(ctx context.Context, workflowID, runID string) (bool, error) {
+	s.result.Replayed++
+	s.metricsScope.Counter(metrics.ReplayCounter).Inc(1)
+	sw := s.metricsScope.Timer(metrics.ReplayLatency).Start()
+	defer sw.Stop()
+
+	success, err := replayWorkflowExecutionHelper(ctx, s.replayer, s.service, s.logger, s.domain, WorkflowExecution{
+		ID:    workflowID,
+		RunID: runID,
+	})
+	switch {
+	case err != nil:
+		failureType := replayFailureType(err)
+		s.result.Failed++
+		s.result.FailedByType[failureType]++
+		tagScope(s.metricsScope, tagReplayFailureType, failureType).Counter(metrics.ReplayFailedCounter).Inc(1)
+	case success:
+		s.result.Succeeded++
+		s.metricsScope.Counter(metrics.ReplaySucceedCounter).Inc(1)
+	default:
+		s.result.Skipped++
+		s.metricsScope.Counter(metrics.ReplaySkippedCounter).Inc(1)
+	}
+	return success, err
+}
+
 func (o *ShadowOptions) validateAndPopulateFields() error {
 	exitConditionSpecified := o.ExitCondition.ExpirationInterval > 0 || o.ExitCondition.ShadowCount > 0
 	if o.Mode == ShadowModeContinuous && !exitConditionSpecified {
@@ -26,6 +26,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
@@ -59,9 +60,10 @@ func scanWorkflowActivity(
 	params shadower.ScanWorkflowActivityParams,
 ) (shadower.ScanWorkflowActivityResult, error) {
 	logger := GetActivityLogger(ctx)
+	scope := tagScope(GetActivityMetricsScope(ctx), tagDomain, params.GetDomain(), tagTaskList, GetActivityInfo(ctx).TaskList)
 	service := ctx.Value(serviceClientContextKey).(workflowserviceclient.Interface)
 
-	scanResult, err := scanWorkflowExecutionsHelper(ctx, service, params, logger)
+	scanResult, _, err := scanWorkflowExecutionsHelper(ctx, service, params, logger, scope)
 	switch err.(type) {
 	case *shared.EntityNotExistsError:
 		err = NewCustomError(shadower.ErrReasonDomainNotExists, err.Error())
@@ -71,12 +73,15 @@ func scanWorkflowActivity(
 	return scanResult, err
 }
 
+// scanWorkflowExecutionsHelper returns the sampled executions matching the query,
+// together with the number of executions scanned before sampling.
 func scanWorkflowExecutionsHelper(
 	ctx context.Context,
 	service workflowserviceclient.Interface,
 	params shadower.ScanWorkflowActivityParams,
 	logger *zap.Logger,
-) (shadower.ScanWorkflowActivityResult, error) {
+	scope tally.Scope,
+) (shadower.ScanWorkflowActivityResult, int, error) {
 	var completionTime time.Time
 	if deadline, ok := ctx.Deadline(); ok {
 		now := time.Now()
@@ -92,6 +97,7 @@ func scanWorkflowExecutionsHelper(
 	}
 
 	result := shadower.ScanWorkflowActivityResult{}
+	scanned := 0
 	for {
 		var resp *shared.ListWorkflowExecutionsResponse
 		if err := backoff.Retry(ctx,
@@ -112,9 +118,11 @@ func scanWorkflowExecutionsHelper(
 				zap.String(tagVisibilityQuery, params.GetWorkflowQuery()),
 				zap.Error(err),
 			)
-			return shadower.ScanWorkflowActivityResult{}, err
+			return shadower.ScanWorkflowActivityResult{}, scanned, err
 		}
 
+		scanned += len(resp.Executions)
+		scope.Counter(metrics.ShadowScannedCounter).Inc(int64(len(resp.Executions)))
 		for _, execution := range resp.Executions {
 			if shouldReplay(params.GetSamplingRate()) {
 				result.Executions = append(result.Executions, execution.Execution)
@@ -132,7 +140,7 @@ func scanWorkflowExecutionsHelper(
 		time.Sleep(scanWorkflowWaitPeriod)
 	}
 
-	return result, nil
+	return result, scanned, nil
 }
 
 func shouldReplay(probability float64) bool {
@@ -173,13 +181,14 @@ func replayWorkflowActivity(
 			continue
 		}
 
+		scope.Counter(metrics.ReplayCounter).Inc(1)
 		sw := scope.Timer(metrics.ReplayLatency).Start()
 		success, err := replayWorkflowExecutionHelper(ctx, replayer, service, logger, params.GetDomain(), WorkflowExecution{
 			ID:    execution.GetWorkflowId(),
 			RunID: execution.GetRunId(),
 		})
 		if err != nil {
-			scope.Counter(metrics.ReplayFailedCounter).Inc(1)
+			tagScope(scope, tagReplayFailureType, replayFailureType(err)).Counter(metrics.ReplayFailedCounter).Inc(1)
 			*progress.Result.Failed++
 			if isWorkflowTypeNotRegisteredError(err) {
 				// this should fail the replay workflow as it requires worker deployment to fix the workflow registration.
@@ -229,6 +238,14 @@ func replayWorkflowExecutionHelper(
 	return false, nil
 }
 
+// replayFailureType classifies an error returned by replayWorkflowExecutionHelper.
+func replayFailureType(err error) string {
+	if isWorkflowTypeNotRegisteredError(err) {
+		return ReplayFailureTypeWorkflowTypeNotRegistered
+	}
+	return ReplayFailureTypeNonDeterministic
+}
+
 func isNondeterministicErr(err error) bool {
 	// There're a few expected replay errors, for example:
 	//   1. errReplayHistoryTooShort
@@ -32,11 +32,13 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
 
 	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
 	"go.uber.org/cadence/.gen/go/shadower"
 	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/metrics"
 )
 
 type workflowShadowerActivitiesSuite struct {
@@ -260,6 +262,40 @@ func (s *workflowShadowerActivitiesSuite) TestReplayWorkflowExecutionActivity_Ra
 	s.Equal(numFailed, result.GetFailed())
 }
 
+func (s *workflowShadowerActivitiesSuite) TestReplayWorkflowExecutionActivity_Metrics() {
+	scope := tally.NewTestScope("", nil)
+	s.env.SetWorkerOptions(WorkerOptions{MetricsScope: scope})
+
+	s.mockService.EXPECT().ScanWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.ListWorkflowExecutionsResponse{
+		Executions: newTestWorkflowExecutions(20),
+	}, nil).Times(1)
+	_, err := s.env.ExecuteActivity(shadower.ScanWorkflowActivityName, shadower.ScanWorkflowActivityParams{
+		Domain:        common.StringPtr(defaultTestDomain),
+		WorkflowQuery: common.StringPtr("some random workflow visibility query"),
+		SamplingRate:  common.Float64Ptr(0.5),
+	})
+	s.NoError(err)
+
+	s.mockService.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.GetWorkflowExecutionHistoryResponse{
+		History: s.testWorkflowHistory,
+	}, nil).Times(2)
+	s.mockService.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(nil, &shared.EntityNotExistsError{}).Times(1)
+	s.mockService.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.GetWorkflowExecutionHistoryResponse{
+		History: getTestReplayWorkflowMismatchHistory(s.T()),
+	}, nil).Times(1)
+	_, err = s.env.ExecuteActivity(shadower.ReplayWorkflowActivityName, newTestReplayWorkflowActivityParams(4))
+	s.NoError(err)
+
+	s.Equal(map[string]int64{
+		metrics.ShadowScannedCounter: 20,
+		metrics.ReplayCounter:        4,
+		metrics.ReplaySucceedCounter: 2,
+		metrics.ReplaySkippedCounter: 1,
+		metrics.ReplayFailedCounter + "/" + ReplayFailureTypeNonDeterministic: 1,
+	}, getShadowCounters(scope))
+	s.Len(scope.Snapshot().Timers(), 1)
+}
+
 func (s *workflowShadowerActivitiesSuite) TestReplayWorkflowExecutionActivity_WorkflowNotRegistered() {
 	s.mockService.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.GetWorkflowExecutionHistoryResponse{
 		History: getTestReplayWorkflowLocalActivityHistory(s.T()), // this workflow type is not registered
@@ -33,10 +33,12 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
 
 	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
 	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/metrics"
 )
 
 type workflowShadowerSuite struct {
@@ -439,6 +441,50 @@ func (s *workflowShadowerSuite) TestShadowWorker_ExpectedReplayError() {
 	}
 }
 
+func (s *workflowShadowerSuite) TestShadowWorker_Result() {
+	scope := tally.NewTestScope("", nil)
+	var result ShadowResult
+	shadower, err := NewWorkflowShadower(s.mockService, "testDomain", ShadowOptions{
+		MetricsScope: scope,
+		OnComplete: func(r ShadowResult) {
+			result = r
+		},
+	}, ReplayOptions{}, nil)
+	s.NoError(err)
+	shadower.RegisterWorkflow(testReplayWorkflow)
+
+	s.mockService.EXPECT().ScanWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.ListWorkflowExecutionsResponse{
+		Executions:    newTestWorkflowExecutions(10),
+		NextPageToken: []byte{1, 2, 3},
+	}, nil).Times(1)
+	s.mockService.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.GetWorkflowExecutionHistoryResponse{
+		History: s.testWorkflowHistory,
+	}, nil).Times(2)
+	s.mockService.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(nil, &shared.EntityNotExistsError{}).Times(1)
+	s.mockService.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.GetWorkflowExecutionHistoryResponse{
+		History: getTestReplayWorkflowMismatchHistory(s.T()),
+	}, nil).Times(1)
+
+	runErr := shadower.Run()
+	s.Error(runErr)
+
+	s.Equal(10, result.Scanned)
+	s.Equal(4, result.Replayed)
+	s.Equal(2, result.Succeeded)
+	s.Equal(1, result.Skipped)
+	s.Equal(1, result.Failed)
+	s.Equal(map[string]int{ReplayFailureTypeNonDeterministic: 1}, result.FailedByType)
+	s.Equal(runErr, result.Err)
+
+	s.Equal(map[string]int64{
+		metrics.ShadowScannedCounter: 10,
+		metrics.ReplayCounter:        4,
+		metrics.ReplaySucceedCounter: 2,
+		metrics.ReplaySkippedCounter: 1,
+		metrics.ReplayFailedCounter + "/" + ReplayFailureTypeNonDeterministic: 1,
+	}, getShadowCounters(scope))
+}
+
 func (s *workflowShadowerSuite) TestWorkflowRegistration() {
 	wfName := s.testShadower.GetRegisteredWorkflows()[0].WorkflowType().Name
 	fnName := getFunctionName(testReplayWorkflow)
@@ -448,6 +494,20 @@ func (s *workflowShadowerSuite) TestWorkflowRegistration() {
 	s.Equal(fnName, runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name())
 }
 
+// getShadowCounters returns the shadowing counters reported to scope,
+// with the failure type appended to the name of the failed replay counter.
+func getShadowCounters(scope tally.TestScope) map[string]int64 {
+	counters := make(map[string]int64)
+	for _, counter := range scope.Snapshot().Counters() {
+		name := counter.Name()
+		if failureType, ok := counter.Tags()[tagReplayFailureType]; ok {
+			name += "/" + failureType
+		}
+		counters[name] += counter.Value()
+	}
+	return counters
+}
+
 func newTestWorkflowExecutions(size int) []*shared.WorkflowExecutionInfo {
 	executions := make([]*shared.WorkflowExecutionInfo, size)
 	for i := 0; i != size; i++ {
@@ -0,0 +1,151 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"time"
+)
+
+const defaultSignalBatchMaximumHistoryLength = 10000
+
+type (
+	// SignalBatchOptions configure ProcessSignalBatches. At least one of MaxBatchSize and Window must be set.
+	SignalBatchOptions struct {
+		// SignalName - The name of the signal whose payloads are batched. Required.
+		SignalName string
+
+		// MaxBatchSize - The number of signals at which a batch is delivered right away.
+		// Optional: defaulted to no limit.
+		MaxBatchSize int
+
+		// Window - The time after the first signal of a batch at which the batch is delivered, measured with workflow
+		// timers. With Debounce, it is the time after the last signal of the batch instead.
+		// Optional: defaulted to no time limit, i.e. batches are only delivered once they have MaxBatchSize signals.
+		Window time.Duration
+
+		// Debounce - Restarts the Window on every signal, so a batch is delivered once no signal was received for the
+		// Window, or once it has MaxBatchSize signals.
+		// Optional: default is false.
+		Debounce bool
+
+		// ContinueAsNew - Called once the history of the workflow has grown to MaximumHistoryLength events, after the
+		// signals which were received so far were delivered as a batch. It should return a ContinueAsNewError, which
+		// ProcessSignalBatches returns.
+		// Optional: by default, ProcessSignalBatches does not continue as new.
+		ContinueAsNew func(ctx Context) error
+
+		// MaximumHistoryLength - The length of the history at which ContinueAsNew is called.
+		// Optional: defaulted to 10000 events.
+		MaximumHistoryLength int64
+	}
+)
+
+// ProcessSignalBatches receives the signals named options.SignalName, decodes their payloads into T, and calls
+// handler with them in batches, as configured by options. Signals which are received while handler is running are
+// added to the next batch. It returns the error of handler, which stops the processing, the error of
+// options.ContinueAsNew, or *CanceledError if ctx is canceled; the signals of an incomplete batch are not delivered
+// then. Signals whose payload fails to decode into T are dropped.
+func ProcessSignalBatches[T any](ctx Context, options SignalBatchOptions, handler func(ctx Context, batch []T) error) error {
+	if options.SignalName == "" {
+		return errors.New("signal batch SignalName is required")
+	}
+	if options.MaxBatchSize < 0 || options.Window < 0 {
+		return errors.New("signal batch MaxBatchSize and Window must not be negative")
+	}
+	if options.MaxBatchSize == 0 && options.Window == 0 {
+		return errors.New("signal batch requires MaxBatchSize or Window")
+	}
+	maximumHistoryLength := options.MaximumHistoryLength
+	if maximumHistoryLength <= 0 {
+		maximumHistoryLength = defaultSignalBatchMaximumHistoryLength
+	}
+
+	ch := GetSignalChannel(ctx, options.SignalName)
+	var batch []T
+	var timer Future
+	cancelTimer := func() {}
+	defer func() { cancelTimer() }()
+	startTimer := func() {
+		cancelTimer()
+		var timerCtx Context
+		timerCtx, cancelTimer = WithCancel(ctx)
+		timer = NewTimer(timerCtx, options.Window)
+	}
+	flush := func() error {
+		cancelTimer()
+		timer = nil
+		if len(batch) == 0 {
+			return nil
+		}
+		delivered := batch
+		batch = nil
+		return handler(ctx, delivered)
+	}
+
+	for {
+		if options.ContinueAsNew != nil && GetHistoryCount(ctx) >= maximumHistoryLength {
+			// the signals which are buffered in the channel would be lost when continuing as new
+			for {
+				var value T
+				if !ch.ReceiveAsync(&value) {
+					break
+				}
+				batch = append(batch, value)
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+			return options.ContinueAsNew(ctx)
+		}
+
+		full, expired := false, false
+		selector := NewSelector(ctx)
+		selector.AddReceive(ch, func(c Channel, more bool) {
+			var value T
+			if !c.ReceiveAsync(&value) {
+				return
+			}
+			batch = append(batch, value)
+			if options.MaxBatchSize > 0 && len(batch) >= options.MaxBatchSize {
+				full = true
+			} else if options.Window > 0 && (timer == nil || options.Debounce) {
+				startTimer()
+			}
+		})
+		if timer != nil {
+			selector.AddFuture(timer, func(f Future) {
+				expired = true
+			})
+		}
+		selector.AddReceive(ctx.Done(), func(c Channel, more bool) {})
+		selector.Select(ctx)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if full || expired {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
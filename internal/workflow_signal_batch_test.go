@@ -0,0 +1,144 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessSignalBatches(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	errDone := errors.New("done")
+
+	type delivery struct {
+		Batch []int
+		After time.Duration
+	}
+	batchWorkflow := func(ctx Context, options SignalBatchOptions) ([]delivery, error) {
+		start := Now(ctx)
+		var deliveries []delivery
+		err := ProcessSignalBatches(ctx, options, func(ctx Context, batch []int) error {
+			deliveries = append(deliveries, delivery{Batch: batch, After: Now(ctx).Sub(start)})
+			if batch[len(batch)-1] < 0 {
+				return errDone
+			}
+			return nil
+		})
+		if err != errDone {
+			return nil, err
+		}
+		return deliveries, nil
+	}
+	// signalAt sends the values as signals at the given minutes
+	signalAt := func(env *TestWorkflowEnvironment, signals map[int][]interface{}) {
+		for minute, values := range signals {
+			values := values
+			env.RegisterDelayedCallback(func() {
+				for _, value := range values {
+					env.SignalWorkflow("event", value)
+				}
+			}, time.Duration(minute)*time.Minute)
+		}
+	}
+	run := func(t *testing.T, options SignalBatchOptions, signals map[int][]interface{}) []delivery {
+		env := testSuite.NewTestWorkflowEnvironment()
+		signalAt(env, signals)
+		env.ExecuteWorkflow(func(ctx Context) ([]delivery, error) {
+			return batchWorkflow(ctx, options)
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var deliveries []delivery
+		require.NoError(t, env.GetWorkflowResult(&deliveries))
+		return deliveries
+	}
+
+	t.Run("size and window", func(t *testing.T) {
+		deliveries := run(t, SignalBatchOptions{SignalName: "event", MaxBatchSize: 3, Window: 10 * time.Minute},
+			map[int][]interface{}{
+				1:  {1, 2, 3, 4},
+				3:  {"corrupt", 5},
+				20: {6},
+				40: {-1},
+			})
+		assert.Equal(t, []delivery{
+			{Batch: []int{1, 2, 3}, After: time.Minute},
+			{Batch: []int{4, 5}, After: 11 * time.Minute},
+			{Batch: []int{6}, After: 30 * time.Minute},
+			{Batch: []int{-1}, After: 50 * time.Minute},
+		}, deliveries)
+	})
+
+	t.Run("debounce", func(t *testing.T) {
+		deliveries := run(t, SignalBatchOptions{SignalName: "event", Window: 5 * time.Minute, Debounce: true},
+			map[int][]interface{}{
+				1:  {1},
+				4:  {2},
+				8:  {3},
+				20: {-1},
+			})
+		assert.Equal(t, []delivery{
+			{Batch: []int{1, 2, 3}, After: 13 * time.Minute},
+			{Batch: []int{-1}, After: 25 * time.Minute},
+		}, deliveries)
+	})
+
+	t.Run("continue as new", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		signalAt(env, map[int][]interface{}{1: {1, 2, 3}})
+		var delivered [][]int
+		env.ExecuteWorkflow(func(ctx Context) error {
+			options := SignalBatchOptions{
+				SignalName:           "event",
+				MaxBatchSize:         2,
+				MaximumHistoryLength: 100,
+				ContinueAsNew: func(ctx Context) error {
+					return NewContinueAsNewError(ctx, "batchWorkflow")
+				},
+			}
+			return ProcessSignalBatches(ctx, options, func(ctx Context, batch []int) error {
+				delivered = append(delivered, batch)
+				// simulate the history growing with every batch
+				GetWorkflowInfo(ctx).HistoryCount += 100
+				return Sleep(ctx, time.Minute)
+			})
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		var continueAsNew *ContinueAsNewError
+		require.True(t, errors.As(env.GetWorkflowError(), &continueAsNew), "%v", env.GetWorkflowError())
+		// the signal which was received while the first batch was handled is delivered before continuing as new
+		assert.Equal(t, [][]int{{1, 2}, {3}}, delivered)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) ([]delivery, error) {
+			return batchWorkflow(ctx, SignalBatchOptions{SignalName: "event"})
+		})
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "requires MaxBatchSize or Window")
+	})
+}
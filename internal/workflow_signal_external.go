@@ -0,0 +1,111 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import "time"
+
+// SignalExternalWorkflowOptions configures a single SignalExternalWorkflowWithOptions call.
+type SignalExternalWorkflowOptions struct {
+	// Domain of the target workflow.
+	// Optional: defaults to the domain set on the context by WithWorkflowDomain, which defaults to the current
+	// workflow's domain.
+	Domain string
+
+	// RetryPolicy for transient signal failures.
+	// Optional: the signal is attempted once when not set.
+	// UnknownExternalWorkflowExecutionError and SignalExternalWorkflowError are retried, which covers targets that are
+	// signaled by workflow ID only and were not started yet. Validation errors, cancellation and
+	// ExternalWorkflowAlreadyCompletedError are never retried. Values of NonRetriableErrorReasons are matched against
+	// the Error() string of the signal failure.
+	RetryPolicy *RetryPolicy
+}
+
+// SignalExternalWorkflowWithOptions is SignalExternalWorkflow with per call options. Input runID is optional, the
+// currently running execution of the workflowID is signaled when it is empty.
+// Each attempt goes through SignalExternalWorkflow, so workflow interceptors observe every retry.
+// The returned Future fails with the error of the last attempt.
+func SignalExternalWorkflowWithOptions(ctx Context, workflowID, runID, signalName string, arg interface{}, options SignalExternalWorkflowOptions) Future {
+	if err := validateSignalExternalWorkflowOptions(workflowID, options); err != nil {
+		future, settable := NewFuture(ctx)
+		settable.Set(nil, err)
+		return future
+	}
+	if options.Domain != "" {
+		ctx = WithWorkflowDomain(ctx, options.Domain)
+	}
+	if options.RetryPolicy == nil {
+		return SignalExternalWorkflow(ctx, workflowID, runID, signalName, arg)
+	}
+
+	policy := options.RetryPolicy
+	future, settable := NewFuture(ctx)
+	Go(ctx, func(ctx Context) {
+		var expireTime time.Time
+		if policy.ExpirationInterval > 0 {
+			expireTime = Now(ctx).Add(policy.ExpirationInterval)
+		}
+		for attempt := int32(0); ; attempt++ {
+			err := SignalExternalWorkflow(ctx, workflowID, runID, signalName, arg).Get(ctx, nil)
+			if err == nil || !isSignalExternalWorkflowRetryable(err) {
+				settable.Set(nil, err)
+				return
+			}
+			if policy.MaximumAttempts > 0 && attempt+1 >= policy.MaximumAttempts {
+				settable.Set(nil, err)
+				return
+			}
+			backoff := getRetryBackoffWithNowTime(policy, attempt, err.Error(), Now(ctx), expireTime)
+			if backoff == noRetryBackoff {
+				settable.Set(nil, err)
+				return
+			}
+			if sleepErr := Sleep(ctx, backoff); sleepErr != nil {
+				settable.Set(nil, sleepErr)
+				return
+			}
+		}
+	})
+	return future
+}
+
+func validateSignalExternalWorkflowOptions(workflowID string, options SignalExternalWorkflowOptions) error {
+	if workflowID == "" {
+		return errWorkflowIDNotSet
+	}
+	if options.Domain != "" {
+		if err := validateExternalWorkflowDomain(&options.Domain); err != nil {
+			return err
+		}
+	}
+	if options.RetryPolicy != nil {
+		return validateRetryPolicy(convertRetryPolicy(options.RetryPolicy))
+	}
+	return nil
+}
+
+func isSignalExternalWorkflowRetryable(err error) bool {
+	switch err.(type) {
+	case *UnknownExternalWorkflowExecutionError, *SignalExternalWorkflowError:
+		return true
+	default:
+		return false
+	}
+}
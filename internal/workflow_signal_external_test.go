@@ -0,0 +1,141 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalExternalWorkflowWithOptions(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	retryPolicy := &RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2,
+		MaximumAttempts:    3,
+	}
+
+	signalWorkflow := func(options SignalExternalWorkflowOptions) func(ctx Context) error {
+		return func(ctx Context) error {
+			return SignalExternalWorkflowWithOptions(ctx, "target-id", "", "signal", "data", options).Get(ctx, nil)
+		}
+	}
+
+	t.Run("retries transient failures", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.OnSignalExternalWorkflow("other-domain", "target-id", "", "signal", "data").
+			Return(newUnknownExternalWorkflowExecutionError()).Once()
+		env.OnSignalExternalWorkflow("other-domain", "target-id", "", "signal", "data").
+			Return(&SignalExternalWorkflowError{Cause: "Unknown"}).Once()
+		env.OnSignalExternalWorkflow("other-domain", "target-id", "", "signal", "data").Return(nil).Once()
+
+		env.ExecuteWorkflow(signalWorkflow(SignalExternalWorkflowOptions{Domain: "other-domain", RetryPolicy: retryPolicy}))
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		env.AssertExpectations(t)
+	})
+
+	t.Run("gives up after maximum attempts", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.OnSignalExternalWorkflow(mock.Anything, "target-id", "", "signal", "data").
+			Return(newUnknownExternalWorkflowExecutionError()).Times(3)
+
+		env.ExecuteWorkflow(signalWorkflow(SignalExternalWorkflowOptions{RetryPolicy: retryPolicy}))
+		require.True(t, env.IsWorkflowCompleted())
+		assert.Contains(t, env.GetWorkflowError().Error(), "UnknownExternalWorkflowExecution")
+		env.AssertExpectations(t)
+	})
+
+	t.Run("does not retry completed workflows", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.OnSignalExternalWorkflow(mock.Anything, "target-id", "", "signal", "data").
+			Return(newExternalWorkflowAlreadyCompletedError()).Once()
+
+		env.ExecuteWorkflow(signalWorkflow(SignalExternalWorkflowOptions{RetryPolicy: retryPolicy}))
+		require.True(t, env.IsWorkflowCompleted())
+		assert.Contains(t, env.GetWorkflowError().Error(), "ExternalWorkflowAlreadyCompleted")
+		env.AssertExpectations(t)
+	})
+
+	t.Run("does not retry without policy", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.OnSignalExternalWorkflow(defaultTestDomain, "target-id", "", "signal", "data").
+			Return(newUnknownExternalWorkflowExecutionError()).Once()
+
+		env.ExecuteWorkflow(signalWorkflow(SignalExternalWorkflowOptions{}))
+		require.True(t, env.IsWorkflowCompleted())
+		assert.Error(t, env.GetWorkflowError())
+		env.AssertExpectations(t)
+	})
+}
+
+func TestValidateSignalExternalWorkflowOptions(t *testing.T) {
+	assert.Equal(t, errWorkflowIDNotSet, validateSignalExternalWorkflowOptions("", SignalExternalWorkflowOptions{}))
+	assert.Equal(t, errDomainInvalid, validateSignalExternalWorkflowOptions("id", SignalExternalWorkflowOptions{Domain: "bad domain"}))
+	assert.Error(t, validateSignalExternalWorkflowOptions("id", SignalExternalWorkflowOptions{RetryPolicy: &RetryPolicy{InitialInterval: time.Second, BackoffCoefficient: 2}}))
+	assert.NoError(t, validateSignalExternalWorkflowOptions("id", SignalExternalWorkflowOptions{Domain: "other-domain"}))
+}
+
+func TestIsSignalExternalWorkflowRetryable(t *testing.T) {
+	assert.True(t, isSignalExternalWorkflowRetryable(newUnknownExternalWorkflowExecutionError()))
+	assert.True(t, isSignalExternalWorkflowRetryable(&SignalExternalWorkflowError{Cause: "Unknown"}))
+	assert.False(t, isSignalExternalWorkflowRetryable(newExternalWorkflowAlreadyCompletedError()))
+	assert.False(t, isSignalExternalWorkflowRetryable(ErrCanceled))
+}
+
+func TestExternalWorkflowCrossDomain(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	t.Run("targets in other domains are not matched by workflow ID", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.OnSignalExternalWorkflow("other-domain", defaultTestWorkflowID, "", "signal", "data").Return(nil).Once()
+		env.OnRequestCancelExternalWorkflow("other-domain", defaultTestWorkflowID, "").Return(nil).Once()
+
+		env.ExecuteWorkflow(func(ctx Context) error {
+			ctx = WithWorkflowDomain(ctx, "other-domain")
+			if err := SignalExternalWorkflow(ctx, defaultTestWorkflowID, "", "signal", "data").Get(ctx, nil); err != nil {
+				return err
+			}
+			return RequestCancelExternalWorkflow(ctx, defaultTestWorkflowID, "").Get(ctx, nil)
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		env.AssertExpectations(t)
+	})
+
+	t.Run("invalid domain", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		var signalErr, cancelErr error
+		env.ExecuteWorkflow(func(ctx Context) error {
+			ctx = WithWorkflowDomain(ctx, "other domain")
+			signalErr = SignalExternalWorkflow(ctx, "target-id", "", "signal", "data").Get(ctx, nil)
+			cancelErr = RequestCancelExternalWorkflow(ctx, "target-id", "").Get(ctx, nil)
+			return nil
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		assert.Equal(t, errDomainInvalid, signalErr)
+		assert.Equal(t, errDomainInvalid, cancelErr)
+	})
+}
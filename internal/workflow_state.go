@@ -0,0 +1,106 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const workflowStateMarkerIDPrefix = "__workflow_state_"
+
+type (
+	// workflowState holds the key/value state of a workflow set through SetState. It is shared by all contexts
+	// derived from the workflow root context.
+	workflowState struct {
+		values  map[string]Value       // recorded values, used by GetState
+		current map[string]interface{} // latest values as set by the workflow, returned by QueryTypeWorkflowState
+
+		queryRegistered bool
+	}
+)
+
+// SetState stores value under key in the replay safe key/value state of the workflow. The value is recorded in the
+// workflow history as a marker only when it differs from the previously stored one, so repeated calls with the same
+// value are cheap. The whole state can be read from outside of the workflow by querying it with the
+// QueryTypeWorkflowState query type.
+func SetState(ctx Context, key string, value interface{}) error {
+	if key == "" {
+		return fmt.Errorf("state key must not be empty")
+	}
+	state, err := getWorkflowState(ctx)
+	if err != nil {
+		return err
+	}
+
+	recorded := MutableSideEffect(ctx, workflowStateMarkerIDPrefix+key, func(ctx Context) interface{} {
+		return value
+	}, func(a, b interface{}) bool {
+		return reflect.DeepEqual(a, b)
+	})
+	state.values[key] = recorded
+	state.current[key] = value
+	return nil
+}
+
+// GetState decodes the value stored under key by SetState into valuePtr. It returns false if the key was never set.
+func GetState(ctx Context, key string, valuePtr interface{}) (bool, error) {
+	value, ok := getWorkflowEnvOptions(ctx).state.values[key]
+	if !ok {
+		return false, nil
+	}
+	return true, value.Get(valuePtr)
+}
+
+// GetStateKeys returns the sorted list of keys set by SetState.
+func GetStateKeys(ctx Context) []string {
+	state := getWorkflowEnvOptions(ctx).state
+	keys := make([]string, 0, len(state.values))
+	for k := range state.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newWorkflowState() *workflowState {
+	return &workflowState{
+		values:  make(map[string]Value),
+		current: make(map[string]interface{}),
+	}
+}
+
+// getWorkflowState returns the state of the workflow, registering the QueryTypeWorkflowState query handler on
+// first use.
+func getWorkflowState(ctx Context) (*workflowState, error) {
+	state := getWorkflowEnvOptions(ctx).state
+	if !state.queryRegistered {
+		err := setQueryHandler(ctx, QueryTypeWorkflowState, func() (map[string]interface{}, error) {
+			return state.current, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		state.queryRegistered = true
+	}
+	return state, nil
+}
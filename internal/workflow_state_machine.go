@@ -0,0 +1,309 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// stateMachineQueryTypePrefix is the query type of the StateMachineStatus of a state machine, followed by its name.
+const stateMachineQueryTypePrefix = "__state_machine"
+
+const (
+	// StateMachineTriggerStart is the trigger of the transition into the initial state.
+	StateMachineTriggerStart = "start"
+	// StateMachineTriggerTimeout is the trigger of the transitions caused by StateDefinition.Timeout.
+	StateMachineTriggerTimeout = "timeout"
+	// StateMachineTriggerSignalPrefix is the prefix of the triggers of the transitions caused by signals, followed by
+	// the signal name.
+	StateMachineTriggerSignalPrefix = "signal:"
+)
+
+type (
+	// StateMachineOptions defines a state machine run by a workflow, see NewStateMachine.
+	StateMachineOptions struct {
+		// Name distinguishes the state machines of a workflow running several of them in their query types.
+		// Optional: default is empty.
+		Name string
+
+		// Initial is the state the machine starts in.
+		Initial string
+
+		// States are the states of the machine by name.
+		States map[string]StateDefinition
+	}
+
+	// StateDefinition defines a state of a state machine: what it does when entered, and its transitions.
+	StateDefinition struct {
+		// OnEnter is called every time the state is entered, e.g. to start the activities of the state and register
+		// transitions on their results with StateMachine.OnFuture. ctx is canceled when the state is left, which
+		// cancels the activities, timers and child workflows the state started and did not wait for.
+		// Returning an error stops the machine with it.
+		OnEnter func(ctx Context, machine *StateMachine) error
+
+		// OnSignal are the transitions on signals, by signal name. Signals which the current state has no transition
+		// for are kept in their signal channel until a state with one is entered.
+		OnSignal map[string]SignalTransition
+
+		// Timeout, if positive, transitions to the OnTimeout state when the state was not left that long after
+		// being entered.
+		Timeout time.Duration
+
+		// OnTimeout is the state entered when Timeout expires. It is required when Timeout is set.
+		OnTimeout string
+
+		// Final states stop the machine when entered, once OnEnter returned.
+		Final bool
+	}
+
+	// SignalTransition is called with the signal channel when the signal is received in a state which has a
+	// transition for it. It must receive the signal from c, and returns the state to enter, or an empty string to
+	// stay in the current state without entering it again.
+	SignalTransition func(ctx Context, c Channel) (next string, err error)
+
+	// FutureTransition is called when a future registered with StateMachine.OnFuture is ready. It returns the state
+	// to enter, or an empty string to stay in the current state without entering it again.
+	FutureTransition func(ctx Context, f Future) (next string, err error)
+
+	// StateTransition is a transition of a state machine.
+	StateTransition struct {
+		From    string
+		To      string
+		Trigger string // StateMachineTriggerStart, StateMachineTriggerTimeout, signal:<name>, or the name of a future
+		Time    time.Time
+	}
+
+	// StateMachineStatus is the current state and the past transitions of a state machine, returned by its query.
+	StateMachineStatus struct {
+		State       string
+		Transitions []StateTransition
+	}
+
+	// StateMachine runs a state machine in a workflow, see NewStateMachine.
+	StateMachine struct {
+		options     StateMachineOptions
+		state       string
+		transitions []StateTransition
+		futures     []*stateMachineFuture // registered by the OnEnter of the current state
+	}
+
+	stateMachineFuture struct {
+		name       string
+		future     Future
+		transition FutureTransition
+		done       bool
+	}
+)
+
+// NewStateMachine validates options and returns a state machine which is started with Run.
+func NewStateMachine(options StateMachineOptions) (*StateMachine, error) {
+	if _, ok := options.States[options.Initial]; !ok {
+		return nil, fmt.Errorf("initial state %q is not defined", options.Initial)
+	}
+	for name, state := range options.States {
+		if state.Timeout < 0 {
+			return nil, fmt.Errorf("state %q has a negative timeout", name)
+		}
+		if state.Timeout > 0 {
+			if _, ok := options.States[state.OnTimeout]; !ok {
+				return nil, fmt.Errorf("timeout state %q of state %q is not defined", state.OnTimeout, name)
+			}
+		}
+		for signalName, transition := range state.OnSignal {
+			if transition == nil {
+				return nil, fmt.Errorf("state %q has no transition function for signal %q", name, signalName)
+			}
+		}
+	}
+	return &StateMachine{options: options}, nil
+}
+
+// StateMachineQueryType returns the query type of the StateMachineStatus of the state machine with the given name.
+func StateMachineQueryType(name string) string {
+	if name == "" {
+		return stateMachineQueryTypePrefix
+	}
+	return stateMachineQueryTypePrefix + ":" + name
+}
+
+// QueryStateMachineStatus queries the StateMachineStatus of the state machine with the given name from the workflow
+// run by the test environment.
+func QueryStateMachineStatus(env *TestWorkflowEnvironment, name string) (StateMachineStatus, error) {
+	var status StateMachineStatus
+	value, err := env.QueryWorkflow(StateMachineQueryType(name))
+	if err != nil {
+		return status, err
+	}
+	err = value.Get(&status)
+	return status, err
+}
+
+// Run runs the machine until it enters a final state, which it returns. It returns the current state and the error
+// when OnEnter or a transition fails, a transition leads to an undefined state, or ctx is canceled. It registers a
+// query handler for the StateMachineStatus of the machine, see StateMachineQueryType.
+func (m *StateMachine) Run(ctx Context) (string, error) {
+	if m.state != "" {
+		return m.state, errors.New("state machine is already running")
+	}
+	err := setQueryHandler(ctx, StateMachineQueryType(m.options.Name), func() (StateMachineStatus, error) {
+		return StateMachineStatus{State: m.state, Transitions: m.transitions}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signals := m.signalNames()
+	channels := make(map[string]Channel, len(signals))
+	for _, name := range signals {
+		channels[name] = GetSignalChannel(ctx, name)
+	}
+
+	next, trigger := m.options.Initial, StateMachineTriggerStart
+	for {
+		stateCtx, cancel := WithCancel(ctx)
+		if err := m.enter(stateCtx, next, trigger); err != nil {
+			cancel()
+			return m.state, err
+		}
+		definition := m.options.States[m.state]
+		if definition.Final {
+			cancel()
+			return m.state, nil
+		}
+		var timer Future
+		if definition.Timeout > 0 {
+			timer = NewTimer(stateCtx, definition.Timeout)
+		}
+
+		next, trigger, err = m.await(ctx, stateCtx, definition, channels, timer)
+		cancel()
+		if err != nil {
+			return m.state, err
+		}
+	}
+}
+
+// await waits for the transition out of the current state.
+func (m *StateMachine) await(ctx, stateCtx Context, definition StateDefinition, channels map[string]Channel, timer Future) (string, string, error) {
+	for {
+		var next, trigger string
+		var err error
+		selector := NewSelector(ctx)
+		selector.AddReceive(ctx.Done(), func(Channel, bool) {
+			err = ctx.Err()
+		})
+		for _, name := range sortedStateMachineSignals(definition.OnSignal) {
+			name, transition := name, definition.OnSignal[name]
+			selector.AddReceive(channels[name], func(c Channel, more bool) {
+				trigger = StateMachineTriggerSignalPrefix + name
+				next, err = transition(stateCtx, c)
+			})
+		}
+		for _, f := range m.futures {
+			if f.done {
+				continue
+			}
+			f := f
+			selector.AddFuture(f.future, func(Future) {
+				f.done = true
+				trigger = f.name
+				next, err = f.transition(stateCtx, f.future)
+			})
+		}
+		if timer != nil {
+			selector.AddFuture(timer, func(Future) {
+				trigger = StateMachineTriggerTimeout
+				next = definition.OnTimeout
+			})
+		}
+		selector.Select(ctx)
+
+		if err != nil {
+			return "", "", err
+		}
+		if next != "" {
+			return next, trigger, nil
+		}
+	}
+}
+
+func (m *StateMachine) enter(ctx Context, state, trigger string) error {
+	definition, ok := m.options.States[state]
+	if !ok {
+		return fmt.Errorf("state %q entered from state %q on %s is not defined", state, m.state, trigger)
+	}
+	m.transitions = append(m.transitions, StateTransition{
+		From:    m.state,
+		To:      state,
+		Trigger: trigger,
+		Time:    Now(ctx),
+	})
+	m.state = state
+	m.futures = nil
+	if definition.OnEnter != nil {
+		return definition.OnEnter(ctx, m)
+	}
+	return nil
+}
+
+// OnFuture registers a transition on the result of f, e.g. an activity started by the OnEnter of the current state.
+// It is called when f is ready, unless the state was left before, with name as the trigger of the transition.
+// Registrations only last until the state is left.
+func (m *StateMachine) OnFuture(f Future, name string, transition FutureTransition) {
+	m.futures = append(m.futures, &stateMachineFuture{name: name, future: f, transition: transition})
+}
+
+// State returns the current state of the machine.
+func (m *StateMachine) State() string {
+	return m.state
+}
+
+// Transitions returns the transitions of the machine so far, starting with the one into the initial state.
+func (m *StateMachine) Transitions() []StateTransition {
+	return append([]StateTransition(nil), m.transitions...)
+}
+
+func (m *StateMachine) signalNames() []string {
+	names := make(map[string]bool)
+	for _, state := range m.options.States {
+		for name := range state.OnSignal {
+			names[name] = true
+		}
+	}
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func sortedStateMachineSignals(transitions map[string]SignalTransition) []string {
+	names := make([]string, 0, len(transitions))
+	for name := range transitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateMachine(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	shipActivity := func(ctx context.Context, order string) (string, error) {
+		if order == "broken" {
+			return "", errors.New("cannot ship")
+		}
+		return "tracking-" + order, nil
+	}
+	newOrderMachine := func(order string) (*StateMachine, error) {
+		return NewStateMachine(StateMachineOptions{
+			Initial: "awaiting-payment",
+			States: map[string]StateDefinition{
+				"awaiting-payment": {
+					OnSignal: map[string]SignalTransition{
+						"payment": func(ctx Context, c Channel) (string, error) {
+							var amount int
+							c.Receive(ctx, &amount)
+							if amount < 10 {
+								return "", nil // partial payment, keep waiting
+							}
+							return "shipping", nil
+						},
+					},
+					Timeout:   time.Hour,
+					OnTimeout: "canceled",
+				},
+				"shipping": {
+					OnEnter: func(ctx Context, machine *StateMachine) error {
+						ctx = WithActivityOptions(ctx, ActivityOptions{
+							ScheduleToStartTimeout: time.Minute,
+							StartToCloseTimeout:    time.Minute,
+						})
+						f := ExecuteActivity(ctx, shipActivity, order)
+						machine.OnFuture(f, "shipped", func(ctx Context, f Future) (string, error) {
+							var tracking string
+							if err := f.Get(ctx, &tracking); err != nil {
+								return "", err
+							}
+							return "done", nil
+						})
+						return nil
+					},
+				},
+				"done":     {Final: true},
+				"canceled": {Final: true},
+			},
+		})
+	}
+	workflowFn := func(ctx Context, order string) (string, error) {
+		machine, err := newOrderMachine(order)
+		if err != nil {
+			return "", err
+		}
+		return machine.Run(ctx)
+	}
+
+	t.Run("signals and activity", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivity(shipActivity)
+		var midway StateMachineStatus
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow("payment", 5)
+		}, time.Minute)
+		env.RegisterDelayedCallback(func() {
+			var err error
+			midway, err = QueryStateMachineStatus(env, "")
+			require.NoError(t, err)
+			env.SignalWorkflow("payment", 20)
+		}, 2*time.Minute)
+		env.ExecuteWorkflow(workflowFn, "order")
+
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var final string
+		require.NoError(t, env.GetWorkflowResult(&final))
+		assert.Equal(t, "done", final)
+
+		assert.Equal(t, "awaiting-payment", midway.State)
+		require.Len(t, midway.Transitions, 1)
+
+		status, err := QueryStateMachineStatus(env, "")
+		require.NoError(t, err)
+		assert.Equal(t, "done", status.State)
+		var triggers, states []string
+		for _, transition := range status.Transitions {
+			triggers = append(triggers, transition.Trigger)
+			states = append(states, transition.From+">"+transition.To)
+		}
+		assert.Equal(t, []string{StateMachineTriggerStart, "signal:payment", "shipped"}, triggers)
+		assert.Equal(t, []string{">awaiting-payment", "awaiting-payment>shipping", "shipping>done"}, states)
+		assert.Equal(t, 2*time.Minute, status.Transitions[1].Time.Sub(status.Transitions[0].Time))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivity(shipActivity)
+		env.ExecuteWorkflow(workflowFn, "order")
+		require.NoError(t, env.GetWorkflowError())
+		var final string
+		require.NoError(t, env.GetWorkflowResult(&final))
+		assert.Equal(t, "canceled", final)
+
+		status, err := QueryStateMachineStatus(env, "")
+		require.NoError(t, err)
+		require.Len(t, status.Transitions, 2)
+		assert.Equal(t, StateMachineTriggerTimeout, status.Transitions[1].Trigger)
+	})
+
+	t.Run("transition error", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivity(shipActivity)
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow("payment", 20)
+		}, time.Minute)
+		env.ExecuteWorkflow(workflowFn, "broken")
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "cannot ship")
+
+		status, err := QueryStateMachineStatus(env, "")
+		require.NoError(t, err)
+		assert.Equal(t, "shipping", status.State)
+	})
+
+	t.Run("undefined state", func(t *testing.T) {
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(func(ctx Context) (string, error) {
+			machine, err := NewStateMachine(StateMachineOptions{
+				Name:    "named",
+				Initial: "a",
+				States: map[string]StateDefinition{
+					"a": {OnEnter: func(ctx Context, machine *StateMachine) error {
+						machine.OnFuture(NewTimer(ctx, time.Second), "tick", func(Context, Future) (string, error) {
+							return "missing", nil
+						})
+						return nil
+					}},
+				},
+			})
+			if err != nil {
+				return "", err
+			}
+			return machine.Run(ctx)
+		})
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), `state "missing" entered from state "a" on tick is not defined`)
+
+		status, err := QueryStateMachineStatus(env, "named")
+		require.NoError(t, err)
+		assert.Equal(t, "a", status.State)
+	})
+}
+
+func TestNewStateMachine_Validation(t *testing.T) {
+	_, err := NewStateMachine(StateMachineOptions{Initial: "a"})
+	assert.EqualError(t, err, `initial state "a" is not defined`)
+
+	_, err = NewStateMachine(StateMachineOptions{
+		Initial: "a",
+		States:  map[string]StateDefinition{"a": {Timeout: time.Second}},
+	})
+	assert.EqualError(t, err, `timeout state "" of state "a" is not defined`)
+
+	_, err = NewStateMachine(StateMachineOptions{
+		Initial: "a",
+		States:  map[string]StateDefinition{"a": {OnSignal: map[string]SignalTransition{"s": nil}}},
+	})
+	assert.EqualError(t, err, `state "a" has no transition function for signal "s"`)
+
+	assert.Equal(t, "__state_machine", StateMachineQueryType(""))
+	assert.Equal(t, "__state_machine:orders", StateMachineQueryType("orders"))
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowState(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+
+	type progress struct {
+		Done  int
+		Total int
+	}
+
+	t.Run("get", func(t *testing.T) {
+		workflowFn := func(ctx Context) (progress, error) {
+			var p progress
+			ok, err := GetState(ctx, "progress", &p)
+			if err != nil || ok {
+				return p, err
+			}
+			if err := SetState(ctx, "progress", progress{Done: 1, Total: 3}); err != nil {
+				return p, err
+			}
+			if err := SetState(ctx, "progress", progress{Done: 2, Total: 3}); err != nil {
+				return p, err
+			}
+			_, err = GetState(ctx, "progress", &p)
+			return p, err
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var result progress
+		require.NoError(t, env.GetWorkflowResult(&result))
+		assert.Equal(t, progress{Done: 2, Total: 3}, result)
+	})
+
+	t.Run("empty key", func(t *testing.T) {
+		workflowFn := func(ctx Context) error {
+			return SetState(ctx, "", 1)
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(workflowFn)
+		require.Error(t, env.GetWorkflowError())
+		assert.Contains(t, env.GetWorkflowError().Error(), "state key must not be empty")
+	})
+
+	t.Run("query", func(t *testing.T) {
+		var keys []string
+		workflowFn := func(ctx Context) error {
+			if err := SetState(ctx, "stage", "download"); err != nil {
+				return err
+			}
+			if err := SetState(ctx, "progress", progress{Done: 1, Total: 3}); err != nil {
+				return err
+			}
+			if err := Sleep(ctx, time.Hour); err != nil {
+				return err
+			}
+			if err := SetState(ctx, "stage", "upload"); err != nil {
+				return err
+			}
+			keys = GetStateKeys(ctx)
+			return Sleep(ctx, time.Hour)
+		}
+		env := testSuite.NewTestWorkflowEnvironment()
+
+		type state struct {
+			Stage    string
+			Progress progress
+		}
+		queryState := func() state {
+			value, err := env.QueryWorkflow(QueryTypeWorkflowState)
+			require.NoError(t, err)
+			var result state
+			require.NoError(t, value.Get(&result))
+			return result
+		}
+		env.RegisterDelayedCallback(func() {
+			assert.Equal(t, state{Stage: "download", Progress: progress{Done: 1, Total: 3}}, queryState())
+		}, time.Minute)
+		env.RegisterDelayedCallback(func() {
+			assert.Equal(t, state{Stage: "upload", Progress: progress{Done: 1, Total: 3}}, queryState())
+		}, time.Hour+time.Minute)
+
+		env.ExecuteWorkflow(workflowFn)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, []string{"progress", "stage"}, keys)
+	})
+}
@@ -25,8 +25,11 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
@@ -60,6 +63,30 @@ type (
 		impl *testWorkflowEnvironmentImpl
 	}
 
+	// PropagatedHeader is a header the tested workflow sent along with an activity, a local activity or a child
+	// workflow. Its fields are written by the context propagators of the test suite.
+	PropagatedHeader struct {
+		Kind PropagatedHeaderKind
+		// Name is the activity type or the child workflow type.
+		Name   string
+		Fields map[string][]byte
+	}
+
+	// PropagatedHeaderKind is the kind of call a PropagatedHeader was sent with.
+	PropagatedHeaderKind string
+
+	// TestBreakpoint pauses the tested workflow at a point of its execution, so that the test can inspect it with
+	// QueryWorkflow and send it signals before resuming it. See TestWorkflowEnvironment.SetBreakpointAt.
+	TestBreakpoint struct {
+		at         time.Time
+		operations int
+
+		reached    bool
+		doneCh     chan struct{}
+		resumeCh   chan struct{}
+		resumeOnce sync.Once
+	}
+
 	// MockCallWrapper is a wrapper to mock.Call. It offers the ability to wait on workflow's clock instead of wall clock.
 	MockCallWrapper struct {
 		call *mock.Call
@@ -70,6 +97,13 @@ type (
 	}
 )
 
+// Kinds of PropagatedHeader.
+const (
+	PropagatedHeaderActivity      PropagatedHeaderKind = "activity"
+	PropagatedHeaderLocalActivity PropagatedHeaderKind = "local-activity"
+	PropagatedHeaderChildWorkflow PropagatedHeaderKind = "child-workflow"
+)
+
 func newEncodedValues(values []byte, dc DataConverter) Values {
 	if dc == nil {
 		dc = getDefaultDataConverter()
@@ -321,6 +355,7 @@ const mockMethodForUpsertSearchAttributes = "workflow.UpsertSearchAttributes"
 // For example, sending signals between parent and child workflows. Or sending signals between 2 child workflows.
 // However, it does not know what to do if your tested workflow code is sending signal to external unknown workflows.
 // In that case, you will need to setup mock for those signal calls.
+// Known workflows are matched by domain and workflow ID, so signals to other domains always go to the mock.
 // Some examples of how to setup mock:
 //
 //   - mock for specific target workflow that matches specific signal name and signal data
@@ -340,11 +375,68 @@ func (t *TestWorkflowEnvironment) OnSignalExternalWorkflow(domainName, workflowI
 	return t.wrapCall(call)
 }
 
+// OnWorkflowRequest setup a mock for the requests sent by a WorkflowRequester of the tested workflow with the signal
+// signalName to the workflow workflowID, which responds with the result or the error returned by handler. handler is
+// called with the argument of the request. Some examples of how to setup mock:
+//
+//   - respond to every request sent with the "quote" signal to "pricing"
+//     env.OnWorkflowRequest("pricing", "quote", func(arg Value) (interface{}, error) {
+//     var item string
+//     _ = arg.Get(&item)
+//     return 42, nil
+//     })
+//   - respond to one request sent to any workflow with an error
+//     env.OnWorkflowRequest(mock.Anything, mock.Anything, func(arg Value) (interface{}, error) {
+//     return nil, errors.New("out of stock")
+//     }).Once()
+func (t *TestWorkflowEnvironment) OnWorkflowRequest(workflowID, signalName interface{}, handler func(arg Value) (interface{}, error)) *MockCallWrapper {
+	return t.OnSignalExternalWorkflow(mock.Anything, workflowID, mock.Anything, signalName, mock.AnythingOfType("internal.WorkflowRequest")).Return(
+		func(domainName, workflowID, runID, signalName string, arg interface{}) error {
+			request := arg.(WorkflowRequest)
+			dc := t.impl.GetDataConverter()
+			response := WorkflowResponse{ID: request.ID}
+			result, err := handler(newEncodedValue(request.Payload, dc))
+			if err != nil {
+				response.Error = err.Error()
+			} else if response.Payload, err = encodeArg(dc, result); err != nil {
+				return err
+			}
+			t.impl.signalWorkflow(request.ResponseSignalName, response, true)
+			return nil
+		})
+}
+
+// SendWorkflowRequest sends a WorkflowRequest with the argument arg to the tested workflow with the signal signalName,
+// like a WorkflowRequester of another workflow. callback is called with the result or the error of the response the
+// tested workflow sends back with RespondToWorkflowRequest.
+func (t *TestWorkflowEnvironment) SendWorkflowRequest(signalName string, arg interface{}, callback func(result Value, err error)) {
+	dc := t.impl.GetDataConverter()
+	payload, err := encodeArg(dc, arg)
+	if err != nil {
+		panic(err)
+	}
+	request := WorkflowRequest{
+		ID:                 uuid.New(),
+		ReplyTo:            WorkflowExecution{ID: testWorkflowRequesterID},
+		ResponseSignalName: DefaultWorkflowResponseSignalName,
+		Payload:            payload,
+	}
+	t.impl.setWorkflowRequestCallback(request.ID, func(response WorkflowResponse) {
+		if response.Error != "" {
+			callback(nil, &WorkflowRequestError{Message: response.Error})
+		} else {
+			callback(newEncodedValue(response.Payload, dc), nil)
+		}
+	})
+	t.SignalWorkflow(signalName, request)
+}
+
 // OnRequestCancelExternalWorkflow setup a mock for cancellation of external workflow.
 // This TestWorkflowEnvironment handles cancellation of workflows that are started from the root workflow.
 // For example, cancellation sent from parent to child workflows. Or cancellation between 2 child workflows.
 // However, it does not know what to do if your tested workflow code is sending cancellation to external unknown workflows.
 // In that case, you will need to setup mock for those cancel calls.
+// Known workflows are matched by domain and workflow ID, so cancellations in other domains always go to the mock.
 // Some examples of how to setup mock:
 //
 //   - mock for specific target workflow that matches specific workflow ID and run ID
@@ -457,6 +549,26 @@ func (t *TestWorkflowEnvironment) SetWorkerStopChannel(c chan struct{}) {
 	t.impl.setWorkerStopChannel(c)
 }
 
+// SetDecisionTimeRemaining sets the function workflow.GetDecisionTimeRemaining returns the result of, to test how the
+// workflow splits its work when its decision tasks are about to time out. By default the test environment returns the
+// full decision task timeout of the workflow.
+func (t *TestWorkflowEnvironment) SetDecisionTimeRemaining(remaining func() time.Duration) *TestWorkflowEnvironment {
+	t.impl.decisionTimeRemaining = remaining
+	return t
+}
+
+// SetQueryHandlerChecks executes the query handlers of the tested workflows which take no arguments at the end of every
+// decision task, once all coroutines are blocked, which is the state the queries of the server observe. It finds query
+// handlers failing on the workflow state between two decision tasks, e.g. a counter incremented before and a list
+// appended to after an activity completes, which otherwise only surfaces under production query load. The workflow
+// fails when a query handler returns an error or panics, with the stack traces of the blocked coroutines. It does not
+// track which state the query handlers access, so handlers returning inconsistent results without failing are not
+// reported.
+func (t *TestWorkflowEnvironment) SetQueryHandlerChecks(enabled bool) *TestWorkflowEnvironment {
+	t.impl.checkQueryHandlers = enabled
+	return t
+}
+
 // SetTestTimeout sets the idle timeout based on wall clock for this tested workflow. Idle is when workflow is blocked
 // waiting on events (including timer, activity, child workflow, signal etc). If there is no event happening longer than
 // this idle timeout, the test framework would stop the workflow and return timeout error.
@@ -492,6 +604,141 @@ func (t *TestWorkflowEnvironment) SetWorkflowCronMaxIterations(maxIterations int
 	return t
 }
 
+// GetPropagatedHeaders returns the headers sent by the tested workflow and its child workflows along with their
+// activities, local activities and child workflows, in the order of the calls.
+func (t *TestWorkflowEnvironment) GetPropagatedHeaders() []PropagatedHeader {
+	return t.impl.getPropagatedHeaders()
+}
+
+// AssertPropagatedHeader asserts that headers were sent along with the activities, local activities or child
+// workflows of the given kind and name, and that all of them hold the expected value for the key.
+func (t *TestWorkflowEnvironment) AssertPropagatedHeader(tt mock.TestingT, kind PropagatedHeaderKind, name, key string, expected []byte) bool {
+	found := false
+	for _, header := range t.impl.getPropagatedHeaders() {
+		if header.Kind != kind || header.Name != name {
+			continue
+		}
+		found = true
+		if value, ok := header.Fields[key]; !ok || !reflect.DeepEqual(value, expected) {
+			tt.Errorf("header %q sent to %v %v is %q, expected %q", key, kind, name, value, expected)
+			return false
+		}
+	}
+	if !found {
+		tt.Errorf("no header was sent to %v %v", kind, name)
+	}
+	return found
+}
+
+// Trace returns the operations of the tested workflow in the order they happened, one line per operation:
+//   - "activity <activity type>" when an activity is scheduled
+//   - "local activity <activity type>" when a local activity is scheduled
+//   - "timer <duration>" when a timer is created, e.g. "timer 1m0s"
+//   - "child workflow <workflow type>" when a child workflow is started
+//   - "signal external <workflow ID> <signal name>" when a signal is sent to another workflow
+//   - "signal <signal name>" when the workflow receives a signal
+//   - "version <change ID> <version>" when GetVersion chooses a version for a change ID
+//
+// Operations of child workflows are not included.
+func (t *TestWorkflowEnvironment) Trace() []string {
+	return t.impl.getTrace()
+}
+
+// AssertTrace asserts that the expected lines appear in the Trace of the tested workflow in the given order. Other
+// operations may happen between them, so that a test only needs to list the operations it is about.
+func (t *TestWorkflowEnvironment) AssertTrace(tt mock.TestingT, expected ...string) bool {
+	trace := t.impl.getTrace()
+	next := 0
+	for _, line := range trace {
+		if next < len(expected) && line == expected[next] {
+			next++
+		}
+	}
+	if next < len(expected) {
+		tt.Errorf("trace does not contain %q after %q\ntrace:\n\t%v", expected[next], expected[:next], strings.Join(trace, "\n\t"))
+		return false
+	}
+	return true
+}
+
+// SetBreakpointAt pauses the tested workflow when the workflow clock reaches the given time and the workflow is blocked.
+// It enables step-through tests of long-running workflows, by running ExecuteWorkflow in a separate goroutine:
+//
+//	breakpoint := env.SetBreakpointAt(env.Now().Add(time.Hour))
+//	done := make(chan struct{})
+//	go func() {
+//		defer close(done)
+//		env.ExecuteWorkflow(workflowFn)
+//	}()
+//	if breakpoint.Wait() {
+//		state, err := env.QueryWorkflow("state")
+//		...
+//		env.SignalWorkflow("approve", nil)
+//		breakpoint.Resume()
+//	}
+//	<-done
+//
+// The workflow stays paused until Resume is called.
+func (t *TestWorkflowEnvironment) SetBreakpointAt(at time.Time) *TestBreakpoint {
+	breakpoint := newTestBreakpoint()
+	breakpoint.at = at
+	t.impl.setBreakpoint(breakpoint, at)
+	return breakpoint
+}
+
+// SetBreakpointAfterOperations pauses the tested workflow once it has done the given number of operations, as recorded
+// by Trace, and is blocked. See SetBreakpointAt.
+func (t *TestWorkflowEnvironment) SetBreakpointAfterOperations(operations int) *TestBreakpoint {
+	breakpoint := newTestBreakpoint()
+	breakpoint.operations = operations
+	t.impl.setBreakpoint(breakpoint, time.Time{})
+	return breakpoint
+}
+
+func newTestBreakpoint() *TestBreakpoint {
+	return &TestBreakpoint{doneCh: make(chan struct{}), resumeCh: make(chan struct{})}
+}
+
+// Wait blocks until the tested workflow is paused at the breakpoint and returns true, or returns false if the workflow
+// completed without reaching it.
+func (b *TestBreakpoint) Wait() bool {
+	<-b.doneCh
+	return b.reached
+}
+
+// Resume resumes the tested workflow paused at the breakpoint.
+func (b *TestBreakpoint) Resume() {
+	b.resumeOnce.Do(func() { close(b.resumeCh) })
+}
+
+func (b *TestBreakpoint) isReached(env *testWorkflowEnvironmentImpl) bool {
+	if !b.at.IsZero() {
+		return !env.mockClock.Now().Before(b.at)
+	}
+	return len(env.getTrace()) >= b.operations
+}
+
+// SetDefaultActivityOptions sets the default activity options of the tested workflow, as if the workflow was started
+// with StartWorkflowOptions.DefaultActivityOptions.
+func (t *TestWorkflowEnvironment) SetDefaultActivityOptions(options ActivityOptions) *TestWorkflowEnvironment {
+	t.impl.setDefaultActivityOptions(func(d *defaultActivityOptions) { d.Activity = &options })
+	return t
+}
+
+// SetDefaultLocalActivityOptions sets the default local activity options of the tested workflow, as if the workflow
+// was started with StartWorkflowOptions.DefaultLocalActivityOptions.
+func (t *TestWorkflowEnvironment) SetDefaultLocalActivityOptions(options LocalActivityOptions) *TestWorkflowEnvironment {
+	t.impl.setDefaultActivityOptions(func(d *defaultActivityOptions) { d.LocalActivity = &options })
+	return t
+}
+
+// SetHeaders sets the headers of the tested workflow, as if the workflow was started with
+// StartWorkflowOptions.Headers.
+func (t *TestWorkflowEnvironment) SetHeaders(headers map[string]string) *TestWorkflowEnvironment {
+	t.impl.setHeaders(headers)
+	return t
+}
+
 // SetOnActivityStartedListener sets a listener that will be called before activity starts execution.
 // Note: ActivityInfo is defined in internal package, use public type activity.Info instead.
 func (t *TestWorkflowEnvironment) SetOnActivityStartedListener(
@@ -619,7 +866,13 @@ func (t *TestWorkflowEnvironment) CompleteActivity(taskToken []byte, result inte
 
 // CancelWorkflow requests cancellation (through workflow Context) to the currently running test workflow.
 func (t *TestWorkflowEnvironment) CancelWorkflow() {
-	t.impl.cancelWorkflow(func(result []byte, err error) {})
+	t.CancelWorkflowWithDetails(CancellationDetails{Source: CancellationSourceClient})
+}
+
+// CancelWorkflowWithDetails requests cancellation of the currently running test workflow like CancelWorkflow, and
+// makes details available to the workflow through GetCancellationDetails.
+func (t *TestWorkflowEnvironment) CancelWorkflowWithDetails(details CancellationDetails) {
+	t.impl.cancelWorkflow(&details, func(result []byte, err error) {})
 }
 
 // SignalWorkflow sends signal to the currently running test workflow.
@@ -0,0 +1,112 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+type (
+	// TypedChannel is a Channel of values of type T, so that sending a value of another type fails to compile, and
+	// Receive returns the value instead of filling a pointer. It is layered on top of Channel, values which cannot be
+	// read as T, e.g. corrupt signals, are handled as by Channel.Receive:
+	//
+	//	orders := AsTypedChannel[Order](GetSignalChannel(ctx, "order"))
+	//	order, _ := orders.Receive(ctx)
+	TypedChannel[T any] interface {
+		// Receive blocks until it receives a value and returns it. more is false when the channel is closed and all
+		// values were received, value is the zero value of T then. See Channel.Receive.
+		Receive(ctx Context) (value T, more bool)
+
+		// ReceiveAsync receives a value without blocking. ok is false when no value is available.
+		ReceiveAsync() (value T, ok bool)
+
+		// ReceiveAsyncWithMoreFlag is the same as ReceiveAsync, with an extra return to indicate if there could be
+		// more values from the channel in the future.
+		ReceiveAsyncWithMoreFlag() (value T, ok bool, more bool)
+
+		// Send blocks until the value is sent. See Channel.Send.
+		Send(ctx Context, value T)
+
+		// SendAsync tries to send the value without blocking. It returns false when the value was not sent.
+		SendAsync(value T) (ok bool)
+
+		// Close closes the channel. See Channel.Close.
+		Close()
+
+		// Channel returns the untyped channel.
+		Channel() Channel
+	}
+
+	typedChannel[T any] struct {
+		channel Channel
+	}
+)
+
+// NewTypedChannel creates a new TypedChannel.
+func NewTypedChannel[T any](ctx Context) TypedChannel[T] {
+	return AsTypedChannel[T](NewChannel(ctx))
+}
+
+// NewBufferedTypedChannel creates a new buffered TypedChannel.
+func NewBufferedTypedChannel[T any](ctx Context, size int) TypedChannel[T] {
+	return AsTypedChannel[T](NewBufferedChannel(ctx, size))
+}
+
+// AsTypedChannel returns a TypedChannel sending and receiving the values of the channel as T, e.g. of a signal channel.
+func AsTypedChannel[T any](channel Channel) TypedChannel[T] {
+	return &typedChannel[T]{channel: channel}
+}
+
+// AddReceiveTyped adds a case to the selector receiving from a TypedChannel. f is invoked when a value can be received
+// from the channel, and must receive it. See Selector.AddReceive.
+func AddReceiveTyped[T any](selector Selector, c TypedChannel[T], f func(c TypedChannel[T], more bool)) Selector {
+	return selector.AddReceive(c.Channel(), func(_ Channel, more bool) {
+		f(c, more)
+	})
+}
+
+func (c *typedChannel[T]) Receive(ctx Context) (value T, more bool) {
+	more = c.channel.Receive(ctx, &value)
+	return value, more
+}
+
+func (c *typedChannel[T]) ReceiveAsync() (value T, ok bool) {
+	ok = c.channel.ReceiveAsync(&value)
+	return value, ok
+}
+
+func (c *typedChannel[T]) ReceiveAsyncWithMoreFlag() (value T, ok bool, more bool) {
+	ok, more = c.channel.ReceiveAsyncWithMoreFlag(&value)
+	return value, ok, more
+}
+
+func (c *typedChannel[T]) Send(ctx Context, value T) {
+	c.channel.Send(ctx, value)
+}
+
+func (c *typedChannel[T]) SendAsync(value T) (ok bool) {
+	return c.channel.SendAsync(value)
+}
+
+func (c *typedChannel[T]) Close() {
+	c.channel.Close()
+}
+
+func (c *typedChannel[T]) Channel() Channel {
+	return c.channel
+}
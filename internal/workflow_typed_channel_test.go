@@ -0,0 +1,85 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedChannelOrder struct {
+	ID    string
+	Items int
+}
+
+func TestTypedChannel(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	workflowFn := func(ctx Context) ([]string, error) {
+		var trace []string
+
+		buffered := NewBufferedTypedChannel[int](ctx, 2)
+		trace = append(trace, fmt.Sprint(buffered.SendAsync(1), buffered.SendAsync(2), buffered.SendAsync(3)))
+		value, ok := buffered.ReceiveAsync()
+		trace = append(trace, fmt.Sprint(value, ok))
+
+		unbuffered := NewTypedChannel[string](ctx)
+		Go(ctx, func(ctx Context) {
+			unbuffered.Send(ctx, "sent")
+			unbuffered.Close()
+		})
+		received, more := unbuffered.Receive(ctx)
+		trace = append(trace, fmt.Sprintf("%v %v", received, more))
+		received, more = unbuffered.Receive(ctx)
+		trace = append(trace, fmt.Sprintf("%q %v", received, more))
+
+		orders := AsTypedChannel[typedChannelOrder](GetSignalChannel(ctx, "order"))
+		for len(trace) < 6 {
+			selector := NewSelector(ctx)
+			AddReceiveTyped(selector, orders, func(c TypedChannel[typedChannelOrder], more bool) {
+				order, _ := c.Receive(ctx)
+				trace = append(trace, fmt.Sprintf("%v %v", order.ID, order.Items))
+			})
+			AddReceiveTyped(selector, buffered, func(c TypedChannel[int], more bool) {
+				value, _, more := c.ReceiveAsyncWithMoreFlag()
+				trace = append(trace, fmt.Sprint(value, more))
+			})
+			selector.Select(ctx)
+		}
+		return trace, nil
+	}
+
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("order", "corrupt")
+		env.SignalWorkflow("order", typedChannelOrder{ID: "order", Items: 3})
+	}, time.Minute)
+	env.ExecuteWorkflow(workflowFn)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var trace []string
+	require.NoError(t, env.GetWorkflowResult(&trace))
+	// the corrupt signal is dropped
+	assert.Equal(t, []string{"true true false", "1 true", "sent true", `"" false`, "2 true", "order 3"}, trace)
+}
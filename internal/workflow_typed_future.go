@@ -0,0 +1,116 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import "fmt"
+
+type (
+	// TypedFuture is a Future whose value is of type T, so that Get returns the value instead of filling a pointer.
+	// It is layered on top of Future:
+	//
+	//	f := AsTypedFuture[string](ExecuteActivity(ctx, activityFn))
+	//	result, err := f.Get(ctx)
+	TypedFuture[T any] interface {
+		// Get blocks until the future is ready and returns its value and error. The value is the zero value of T
+		// when the error is not nil.
+		Get(ctx Context) (T, error)
+
+		// IsReady returns true when Get is guaranteed to not block.
+		IsReady() bool
+
+		// Future returns the untyped future, e.g. to add it to a Selector.
+		Future() Future
+	}
+
+	// TypedSettable sets the value or the error of a TypedFuture. See NewTypedFuture.
+	TypedSettable[T any] interface {
+		Set(value T, err error)
+		SetValue(value T)
+		SetError(err error)
+		Chain(future TypedFuture[T]) // Value (or error) of the future become the same of the chained one.
+	}
+
+	typedFuture[T any] struct {
+		future Future
+	}
+
+	typedSettable[T any] struct {
+		settable Settable
+	}
+)
+
+// NewTypedFuture creates a new TypedFuture as well as the associated TypedSettable that is used to set its value.
+func NewTypedFuture[T any](ctx Context) (TypedFuture[T], TypedSettable[T]) {
+	future, settable := NewFuture(ctx)
+	return &typedFuture[T]{future: future}, &typedSettable[T]{settable: settable}
+}
+
+// AsTypedFuture returns a TypedFuture reading the value of the future as T, e.g. the result of an activity or a child
+// workflow. A value that cannot be read as T is returned as an error by Get.
+func AsTypedFuture[T any](future Future) TypedFuture[T] {
+	return &typedFuture[T]{future: future}
+}
+
+func (f *typedFuture[T]) Get(ctx Context) (T, error) {
+	var result T
+	// wait for the future without decoding its value
+	if err := f.future.Get(ctx, nil); err != nil {
+		return result, err
+	}
+	// values set by workflow code are stored as they are, and do not need to go through reflection
+	if impl, ok := f.future.(*futureImpl); ok {
+		if impl.value == nil {
+			return result, nil
+		}
+		if value, ok := impl.value.(T); ok {
+			return value, nil
+		}
+		if _, ok := impl.value.([]byte); !ok {
+			return result, fmt.Errorf("value of type %T cannot be read as %T", impl.value, result)
+		}
+	}
+	err := f.future.Get(ctx, &result)
+	return result, err
+}
+
+func (f *typedFuture[T]) IsReady() bool {
+	return f.future.IsReady()
+}
+
+func (f *typedFuture[T]) Future() Future {
+	return f.future
+}
+
+func (s *typedSettable[T]) Set(value T, err error) {
+	s.settable.Set(value, err)
+}
+
+func (s *typedSettable[T]) SetValue(value T) {
+	s.settable.SetValue(value)
+}
+
+func (s *typedSettable[T]) SetError(err error) {
+	s.settable.SetError(err)
+}
+
+func (s *typedSettable[T]) Chain(future TypedFuture[T]) {
+	s.settable.Chain(future.Future())
+}
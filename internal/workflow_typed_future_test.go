@@ -0,0 +1,104 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedFutureResult struct {
+	Name  string
+	Count int
+}
+
+func TestTypedFuture(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	activityFn := func(ctx context.Context, name string) (typedFutureResult, error) {
+		return typedFutureResult{Name: name, Count: len(name)}, nil
+	}
+
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activityFn)
+	env.ExecuteWorkflow(func(ctx Context) ([]string, error) {
+		var trace []string
+
+		f, s := NewTypedFuture[*typedFutureResult](ctx)
+		Go(ctx, func(ctx Context) {
+			_ = Sleep(ctx, time.Second)
+			s.SetValue(&typedFutureResult{Name: "set"})
+		})
+		if f.IsReady() {
+			return nil, errors.New("future is ready before it is set")
+		}
+		set, err := f.Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		trace = append(trace, set.Name)
+
+		failed, failedSettable := NewTypedFuture[int](ctx)
+		failedSettable.Set(0, errors.New("failed"))
+		if _, err := failed.Get(ctx); err != nil {
+			trace = append(trace, err.Error())
+		}
+
+		chained, chainedSettable := NewTypedFuture[int](ctx)
+		source, sourceSettable := NewTypedFuture[int](ctx)
+		chainedSettable.Chain(source)
+		sourceSettable.SetValue(42)
+		value, err := chained.Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		trace = append(trace, fmt.Sprint(value))
+
+		ctx = WithActivityOptions(ctx, ActivityOptions{ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: time.Minute})
+		activity := AsTypedFuture[typedFutureResult](ExecuteActivity(ctx, activityFn, "activity"))
+		var selected typedFutureResult
+		NewSelector(ctx).AddFuture(activity.Future(), func(f Future) {
+			selected, err = activity.Get(ctx)
+		}).Select(ctx)
+		if err != nil {
+			return nil, err
+		}
+		trace = append(trace, fmt.Sprintf("%v %v", selected.Name, selected.Count))
+
+		// a value that cannot be read as T
+		wrong, wrongSettable := NewFuture(ctx)
+		wrongSettable.SetValue("not a number")
+		if _, err := AsTypedFuture[int](wrong).Get(ctx); err != nil {
+			trace = append(trace, "wrong type")
+		}
+		return trace, nil
+	})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var trace []string
+	require.NoError(t, env.GetWorkflowResult(&trace))
+	assert.Equal(t, []string{"set", "failed", "42", "activity 8", "wrong type"}, trace)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"time"
+)
+
+type (
+	// UpdatableTimer is a timer whose firing time can be changed while it is pending, e.g. to push back an
+	// inactivity deadline every time a signal is received. Use Future() to add it to a Selector.
+	UpdatableTimer interface {
+		// Get blocks until the timer fires. It returns *CanceledError if the ctx of NewUpdatableTimer is canceled.
+		Get(ctx Context) error
+		// IsReady returns true when the timer fired or was canceled.
+		IsReady() bool
+		// Future returns the Future which becomes ready when the timer fires or is canceled.
+		Future() Future
+		// FireTime returns the time when the timer fires, in workflow time.
+		FireTime() time.Time
+		// SetFireTime changes the time when the timer fires. A time which is not in the future fires the timer right
+		// away. It has no effect once the timer fired or was canceled.
+		SetFireTime(t time.Time)
+		// Extend postpones the firing time by d, or moves it earlier when d is negative.
+		Extend(d time.Duration)
+		// Reset sets the firing time to d from now, as if the timer was created again.
+		Reset(d time.Duration)
+	}
+
+	updatableTimerImpl struct {
+		ctx         Context
+		future      Future
+		settable    Settable
+		fireTime    time.Time
+		cancelTimer CancelFunc
+		generation  int
+	}
+)
+
+// NewUpdatableTimer returns immediately a timer which fires after the duration d, unless its firing time is changed
+// with SetFireTime, Extend or Reset. Each change cancels the pending timer and starts a new one, so the history of the
+// workflow has a canceled timer for each change, and replaying the workflow makes the same changes.
+// Canceling ctx cancels the timer, Get then returns *CanceledError.
+func NewUpdatableTimer(ctx Context, d time.Duration) UpdatableTimer {
+	future, settable := NewFuture(ctx)
+	t := &updatableTimerImpl{
+		ctx:      ctx,
+		future:   future,
+		settable: settable,
+		fireTime: Now(ctx).Add(d),
+	}
+	t.schedule()
+	return t
+}
+
+func (t *updatableTimerImpl) Get(ctx Context) error {
+	return t.future.Get(ctx, nil)
+}
+
+func (t *updatableTimerImpl) IsReady() bool {
+	return t.future.IsReady()
+}
+
+func (t *updatableTimerImpl) Future() Future {
+	return t.future
+}
+
+func (t *updatableTimerImpl) FireTime() time.Time {
+	return t.fireTime
+}
+
+func (t *updatableTimerImpl) SetFireTime(fireTime time.Time) {
+	if t.future.IsReady() {
+		return
+	}
+	t.fireTime = fireTime
+	t.schedule()
+}
+
+func (t *updatableTimerImpl) Extend(d time.Duration) {
+	t.SetFireTime(t.fireTime.Add(d))
+}
+
+func (t *updatableTimerImpl) Reset(d time.Duration) {
+	t.SetFireTime(Now(t.ctx).Add(d))
+}
+
+// schedule cancels the pending timer, if any, and starts a timer firing at fireTime.
+func (t *updatableTimerImpl) schedule() {
+	if t.cancelTimer != nil {
+		t.cancelTimer()
+		t.cancelTimer = nil
+	}
+	t.generation++
+	d := t.fireTime.Sub(Now(t.ctx))
+	if d <= 0 {
+		t.settable.Set(nil, nil)
+		return
+	}
+
+	timerCtx, cancel := WithCancel(t.ctx)
+	t.cancelTimer = cancel
+	timer := NewTimer(timerCtx, d)
+	generation := t.generation
+	GoNamed(t.ctx, "updatable-timer", func(ctx Context) {
+		err := timer.Get(ctx, nil)
+		// the timer canceled by a change of the firing time is ignored, while the cancellation of t.ctx is reported
+		if generation == t.generation && !t.future.IsReady() {
+			cancel()
+			t.settable.Set(nil, err)
+		}
+	})
+}
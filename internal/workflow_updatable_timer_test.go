@@ -0,0 +1,97 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdatableTimer(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.ExecuteWorkflow(func(ctx Context) ([]time.Duration, error) {
+		start := Now(ctx)
+		var fired []time.Duration
+
+		// extended while pending
+		timer := NewUpdatableTimer(ctx, time.Hour)
+		Go(ctx, func(ctx Context) {
+			_ = Sleep(ctx, 30*time.Minute)
+			timer.Extend(time.Hour)
+		})
+		if err := timer.Get(ctx); err != nil {
+			return nil, err
+		}
+		fired = append(fired, Now(ctx).Sub(start))
+
+		// shortened while pending
+		timer = NewUpdatableTimer(ctx, time.Hour)
+		Go(ctx, func(ctx Context) {
+			_ = Sleep(ctx, 5*time.Minute)
+			timer.SetFireTime(timer.FireTime().Add(-50 * time.Minute))
+		})
+		if err := timer.Get(ctx); err != nil {
+			return nil, err
+		}
+		fired = append(fired, Now(ctx).Sub(start))
+
+		// reset to the past fires right away, later changes have no effect
+		timer = NewUpdatableTimer(ctx, time.Hour)
+		timer.Reset(0)
+		if !timer.IsReady() {
+			return nil, NewCustomError("timer is not fired")
+		}
+		timer.Extend(time.Hour)
+		if err := timer.Get(ctx); err != nil {
+			return nil, err
+		}
+		fired = append(fired, Now(ctx).Sub(start))
+		return fired, nil
+	})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var fired []time.Duration
+	require.NoError(t, env.GetWorkflowResult(&fired))
+	assert.Equal(t, []time.Duration{2 * time.Hour, 2*time.Hour + 10*time.Minute, 2*time.Hour + 10*time.Minute}, fired)
+	env.AssertTrace(t, "timer 1h0m0s", "timer 1h30m0s", "timer 1h0m0s", "timer 5m0s")
+}
+
+func TestUpdatableTimer_Canceled(t *testing.T) {
+	testSuite := &WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.ExecuteWorkflow(func(ctx Context) error {
+		ctx, cancel := WithCancel(ctx)
+		timer := NewUpdatableTimer(ctx, time.Hour)
+		Go(ctx, func(ctx Context) {
+			_ = Sleep(ctx, time.Minute)
+			timer.Extend(time.Hour)
+			cancel()
+		})
+		return timer.Get(ctx)
+	})
+	require.True(t, env.IsWorkflowCompleted())
+	var canceledErr *CanceledError
+	require.ErrorAs(t, env.GetWorkflowError(), &canceledErr)
+}
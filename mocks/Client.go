@@ -181,6 +181,52 @@ func (_m *Client) ExecuteWorkflow(ctx context.Context, options internal.StartWor
 	return r0, r1
 }
 
+// GetClusterInfo provides a mock function with given fields: ctx
+func (_m *Client) GetClusterInfo(ctx context.Context) (*internal.ClusterInfo, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *internal.ClusterInfo
+	if rf, ok := ret.Get(0).(func(context.Context) *internal.ClusterInfo); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.ClusterInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDecisionTaskFailure provides a mock function with given fields: ctx, workflowID, runID
+func (_m *Client) GetDecisionTaskFailure(ctx context.Context, workflowID string, runID string) (*internal.DecisionTaskFailure, error) {
+	ret := _m.Called(ctx, workflowID, runID)
+
+	var r0 *internal.DecisionTaskFailure
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *internal.DecisionTaskFailure); ok {
+		r0 = rf(ctx, workflowID, runID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.DecisionTaskFailure)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, workflowID, runID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetSearchAttributes provides a mock function with given fields: ctx
 func (_m *Client) GetSearchAttributes(ctx context.Context) (*shared.GetSearchAttributesResponse, error) {
 	ret := _m.Called(ctx)
@@ -282,6 +328,29 @@ func (_m *Client) ListClosedWorkflow(ctx context.Context, request *shared.ListCl
 	return r0, r1
 }
 
+// ListPendingActivities provides a mock function with given fields: ctx, workflowID, runID
+func (_m *Client) ListPendingActivities(ctx context.Context, workflowID string, runID string) ([]*shared.PendingActivityInfo, error) {
+	ret := _m.Called(ctx, workflowID, runID)
+
+	var r0 []*shared.PendingActivityInfo
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []*shared.PendingActivityInfo); ok {
+		r0 = rf(ctx, workflowID, runID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*shared.PendingActivityInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, workflowID, runID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListOpenWorkflow provides a mock function with given fields: ctx, request
 func (_m *Client) ListOpenWorkflow(ctx context.Context, request *shared.ListOpenWorkflowExecutionsRequest) (*shared.ListOpenWorkflowExecutionsResponse, error) {
 	ret := _m.Called(ctx, request)
@@ -589,6 +658,46 @@ func (_m *Client) StartWorkflowAsync(ctx context.Context, options internal.Start
 	return r0, r1
 }
 
+// StartWorkflowIfNotRunning provides a mock function with given fields: ctx, options, workflow, args
+func (_m *Client) StartWorkflowIfNotRunning(ctx context.Context, options internal.StartWorkflowOptions, workflow interface{}, args ...interface{}) (*internal.WorkflowExecution, error) {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, options, workflow)
+	_ca = append(_ca, args...)
+	ret := _m.Called(_ca...)
+
+	var r0 *internal.WorkflowExecution
+	if rf, ok := ret.Get(0).(func(context.Context, internal.StartWorkflowOptions, interface{}, ...interface{}) *internal.WorkflowExecution); ok {
+		r0 = rf(ctx, options, workflow, args...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.WorkflowExecution)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, internal.StartWorkflowOptions, interface{}, ...interface{}) error); ok {
+		r1 = rf(ctx, options, workflow, args...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StopWorkflow provides a mock function with given fields: ctx, workflowID, runID, options
+func (_m *Client) StopWorkflow(ctx context.Context, workflowID string, runID string, options internal.StopWorkflowOptions) error {
+	ret := _m.Called(ctx, workflowID, runID, options)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, internal.StopWorkflowOptions) error); ok {
+		r0 = rf(ctx, workflowID, runID, options)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // TerminateWorkflow provides a mock function with given fields: ctx, workflowID, runID, reason, details
 func (_m *Client) TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details []byte) error {
 	ret := _m.Called(ctx, workflowID, runID, reason, details)
@@ -603,6 +712,43 @@ func (_m *Client) TerminateWorkflow(ctx context.Context, workflowID string, runI
 	return r0
 }
 
+// WatchWorkflow provides a mock function with given fields: ctx, workflowID, options
+func (_m *Client) WatchWorkflow(ctx context.Context, workflowID string, options internal.WatchWorkflowOptions) (<-chan internal.WorkflowEvent, error) {
+	ret := _m.Called(ctx, workflowID, options)
+
+	var r0 <-chan internal.WorkflowEvent
+	if rf, ok := ret.Get(0).(func(context.Context, string, internal.WatchWorkflowOptions) <-chan internal.WorkflowEvent); ok {
+		r0 = rf(ctx, workflowID, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan internal.WorkflowEvent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, internal.WatchWorkflowOptions) error); ok {
+		r1 = rf(ctx, workflowID, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OnWorkflowCompletion provides a mock function with given fields: ctx, workflowID, runID, callback
+func (_m *Client) OnWorkflowCompletion(ctx context.Context, workflowID string, runID string, callback internal.WorkflowCompletionCallback) error {
+	ret := _m.Called(ctx, workflowID, runID, callback)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, internal.WorkflowCompletionCallback) error); ok {
+		r0 = rf(ctx, workflowID, runID, callback)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewClient interface {
 	mock.TestingT
 	Cleanup(func())
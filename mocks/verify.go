@@ -28,6 +28,7 @@ import (
 // make sure mocks are in sync with interfaces
 var _ client.Client = (*Client)(nil)
 var _ client.DomainClient = (*DomainClient)(nil)
+var _ client.ScheduleClient = (*ScheduleClient)(nil)
 var _ client.HistoryEventIterator = (*HistoryEventIterator)(nil)
 var _ encoded.Value = (*Value)(nil)
 var _ client.WorkflowRun = (*WorkflowRun)(nil)
@@ -0,0 +1,117 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package statemachine models workflows as explicit state machines: states which start activities when entered,
+// transitions on signals, activity results and per state timeouts, and final states. The machine runs
+// deterministically in the workflow, and registers a query returning its current state and the history of its
+// transitions.
+//
+//	machine, err := statemachine.New(statemachine.Options{
+//		Initial: "awaiting-payment",
+//		States: map[string]statemachine.State{
+//			"awaiting-payment": {
+//				OnSignal: map[string]statemachine.SignalTransition{
+//					"payment": func(ctx workflow.Context, c workflow.Channel) (string, error) {
+//						c.Receive(ctx, &payment)
+//						return "shipping", nil
+//					},
+//				},
+//				Timeout:   24 * time.Hour,
+//				OnTimeout: "canceled",
+//			},
+//			"shipping": {
+//				OnEnter: func(ctx workflow.Context, machine *statemachine.Machine) error {
+//					f := workflow.ExecuteActivity(ctx, ShipActivity, order)
+//					machine.OnFuture(f, "shipped", func(ctx workflow.Context, f workflow.Future) (string, error) {
+//						if err := f.Get(ctx, nil); err != nil {
+//							return "", err
+//						}
+//						return "done", nil
+//					})
+//					return nil
+//				},
+//			},
+//			"done":     {Final: true},
+//			"canceled": {Final: true},
+//		},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	final, err := machine.Run(ctx)
+package statemachine
+
+import (
+	"go.uber.org/cadence/internal"
+	"go.uber.org/cadence/testsuite"
+)
+
+type (
+	// Options defines a state machine, see New.
+	Options = internal.StateMachineOptions
+
+	// State defines a state of a state machine: what it does when entered, and its transitions.
+	State = internal.StateDefinition
+
+	// SignalTransition is called with the signal channel when the signal is received in a state which has a
+	// transition for it. It must receive the signal, and returns the state to enter, or an empty string to stay in
+	// the current state.
+	SignalTransition = internal.SignalTransition
+
+	// FutureTransition is called when a future registered with Machine.OnFuture is ready. It returns the state to
+	// enter, or an empty string to stay in the current state.
+	FutureTransition = internal.FutureTransition
+
+	// Transition is a transition of a state machine.
+	Transition = internal.StateTransition
+
+	// Status is the current state and the past transitions of a state machine, returned by its query.
+	Status = internal.StateMachineStatus
+
+	// Machine runs a state machine in a workflow.
+	Machine = internal.StateMachine
+)
+
+const (
+	// TriggerStart is the trigger of the transition into the initial state.
+	TriggerStart = internal.StateMachineTriggerStart
+	// TriggerTimeout is the trigger of the transitions caused by State.Timeout.
+	TriggerTimeout = internal.StateMachineTriggerTimeout
+	// TriggerSignalPrefix is the prefix of the triggers of the transitions caused by signals, followed by the signal
+	// name.
+	TriggerSignalPrefix = internal.StateMachineTriggerSignalPrefix
+)
+
+// New validates options and returns a state machine, which the workflow runs with Machine.Run.
+func New(options Options) (*Machine, error) {
+	return internal.NewStateMachine(options)
+}
+
+// QueryType returns the query type of the Status of the state machine with the given Options.Name, to query it with
+// client.Client.QueryWorkflow.
+func QueryType(name string) string {
+	return internal.StateMachineQueryType(name)
+}
+
+// QueryStatus queries the Status of the state machine with the given Options.Name from the workflow run by the test
+// environment, e.g. from a callback registered with RegisterDelayedCallback to check the state at a point in time.
+func QueryStatus(env *testsuite.TestWorkflowEnvironment, name string) (Status, error) {
+	return internal.QueryStateMachineStatus(env, name)
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tasktoken gives access to the content of activity task tokens, e.g. to validate and shard tokens of
+// activities completed asynchronously through a queue with client.Client.CompleteActivity.
+//
+// Task tokens are created by the Cadence server, and identify the domain by its ID rather than its name.
+// Tokens should still be passed around as they are: a token is only meaningful to the server that issued it.
+package tasktoken
+
+import (
+	internal "go.uber.org/cadence/internal/common/tasktoken"
+)
+
+// TaskToken is the content of an activity task token.
+type TaskToken = internal.TaskToken
+
+// Parse decodes and validates a task token, e.g. activity.GetInfo(ctx).TaskToken.
+func Parse(token []byte) (*TaskToken, error) {
+	return internal.Parse(token)
+}
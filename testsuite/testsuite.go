@@ -37,6 +37,22 @@ type (
 
 	// MockCallWrapper is a wrapper to mock.Call. It offers the ability to wait on workflow's clock instead of wall clock.
 	MockCallWrapper = internal.MockCallWrapper
+
+	// PropagatedHeader is a header the tested workflow sent along with an activity, a local activity or a child workflow.
+	PropagatedHeader = internal.PropagatedHeader
+
+	// PropagatedHeaderKind is the kind of call a PropagatedHeader was sent with.
+	PropagatedHeaderKind = internal.PropagatedHeaderKind
+
+	// TestBreakpoint pauses the tested workflow so that the test can inspect it before resuming it.
+	TestBreakpoint = internal.TestBreakpoint
+)
+
+// Kinds of PropagatedHeader.
+const (
+	PropagatedHeaderActivity      = internal.PropagatedHeaderActivity
+	PropagatedHeaderLocalActivity = internal.PropagatedHeaderLocalActivity
+	PropagatedHeaderChildWorkflow = internal.PropagatedHeaderChildWorkflow
 )
 
 // ErrMockStartChildWorkflowFailed is special error used to indicate the mocked child workflow should fail to start.
@@ -25,6 +25,7 @@ package worker
 import (
 	"context"
 	"io"
+	"net/http"
 
 	"go.uber.org/zap"
 
@@ -44,11 +45,44 @@ type (
 
 		// Start starts the worker in a non-blocking fashion
 		Start() error
-		// Run is a blocking start and cleans up resources when killed
-		// returns error only if it fails to start the worker
+		// Run is a blocking start and cleans up resources when the process receives SIGINT or SIGTERM.
+		// It returns the same errors as RunContext.
 		Run() error
+		// RunContext starts the worker and blocks until ctx is done, then stops the worker and returns nil:
+		//  ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		//  defer cancel()
+		//  if err := w.RunContext(ctx); err != nil {
+		//      logger.Fatal("worker failed", zap.Error(err))
+		//  }
+		// It returns early with a *RegistrationError if nothing is registered with the worker, a *PollerStartError
+		// if the pollers can not be started, a *FatalServiceError if a poller receives an error from the service
+		// which can not be recovered from by retrying, or the error returned by a failing OnStart hook.
+		RunContext(ctx context.Context) error
 		// Stop cleans up any resources opened by worker
 		Stop()
+		// OnStart registers a hook which is called before the worker starts polling, e.g. to initialize resources
+		// used by activities. Hooks are called in registration order and the worker does not start if any of them
+		// fails. For RunContext the hooks receive its context, for Start a background context.
+		OnStart(hook Hook)
+		// OnStop registers a hook which is called by Stop after the worker stopped polling, e.g. to release
+		// resources used by activities. Hooks are called in reverse registration order, and only if all OnStart
+		// hooks succeeded. Errors returned by OnStop hooks are logged.
+		OnStop(hook Hook)
+		// Validate replays up to sampleSize of the most recent executions of each registered workflow type against
+		// the registered workflow code, and returns a *ValidationError if any of them is nondeterministic.
+		// Call it before Start to refuse to start, or only log a warning, when a deployment breaks open workflows:
+		//  if err := w.Validate(ctx, 10); err != nil {
+		//      logger.Fatal("worker is not compatible with recent workflow histories", zap.Error(err))
+		//  }
+		// Validate requires advanced visibility to find recent executions.
+		Validate(ctx context.Context, sampleSize int) error
+		// CheckReadiness returns nil if the worker is ready to process tasks: it is started and its pollers are
+		// running, and the Cadence frontend is reachable and confirms that the worker's domain is registered.
+		CheckReadiness(ctx context.Context) error
+		// CheckLiveness returns nil unless the worker is deadlocked: a poll is stuck for much longer than the long
+		// poll timeout, or a decision task has been processing for minutes. Long running activities do not affect
+		// liveness. Use NewHealthHandler to serve the checks to Kubernetes probes.
+		CheckLiveness(ctx context.Context) error
 	}
 
 	// Registry exposes registration functions to consumers.
@@ -221,6 +255,8 @@ type (
 	ShadowOptions = internal.ShadowOptions
 	// ShadowMode is an enum for configuring if shadowing should continue after all workflows matches the WorkflowQuery have been replayed.
 	ShadowMode = internal.ShadowMode
+	// ShadowResult summarizes a run of the WorkflowShadower, see ShadowOptions.OnComplete.
+	ShadowResult = internal.ShadowResult
 	// TimeFilter represents a time range through the min and max timestamp
 	TimeFilter = internal.TimeFilter
 	// ShadowExitCondition configures when the workflow shadower should exit.
@@ -230,6 +266,93 @@ type (
 	// ReplayOptions is used to configure the replay decision task worker.
 	ReplayOptions = internal.ReplayOptions
 
+	// ValidationError is returned by Worker.Validate when recent workflow histories fail to replay.
+	ValidationError = internal.WorkerValidationError
+
+	// ValidationFailure describes a workflow execution that failed to replay during Worker.Validate.
+	ValidationFailure = internal.WorkerValidationFailure
+
+	// Hook is a function registered with Worker.OnStart or Worker.OnStop.
+	Hook = internal.WorkerHook
+
+	// RegistrationError is returned by Worker.RunContext when the worker has nothing to run,
+	// e.g. because no workflows or activities were registered with it.
+	RegistrationError = internal.WorkerRegistrationError
+
+	// PollerStartError is returned by Worker.RunContext when the worker failed to start its pollers,
+	// e.g. because its domain does not exist.
+	PollerStartError = internal.WorkerPollerStartError
+
+	// FatalServiceError is returned by Worker.RunContext when a poller received an error from the Cadence
+	// service which can not be recovered from by retrying, e.g. because the client version is not supported.
+	FatalServiceError = internal.WorkerFatalServiceError
+
+	// ServiceCapability is a feature of the Cadence service which can be required with
+	// Options.RequiredServiceCapabilities.
+	ServiceCapability = internal.ServiceCapability
+
+	// ServiceCapabilityError is returned by Worker.Start and Worker.Run when the Cadence service does not support
+	// one of Options.RequiredServiceCapabilities.
+	ServiceCapabilityError = internal.ServiceCapabilityError
+
+	// PayloadRedactor redacts payloads, and values which may contain them, before the worker writes them to its logs
+	// or returns them in query errors. See Options.PayloadRedactor.
+	PayloadRedactor = internal.PayloadRedactor
+
+	// DiagnosticsSamplingOptions select the workflow executions for which the worker emits verbose diagnostics.
+	// See Options.DiagnosticsSampling.
+	DiagnosticsSamplingOptions = internal.DiagnosticsSamplingOptions
+
+	// HistoryEventTap receives the new history events of the decision tasks processed by the worker, see
+	// Options.HistoryEventTap. It is called outside of the workflow code and must be fast. Events are delivered at
+	// least once, so consumers should deduplicate them by event ID.
+	HistoryEventTap = internal.HistoryEventTap
+
+	// RateLimitStore coordinates Options.WorkerActivitiesPerSecond across the workers of a task list, see
+	// Options.ActivityRateLimitStore. Take returns 0 once it took a permit of the limit of key, or how long to wait
+	// before trying again.
+	RateLimitStore = internal.RateLimitStore
+
+	// WorkerRegistry stores the registrations workers publish, i.e. their task list and the workflow and activity
+	// types they serve. See Options.WorkerRegistry and client.FindWorkflowTaskLists.
+	WorkerRegistry = internal.WorkerRegistry
+
+	// WorkerRegistration describes a running worker published to a WorkerRegistry.
+	WorkerRegistration = internal.WorkerRegistration
+
+	// PriorityTaskLists are the activity task lists of priority tiers the worker polls with weighted pollers, see
+	// Options.ActivityPriorityTaskLists and workflow.WithActivityPriority.
+	PriorityTaskLists = internal.PriorityTaskLists
+
+	// PriorityTier is a priority tier of PriorityTaskLists.
+	PriorityTier = internal.PriorityTier
+
+	// NamingPolicies constrain the query types registered by workflows, see Options.NamingPolicies.
+	NamingPolicies = internal.NamingPolicies
+
+	// HistoryCorpusOptions configures DownloadHistoryCorpus.
+	HistoryCorpusOptions = internal.HistoryCorpusOptions
+
+	// HistoryCorpusEntry describes a single workflow history stored by DownloadHistoryCorpus.
+	HistoryCorpusEntry = internal.HistoryCorpusEntry
+
+	// HistoryExportOptions configures ExportHistories.
+	HistoryExportOptions = internal.HistoryExportOptions
+
+	// HistoryExportRecord is the flattened description of a closed workflow execution written by ExportHistories.
+	HistoryExportRecord = internal.HistoryExportRecord
+
+	// HistoryExportActivityStats aggregates the activities of a single type scheduled by an exported execution.
+	HistoryExportActivityStats = internal.HistoryExportActivityStats
+
+	// HistoryExportWriter writes the records of ExportHistories to a sink. Implement it to export to other formats,
+	// e.g. Parquet.
+	HistoryExportWriter = internal.HistoryExportWriter
+
+	// NonDeterministicError is the error returned by WorkflowReplayer when the replayed workflow code does not match
+	// its history. Its fields describe the mismatching history event and replay decision.
+	NonDeterministicError = internal.NonDeterministicError
+
 	// NonDeterministicWorkflowPolicy is an enum for configuring how client's decision task handler deals with
 	// mismatched history events (presumably arising from non-deterministic workflow definitions).
 	NonDeterministicWorkflowPolicy = internal.NonDeterministicWorkflowPolicy
@@ -269,6 +392,37 @@ const (
 	ShadowModeContinuous = internal.ShadowModeContinuous
 )
 
+const (
+	// ReplayFailureTypeNonDeterministic is the failure type of a replay which detected a nondeterministic change
+	// in the workflow definition.
+	ReplayFailureTypeNonDeterministic = internal.ReplayFailureTypeNonDeterministic
+	// ReplayFailureTypeWorkflowTypeNotRegistered is the failure type of a replay whose workflow type is not
+	// registered with the shadower.
+	ReplayFailureTypeWorkflowTypeNotRegistered = internal.ReplayFailureTypeWorkflowTypeNotRegistered
+)
+
+const (
+	// ServiceCapabilityAdvancedVisibility requires advanced visibility, which is used by ListWorkflow, ScanWorkflow
+	// and CountWorkflow.
+	ServiceCapabilityAdvancedVisibility = internal.ServiceCapabilityAdvancedVisibility
+	// ServiceCapabilityAsyncAPIs requires the asynchronous StartWorkflowExecutionAsync and
+	// SignalWithStartWorkflowExecutionAsync APIs.
+	ServiceCapabilityAsyncAPIs = internal.ServiceCapabilityAsyncAPIs
+)
+
+// NewInMemoryWorkerRegistry returns a WorkerRegistry storing registrations in memory, for the workers of the current
+// process. Discovering task lists across services requires a WorkerRegistry backed by a shared store.
+func NewInMemoryWorkerRegistry() WorkerRegistry {
+	return internal.NewInMemoryWorkerRegistry()
+}
+
+// NewTruncatingPayloadRedactor returns a PayloadRedactor keeping at most the first maxLength bytes of a payload,
+// followed by the length and a SHA-256 digest of the whole payload, so equal payloads can still be correlated
+// across log entries. A maxLength of 0 keeps nothing of the payload.
+func NewTruncatingPayloadRedactor(maxLength int) PayloadRedactor {
+	return internal.NewTruncatingPayloadRedactor(maxLength)
+}
+
 // Deprecated: use NewV2 instead since this implementation will panic on error
 func New(
 	service workflowserviceclient.Interface,
@@ -300,6 +454,15 @@ func NewV2(
 	return internal.NewWorker(service, domain, taskList, options)
 }
 
+// NewHealthHandler returns an http.Handler which responds with 200 if check succeeds, and with 503 and the error
+// otherwise. It can be used to serve the worker health checks to Kubernetes probes:
+//
+//	mux.Handle("/ready", worker.NewHealthHandler(w.CheckReadiness))
+//	mux.Handle("/live", worker.NewHealthHandler(w.CheckLiveness))
+func NewHealthHandler(check func(ctx context.Context) error) http.Handler {
+	return internal.NewHealthHandler(check)
+}
+
 // NewWorkflowReplayer creates a WorkflowReplayer instance.
 func NewWorkflowReplayer() WorkflowReplayer {
 	return internal.NewWorkflowReplayer()
@@ -313,6 +476,47 @@ func NewWorkflowReplayerWithOptions(
 	return internal.NewWorkflowReplayerWithOptions(options)
 }
 
+// DownloadHistoryCorpus downloads the histories of the executions matching options.Query and stores them in dir,
+// building a golden corpus for replay tests. Each history is written as a JSON array of events to
+// dir/<workflow type>/<workflow ID>_<run ID>.json, which can be replayed with
+// WorkflowReplayer.ReplayWorkflowHistoryFromJSONFile. Use options.Redactor to anonymize the events before they
+// are stored, e.g. with RedactHistoryPayloads.
+func DownloadHistoryCorpus(
+	ctx context.Context,
+	service workflowserviceclient.Interface,
+	domain string,
+	dir string,
+	options HistoryCorpusOptions,
+) ([]HistoryCorpusEntry, error) {
+	return internal.DownloadHistoryCorpus(ctx, service, domain, dir, options)
+}
+
+// RedactHistoryPayloads strips the user payloads (inputs, results, failure details, signal data, memos and search
+// attributes) of a history event. Marker details are kept, as they are required for a faithful replay.
+// It can be used as HistoryCorpusOptions.Redactor.
+func RedactHistoryPayloads(event *shared.HistoryEvent) {
+	internal.RedactHistoryPayloads(event)
+}
+
+// ExportHistories streams the histories of the closed executions matching options.Query and writes a flattened
+// HistoryExportRecord for each of them to writer: execution metadata, per activity type statistics and the custom
+// fields returned by options.Extractor. It returns the number of written records.
+func ExportHistories(
+	ctx context.Context,
+	service workflowserviceclient.Interface,
+	domain string,
+	writer HistoryExportWriter,
+	options HistoryExportOptions,
+) (int, error) {
+	return internal.ExportHistories(ctx, service, domain, writer, options)
+}
+
+// NewJSONLinesHistoryExportWriter returns a HistoryExportWriter which writes every record to w as a single line of
+// JSON.
+func NewJSONLinesHistoryExportWriter(w io.Writer) HistoryExportWriter {
+	return internal.NewJSONLinesHistoryExportWriter(w)
+}
+
 // NewWorkflowShadower creates a WorkflowShadower instance.
 func NewWorkflowShadower(
 	service workflowserviceclient.Interface,
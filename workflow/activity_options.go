@@ -32,6 +32,9 @@ type ActivityOptions = internal.ActivityOptions
 // LocalActivityOptions doc
 type LocalActivityOptions = internal.LocalActivityOptions
 
+// ActivityOptionsBuilder composes ActivityOptions from base defaults and per-call overrides.
+type ActivityOptionsBuilder = internal.ActivityOptionsBuilder
+
 // RetryPolicy specify how to retry activity if error happens.
 type RetryPolicy = internal.RetryPolicy
 
@@ -40,10 +43,30 @@ type RetryPolicy = internal.RetryPolicy
 // it will be overwritten by the passed in value as a whole.
 // So specify all the values in the options as necessary, as values
 // in the existing context options will not be carried over.
+// Use WithActivityOptionOverrides to only change some of the values.
 func WithActivityOptions(ctx Context, options ActivityOptions) Context {
 	return internal.WithActivityOptions(ctx, options)
 }
 
+// WithActivityOptionOverrides makes a copy of the context and adds the
+// fields set in overrides to its activity options. Fields that are not set
+// in overrides, like an unset HeartbeatTimeout, keep the value already stored
+// in the context. An empty activity options will be created if it does not
+// exist in the original context.
+func WithActivityOptionOverrides(ctx Context, overrides ActivityOptions) Context {
+	return internal.WithActivityOptionOverrides(ctx, overrides)
+}
+
+// NewActivityOptionsBuilder creates a builder starting from a copy of the base options.
+// Fields that are not set on the builder keep the value of the base options:
+//
+//	ao := workflow.NewActivityOptionsBuilder(defaultOptions).
+//		StartToCloseTimeout(time.Hour).
+//		Build()
+func NewActivityOptionsBuilder(base ActivityOptions) *ActivityOptionsBuilder {
+	return internal.NewActivityOptionsBuilder(base)
+}
+
 // WithLocalActivityOptions makes a copy of the context and adds the
 // passed in options to the context. If a local activity options exists,
 // it will be overwritten by the passed in value.
@@ -51,6 +74,12 @@ func WithLocalActivityOptions(ctx Context, options LocalActivityOptions) Context
 	return internal.WithLocalActivityOptions(ctx, options)
 }
 
+// WithActivityID makes a copy of the current context and update the activityID field in its activity options. The ID
+// must not be used by another pending activity of the workflow, or the activity fails with *IDCollisionError.
+func WithActivityID(ctx Context, activityID string) Context {
+	return internal.WithActivityID(ctx, activityID)
+}
+
 // WithTaskList makes a copy of the current context and update the taskList
 // field in its activity options. An empty activity options will be created
 // if it does not exist in the original context.
@@ -58,6 +87,49 @@ func WithTaskList(ctx Context, name string) Context {
 	return internal.WithTaskList(ctx, name)
 }
 
+// PriorityTaskLists map activity priorities to the task lists of priority tiers, see WithActivityPriority.
+type PriorityTaskLists = internal.PriorityTaskLists
+
+// PriorityTier is a priority tier of PriorityTaskLists.
+type PriorityTier = internal.PriorityTier
+
+// WithActivityPriority makes a copy of the current context whose activities are scheduled on the task list of the
+// given priority, 0 being the highest. Workers poll the tiers with worker.Options.ActivityPriorityTaskLists.
+// It returns an error if tiers has no tiers, or an invalid one.
+func WithActivityPriority(ctx Context, tiers PriorityTaskLists, priority int) (Context, error) {
+	return internal.WithActivityPriority(ctx, tiers, priority)
+}
+
+// TaskListRouterOptions configure a TaskListRouter.
+type TaskListRouterOptions = internal.TaskListRouterOptions
+
+// TaskListRouter shards activities across task lists by a key with consistent hashing, for the locality of the
+// activities of a key, see WithActivityRoutingKey. Workers poll the task lists returned by AssignedTaskLists with
+// worker.Options.AdditionalActivityTaskLists.
+type TaskListRouter = internal.TaskListRouter
+
+// NewTaskListRouter returns a TaskListRouter over the task lists of the options.
+func NewTaskListRouter(options TaskListRouterOptions) (*TaskListRouter, error) {
+	return internal.NewTaskListRouter(options)
+}
+
+// WithActivityRoutingKey makes a copy of the current context whose activities are scheduled on the task list of the
+// key in router.
+func WithActivityRoutingKey(ctx Context, router *TaskListRouter, key string) Context {
+	return internal.WithActivityRoutingKey(ctx, router, key)
+}
+
+// ActivityHedgingOptions configure the hedging of activities, see WithActivityHedging.
+type ActivityHedgingOptions = internal.ActivityHedgingOptions
+
+// WithActivityHedging makes a copy of the current context whose activities are hedged: when an activity has not
+// completed after options.Delay, a backup attempt of it is scheduled, optionally on another task list. The attempt
+// completing first provides the result of the activity, and the other one is canceled. Only use it for idempotent
+// activities, as both attempts may execute.
+func WithActivityHedging(ctx Context, options ActivityHedgingOptions) Context {
+	return internal.WithActivityHedging(ctx, options)
+}
+
 // GetActivityTaskList returns tasklist in the Context's current ActivityOptions,
 // or workflow.GetInfo(ctx).TaskListName if not set or empty
 func GetActivityTaskList(ctx Context) string {
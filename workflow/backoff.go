@@ -0,0 +1,54 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"time"
+
+	"go.uber.org/cadence/internal"
+)
+
+// BackoffPolicy describes the exponential backoff computed by Backoff and BackoffDuration.
+type BackoffPolicy = internal.BackoffPolicy
+
+// Backoff pauses the current workflow for the backoff interval of the attempt, starting from 0, of the policy. Use
+// it in retry loops which are not covered by the retry policies of activities and child workflows:
+//
+//	for attempt := 0; ; attempt++ {
+//		if err := workflow.ExecuteChildWorkflow(ctx, childFn).Get(ctx, nil); err == nil {
+//			break
+//		}
+//		if err := workflow.Backoff(ctx, policy, attempt); err != nil {
+//			return err
+//		}
+//	}
+//
+// The jitter is derived from a seed recorded once per workflow with SideEffect, so the workflow sleeps for the same
+// durations when it is replayed.
+// Backoff() returns nil if the interval is passed, or it returns *CanceledError if the ctx is canceled.
+func Backoff(ctx Context, policy BackoffPolicy, attempt int) error {
+	return internal.Backoff(ctx, policy, attempt)
+}
+
+// BackoffDuration returns the backoff interval of the attempt, starting from 0, of the policy, without sleeping.
+func BackoffDuration(ctx Context, policy BackoffPolicy, attempt int) (time.Duration, error) {
+	return internal.BackoffDuration(ctx, policy, attempt)
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"time"
+
+	"go.uber.org/cadence/internal"
+)
+
+// CallOption overrides an option of a single ExecuteActivity or ExecuteChildWorkflow call. Call options are passed
+// among the arguments of the call, and are not passed to the activity or child workflow:
+//
+//	f := workflow.ExecuteActivity(ctx, SendEmail, email, workflow.CallTaskList("emails"), workflow.CallActivityID(email.ID))
+type CallOption = internal.CallOption
+
+// CallTaskList sets the task list of the activity or child workflow.
+func CallTaskList(name string) CallOption {
+	return internal.CallTaskList(name)
+}
+
+// CallRetryPolicy sets the retry policy of the activity or child workflow.
+func CallRetryPolicy(retryPolicy RetryPolicy) CallOption {
+	return internal.CallRetryPolicy(retryPolicy)
+}
+
+// CallStartToCloseTimeout sets the StartToCloseTimeout of the activity, or the ExecutionStartToCloseTimeout of the
+// child workflow.
+func CallStartToCloseTimeout(d time.Duration) CallOption {
+	return internal.CallStartToCloseTimeout(d)
+}
+
+// CallScheduleToCloseTimeout sets the ScheduleToCloseTimeout of the activity. It does not apply to child workflows.
+func CallScheduleToCloseTimeout(d time.Duration) CallOption {
+	return internal.CallScheduleToCloseTimeout(d)
+}
+
+// CallActivityID sets the ID of the activity. It does not apply to child workflows.
+func CallActivityID(activityID string) CallOption {
+	return internal.CallActivityID(activityID)
+}
+
+// CallWorkflowID sets the workflow ID of the child workflow. It does not apply to activities.
+func CallWorkflowID(workflowID string) CallOption {
+	return internal.CallWorkflowID(workflowID)
+}
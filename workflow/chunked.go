@@ -0,0 +1,67 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"context"
+
+	"go.uber.org/cadence/internal"
+)
+
+// ChunkedOptions configure ProcessInChunks.
+type ChunkedOptions = internal.ChunkedOptions
+
+// ProcessInChunks calls the pure function fn with consecutive chunks of items in local activities, and returns the
+// concatenation of their results. The chunk sizes adapt to the processing time of the previous chunk, to take about
+// options.TargetChunkDuration, so CPU heavy computations over large datasets do not run into decision task timeouts:
+//
+//	scores, err := workflow.ProcessInChunks(ctx, documents, func(ctx context.Context, chunk []Document) ([]float64, error) {
+//		return score(chunk), nil
+//	}, workflow.ChunkedOptions{})
+//
+// The chunk sizes are recorded with the results of the local activities, so replays process the same chunks.
+//
+// Every chunk adds a local activity marker holding its results to the history of the workflow, which is limited in
+// size and length by the server. Workflows processing more items than their history can hold should process a bounded
+// slice of them per run, and continue as new with the rest:
+//
+//	batch := documents
+//	if len(batch) > 100000 {
+//		batch = documents[:100000]
+//	}
+//	scores, err := workflow.ProcessInChunks(ctx, batch, score, workflow.ChunkedOptions{})
+//	if err != nil {
+//		return err
+//	}
+//	if err := save(ctx, scores); err != nil {
+//		return err
+//	}
+//	if len(batch) < len(documents) {
+//		return workflow.NewContinueAsNewError(ctx, ScoreWorkflow, documents[len(batch):])
+//	}
+func ProcessInChunks[T, R any](
+	ctx Context,
+	items []T,
+	fn func(ctx context.Context, chunk []T) ([]R, error),
+	options ChunkedOptions,
+) ([]R, error) {
+	return internal.ProcessInChunks(ctx, items, fn, options)
+}
@@ -35,6 +35,39 @@ type Context = internal.Context
 // ErrCanceled is the error returned by Context.Err when the context is canceled.
 var ErrCanceled = internal.ErrCanceled
 
+// CancellationDetails describes why a workflow or an activity was canceled.
+type CancellationDetails = internal.CancellationDetails
+
+// CancellationSource is what requested the cancellation of a workflow or an activity.
+type CancellationSource = internal.CancellationSource
+
+const (
+	// CancellationSourceUnknown is used when the source of the cancellation could not be determined.
+	CancellationSourceUnknown = internal.CancellationSourceUnknown
+	// CancellationSourceClient means the workflow cancellation was requested through Client.CancelWorkflow.
+	CancellationSourceClient = internal.CancellationSourceClient
+	// CancellationSourceWorkflow means the cancellation was requested by a workflow: the workflow cancellation by
+	// another workflow with RequestCancelExternalWorkflow or by canceling the context of a child workflow, the
+	// activity cancellation by the workflow executing the activity.
+	CancellationSourceWorkflow = internal.CancellationSourceWorkflow
+	// CancellationSourceParentClosePolicy means the workflow cancellation was requested because its parent closed
+	// with ParentClosePolicyRequestCancel.
+	CancellationSourceParentClosePolicy = internal.CancellationSourceParentClosePolicy
+	// CancellationSourceWorkflowClosed means the activity was canceled because its workflow was closed or could not
+	// be found.
+	CancellationSourceWorkflowClosed = internal.CancellationSourceWorkflowClosed
+)
+
+// GetCancellationDetails returns why the workflow was canceled, or nil if its cancellation was not requested.
+// Call it once ctx.Done() is ready to branch the compensation logic on the source of the cancellation:
+//
+//	if details := workflow.GetCancellationDetails(ctx); details != nil && details.Source == workflow.CancellationSourceClient {
+//		logger.Info("canceled by operator", zap.String("Identity", details.Identity), zap.String("Cause", details.Cause))
+//	}
+func GetCancellationDetails(ctx Context) *CancellationDetails {
+	return internal.GetCancellationDetails(ctx)
+}
+
 // ErrDeadlineExceeded is the error returned by Context.Err when the context's
 // deadline passes.
 var ErrDeadlineExceeded = internal.ErrDeadlineExceeded
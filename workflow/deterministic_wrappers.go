@@ -22,6 +22,7 @@
 package workflow
 
 import (
+	"cmp"
 	"time"
 
 	"go.uber.org/cadence/internal"
@@ -33,6 +34,10 @@ type (
 	// Use workflow.NewChannel(ctx) method to create Channel instance.
 	Channel = internal.Channel
 
+	// BufferedChannel is a Channel whose buffer capacity can be changed at runtime.
+	// Use workflow.NewResizableBufferedChannel(ctx, size) method to create a BufferedChannel instance.
+	BufferedChannel = internal.BufferedChannel
+
 	// Selector must be used instead of native go select by workflow code.
 	// Use workflow.NewSelector(ctx) method to create a Selector instance.
 	Selector = internal.Selector
@@ -47,6 +52,22 @@ type (
 	// WaitGroup is used to wait for a collection of
 	// coroutines to finish
 	WaitGroup = internal.WaitGroup
+
+	// Semaphore is a weighted semaphore bounding the concurrency of the coroutines of a workflow.
+	// Use workflow.NewSemaphore(ctx, size) method to create a Semaphore instance.
+	Semaphore = internal.Semaphore
+
+	// ErrGroup runs a group of coroutines and collects their errors, like golang.org/x/sync/errgroup.
+	// Use workflow.NewErrGroup(ctx) method to create an ErrGroup instance.
+	ErrGroup = internal.ErrGroup
+
+	// BatchFuture is a Future of a batch of futures which is ready when all of them are ready.
+	// Use workflow.NewBatchFuture(ctx, concurrency, factories) method to create a BatchFuture instance.
+	BatchFuture = internal.BatchFuture
+
+	// RateLimiter paces workflow code with a token bucket based on workflow time.
+	// Use workflow.NewRateLimiter(ctx, perSecond, burst) method to create a RateLimiter instance.
+	RateLimiter = internal.RateLimiter
 )
 
 // Await blocks the calling thread until condition() returns true.
@@ -62,6 +83,19 @@ func Await(ctx Context, condition func() bool) error {
 	return internal.Await(ctx, condition)
 }
 
+// AwaitWithTimeout blocks the calling thread until condition() returns true or the timeout expires.
+// Do not mutate values or trigger side effects inside condition.
+// Returns true if the condition was met, false if the timeout expired first.
+// Returns CanceledError if the ctx is canceled, and an error if the timeout is not positive.
+// The following code is going to block until the captured approved variable is set, for up to an hour.
+//
+//	ok, err := workflow.AwaitWithTimeout(ctx, time.Hour, func() bool {
+//	  return approved
+//	})
+func AwaitWithTimeout(ctx Context, timeout time.Duration, condition func() bool) (bool, error) {
+	return internal.AwaitWithTimeout(ctx, timeout, condition)
+}
+
 // NewChannel create new Channel instance
 func NewChannel(ctx Context) Channel {
 	return internal.NewChannel(ctx)
@@ -84,6 +118,17 @@ func NewNamedBufferedChannel(ctx Context, name string, size int) Channel {
 	return internal.NewNamedBufferedChannel(ctx, name, size)
 }
 
+// NewResizableBufferedChannel create new BufferedChannel instance, whose buffer can be resized.
+func NewResizableBufferedChannel(ctx Context, size int) BufferedChannel {
+	return internal.NewResizableBufferedChannel(ctx, size)
+}
+
+// NewNamedResizableBufferedChannel create new BufferedChannel instance, whose buffer can be resized, with a given
+// human readable name. Name appears in stack traces that are blocked on this Channel.
+func NewNamedResizableBufferedChannel(ctx Context, name string, size int) BufferedChannel {
+	return internal.NewNamedResizableBufferedChannel(ctx, name, size)
+}
+
 // NewSelector creates a new Selector instance.
 func NewSelector(ctx Context) Selector {
 	return internal.NewSelector(ctx)
@@ -100,6 +145,65 @@ func NewWaitGroup(ctx Context) WaitGroup {
 	return internal.NewWaitGroup(ctx)
 }
 
+// NewSemaphore creates a new Semaphore instance with size permits. For example, to run at most 10 child workflows at
+// a time:
+//
+//	sem := workflow.NewSemaphore(ctx, 10)
+//	for _, item := range items {
+//		if err := sem.Acquire(ctx, 1); err != nil {
+//			return err
+//		}
+//		f := workflow.ExecuteChildWorkflow(ctx, ProcessItem, item)
+//		workflow.Go(ctx, func(ctx workflow.Context) {
+//			defer sem.Release(1)
+//			_ = f.Get(ctx, nil)
+//		})
+//	}
+func NewSemaphore(ctx Context, size int64) Semaphore {
+	return internal.NewSemaphore(ctx, size)
+}
+
+// NewErrGroup creates a new ErrGroup instance, and the context of the group derived from ctx, which is canceled when
+// a coroutine of the group returns an error or when Wait returns. For example, to run child workflows and stop at
+// the first failure:
+//
+//	g, _ := workflow.NewErrGroup(ctx)
+//	for _, item := range items {
+//		item := item
+//		g.Go(func(ctx workflow.Context) error {
+//			return workflow.ExecuteChildWorkflow(ctx, ProcessItem, item).Get(ctx, nil)
+//		})
+//	}
+//	if err := g.Wait(ctx); err != nil {
+//		return err
+//	}
+func NewErrGroup(ctx Context) (ErrGroup, Context) {
+	return internal.NewErrGroup(ctx)
+}
+
+// NewBatchFuture calls the factories in order, running at most concurrency of the futures they create at the same
+// time, and returns a BatchFuture which is ready when all of them are ready. For example, to run at most 10 activities
+// at a time and collect their results:
+//
+//	var factories []func(ctx workflow.Context) workflow.Future
+//	for _, item := range items {
+//		item := item
+//		factories = append(factories, func(ctx workflow.Context) workflow.Future {
+//			return workflow.ExecuteActivity(ctx, ProcessItem, item)
+//		})
+//	}
+//	batch, err := workflow.NewBatchFuture(ctx, 10, factories)
+//	if err != nil {
+//		return err
+//	}
+//	var results []string
+//	err = batch.Get(ctx, &results)
+//
+// Get combines the errors of all the futures, batch.GetFutures() gives access to the result and error of every item.
+func NewBatchFuture(ctx Context, concurrency int, factories []func(ctx Context) Future) (BatchFuture, error) {
+	return internal.NewBatchFuture(ctx, concurrency, factories)
+}
+
 // Go creates a new coroutine. It has similar semantic to goroutine in a context of the workflow.
 func Go(ctx Context, f func(ctx Context)) {
 	internal.Go(ctx, f)
@@ -117,6 +221,12 @@ func NewFuture(ctx Context) (Future, Settable) {
 	return internal.NewFuture(ctx)
 }
 
+// NewNamedFuture creates a new future with a given human readable name as well as associated Settable that is used to
+// set its value. Name appears in stack traces that are blocked on this future.
+func NewNamedFuture(ctx Context, name string) (Future, Settable) {
+	return internal.NewNamedFuture(ctx, name)
+}
+
 // Now returns the time that the current decision task was started.
 // Workflows need to base any behavior off this time, rather than `time.Now()`, because `time.Now()` will change during
 // future replays.
@@ -165,3 +275,61 @@ func NewTimer(ctx Context, d time.Duration) Future {
 func Sleep(ctx Context, d time.Duration) (err error) {
 	return internal.Sleep(ctx, d)
 }
+
+// SleepWithJitter pauses the current workflow for at least the duration d plus a random jitter in [0, maxJitter).
+// The jitter is recorded with SideEffect, so the workflow sleeps for the same duration when it is replayed, which
+// makes it safe to use for spreading out the timers of polling workflows and fan-outs.
+// SleepWithJitter() returns nil if the duration is passed, or it returns *CanceledError if the ctx is canceled.
+// As the timer resolution is in seconds, a maxJitter of less than a second has no effect.
+func SleepWithJitter(ctx Context, d, maxJitter time.Duration) error {
+	return internal.SleepWithJitter(ctx, d, maxJitter)
+}
+
+// NewRateLimiter returns a RateLimiter which allows events up to perSecond per second, and bursts of at most burst
+// events. Its token bucket is refilled based on workflow.Now(ctx), and it waits with workflow timers, so it paces the
+// workflow the same way when it is replayed. For example, to start at most 10 child workflows per minute:
+//
+//	limiter := workflow.NewRateLimiter(ctx, 10.0/60, 10)
+//	for _, input := range inputs {
+//		if err := limiter.Wait(ctx); err != nil {
+//			return err
+//		}
+//		futures = append(futures, workflow.ExecuteChildWorkflow(ctx, childWorkflow, input))
+//	}
+func NewRateLimiter(ctx Context, perSecond float64, burst int) RateLimiter {
+	return internal.NewRateLimiter(ctx, perSecond, burst)
+}
+
+// SortedKeys returns the keys of the map in ascending order. Ranging over a map visits the keys in a random order, so
+// range over the sorted keys when the loop schedules activities, child workflows or timers:
+//
+//	for _, region := range workflow.SortedKeys(regions) {
+//		futures = append(futures, workflow.ExecuteActivity(ctx, deploy, region, regions[region]))
+//	}
+func SortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	return internal.SortedKeys(m)
+}
+
+// SortedKeysFunc returns the keys of the map ordered by the comparison function, which must only return zero for
+// equal keys.
+func SortedKeysFunc[K comparable, V any](m map[K]V, compare func(a, b K) int) []K {
+	return internal.SortedKeysFunc(m, compare)
+}
+
+// StableSortFunc sorts the slice by the comparison function, keeping the original order of elements comparing as
+// equal.
+func StableSortFunc[T any](s []T, compare func(a, b T) int) {
+	internal.StableSortFunc(s, compare)
+}
+
+// StableShuffle shuffles the slice in place in an order that only depends on the seed and on the length of the slice,
+// so replaying the workflow produces the same order. Use a seed that is stable across replays, such as the run ID.
+func StableShuffle[T any](seed string, s []T) {
+	internal.StableShuffle(seed, s)
+}
+
+// StableHash returns the 64-bit FNV-1a hash of the data, which does not depend on the process and can be used to
+// partition or route work deterministically.
+func StableHash(data []byte) uint64 {
+	return internal.StableHash(data)
+}
@@ -115,7 +115,7 @@ A simplistic way to think about these requirements is that the workflow code:
   - Should do all logging via the logger provided by the Cadence client
     library (i.e. workflow.GetLogger())
   - Should not iterate over maps using range as order of map iteration is
-    randomized
+    randomized, range over workflow.SortedKeys(m) instead
 
 Now that we laid out the ground rules we can take a look at how to implement some common patterns inside workflows.
 
@@ -193,6 +193,7 @@ As an incomplete summary, these actions will all fail immediately, and the assoc
 a Future) will be a workflow.CanceledError:
 
   - workflow.Await
+  - workflow.AwaitWithTimeout
   - workflow.Sleep
   - workflow.Timer
 
@@ -367,6 +368,20 @@ pattern, extra care needs to be taken to ensure the child workflow is started be
 		return err
 	}
 
+workflow.StartAbandonedChildWorkflow() wraps this pattern. It forces client.ParentClosePolicyAbandon, waits for the child
+to start and returns a workflow.ChildHandle. The handle only contains the domain, workflow ID and run ID of the child,
+so it can be returned from the parent or passed to an activity, which can later wait for the child result through a
+client created for the handle's domain.
+
+	handle, err := workflow.StartAbandonedChildWorkflow(childCtx, SimpleChildWorkflow, value)
+	if err != nil {
+		return err
+	}
+
+	// in an activity, or any other code outside of workflows
+	var result string
+	err := handle.GetResult(ctx, cadenceClient, &result)
+
 # Error Handling
 
 Activities and child workflows can fail. You could handle errors differently based on different error cases. If the
@@ -496,6 +511,14 @@ SideEffect function any other way than through its recorded return value.
 		....
 	}
 
+For random numbers, workflow.NewRand returns a *rand.Rand whose seed is recorded with SideEffect, so it produces the
+same sequence on replay without wrapping every call:
+
+	r := workflow.NewRand(ctx)
+	if r.Intn(100) < 50 {
+		....
+	}
+
 # Query API
 
 A workflow execution could be stuck at some state for longer than expected period. Cadence provide facilities to query
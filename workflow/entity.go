@@ -0,0 +1,65 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"go.uber.org/cadence/internal"
+)
+
+// EntityHeartbeatQueryType is the query type of the EntityHeartbeat of a workflow run by RunEntity.
+const EntityHeartbeatQueryType = internal.EntityHeartbeatQueryType
+
+type (
+	// EntityOptions configure RunEntity.
+	EntityOptions = internal.EntityOptions
+
+	// EntityHeartbeat is the liveness of a workflow run by RunEntity, returned by its EntityHeartbeatQueryType query.
+	EntityHeartbeat = internal.EntityHeartbeat
+)
+
+// RunEntity runs the workflow of an entity, i.e. a long-running workflow per key which handles the signals sent to
+// the key with client.EntityClient. It calls the handler of every signal received, answers the
+// EntityHeartbeatQueryType query which client.EntityClient.Heartbeat uses to verify the liveness of the entity, and
+// continues as new once the history grew too long:
+//
+//	func CartWorkflow(ctx workflow.Context, cart Cart) error {
+//		return workflow.RunEntity(ctx, workflow.EntityOptions{
+//			Handlers: map[string]func(ctx workflow.Context, c workflow.Channel) error{
+//				"add-item": func(ctx workflow.Context, c workflow.Channel) error {
+//					var item string
+//					c.Receive(ctx, &item)
+//					cart.Items = append(cart.Items, item)
+//					return nil
+//				},
+//			},
+//			IdleTimeout: 30 * 24 * time.Hour,
+//			ContinueAsNew: func(ctx workflow.Context) error {
+//				return workflow.NewContinueAsNewError(ctx, CartWorkflow, cart)
+//			},
+//		})
+//	}
+//
+// It returns nil after options.IdleTimeout, the error of a handler, the error of options.ContinueAsNew, or
+// *CanceledError if ctx is canceled. The signals which are buffered when the workflow completes or continues as new
+// are handled first.
+func RunEntity(ctx Context, options EntityOptions) error {
+	return internal.RunEntity(ctx, options)
+}
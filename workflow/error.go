@@ -97,12 +97,44 @@ type (
 	// PanicError contains information about panicked workflow/activity.
 	PanicError = internal.PanicError
 
+	// PanicCause classifies the value a workflow or an activity panicked with, see PanicError.Cause
+	PanicCause = internal.PanicCause
+
 	// ContinueAsNewError can be returned by a workflow implementation function and indicates that
 	// the workflow should continue as new with the same WorkflowID, but new RunID and new history.
 	ContinueAsNewError = internal.ContinueAsNewError
 
 	// UnknownExternalWorkflowExecutionError can be returned when external workflow doesn't exist
 	UnknownExternalWorkflowExecutionError = internal.UnknownExternalWorkflowExecutionError
+
+	// ExternalWorkflowAlreadyCompletedError can be returned when external workflow was already completed
+	ExternalWorkflowAlreadyCompletedError = internal.ExternalWorkflowAlreadyCompletedError
+
+	// SignalExternalWorkflowError is returned when signaling an external workflow failed for another cause
+	SignalExternalWorkflowError = internal.SignalExternalWorkflowError
+
+	// OptionsValidationError is returned by the Validate methods of the options structs and lists every invalid field
+	OptionsValidationError = internal.OptionsValidationError
+
+	// OptionsProblem describes a single invalid field of an options struct
+	OptionsProblem = internal.OptionsProblem
+
+	// IDCollisionError is returned when the explicit ID of an activity or child workflow is used by another pending
+	// activity or child workflow of the workflow
+	IDCollisionError = internal.IDCollisionError
+)
+
+const (
+	// PanicCauseUser means the code called panic with its own value.
+	PanicCauseUser = internal.PanicCauseUser
+	// PanicCauseNilMapWrite means the code assigned to an entry of a nil map.
+	PanicCauseNilMapWrite = internal.PanicCauseNilMapWrite
+	// PanicCauseIndexOutOfRange means the code indexed or sliced an array, a slice or a string out of its range.
+	PanicCauseIndexOutOfRange = internal.PanicCauseIndexOutOfRange
+	// PanicCauseNilPointerDereference means the code dereferenced a nil pointer.
+	PanicCauseNilPointerDereference = internal.PanicCauseNilPointerDereference
+	// PanicCauseRuntime means the go runtime panicked for another reason.
+	PanicCauseRuntime = internal.PanicCauseRuntime
 )
 
 // NewContinueAsNewError creates ContinueAsNewError instance
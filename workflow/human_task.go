@@ -0,0 +1,91 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"go.uber.org/cadence/internal"
+)
+
+type (
+	// HumanTask waits for a person to complete a task with a signal, see NewHumanTask.
+	HumanTask = internal.HumanTask
+
+	// HumanTaskOptions configure a HumanTask.
+	HumanTaskOptions = internal.HumanTaskOptions
+
+	// HumanTaskEscalation escalates a human task which is still pending some time after it was created.
+	HumanTaskEscalation = internal.HumanTaskEscalation
+
+	// HumanTaskReminder is the argument of the reminder and escalation activities of a human task.
+	HumanTaskReminder = internal.HumanTaskReminder
+
+	// HumanTaskStatus is the status of a human task, returned by its query.
+	HumanTaskStatus = internal.HumanTaskStatus
+)
+
+// The states of a human task in HumanTaskStatus.State.
+const (
+	HumanTaskPending   = internal.HumanTaskPending
+	HumanTaskCompleted = internal.HumanTaskCompleted
+	HumanTaskExpired   = internal.HumanTaskExpired
+	HumanTaskCanceled  = internal.HumanTaskCanceled
+)
+
+// ErrHumanTaskExpired is returned by HumanTask.Wait when HumanTaskOptions.Deadline passed before the task was completed.
+var ErrHumanTaskExpired = internal.ErrHumanTaskExpired
+
+// NewHumanTask returns a pending task for a person, who completes it by signaling the workflow with the result of the
+// task. While Wait is running, the Reminder activity is executed every ReminderInterval, and the Escalations happen
+// once their time after creating the task passed:
+//
+//	task, err := workflow.NewHumanTask(ctx, workflow.HumanTaskOptions{
+//		Name:             "approve-order",
+//		Assignee:         "alice",
+//		Deadline:         3 * 24 * time.Hour,
+//		ReminderInterval: 24 * time.Hour,
+//		Reminder:         SendReminderEmail,
+//		Escalations: []workflow.HumanTaskEscalation{
+//			{After: 2 * 24 * time.Hour, Activity: NotifyManager, Assignee: "bob"},
+//		},
+//		Validator: func(value interface{}) error {
+//			if value.(Approval).Approver == "" {
+//				return errors.New("approver is required")
+//			}
+//			return nil
+//		},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	var approval Approval
+//	if err := task.Wait(ctx, &approval); err != nil {
+//		return err // workflow.ErrHumanTaskExpired after 3 days
+//	}
+//
+// The status of the task can be queried with HumanTaskQueryType, e.g. to show it in a task inbox.
+func NewHumanTask(ctx Context, options HumanTaskOptions) (*HumanTask, error) {
+	return internal.NewHumanTask(ctx, options)
+}
+
+// HumanTaskQueryType returns the query type of the HumanTaskStatus of the human task with the given name.
+func HumanTaskQueryType(name string) string {
+	return internal.HumanTaskQueryType(name)
+}
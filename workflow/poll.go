@@ -0,0 +1,66 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"go.uber.org/cadence/internal"
+)
+
+type (
+	// PollOptions configure Poll.
+	PollOptions = internal.PollOptions
+
+	// PollState is the progress of a Poll, which is passed to the new run when it continues as new.
+	PollState = internal.PollState
+)
+
+// ErrPollExpired is returned by Poll when PollOptions.MaximumDuration passed before the probe reported completion.
+var ErrPollExpired = internal.ErrPollExpired
+
+// Poll calls probe until it reports done or returns an error, sleeping with a backoff between the calls. It is meant
+// for waiting on a condition in an external system, typically by executing an activity in the probe. To keep the
+// history of long polls bounded, set PollOptions.ContinueAsNew to continue the workflow as new with the PollState,
+// and pass it back as PollOptions.State in the new run:
+//
+//	func PollWorkflow(ctx workflow.Context, jobID string, state *workflow.PollState) (string, error) {
+//		var status string
+//		options := workflow.PollOptions{
+//			InitialInterval:    time.Minute,
+//			BackoffCoefficient: 1.5,
+//			MaximumInterval:    time.Hour,
+//			MaximumDuration:    7 * 24 * time.Hour,
+//			State:              state,
+//			ContinueAsNew: func(ctx workflow.Context, state workflow.PollState) error {
+//				return workflow.NewContinueAsNewError(ctx, PollWorkflow, jobID, &state)
+//			},
+//		}
+//		err := workflow.Poll(ctx, options, func(ctx workflow.Context) (bool, error) {
+//			err := workflow.ExecuteActivity(ctx, GetJobStatus, jobID).Get(ctx, &status)
+//			return status == "done", err
+//		})
+//		return status, err
+//	}
+//
+// Poll returns nil once probe reports done, the error of probe if it fails, ErrPollExpired once
+// PollOptions.MaximumDuration passed, or the error of PollOptions.ContinueAsNew when the history became too long.
+func Poll(ctx Context, options PollOptions, probe func(ctx Context) (bool, error)) error {
+	return internal.Poll(ctx, options, probe)
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"go.uber.org/cadence/internal"
+)
+
+// DefaultResponseSignalName is the default name of the signal delivering Response to a Requester.
+const DefaultResponseSignalName = internal.DefaultWorkflowResponseSignalName
+
+// ResponseListenerName is the workflow type ResponseListener must be registered with, for client.WorkflowRequestClient
+// to start it.
+const ResponseListenerName = internal.WorkflowResponseListenerName
+
+// ErrRequestTimeout is the error of the future returned by Requester.Request when no response was received within
+// RequesterOptions.Timeout.
+var ErrRequestTimeout = internal.ErrWorkflowRequestTimeout
+
+type (
+	// RequesterOptions configure a Requester.
+	RequesterOptions = internal.WorkflowRequesterOptions
+
+	// Requester sends requests to other workflows with signals, and correlates the responses they send back with
+	// signals.
+	Requester = internal.WorkflowRequester
+
+	// Request is the payload of the signal carrying a request from a Requester to another workflow, which responds
+	// with Respond.
+	Request = internal.WorkflowRequest
+
+	// Response is the payload of the signal carrying the response to a Request.
+	Response = internal.WorkflowResponse
+
+	// RequestError is the error of the future returned by Requester.Request when the other workflow responded with
+	// an error.
+	RequestError = internal.WorkflowRequestError
+)
+
+// NewRequester returns a requester for the signal-back pattern: it signals a Request with a correlation ID to another
+// workflow, which sends the Response back with a signal, and completes the future of the request with it:
+//
+//	requester := workflow.NewRequester(ctx, workflow.RequesterOptions{Timeout: time.Minute})
+//	var price int
+//	err := requester.Request(ctx, "pricing", "", "quote", item).Get(ctx, &price)
+//
+// The other workflow receives the Request from its signal channel, and responds with Respond:
+//
+//	var request workflow.Request
+//	workflow.GetSignalChannel(ctx, "quote").Receive(ctx, &request)
+//	var item string
+//	err := request.Get(ctx, &item)
+//	...
+//	err = workflow.Respond(ctx, request, price, nil).Get(ctx, nil)
+//
+// In tests, mock the other workflow with TestWorkflowEnvironment.OnWorkflowRequest, or send requests to the tested
+// workflow with TestWorkflowEnvironment.SendWorkflowRequest.
+func NewRequester(ctx Context, options RequesterOptions) *Requester {
+	return internal.NewWorkflowRequester(ctx, options)
+}
+
+// Respond sends the response to the request back to the workflow which sent it, with the result of the request, or
+// its error if err is not nil. It returns the future of sending the signal.
+func Respond(ctx Context, request Request, result interface{}, err error) Future {
+	return internal.RespondToWorkflowRequest(ctx, request, result, err)
+}
+
+// ResponseListener is the listener workflow started by client.WorkflowRequestClient for every request, which completes
+// with the response to the request. Workers on the task list of the client must register it with the name
+// ResponseListenerName.
+func ResponseListener(ctx Context) (Response, error) {
+	return internal.WorkflowResponseListener(ctx)
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"go.uber.org/cadence/internal"
+)
+
+// SignalBatchOptions configure ProcessSignalBatches.
+type SignalBatchOptions = internal.SignalBatchOptions
+
+// ProcessSignalBatches receives the signals named options.SignalName and calls handler with their payloads in batches,
+// which are delivered once they have MaxBatchSize signals, or Window after their first signal, or with Debounce,
+// Window after their last signal. It is the shape of workflows which aggregate events before processing them:
+//
+//	func AggregateWorkflow(ctx workflow.Context) error {
+//		options := workflow.SignalBatchOptions{
+//			SignalName:   "event",
+//			MaxBatchSize: 100,
+//			Window:       time.Minute,
+//			ContinueAsNew: func(ctx workflow.Context) error {
+//				return workflow.NewContinueAsNewError(ctx, AggregateWorkflow)
+//			},
+//		}
+//		return workflow.ProcessSignalBatches(ctx, options, func(ctx workflow.Context, events []Event) error {
+//			return workflow.ExecuteActivity(ctx, StoreEvents, events).Get(ctx, nil)
+//		})
+//	}
+//
+// Once the history has grown to MaximumHistoryLength events, the signals which were received so far are delivered,
+// and the error of ContinueAsNew is returned. ProcessSignalBatches also returns the error of handler, which stops
+// the processing, or *CanceledError if ctx is canceled.
+func ProcessSignalBatches[T any](ctx Context, options SignalBatchOptions, handler func(ctx Context, batch []T) error) error {
+	return internal.ProcessSignalBatches(ctx, options, handler)
+}
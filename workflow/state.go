@@ -0,0 +1,49 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"go.uber.org/cadence/internal"
+)
+
+// SetState stores value under key in the key/value state of the workflow. It lets workflows checkpoint parts of their
+// in-memory state and expose them without writing a query handler for each field:
+//
+//	err := workflow.SetState(ctx, "progress", progress)
+//
+// The value is recorded in the workflow history as a marker only when it differs from the previously stored one, and
+// the recorded value is used on replay. Outside of the workflow, the whole state can be read with the
+// client.QueryTypeWorkflowState query type, which returns a map of key to value.
+// Keep the values small: each change is written to the history.
+func SetState(ctx Context, key string, value interface{}) error {
+	return internal.SetState(ctx, key, value)
+}
+
+// GetState decodes the value stored under key by SetState into valuePtr. It returns false if the key was never set
+// in this run.
+func GetState(ctx Context, key string, valuePtr interface{}) (bool, error) {
+	return internal.GetState(ctx, key, valuePtr)
+}
+
+// GetStateKeys returns the sorted list of keys set by SetState in this run.
+func GetStateKeys(ctx Context) []string {
+	return internal.GetStateKeys(ctx)
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"go.uber.org/cadence/internal"
+)
+
+// TypedChannel is a Channel of values of type T, so that sending a value of another type fails to compile, and
+// Receive returns the value instead of filling a pointer:
+//
+//	orders := workflow.AsTypedChannel[Order](workflow.GetSignalChannel(ctx, "order"))
+//	order, _ := orders.Receive(ctx)
+//
+// Values which cannot be read as T, e.g. corrupt signals, are handled as by Channel.Receive.
+type TypedChannel[T any] interface {
+	internal.TypedChannel[T]
+}
+
+// NewTypedChannel creates a new TypedChannel.
+func NewTypedChannel[T any](ctx Context) TypedChannel[T] {
+	return internal.NewTypedChannel[T](ctx)
+}
+
+// NewBufferedTypedChannel creates a new buffered TypedChannel.
+func NewBufferedTypedChannel[T any](ctx Context, size int) TypedChannel[T] {
+	return internal.NewBufferedTypedChannel[T](ctx, size)
+}
+
+// AsTypedChannel returns a TypedChannel sending and receiving the values of the channel as T, e.g. of a signal channel.
+func AsTypedChannel[T any](channel Channel) TypedChannel[T] {
+	return internal.AsTypedChannel[T](channel)
+}
+
+// AddReceiveTyped adds a case to the selector receiving from a TypedChannel. f is invoked when a value can be received
+// from the channel, and must receive it:
+//
+//	workflow.AddReceiveTyped(selector, orders, func(c workflow.TypedChannel[Order], more bool) {
+//		order, _ := c.Receive(ctx)
+//		...
+//	})
+func AddReceiveTyped[T any](selector Selector, c TypedChannel[T], f func(c TypedChannel[T], more bool)) Selector {
+	return internal.AddReceiveTyped[T](selector, c, func(_ internal.TypedChannel[T], more bool) {
+		f(c, more)
+	})
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"go.uber.org/cadence/internal"
+)
+
+// TypedFuture is a Future whose value is of type T, so that Get returns the value instead of filling a pointer:
+//
+//	f := workflow.AsTypedFuture[string](workflow.ExecuteActivity(ctx, activityFn))
+//	result, err := f.Get(ctx)
+//
+// Use Future() to add it to a Selector.
+type TypedFuture[T any] interface {
+	internal.TypedFuture[T]
+}
+
+// TypedSettable sets the value or the error of a TypedFuture.
+type TypedSettable[T any] interface {
+	internal.TypedSettable[T]
+}
+
+// NewTypedFuture creates a new TypedFuture as well as the associated TypedSettable that is used to set its value.
+func NewTypedFuture[T any](ctx Context) (TypedFuture[T], TypedSettable[T]) {
+	return internal.NewTypedFuture[T](ctx)
+}
+
+// AsTypedFuture returns a TypedFuture reading the value of the future as T, e.g. the result of an activity or a child
+// workflow.
+func AsTypedFuture[T any](future Future) TypedFuture[T] {
+	return internal.AsTypedFuture[T](future)
+}
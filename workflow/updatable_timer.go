@@ -0,0 +1,50 @@
+// Copyright (c) 2017-2021 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workflow
+
+import (
+	"time"
+
+	"go.uber.org/cadence/internal"
+)
+
+// UpdatableTimer is a timer whose firing time can be changed while it is pending, e.g. to push back an inactivity
+// deadline every time a signal is received:
+//
+//	timer := workflow.NewUpdatableTimer(ctx, time.Hour)
+//	for !timer.IsReady() {
+//		s := workflow.NewSelector(ctx)
+//		s.AddReceive(activityCh, func(c workflow.Channel, more bool) {
+//			c.Receive(ctx, nil)
+//			timer.Reset(time.Hour)
+//		})
+//		s.AddFuture(timer.Future(), func(f workflow.Future) {})
+//		s.Select(ctx)
+//	}
+type UpdatableTimer = internal.UpdatableTimer
+
+// NewUpdatableTimer returns immediately a timer which fires after the duration d, unless its firing time is changed
+// with SetFireTime, Extend or Reset. Each change cancels the pending timer and starts a new one, so the history of the
+// workflow has a canceled timer for each change, and replaying the workflow makes the same changes.
+// Canceling ctx cancels the timer, Get then returns *CanceledError.
+func NewUpdatableTimer(ctx Context, d time.Duration) UpdatableTimer {
+	return internal.NewUpdatableTimer(ctx, d)
+}
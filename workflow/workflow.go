@@ -21,6 +21,8 @@
 package workflow
 
 import (
+	"time"
+
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 
@@ -33,6 +35,12 @@ type (
 	// ChildWorkflowFuture represents the result of a child workflow execution
 	ChildWorkflowFuture = internal.ChildWorkflowFuture
 
+	// ChildHandle identifies a child workflow started with StartAbandonedChildWorkflow.
+	ChildHandle = internal.ChildHandle
+
+	// SignalExternalWorkflowOptions configures a single SignalExternalWorkflowWithOptions call.
+	SignalExternalWorkflowOptions = internal.SignalExternalWorkflowOptions
+
 	// Type identifies a workflow type.
 	Type = internal.WorkflowType
 
@@ -58,9 +66,47 @@ type (
 	// Info information about currently executing workflow
 	Info = internal.WorkflowInfo
 
+	// QueryContext is a read-only view of the workflow that query handlers can declare as their first parameter.
+	// See SetQueryHandler.
+	QueryContext = internal.QueryContext
+
 	RegistryInfo = internal.RegistryWorkflowInfo
+
+	// UnhandledSignalOptions configures what happens to signals which are still pending when the workflow function
+	// returns. See SetUnhandledSignalOptions.
+	UnhandledSignalOptions = internal.UnhandledSignalOptions
+
+	// CorruptSignal is a signal which could not be decoded when it was received.
+	// See SetCorruptSignalHandler and GetCorruptSignalChannel.
+	CorruptSignal = internal.CorruptSignal
+
+	// ActivityProgress is the state of a scheduled or running activity of the workflow, see GetActivityProgress.
+	ActivityProgress = internal.ActivityProgress
+
+	// ChildWorkflowState is the state of a child workflow as reported by the Cadence service, see
+	// GetChildWorkflowState.
+	ChildWorkflowState = internal.ChildWorkflowState
+
+	// WorkflowConfig holds per-execution configuration values read from the memo and search attributes of the
+	// workflow and updated with client.UpdateWorkflowConfig, see NewWorkflowConfig.
+	WorkflowConfig = internal.WorkflowConfig
+
+	// WorkflowConfigOptions configure NewWorkflowConfig.
+	WorkflowConfigOptions = internal.WorkflowConfigOptions
 )
 
+// ErrActivityNotPending is returned by GetActivityProgress when the workflow has no scheduled or running activity
+// with the requested ID, e.g. because it already completed.
+var ErrActivityNotPending = internal.ErrActivityNotPending
+
+// CancelActivitySignalName is the conventional name of the signal asking a workflow to cancel one of its pending
+// activities, with the activity ID as signal input. See client.CancelActivitySignalName for the full recipe.
+const CancelActivitySignalName = internal.CancelActivitySignalName
+
+// UnhandledSignalsReason is the reason of the CustomError a workflow fails with when UnhandledSignalOptions.FailWorkflow
+// is set and signals are left unhandled. The error details are a map of signal name to the number of unhandled signals.
+const UnhandledSignalsReason = internal.UnhandledSignalsReason
+
 // Register - registers a workflow function with the framework.
 // A workflow takes a workflow context and input and returns a (result, error) or just error.
 // Examples:
@@ -180,6 +226,23 @@ func ExecuteLocalActivity(ctx Context, activity interface{}, args ...interface{}
 	return internal.ExecuteLocalActivity(ctx, activity, args...)
 }
 
+// GetActivityProgress returns the state and the latest heartbeat details of a scheduled or running activity of the
+// workflow, identified by the ActivityOptions.ActivityID it was started with. A workflow can call it periodically to
+// keep the progress reported by its query handlers up to date, or to cancel a stalled activity:
+//
+//	progress, err := workflow.GetActivityProgress(ctx, "import")
+//	var rowsImported int
+//	if err == nil && progress.HasHeartbeatDetails() {
+//		err = progress.HeartbeatDetails(&rowsImported)
+//	}
+//
+// Heartbeats are not part of the workflow history, so the progress is read from the Cadence service by a local
+// activity, whose result is recorded in the history. It blocks until the local activity completes and must not be
+// called from query handlers. It returns ErrActivityNotPending if the workflow has no such activity.
+func GetActivityProgress(ctx Context, activityID string) (*ActivityProgress, error) {
+	return internal.GetActivityProgress(ctx, activityID)
+}
+
 // ExecuteChildWorkflow requests child workflow execution in the context of a workflow.
 // Context can be used to pass the settings for the child workflow.
 // For example: task list that this child workflow should be routed, timeouts that need to be configured.
@@ -202,11 +265,42 @@ func ExecuteChildWorkflow(ctx Context, childWorkflow interface{}, args ...interf
 	return internal.ExecuteChildWorkflow(ctx, childWorkflow, args...)
 }
 
+// StartAbandonedChildWorkflow starts a child workflow with client.ParentClosePolicyAbandon and waits until it has
+// started. The returned ChildHandle can be passed to activities or other workflows to retrieve the child result with
+// ChildHandle.GetResult after the parent has closed.
+func StartAbandonedChildWorkflow(ctx Context, childWorkflow interface{}, args ...interface{}) (ChildHandle, error) {
+	return internal.StartAbandonedChildWorkflow(ctx, childWorkflow, args...)
+}
+
+// GetChildWorkflowState blocks until the child workflow is started and returns its current run and, when queryType is
+// not empty, the result of querying its latest run. Unlike the execution returned by GetChildWorkflowExecution, it
+// follows the child across continue-as-new:
+//
+//	child := workflow.ExecuteChildWorkflow(ctx, ChildWorkflow)
+//	...
+//	state, err := workflow.GetChildWorkflowState(ctx, child, "progress")
+//	var progress int
+//	if err == nil && state.HasQueryResult() {
+//		err = state.QueryResult(&progress)
+//	}
+//
+// The state is read from the Cadence service by a local activity, which records the result in the history of the
+// parent. It must not be called from query handlers.
+func GetChildWorkflowState(ctx Context, child ChildWorkflowFuture, queryType string, args ...interface{}) (*ChildWorkflowState, error) {
+	return internal.GetChildWorkflowState(ctx, child, queryType, args...)
+}
+
 // GetInfo extracts info of a current workflow from a context.
 func GetInfo(ctx Context) *Info {
 	return internal.GetWorkflowInfo(ctx)
 }
 
+// GetHeaders returns a copy of the headers the workflow was started with, see client.StartWorkflowOptions.Headers.
+// They are inherited from the parent by child workflows and from the previous run by continued-as-new runs.
+func GetHeaders(ctx Context) map[string]string {
+	return internal.GetWorkflowHeaders(ctx)
+}
+
 // GetLogger returns a logger to be used in workflow's context
 func GetLogger(ctx Context) *zap.Logger {
 	return internal.GetLogger(ctx)
@@ -217,6 +311,22 @@ func GetUnhandledSignalNames(ctx Context) []string {
 	return internal.GetUnhandledSignalNames(ctx)
 }
 
+// SetUnhandledSignalOptions configures how the workflow handles signals that are still pending when the workflow
+// function returns, instead of silently dropping them. For example, to forward them to another workflow:
+//
+//	workflow.SetUnhandledSignalOptions(ctx, workflow.UnhandledSignalOptions{
+//		DeadLetterHandler: func(ctx workflow.Context, signalName string, value encoded.Value) {
+//			var payload []byte
+//			_ = value.Get(&payload)
+//			_ = workflow.SignalExternalWorkflow(ctx, deadLetterWorkflowID, "", signalName, payload).Get(ctx, nil)
+//		},
+//	})
+//
+// Pending signals can also be inspected from outside of the workflow with the client.QueryTypeUnhandledSignals query.
+func SetUnhandledSignalOptions(ctx Context, options UnhandledSignalOptions) {
+	internal.SetUnhandledSignalOptions(ctx, options)
+}
+
 // GetMetricsScope returns a metrics scope to be used in workflow's context
 func GetMetricsScope(ctx Context) tally.Scope {
 	return internal.GetMetricsScope(ctx)
@@ -232,6 +342,13 @@ func GetHistoryCount(ctx Context) int64 {
 	return internal.GetHistoryCount(ctx)
 }
 
+// GetDecisionTimeRemaining returns the approximate time left before the current decision task times out, so that CPU
+// heavy workflow code can yield or move work to local activities in time. The result is not deterministic: record
+// the choices based on it, e.g. with SideEffect.
+func GetDecisionTimeRemaining(ctx Context) time.Duration {
+	return internal.GetDecisionTimeRemaining(ctx)
+}
+
 // RequestCancelExternalWorkflow can be used to request cancellation of an external workflow.
 // Input workflowID is the workflow ID of target workflow.
 // Input runID indicates the instance of a workflow. Input runID is optional (default is ""). When runID is not specified,
@@ -260,11 +377,36 @@ func SignalExternalWorkflow(ctx Context, workflowID, runID, signalName string, a
 	return internal.SignalExternalWorkflow(ctx, workflowID, runID, signalName, arg)
 }
 
+// SignalExternalWorkflowWithOptions is SignalExternalWorkflow with per call options, like the target domain and a
+// retry policy for transient failures. The returned Future fails with UnknownExternalWorkflowExecutionError,
+// ExternalWorkflowAlreadyCompletedError or SignalExternalWorkflowError when the signal could not be delivered.
+func SignalExternalWorkflowWithOptions(ctx Context, workflowID, runID, signalName string, arg interface{}, options SignalExternalWorkflowOptions) Future {
+	return internal.SignalExternalWorkflowWithOptions(ctx, workflowID, runID, signalName, arg, options)
+}
+
 // GetSignalChannel returns channel corresponding to the signal name.
 func GetSignalChannel(ctx Context, signalName string) Channel {
 	return internal.GetSignalChannel(ctx, signalName)
 }
 
+// SetCorruptSignalHandler registers a handler which is called with every signal that fails to be decoded when it is
+// received, before it is dropped from the signal channel. The handler runs synchronously inside Receive, so it must
+// not block. It replaces the handler set by previous calls, a nil handler removes it.
+func SetCorruptSignalHandler(ctx Context, handler func(signal CorruptSignal)) {
+	internal.SetCorruptSignalHandler(ctx, handler)
+}
+
+// GetCorruptSignalChannel returns a Channel which receives a CorruptSignal for every signal that fails to be decoded
+// when it is received, so it can be inspected or reprocessed by the workflow:
+//
+//	var corrupt workflow.CorruptSignal
+//	workflow.GetCorruptSignalChannel(ctx).Receive(ctx, &corrupt)
+//
+// Corrupt signals exceeding the buffer of the channel are dropped.
+func GetCorruptSignalChannel(ctx Context) Channel {
+	return internal.GetCorruptSignalChannel(ctx)
+}
+
 // SideEffect executes the provided callback, and records its result into the workflow history.
 // During replay the recorded result will be returned instead, so the callback can do non-deterministic
 // things (such as reading files or getting random numbers) without breaking determinism during replay.
@@ -432,6 +574,8 @@ func GetVersion(ctx Context, changeID string, minSupported, maxSupported Version
 // context to do things like workflow.NewChannel(), workflow.Go() or to call any workflow blocking functions like
 // Channel.Get() or Future.Get(). Trying to do so in query handler code will fail the query and client will receive
 // QueryFailedError.
+// The handler may declare a QueryContext as its first parameter to read the workflow info, the workflow time and
+// the state set by SetState, e.g. func(ctx workflow.QueryContext, field string) (string, error).
 // Example of workflow code that support query type "current_state":
 //
 //	func MyWorkflow(ctx workflow.Context, input string) error {
@@ -534,3 +678,38 @@ func GetLastCompletionResult(ctx Context, d ...interface{}) error {
 func UpsertSearchAttributes(ctx Context, attributes map[string]interface{}) error {
 	return internal.UpsertSearchAttributes(ctx, attributes)
 }
+
+// NewWorkflowConfig reads the config of the workflow from the memo it was started with (see
+// client.WorkflowConfigMemo) and from the search attributes listed in options, and keeps it up to date with the
+// values sent by client.UpdateWorkflowConfig. Updates are applied when the workflow receives the signal, so the
+// typed getters are deterministic:
+//
+//	config, err := workflow.NewWorkflowConfig(ctx, workflow.WorkflowConfigOptions{})
+//	if err != nil {
+//		return err
+//	}
+//	for {
+//		batch := fetchBatch(ctx, config.Int("batch-size", 100))
+//		...
+//	}
+//
+// It should be called once, at the start of the workflow.
+func NewWorkflowConfig(ctx Context, options WorkflowConfigOptions) (*WorkflowConfig, error) {
+	return internal.NewWorkflowConfig(ctx, options)
+}
+
+// IsFeatureEnabled returns whether flag is enabled for the workflow execution, as decided by the provider installed
+// with interceptors.NewFeatureFlagInterceptorFactory:
+//
+//	if workflow.IsFeatureEnabled(ctx, "parallel-shipping") {
+//		err = shipInParallel(ctx, order)
+//	} else {
+//		err = ship(ctx, order)
+//	}
+//
+// The provider is asked once per flag and execution and its decision is recorded in the workflow history, so it stays
+// the same for the rest of the execution and when the workflow is replayed. Flags are disabled when no provider is
+// installed.
+func IsFeatureEnabled(ctx Context, flag string) bool {
+	return internal.IsFeatureEnabled(ctx, flag)
+}
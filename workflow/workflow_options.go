@@ -57,6 +57,11 @@ func WithWorkflowID(ctx Context, workflowID string) Context {
 	return internal.WithWorkflowID(ctx, workflowID)
 }
 
+// WithParentClosePolicy adds a ParentClosePolicy to the context.
+func WithParentClosePolicy(ctx Context, policy internal.ParentClosePolicy) Context {
+	return internal.WithParentClosePolicy(ctx, policy)
+}
+
 // WithExecutionStartToCloseTimeout adds a workflow execution timeout to the context.
 func WithExecutionStartToCloseTimeout(ctx Context, d time.Duration) Context {
 	return internal.WithExecutionStartToCloseTimeout(ctx, d)